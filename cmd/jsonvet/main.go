@@ -0,0 +1,18 @@
+// Command jsonvet reports struct types that shape-json cannot encode or
+// decode as written. It runs on its own or as a go vet tool:
+//
+//	jsonvet ./...
+//	go vet -vettool=$(which jsonvet) ./...
+//
+// See package github.com/shapestone/shape-json/pkg/jsonvet for the checks.
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"github.com/shapestone/shape-json/pkg/jsonvet"
+)
+
+func main() {
+	singlechecker.Main(jsonvet.Analyzer)
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shapestone/shape-json/pkg/json"
+	"github.com/shapestone/shape-json/pkg/jsonpath"
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+var validateCommand = &command{
+	name:    "validate",
+	args:    "[file ...]",
+	summary: "Check that each input is well-formed JSON. Errors are reported per file.",
+	run: func(c *cli, fs *flag.FlagSet, args []string) int {
+		status := exitOK
+		for _, name := range inputArgs(args) {
+			data, err := c.read(name)
+			if err != nil {
+				status = c.fail("%v", err)
+				continue
+			}
+			if err := json.Validate(string(data)); err != nil {
+				fmt.Fprintf(c.stderr, "%s: %v\n", displayName(name), err)
+				status = exitFail
+			}
+		}
+		return status
+	},
+}
+
+var (
+	fmtIndent  string
+	fmtCompact bool
+	fmtSort    bool
+	fmtColor   string
+	fmtLenient bool
+)
+
+var fmtCommand = &command{
+	name:    "fmt",
+	args:    "[-indent str] [-compact] [-sort] [-color mode] [-lenient] [file]",
+	summary: "Pretty-print or compact a document. Without -sort, key order is preserved.\nWith -lenient, JSONC and JSON5 input is formatted keeping its comments.",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&fmtIndent, "indent", "  ", "indentation `string` for each nesting level")
+		fs.BoolVar(&fmtCompact, "compact", false, "remove all insignificant whitespace")
+		fs.BoolVar(&fmtSort, "sort", false, "sort object keys")
+		fs.StringVar(&fmtColor, "color", "auto", "colorize output: auto, always, or never")
+		fs.BoolVar(&fmtLenient, "lenient", false, "accept comments, trailing commas, single quotes, and unquoted keys, and keep comments")
+	},
+	run: func(c *cli, fs *flag.FlagSet, args []string) int {
+		if len(args) > 1 {
+			return c.usageError(fs, "too many arguments")
+		}
+		switch fmtColor {
+		case "auto":
+			c.color = json.ColorAuto
+		case "always":
+			c.color = json.ColorAlways
+		case "never":
+			c.color = json.ColorNever
+		default:
+			return c.usageError(fs, "invalid -color %q", fmtColor)
+		}
+		name := inputArgs(args)[0]
+		data, err := c.read(name)
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		if fmtLenient {
+			if fmtCompact {
+				return c.usageError(fs, "-lenient cannot be combined with -compact")
+			}
+			out, err := json.FormatSource(data, json.FormatOptions{Indent: fmtIndent, SortKeys: fmtSort, Lenient: true})
+			if err != nil {
+				return c.fail("%s: %v", displayName(name), err)
+			}
+			if _, err := c.stdout.Write(out); err != nil {
+				return c.fail("%v", err)
+			}
+			return exitOK
+		}
+
+		if fmtSort {
+			v, err := decode(data)
+			if err != nil {
+				return c.fail("%s: %v", displayName(name), err)
+			}
+			if data, err = json.Marshal(v); err != nil {
+				return c.fail("%v", err)
+			}
+		}
+
+		indent := fmtIndent
+		if fmtCompact {
+			indent = ""
+		}
+		if err := c.writeJSON(data, indent); err != nil {
+			return c.fail("%s: %v", displayName(name), err)
+		}
+		return exitOK
+	},
+}
+
+var pathCommand = &command{
+	name:    "path",
+	args:    "<expression> [file]",
+	summary: "Print every value matching a JSONPath expression, one compact value per line.\nExits with status 1 if nothing matched.",
+	run: func(c *cli, fs *flag.FlagSet, args []string) int {
+		if len(args) < 1 || len(args) > 2 {
+			return c.usageError(fs, "expected an expression and at most one file")
+		}
+		expr, err := jsonpath.ParseString(args[0])
+		if err != nil {
+			var syntax *jsonpath.SyntaxError
+			if errors.As(err, &syntax) {
+				// Point at the problem under the expression
+				snippet := strings.ReplaceAll(syntax.Snippet(), "\n", "\n\t")
+				return c.usageError(fs, "invalid expression: %v\n\t%s", err, snippet)
+			}
+			return c.usageError(fs, "invalid expression: %v", err)
+		}
+		name := inputArgs(args[1:])[0]
+		v, err := c.decodeFile(name)
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		matches := expr.Get(v)
+		for _, m := range matches {
+			if err := c.writeValue(m, ""); err != nil {
+				return c.fail("%v", err)
+			}
+		}
+		if len(matches) == 0 {
+			return exitFail
+		}
+		return exitOK
+	},
+}
+
+var patchIndent string
+
+var patchCommand = &command{
+	name:    "patch",
+	args:    "[-indent str] <patch-file> [file]",
+	summary: "Apply an RFC 6902 JSON Patch to a document and print the result.",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&patchIndent, "indent", "", "indent the output with `string` (default compact)")
+	},
+	run: func(c *cli, fs *flag.FlagSet, args []string) int {
+		if len(args) < 1 || len(args) > 2 {
+			return c.usageError(fs, "expected a patch file and at most one document")
+		}
+		if args[0] == "-" && len(args) == 1 {
+			return c.usageError(fs, "the patch and the document cannot both be read from standard input")
+		}
+		patch, err := c.read(args[0])
+		if err != nil {
+			return c.fail("%v", err)
+		}
+		name := inputArgs(args[1:])[0]
+		doc, err := c.read(name)
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		out, err := json.ApplyPatch(doc, patch)
+		if err != nil {
+			return c.fail("%v", err)
+		}
+		if err := c.writeJSON(out, patchIndent); err != nil {
+			return c.fail("%v", err)
+		}
+		return exitOK
+	},
+}
+
+var (
+	diffIndent    string
+	diffTolerance float64
+)
+
+var diffCommand = &command{
+	name:    "diff",
+	args:    "[-indent str] [-tolerance n] <from-file> <to-file>",
+	summary: "Print a JSON Patch that transforms the first document into the second.\nExits with status 1 if the documents differ.",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&diffIndent, "indent", "", "indent the output with `string` (default compact)")
+		fs.Float64Var(&diffTolerance, "tolerance", 0, "treat numbers that differ by at most `n` as equal")
+	},
+	run: func(c *cli, fs *flag.FlagSet, args []string) int {
+		if len(args) != 2 {
+			return c.usageError(fs, "expected two files")
+		}
+		a, err := c.decodeFile(args[0])
+		if err != nil {
+			return c.fail("%v", err)
+		}
+		b, err := c.decodeFile(args[1])
+		if err != nil {
+			return c.fail("%v", err)
+		}
+
+		patch := json.DiffWithOptions(a, b, json.CompareOptions{FloatTolerance: diffTolerance})
+		if err := c.writeValue(patch, diffIndent); err != nil {
+			return c.fail("%v", err)
+		}
+		if len(patch) > 0 {
+			return exitFail
+		}
+		return exitOK
+	},
+}
+
+var (
+	convertFrom   string
+	convertTo     string
+	convertIndent string
+	convertInfer  bool
+)
+
+var convertCommand = &command{
+	name:    "convert",
+	args:    "-to format [-from format] [-indent str] [-infer] [file]",
+	summary: "Convert a document between formats.\nInput formats: json, ndjson, csv, xml. Output formats: json, ndjson, yaml, csv, xml.",
+	flags: func(fs *flag.FlagSet) {
+		fs.StringVar(&convertFrom, "from", "json", "input `format`")
+		fs.StringVar(&convertTo, "to", "", "output `format` (required)")
+		fs.StringVar(&convertIndent, "indent", "", "indent json and xml output with `string`")
+		fs.BoolVar(&convertInfer, "infer", false, "convert csv cells that look like numbers, booleans, or null")
+	},
+	run: func(c *cli, fs *flag.FlagSet, args []string) int {
+		if convertTo == "" {
+			return c.usageError(fs, "-to is required")
+		}
+		if len(args) > 1 {
+			return c.usageError(fs, "too many arguments")
+		}
+		name := inputArgs(args)[0]
+		r, err := c.open(name)
+		if err != nil {
+			return c.fail("%v", err)
+		}
+		defer r.Close()
+
+		v, err := convertInput(r, convertFrom)
+		if err != nil {
+			return c.fail("%s: %v", displayName(name), err)
+		}
+		if err := c.convertOutput(v, convertTo); err != nil {
+			return c.fail("%v", err)
+		}
+		return exitOK
+	},
+}
+
+var schemaCommand = &command{
+	name:    "schema",
+	args:    "<schema-file> [file ...]",
+	summary: "Validate each document against a JSON Schema, reporting every violation.",
+	run: func(c *cli, fs *flag.FlagSet, args []string) int {
+		if len(args) < 1 {
+			return c.usageError(fs, "expected a schema file")
+		}
+		data, err := c.read(args[0])
+		if err != nil {
+			return c.fail("%v", err)
+		}
+		schema, err := jsonschema.Compile(data)
+		if err != nil {
+			return c.fail("%s: %v", displayName(args[0]), err)
+		}
+
+		status := exitOK
+		for _, name := range inputArgs(args[1:]) {
+			data, err := c.read(name)
+			if err != nil {
+				status = c.fail("%v", err)
+				continue
+			}
+			err = schema.ValidateBytes(data)
+			var verrs jsonschema.ValidationErrors
+			switch {
+			case err == nil:
+				continue
+			case errors.As(err, &verrs):
+				for _, e := range verrs {
+					fmt.Fprintf(c.stderr, "%s: %v\n", displayName(name), e)
+				}
+			default:
+				fmt.Fprintf(c.stderr, "%s: %v\n", displayName(name), err)
+			}
+			status = exitFail
+		}
+		return status
+	},
+}
+
+// decode unmarshals a JSON document into native values.
+func decode(data []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// decodeFile reads and decodes the named input.
+func (c *cli) decodeFile(name string) (interface{}, error) {
+	data, err := c.read(name)
+	if err != nil {
+		return nil, err
+	}
+	v, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", displayName(name), err)
+	}
+	return v, nil
+}
+
+// writeJSON writes a JSON document to stdout, compact when indent is empty,
+// followed by a newline. Key order is preserved.
+func (c *cli) writeJSON(data []byte, indent string) error {
+	return json.PrettyPrint(c.stdout, data, json.StyleOptions{
+		Indent:  indent,
+		Compact: indent == "",
+		Color:   c.color,
+	})
+}
+
+// writeValue marshals v and writes it with writeJSON.
+func (c *cli) writeValue(v interface{}, indent string) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.writeJSON(data, indent)
+}
+
+// convertInput reads r in the given format into native values.
+func convertInput(r io.Reader, format string) (interface{}, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return decode(data)
+	case "ndjson", "jsonl":
+		var values []interface{}
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for line := 1; sc.Scan(); line++ {
+			if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+				continue
+			}
+			v, err := decode(sc.Bytes())
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			values = append(values, v)
+		}
+		if values == nil {
+			values = []interface{}{}
+		}
+		return values, sc.Err()
+	case "csv":
+		arr, err := json.FromCSV(r, json.CSVOptions{InferTypes: convertInfer})
+		if err != nil {
+			return nil, err
+		}
+		return arr.ToSlice(), nil
+	case "xml":
+		node, err := json.XMLToNode(r, json.XMLOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return json.NodeToInterface(node), nil
+	case "yaml", "yml":
+		return nil, errors.New("yaml is supported as an output format only")
+	}
+	return nil, fmt.Errorf("unknown input format %q", format)
+}
+
+// convertOutput writes v to stdout in the given format.
+func (c *cli) convertOutput(v interface{}, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		return c.writeValue(v, convertIndent)
+	case "ndjson", "jsonl":
+		items, ok := v.([]interface{})
+		if !ok {
+			return c.writeValue(v, "")
+		}
+		for _, item := range items {
+			if err := c.writeValue(item, ""); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "yaml", "yml":
+		w := bufio.NewWriter(c.stdout)
+		if err := writeYAML(w, v); err != nil {
+			return err
+		}
+		return w.Flush()
+	case "csv":
+		items, ok := v.([]interface{})
+		if !ok {
+			return errors.New("csv output requires an array of objects")
+		}
+		arr := json.NewArray()
+		for _, item := range items {
+			arr.Add(item)
+		}
+		return json.ToCSV(c.stdout, arr, json.CSVOptions{})
+	case "xml":
+		node, err := json.InterfaceToNode(v)
+		if err != nil {
+			return err
+		}
+		if err := json.NodeToXML(c.stdout, node, json.XMLOptions{Indent: convertIndent}); err != nil {
+			return err
+		}
+		_, err = io.WriteString(c.stdout, "\n")
+		return err
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
@@ -0,0 +1,181 @@
+// Command shapejson exposes the shape-json library on the command line, for
+// use in shell pipelines and CI jobs.
+//
+// Usage:
+//
+//	shapejson <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	validate   check that inputs are well-formed JSON
+//	fmt        pretty-print, compact, or key-sort a document
+//	path       query a document with a JSONPath expression
+//	patch      apply an RFC 6902 JSON Patch to a document
+//	diff       print the JSON Patch that turns one document into another
+//	convert    convert between JSON, NDJSON, YAML, CSV, and XML
+//	schema     validate documents against a JSON Schema
+//
+// Wherever a file argument is accepted, "-" or an omitted argument reads
+// standard input. Results are written to standard output and diagnostics to
+// standard error.
+//
+// The exit status is 0 on success, 1 when an input is invalid (or, for diff
+// and path, when the documents differ or nothing matched), and 2 for usage
+// errors.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/shapestone/shape-json/pkg/json"
+)
+
+// Exit statuses.
+const (
+	exitOK    = 0
+	exitFail  = 1
+	exitUsage = 2
+)
+
+// command is one shapejson subcommand.
+type command struct {
+	name    string
+	args    string // argument synopsis for usage messages
+	summary string
+	run     func(c *cli, fs *flag.FlagSet, args []string) int
+	flags   func(fs *flag.FlagSet) // optional flag registration
+}
+
+// commands lists the subcommands in the order they are documented.
+var commands = []*command{
+	validateCommand,
+	fmtCommand,
+	pathCommand,
+	patchCommand,
+	diffCommand,
+	convertCommand,
+	schemaCommand,
+}
+
+// cli holds the standard streams, so that run can be tested in-process.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	color  json.ColorMode // for JSON written to stdout
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line args and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+
+	if len(args) == 0 {
+		c.usage()
+		return exitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		c.usage()
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		fs.Usage = func() {
+			fmt.Fprintf(stderr, "usage: shapejson %s %s\n\n%s\n", cmd.name, cmd.args, cmd.summary)
+			if hasFlags(fs) {
+				fmt.Fprintln(stderr, "\nflags:")
+				fs.PrintDefaults()
+			}
+		}
+		if cmd.flags != nil {
+			cmd.flags(fs)
+		}
+		if err := fs.Parse(args[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return exitOK
+			}
+			return exitUsage
+		}
+		return cmd.run(c, fs, fs.Args())
+	}
+
+	fmt.Fprintf(stderr, "shapejson: unknown command %q\n\n", args[0])
+	c.usage()
+	return exitUsage
+}
+
+// usage prints the list of commands.
+func (c *cli) usage() {
+	fmt.Fprint(c.stderr, "usage: shapejson <command> [flags] [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-9s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprint(c.stderr, "\nRun 'shapejson <command> -h' for details.\n")
+}
+
+// fail reports an error on stderr and returns exitFail.
+func (c *cli) fail(format string, args ...interface{}) int {
+	fmt.Fprintf(c.stderr, "shapejson: "+format+"\n", args...)
+	return exitFail
+}
+
+// usageError reports a usage problem and returns exitUsage.
+func (c *cli) usageError(fs *flag.FlagSet, format string, args ...interface{}) int {
+	fmt.Fprintf(c.stderr, "shapejson %s: "+format+"\n", append([]interface{}{fs.Name()}, args...)...)
+	fs.Usage()
+	return exitUsage
+}
+
+// open returns a reader for the named input; "-" is standard input.
+func (c *cli) open(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(c.stdin), nil
+	}
+	return os.Open(name)
+}
+
+// read reads the named input in full.
+func (c *cli) read(name string) ([]byte, error) {
+	r, err := c.open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// displayName returns the name used for an input in messages.
+func displayName(name string) string {
+	if name == "-" {
+		return "<stdin>"
+	}
+	return name
+}
+
+// inputArgs returns the file arguments, defaulting to standard input.
+func inputArgs(args []string) []string {
+	if len(args) == 0 {
+		return []string{"-"}
+	}
+	return args
+}
+
+// hasFlags reports whether fs defines any flags.
+func hasFlags(fs *flag.FlagSet) bool {
+	has := false
+	fs.VisitAll(func(*flag.Flag) { has = true })
+	return has
+}
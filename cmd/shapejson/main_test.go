@@ -0,0 +1,322 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCLI runs the command line with the given stdin and returns the exit
+// status and captured output.
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// writeFile creates a file in a temporary directory and returns its path.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRun_Usage(t *testing.T) {
+	code, _, stderr := runCLI(t, "")
+	if code != exitUsage || !strings.Contains(stderr, "commands:") {
+		t.Errorf("no args: code = %d, stderr = %q", code, stderr)
+	}
+
+	code, _, stderr = runCLI(t, "", "frobnicate")
+	if code != exitUsage || !strings.Contains(stderr, `unknown command "frobnicate"`) {
+		t.Errorf("unknown command: code = %d, stderr = %q", code, stderr)
+	}
+
+	code, _, _ = runCLI(t, "", "help")
+	if code != exitOK {
+		t.Errorf("help: code = %d", code)
+	}
+
+	code, _, stderr = runCLI(t, "", "fmt", "-h")
+	if code != exitOK || !strings.Contains(stderr, "usage: shapejson fmt") {
+		t.Errorf("fmt -h: code = %d, stderr = %q", code, stderr)
+	}
+
+	code, _, _ = runCLI(t, "", "fmt", "-bogus")
+	if code != exitUsage {
+		t.Errorf("bad flag: code = %d", code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	good := writeFile(t, "good.json", `{"a": [1, 2]}`)
+	bad := writeFile(t, "bad.json", `{"a": [1, 2}`)
+
+	if code, _, stderr := runCLI(t, "", "validate", good); code != exitOK {
+		t.Errorf("valid file: code = %d, stderr = %q", code, stderr)
+	}
+
+	code, _, stderr := runCLI(t, "", "validate", good, bad)
+	if code != exitFail || !strings.HasPrefix(stderr, bad+": ") || strings.Contains(stderr, good) {
+		t.Errorf("invalid file: code = %d, stderr = %q", code, stderr)
+	}
+
+	code, _, stderr = runCLI(t, "nope", "validate")
+	if code != exitFail || !strings.HasPrefix(stderr, "<stdin>: ") {
+		t.Errorf("stdin: code = %d, stderr = %q", code, stderr)
+	}
+
+	code, _, stderr = runCLI(t, "", "validate", filepath.Join(t.TempDir(), "missing.json"))
+	if code != exitFail || !strings.Contains(stderr, "missing.json") {
+		t.Errorf("missing file: code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestFmt(t *testing.T) {
+	input := `{"b": 1, "a": [true, null]}`
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"default indent", nil, "{\n  \"b\": 1,\n  \"a\": [\n    true,\n    null\n  ]\n}\n"},
+		{"custom indent", []string{"-indent", "\t"}, "{\n\t\"b\": 1,\n\t\"a\": [\n\t\ttrue,\n\t\tnull\n\t]\n}\n"},
+		{"compact", []string{"-compact"}, `{"b":1,"a":[true,null]}` + "\n"},
+		{"sorted", []string{"-sort", "-compact"}, `{"a":[true,null],"b":1}` + "\n"},
+	}
+	if code, stdout, _ := runCLI(t, `{"a":null}`, "fmt", "-compact", "-color", "always"); code != exitOK || stdout != "{\x1b[34;1m\"a\"\x1b[0m:\x1b[90mnull\x1b[0m}\n" {
+		t.Errorf("color: code = %d, stdout = %q", code, stdout)
+	}
+	if code, _, _ := runCLI(t, `{}`, "fmt", "-color", "rainbow"); code != exitUsage {
+		t.Errorf("bad -color: code = %d", code)
+	}
+	// Number text and empty containers pass through unchanged
+	if code, stdout, _ := runCLI(t, `[1.50, 1e3, {}, []]`, "fmt"); code != exitOK || stdout != "[\n  1.50,\n  1e3,\n  {},\n  []\n]\n" {
+		t.Errorf("literals: code = %d, stdout = %q", code, stdout)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, input, append([]string{"fmt"}, tt.args...)...)
+			if code != exitOK {
+				t.Fatalf("code = %d, stderr = %q", code, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("output = %q, want %q", stdout, tt.want)
+			}
+		})
+	}
+
+	if code, _, _ := runCLI(t, `{`, "fmt"); code != exitFail {
+		t.Errorf("malformed input: code = %d", code)
+	}
+
+	code, stdout, stderr := runCLI(t, "{b: 1, // one\n'a': 2,}", "fmt", "-lenient", "-sort")
+	if want := "{\n  'a': 2,\n  b: 1 // one\n}\n"; code != exitOK || stdout != want {
+		t.Errorf("lenient: code = %d, stdout = %q, stderr = %q", code, stdout, stderr)
+	}
+	if code, _, _ := runCLI(t, `{}`, "fmt", "-lenient", "-compact"); code != exitUsage {
+		t.Errorf("-lenient -compact: code = %d", code)
+	}
+}
+
+func TestPath(t *testing.T) {
+	input := `{"store": {"book": [{"title": "A", "price": 8}, {"title": "B", "price": 22}]}}`
+
+	code, stdout, stderr := runCLI(t, input, "path", "$.store.book[*].title")
+	if code != exitOK || stdout != "\"A\"\n\"B\"\n" {
+		t.Errorf("code = %d, stdout = %q, stderr = %q", code, stdout, stderr)
+	}
+
+	code, stdout, _ = runCLI(t, input, "path", "$.store.book[?(@.price > 10)]")
+	if code != exitOK || stdout != `{"price":22,"title":"B"}`+"\n" {
+		t.Errorf("filter: code = %d, stdout = %q", code, stdout)
+	}
+
+	if code, stdout, _ = runCLI(t, input, "path", "$.missing"); code != exitFail || stdout != "" {
+		t.Errorf("no match: code = %d, stdout = %q", code, stdout)
+	}
+	code, _, stderr = runCLI(t, input, "path", "$.[0]")
+	if code != exitUsage || !strings.Contains(stderr, "did you mean $[0]?\n\t$.[0]\n\t  ^\n") {
+		t.Errorf("invalid expression: code = %d, stderr = %q", code, stderr)
+	}
+	if code, _, _ = runCLI(t, input, "path"); code != exitUsage {
+		t.Errorf("missing expression: code = %d", code)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	patch := writeFile(t, "patch.json", `[
+		{"op": "replace", "path": "/name", "value": "new"},
+		{"op": "add", "path": "/tags/-", "value": "x"}
+	]`)
+
+	code, stdout, stderr := runCLI(t, `{"name": "old", "tags": []}`, "patch", patch)
+	if code != exitOK || stdout != `{"name":"new","tags":["x"]}`+"\n" {
+		t.Errorf("code = %d, stdout = %q, stderr = %q", code, stdout, stderr)
+	}
+
+	code, _, stderr = runCLI(t, `{"tags": []}`, "patch", patch)
+	if code != exitFail || stderr == "" {
+		t.Errorf("failing patch: code = %d, stderr = %q", code, stderr)
+	}
+
+	if code, _, _ = runCLI(t, `[]`, "patch", "-"); code != exitUsage {
+		t.Errorf("both from stdin: code = %d", code)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := writeFile(t, "a.json", `{"a": 1, "b": [1, 2]}`)
+	b := writeFile(t, "b.json", `{"a": 2, "b": [1, 2], "c": true}`)
+
+	code, stdout, stderr := runCLI(t, "", "diff", a, b)
+	want := `[{"op":"replace","path":"\/a","value":2},{"op":"add","path":"\/c","value":true}]` + "\n"
+	if code != exitFail || stdout != want {
+		t.Errorf("code = %d, stdout = %q, stderr = %q", code, stdout, stderr)
+	}
+
+	code, stdout, _ = runCLI(t, "", "diff", a, a)
+	if code != exitOK || stdout != "[]\n" {
+		t.Errorf("equal documents: code = %d, stdout = %q", code, stdout)
+	}
+
+	c := writeFile(t, "c.json", `{"a": 1.0000001, "b": [1, 2]}`)
+	code, stdout, _ = runCLI(t, "", "diff", "-tolerance", "1e-6", a, c)
+	if code != exitOK || stdout != "[]\n" {
+		t.Errorf("within tolerance: code = %d, stdout = %q", code, stdout)
+	}
+
+	if code, _, _ = runCLI(t, "", "diff", a); code != exitUsage {
+		t.Errorf("one file: code = %d", code)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		want  string
+	}{
+		{
+			"json to ndjson",
+			[]string{"-to", "ndjson"},
+			`[{"a":1},{"a":2}]`,
+			"{\"a\":1}\n{\"a\":2}\n",
+		},
+		{
+			"ndjson to json",
+			[]string{"-from", "ndjson", "-to", "json"},
+			"{\"a\":1}\n\n{\"a\":2}\n",
+			`[{"a":1},{"a":2}]` + "\n",
+		},
+		{
+			"json to yaml",
+			[]string{"-to", "yaml"},
+			`{"name":"svc","ports":[80,443],"tls":{"enabled":true,"cert":null},"env":{},"note":"yes","list":[{"k":"v","w":1}]}`,
+			"env: {}\nlist:\n  - k: v\n    w: 1\nname: svc\nnote: \"yes\"\nports:\n  - 80\n  - 443\ntls:\n  cert: null\n  enabled: true\n",
+		},
+		{
+			"scalar to yaml",
+			[]string{"-to", "yaml"},
+			`"12"`,
+			"\"12\"\n",
+		},
+		{
+			"json to csv",
+			[]string{"-to", "csv"},
+			`[{"id":1,"user":{"name":"a"}},{"id":2,"user":{"name":"b"}}]`,
+			"id,user.name\n1,a\n2,b\n",
+		},
+		{
+			"csv to json",
+			[]string{"-from", "csv", "-to", "json", "-infer"},
+			"id,name\n1,a\n",
+			`[{"id":1,"name":"a"}]` + "\n",
+		},
+		{
+			"xml to json",
+			[]string{"-from", "xml", "-to", "json"},
+			`<order id="7"><item>a</item></order>`,
+			`{"order":{"@id":"7","item":"a"}}` + "\n",
+		},
+		{
+			"json to xml",
+			[]string{"-to", "xml"},
+			`{"order":{"@id":7,"item":["a","b"]}}`,
+			`<order id="7"><item>a</item><item>b</item></order>` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := runCLI(t, tt.input, append([]string{"convert"}, tt.args...)...)
+			if code != exitOK {
+				t.Fatalf("code = %d, stderr = %q", code, stderr)
+			}
+			if stdout != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", stdout, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		input string
+		code  int
+	}{
+		{"missing -to", nil, `{}`, exitUsage},
+		{"unknown output", []string{"-to", "toml"}, `{}`, exitFail},
+		{"unknown input", []string{"-from", "toml", "-to", "json"}, `{}`, exitFail},
+		{"yaml input", []string{"-from", "yaml", "-to", "json"}, `a: 1`, exitFail},
+		{"csv needs array", []string{"-to", "csv"}, `{}`, exitFail},
+		{"bad ndjson line", []string{"-from", "ndjson", "-to", "json"}, "{}\n{", exitFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runCLI(t, tt.input, append([]string{"convert"}, tt.args...)...)
+			if code != tt.code || stderr == "" {
+				t.Errorf("code = %d, want %d; stderr = %q", code, tt.code, stderr)
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema := writeFile(t, "schema.json", `{
+		"type": "object",
+		"required": ["id"],
+		"properties": {"id": {"type": "integer"}, "tags": {"items": {"type": "string"}}}
+	}`)
+	good := writeFile(t, "good.json", `{"id": 1, "tags": ["a"]}`)
+	bad := writeFile(t, "bad.json", `{"tags": ["a", 2]}`)
+
+	if code, _, stderr := runCLI(t, "", "schema", schema, good); code != exitOK {
+		t.Errorf("valid document: code = %d, stderr = %q", code, stderr)
+	}
+
+	code, _, stderr := runCLI(t, "", "schema", schema, good, bad)
+	want := bad + `: (root): required: missing property "id"` + "\n" +
+		bad + ": /tags/1: type: expected string, got integer\n"
+	if code != exitFail || stderr != want {
+		t.Errorf("code = %d, stderr =\n%s\nwant\n%s", code, stderr, want)
+	}
+
+	invalid := writeFile(t, "invalid.json", `{"type": "text"}`)
+	code, _, stderr = runCLI(t, "{}", "schema", invalid)
+	if code != exitFail || !strings.Contains(stderr, "invalid schema") {
+		t.Errorf("invalid schema: code = %d, stderr = %q", code, stderr)
+	}
+
+	if code, _, _ = runCLI(t, "", "schema"); code != exitUsage {
+		t.Errorf("missing schema: code = %d", code)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-json/pkg/json"
+)
+
+// writeYAML writes v as a block-style YAML document. Object keys are sorted.
+//
+// Strings are written plain when that cannot change their meaning and
+// double-quoted otherwise, so the output round-trips through any YAML 1.2
+// parser.
+func writeYAML(w *bufio.Writer, v interface{}) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) > 0 {
+			return writeYAMLMap(w, val, 0)
+		}
+	case []interface{}:
+		if len(val) > 0 {
+			return writeYAMLSeq(w, val, 0)
+		}
+	}
+	s, err := yamlScalar(v)
+	if err != nil {
+		return err
+	}
+	w.WriteString(s)
+	return w.WriteByte('\n')
+}
+
+func writeYAMLMap(w *bufio.Writer, m map[string]interface{}, depth int) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		w.WriteString(strings.Repeat("  ", depth))
+		w.WriteString(yamlString(k))
+		w.WriteByte(':')
+		if err := writeYAMLChild(w, m[k], depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeYAMLSeq(w *bufio.Writer, items []interface{}, depth int) error {
+	for _, item := range items {
+		w.WriteString(strings.Repeat("  ", depth))
+		w.WriteByte('-')
+
+		// Nested mappings start on the same line as the dash
+		if m, ok := item.(map[string]interface{}); ok && len(m) > 0 {
+			var sub strings.Builder
+			bw := bufio.NewWriter(&sub)
+			if err := writeYAMLMap(bw, m, depth+1); err != nil {
+				return err
+			}
+			bw.Flush()
+			w.WriteByte(' ')
+			w.WriteString(strings.TrimLeft(sub.String(), " "))
+			continue
+		}
+		if err := writeYAMLChild(w, item, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeYAMLChild writes a value following "key:" or "-".
+func writeYAMLChild(w *bufio.Writer, v interface{}, depth int) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(val) > 0 {
+			w.WriteByte('\n')
+			return writeYAMLMap(w, val, depth)
+		}
+	case []interface{}:
+		if len(val) > 0 {
+			w.WriteByte('\n')
+			return writeYAMLSeq(w, val, depth)
+		}
+	}
+	s, err := yamlScalar(v)
+	if err != nil {
+		return err
+	}
+	w.WriteByte(' ')
+	w.WriteString(s)
+	return w.WriteByte('\n')
+}
+
+// yamlScalar renders a scalar or empty container.
+func yamlScalar(v interface{}) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return "null", nil
+	case string:
+		return yamlString(val), nil
+	case map[string]interface{}:
+		return "{}", nil
+	case []interface{}:
+		return "[]", nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// yamlPlain matches strings that YAML reads back as the same string when
+// written without quotes.
+var yamlPlain = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_./ -]*$`)
+
+// yamlString renders a string, quoting it when a plain scalar would be
+// misread.
+func yamlString(s string) string {
+	if yamlPlain.MatchString(s) && !strings.HasSuffix(s, " ") && !strings.Contains(s, " -") {
+		switch strings.ToLower(s) {
+		case "true", "false", "null", "yes", "no", "on", "off", "y", "n":
+		default:
+			return s
+		}
+	}
+	return strconv.Quote(s)
+}
@@ -16,7 +16,7 @@
 //
 // Examples covered:
 //  1. Simple Object - Parse and access object properties
-//  2. Nested Structures - Navigate nested objects and arrays
+//  2. Nested Structures - Navigate nested objects and arrays with astutil
 //  3. All Value Types - Work with strings, numbers, booleans, null, arrays, objects
 //  4. Format Identification - Detect JSON format
 //
@@ -30,6 +30,7 @@ import (
 	"log"
 
 	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-json/pkg/astutil"
 	"github.com/shapestone/shape-json/pkg/json"
 )
 
@@ -80,27 +81,17 @@ func main() {
 		log.Fatalf("Parse error: %v", err)
 	}
 
-	obj = node.(*ast.ObjectNode)
-
-	// Access nested object
-	if userNode, ok := obj.GetProperty("user"); ok {
-		userObj := userNode.(*ast.ObjectNode)
-		if emailNode, ok := userObj.GetProperty("email"); ok {
-			email := emailNode.(*ast.LiteralNode).Value().(string)
-			fmt.Printf("User email: %s\n", email)
-		}
+	// The astutil helpers follow a path of member names and array indexes,
+	// replacing a type assertion at every step
+	if email, ok := astutil.GetString(node, "user", "email"); ok {
+		fmt.Printf("User email: %s\n", email)
 	}
 
-	// Access array
-	if tagsNode, ok := obj.GetProperty("tags"); ok {
-		tagsObj := tagsNode.(*ast.ObjectNode)
-		fmt.Printf("Number of tags: %d\n", len(tagsObj.Properties()))
-
-		// Arrays are represented as objects with string keys "0", "1", etc.
-		if tag0, ok := tagsObj.GetProperty("0"); ok {
-			tag := tag0.(*ast.LiteralNode).Value().(string)
-			fmt.Printf("First tag: %s\n", tag)
-		}
+	if tags, ok := astutil.GetArray(node, "tags"); ok {
+		fmt.Printf("Number of tags: %d\n", tags.Len())
+	}
+	if tag, ok := astutil.GetString(node, "tags", 0); ok {
+		fmt.Printf("First tag: %s\n", tag)
 	}
 
 	// Example 3: All JSON value types
@@ -122,7 +113,7 @@ func main() {
 
 	obj = node.(*ast.ObjectNode)
 	for key, valueNode := range obj.Properties() {
-		fmt.Printf("%s: %s (type: %s)\n", key, valueNode.String(), valueNode.Type())
+		fmt.Printf("%s: %s (kind: %s)\n", key, valueNode.String(), astutil.KindOf(valueNode))
 	}
 
 	// Example 4: Format identification
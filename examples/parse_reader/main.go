@@ -94,11 +94,11 @@ func main() {
 
 	// Access nested data
 	if usersNode, ok := obj.GetProperty("users"); ok {
-		usersArray := usersNode.(*ast.ObjectNode)
-		fmt.Printf("Number of users: %d\n", len(usersArray.Properties()))
+		usersArray := usersNode.(*ast.ArrayDataNode)
+		fmt.Printf("Number of users: %d\n", usersArray.Len())
 
 		// Get first user
-		if user0, ok := usersArray.GetProperty("0"); ok {
+		if user0 := usersArray.Get(0); user0 != nil {
 			userObj := user0.(*ast.ObjectNode)
 			if nameNode, ok := userObj.GetProperty("name"); ok {
 				name := nameNode.(*ast.LiteralNode).Value().(string)
@@ -0,0 +1,49 @@
+//go:build js && wasm
+
+// Command wasm_validate exposes JSON validation to JavaScript, for checking
+// documents in the browser before they are sent.
+//
+// It registers two functions on the global object:
+//
+//	shapeJSONValidate(text)  // null if text is valid JSON, else the error message
+//	shapeJSONDiagnose(text)  // an array of {line, character, message} problems
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/shapestone/shape-json/pkg/json"
+)
+
+func main() {
+	js.Global().Set("shapeJSONValidate", js.FuncOf(validate))
+	js.Global().Set("shapeJSONDiagnose", js.FuncOf(diagnose))
+	select {} // keep the functions alive
+}
+
+// validate implements shapeJSONValidate.
+func validate(_ js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		return "shapeJSONValidate takes one argument"
+	}
+	if err := json.Validate(args[0].String()); err != nil {
+		return err.Error()
+	}
+	return nil
+}
+
+// diagnose implements shapeJSONDiagnose.
+func diagnose(_ js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		return "shapeJSONDiagnose takes one argument"
+	}
+	var problems []interface{}
+	for _, d := range json.Diagnose([]byte(args[0].String())) {
+		problems = append(problems, map[string]interface{}{
+			"line":      d.Range.Start.Line,
+			"character": d.Range.Start.Character,
+			"message":   d.Message,
+		})
+	}
+	return problems
+}
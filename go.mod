@@ -2,6 +2,13 @@ module github.com/shapestone/shape-json
 
 go 1.23
 
-require github.com/shapestone/shape-core v0.9.3
+require (
+	github.com/shapestone/shape-core v0.9.3
+	golang.org/x/tools v0.30.0
+)
 
-require github.com/google/uuid v1.6.0 // indirect
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	golang.org/x/mod v0.23.0 // indirect
+	golang.org/x/sync v0.11.0 // indirect
+)
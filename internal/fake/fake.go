@@ -0,0 +1,209 @@
+// Package fake makes up realistic-looking values for generated JSON
+// documents: names, words, e-mail addresses, timestamps, and strings that
+// match a JSON Schema format or regular expression. It serves the
+// reflection-based json.Generate and the schema-based Schema.Generate of
+// package jsonschema alike.
+package fake
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	words = []string{
+		"amber", "anchor", "atlas", "beacon", "birch", "cedar", "comet", "coral",
+		"delta", "ember", "falcon", "fern", "harbor", "indigo", "juniper", "lumen",
+		"maple", "meadow", "nova", "orbit", "pebble", "quartz", "river", "sage",
+		"summit", "tidal", "umber", "vertex", "willow", "zephyr",
+	}
+	firstNames = []string{
+		"Ada", "Alan", "Amara", "Chen", "Diego", "Elif", "Grace", "Hana",
+		"Ines", "Kofi", "Lars", "Leila", "Mateo", "Noor", "Priya", "Sven",
+	}
+	lastNames = []string{
+		"Abara", "Berg", "Costa", "Dubois", "Hopper", "Ito", "Kowalski", "Lovelace",
+		"Mensah", "Nakamura", "Okafor", "Patel", "Rossi", "Silva", "Turing", "Weber",
+	}
+	domains = []string{"example.com", "example.org", "example.net"}
+)
+
+// Word returns a lowercase word.
+func Word(r *rand.Rand) string {
+	return words[r.Intn(len(words))]
+}
+
+// Words returns n words separated by spaces.
+func Words(r *rand.Rand, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = Word(r)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Name returns a person's full name.
+func Name(r *rand.Rand) string {
+	return firstNames[r.Intn(len(firstNames))] + " " + lastNames[r.Intn(len(lastNames))]
+}
+
+// Time returns a time in UTC, to the second, between 2020 and 2030.
+func Time(r *rand.Rand) time.Time {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	return time.Unix(start+r.Int63n(10*365*24*3600), 0).UTC()
+}
+
+// Format returns a string in format, one of the JSON Schema formats
+// date-time, date, time, email, uuid, ipv4, ipv6, and uri, and false for
+// any other format.
+func Format(r *rand.Rand, format string) (string, bool) {
+	switch format {
+	case "date-time":
+		return Time(r).Format(time.RFC3339), true
+	case "date":
+		return Time(r).Format("2006-01-02"), true
+	case "time":
+		return Time(r).Format("15:04:05Z"), true
+	case "email":
+		first := strings.ToLower(firstNames[r.Intn(len(firstNames))])
+		return first + "." + Word(r) + "@" + domains[r.Intn(len(domains))], true
+	case "uuid":
+		b := make([]byte, 16)
+		r.Read(b)
+		b[6] = b[6]&0x0f | 0x40 // version 4
+		b[8] = b[8]&0x3f | 0x80 // variant 10
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), true
+	case "ipv4":
+		return fmt.Sprintf("10.%d.%d.%d", r.Intn(256), r.Intn(256), 1+r.Intn(254)), true
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x:%x", r.Intn(0x10000), 1+r.Intn(0xffff)), true
+	case "uri":
+		return "https://" + domains[r.Intn(len(domains))] + "/" + Word(r) + "/" + Word(r), true
+	}
+	return "", false
+}
+
+// ForName returns a string suited to a member called name, guessing from
+// the name what it holds: an e-mail address for "email", a URL for "url",
+// a UUID for "id" or "uuid", a timestamp for "createdAt", a person's name
+// for "name", and a few words otherwise.
+func ForName(r *rand.Rand, name string) string {
+	lower := strings.ToLower(name)
+	format := ""
+	switch {
+	case strings.Contains(lower, "email"):
+		format = "email"
+	case strings.Contains(lower, "url"), strings.Contains(lower, "uri"), strings.Contains(lower, "link"):
+		format = "uri"
+	case lower == "id", strings.Contains(lower, "uuid"), strings.HasSuffix(name, "ID"), strings.HasSuffix(lower, "_id"):
+		format = "uuid"
+	case strings.HasSuffix(lower, "date"):
+		format = "date"
+	case strings.Contains(lower, "time"), strings.HasSuffix(name, "At"), strings.HasSuffix(lower, "_at"):
+		format = "date-time"
+	case lower == "ip", strings.HasPrefix(lower, "ip"), strings.HasSuffix(lower, "ip"):
+		format = "ipv4"
+	case strings.Contains(lower, "name"):
+		return Name(r)
+	}
+	if s, ok := Format(r, format); ok {
+		return s
+	}
+	return Words(r, 1+r.Intn(3))
+}
+
+// Match returns a string that matches the regular expression expr, or
+// false if expr does not parse. Unbounded repetitions repeat at most three
+// times more than their minimum. Anchors and word boundaries are not
+// enforced, so a caller should check the result.
+func Match(r *rand.Rand, expr string) (string, bool) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	match(r, &b, re.Simplify())
+	return b.String(), true
+}
+
+// match writes a string that re matches to b.
+func match(r *rand.Rand, b *strings.Builder, re *syntax.Regexp) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if len(re.Rune) == 0 {
+			return
+		}
+		// Prefer printable ASCII when the class has some, since classes
+		// such as [^"] span all of Unicode
+		ranges := re.Rune
+		var ascii []rune
+		for i := 0; i < len(ranges); i += 2 {
+			lo, hi := ranges[i], ranges[i+1]
+			if lo < 0x21 {
+				lo = 0x21
+			}
+			if hi > 0x7e {
+				hi = 0x7e
+			}
+			if lo <= hi {
+				ascii = append(ascii, lo, hi)
+			}
+		}
+		if len(ascii) > 0 {
+			ranges = ascii
+		}
+		i := 2 * r.Intn(len(ranges)/2)
+		b.WriteRune(ranges[i] + rune(r.Intn(int(ranges[i+1]-ranges[i])+1)))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte(byte('a' + r.Intn(26)))
+	case syntax.OpCapture:
+		match(r, b, re.Sub[0])
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		lo, hi := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			lo, hi = 0, -1
+		case syntax.OpPlus:
+			lo, hi = 1, -1
+		case syntax.OpQuest:
+			lo, hi = 0, 1
+		}
+		if hi < 0 || hi > lo+3 {
+			hi = lo + 3
+		}
+		for n := lo + r.Intn(hi-lo+1); n > 0; n-- {
+			match(r, b, re.Sub[0])
+		}
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			match(r, b, sub)
+		}
+	case syntax.OpAlternate:
+		match(r, b, re.Sub[r.Intn(len(re.Sub))])
+	}
+}
+
+// Letters returns n lowercase letters, used to pad strings to a minimum
+// length.
+func Letters(r *rand.Rand, n int) string {
+	b := make([]rune, n)
+	for i := range b {
+		b[i] = rune('a' + r.Intn(26))
+	}
+	return string(b)
+}
+
+// Truncate returns s cut to at most n runes, without trailing spaces.
+func Truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return strings.TrimRightFunc(string(runes[:n]), unicode.IsSpace)
+}
@@ -0,0 +1,74 @@
+package fake
+
+import (
+	"math/rand"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestFormat(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	valid := map[string]func(string) bool{
+		"date-time": func(s string) bool { _, err := time.Parse(time.RFC3339, s); return err == nil },
+		"date":      func(s string) bool { _, err := time.Parse("2006-01-02", s); return err == nil },
+		"time":      regexp.MustCompile(`^\d{2}:\d{2}:\d{2}Z$`).MatchString,
+		"email":     func(s string) bool { a, err := mail.ParseAddress(s); return err == nil && a.Address == s },
+		"uuid":      regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString,
+		"ipv4":      func(s string) bool { ip := net.ParseIP(s); return ip != nil && ip.To4() != nil },
+		"ipv6":      func(s string) bool { ip := net.ParseIP(s); return ip != nil && ip.To4() == nil },
+		"uri":       func(s string) bool { u, err := url.Parse(s); return err == nil && u.Scheme == "https" },
+	}
+	for format, check := range valid {
+		for i := 0; i < 20; i++ {
+			s, ok := Format(r, format)
+			if !ok || !check(s) {
+				t.Errorf("Format(%q) = %q, %v", format, s, ok)
+			}
+		}
+	}
+	if _, ok := Format(r, "hostname"); ok {
+		t.Error(`Format("hostname") reported a known format`)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, expr := range []string{
+		`^[A-Z]{3}-\d{4}$`,
+		`^(red|green|blue)+$`,
+		`^[^"\\]{2,5}x?$`,
+		`^\w+@[a-z]+\.(com|org)$`,
+		`^a.b*c{2,}$`,
+	} {
+		re := regexp.MustCompile(expr)
+		for i := 0; i < 20; i++ {
+			if s, ok := Match(r, expr); !ok || !re.MatchString(s) {
+				t.Errorf("Match(%q) = %q, %v", expr, s, ok)
+			}
+		}
+	}
+	if _, ok := Match(r, `[`); ok {
+		t.Error("Match(invalid) reported success")
+	}
+}
+
+func TestForName(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for name, re := range map[string]string{
+		"email":       `@example\.`,
+		"homepageURL": `^https://`,
+		"id":          `^[0-9a-f]{8}-`,
+		"createdAt":   `^20[23]\d-\d\d-\d\dT`,
+		"birthDate":   `^20[23]\d-\d\d-\d\d$`,
+		"name":        `^[A-Z][a-z]+ [A-Z][a-z]+$`,
+		"comment":     `^[a-z]+( [a-z]+)*$`,
+	} {
+		if s := ForName(r, name); !regexp.MustCompile(re).MatchString(s) {
+			t.Errorf("ForName(%q) = %q, want a match for %s", name, s, re)
+		}
+	}
+}
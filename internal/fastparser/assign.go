@@ -0,0 +1,79 @@
+package fastparser
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Assign stores value, a value as Parse returns it, in the variable that v
+// points to, for the types that can be set without reflection:
+// *interface{}, *map[string]interface{}, *[]interface{}, *string, *bool,
+// and *float64. Null stores the zero value. It reports false if v is of
+// another type. TinyGo builds, which leave out the reflection-based
+// decoder, decode with Assign alone.
+func Assign(v, value interface{}) (bool, error) {
+	switch t := v.(type) {
+	case *interface{}:
+		*t = value
+	case *map[string]interface{}:
+		m, ok := value.(map[string]interface{})
+		if !ok && value != nil {
+			return true, assignError(value, "map[string]interface {}")
+		}
+		*t = m
+	case *[]interface{}:
+		s, ok := value.([]interface{})
+		if !ok && value != nil {
+			return true, assignError(value, "[]interface {}")
+		}
+		*t = s
+	case *string:
+		s, ok := value.(string)
+		if !ok && value != nil {
+			return true, assignError(value, "string")
+		}
+		*t = s
+	case *bool:
+		b, ok := value.(bool)
+		if !ok && value != nil {
+			return true, assignError(value, "bool")
+		}
+		*t = b
+	case *float64:
+		switch n := value.(type) {
+		case float64:
+			*t = n
+		case int64:
+			*t = float64(n)
+		case Number:
+			f, err := strconv.ParseFloat(string(n), 64)
+			if err != nil {
+				return true, err
+			}
+			*t = f
+		case nil:
+			*t = 0
+		default:
+			return true, assignError(value, "float64")
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// assignError reports that value cannot be stored in a Go value of type typ.
+func assignError(value interface{}, typ string) error {
+	kind := "number"
+	switch value.(type) {
+	case map[string]interface{}:
+		kind = "object"
+	case []interface{}:
+		kind = "array"
+	case string:
+		kind = "string"
+	case bool:
+		kind = "bool"
+	}
+	return fmt.Errorf("json: cannot unmarshal %s into Go value of type %s", kind, typ)
+}
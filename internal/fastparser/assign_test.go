@@ -0,0 +1,51 @@
+package fastparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAssign(t *testing.T) {
+	var (
+		iface interface{}
+		obj   map[string]interface{}
+		arr   []interface{}
+		str   string
+		flag  bool
+		num   float64
+		other int
+	)
+	tests := []struct {
+		v, value, want interface{}
+		err            bool
+	}{
+		{&iface, int64(1), int64(1), false},
+		{&obj, map[string]interface{}{"a": true}, map[string]interface{}{"a": true}, false},
+		{&obj, nil, map[string]interface{}(nil), false},
+		{&obj, []interface{}{}, nil, true},
+		{&arr, []interface{}{"x"}, []interface{}{"x"}, false},
+		{&arr, "x", nil, true},
+		{&str, "s", "s", false},
+		{&str, 1.5, nil, true},
+		{&flag, true, true, false},
+		{&flag, "true", nil, true},
+		{&num, int64(2), 2.0, false},
+		{&num, Number("2.5"), 2.5, false},
+		{&num, nil, 0.0, false},
+		{&num, false, nil, true},
+	}
+	for _, tt := range tests {
+		ok, err := Assign(tt.v, tt.value)
+		if !ok || (err != nil) != tt.err {
+			t.Errorf("Assign(%T, %#v) = %v, %v", tt.v, tt.value, ok, err)
+			continue
+		}
+		if got := reflect.ValueOf(tt.v).Elem().Interface(); !tt.err && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Assign(%T, %#v) stored %#v, want %#v", tt.v, tt.value, got, tt.want)
+		}
+	}
+
+	if ok, _ := Assign(&other, int64(1)); ok {
+		t.Error("Assign(*int) reported true")
+	}
+}
@@ -0,0 +1,158 @@
+//go:build !tinygo
+
+package fastparser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// DecodeColumns decodes a JSON array of objects into one slice per
+// requested member. Each value in columns must be a non-nil pointer to a
+// slice; the slices are truncated and then get one element per object, the
+// zero value where the object lacks the member, so that they stay aligned
+// by row. Members not in columns are validated and skipped.
+func DecodeColumns(data []byte, columns map[string]interface{}) error {
+	cols := make(map[string]reflect.Value, len(columns))
+	for key, target := range columns {
+		rv := reflect.ValueOf(target)
+		if !rv.IsValid() || rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+			return fmt.Errorf("json: DecodeColumns target for %q must be a non-nil pointer to a slice", key)
+		}
+		col := rv.Elem()
+		col.SetLen(0)
+		cols[key] = col
+	}
+
+	p := NewParser(data)
+	defer p.releaseKeys()
+	p.skipWhitespace()
+	if p.pos >= p.length {
+		return errors.New("unexpected end of JSON input")
+	}
+	if p.data[p.pos] != '[' {
+		return fmt.Errorf("json: DecodeColumns requires a JSON array, found '%c' at position %d", p.data[p.pos], p.pos)
+	}
+	p.pos++ // skip '['
+
+	if err := p.decodeRows(cols); err != nil {
+		return err
+	}
+
+	p.skipWhitespace()
+	if p.pos < p.length {
+		return fmt.Errorf("unexpected data after JSON value at position %d", p.pos)
+	}
+	return nil
+}
+
+// decodeRows decodes the objects of an array whose '[' has been consumed
+// into cols.
+func (p *Parser) decodeRows(cols map[string]reflect.Value) error {
+	p.skipWhitespace()
+	if p.pos < p.length && p.data[p.pos] == ']' {
+		p.pos++
+		return nil
+	}
+
+	for row := 0; ; row++ {
+		p.skipWhitespace()
+		if p.pos >= p.length || p.data[p.pos] != '{' {
+			return fmt.Errorf("json: DecodeColumns row %d is not an object at position %d", row, p.pos)
+		}
+		if err := p.enter(); err != nil {
+			return err
+		}
+		p.pos++ // skip '{'
+		for _, col := range cols {
+			appendZero(col)
+		}
+		if err := p.decodeRow(cols, row); err != nil {
+			return err
+		}
+		p.depth--
+
+		p.skipWhitespace()
+		if p.pos >= p.length {
+			return errors.New("unexpected end of JSON input in array")
+		}
+		if p.data[p.pos] == ']' {
+			p.pos++
+			return nil
+		}
+		if p.data[p.pos] != ',' {
+			return fmt.Errorf("expected ',' or ']' in array at position %d", p.pos)
+		}
+		p.pos++
+	}
+}
+
+// decodeRow decodes the members of the object for row, whose '{' has been
+// consumed, into the last elements of cols.
+func (p *Parser) decodeRow(cols map[string]reflect.Value, row int) error {
+	p.skipWhitespace()
+	if p.pos < p.length && p.data[p.pos] == '}' {
+		p.pos++
+		return nil
+	}
+
+	for {
+		p.skipWhitespace()
+
+		// Parse key
+		if p.pos >= p.length || p.data[p.pos] != '"' {
+			return errors.New("expected string key in object")
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+
+		p.skipWhitespace()
+
+		// Expect ':'
+		if p.pos >= p.length || p.data[p.pos] != ':' {
+			return errors.New("expected ':' after object key")
+		}
+		p.pos++
+
+		p.skipWhitespace()
+
+		if col, ok := cols[key]; ok {
+			if err := p.unmarshalValue(col.Index(row)); err != nil {
+				return fmt.Errorf("json: row %d, column %q: %w", row, key, err)
+			}
+		} else if err := p.scanValue(); err != nil {
+			return err
+		}
+
+		p.skipWhitespace()
+
+		// Check for more entries or end of object
+		if p.pos >= p.length {
+			return errors.New("unexpected end of JSON input in object")
+		}
+
+		if p.data[p.pos] == '}' {
+			p.pos++
+			return nil
+		}
+
+		if p.data[p.pos] != ',' {
+			return fmt.Errorf("expected ',' or '}' in object at position %d", p.pos)
+		}
+		p.pos++
+	}
+}
+
+// appendZero extends the slice s by a zero element, reusing its capacity.
+func appendZero(s reflect.Value) {
+	n := s.Len()
+	if n == s.Cap() {
+		s.Grow(1)
+	}
+	s.SetLen(n + 1)
+	s.Index(n).SetZero()
+}
@@ -0,0 +1,31 @@
+package fastparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxDepth is the deepest nesting of objects and arrays the parsers accept.
+// They recurse once per level, so without a limit a few megabytes of
+// brackets could exhaust the goroutine stack and crash the process.
+const MaxDepth = 10000
+
+// ErrDepthExceeded is wrapped by the error returned for a document nested
+// deeper than MaxDepth.
+var ErrDepthExceeded = errors.New("nesting depth exceeds the limit")
+
+// DepthError returns the error for the container at pos, which is nested
+// deeper than MaxDepth.
+func DepthError(pos int) error {
+	return fmt.Errorf("%w of %d at position %d", ErrDepthExceeded, MaxDepth, pos)
+}
+
+// enter records that the parser is entering the container at the current
+// position, failing if it is nested too deeply. The caller decrements
+// p.depth when it leaves the container.
+func (p *Parser) enter() error {
+	if p.depth++; p.depth > MaxDepth {
+		return DepthError(p.pos)
+	}
+	return nil
+}
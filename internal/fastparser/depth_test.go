@@ -0,0 +1,45 @@
+package fastparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// nested returns depth arrays nested in one another.
+func nested(depth int) []byte {
+	return []byte(strings.Repeat("[", depth) + strings.Repeat("]", depth))
+}
+
+func TestMaxDepth(t *testing.T) {
+	ops := map[string]func([]byte) error{
+		"Parse":     func(data []byte) error { _, err := NewParser(data).Parse(); return err },
+		"SkipValue": func(data []byte) error { _, err := SkipValue(data, 0); return err },
+		"Validate":  Validate,
+		"Walk":      func(data []byte) error { return NewParser(data).Walk(&Handler{}) },
+		"Unmarshal": func(data []byte) error { var v interface{}; return Unmarshal(data, &v) },
+	}
+	if typedDecoding {
+		ops["Unmarshal typed"] = func(data []byte) error {
+			type tree []tree
+			var v tree
+			return Unmarshal(data, &v)
+		}
+	}
+	for name, op := range ops {
+		if err := op(nested(MaxDepth)); err != nil {
+			t.Errorf("%s at MaxDepth: %v", name, err)
+		}
+		err := op(nested(MaxDepth + 1))
+		if !errors.Is(err, ErrDepthExceeded) {
+			t.Errorf("%s beyond MaxDepth: error = %v, want ErrDepthExceeded", name, err)
+		}
+		if err := op([]byte(strings.Repeat("[", 1<<20))); !errors.Is(err, ErrDepthExceeded) {
+			t.Errorf("%s on unterminated brackets: error = %v, want ErrDepthExceeded", name, err)
+		}
+	}
+
+	if err := Validate(nested(MaxDepth + 1)); err == nil || !strings.HasSuffix(err.Error(), "at position 10000") {
+		t.Errorf("Validate error = %v, want the offending position", err)
+	}
+}
@@ -0,0 +1,168 @@
+//go:build !tinygo
+
+package fastparser
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FlatField is a field of a struct as encoding/json sees it, with the
+// fields of embedded structs promoted into the outer object.
+type FlatField struct {
+	Name  string              // JSON name
+	Index []int               // for reflect.Type.FieldByIndex
+	Field reflect.StructField // the field itself, with its tag
+}
+
+var flatFieldsCache sync.Map // reflect.Type -> []FlatField
+
+// HasEmbedded reports whether the struct type t has an embedded field,
+// so that FlatFields may differ from its own fields.
+func HasEmbedded(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Anonymous {
+			return true
+		}
+	}
+	return false
+}
+
+// FlatFields returns the fields of the struct type t that encoding/json
+// encodes and decodes, in the order it writes them, following Go's rules
+// for embedded structs: the fields of an embedded struct, or of a pointer
+// to one, are promoted unless the embedded field has a json name of its
+// own, even when its type is unexported; of several fields with the same
+// JSON name, the least deeply nested one wins, or the only tagged one at
+// that depth, and if there is no such field, none is used.
+func FlatFields(t reflect.Type) []FlatField {
+	if f, ok := flatFieldsCache.Load(t); ok {
+		return f.([]FlatField)
+	}
+	f, _ := flatFieldsCache.LoadOrStore(t, flatFields(t))
+	return f.([]FlatField)
+}
+
+// flatField is a candidate for FlatFields.
+type flatField struct {
+	FlatField
+	tagged bool
+	typ    reflect.Type // the struct to descend into, for an embedded one
+}
+
+// flatFields implements FlatFields with a breadth-first walk of the
+// embedded structs, as encoding/json does.
+func flatFields(t reflect.Type) []FlatField {
+	var fields []flatField
+	current := []flatField{}
+	next := []flatField{{typ: t}}
+	var count, nextCount map[reflect.Type]int
+	visited := map[reflect.Type]bool{}
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, f := range current {
+			if visited[f.typ] {
+				continue
+			}
+			visited[f.typ] = true
+
+			for i := 0; i < f.typ.NumField(); i++ {
+				sf := f.typ.Field(i)
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+					if !sf.IsExported() && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, _, _ := strings.Cut(tag, ",")
+				index := make([]int, len(f.Index)+1)
+				copy(index, f.Index)
+				index[len(f.Index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					field := flatField{tagged: name != ""}
+					field.Name = name
+					if name == "" {
+						field.Name = sf.Name
+					}
+					field.Index = index
+					field.Field = sf
+					fields = append(fields, field)
+					// Two copies of the same embedded struct at one depth
+					// hide each other's fields
+					if count[f.typ] > 1 {
+						fields = append(fields, field)
+					}
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, flatField{FlatField: FlatField{Name: ft.Name(), Index: index}, typ: ft})
+				}
+			}
+		}
+	}
+
+	// Group the fields by name, most visible first
+	sort.Slice(fields, func(i, j int) bool {
+		x, y := &fields[i], &fields[j]
+		if x.Name != y.Name {
+			return x.Name < y.Name
+		}
+		if len(x.Index) != len(y.Index) {
+			return len(x.Index) < len(y.Index)
+		}
+		if x.tagged != y.tagged {
+			return x.tagged
+		}
+		return indexLess(x.Index, y.Index)
+	})
+
+	out := make([]FlatField, 0, len(fields))
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].Name == fields[i].Name {
+			j++
+		}
+		// The first of the group wins unless the next is as visible
+		if j-i == 1 || len(fields[i].Index) < len(fields[i+1].Index) || fields[i].tagged && !fields[i+1].tagged {
+			out = append(out, fields[i].FlatField)
+		}
+		i = j
+	}
+
+	sort.Slice(out, func(i, j int) bool { return indexLess(out[i].Index, out[j].Index) })
+	return out
+}
+
+// indexLess orders field index sequences by their position in the struct.
+func indexLess(x, y []int) bool {
+	for k, xk := range x {
+		if k >= len(y) {
+			return false
+		}
+		if xk != y[k] {
+			return xk < y[k]
+		}
+	}
+	return len(x) < len(y)
+}
@@ -0,0 +1,74 @@
+//go:build !tinygo
+
+package fastparser
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type flatLeaf struct {
+	A int
+	B int `json:"b"`
+}
+
+type flatMid struct {
+	flatLeaf
+	A int // shallower than flatLeaf.A
+	C int
+}
+
+type flatTwin struct {
+	C int
+}
+
+type FlatNamed int
+
+type flatCycle struct {
+	*flatCycle
+	D int
+}
+
+type flatRoot struct {
+	flatMid
+	flatTwin           // C hides flatMid.C at the same depth
+	Leaf     flatLeaf  `json:"leaf"` // named: not flattened
+	Other    *flatLeaf `json:"-"`
+	FlatNamed
+	*flatCycle
+	E int
+}
+
+func TestFlatFields(t *testing.T) {
+	var names []string
+	for _, f := range FlatFields(reflect.TypeOf(flatRoot{})) {
+		names = append(names, f.Name)
+	}
+
+	// encoding/json writes exactly these members, in this order
+	v := flatRoot{flatCycle: &flatCycle{}}
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.Token()
+	for dec.More() {
+		key, _ := dec.Token()
+		want = append(want, key.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("FlatFields = %q, want %q", names, want)
+	}
+
+	if !HasEmbedded(reflect.TypeOf(flatRoot{})) || HasEmbedded(reflect.TypeOf(flatLeaf{})) {
+		t.Error("HasEmbedded is wrong")
+	}
+}
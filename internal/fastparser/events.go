@@ -0,0 +1,250 @@
+package fastparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSkipSubtree is returned by a Handler callback to skip the current value
+// without emitting any further events for it. See Handler for details.
+var ErrSkipSubtree = errors.New("skip subtree")
+
+// Handler receives parse events from Walk.
+//
+// Every callback is optional. When a value callback is nil the value is still
+// fully validated but scanned without being decoded, so ignored strings and
+// numbers cost no allocations.
+//
+// Returning a non-nil error from a callback stops the walk and Walk returns
+// that error, except for ErrSkipSubtree:
+//   - from OnKey, the member's value is skipped;
+//   - from OnObjectStart or OnArrayStart, the container's contents and its
+//     matching end event are skipped.
+type Handler struct {
+	OnObjectStart func() error
+	OnObjectEnd   func() error
+	OnArrayStart  func() error
+	OnArrayEnd    func() error
+	OnKey         func(key string) error
+	OnString      func(s string) error
+	OnNumber      func(raw string) error
+	OnBool        func(b bool) error
+	OnNull        func() error
+}
+
+// Walk parses a single JSON value and reports it to h as a sequence of events.
+// Trailing non-whitespace data after the value is an error.
+func (p *Parser) Walk(h *Handler) error {
+	defer p.releaseKeys()
+	p.skipWhitespace()
+	if p.pos >= p.length {
+		return errors.New("unexpected end of JSON input")
+	}
+
+	if err := p.walkValue(h); err != nil {
+		return err
+	}
+
+	p.skipWhitespace()
+	if p.pos < p.length {
+		return fmt.Errorf("unexpected data after JSON value at position %d", p.pos)
+	}
+	return nil
+}
+
+// walkValue emits events for the value at the current position.
+func (p *Parser) walkValue(h *Handler) error {
+	if p.pos >= p.length {
+		return errors.New("unexpected end of JSON input")
+	}
+
+	switch c := p.data[p.pos]; c {
+	case '{', '[':
+		if err := p.enter(); err != nil {
+			return err
+		}
+		var err error
+		if c == '{' {
+			err = p.walkObject(h)
+		} else {
+			err = p.walkArray(h)
+		}
+		p.depth--
+		return err
+	case '"':
+		if h.OnString == nil {
+			return p.scanString()
+		}
+		s, err := p.parseString()
+		if err != nil {
+			return err
+		}
+		return h.OnString(s)
+	case 't':
+		if _, err := p.parseTrue(); err != nil {
+			return err
+		}
+		if h.OnBool != nil {
+			return h.OnBool(true)
+		}
+		return nil
+	case 'f':
+		if _, err := p.parseFalse(); err != nil {
+			return err
+		}
+		if h.OnBool != nil {
+			return h.OnBool(false)
+		}
+		return nil
+	case 'n':
+		if _, err := p.parseNull(); err != nil {
+			return err
+		}
+		if h.OnNull != nil {
+			return h.OnNull()
+		}
+		return nil
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		start := p.pos
+		if _, err := p.scanNumber(); err != nil {
+			return err
+		}
+		if h.OnNumber != nil {
+			return h.OnNumber(string(p.data[start:p.pos]))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unexpected character '%c' at position %d", c, p.pos)
+	}
+}
+
+// walkObject emits events for an object and its members.
+func (p *Parser) walkObject(h *Handler) error {
+	if h.OnObjectStart != nil {
+		if err := h.OnObjectStart(); err != nil {
+			if err == ErrSkipSubtree {
+				return p.scanObject()
+			}
+			return err
+		}
+	}
+	p.pos++ // skip '{'
+	p.skipWhitespace()
+
+	if p.pos < p.length && p.data[p.pos] == '}' {
+		p.pos++
+		return callEnd(h.OnObjectEnd)
+	}
+
+	for {
+		p.skipWhitespace()
+
+		if p.pos >= p.length || p.data[p.pos] != '"' {
+			return errors.New("expected string key in object")
+		}
+
+		skip := false
+		if h.OnKey == nil {
+			if err := p.scanString(); err != nil {
+				return err
+			}
+		} else {
+			key, err := p.parseKey()
+			if err != nil {
+				return err
+			}
+			if err := h.OnKey(key); err != nil {
+				if err != ErrSkipSubtree {
+					return err
+				}
+				skip = true
+			}
+		}
+
+		p.skipWhitespace()
+
+		if p.pos >= p.length || p.data[p.pos] != ':' {
+			return errors.New("expected ':' after object key")
+		}
+		p.pos++ // skip ':'
+
+		p.skipWhitespace()
+
+		var err error
+		if skip {
+			err = p.scanValue()
+		} else {
+			err = p.walkValue(h)
+		}
+		if err != nil {
+			return err
+		}
+
+		p.skipWhitespace()
+
+		if p.pos >= p.length {
+			return errors.New("unexpected end of JSON input in object")
+		}
+
+		if p.data[p.pos] == '}' {
+			p.pos++
+			return callEnd(h.OnObjectEnd)
+		}
+
+		if p.data[p.pos] != ',' {
+			return fmt.Errorf("expected ',' or '}' in object at position %d", p.pos)
+		}
+		p.pos++ // skip ','
+	}
+}
+
+// walkArray emits events for an array and its elements.
+func (p *Parser) walkArray(h *Handler) error {
+	if h.OnArrayStart != nil {
+		if err := h.OnArrayStart(); err != nil {
+			if err == ErrSkipSubtree {
+				return p.scanArray()
+			}
+			return err
+		}
+	}
+	p.pos++ // skip '['
+	p.skipWhitespace()
+
+	if p.pos < p.length && p.data[p.pos] == ']' {
+		p.pos++
+		return callEnd(h.OnArrayEnd)
+	}
+
+	for {
+		p.skipWhitespace()
+
+		if err := p.walkValue(h); err != nil {
+			return err
+		}
+
+		p.skipWhitespace()
+
+		if p.pos >= p.length {
+			return errors.New("unexpected end of JSON input in array")
+		}
+
+		if p.data[p.pos] == ']' {
+			p.pos++
+			return callEnd(h.OnArrayEnd)
+		}
+
+		if p.data[p.pos] != ',' {
+			return fmt.Errorf("expected ',' or ']' in array at position %d", p.pos)
+		}
+		p.pos++ // skip ','
+	}
+}
+
+// callEnd invokes an optional end-of-container callback.
+func callEnd(fn func() error) error {
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
@@ -0,0 +1,138 @@
+package fastparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// recordingHandler returns a Handler that appends a trace of every event to out.
+func recordingHandler(out *[]string) *Handler {
+	return &Handler{
+		OnObjectStart: func() error { *out = append(*out, "{"); return nil },
+		OnObjectEnd:   func() error { *out = append(*out, "}"); return nil },
+		OnArrayStart:  func() error { *out = append(*out, "["); return nil },
+		OnArrayEnd:    func() error { *out = append(*out, "]"); return nil },
+		OnKey:         func(k string) error { *out = append(*out, "k:"+k); return nil },
+		OnString:      func(s string) error { *out = append(*out, "s:"+s); return nil },
+		OnNumber:      func(n string) error { *out = append(*out, "n:"+n); return nil },
+		OnBool: func(b bool) error {
+			if b {
+				*out = append(*out, "true")
+			} else {
+				*out = append(*out, "false")
+			}
+			return nil
+		},
+		OnNull: func() error { *out = append(*out, "null"); return nil },
+	}
+}
+
+func TestWalk_Events(t *testing.T) {
+	input := `{"a": [1, -2.5e3, "x\n"], "b": {"c": true, "d": false}, "e": null}`
+
+	var events []string
+	if err := NewParser([]byte(input)).Walk(recordingHandler(&events)); err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+
+	want := "{ k:a [ n:1 n:-2.5e3 s:x\n ] k:b { k:c true k:d false } k:e null }"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestWalk_SkipSubtree(t *testing.T) {
+	input := `{"skip": {"deep": [1, 2, {"x": "y"}]}, "keep": [3, [4]]}`
+
+	var events []string
+	h := recordingHandler(&events)
+	h.OnKey = func(k string) error {
+		events = append(events, "k:"+k)
+		if k == "skip" {
+			return ErrSkipSubtree
+		}
+		return nil
+	}
+	depth := 0
+	h.OnArrayStart = func() error {
+		depth++
+		if depth > 1 {
+			return ErrSkipSubtree
+		}
+		events = append(events, "[")
+		return nil
+	}
+
+	if err := NewParser([]byte(input)).Walk(h); err != nil {
+		t.Fatalf("Walk() error = %v", err)
+	}
+
+	want := "{ k:skip k:keep [ n:3 ] }"
+	if got := strings.Join(events, " "); got != want {
+		t.Errorf("events = %q, want %q", got, want)
+	}
+}
+
+func TestWalk_CallbackError(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	h := &Handler{OnNumber: func(string) error {
+		calls++
+		if calls == 2 {
+			return stop
+		}
+		return nil
+	}}
+
+	err := NewParser([]byte(`[1, 2, 3]`)).Walk(h)
+	if err != stop {
+		t.Errorf("Walk() error = %v, want %v", err, stop)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestWalk_Errors(t *testing.T) {
+	tests := []string{
+		``,
+		`{`,
+		`[1,]`,
+		`{"a" 1}`,
+		`{"a":1,}`,
+		`{1:2}`,
+		`"unterminated`,
+		`"bad \x escape"`,
+		`"bad \u12G4"`,
+		"\"ctrl \x01\"",
+		`01x`,
+		`1.`,
+		`tru`,
+		`[1] 2`,
+		`@`,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			if err := NewParser([]byte(input)).Walk(&Handler{}); err == nil {
+				t.Errorf("Walk(%q) expected error", input)
+			}
+		})
+	}
+}
+
+func TestWalk_IgnoredValuesDoNotAllocate(t *testing.T) {
+	data := []byte(`{"name": "Alice \"A\" é", "tags": ["go", "json"], "n": [1, 2.5, -3e2], "ok": true, "z": null}`)
+	h := &Handler{}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := NewParser(data).Walk(h); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// NewParser itself allocates the Parser struct.
+	if allocs > 1 {
+		t.Errorf("allocs = %v, want <= 1", allocs)
+	}
+}
@@ -0,0 +1,85 @@
+//go:build !tinygo
+
+package fastparser
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxExactExponent bounds the exponents checkExact evaluates exactly, so
+// that a number such as 1e999999999 cannot make it build a huge integer. A
+// nonzero number with a larger exponent overflows or underflows every Go
+// numeric type anyway.
+const maxExactExponent = 10000
+
+// checkExact returns an error unless the JSON number text can be stored in
+// rv, an integer or floating-point value, without rounding, truncation, or
+// overflow. A float is exact if text denotes the float's own value or the
+// shortest decimal that identifies it, so 0.1 fits a float32 but 16777217
+// does not.
+func checkExact(text []byte, rv reflect.Value) error {
+	s := string(text)
+	inexact := func() error {
+		return fmt.Errorf("json: number %s cannot be represented exactly in Go value of type %s", s, rv.Type())
+	}
+
+	// Plain integers, the common case, need no big arithmetic
+	if strings.IndexAny(s, ".eE") < 0 {
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if _, err := strconv.ParseInt(s, 10, rv.Type().Bits()); err != nil {
+				return inexact()
+			}
+			return nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if _, err := strconv.ParseUint(s, 10, rv.Type().Bits()); err != nil {
+				return inexact()
+			}
+			return nil
+		}
+	}
+
+	var exact big.Rat
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		exp, err := strconv.Atoi(strings.TrimPrefix(s[i+1:], "+"))
+		if err != nil || exp > maxExactExponent || exp < -maxExactExponent {
+			if strings.Trim(s[:i], "-0.") != "" {
+				return inexact()
+			}
+			s = "0" // a zero mantissa is zero whatever the exponent
+		}
+	}
+	if _, ok := exact.SetString(s); !ok {
+		return inexact()
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !exact.IsInt() || !exact.Num().IsInt64() || rv.OverflowInt(exact.Num().Int64()) {
+			return inexact()
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !exact.IsInt() || !exact.Num().IsUint64() || rv.OverflowUint(exact.Num().Uint64()) {
+			return inexact()
+		}
+	case reflect.Float32, reflect.Float64:
+		bits := rv.Type().Bits()
+		f, err := strconv.ParseFloat(s, bits)
+		if err != nil {
+			return inexact()
+		}
+		var back big.Rat
+		if back.SetFloat64(f).Cmp(&exact) == 0 {
+			return nil
+		}
+		back.SetString(strconv.FormatFloat(f, 'g', -1, bits))
+		if back.Cmp(&exact) != 0 {
+			return inexact()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build !tinygo
+
+package fastparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckExact(t *testing.T) {
+	tests := []struct {
+		text  string
+		into  interface{}
+		exact bool
+	}{
+		{"42", int8(0), true},
+		{"128", int8(0), false},
+		{"-1", uint(0), false},
+		{"1e2", int(0), true},
+		{"1.5", int(0), false},
+		{"1.0", int(0), true},
+		{"1e19", int64(0), false},
+		{"18446744073709551615", uint64(0), true},
+		{"18446744073709551616", uint64(0), false},
+		{"0.1", float32(0), true},
+		{"0.1", float64(0), true},
+		{"16777216", float32(0), true},
+		{"16777217", float32(0), false},
+		{"1152921504606846976", float64(0), true},
+		{"9007199254740993", float64(0), false},
+		{"1e308", float32(0), false},
+		{"1e308", float64(0), true},
+		{"1e-400", float64(0), false},
+		{"0e-99999999", float64(0), true},
+		{"1e99999999", float64(0), false},
+		{"-0", float64(0), true},
+	}
+	for _, tt := range tests {
+		rv := reflect.New(reflect.TypeOf(tt.into)).Elem()
+		err := checkExact([]byte(tt.text), rv)
+		if (err == nil) != tt.exact {
+			t.Errorf("checkExact(%s, %T) = %v, want exact %v", tt.text, tt.into, err, tt.exact)
+		}
+	}
+}
+
+func TestUnmarshalStrictNumbers(t *testing.T) {
+	var v struct {
+		N int
+		F float32
+	}
+	opts := Options{StrictNumbers: true}
+	if err := UnmarshalWithOptions([]byte(`{"N":3,"F":0.25}`), &v, opts); err != nil {
+		t.Fatal(err)
+	}
+	err := UnmarshalWithOptions([]byte(`{"F":16777217}`), &v, opts)
+	want := "json: F: number 16777217 cannot be represented exactly in Go value of type float32"
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+
+	// Without the option the value is rounded as before
+	if err := UnmarshalWithOptions([]byte(`{"F":16777217}`), &v, Options{}); err != nil || v.F != 16777216 {
+		t.Errorf("F = %v, err = %v", v.F, err)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build !tinygo
+
+package fastparser
+
+import "reflect"
+
+// The functions below decode one value in the middle of a document whose
+// structure has been parsed by other means, such as into an AST. They
+// apply the same rules, and return the same errors, positions included, as
+// UnmarshalWithOptions does on reaching the value at offset. opts may be
+// nil. If opts.Validator is set, it is notified of the value, as the
+// validator for it.
+
+// DecodeAt decodes the value at data[offset:] into rv.
+func DecodeAt(data []byte, offset int, rv reflect.Value, opts *Options) error {
+	p := at(data, offset, opts)
+	defer p.releaseKeys()
+	return p.unmarshalValue(rv)
+}
+
+// DecodeQuotedAt decodes the JSON string at data[offset:], which holds a
+// number or bool, into rv, as for a field with the ",string" option.
+func DecodeQuotedAt(data []byte, offset int, rv reflect.Value, opts *Options) error {
+	return at(data, offset, opts).unmarshalQuotedChecked(rv)
+}
+
+// DecodeTransformedAt passes the value at data[offset:] through the named
+// transform and decodes the result into rv, as for a field with the
+// "transform:<name>" option. field is the field's JSON name.
+func DecodeTransformedAt(data []byte, offset int, rv reflect.Value, opts *Options, name, field string) error {
+	p := at(data, offset, opts)
+	defer p.releaseKeys()
+	if p.validator == nil {
+		return p.unmarshalTransformed(rv, name, field)
+	}
+	return p.validated(func() error { return p.unmarshalTransformed(rv, name, field) })
+}
+
+// at returns a parser positioned at data[offset].
+func at(data []byte, offset int, opts *Options) *Parser {
+	p := &Parser{data: data, pos: offset, length: len(data), opts: opts}
+	if opts != nil {
+		p.validator = opts.Validator
+	}
+	return p
+}
@@ -0,0 +1,52 @@
+package fastparser
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// Number is the text of a JSON number, as stored in interface{} values
+// under NumbersAsNumber. See json.Number.
+type Number string
+
+// String returns the literal text of the number.
+func (n Number) String() string { return string(n) }
+
+// Float64 returns the number as a float64.
+func (n Number) Float64() (float64, error) {
+	return strconv.ParseFloat(string(n), 64)
+}
+
+// Int64 returns the number as an int64.
+func (n Number) Int64() (int64, error) {
+	return strconv.ParseInt(string(n), 10, 64)
+}
+
+// NumberType is the reflect.Type of Number.
+var NumberType = reflect.TypeOf(Number(""))
+
+// NumberMode selects the Go type of the numbers decoded into interface{}
+// values.
+type NumberMode uint8
+
+const (
+	// NumbersPreferInt64 stores numbers without a fraction or exponent
+	// that fit in an int64 as int64, and others as float64.
+	NumbersPreferInt64 NumberMode = iota
+
+	// NumbersFloat64 stores every number as float64.
+	NumbersFloat64
+
+	// NumbersAsNumber stores every number as a Number holding its text.
+	NumbersAsNumber
+)
+
+// IsNumber reports whether s is a number in JSON syntax.
+func IsNumber(s string) bool {
+	p := NewParser([]byte(s))
+	if s == "" || s[0] != '-' && (s[0] < '0' || s[0] > '9') {
+		return false
+	}
+	_, err := p.scanNumber()
+	return err == nil && p.pos == len(s)
+}
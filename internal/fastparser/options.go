@@ -0,0 +1,113 @@
+package fastparser
+
+import (
+	"reflect"
+
+	"github.com/shapestone/shape-json/internal/sizehint"
+)
+
+// elementCounts estimates how many elements the slices and maps of each
+// type hold, so decoding can allocate them once at about their final size.
+var elementCounts = sizehint.Table{Max: 1 << 16}
+
+// Unmarshaler is the interface implemented by types that can unmarshal a JSON description of themselves.
+type Unmarshaler interface {
+	UnmarshalJSON([]byte) error
+}
+
+// Slot is implemented by wrapper types, such as json.Optional, that hold a
+// single value and record whether and how it was decoded. The decoder fills
+// a Slot in place, in the same pass as the rest of the document, instead of
+// handing its raw JSON to UnmarshalJSON.
+type Slot interface {
+	// JSONSlot is called when a value for the slot is about to be decoded;
+	// null reports whether it is the null literal. It returns a pointer to
+	// the value to decode into, or nil if the slot has recorded the null
+	// itself.
+	JSONSlot(null bool) interface{}
+}
+
+// Options configures UnmarshalWithOptions.
+type Options struct {
+	// Transforms maps transform names to functions that rewrite the raw JSON
+	// of struct fields tagged with a "transform:<name>" option before it is
+	// decoded into the field. field is the field's JSON name. Decoding a
+	// field whose transform is missing is an error.
+	Transforms map[string]func(field string, data []byte) ([]byte, error)
+
+	// Interfaces maps interface types to functions that select the concrete
+	// type to decode a value into, given its raw JSON. The returned type must
+	// implement the interface.
+	Interfaces map[reflect.Type]func(data []byte) (reflect.Type, error)
+
+	// Validator, if set, is notified of every value as it is decoded.
+	Validator Validator
+
+	// RejectNull makes null an error for values whose kind is not NullableKind,
+	// instead of leaving them zero. Values with an UnmarshalJSON method
+	// receive null and decide for themselves.
+	RejectNull bool
+
+	// StrictNumbers makes a number an error unless the target integer or
+	// float holds it exactly; see checkExact.
+	StrictNumbers bool
+
+	// Int64AsString accepts 64-bit integers (see IsWideInt) written as JSON
+	// strings, as well as plain numbers.
+	Int64AsString bool
+
+	// Stdlib decodes as encoding/json does where it differs from the
+	// defaults: object keys into the struct field whose name they match
+	// case-insensitively when none matches exactly, and into the fields
+	// promoted from embedded structs (see FlatFields); strings into []byte
+	// as base64 and into encoding.TextUnmarshaler with UnmarshalText;
+	// objects into maps with integer and TextUnmarshaler keys (see MapKey)
+	// and the database/sql null types as plain structs; and null into
+	// values that cannot be nil by leaving them unchanged.
+	Stdlib bool
+
+	// Numbers selects the Go type of numbers decoded into interface{}.
+	Numbers NumberMode
+
+	// CoerceStrings accepts numbers and bools written as JSON strings
+	// wherever a Quotable type is expected, as if every field had the
+	// ",coerce" tag option.
+	CoerceStrings bool
+
+	// Types maps Go types to functions that decode the raw JSON of a value,
+	// null included, into rv in place of the default rules.
+	Types map[reflect.Type]func(data []byte, rv reflect.Value) error
+
+	// Scanners makes types that implement sql.Scanner, and not Unmarshaler,
+	// decode by passing Scan the value as database/sql would: a string,
+	// int64, float64, bool, or nil, with objects and arrays as raw JSON
+	// text. The nullable types of database/sql are decoded natively
+	// regardless.
+	Scanners bool
+}
+
+// NullableKind reports whether null is a natural value for Go values of kind k:
+// nil pointers, interfaces, maps, and slices.
+func NullableKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		return true
+	}
+	return false
+}
+
+// Validator follows a decode through the document so that a schema can be
+// checked in the same pass.
+//
+// Before decoding a member or element, the decoder asks the validator of the
+// enclosing value for the child's validator; nil means the child needs no
+// checks. After a value has been read, the decoder calls Done with its raw
+// JSON text. walked reports whether Member or Element was called for each
+// of its members or elements; it is false for scalars and for containers
+// that were decoded as a whole, such as into interface{} or by an
+// Unmarshaler.
+type Validator interface {
+	Member(key string) Validator
+	Element(index int) Validator
+	Done(raw []byte, walked bool)
+}
@@ -1,7 +1,10 @@
 package fastparser
 
 import (
+	"reflect"
+	"strconv"
 	"testing"
+	"unsafe"
 )
 
 func TestParseString(t *testing.T) {
@@ -51,6 +54,16 @@ func TestParseString(t *testing.T) {
 			input: `"\u0041\u0042\u0043"`,
 			want:  "ABC",
 		},
+		{
+			name:  "string with surrogate pair escape",
+			input: `"\ud83d\ude00!"`,
+			want:  "\U0001f600!",
+		},
+		{
+			name:  "string with lone surrogate escape",
+			input: `"\ud83d\u0041"`,
+			want:  "\ufffdA",
+		},
 		{
 			name:    "unclosed string",
 			input:   `"hello`,
@@ -162,6 +175,100 @@ func TestParseNumber(t *testing.T) {
 	}
 }
 
+// integerInputs are integers around every boundary the digit accumulator
+// and the int64 and uint64 ranges have.
+var integerInputs = []string{
+	"0", "-0", "7", "-7", "127", "-128", "255", "256", "65535",
+	"999999999999999999", "1000000000000000000", "-999999999999999999",
+	"9223372036854775807", "9223372036854775808", "-9223372036854775808", "-9223372036854775809",
+	"9999999999999999999", "-9999999999999999999", "10000000000000000000",
+	"18446744073709551615", "18446744073709551616", "-18446744073709551615",
+	"123456789012345678901234567890",
+}
+
+// TestParseInt64 checks the digit accumulator against strconv.ParseInt.
+func TestParseInt64(t *testing.T) {
+	for _, s := range integerInputs {
+		want, err := strconv.ParseInt(s, 10, 64)
+		got, ok := parseInt64([]byte(s))
+		if ok && (err != nil || got != want) {
+			t.Errorf("parseInt64(%s) = %d, want %d (%v)", s, got, want, err)
+		}
+		if !ok && err == nil && len(s) <= maxFastDigits {
+			t.Errorf("parseInt64(%s) failed, want %d", s, want)
+		}
+	}
+}
+
+// TestUnmarshalIntegers decodes integers into every integer kind and
+// checks the values against strconv and the errors against range.
+func TestUnmarshalIntegers(t *testing.T) {
+	if !typedDecoding {
+		t.Skip("TinyGo builds decode only into the types Assign accepts")
+	}
+	targets := []interface{}{new(int), new(int8), new(int16), new(int32), new(int64),
+		new(uint), new(uint8), new(uint16), new(uint32), new(uint64)}
+	for _, s := range integerInputs {
+		for _, target := range targets {
+			rv := reflect.New(reflect.TypeOf(target).Elem())
+			err := Unmarshal([]byte(s), rv.Interface())
+			bits := rv.Elem().Type().Bits()
+
+			var want interface{}
+			var rangeErr error
+			switch rv.Elem().Kind() {
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				u, perr := strconv.ParseUint(s, 10, bits)
+				if s == "-0" {
+					u, perr = 0, nil
+				}
+				want, rangeErr = u, perr
+			default:
+				i, perr := strconv.ParseInt(s, 10, bits)
+				want, rangeErr = i, perr
+			}
+			if (err != nil) != (rangeErr != nil) {
+				t.Errorf("Unmarshal(%s) into %s: error %v, want error %v", s, rv.Elem().Type(), err, rangeErr)
+				continue
+			}
+			if err != nil {
+				continue
+			}
+			var got interface{} = rv.Elem().Convert(reflect.TypeOf(want)).Interface()
+			if got != want {
+				t.Errorf("Unmarshal(%s) into %s = %v, want %v", s, rv.Elem().Type(), got, want)
+			}
+		}
+	}
+}
+
+// TestUnmarshalFloatBounds decodes floats at the edges of the int64 and
+// uint64 ranges, where converting the float is implementation-defined and
+// differs between amd64 and wasm.
+func TestUnmarshalFloatBounds(t *testing.T) {
+	if !typedDecoding {
+		t.Skip("TinyGo builds decode only into the types Assign accepts")
+	}
+	tests := []struct {
+		in      string
+		target  interface{}
+		wantErr bool
+	}{
+		{"9.223372036854775808e18", new(int64), true},
+		{"-9.223372036854775808e18", new(int64), false},
+		{"1e19", new(int64), true},
+		{"1.8446744073709551616e19", new(uint64), true},
+		{"1.8446744073709550e19", new(uint64), false},
+		{"1e30", new(uint64), true},
+	}
+	for _, tt := range tests {
+		err := Unmarshal([]byte(tt.in), tt.target)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) into %T: error %v, want error %v", tt.in, tt.target, err, tt.wantErr)
+		}
+	}
+}
+
 func TestParseLiterals(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -348,6 +455,48 @@ func TestParseObject(t *testing.T) {
 	}
 }
 
+func TestParseObject_SharesKeys(t *testing.T) {
+	data := []byte(`[{"name":"a","escaped\u0041":1},{"name":"b","escaped\u0041":2}]`)
+
+	v, err := NewParser(data).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyData := func(m interface{}, want string) *byte {
+		for k := range m.(map[string]interface{}) {
+			if k == want {
+				return unsafe.StringData(k)
+			}
+		}
+		t.Fatalf("no key %q in %v", want, m)
+		return nil
+	}
+	list := v.([]interface{})
+	for _, key := range []string{"name", "escapedA"} {
+		if keyData(list[0], key) != keyData(list[1], key) {
+			t.Errorf("key %q is not shared between the objects", key)
+		}
+	}
+
+	if !typedDecoding {
+		return
+	}
+	var maps []map[string]int
+	if err := Unmarshal([]byte(`[{"n":1},{"n":2}]`), &maps); err != nil {
+		t.Fatal(err)
+	}
+	var first, second *byte
+	for k := range maps[0] {
+		first = unsafe.StringData(k)
+	}
+	for k := range maps[1] {
+		second = unsafe.StringData(k)
+	}
+	if first != second {
+		t.Error("map keys decoded by Unmarshal are not shared")
+	}
+}
+
 func TestParseWhitespace(t *testing.T) {
 	tests := []struct {
 		name  string
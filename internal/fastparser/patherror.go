@@ -0,0 +1,82 @@
+package fastparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// PathError is a decode error together with the location of the value that
+// failed, such as users[3].address.zip.
+//
+// The path is built while the error unwinds through the enclosing members
+// and elements, so decoding that succeeds pays nothing for it.
+type PathError struct {
+	// Path locates the value in JavaScript notation: member names joined
+	// with dots, array indexes in brackets, and names that are not
+	// identifiers quoted in brackets, as in users[3]['zip code'].
+	Path string
+
+	// Err is the error decoding the value.
+	Err error
+}
+
+func (e *PathError) Error() string {
+	return "json: " + e.Path + ": " + strings.TrimPrefix(e.Err.Error(), "json: ")
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// WithKey adds the object member key to the front of err's path, making err
+// a *PathError if it is not one already.
+func WithKey(err error, key string) error {
+	if isIdentifier(key) {
+		return withSegment(err, key)
+	}
+	var b strings.Builder
+	b.WriteString("['")
+	for i := 0; i < len(key); i++ {
+		if key[i] == '\'' || key[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(key[i])
+	}
+	b.WriteString("']")
+	return withSegment(err, b.String())
+}
+
+// WithIndex adds the array index to the front of err's path, like WithKey.
+func WithIndex(err error, index int) error {
+	return withSegment(err, "["+strconv.Itoa(index)+"]")
+}
+
+func withSegment(err error, seg string) error {
+	pe, ok := err.(*PathError)
+	if !ok {
+		return &PathError{Path: seg, Err: err}
+	}
+	if pe.Path[0] == '[' {
+		pe.Path = seg + pe.Path
+	} else {
+		pe.Path = seg + "." + pe.Path
+	}
+	return pe
+}
+
+// isIdentifier reports whether key can be written after a dot in a path.
+func isIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+//go:build !tinygo
+
+package fastparser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPathError(t *testing.T) {
+	base := errors.New("json: cannot unmarshal number into Go value of type string")
+
+	tests := []struct {
+		build func() error
+		want  string
+	}{
+		{func() error { return WithKey(base, "zip") }, "zip"},
+		{func() error { return WithKey(WithKey(WithIndex(WithKey(base, "zip"), 3), "users"), "data") }, "data.users[3].zip"},
+		{func() error { return WithIndex(WithIndex(base, 1), 0) }, "[0][1]"},
+		{func() error { return WithKey(WithKey(base, "zip code"), "a") }, "a['zip code']"},
+		{func() error { return WithKey(WithKey(base, "x"), "it's") }, `['it\'s'].x`},
+		{func() error { return WithKey(base, "") }, "['']"},
+		{func() error { return WithKey(base, "1st") }, "['1st']"},
+	}
+	for _, tt := range tests {
+		err := tt.build()
+		var pe *PathError
+		if !errors.As(err, &pe) || pe.Path != tt.want {
+			t.Errorf("path = %v, want %q", err, tt.want)
+			continue
+		}
+		if !errors.Is(err, base) {
+			t.Errorf("%v does not wrap the original error", err)
+		}
+		if want := "json: " + tt.want + ": cannot unmarshal number into Go value of type string"; err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	}
+}
+
+func TestUnmarshalErrorPath(t *testing.T) {
+	type address struct {
+		Zip string `json:"zip"`
+	}
+	type user struct {
+		Address address `json:"address"`
+	}
+	var v struct {
+		Users []user            `json:"users"`
+		Tags  map[string][2]int `json:"tags"`
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"users": [{}, {"address": {"zip": 8000}}]}`, "json: users[1].address.zip: cannot unmarshal number into Go value of type string"},
+		{`{"tags": {"a b": [1, 2, 3]}}`, "json: tags['a b']: array length exceeds target array length 2"},
+		{`{"tags": {"x": [1, "2"]}}`, "json: tags.x[1]: cannot unmarshal string into Go value of type int"},
+		{`{"users": [{"address": {"zip": "1"]}]}`, "json: users[0].address: expected ',' or '}' in object at position 34"},
+	}
+	for _, tt := range tests {
+		err := Unmarshal([]byte(tt.input), &v)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Unmarshal(%s) error = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+
+	// Errors at the top level have no path
+	var s string
+	if err := Unmarshal([]byte(`1`), &s); err == nil || errors.As(err, new(*PathError)) {
+		t.Errorf("Unmarshal() error = %v, want an error without a path", err)
+	}
+}
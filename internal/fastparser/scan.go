@@ -0,0 +1,205 @@
+package fastparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SkipValue skips the JSON value starting at data[offset], after any leading
+// whitespace, and returns the offset just past its end. The value is fully
+// validated but not decoded. On error, the returned offset is the position at
+// which scanning stopped.
+func SkipValue(data []byte, offset int) (int, error) {
+	if offset < 0 || offset > len(data) {
+		return offset, fmt.Errorf("offset %d out of range [0, %d]", offset, len(data))
+	}
+
+	p := &Parser{data: data, pos: offset, length: len(data)}
+	p.skipWhitespace()
+	if err := p.scanValue(); err != nil {
+		return p.pos, err
+	}
+	return p.pos, nil
+}
+
+// scanValue advances past the value at the current position, validating it
+// fully but without decoding or allocating.
+func (p *Parser) scanValue() error {
+	if p.pos >= p.length {
+		return errors.New("unexpected end of JSON input")
+	}
+
+	switch c := p.data[p.pos]; c {
+	case '{', '[':
+		if err := p.enter(); err != nil {
+			return err
+		}
+		var err error
+		if c == '{' {
+			err = p.scanObject()
+		} else {
+			err = p.scanArray()
+		}
+		p.depth--
+		return err
+	case '"':
+		return p.scanString()
+	case 't':
+		_, err := p.parseTrue()
+		return err
+	case 'f':
+		_, err := p.parseFalse()
+		return err
+	case 'n':
+		_, err := p.parseNull()
+		return err
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		_, err := p.scanNumber()
+		return err
+	default:
+		return fmt.Errorf("unexpected character '%c' at position %d", c, p.pos)
+	}
+}
+
+// scanObject advances past an object without decoding it.
+func (p *Parser) scanObject() error {
+	p.pos++ // skip '{'
+	p.skipWhitespace()
+
+	if p.pos < p.length && p.data[p.pos] == '}' {
+		p.pos++
+		return nil
+	}
+
+	for {
+		p.skipWhitespace()
+
+		if p.pos >= p.length || p.data[p.pos] != '"' {
+			return errors.New("expected string key in object")
+		}
+		if err := p.scanString(); err != nil {
+			return err
+		}
+
+		p.skipWhitespace()
+
+		if p.pos >= p.length || p.data[p.pos] != ':' {
+			return errors.New("expected ':' after object key")
+		}
+		p.pos++ // skip ':'
+
+		p.skipWhitespace()
+
+		if err := p.scanValue(); err != nil {
+			return err
+		}
+
+		p.skipWhitespace()
+
+		if p.pos >= p.length {
+			return errors.New("unexpected end of JSON input in object")
+		}
+
+		if p.data[p.pos] == '}' {
+			p.pos++
+			return nil
+		}
+
+		if p.data[p.pos] != ',' {
+			return fmt.Errorf("expected ',' or '}' in object at position %d", p.pos)
+		}
+		p.pos++ // skip ','
+	}
+}
+
+// scanArray advances past an array without decoding it.
+func (p *Parser) scanArray() error {
+	p.pos++ // skip '['
+	p.skipWhitespace()
+
+	if p.pos < p.length && p.data[p.pos] == ']' {
+		p.pos++
+		return nil
+	}
+
+	for {
+		p.skipWhitespace()
+
+		if err := p.scanValue(); err != nil {
+			return err
+		}
+
+		p.skipWhitespace()
+
+		if p.pos >= p.length {
+			return errors.New("unexpected end of JSON input in array")
+		}
+
+		if p.data[p.pos] == ']' {
+			p.pos++
+			return nil
+		}
+
+		if p.data[p.pos] != ',' {
+			return fmt.Errorf("expected ',' or ']' in array at position %d", p.pos)
+		}
+		p.pos++ // skip ','
+	}
+}
+
+// scanString advances past a string, validating escape sequences and
+// rejecting control characters, without building the decoded value.
+func (p *Parser) scanString() error {
+	if p.pos >= p.length || p.data[p.pos] != '"' {
+		return errors.New("expected '\"'")
+	}
+	p.pos++ // skip opening '"'
+
+	for p.pos < p.length {
+		p.pos = skipPlain(p.data, p.pos)
+		if p.pos >= p.length {
+			break
+		}
+		c := p.data[p.pos]
+
+		switch {
+		case c == '"':
+			p.pos++ // skip closing '"'
+			return nil
+		case c == '\\':
+			p.pos++
+			if p.pos >= p.length {
+				return errors.New("unexpected end of JSON input after backslash")
+			}
+			escaped := p.data[p.pos]
+			p.pos++
+
+			switch escaped {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			case 'u':
+				if p.pos+4 > p.length {
+					return errors.New("incomplete unicode escape")
+				}
+				for i := 0; i < 4; i++ {
+					if !isHexDigit(p.data[p.pos+i]) {
+						return fmt.Errorf("invalid unicode escape at position %d", p.pos)
+					}
+				}
+				p.pos += 4
+			default:
+				return fmt.Errorf("invalid escape sequence '\\%c'", escaped)
+			}
+		case c < 0x20:
+			return fmt.Errorf("invalid control character in string at position %d", p.pos)
+		default:
+			p.pos++
+		}
+	}
+
+	return errors.New("unexpected end of JSON input in string")
+}
+
+// isHexDigit reports whether c is an ASCII hexadecimal digit.
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,78 @@
+package fastparser
+
+import (
+	"testing"
+)
+
+func TestSkipValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		offset int
+		want   int
+	}{
+		{"number", `123`, 0, 3},
+		{"leading whitespace", `  true `, 0, 6},
+		{"string with escapes", `"a\"bé" rest`, 0, 8},
+		{"nested object", `{"a": [1, {"b": null}], "c": "d"},`, 0, 33},
+		{"element in array", `[10, [2, 3], 4]`, 4, 11},
+		{"negative float", `-1.5e+10]`, 0, 8},
+		{"empty containers", `[{}, []]`, 0, 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, err := SkipValue([]byte(tt.input), tt.offset)
+			if err != nil {
+				t.Fatalf("SkipValue() error = %v", err)
+			}
+			if end != tt.want {
+				t.Errorf("SkipValue() = %d, want %d", end, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkipValue_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		offset int
+	}{
+		{"empty", ``, 0},
+		{"whitespace only", `   `, 0},
+		{"negative offset", `1`, -1},
+		{"offset past end", `1`, 2},
+		{"unterminated array", `[1, 2`, 0},
+		{"bad escape", `"\x"`, 0},
+		{"short unicode escape", `"\u12"`, 0},
+		{"non-hex unicode escape", `"\uZZZZ"`, 0},
+		{"control character", "\"a\tb\"", 0},
+		{"missing colon", `{"a" 1}`, 0},
+		{"bad literal", `nul`, 0},
+		{"leading zero then garbage", `-`, 0},
+		{"structural character", `]`, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := SkipValue([]byte(tt.input), tt.offset); err == nil {
+				t.Errorf("SkipValue(%q, %d) expected error", tt.input, tt.offset)
+			}
+		})
+	}
+}
+
+func TestSkipValue_DoesNotAllocate(t *testing.T) {
+	data := []byte(`{"users": [{"name": "Al\"ice", "tags": ["x", "y"], "score": 9.75, "ok": true, "z": null}]}`)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := SkipValue(data, 0); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// Only the Parser struct itself may escape.
+	if allocs > 1 {
+		t.Errorf("allocs = %v, want <= 1", allocs)
+	}
+}
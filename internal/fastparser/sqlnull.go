@@ -0,0 +1,46 @@
+//go:build !tinygo
+
+package fastparser
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// IsSQLNull reports whether t is one of the nullable types of database/sql,
+// such as sql.NullString, sql.NullTime, or sql.Null[T]. Each is a struct
+// whose first field holds the value and whose second, Valid, reports
+// whether there is one.
+func IsSQLNull(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t.PkgPath() == "database/sql" &&
+		strings.HasPrefix(t.Name(), "Null") && t.NumField() == 2 &&
+		t.Field(1).Name == "Valid" && t.Field(1).Type.Kind() == reflect.Bool
+}
+
+// scan decodes the value at the current position into s, as the
+// driver.Value database/sql would pass to it: a string, int64, float64,
+// bool, or nil. Objects and arrays are passed as their raw JSON text.
+func (p *Parser) scan(s sql.Scanner) error {
+	var src driver.Value
+	switch p.data[p.pos] {
+	case '{', '[':
+		start := p.pos
+		if err := p.scanValue(); err != nil {
+			return err
+		}
+		src = string(p.data[start:p.pos])
+	default:
+		v, err := p.parseValue()
+		if err != nil {
+			return err
+		}
+		src = v
+	}
+	if err := s.Scan(src); err != nil {
+		return fmt.Errorf("json: %T.Scan: %w", s, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package fastparser
+
+import (
+	"errors"
+	"strings"
+)
+
+// NextTagOption returns the first of opts, the options of a json struct
+// tag after its name, trimmed of spaces, and the options after it. An
+// option is a word such as "omitempty" or a key:value pair such as
+// "transform:encrypt", whose value may be quoted with single or double
+// quotes to hold commas, as in default:'a,b'. Inside quotes a backslash
+// escapes the next character. It does not allocate.
+//
+//	for rest := opts; rest != ""; {
+//	    var opt string
+//	    opt, rest = NextTagOption(rest)
+//	    ...
+//	}
+func NextTagOption(opts string) (opt, rest string) {
+	var quote byte
+	for i := 0; i < len(opts); i++ {
+		switch c := opts[i]; {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '\'' || c == '"':
+			quote = c
+		case c == ',':
+			return strings.TrimSpace(opts[:i]), opts[i+1:]
+		}
+	}
+	return strings.TrimSpace(opts), ""
+}
+
+// TagOptionValue splits an option returned by NextTagOption into its key
+// and value at the first ':', removing the quotes around the value and
+// the backslashes inside them. hasValue is false for an option with no
+// ':'.
+func TagOptionValue(opt string) (key, value string, hasValue bool, err error) {
+	key, value, hasValue = strings.Cut(opt, ":")
+	if !hasValue || value == "" || value[0] != '\'' && value[0] != '"' {
+		return key, value, hasValue, nil
+	}
+	quote := value[0]
+	var b strings.Builder
+	for i := 1; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\\' && i+1 < len(value):
+			i++
+			b.WriteByte(value[i])
+		case c == quote:
+			if i != len(value)-1 {
+				return key, "", true, errors.New("text after closing quote")
+			}
+			return key, b.String(), true, nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return key, "", true, errors.New("unterminated quote")
+}
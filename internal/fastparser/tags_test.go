@@ -0,0 +1,65 @@
+//go:build !tinygo
+
+package fastparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNextTagOption(t *testing.T) {
+	tests := []struct {
+		opts string
+		want []string
+	}{
+		{"omitempty", []string{"omitempty"}},
+		{"omitempty, string", []string{"omitempty", "string"}},
+		{"default:'a,b',required", []string{"default:'a,b'", "required"}},
+		{`format:"x,\"y,z",omitempty`, []string{`format:"x,\"y,z"`, "omitempty"}},
+		{"default:'a,b", []string{"default:'a,b"}},
+		{",omitempty", []string{"", "omitempty"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for rest := tt.opts; rest != ""; {
+			var opt string
+			opt, rest = NextTagOption(rest)
+			got = append(got, opt)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NextTagOption(%q) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestTagOptionValue(t *testing.T) {
+	tests := []struct {
+		opt, key, value string
+		hasValue, err   bool
+	}{
+		{opt: "omitempty", key: "omitempty"},
+		{opt: "transform:encrypt", key: "transform", value: "encrypt", hasValue: true},
+		{opt: "transform:", key: "transform", hasValue: true},
+		{opt: "default:'a,b'", key: "default", value: "a,b", hasValue: true},
+		{opt: `format:"x\"y"`, key: "format", value: `x"y`, hasValue: true},
+		{opt: "format:'15:04'", key: "format", value: "15:04", hasValue: true},
+		{opt: "default:'a,b", key: "default", hasValue: true, err: true},
+		{opt: "default:'a'b", key: "default", hasValue: true, err: true},
+	}
+	for _, tt := range tests {
+		key, value, hasValue, err := TagOptionValue(tt.opt)
+		if key != tt.key || value != tt.value || hasValue != tt.hasValue || (err != nil) != tt.err {
+			t.Errorf("TagOptionValue(%q) = %q, %q, %v, %v", tt.opt, key, value, hasValue, err)
+		}
+	}
+}
+
+func TestTagHasOptionQuoted(t *testing.T) {
+	tag := "name,default:'x,required',transform:'a,b'"
+	if tagHasOption(tag, "required") {
+		t.Error("tagHasOption found an option inside a quoted value")
+	}
+	if got := tagTransform(tag); got != "a,b" {
+		t.Errorf("tagTransform = %q, want %q", got, "a,b")
+	}
+}
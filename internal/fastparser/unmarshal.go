@@ -1,16 +1,19 @@
+//go:build !tinygo
+
 package fastparser
 
 import (
+	"database/sql"
+	"encoding"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
+	"strings"
 )
 
-// Unmarshaler is the interface implemented by types that can unmarshal a JSON description of themselves.
-type Unmarshaler interface {
-	UnmarshalJSON([]byte) error
-}
-
 // Unmarshal parses JSON and unmarshals it into the value pointed to by v.
 // This is the fast path that bypasses AST construction.
 func Unmarshal(data []byte, v interface{}) error {
@@ -34,11 +37,104 @@ func Unmarshal(data []byte, v interface{}) error {
 	}
 
 	p := NewParser(data)
+	defer p.releaseKeys()
+	return p.unmarshalValue(rv.Elem())
+}
+
+// UnmarshalWithOptions is like Unmarshal but applies opts.
+func UnmarshalWithOptions(data []byte, v interface{}, opts Options) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || v == nil {
+		return errors.New("json: Unmarshal(nil)")
+	}
+
+	if rv.Kind() != reflect.Ptr {
+		return errors.New("json: Unmarshal(non-pointer " + rv.Type().String() + ")")
+	}
+
+	if rv.IsNil() {
+		return errors.New("json: Unmarshal(nil " + rv.Type().String() + ")")
+	}
+
+	if rv.Type().Implements(reflect.TypeOf((*Unmarshaler)(nil)).Elem()) {
+		unmarshaler := rv.Interface().(Unmarshaler)
+		if err := unmarshaler.UnmarshalJSON(data); err != nil {
+			return err
+		}
+		if opts.Validator != nil {
+			p := NewParser(data)
+			p.skipWhitespace()
+			start := p.pos
+			if err := p.scanValue(); err != nil {
+				return err
+			}
+			opts.Validator.Done(p.data[start:p.pos], false)
+		}
+		return nil
+	}
+
+	p := NewParser(data)
+	defer p.releaseKeys()
+	p.opts = &opts
+	p.validator = opts.Validator
 	return p.unmarshalValue(rv.Elem())
 }
 
-// unmarshalValue unmarshals JSON into a reflect.Value.
+// unmarshalValue unmarshals JSON into a reflect.Value, reporting it to the
+// current validator if there is one.
 func (p *Parser) unmarshalValue(rv reflect.Value) error {
+	if p.validator == nil {
+		return p.decodeValue(rv)
+	}
+	return p.validated(func() error { return p.decodeValue(rv) })
+}
+
+// validated runs decode on the value at the current position and then
+// reports the value to the current validator.
+func (p *Parser) validated(decode func() error) error {
+	v := p.validator
+	p.skipWhitespace()
+	start := p.pos
+	p.walked = false
+	if err := decode(); err != nil {
+		return err
+	}
+	walked := p.walked
+	p.walked = false
+	v.Done(p.data[start:p.pos], walked)
+	return nil
+}
+
+// member switches to the validator for the value of the named member and
+// returns the current one, which the caller restores afterwards.
+func (p *Parser) member(key string) Validator {
+	parent := p.validator
+	if parent != nil {
+		p.validator = parent.Member(key)
+	}
+	return parent
+}
+
+// element is like member for the array element at index.
+func (p *Parser) element(index int) Validator {
+	parent := p.validator
+	if parent != nil {
+		p.validator = parent.Element(index)
+	}
+	return parent
+}
+
+// skipChecked skips the value at the current position, reporting it to the
+// current validator as a whole.
+func (p *Parser) skipChecked() error {
+	if p.validator == nil {
+		return p.skipValue()
+	}
+	return p.validated(p.skipValue)
+}
+
+// decodeValue unmarshals JSON into a reflect.Value.
+func (p *Parser) decodeValue(rv reflect.Value) error {
 	p.skipWhitespace()
 	if p.pos >= p.length {
 		return errors.New("unexpected end of JSON input")
@@ -46,14 +142,29 @@ func (p *Parser) unmarshalValue(rv reflect.Value) error {
 
 	c := p.data[p.pos]
 
+	if p.opts != nil && p.opts.Types != nil {
+		if decode, ok := p.opts.Types[rv.Type()]; ok {
+			start := p.pos
+			if err := p.scanValue(); err != nil {
+				return err
+			}
+			return decode(p.data[start:p.pos], rv)
+		}
+	}
+
 	// Handle null
 	if c == 'n' {
 		if err := p.expectLiteral("null"); err != nil {
 			return err
 		}
-		// Set to zero value
-		rv.Set(reflect.Zero(rv.Type()))
-		return nil
+		return p.setNull(rv)
+	}
+
+	// Interfaces with a registered resolver decode into the type it selects
+	if rv.Kind() == reflect.Interface && p.opts != nil {
+		if resolve, ok := p.opts.Interfaces[rv.Type()]; ok {
+			return p.unmarshalResolved(rv, resolve)
+		}
 	}
 
 	// Handle interface{} specially - parse to native Go types
@@ -71,16 +182,60 @@ func (p *Parser) unmarshalValue(rv reflect.Value) error {
 		if rv.IsNil() {
 			rv.Set(reflect.New(rv.Type().Elem()))
 		}
-		return p.unmarshalValue(rv.Elem())
+		return p.decodeValue(rv.Elem())
+	}
+
+	// Nested values whose pointer type implements Unmarshaler decode
+	// themselves. Only named types can have methods, which keeps the check
+	// off the path for plain strings, numbers, slices, and maps.
+	if rv.CanAddr() && rv.Kind() != reflect.Interface && rv.Type().PkgPath() != "" {
+		switch u := rv.Addr().Interface().(type) {
+		case Slot:
+			return p.decodeValue(reflect.ValueOf(u.JSONSlot(false)).Elem())
+		case Unmarshaler:
+			start := p.pos
+			if err := p.scanValue(); err != nil {
+				return err
+			}
+			return u.UnmarshalJSON(p.data[start:p.pos])
+		case sql.Scanner:
+			// encoding/json decodes them as the structs they are
+			if IsSQLNull(rv.Type()) && (p.opts == nil || !p.opts.Stdlib) {
+				if err := p.decodeValue(rv.Field(0)); err != nil {
+					return err
+				}
+				rv.Field(1).SetBool(true)
+				return nil
+			}
+			if p.opts != nil && p.opts.Scanners {
+				return p.scan(u)
+			}
+		}
+		if p.opts != nil && p.opts.Stdlib {
+			if u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+				return p.unmarshalText(u, rv.Type())
+			}
+		}
 	}
 
 	// Route based on JSON type
 	switch c {
-	case '{':
-		return p.unmarshalObject(rv)
-	case '[':
-		return p.unmarshalArray(rv)
+	case '{', '[':
+		if err := p.enter(); err != nil {
+			return err
+		}
+		var err error
+		if c == '{' {
+			err = p.unmarshalObject(rv)
+		} else {
+			err = p.unmarshalArray(rv)
+		}
+		p.depth--
+		return err
 	case '"':
+		if p.opts != nil && (p.opts.Int64AsString && IsWideInt(rv.Kind()) || p.opts.CoerceStrings && Quotable(rv.Type())) {
+			return p.unmarshalQuoted(rv)
+		}
 		return p.unmarshalString(rv)
 	case 't', 'f':
 		return p.unmarshalBool(rv)
@@ -91,6 +246,72 @@ func (p *Parser) unmarshalValue(rv reflect.Value) error {
 	}
 }
 
+// setNull stores the null literal just read into rv.
+func (p *Parser) setNull(rv reflect.Value) error {
+	// Values that decode themselves see null too, so that they can tell
+	// it apart from an absent member
+	if rv.CanAddr() && rv.Kind() != reflect.Ptr && rv.Kind() != reflect.Interface && rv.Type().PkgPath() != "" {
+		switch u := rv.Addr().Interface().(type) {
+		case Slot:
+			if target := u.JSONSlot(true); target != nil {
+				return p.setNull(reflect.ValueOf(target).Elem())
+			}
+			return nil
+		case Unmarshaler:
+			return u.UnmarshalJSON(p.data[p.pos-len("null") : p.pos])
+		case sql.Scanner:
+			if IsSQLNull(rv.Type()) && (p.opts == nil || !p.opts.Stdlib) {
+				rv.Set(reflect.Zero(rv.Type()))
+				return nil
+			}
+			if p.opts != nil && p.opts.Scanners {
+				return u.Scan(nil)
+			}
+		}
+	}
+	if p.opts != nil && p.opts.RejectNull && !NullableKind(rv.Kind()) {
+		return fmt.Errorf("json: cannot unmarshal null into Go value of type %s", rv.Type())
+	}
+	// encoding/json leaves values that cannot be nil as they are
+	if p.opts != nil && p.opts.Stdlib && !NullableKind(rv.Kind()) {
+		return nil
+	}
+	// Set to zero value
+	rv.Set(reflect.Zero(rv.Type()))
+	return nil
+}
+
+// unmarshalText decodes the value at the current position, which must be
+// a string, with the UnmarshalText method of u, a value of type t, as
+// encoding/json does under Options.Stdlib.
+func (p *Parser) unmarshalText(u encoding.TextUnmarshaler, t reflect.Type) error {
+	if c := p.data[p.pos]; c != '"' {
+		return fmt.Errorf("json: cannot unmarshal %s into Go value of type %s", jsonKind(c), t)
+	}
+	s, err := p.parseString()
+	if err != nil {
+		return err
+	}
+	return u.UnmarshalText([]byte(s))
+}
+
+// jsonKind names the kind of JSON value that starts with c, for errors.
+func jsonKind(c byte) string {
+	switch c {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "bool"
+	case 'n':
+		return "null"
+	}
+	return "number"
+}
+
 // unmarshalObject unmarshals a JSON object.
 func (p *Parser) unmarshalObject(rv reflect.Value) error {
 	if p.pos >= p.length || p.data[p.pos] != '{' {
@@ -111,44 +332,21 @@ func (p *Parser) unmarshalObject(rv reflect.Value) error {
 // unmarshalStruct unmarshals a JSON object into a struct.
 func (p *Parser) unmarshalStruct(rv reflect.Value) error {
 	structType := rv.Type()
-
-	// Build field map
-	fieldMap := make(map[string]int)
-	for i := 0; i < structType.NumField(); i++ {
-		field := structType.Field(i)
-		if field.PkgPath != "" { // Skip unexported fields
-			continue
-		}
-
-		// Check JSON tag
-		tag := field.Tag.Get("json")
-		if tag == "-" {
-			// Field should be ignored
-			continue
-		}
-
-		// Get JSON name from tag or use field name
-		jsonName := field.Name
-		if tag != "" {
-			jsonName = tag
-			// Handle "name,omitempty" format
-			for idx := 0; idx < len(tag); idx++ {
-				if tag[idx] == ',' {
-					jsonName = tag[:idx]
-					break
-				}
-			}
-		}
-
-		fieldMap[jsonName] = i
+	var fields *StructFields
+	if p.opts != nil && p.opts.Stdlib {
+		fields = NewStdlibStructFields(structType)
+	} else {
+		fields = NewStructFields(structType)
 	}
+	required := fields.Required
 
 	p.skipWhitespace()
 
 	// Handle empty object
 	if p.pos < p.length && p.data[p.pos] == '}' {
 		p.pos++
-		return nil
+		p.walked = true
+		return CheckRequired(structType, required)
 	}
 
 	for {
@@ -159,7 +357,7 @@ func (p *Parser) unmarshalStruct(rv reflect.Value) error {
 			return errors.New("expected string key in object")
 		}
 
-		key, err := p.parseString()
+		key, err := p.parseKey()
 		if err != nil {
 			return err
 		}
@@ -175,17 +373,38 @@ func (p *Parser) unmarshalStruct(rv reflect.Value) error {
 		p.skipWhitespace()
 
 		// Unmarshal value into struct field if it exists
-		if fieldIdx, ok := fieldMap[key]; ok {
-			fieldVal := rv.Field(fieldIdx)
-			if err := p.unmarshalValue(fieldVal); err != nil {
-				return err
+		parent := p.member(key)
+		if fieldIdx, ok := fields.Lookup(key, p.opts != nil && p.opts.Stdlib); ok {
+			delete(required, fieldIdx)
+			fieldVal, err := fields.Field(rv, fieldIdx)
+			if err != nil {
+				return WithKey(err, key)
+			}
+			if name, ok := fields.Transforms[fieldIdx]; !ok {
+				if fields.Quoted[fieldIdx] && p.pos < p.length && p.data[p.pos] == '"' {
+					err = p.unmarshalQuotedChecked(fieldVal)
+				} else {
+					err = p.unmarshalValue(fieldVal)
+				}
+			} else if p.validator != nil {
+				err = p.validated(func() error { return p.unmarshalTransformed(fieldVal, name, key) })
+			} else {
+				err = p.unmarshalTransformed(fieldVal, name, key)
+			}
+			if err != nil {
+				return WithKey(err, key)
+			}
+		} else if fields.Unknown >= 0 {
+			if err := p.unmarshalUnknown(rv.Field(fields.Unknown), key); err != nil {
+				return WithKey(err, key)
 			}
 		} else {
 			// Skip unknown field
-			if err := p.skipValue(); err != nil {
+			if err := p.skipChecked(); err != nil {
 				return err
 			}
 		}
+		p.validator = parent
 
 		p.skipWhitespace()
 
@@ -196,7 +415,8 @@ func (p *Parser) unmarshalStruct(rv reflect.Value) error {
 
 		if p.data[p.pos] == '}' {
 			p.pos++
-			return nil
+			p.walked = true
+			return CheckRequired(structType, required)
 		}
 
 		if p.data[p.pos] != ',' {
@@ -206,18 +426,338 @@ func (p *Parser) unmarshalStruct(rv reflect.Value) error {
 	}
 }
 
+// StructFields maps the members of a JSON object to the fields of a struct
+// type, as read from the fields' json tags. Fields are identified by their
+// index in the struct, or with Paths, by their position in FlatFields.
+type StructFields struct {
+	Index      map[string]int // JSON name -> field
+	Transforms map[int]string // field -> transform name, if any
+	Required   map[int]string // field -> JSON name, for required fields
+	Quoted     map[int]bool   // fields with the ",string" or ",coerce" option
+	Unknown    int            // index of the field with the ",unknown" option, or -1
+	Paths      [][]int        // field -> index sequence, if from FlatFields
+}
+
+// Lookup returns the field that the member named key decodes into. If fold
+// is set and no field is named key, it falls back to the first field, in
+// declaration order, whose name matches key case-insensitively, as
+// encoding/json does.
+func (f *StructFields) Lookup(key string, fold bool) (int, bool) {
+	if i, ok := f.Index[key]; ok || !fold {
+		return i, ok
+	}
+	index := -1
+	for name, i := range f.Index {
+		if (index < 0 || i < index) && strings.EqualFold(name, key) {
+			index = i
+		}
+	}
+	return index, index >= 0
+}
+
+// Field returns the field i of the struct rv. A field promoted from an
+// embedded struct pointer that is nil is reached by allocating the struct,
+// which is an error if its type is unexported, as in encoding/json.
+func (f *StructFields) Field(rv reflect.Value, i int) (reflect.Value, error) {
+	if f.Paths == nil {
+		return rv.Field(i), nil
+	}
+	path := f.Paths[i]
+	for _, j := range path[:len(path)-1] {
+		rv = rv.Field(j)
+		if rv.Kind() != reflect.Ptr {
+			continue
+		}
+		if rv.IsNil() {
+			if !rv.CanSet() {
+				return reflect.Value{}, fmt.Errorf("json: cannot set embedded pointer to unexported struct: %v", rv.Type().Elem())
+			}
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	return rv.Field(path[len(path)-1]), nil
+}
+
+// NewStructFields reads the fields of the struct type t. The maps are
+// built afresh on every call, so callers may delete from Required as
+// members are seen.
+func NewStructFields(t reflect.Type) *StructFields {
+	f := &StructFields{Index: make(map[string]int), Unknown: -1}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" { // Skip unexported fields
+			continue
+		}
+
+		// Check JSON tag
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			// Field should be ignored
+			continue
+		}
+		if CapturesUnknown(field) {
+			f.Unknown = i
+			continue
+		}
+
+		// Get JSON name from tag or use field name
+		jsonName := field.Name
+		if tag != "" {
+			jsonName = tag
+			// Handle "name,omitempty" format
+			for idx := 0; idx < len(tag); idx++ {
+				if tag[idx] == ',' {
+					jsonName = tag[:idx]
+					break
+				}
+			}
+		}
+		f.add(i, jsonName, field)
+	}
+	return f
+}
+
+// NewStdlibStructFields is NewStructFields for decoding as encoding/json
+// does, with the fields of embedded structs promoted as FlatFields
+// describes. The ",unknown" option is honored on the struct's own fields.
+func NewStdlibStructFields(t reflect.Type) *StructFields {
+	if !HasEmbedded(t) {
+		return NewStructFields(t)
+	}
+	flat := FlatFields(t)
+	f := &StructFields{Index: make(map[string]int, len(flat)), Unknown: -1, Paths: make([][]int, len(flat))}
+	for i, ff := range flat {
+		f.Paths[i] = ff.Index
+		if len(ff.Index) == 1 && CapturesUnknown(ff.Field) {
+			f.Unknown = ff.Index[0]
+			continue
+		}
+		f.add(i, ff.Name, ff.Field)
+	}
+	return f
+}
+
+// add records the field i, named jsonName, with the options of its tag.
+func (f *StructFields) add(i int, jsonName string, field reflect.StructField) {
+	tag := field.Tag.Get("json")
+	f.Index[jsonName] = i
+	if name := tagTransform(tag); name != "" {
+		if f.Transforms == nil {
+			f.Transforms = make(map[int]string)
+		}
+		f.Transforms[i] = name
+	}
+	if tagHasOption(tag, "required") {
+		if f.Required == nil {
+			f.Required = make(map[int]string)
+		}
+		f.Required[i] = jsonName
+	}
+	if (tagHasOption(tag, "string") || tagHasOption(tag, "coerce")) && Quotable(field.Type) {
+		if f.Quoted == nil {
+			f.Quoted = make(map[int]bool)
+		}
+		f.Quoted[i] = true
+	}
+}
+
+// unmarshalUnknown decodes the value at the current position, that of a
+// member named key which matches no struct field, into a new entry of m, the
+// field with the ",unknown" option.
+func (p *Parser) unmarshalUnknown(m reflect.Value, key string) error {
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if err := p.unmarshalValue(elem); err != nil {
+		return err
+	}
+	m.SetMapIndex(reflect.ValueOf(key).Convert(m.Type().Key()), elem)
+	return nil
+}
+
+// CapturesUnknown reports whether field receives the object members that
+// match no other field of its struct: it has the ",unknown" tag option and
+// is a map with string keys. The option is ignored on fields of other types.
+func CapturesUnknown(field reflect.StructField) bool {
+	return tagHasOption(field.Tag.Get("json"), "unknown") &&
+		field.Type.Kind() == reflect.Map && field.Type.Key().Kind() == reflect.String
+}
+
+// unmarshalTransformed passes the raw JSON of the value at the current
+// position through the named transform and decodes the result into rv.
+func (p *Parser) unmarshalTransformed(rv reflect.Value, name, field string) error {
+	var transform func(field string, data []byte) ([]byte, error)
+	if p.opts != nil {
+		transform = p.opts.Transforms[name]
+	}
+	if transform == nil {
+		return fmt.Errorf("json: field %q requires transform %q, which is not registered", field, name)
+	}
+
+	start := p.pos
+	if err := p.scanValue(); err != nil {
+		return err
+	}
+
+	out, err := transform(field, p.data[start:p.pos:p.pos])
+	if err != nil {
+		return fmt.Errorf("json: transform %q on field %q: %w", name, field, err)
+	}
+
+	sub := &Parser{data: out, length: len(out), opts: p.opts}
+	if err := sub.unmarshalValue(rv); err != nil {
+		return fmt.Errorf("json: transform %q on field %q: %w", name, field, err)
+	}
+	sub.skipWhitespace()
+	if sub.pos < sub.length {
+		return fmt.Errorf("json: transform %q on field %q: unexpected data after JSON value", name, field)
+	}
+	return nil
+}
+
+// Quotable reports whether values of type t, or of the type t points to, may
+// be written as JSON strings under the ",string" and ",coerce" tag options:
+// integers, floats, and bools.
+func Quotable(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Bool:
+		return true
+	}
+	return false
+}
+
+// IsWideInt reports whether k is a 64-bit integer kind, which
+// Options.Int64AsString lets be written as a JSON string: int64 and uint64,
+// and int and uint, which are 64 bits on the platforms that matter.
+func IsWideInt(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// UnmarshalQuoted decodes s, the contents of a JSON string holding a number
+// or bool, into rv.
+func UnmarshalQuoted(s string, rv reflect.Value) error {
+	return unmarshalQuoted(s, rv, nil)
+}
+
+func unmarshalQuoted(s string, rv reflect.Value, opts *Options) error {
+	sub := &Parser{data: []byte(s), length: len(s), opts: opts}
+	err := sub.decodeValue(rv)
+	sub.skipWhitespace()
+	if err != nil || sub.pos < sub.length {
+		return fmt.Errorf("json: cannot unmarshal string %q into Go value of type %s", s, rv.Type())
+	}
+	return nil
+}
+
+// unmarshalQuoted decodes the JSON string at the current position, which
+// holds a number or bool, into rv.
+func (p *Parser) unmarshalQuoted(rv reflect.Value) error {
+	s, err := p.parseString()
+	if err != nil {
+		return err
+	}
+	return unmarshalQuoted(s, rv, p.opts)
+}
+
+// unmarshalQuotedChecked is unmarshalQuoted reporting to the validator.
+func (p *Parser) unmarshalQuotedChecked(rv reflect.Value) error {
+	if p.validator == nil {
+		return p.unmarshalQuoted(rv)
+	}
+	return p.validated(func() error { return p.unmarshalQuoted(rv) })
+}
+
+// CheckRequired returns an error naming the first missing required field in
+// declaration order. missing maps field indexes to JSON names.
+func CheckRequired(structType reflect.Type, missing map[int]string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	first := -1
+	for i := range missing {
+		if first < 0 || i < first {
+			first = i
+		}
+	}
+	return fmt.Errorf("json: missing required field %q in Go value of type %s", missing[first], structType)
+}
+
+// unmarshalResolved decodes the value at the current position into a new
+// value of the concrete type chosen by resolve and stores it in the
+// interface rv.
+func (p *Parser) unmarshalResolved(rv reflect.Value, resolve func([]byte) (reflect.Type, error)) error {
+	start := p.pos
+	if err := p.scanValue(); err != nil {
+		return err
+	}
+	raw := p.data[start:p.pos]
+
+	t, err := resolve(raw)
+	if err != nil {
+		return err
+	}
+	if !t.Implements(rv.Type()) {
+		return fmt.Errorf("json: resolved type %s does not implement %s", t, rv.Type())
+	}
+
+	v := reflect.New(t).Elem()
+	sub := &Parser{data: raw, length: len(raw), opts: p.opts}
+	if err := sub.unmarshalValue(v); err != nil {
+		return err
+	}
+	rv.Set(v)
+	return nil
+}
+
+// tagHasOption reports whether a json struct tag lists the given option
+// after its name.
+func tagHasOption(tag, option string) bool {
+	_, opts, _ := strings.Cut(tag, ",")
+	for opts != "" {
+		var opt string
+		if opt, opts = NextTagOption(opts); opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// tagTransform returns the name from a "transform:<name>" option in a json
+// struct tag, or "" if there is none.
+func tagTransform(tag string) string {
+	_, opts, _ := strings.Cut(tag, ",")
+	for opts != "" {
+		var opt string
+		opt, opts = NextTagOption(opts)
+		if key, name, _, err := TagOptionValue(opt); key == "transform" && err == nil {
+			return name
+		}
+	}
+	return ""
+}
+
 // unmarshalMap unmarshals a JSON object into a map.
 func (p *Parser) unmarshalMap(rv reflect.Value) error {
 	mapType := rv.Type()
-
-	// Only support string keys
-	if mapType.Key().Kind() != reflect.String {
+	stdlib := p.opts != nil && p.opts.Stdlib
+	if !MapKeySupported(mapType.Key(), stdlib) {
 		return fmt.Errorf("json: unsupported map key type %s", mapType.Key())
 	}
 
 	// Create the map if nil
 	if rv.IsNil() {
-		rv.Set(reflect.MakeMap(mapType))
+		rv.Set(reflect.MakeMapWithSize(mapType, elementCounts.Get(mapType)))
 	}
 
 	valueType := mapType.Elem()
@@ -227,10 +767,12 @@ func (p *Parser) unmarshalMap(rv reflect.Value) error {
 	// Handle empty object
 	if p.pos < p.length && p.data[p.pos] == '}' {
 		p.pos++
+		p.walked = true
+		elementCounts.Observe(mapType, 0)
 		return nil
 	}
 
-	for {
+	for n := 1; ; n++ {
 		p.skipWhitespace()
 
 		// Parse key
@@ -238,7 +780,7 @@ func (p *Parser) unmarshalMap(rv reflect.Value) error {
 			return errors.New("expected string key in object")
 		}
 
-		key, err := p.parseString()
+		key, err := p.parseKey()
 		if err != nil {
 			return err
 		}
@@ -255,12 +797,18 @@ func (p *Parser) unmarshalMap(rv reflect.Value) error {
 
 		// Create value and unmarshal
 		elemVal := reflect.New(valueType).Elem()
+		parent := p.member(key)
 		if err := p.unmarshalValue(elemVal); err != nil {
-			return err
+			return WithKey(err, key)
 		}
+		p.validator = parent
 
 		// Set map entry
-		rv.SetMapIndex(reflect.ValueOf(key), elemVal)
+		keyVal, err := MapKey(mapType.Key(), key, stdlib)
+		if err != nil {
+			return err
+		}
+		rv.SetMapIndex(keyVal, elemVal)
 
 		p.skipWhitespace()
 
@@ -271,6 +819,8 @@ func (p *Parser) unmarshalMap(rv reflect.Value) error {
 
 		if p.data[p.pos] == '}' {
 			p.pos++
+			p.walked = true
+			elementCounts.Observe(mapType, n)
 			return nil
 		}
 
@@ -281,6 +831,62 @@ func (p *Parser) unmarshalMap(rv reflect.Value) error {
 	}
 }
 
+// MapKeySupported reports whether objects can be decoded into maps with
+// keys of type kt: string kinds, and under Options.Stdlib also integers and
+// types whose pointers implement encoding.TextUnmarshaler, as in
+// encoding/json.
+func MapKeySupported(kt reflect.Type, stdlib bool) bool {
+	if kt.Kind() == reflect.String {
+		return true
+	}
+	if !stdlib {
+		return false
+	}
+	switch kt.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return reflect.PointerTo(kt).Implements(textUnmarshalerType)
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// MapKey returns the map key of type kt, which MapKeySupported accepts,
+// for the member name key. Under stdlib, keys are decoded as encoding/json
+// decodes them: with UnmarshalText if the key type has the method, and as
+// integers for integer kinds.
+func MapKey(kt reflect.Type, key string, stdlib bool) (reflect.Value, error) {
+	if stdlib && reflect.PointerTo(kt).Implements(textUnmarshalerType) {
+		kv := reflect.New(kt)
+		if err := kv.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(key)); err != nil {
+			return reflect.Value{}, err
+		}
+		return kv.Elem(), nil
+	}
+	switch kt.Kind() {
+	case reflect.String:
+		if kt == stringType {
+			return reflect.ValueOf(key), nil
+		}
+		return reflect.ValueOf(key).Convert(kt), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(key, 10, kt.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("json: cannot unmarshal number %s into Go value of type %s", key, kt)
+		}
+		return reflect.ValueOf(n).Convert(kt), nil
+	default:
+		n, err := strconv.ParseUint(key, 10, kt.Bits())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("json: cannot unmarshal number %s into Go value of type %s", key, kt)
+		}
+		return reflect.ValueOf(n).Convert(kt), nil
+	}
+}
+
+var stringType = reflect.TypeOf("")
+
 // unmarshalArray unmarshals a JSON array.
 func (p *Parser) unmarshalArray(rv reflect.Value) error {
 	if p.pos >= p.length || p.data[p.pos] != '[' {
@@ -298,13 +904,11 @@ func (p *Parser) unmarshalArray(rv reflect.Value) error {
 	}
 }
 
-// unmarshalSlice unmarshals a JSON array into a slice.
+// unmarshalSlice unmarshals a JSON array into a slice. Elements are decoded
+// in place into a slice sized from elementCounts, which grows only if the
+// array is longer than usual for its type.
 func (p *Parser) unmarshalSlice(rv reflect.Value) error {
 	sliceType := rv.Type()
-	elemType := sliceType.Elem()
-
-	// Collect elements
-	var elements []reflect.Value
 
 	p.skipWhitespace()
 
@@ -312,19 +916,28 @@ func (p *Parser) unmarshalSlice(rv reflect.Value) error {
 	if p.pos < p.length && p.data[p.pos] == ']' {
 		p.pos++
 		rv.Set(reflect.MakeSlice(sliceType, 0, 0))
+		p.walked = true
+		elementCounts.Observe(sliceType, 0)
 		return nil
 	}
 
-	for {
+	// Grow and SetLen need an addressable slice
+	slice := reflect.New(sliceType).Elem()
+	slice.Set(reflect.MakeSlice(sliceType, 0, elementCounts.Get(sliceType)))
+
+	for n := 0; ; {
 		p.skipWhitespace()
 
-		// Create element and unmarshal
-		elemVal := reflect.New(elemType).Elem()
-		if err := p.unmarshalValue(elemVal); err != nil {
-			return err
+		if n == slice.Cap() {
+			slice.Grow(1)
 		}
-
-		elements = append(elements, elemVal)
+		slice.SetLen(n + 1)
+		parent := p.element(n)
+		if err := p.unmarshalValue(slice.Index(n)); err != nil {
+			return WithIndex(err, n)
+		}
+		p.validator = parent
+		n++
 
 		p.skipWhitespace()
 
@@ -335,6 +948,7 @@ func (p *Parser) unmarshalSlice(rv reflect.Value) error {
 
 		if p.data[p.pos] == ']' {
 			p.pos++
+			elementCounts.Observe(sliceType, n)
 			break
 		}
 
@@ -344,13 +958,9 @@ func (p *Parser) unmarshalSlice(rv reflect.Value) error {
 		p.pos++
 	}
 
-	// Create slice and copy elements
-	slice := reflect.MakeSlice(sliceType, len(elements), len(elements))
-	for i, elem := range elements {
-		slice.Index(i).Set(elem)
-	}
 	rv.Set(slice)
 
+	p.walked = true
 	return nil
 }
 
@@ -363,6 +973,7 @@ func (p *Parser) unmarshalFixedArray(rv reflect.Value) error {
 	// Handle empty array
 	if p.pos < p.length && p.data[p.pos] == ']' {
 		p.pos++
+		p.walked = true
 		return nil
 	}
 
@@ -376,9 +987,11 @@ func (p *Parser) unmarshalFixedArray(rv reflect.Value) error {
 
 		// Unmarshal element
 		elemVal := rv.Index(idx)
+		parent := p.element(idx)
 		if err := p.unmarshalValue(elemVal); err != nil {
-			return err
+			return WithIndex(err, idx)
 		}
+		p.validator = parent
 
 		idx++
 
@@ -391,6 +1004,7 @@ func (p *Parser) unmarshalFixedArray(rv reflect.Value) error {
 
 		if p.data[p.pos] == ']' {
 			p.pos++
+			p.walked = true
 			return nil
 		}
 
@@ -408,9 +1022,20 @@ func (p *Parser) unmarshalString(rv reflect.Value) error {
 		return err
 	}
 
+	if p.opts != nil && p.opts.Stdlib && rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("json: cannot unmarshal string into Go value of type %s: %v", rv.Type(), err)
+		}
+		rv.SetBytes(b)
+		return nil
+	}
 	if rv.Kind() != reflect.String {
 		return fmt.Errorf("json: cannot unmarshal string into Go value of type %s", rv.Type())
 	}
+	if rv.Type() == NumberType && !IsNumber(s) {
+		return fmt.Errorf("json: invalid number literal %q for Go value of type %s", s, rv.Type())
+	}
 
 	rv.SetString(s)
 	return nil
@@ -418,7 +1043,33 @@ func (p *Parser) unmarshalString(rv reflect.Value) error {
 
 // unmarshalNumber unmarshals a JSON number.
 func (p *Parser) unmarshalNumber(rv reflect.Value) error {
-	num, err := p.parseNumber()
+	start := p.pos
+	isFloat, err := p.scanNumber()
+	if err != nil {
+		return err
+	}
+	text := p.data[start:p.pos]
+	if rv.Type() == NumberType {
+		rv.SetString(string(text))
+		return nil
+	}
+	if p.opts != nil && p.opts.StrictNumbers {
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			if err := checkExact(text, rv); err != nil {
+				return err
+			}
+		}
+	}
+	if !isFloat {
+		if done, err := setInteger(text, rv); done {
+			return err
+		}
+	}
+
+	num, err := numberValue(text, isFloat)
 	if err != nil {
 		return err
 	}
@@ -430,7 +1081,7 @@ func (p *Parser) unmarshalNumber(rv reflect.Value) error {
 		case int64:
 			i = v
 		case float64:
-			if v != float64(int64(v)) {
+			if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
 				return fmt.Errorf("json: cannot unmarshal number %v into Go value of type %s", v, rv.Type())
 			}
 			i = int64(v)
@@ -453,7 +1104,7 @@ func (p *Parser) unmarshalNumber(rv reflect.Value) error {
 			}
 			u = uint64(v)
 		case float64:
-			if v < 0 || v != float64(uint64(v)) {
+			if v < 0 || v != math.Trunc(v) || v >= math.MaxUint64 {
 				return fmt.Errorf("json: cannot unmarshal number %v into Go value of type %s", v, rv.Type())
 			}
 			u = uint64(v)
@@ -489,6 +1140,50 @@ func (p *Parser) unmarshalNumber(rv reflect.Value) error {
 	}
 }
 
+// setInteger stores the integer text, which scanNumber accepted, in rv if
+// rv is an integer kind and the digits can be read without strconv, or, for
+// an unsigned kind, if the text is a uint64 beyond int64 range. Integers
+// read that way are range-checked exactly, never through a float64. It
+// reports whether it handled the value, leaving everything else to the
+// general conversion.
+func setInteger(text []byte, rv reflect.Value) (bool, error) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := parseInt64(text)
+		if !ok {
+			if _, _, read := parseDigits(text); read {
+				return true, fmt.Errorf("json: value %s overflows %s", text, rv.Type())
+			}
+			return false, nil
+		}
+		if rv.OverflowInt(i) {
+			return true, fmt.Errorf("json: value %d overflows %s", i, rv.Type())
+		}
+		rv.SetInt(i)
+		return true, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, neg, ok := parseDigits(text)
+		if !ok && !neg {
+			var err error
+			u, err = strconv.ParseUint(string(text), 10, 64)
+			ok = err == nil
+		}
+		if !ok || neg && u > 1<<63 {
+			return false, nil
+		}
+		if neg && u != 0 {
+			return true, fmt.Errorf("json: cannot unmarshal negative number into Go value of type %s", rv.Type())
+		}
+		if rv.OverflowUint(u) {
+			return true, fmt.Errorf("json: value %d overflows %s", u, rv.Type())
+		}
+		rv.SetUint(u)
+		return true, nil
+	}
+	return false, nil
+}
+
 // unmarshalBool unmarshals a JSON boolean.
 func (p *Parser) unmarshalBool(rv reflect.Value) error {
 	var b bool
@@ -513,9 +1208,9 @@ func (p *Parser) unmarshalBool(rv reflect.Value) error {
 }
 
 // skipValue skips over a JSON value (used for unknown struct fields).
+// The value is validated but not decoded.
 func (p *Parser) skipValue() error {
-	_, err := p.parseValue()
-	return err
+	return p.scanValue()
 }
 
 // expectLiteral expects and consumes a specific literal string.
@@ -531,3 +1226,110 @@ func (p *Parser) expectLiteral(literal string) error {
 	p.pos += len(literal)
 	return nil
 }
+
+// UnmarshalFields decodes only the requested top-level members of a JSON
+// object. Each value in fields must be a non-nil pointer; members whose key
+// is not in fields are validated and skipped without being decoded.
+func UnmarshalFields(data []byte, fields map[string]interface{}) error {
+	for key, target := range fields {
+		rv := reflect.ValueOf(target)
+		if !rv.IsValid() || rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return fmt.Errorf("json: UnmarshalFields target for %q must be a non-nil pointer", key)
+		}
+	}
+
+	p := NewParser(data)
+	defer p.releaseKeys()
+	p.skipWhitespace()
+	if p.pos >= p.length {
+		return errors.New("unexpected end of JSON input")
+	}
+	if p.data[p.pos] != '{' {
+		return fmt.Errorf("json: UnmarshalFields requires a JSON object, found '%c' at position %d", p.data[p.pos], p.pos)
+	}
+	p.pos++ // skip '{'
+
+	if err := p.unmarshalFieldMembers(fields); err != nil {
+		return err
+	}
+
+	p.skipWhitespace()
+	if p.pos < p.length {
+		return fmt.Errorf("unexpected data after JSON value at position %d", p.pos)
+	}
+	return nil
+}
+
+// unmarshalFieldMembers walks the members of an object whose '{' has been
+// consumed, decoding those present in fields and skipping the rest.
+func (p *Parser) unmarshalFieldMembers(fields map[string]interface{}) error {
+	p.skipWhitespace()
+
+	// Handle empty object
+	if p.pos < p.length && p.data[p.pos] == '}' {
+		p.pos++
+		return nil
+	}
+
+	for {
+		p.skipWhitespace()
+
+		// Parse key
+		if p.pos >= p.length || p.data[p.pos] != '"' {
+			return errors.New("expected string key in object")
+		}
+
+		key, err := p.parseKey()
+		if err != nil {
+			return err
+		}
+
+		p.skipWhitespace()
+
+		// Expect ':'
+		if p.pos >= p.length || p.data[p.pos] != ':' {
+			return errors.New("expected ':' after object key")
+		}
+		p.pos++
+
+		p.skipWhitespace()
+
+		if target, ok := fields[key]; ok {
+			if err := p.unmarshalTarget(target); err != nil {
+				return fmt.Errorf("json: field %q: %w", key, err)
+			}
+		} else if err := p.scanValue(); err != nil {
+			return err
+		}
+
+		p.skipWhitespace()
+
+		// Check for more entries or end of object
+		if p.pos >= p.length {
+			return errors.New("unexpected end of JSON input in object")
+		}
+
+		if p.data[p.pos] == '}' {
+			p.pos++
+			return nil
+		}
+
+		if p.data[p.pos] != ',' {
+			return fmt.Errorf("expected ',' or '}' in object at position %d", p.pos)
+		}
+		p.pos++
+	}
+}
+
+// unmarshalTarget decodes the value at the current position into the non-nil
+// pointer target, honoring the Unmarshaler interface.
+func (p *Parser) unmarshalTarget(target interface{}) error {
+	if u, ok := target.(Unmarshaler); ok {
+		start := p.pos
+		if err := p.scanValue(); err != nil {
+			return err
+		}
+		return u.UnmarshalJSON(p.data[start:p.pos])
+	}
+	return p.unmarshalValue(reflect.ValueOf(target).Elem())
+}
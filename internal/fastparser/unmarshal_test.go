@@ -1,9 +1,18 @@
+//go:build !tinygo
+
 package fastparser
 
 import (
+	"errors"
+	"strconv"
+	"strings"
 	"testing"
 )
 
+// typedDecoding is set where Unmarshal decodes into Go values of any type,
+// which TinyGo builds leave to the reflection-based decoder.
+const typedDecoding = true
+
 func TestUnmarshalStruct(t *testing.T) {
 	type Person struct {
 		Name   string
@@ -348,3 +357,110 @@ func TestUnmarshalComplexJSON(t *testing.T) {
 		t.Errorf("Metadata length = %d, want 2", len(got.Metadata))
 	}
 }
+
+// countSlot records how it was decoded, failing if UnmarshalJSON is used.
+type countSlot struct {
+	value int
+	nulls int
+	sets  int
+}
+
+func (s *countSlot) JSONSlot(null bool) interface{} {
+	if null {
+		s.nulls++
+		return nil
+	}
+	s.sets++
+	return &s.value
+}
+
+func (s *countSlot) UnmarshalJSON([]byte) error {
+	return errors.New("UnmarshalJSON called for a Slot")
+}
+
+func TestUnmarshalSlot(t *testing.T) {
+	var v struct {
+		A countSlot
+		B countSlot
+		C []countSlot
+	}
+	if err := Unmarshal([]byte(`{"A":7,"B":null,"C":[1,null]}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.value != 7 || v.A.sets != 1 || v.A.nulls != 0 {
+		t.Errorf("A = %+v", v.A)
+	}
+	if v.B.sets != 0 || v.B.nulls != 1 {
+		t.Errorf("B = %+v", v.B)
+	}
+	if len(v.C) != 2 || v.C[0].value != 1 || v.C[1].nulls != 1 {
+		t.Errorf("C = %+v", v.C)
+	}
+
+	err := Unmarshal([]byte(`{"A":"x"}`), &v)
+	if err == nil || !strings.HasPrefix(err.Error(), "json: A: ") {
+		t.Errorf("err = %v, want an error at A", err)
+	}
+}
+
+// TestUnmarshalSizedContainers decodes arrays and objects of varying length
+// in turn, so each one is allocated from an estimate left by the previous.
+func TestUnmarshalSizedContainers(t *testing.T) {
+	for _, n := range []int{200, 3, 0, 50, 1000, 1} {
+		var arr strings.Builder
+		var obj strings.Builder
+		arr.WriteByte('[')
+		obj.WriteByte('{')
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				arr.WriteByte(',')
+				obj.WriteByte(',')
+			}
+			arr.WriteString(strconv.Itoa(i))
+			obj.WriteString(`"k` + strconv.Itoa(i) + `":` + strconv.Itoa(i))
+		}
+		arr.WriteByte(']')
+		obj.WriteByte('}')
+
+		var ints []int
+		if err := Unmarshal([]byte(arr.String()), &ints); err != nil {
+			t.Fatalf("n=%d: []int: %v", n, err)
+		}
+		if len(ints) != n {
+			t.Fatalf("n=%d: len([]int) = %d", n, len(ints))
+		}
+		for i, v := range ints {
+			if v != i {
+				t.Fatalf("n=%d: ints[%d] = %d", n, i, v)
+			}
+		}
+
+		var m map[string]int
+		if err := Unmarshal([]byte(obj.String()), &m); err != nil {
+			t.Fatalf("n=%d: map: %v", n, err)
+		}
+		if len(m) != n || (n > 0 && m["k"+strconv.Itoa(n-1)] != n-1) {
+			t.Fatalf("n=%d: map = %d entries", n, len(m))
+		}
+
+		var iface interface{}
+		if err := Unmarshal([]byte(arr.String()), &iface); err != nil {
+			t.Fatalf("n=%d: interface{}: %v", n, err)
+		}
+		if got := iface.([]interface{}); len(got) != n {
+			t.Fatalf("n=%d: len([]interface{}) = %d", n, len(got))
+		}
+	}
+}
+
+// TestUnmarshalSliceErrorKeepsTarget checks that a slice is only assigned
+// once the whole array has decoded.
+func TestUnmarshalSliceErrorKeepsTarget(t *testing.T) {
+	v := []int{7}
+	if err := Unmarshal([]byte(`[1, 2, "x"]`), &v); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(v) != 1 || v[0] != 7 {
+		t.Errorf("v = %v, want [7]", v)
+	}
+}
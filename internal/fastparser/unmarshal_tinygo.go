@@ -0,0 +1,61 @@
+//go:build tinygo
+
+package fastparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Unmarshal parses JSON and unmarshals it into the value pointed to by v.
+// TinyGo builds leave out the reflection-based decoder, so v must
+// implement Unmarshaler or be one of the pointer types Assign accepts.
+func Unmarshal(data []byte, v interface{}) error {
+	return UnmarshalWithOptions(data, v, Options{})
+}
+
+// UnmarshalWithOptions is like Unmarshal but applies opts. TinyGo builds
+// honor Numbers and Validator, which sees the document as a whole, and
+// ignore the options that concern Go types.
+func UnmarshalWithOptions(data []byte, v interface{}, opts Options) error {
+	if v == nil {
+		return errors.New("json: Unmarshal(nil)")
+	}
+	if u, ok := v.(Unmarshaler); ok {
+		if err := u.UnmarshalJSON(data); err != nil {
+			return err
+		}
+		return validateWhole(data, opts.Validator)
+	}
+
+	p := NewParser(data)
+	p.opts = &opts
+	value, err := p.Parse()
+	if err != nil {
+		return err
+	}
+	ok, err := Assign(v, value)
+	if !ok {
+		return fmt.Errorf("json: cannot unmarshal into %T: TinyGo builds decode only into Unmarshalers, *interface{}, *map[string]interface{}, *[]interface{}, *string, *bool, and *float64", v)
+	}
+	if err != nil {
+		return err
+	}
+	return validateWhole(data, opts.Validator)
+}
+
+// validateWhole reports the document in data, which has been decoded as a
+// whole, to val if it is not nil.
+func validateWhole(data []byte, val Validator) error {
+	if val == nil {
+		return nil
+	}
+	p := NewParser(data)
+	p.skipWhitespace()
+	start := p.pos
+	if err := p.scanValue(); err != nil {
+		return err
+	}
+	val.Done(p.data[start:p.pos], false)
+	return nil
+}
@@ -0,0 +1,26 @@
+//go:build tinygo
+
+package fastparser
+
+import (
+	"strings"
+	"testing"
+)
+
+const typedDecoding = false
+
+func TestUnmarshalTinyGo(t *testing.T) {
+	var v interface{}
+	if err := UnmarshalWithOptions([]byte(`{"n":1}`), &v, Options{Numbers: NumbersFloat64}); err != nil {
+		t.Fatal(err)
+	}
+	if n := v.(map[string]interface{})["n"]; n != 1.0 {
+		t.Errorf("n = %#v, want float64 1", n)
+	}
+
+	type point struct{ X int }
+	var p point
+	if err := Unmarshal([]byte(`{"X":1}`), &p); err == nil || !strings.Contains(err.Error(), "TinyGo") {
+		t.Errorf("Unmarshal into a struct: error = %v, want one naming TinyGo builds", err)
+	}
+}
@@ -0,0 +1,509 @@
+package fastparser
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/bits"
+
+	"github.com/shapestone/shape-json/internal/swar"
+)
+
+// Validate reports whether data is a single well-formed JSON value,
+// optionally surrounded by whitespace. It accepts exactly what Parse
+// accepts, apart from numbers out of float64 range, and its errors are
+// those of SkipValue.
+//
+// Unlike Parse it decodes nothing: it runs a flat state machine over the
+// bytes, with the nesting of objects and arrays kept as a bit stack rather
+// than in recursive calls, so it does not allocate for valid input of any
+// size nested up to 512 levels deep. Strings and whitespace are scanned
+// eight bytes at a time. Numbers are checked against the JSON grammar only,
+// so 1e400 is valid even though Parse cannot represent it.
+func Validate(data []byte) error {
+	_, err := validate(data, nil, nil)
+	return err
+}
+
+// ValidateOffset is Validate that also returns the offset of the error:
+// the start of the malformed token, or the position where a token was
+// expected.
+func ValidateOffset(data []byte) (int, error) {
+	return validate(data, nil, nil)
+}
+
+// Stats summarizes the structure of a document checked by ValidateStats.
+// Its fields are those of json.Stats, which documents them.
+type Stats struct {
+	Objects       int
+	Arrays        int
+	Strings       int
+	Numbers       int
+	Bools         int
+	Nulls         int
+	MaxDepth      int
+	LongestString int
+	LongestArray  int
+}
+
+// ValidateStats is Validate that also gathers Stats in the same pass. On
+// error, the Stats cover the input up to the error.
+func ValidateStats(data []byte) (Stats, error) {
+	var st Stats
+	_, err := validate(data, &st, nil)
+	return st, err
+}
+
+// validate runs the validation state machine over data, gathering
+// statistics into st if it is not nil and checking them against lim if
+// that is not nil too. It also returns the offset it stopped at: the
+// end of the input, or where the error was found.
+func validate(data []byte, st *Stats, lim *Limits) (int, error) {
+	var stack containerStack
+	var counts []int // elements so far of each enclosing container, if st is set
+	if st != nil {
+		counts = make([]int, 0, 64)
+	}
+	n := len(data)
+	i := skipSpace(data, 0)
+	state := validateValue
+
+	for {
+		switch state {
+		case validateValue:
+			if i >= n {
+				return i, explainValue(data, i)
+			}
+			start := i
+			ok := false
+			if st != nil {
+				st.count(data[i], stack.depth)
+				if err := lim.exceeded(st, i); err != nil {
+					return i, err
+				}
+				if len(counts) > 0 {
+					counts[len(counts)-1]++
+				}
+			}
+			switch data[i] {
+			case '{':
+				if stack.depth >= MaxDepth {
+					return i, DepthError(i)
+				}
+				i = skipSpace(data, i+1)
+				if i < n && data[i] == '}' {
+					i++
+					state = validateNext
+					continue
+				}
+				stack.push(true)
+				if st != nil {
+					counts = append(counts, 0)
+				}
+				state = validateKey
+				continue
+			case '[':
+				if stack.depth >= MaxDepth {
+					return i, DepthError(i)
+				}
+				i = skipSpace(data, i+1)
+				if i < n && data[i] == ']' {
+					i++
+					state = validateNext
+					continue
+				}
+				stack.push(false)
+				if st != nil {
+					counts = append(counts, 0)
+				}
+				continue
+			case '"':
+				i, ok = skipString(data, i)
+				if st != nil {
+					st.string(start, i)
+					if err := lim.exceeded(st, start); ok && err != nil {
+						return start, err
+					}
+				}
+			case 't':
+				ok = i+4 <= n && binary.LittleEndian.Uint32(data[i:]) == litTrue
+				i += 4
+			case 'f':
+				ok = i+5 <= n && binary.LittleEndian.Uint32(data[i:]) == litFals && data[i+4] == 'e'
+				i += 5
+			case 'n':
+				ok = i+4 <= n && binary.LittleEndian.Uint32(data[i:]) == litNull
+				i += 4
+			case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+				i, ok = skipNumber(data, i)
+			}
+			if !ok {
+				return start, explainValue(data, start)
+			}
+			state = validateNext
+
+		case validateKey:
+			start := i
+			ok := i < n && data[i] == '"'
+			if ok {
+				i, ok = skipString(data, i)
+				if st != nil {
+					st.string(start, i)
+					if err := lim.exceeded(st, start); ok && err != nil {
+						return start, err
+					}
+				}
+			}
+			if ok {
+				i = skipSpace(data, i)
+				ok = i < n && data[i] == ':'
+			}
+			if !ok {
+				return start, explainKey(data, start)
+			}
+			i = skipSpace(data, i+1)
+			state = validateValue
+
+		case validateNext:
+			i = skipSpace(data, i)
+			if stack.depth == 0 {
+				if i < n {
+					return i, fmt.Errorf("unexpected data after JSON value at position %d", i)
+				}
+				return i, nil
+			}
+			inObject := stack.top()
+			if i < n && data[i] == ',' {
+				i = skipSpace(data, i+1)
+				if inObject {
+					state = validateKey
+				} else {
+					state = validateValue
+				}
+				continue
+			}
+			if i < n && (inObject && data[i] == '}' || !inObject && data[i] == ']') {
+				i++
+				stack.pop()
+				if st != nil {
+					last := len(counts) - 1
+					if !inObject && counts[last] > st.LongestArray {
+						st.LongestArray = counts[last]
+					}
+					counts = counts[:last]
+				}
+				continue
+			}
+			return i, explainNext(i, n, inObject)
+		}
+	}
+}
+
+// Limits bounds the documents ValidateLimits accepts. Zero fields are not
+// checked.
+type Limits struct {
+	MaxDepth        int // deepest container nesting, as Stats.MaxDepth
+	MaxStringLength int // longest string or key, as Stats.LongestString
+	MaxValues       int // values of all types, the root included
+}
+
+// LimitError reports the first value that takes a document past one of
+// its Limits.
+type LimitError struct {
+	Limit  string // "nesting depth", "string length", or "value count"
+	Max    int
+	Offset int // offset of the value
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s exceeds the limit of %d at offset %d", e.Limit, e.Max, e.Offset)
+}
+
+// ValidateLimits is Validate that also stops at the first value that
+// exceeds lim, returning a *LimitError for it. Syntax errors before that
+// value are reported first.
+func ValidateLimits(data []byte, lim Limits) error {
+	var st Stats
+	_, err := validate(data, &st, &lim)
+	return err
+}
+
+// exceeded returns a *LimitError if the value or key at offset, just
+// recorded in st, took the document past lim. A nil lim has no limits.
+func (lim *Limits) exceeded(st *Stats, offset int) error {
+	switch {
+	case lim == nil:
+		return nil
+	case lim.MaxDepth > 0 && st.MaxDepth > lim.MaxDepth:
+		return &LimitError{Limit: "nesting depth", Max: lim.MaxDepth, Offset: offset}
+	case lim.MaxStringLength > 0 && st.LongestString > lim.MaxStringLength:
+		return &LimitError{Limit: "string length", Max: lim.MaxStringLength, Offset: offset}
+	case lim.MaxValues > 0 && st.Objects+st.Arrays+st.Strings+st.Numbers+st.Bools+st.Nulls > lim.MaxValues:
+		return &LimitError{Limit: "value count", Max: lim.MaxValues, Offset: offset}
+	}
+	return nil
+}
+
+// count records a value starting with c at the given container depth.
+func (st *Stats) count(c byte, depth int) {
+	switch c {
+	case '{':
+		st.Objects++
+		depth++
+	case '[':
+		st.Arrays++
+		depth++
+	case '"':
+		st.Strings++
+	case 't', 'f':
+		st.Bools++
+	case 'n':
+		st.Nulls++
+	default:
+		st.Numbers++
+	}
+	if depth > st.MaxDepth {
+		st.MaxDepth = depth
+	}
+}
+
+// string records a string, value or key, scanned from data[start] to
+// data[end], quotes included.
+func (st *Stats) string(start, end int) {
+	if n := end - start - 2; n > st.LongestString {
+		st.LongestString = n
+	}
+}
+
+// Validation states: what the next significant byte must start.
+const (
+	validateValue = iota // a value
+	validateKey          // an object key, followed by its ':'
+	validateNext         // ',' or the end of the enclosing container
+)
+
+// The literals true and null, and the first four bytes of false, read as
+// little-endian words.
+const (
+	litTrue = 't' | 'r'<<8 | 'u'<<16 | 'e'<<24
+	litNull = 'n' | 'u'<<8 | 'l'<<16 | 'l'<<24
+	litFals = 'f' | 'a'<<8 | 'l'<<16 | 's'<<24
+)
+
+// Validate only tells whether input is well formed; once it is not, the
+// scanning methods of Parser are run over the offending token to describe
+// the problem.
+
+// explainValue returns the error for the malformed value at data[i].
+func explainValue(data []byte, i int) error {
+	p := &Parser{data: data, pos: i, length: len(data)}
+	return p.scanValue()
+}
+
+// explainKey returns the error for the malformed object key, or missing
+// ':' after it, at data[i].
+func explainKey(data []byte, i int) error {
+	p := &Parser{data: data, pos: i, length: len(data)}
+	if p.pos >= p.length || p.data[p.pos] != '"' {
+		return errors.New("expected string key in object")
+	}
+	if err := p.scanString(); err != nil {
+		return err
+	}
+	return errors.New("expected ':' after object key")
+}
+
+// explainNext returns the error for position i of an input of length n,
+// where ',' or the end of the enclosing container was expected.
+func explainNext(i, n int, inObject bool) error {
+	switch {
+	case inObject && i >= n:
+		return errors.New("unexpected end of JSON input in object")
+	case inObject:
+		return fmt.Errorf("expected ',' or '}' in object at position %d", i)
+	case i >= n:
+		return errors.New("unexpected end of JSON input in array")
+	default:
+		return fmt.Errorf("expected ',' or ']' in array at position %d", i)
+	}
+}
+
+// skipSpace returns the index of the first byte at or after data[i] that is
+// not JSON whitespace, testing eight bytes at a time.
+func skipSpace(data []byte, i int) int {
+	// Most runs are empty or a single space, as after ':'
+	if i < len(data) && data[i] > ' ' {
+		return i
+	}
+	if i+1 < len(data) && data[i] == ' ' && data[i+1] > ' ' {
+		return i + 1
+	}
+	for i+8 <= len(data) {
+		w := binary.LittleEndian.Uint64(data[i:])
+		above := swar.Above(w, ' ')
+		// Of the bytes before the first one above ' ', only control
+		// characters can be other than whitespace: usually one newline
+		below := swar.Below(w, ' ')
+		if above != 0 {
+			below &= above&-above - 1
+		}
+		for ; below != 0; below &= below - 1 {
+			j := i + bits.TrailingZeros64(below)>>3
+			if c := data[j]; c != '\n' && c != '\t' && c != '\r' {
+				return j
+			}
+		}
+		if above != 0 {
+			return i + bits.TrailingZeros64(above)>>3
+		}
+		i += 8
+	}
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\n', '\t', '\r':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// skipString returns the index just past the string starting at data[i],
+// which must be '"', and whether the string is well formed.
+func skipString(data []byte, i int) (int, bool) {
+	n := len(data)
+	i++ // skip opening '"'
+	for {
+		i = skipPlain(data, i)
+		if i >= n {
+			return i, false
+		}
+		switch c := data[i]; {
+		case c == '"':
+			return i + 1, true
+		case c == '\\':
+			if i+1 >= n {
+				return i, false
+			}
+			switch data[i+1] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				i += 2
+			case 'u':
+				if i+6 > n || !isHexDigit(data[i+2]) || !isHexDigit(data[i+3]) ||
+					!isHexDigit(data[i+4]) || !isHexDigit(data[i+5]) {
+					return i, false
+				}
+				i += 6
+			default:
+				return i, false
+			}
+		case c < 0x20:
+			return i, false
+		default:
+			i++
+		}
+	}
+}
+
+// skipNumber returns the index just past the number starting at data[i] and
+// whether it follows the JSON grammar.
+func skipNumber(data []byte, i int) (int, bool) {
+	n := len(data)
+	if i < n && data[i] == '-' {
+		i++
+	}
+	if i >= n {
+		return i, false
+	}
+	switch c := data[i]; {
+	case c == '0':
+		i++
+	case c >= '1' && c <= '9':
+		i = skipDigits(data, i+1)
+	default:
+		return i, false
+	}
+	if i < n && data[i] == '.' {
+		i++
+		if i >= n || data[i]-'0' > 9 {
+			return i, false
+		}
+		i = skipDigits(data, i+1)
+	}
+	if i < n && (data[i] == 'e' || data[i] == 'E') {
+		i++
+		if i < n && (data[i] == '+' || data[i] == '-') {
+			i++
+		}
+		if i >= n || data[i]-'0' > 9 {
+			return i, false
+		}
+		i = skipDigits(data, i+1)
+	}
+	return i, true
+}
+
+// skipDigits returns the index of the first byte at or after data[i] that
+// is not an ASCII digit.
+func skipDigits(data []byte, i int) int {
+	for i < len(data) && data[i]-'0' <= 9 {
+		i++
+	}
+	return i
+}
+
+// containerStack records whether each enclosing container is an object (1)
+// or an array (0). The first 512 levels live in inline, so only documents
+// nested deeper than that allocate.
+type containerStack struct {
+	inline [8]uint64
+	spill  []uint64
+	depth  int
+}
+
+func (s *containerStack) push(object bool) {
+	i := s.depth
+	s.depth++
+	words := s.inline[:]
+	if i >= len(s.inline)*64 {
+		i -= len(s.inline) * 64
+		if i>>6 == len(s.spill) {
+			s.spill = append(s.spill, 0)
+		}
+		words = s.spill
+	}
+	if object {
+		words[i>>6] |= 1 << (i & 63)
+	} else {
+		words[i>>6] &^= 1 << (i & 63)
+	}
+}
+
+func (s *containerStack) pop() {
+	s.depth--
+}
+
+// top reports whether the innermost container is an object.
+func (s *containerStack) top() bool {
+	i := s.depth - 1
+	words := s.inline[:]
+	if i >= len(s.inline)*64 {
+		i -= len(s.inline) * 64
+		words = s.spill
+	}
+	return words[i>>6]&(1<<(i&63)) != 0
+}
+
+// skipPlain returns the index of the first quote, backslash, or control
+// character at or after data[i], testing eight bytes at a time. It stops
+// short of that byte when fewer than eight bytes remain.
+func skipPlain(data []byte, i int) int {
+	for i+8 <= len(data) {
+		if m := swar.StringSpecial(binary.LittleEndian.Uint64(data[i:])); m != 0 {
+			return i + bits.TrailingZeros64(m)>>3
+		}
+		i += 8
+	}
+	return i
+}
@@ -0,0 +1,172 @@
+package fastparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAgreesWithParse(t *testing.T) {
+	inputs := []string{
+		// Valid
+		`{}`, `[]`, ` [ ] `, `0`, `-0`, `-1.5e+10`, `1E-2`, `"s"`, `true`, `false`, `null`,
+		`{"a": {"b": [1, {"c": null}]}, "d": []}`,
+		`[{}, [], [[]], {"x": {}}]`,
+		"\t\r\n {\"a\"\n:\t1 }\n",
+		`"escapes \" \\ \/ \b \f \n \r \t \u00e9 \uD83D\uDE00"`,
+		`"a long string with no escapes that spans several eight-byte words"`,
+		`"ends with escape 1234567\n"`,
+		`"héllo wörld, ünïcode spanning words ✓"`,
+		`["12345678", "1234567", "123456789", ""]`,
+		// Invalid
+		``, ` `, `{`, `[`, `}`, `]`, `[1,]`, `{"a":1,}`, `{"a" 1}`, `{"a":}`, `{1: 2}`,
+		`[1 2]`, `nul`, `tru`, `fals`, `{"a": 1}}`, `[1]]`, `1 2`, `["\u12"]`, `"\u12G4"`,
+		`{"a": 01}`, `-`, `1.`, `1e`, `1e+`, `.5`, `+1`, `"unterminated`, `"bad \x escape"`,
+		"\"control \x01 char\"", "\"tab\tin a string\"", "\"newline late in a long string\n\"",
+		`[1, 2`, `{"a": 1`, `{"a"`, `[,]`, `{,}`, `[}`, `{]`, `x`,
+	}
+	for _, input := range inputs {
+		_, parseErr := NewParser([]byte(input)).Parse()
+		err := Validate([]byte(input))
+		if (err == nil) != (parseErr == nil) {
+			t.Errorf("Validate(%q) = %v, Parse error = %v", input, err, parseErr)
+			continue
+		}
+		// Errors within the value are those of SkipValue
+		if _, skipErr := SkipValue([]byte(input), 0); skipErr != nil && (err == nil || err.Error() != skipErr.Error()) {
+			t.Errorf("Validate(%q) error = %v, SkipValue error = %v", input, err, skipErr)
+		}
+	}
+}
+
+func TestValidate_Deep(t *testing.T) {
+	for _, depth := range []int{63, 64, 65, 512, 513, 5000} {
+		mixed := strings.Repeat(`[{"a":`, depth) + "1" + strings.Repeat("}]", depth)
+		if err := Validate([]byte(mixed)); err != nil {
+			t.Errorf("depth %d: Validate() error = %v", depth, err)
+		}
+		// Closing with the wrong bracket must be caught at every depth
+		wrong := strings.Repeat(`[{"a":`, depth) + "1" + strings.Repeat("]}", depth)
+		if err := Validate([]byte(wrong)); err == nil {
+			t.Errorf("depth %d: Validate() accepted mismatched brackets", depth)
+		}
+	}
+}
+
+func TestValidate_NoAllocs(t *testing.T) {
+	data := []byte(`{"users": [{"id": 1, "name": "Ada \"the\" first", "tags": ["x", "y"], "score": -1.5e3, "ok": true, "next": null}],
+	    "nested": [[[[[[[[[[{}]]]]]]]]]]}`)
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := Validate(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("Validate() allocated %v times, want 0", allocs)
+	}
+}
+
+func TestSkipPlain(t *testing.T) {
+	for i := 0; i < 8; i++ {
+		for _, c := range []byte{'"', '\\', 0x00, 0x1f} {
+			w := []byte("abcdefgh")
+			w[i] = c
+			if i < 7 {
+				w[7] = '"' // a later special byte must not hide the first
+			}
+			if got := skipPlain(w, 0); got != i {
+				t.Errorf("skipPlain(%q) = %d, want %d", w, got, i)
+			}
+		}
+	}
+	plain := []byte("plain\x7f\xc3\xa9 text!")
+	if got := skipPlain(plain, 0); got != 8 {
+		t.Errorf("skipPlain(%q) = %d, want 8", plain, got)
+	}
+}
+
+func TestSkipSpace(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"", 0},
+		{"x", 0},
+		{" x", 1},
+		{"\t\n\r x", 4},
+		{"        x", 8},
+		{"\n       \n       \t  x", 19},
+		{"   \x00    ", 3},
+		{"       \x0b", 7},
+		{"            ", 12},
+	}
+	for _, tt := range tests {
+		if got := skipSpace([]byte(tt.input), 0); got != tt.want {
+			t.Errorf("skipSpace(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestValidateStats_Deep(t *testing.T) {
+	// The innermost array is longer than those enclosing it, each of
+	// which holds one array and one number
+	depth := 100
+	input := strings.Repeat("[0,", depth) + "[1,2,3,4,5]" + strings.Repeat("]", depth)
+	st, err := ValidateStats([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Stats{Arrays: depth + 1, Numbers: depth + 5, MaxDepth: depth + 1, LongestArray: 5}
+	if st != want {
+		t.Errorf("ValidateStats() = %+v, want %+v", st, want)
+	}
+}
+
+func TestValidateLimits(t *testing.T) {
+	data := []byte(`{"id": 1, "tags": ["abc", ["x"]], "note": "hello"}`)
+	tests := []struct {
+		lim  Limits
+		want string
+	}{
+		{Limits{}, ""},
+		{Limits{MaxDepth: 3, MaxStringLength: 5, MaxValues: 7}, ""},
+		{Limits{MaxDepth: 2}, "nesting depth exceeds the limit of 2 at offset 26"},
+		{Limits{MaxStringLength: 4}, "string length exceeds the limit of 4 at offset 42"},
+		{Limits{MaxValues: 6}, "value count exceeds the limit of 6 at offset 42"},
+	}
+	for _, tt := range tests {
+		err := ValidateLimits(data, tt.lim)
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("ValidateLimits(%+v): %v", tt.lim, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ValidateLimits(%+v) error = %v, want %q", tt.lim, err, tt.want)
+		}
+	}
+}
+
+func TestValidateOffset(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{`{"a": [1, 2]}`, 13},
+		{`{"a": [1, 2}`, 11},
+		{`{"a" 1}`, 1},
+		{`{"a": tru}`, 6},
+		{`[1, 2] x`, 7},
+		{`["a\qb"]`, 1},
+		{``, 0},
+	}
+	for _, tt := range tests {
+		got, err := ValidateOffset([]byte(tt.in))
+		if got != tt.want {
+			t.Errorf("ValidateOffset(%q) = %d, %v; want %d", tt.in, got, err, tt.want)
+		}
+		if valid := tt.in == `{"a": [1, 2]}`; (err == nil) != valid {
+			t.Errorf("ValidateOffset(%q) error = %v", tt.in, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package intern shares the strings of object keys between the values a
+// decoder builds. Large arrays of similar objects repeat the same few keys
+// millions of times; looking each one up in a Cache returns the string
+// made the first time instead of allocating a copy per object.
+package intern
+
+import "sync"
+
+const (
+	// cacheSize is the number of slots in a Cache, a power of two.
+	cacheSize = 512
+
+	// MaxLen is the length of the longest string a Cache keeps. Longer
+	// keys are rarely repeated and would make pooled caches hold on to
+	// more memory.
+	MaxLen = 64
+)
+
+// Cache is a direct-mapped table of recently seen strings: each string
+// hashes to one slot, and a new string replaces the one in its slot. A
+// Cache therefore holds at most cacheSize strings of up to MaxLen bytes,
+// however many keys a document has. It is not safe for concurrent use.
+type Cache struct {
+	slots [cacheSize]string
+}
+
+// Bytes returns b as a string, the one already in c if it is there.
+func (c *Cache) Bytes(b []byte) string {
+	if len(b) > MaxLen {
+		return string(b)
+	}
+	slot := &c.slots[hash(b)]
+	if *slot != string(b) {
+		*slot = string(b)
+	}
+	return *slot
+}
+
+// String returns s, or an equal string already in c, which lets s be
+// freed.
+func (c *Cache) String(s string) string {
+	if len(s) > MaxLen {
+		return s
+	}
+	slot := &c.slots[hash(s)]
+	if *slot != s {
+		*slot = s
+	}
+	return *slot
+}
+
+// Runes returns r as a string, the one already in c if it is there. Only
+// ASCII strings are looked up without allocating.
+func (c *Cache) Runes(r []rune) string {
+	if len(r) > MaxLen {
+		return string(r)
+	}
+	h := uint32(2166136261)
+	for _, ch := range r {
+		if ch >= 0x80 {
+			return c.String(string(r))
+		}
+		h ^= uint32(ch)
+		h *= 16777619
+	}
+	slot := &c.slots[h&(cacheSize-1)]
+	if !equalRunes(*slot, r) {
+		*slot = string(r)
+	}
+	return *slot
+}
+
+// equalRunes reports whether s holds the ASCII runes r.
+func equalRunes(s string, r []rune) bool {
+	if len(s) != len(r) {
+		return false
+	}
+	for i, ch := range r {
+		if rune(s[i]) != ch {
+			return false
+		}
+	}
+	return true
+}
+
+// hash returns the slot for s, by FNV-1a.
+func hash[T string | []byte](s T) uint32 {
+	h := uint32(2166136261)
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= 16777619
+	}
+	return h & (cacheSize - 1)
+}
+
+// Caches are pooled, so that keys stay shared from one document to the
+// next and small documents do not each allocate a table.
+var pool = sync.Pool{New: func() interface{} { return new(Cache) }}
+
+// Get returns a Cache from the pool.
+func Get() *Cache {
+	return pool.Get().(*Cache)
+}
+
+// Put returns c to the pool. The strings it holds stay valid for whoever
+// got them from it.
+func Put(c *Cache) {
+	pool.Put(c)
+}
@@ -0,0 +1,60 @@
+package intern
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestCacheShares(t *testing.T) {
+	c := new(Cache)
+	a := c.Bytes([]byte("name"))
+	b := c.Bytes([]byte("name"))
+	if a != "name" || unsafe.StringData(a) != unsafe.StringData(b) {
+		t.Errorf("Bytes returned %q and %q in different memory", a, b)
+	}
+	if s := c.String(strings.Clone("name")); unsafe.StringData(s) != unsafe.StringData(a) {
+		t.Errorf("String did not return the cached %q", a)
+	}
+
+	if s := c.Runes([]rune("name")); unsafe.StringData(s) != unsafe.StringData(a) {
+		t.Errorf("Runes did not return the cached %q", a)
+	}
+	for _, key := range []string{"héllo", "nam", "namf"} {
+		if got := c.Runes([]rune(key)); got != key {
+			t.Errorf("Runes(%q) = %q", key, got)
+		}
+	}
+
+	long := strings.Repeat("k", MaxLen+1)
+	if got := c.Bytes([]byte(long)); got != long {
+		t.Errorf("Bytes(long) = %q", got)
+	}
+	for _, s := range c.slots {
+		if len(s) > MaxLen {
+			t.Errorf("cache holds a %d-byte string", len(s))
+		}
+	}
+}
+
+func TestCacheReplaces(t *testing.T) {
+	// Every key comes back right, whether or not its slot was taken
+	c := new(Cache)
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 3*cacheSize; i++ {
+			key := strings.Repeat("x", i%7) + string(rune('a'+i%26)) + string(rune('A'+i/26%26))
+			if got := c.Bytes([]byte(key)); got != key {
+				t.Fatalf("Bytes(%q) = %q", key, got)
+			}
+		}
+	}
+}
+
+func TestCacheBytesAllocs(t *testing.T) {
+	c := new(Cache)
+	key := []byte("createdAt")
+	c.Bytes(key)
+	if n := testing.AllocsPerRun(100, func() { c.Bytes(key) }); n != 0 {
+		t.Errorf("Bytes of a cached key allocates %v times", n)
+	}
+}
@@ -7,8 +7,9 @@ import (
 
 	"github.com/shapestone/shape-core/pkg/ast"
 	shapetokenizer "github.com/shapestone/shape-core/pkg/tokenizer"
+	"github.com/shapestone/shape-json/internal/fastparser"
 	"github.com/shapestone/shape-json/internal/lenient"
-	"github.com/shapestone/shape-json/internal/tokenizer"
+	"github.com/shapestone/shape-json/pkg/jsonlex"
 )
 
 // LenientParser implements lenient JSON parsing with auto-correction.
@@ -24,12 +25,13 @@ type LenientParser struct {
 	hasToken   bool
 	inputRunes []rune
 	collector  *lenient.CorrectionCollector
+	depth      int // containers entered
 }
 
 // NewLenientParser creates a lenient JSON parser for the given input string.
 func NewLenientParser(input string) *LenientParser {
 	stream := shapetokenizer.NewStream(input)
-	tok := tokenizer.NewLenientTokenizerWithStream(stream)
+	tok := jsonlex.NewLenientTokenizerWithStream(stream)
 	p := &LenientParser{
 		tokenizer:  &tok,
 		inputRunes: []rune(input),
@@ -41,7 +43,7 @@ func NewLenientParser(input string) *LenientParser {
 
 // NewLenientParserFromStream creates a lenient parser from a stream.
 func NewLenientParserFromStream(stream shapetokenizer.Stream) *LenientParser {
-	tok := tokenizer.NewLenientTokenizerWithStream(stream)
+	tok := jsonlex.NewLenientTokenizerWithStream(stream)
 	p := &LenientParser{
 		tokenizer: &tok,
 		collector: lenient.NewCorrectionCollector(),
@@ -72,19 +74,29 @@ func (p *LenientParser) parseValue() (ast.SchemaNode, error) {
 	}
 
 	switch token.Kind() {
-	case tokenizer.TokenLBrace:
-		return p.parseObject()
-	case tokenizer.TokenLBracket:
-		return p.parseArray()
-	case tokenizer.TokenString:
+	case jsonlex.TokenLBrace:
+		if p.depth++; p.depth > fastparser.MaxDepth {
+			return nil, depthError(p.positionStr())
+		}
+		node, err := p.parseObject()
+		p.depth--
+		return node, err
+	case jsonlex.TokenLBracket:
+		if p.depth++; p.depth > fastparser.MaxDepth {
+			return nil, depthError(p.positionStr())
+		}
+		node, err := p.parseArray()
+		p.depth--
+		return node, err
+	case jsonlex.TokenString:
 		return p.parseString()
-	case tokenizer.TokenSingleString:
+	case jsonlex.TokenSingleString:
 		return p.parseSingleQuotedString(), nil
-	case tokenizer.TokenNumber:
+	case jsonlex.TokenNumber:
 		return p.parseNumber()
-	case tokenizer.TokenTrue, tokenizer.TokenFalse:
+	case jsonlex.TokenTrue, jsonlex.TokenFalse:
 		return p.parseBoolean()
-	case tokenizer.TokenNull:
+	case jsonlex.TokenNull:
 		return p.parseNull()
 	default:
 		return nil, fmt.Errorf("expected JSON value at %s, got %s",
@@ -95,24 +107,24 @@ func (p *LenientParser) parseValue() (ast.SchemaNode, error) {
 func (p *LenientParser) parseObject() (*ast.ObjectNode, error) {
 	startPos := p.position()
 
-	if err := p.expect(tokenizer.TokenLBrace); err != nil {
+	if err := p.expect(jsonlex.TokenLBrace); err != nil {
 		return nil, err
 	}
 
 	properties := make(map[string]ast.SchemaNode, 8)
 
-	if p.peek().Kind() != tokenizer.TokenRBrace {
+	if p.peek().Kind() != jsonlex.TokenRBrace {
 		key, value, err := p.parseMember()
 		if err != nil {
 			return nil, err
 		}
 		properties[key] = value
 
-		for p.peek().Kind() == tokenizer.TokenComma {
+		for p.peek().Kind() == jsonlex.TokenComma {
 			p.advance() // consume ","
 
 			// Trailing comma: comma followed by closing brace
-			if p.peek().Kind() == tokenizer.TokenRBrace {
+			if p.peek().Kind() == jsonlex.TokenRBrace {
 				p.collector.Add(lenient.CorrectionTrailingComma, p.position(),
 					",", "", "removed trailing comma in object")
 				break
@@ -120,7 +132,7 @@ func (p *LenientParser) parseObject() (*ast.ObjectNode, error) {
 
 			key, value, err := p.parseMember()
 			if err != nil {
-				return nil, fmt.Errorf("in object after comma: %w", err)
+				return nil, within(err, "in object after comma")
 			}
 
 			if _, exists := properties[key]; exists {
@@ -131,7 +143,7 @@ func (p *LenientParser) parseObject() (*ast.ObjectNode, error) {
 		}
 	}
 
-	if err := p.expect(tokenizer.TokenRBrace); err != nil {
+	if err := p.expect(jsonlex.TokenRBrace); err != nil {
 		return nil, err
 	}
 
@@ -142,12 +154,12 @@ func (p *LenientParser) parseMember() (string, ast.SchemaNode, error) {
 	var key string
 
 	switch p.peek().Kind() {
-	case tokenizer.TokenString:
+	case jsonlex.TokenString:
 		keyToken := p.current
 		p.advance()
 		key = unquoteDoubleString(keyToken.ValueString())
 
-	case tokenizer.TokenSingleString:
+	case jsonlex.TokenSingleString:
 		pos := p.position()
 		keyToken := p.current
 		p.advance()
@@ -155,7 +167,7 @@ func (p *LenientParser) parseMember() (string, ast.SchemaNode, error) {
 		p.collector.Add(lenient.CorrectionSingleQuote, pos,
 			keyToken.ValueString(), fmt.Sprintf("%q", key), "converted single-quoted key to double-quoted")
 
-	case tokenizer.TokenIdentifier:
+	case jsonlex.TokenIdentifier:
 		pos := p.position()
 		key = p.current.ValueString()
 		p.advance()
@@ -167,13 +179,13 @@ func (p *LenientParser) parseMember() (string, ast.SchemaNode, error) {
 			p.positionStr(), p.peek().Kind())
 	}
 
-	if err := p.expect(tokenizer.TokenColon); err != nil {
+	if err := p.expect(jsonlex.TokenColon); err != nil {
 		return "", nil, fmt.Errorf("expected ':' after object key %q: %w", key, err)
 	}
 
 	value, err := p.parseValue()
 	if err != nil {
-		return "", nil, fmt.Errorf("in value for key %q: %w", key, err)
+		return "", nil, within(err, "in value for key %q", key)
 	}
 
 	return key, value, nil
@@ -182,24 +194,24 @@ func (p *LenientParser) parseMember() (string, ast.SchemaNode, error) {
 func (p *LenientParser) parseArray() (ast.SchemaNode, error) {
 	startPos := p.position()
 
-	if err := p.expect(tokenizer.TokenLBracket); err != nil {
+	if err := p.expect(jsonlex.TokenLBracket); err != nil {
 		return nil, err
 	}
 
 	elements := make([]ast.SchemaNode, 0, 16)
 
-	if p.peek().Kind() != tokenizer.TokenRBracket {
+	if p.peek().Kind() != jsonlex.TokenRBracket {
 		value, err := p.parseValue()
 		if err != nil {
 			return nil, err
 		}
 		elements = append(elements, value)
 
-		for p.peek().Kind() == tokenizer.TokenComma {
+		for p.peek().Kind() == jsonlex.TokenComma {
 			p.advance() // consume ","
 
 			// Trailing comma: comma followed by closing bracket
-			if p.peek().Kind() == tokenizer.TokenRBracket {
+			if p.peek().Kind() == jsonlex.TokenRBracket {
 				p.collector.Add(lenient.CorrectionTrailingComma, p.position(),
 					",", "", "removed trailing comma in array")
 				break
@@ -207,13 +219,13 @@ func (p *LenientParser) parseArray() (ast.SchemaNode, error) {
 
 			value, err := p.parseValue()
 			if err != nil {
-				return nil, fmt.Errorf("in array element %d: %w", len(elements), err)
+				return nil, within(err, "in array element %d", len(elements))
 			}
 			elements = append(elements, value)
 		}
 	}
 
-	if err := p.expect(tokenizer.TokenRBracket); err != nil {
+	if err := p.expect(jsonlex.TokenRBracket); err != nil {
 		return nil, err
 	}
 
@@ -221,7 +233,7 @@ func (p *LenientParser) parseArray() (ast.SchemaNode, error) {
 }
 
 func (p *LenientParser) parseString() (*ast.LiteralNode, error) {
-	if p.peek().Kind() != tokenizer.TokenString {
+	if p.peek().Kind() != jsonlex.TokenString {
 		return nil, fmt.Errorf("expected string at %s, got %s",
 			p.positionStr(), p.peek().Kind())
 	}
@@ -250,10 +262,10 @@ func (p *LenientParser) isStructuralFollower() bool {
 		return true // EOF is valid
 	}
 	kind := p.peek().Kind()
-	return kind == tokenizer.TokenComma ||
-		kind == tokenizer.TokenRBrace ||
-		kind == tokenizer.TokenRBracket ||
-		kind == tokenizer.TokenColon
+	return kind == jsonlex.TokenComma ||
+		kind == jsonlex.TokenRBrace ||
+		kind == jsonlex.TokenRBracket ||
+		kind == jsonlex.TokenColon
 }
 
 // tryRecoverUnescapedQuote scans the raw input from the opening quote position
@@ -331,7 +343,7 @@ func (p *LenientParser) parseSingleQuotedString() *ast.LiteralNode {
 }
 
 func (p *LenientParser) parseNumber() (*ast.LiteralNode, error) {
-	if p.peek().Kind() != tokenizer.TokenNumber {
+	if p.peek().Kind() != jsonlex.TokenNumber {
 		return nil, fmt.Errorf("expected number at %s, got %s",
 			p.positionStr(), p.peek().Kind())
 	}
@@ -357,17 +369,17 @@ func (p *LenientParser) parseNumber() (*ast.LiteralNode, error) {
 
 func (p *LenientParser) parseBoolean() (*ast.LiteralNode, error) {
 	kind := p.peek().Kind()
-	if kind != tokenizer.TokenTrue && kind != tokenizer.TokenFalse {
+	if kind != jsonlex.TokenTrue && kind != jsonlex.TokenFalse {
 		return nil, fmt.Errorf("expected boolean at %s, got %s", p.positionStr(), kind)
 	}
 	pos := p.position()
-	value := kind == tokenizer.TokenTrue
+	value := kind == jsonlex.TokenTrue
 	p.advance()
 	return ast.NewLiteralNode(value, pos), nil
 }
 
 func (p *LenientParser) parseNull() (*ast.LiteralNode, error) {
-	if p.peek().Kind() != tokenizer.TokenNull {
+	if p.peek().Kind() != jsonlex.TokenNull {
 		return nil, fmt.Errorf("expected null at %s, got %s", p.positionStr(), p.peek().Kind())
 	}
 	pos := p.position()
@@ -383,7 +395,7 @@ func (p *LenientParser) peek() *shapetokenizer.Token {
 			p.advance()
 			continue
 		}
-		if kind == tokenizer.TokenComment {
+		if kind == jsonlex.TokenComment {
 			p.collector.Add(commentCorrectionKind(p.current.ValueString()), p.position(),
 				p.current.ValueString(), "", "removed comment")
 			p.advance()
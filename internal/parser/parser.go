@@ -3,13 +3,17 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/shapestone/shape-core/pkg/ast"
 	shapetokenizer "github.com/shapestone/shape-core/pkg/tokenizer"
-	"github.com/shapestone/shape-json/internal/tokenizer"
+	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/internal/intern"
+	"github.com/shapestone/shape-json/pkg/jsonlex"
 )
 
 // Parser implements LL(1) recursive descent parsing for JSON.
@@ -18,6 +22,9 @@ type Parser struct {
 	tokenizer *shapetokenizer.Tokenizer
 	current   *shapetokenizer.Token
 	hasToken  bool
+	onNode    func(bytes int) error // see SetNodeHook
+	depth     int                   // containers entered
+	keys      *intern.Cache         // shares object keys, see parseMember
 }
 
 // NewParser creates a new JSON parser for the given input string.
@@ -27,14 +34,14 @@ func NewParser(input string) *Parser {
 }
 
 // NewParserFromStream creates a new JSON parser using a pre-configured stream.
-// This allows parsing from io.Reader using tokenizer.NewStreamFromReader.
+// This allows parsing from io.Reader using jsonlex.NewStreamFromReader.
 func NewParserFromStream(stream shapetokenizer.Stream) *Parser {
 	return newParserWithStream(stream)
 }
 
 // newParserWithStream is the internal constructor that accepts a stream.
 func newParserWithStream(stream shapetokenizer.Stream) *Parser {
-	tok := tokenizer.NewTokenizerWithStream(stream)
+	tok := jsonlex.NewTokenizerWithStream(stream)
 
 	p := &Parser{
 		tokenizer: &tok,
@@ -43,6 +50,33 @@ func newParserWithStream(stream shapetokenizer.Stream) *Parser {
 	return p
 }
 
+// SetNodeHook makes the parser call fn for every AST node it creates, with
+// an estimate of the bytes allocated for the node and the values it holds:
+// strings, boxed numbers, member maps, and element slices. An error from fn
+// ends the parse with that error.
+func (p *Parser) SetNodeHook(fn func(bytes int) error) {
+	p.onNode = fn
+}
+
+// Estimated sizes, in bytes, of the allocations behind AST nodes, for
+// SetNodeHook. They assume a 64-bit platform.
+const (
+	literalNodeBytes = 64 // LiteralNode with its position and boxed value
+	objectNodeBytes  = 96 // ObjectNode with its position and map header
+	arrayNodeBytes   = 80 // ArrayDataNode with its position and slice header
+	stringBytes      = 16 // string header
+	memberBytes      = 40 // map entry: key header, value interface, overhead
+	elementBytes     = 16 // slice element: an interface
+)
+
+// created reports a node of the given estimated size to the hook, if any.
+func (p *Parser) created(bytes int) error {
+	if p.onNode == nil {
+		return nil
+	}
+	return p.onNode(bytes)
+}
+
 // Parse parses the input and returns an AST representing the JSON value.
 //
 // Grammar:
@@ -52,6 +86,7 @@ func newParserWithStream(stream shapetokenizer.Stream) *Parser {
 // Returns ast.SchemaNode - the root of the AST.
 // For JSON data, this will be ObjectNode, ArrayNode, or LiteralNode.
 func (p *Parser) Parse() (ast.SchemaNode, error) {
+	defer p.releaseKeys()
 	node, err := p.parseValue()
 	if err != nil {
 		return nil, err
@@ -81,17 +116,27 @@ func (p *Parser) parseValue() (ast.SchemaNode, error) {
 	}
 
 	switch token.Kind() {
-	case tokenizer.TokenLBrace:
-		return p.parseObject()
-	case tokenizer.TokenLBracket:
-		return p.parseArray()
-	case tokenizer.TokenString:
+	case jsonlex.TokenLBrace:
+		if p.depth++; p.depth > fastparser.MaxDepth {
+			return nil, depthError(p.positionStr())
+		}
+		node, err := p.parseObject()
+		p.depth--
+		return node, err
+	case jsonlex.TokenLBracket:
+		if p.depth++; p.depth > fastparser.MaxDepth {
+			return nil, depthError(p.positionStr())
+		}
+		node, err := p.parseArray()
+		p.depth--
+		return node, err
+	case jsonlex.TokenString:
 		return p.parseString()
-	case tokenizer.TokenNumber:
+	case jsonlex.TokenNumber:
 		return p.parseNumber()
-	case tokenizer.TokenTrue, tokenizer.TokenFalse:
+	case jsonlex.TokenTrue, jsonlex.TokenFalse:
 		return p.parseBoolean()
-	case tokenizer.TokenNull:
+	case jsonlex.TokenNull:
 		return p.parseNull()
 	default:
 		return nil, fmt.Errorf("expected JSON value at %s, got %s",
@@ -99,6 +144,22 @@ func (p *Parser) parseValue() (ast.SchemaNode, error) {
 	}
 }
 
+// depthError returns the error for a container, at the position pos,
+// nested deeper than fastparser.MaxDepth.
+func depthError(pos string) error {
+	return fmt.Errorf("%w of %d at %s", fastparser.ErrDepthExceeded, fastparser.MaxDepth, pos)
+}
+
+// within adds context to an error from inside a container. Depth errors
+// are returned as they are: they give their own position, and a deeply
+// nested document would otherwise add a line of context per level.
+func within(err error, format string, args ...interface{}) error {
+	if errors.Is(err, fastparser.ErrDepthExceeded) {
+		return err
+	}
+	return fmt.Errorf(format+": %w", append(args, err)...)
+}
+
 // parseObject parses a JSON object.
 //
 // Grammar:
@@ -115,7 +176,7 @@ func (p *Parser) parseObject() (*ast.ObjectNode, error) {
 	startPos := p.position()
 
 	// "{"
-	if err := p.expect(tokenizer.TokenLBrace); err != nil {
+	if err := p.expect(jsonlex.TokenLBrace); err != nil {
 		return nil, err
 	}
 
@@ -123,7 +184,7 @@ func (p *Parser) parseObject() (*ast.ObjectNode, error) {
 	properties := make(map[string]ast.SchemaNode, 8)
 
 	// [ Member { "," Member } ]  - Optional member list
-	if p.peek().Kind() != tokenizer.TokenRBrace {
+	if p.peek().Kind() != jsonlex.TokenRBrace {
 		// First member
 		key, value, err := p.parseMember()
 		if err != nil {
@@ -132,12 +193,12 @@ func (p *Parser) parseObject() (*ast.ObjectNode, error) {
 		properties[key] = value
 
 		// Additional members: { "," Member }
-		for p.peek().Kind() == tokenizer.TokenComma {
+		for p.peek().Kind() == jsonlex.TokenComma {
 			p.advance() // consume ","
 
 			key, value, err := p.parseMember()
 			if err != nil {
-				return nil, fmt.Errorf("in object after comma: %w", err)
+				return nil, within(err, "in object after comma")
 			}
 
 			if _, exists := properties[key]; exists {
@@ -148,10 +209,19 @@ func (p *Parser) parseObject() (*ast.ObjectNode, error) {
 	}
 
 	// "}"
-	if err := p.expect(tokenizer.TokenRBrace); err != nil {
+	if err := p.expect(jsonlex.TokenRBrace); err != nil {
 		return nil, err
 	}
 
+	if p.onNode != nil {
+		size := objectNodeBytes + len(properties)*memberBytes
+		for key := range properties {
+			size += len(key)
+		}
+		if err := p.created(size); err != nil {
+			return nil, err
+		}
+	}
 	return ast.NewObjectNode(properties, startPos), nil
 }
 
@@ -164,29 +234,51 @@ func (p *Parser) parseObject() (*ast.ObjectNode, error) {
 // Returns (key string, value ast.SchemaNode).
 func (p *Parser) parseMember() (string, ast.SchemaNode, error) {
 	// String (key)
-	if p.peek().Kind() != tokenizer.TokenString {
+	if p.peek().Kind() != jsonlex.TokenString {
 		return "", nil, fmt.Errorf("object key must be string at %s, got %s",
 			p.positionStr(), p.peek().Kind())
 	}
 
 	keyToken := p.current
 	p.advance()
-	key := p.unquoteString(keyToken.ValueString())
+	key := p.internKey(keyToken.Value())
 
 	// ":"
-	if err := p.expect(tokenizer.TokenColon); err != nil {
+	if err := p.expect(jsonlex.TokenColon); err != nil {
 		return "", nil, fmt.Errorf("expected ':' after object key %q: %w", key, err)
 	}
 
 	// Value
 	value, err := p.parseValue()
 	if err != nil {
-		return "", nil, fmt.Errorf("in value for key %q: %w", key, err)
+		return "", nil, within(err, "in value for key %q", key)
 	}
 
 	return key, value, nil
 }
 
+// internKey returns the key written as the string token value, from a
+// pooled intern.Cache, so that the objects of a large array share their
+// keys instead of each holding copies.
+func (p *Parser) internKey(value []rune) string {
+	if p.keys == nil {
+		p.keys = intern.Get()
+	}
+	n := len(value)
+	if n >= 2 && value[0] == '"' && value[n-1] == '"' && !slices.Contains(value, '\\') {
+		return p.keys.Runes(value[1 : n-1])
+	}
+	return p.keys.String(p.unquoteString(string(value)))
+}
+
+// releaseKeys returns the parser's intern.Cache to the pool.
+func (p *Parser) releaseKeys() {
+	if p.keys != nil {
+		intern.Put(p.keys)
+		p.keys = nil
+	}
+}
+
 // parseArray parses a JSON array.
 //
 // Grammar:
@@ -204,7 +296,7 @@ func (p *Parser) parseArray() (ast.SchemaNode, error) {
 	startPos := p.position()
 
 	// "["
-	if err := p.expect(tokenizer.TokenLBracket); err != nil {
+	if err := p.expect(jsonlex.TokenLBracket); err != nil {
 		return nil, err
 	}
 
@@ -212,7 +304,7 @@ func (p *Parser) parseArray() (ast.SchemaNode, error) {
 	elements := make([]ast.SchemaNode, 0, 16)
 
 	// [ Value { "," Value } ]  - Optional value list
-	if p.peek().Kind() != tokenizer.TokenRBracket {
+	if p.peek().Kind() != jsonlex.TokenRBracket {
 		// First value
 		value, err := p.parseValue()
 		if err != nil {
@@ -221,19 +313,23 @@ func (p *Parser) parseArray() (ast.SchemaNode, error) {
 		elements = append(elements, value)
 
 		// Additional values: { "," Value }
-		for p.peek().Kind() == tokenizer.TokenComma {
+		for p.peek().Kind() == jsonlex.TokenComma {
 			p.advance() // consume ","
 
 			value, err := p.parseValue()
 			if err != nil {
-				return nil, fmt.Errorf("in array element %d: %w", len(elements), err)
+				return nil, within(err, "in array element %d", len(elements))
 			}
 			elements = append(elements, value)
 		}
 	}
 
 	// "]"
-	if err := p.expect(tokenizer.TokenRBracket); err != nil {
+	if err := p.expect(jsonlex.TokenRBracket); err != nil {
+		return nil, err
+	}
+
+	if err := p.created(arrayNodeBytes + cap(elements)*elementBytes); err != nil {
 		return nil, err
 	}
 
@@ -250,7 +346,7 @@ func (p *Parser) parseArray() (ast.SchemaNode, error) {
 // Returns *ast.LiteralNode with the unescaped string value.
 // Handles escape sequences: \", \\, \/, \b, \f, \n, \r, \t, \uXXXX
 func (p *Parser) parseString() (*ast.LiteralNode, error) {
-	if p.peek().Kind() != tokenizer.TokenString {
+	if p.peek().Kind() != jsonlex.TokenString {
 		return nil, fmt.Errorf("expected string at %s, got %s",
 			p.positionStr(), p.peek().Kind())
 	}
@@ -262,6 +358,9 @@ func (p *Parser) parseString() (*ast.LiteralNode, error) {
 	// Unquote and unescape the string
 	unquoted := p.unquoteString(tokenValue)
 
+	if err := p.created(literalNodeBytes + stringBytes + len(unquoted)); err != nil {
+		return nil, err
+	}
 	return ast.NewLiteralNode(unquoted, pos), nil
 }
 
@@ -274,7 +373,7 @@ func (p *Parser) parseString() (*ast.LiteralNode, error) {
 // Returns *ast.LiteralNode with int64 or float64 value.
 // Examples: 0, -123, 123.456, 1e10, 1.5e-3
 func (p *Parser) parseNumber() (*ast.LiteralNode, error) {
-	if p.peek().Kind() != tokenizer.TokenNumber {
+	if p.peek().Kind() != jsonlex.TokenNumber {
 		return nil, fmt.Errorf("expected number at %s, got %s",
 			p.positionStr(), p.peek().Kind())
 	}
@@ -283,6 +382,10 @@ func (p *Parser) parseNumber() (*ast.LiteralNode, error) {
 	tokenValue := p.current.ValueString()
 	p.advance()
 
+	if err := p.created(literalNodeBytes); err != nil {
+		return nil, err
+	}
+
 	// Try parsing as integer first
 	if !strings.Contains(tokenValue, ".") && !strings.ContainsAny(tokenValue, "eE") {
 		i, err := strconv.ParseInt(tokenValue, 10, 64)
@@ -309,15 +412,19 @@ func (p *Parser) parseNumber() (*ast.LiteralNode, error) {
 // Returns *ast.LiteralNode with bool value.
 func (p *Parser) parseBoolean() (*ast.LiteralNode, error) {
 	kind := p.peek().Kind()
-	if kind != tokenizer.TokenTrue && kind != tokenizer.TokenFalse {
+	if kind != jsonlex.TokenTrue && kind != jsonlex.TokenFalse {
 		return nil, fmt.Errorf("expected boolean at %s, got %s",
 			p.positionStr(), kind)
 	}
 
 	pos := p.position()
-	value := kind == tokenizer.TokenTrue
+	value := kind == jsonlex.TokenTrue
 	p.advance()
 
+	if err := p.created(literalNodeBytes); err != nil {
+		return nil, err
+	}
+
 	return ast.NewLiteralNode(value, pos), nil
 }
 
@@ -329,7 +436,7 @@ func (p *Parser) parseBoolean() (*ast.LiteralNode, error) {
 //
 // Returns *ast.LiteralNode with nil value.
 func (p *Parser) parseNull() (*ast.LiteralNode, error) {
-	if p.peek().Kind() != tokenizer.TokenNull {
+	if p.peek().Kind() != jsonlex.TokenNull {
 		return nil, fmt.Errorf("expected null at %s, got %s",
 			p.positionStr(), p.peek().Kind())
 	}
@@ -337,6 +444,9 @@ func (p *Parser) parseNull() (*ast.LiteralNode, error) {
 	pos := p.position()
 	p.advance()
 
+	if err := p.created(literalNodeBytes); err != nil {
+		return nil, err
+	}
 	return ast.NewLiteralNode(nil, pos), nil
 }
 
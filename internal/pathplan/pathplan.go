@@ -0,0 +1,37 @@
+// Package pathplan passes the streaming plan of a JSONPath query from
+// pkg/jsonpath, which builds it from the compiled selectors, to
+// json.Decoder.Filter, which follows it through the input, without making
+// it part of the public API of either package.
+package pathplan
+
+// Plan is a query split for evaluation over a document that is read as a
+// stream of tokens rather than held in memory. Its steps are the leading
+// selectors that pick a child by name, by non-negative index, or with a
+// wildcard, optionally followed by one filter; a reader can follow them
+// knowing only the name or index of each member or element it passes, so
+// the values they skip are never decoded. The values the steps reach are
+// decoded one at a time and passed to Apply.
+type Plan interface {
+	// Len returns the number of steps, which is the depth below the root
+	// of the values passed to Apply.
+	Len() int
+
+	// Member reports whether step i selects the object member named name.
+	Member(i int, name string) bool
+
+	// Element reports whether step i may select element n of an array. A
+	// filter step selects every element here; Apply then evaluates the
+	// filter against the element.
+	Element(i, n int) bool
+
+	// Apply returns the values the query selects from v, a value reached
+	// by the steps, or with no steps a whole document, in the order
+	// Expr.Get returns them.
+	Apply(v interface{}) []interface{}
+}
+
+// ForStream parses query and plans it. Every query that parses can be
+// planned; one that begins with a selector a stream cannot follow, such as
+// $..name or $[-1], has no steps. It is set by pkg/jsonpath when the
+// package is initialized.
+var ForStream func(query string) (Plan, error)
@@ -0,0 +1,63 @@
+// Package sizehint keeps per-type moving estimates of how large values turn
+// out to be, so the encoder and decoder can allocate a buffer, slice or map
+// once at about its final size instead of growing it step by step.
+package sizehint
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Table maps types to a moving estimate of a size observed for them, such
+// as the encoded length of a value or the element count of a slice. The
+// zero value is empty and ready to use, and a Table is safe for concurrent
+// use.
+type Table struct {
+	// Max caps the estimate Get returns, so one outsized value cannot make
+	// every later one allocate as much. Zero means no cap.
+	Max int
+
+	m sync.Map // reflect.Type -> *atomic.Int64
+}
+
+// Get returns the estimate for t, or 0 if nothing has been observed for it.
+func (h *Table) Get(t reflect.Type) int {
+	v, ok := h.m.Load(t)
+	if !ok {
+		return 0
+	}
+	n := int(v.(*atomic.Int64).Load())
+	if h.Max > 0 && n > h.Max {
+		n = h.Max
+	}
+	return n
+}
+
+// Observe folds the size n of a value of type t into its estimate. The
+// first observation becomes the estimate; later ones move it a quarter of
+// the way towards n, rounding away from the old estimate so that a steady
+// size is reached rather than only approached. Concurrent observations may overwrite each other,
+// which only costs accuracy.
+func (h *Table) Observe(t reflect.Type, n int) {
+	v, ok := h.m.Load(t)
+	if !ok {
+		v, ok = h.m.LoadOrStore(t, new(atomic.Int64))
+		if !ok {
+			v.(*atomic.Int64).Store(int64(n))
+			return
+		}
+	}
+	est := v.(*atomic.Int64)
+	old := est.Load()
+	d := int64(n) - old
+	if d > 0 {
+		d += 3
+	} else {
+		d -= 3
+	}
+	next := old + d/4
+	if next != old {
+		est.Store(next)
+	}
+}
@@ -0,0 +1,46 @@
+package sizehint
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableObserve(t *testing.T) {
+	var h Table
+	typ := reflect.TypeOf([]int(nil))
+
+	if got := h.Get(typ); got != 0 {
+		t.Fatalf("Get before Observe = %d, want 0", got)
+	}
+
+	h.Observe(typ, 100)
+	if got := h.Get(typ); got != 100 {
+		t.Fatalf("Get after first Observe = %d, want 100", got)
+	}
+
+	h.Observe(typ, 20)
+	if got := h.Get(typ); got != 80 {
+		t.Errorf("Get after Observe(20) = %d, want 80", got)
+	}
+
+	for i := 0; i < 20; i++ {
+		h.Observe(typ, 50)
+	}
+	if got := h.Get(typ); got != 50 {
+		t.Errorf("Get after steady Observe(50) = %d, want 50", got)
+	}
+
+	if got := h.Get(reflect.TypeOf("")); got != 0 {
+		t.Errorf("Get for another type = %d, want 0", got)
+	}
+}
+
+func TestTableMax(t *testing.T) {
+	h := Table{Max: 64}
+	typ := reflect.TypeOf("")
+
+	h.Observe(typ, 1000)
+	if got := h.Get(typ); got != 64 {
+		t.Errorf("Get = %d, want Max 64", got)
+	}
+}
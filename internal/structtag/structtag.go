@@ -0,0 +1,125 @@
+// Package structtag checks the options of json struct tags, for the
+// reflection-based json.VetTags and json.VetType and the static jsonvet
+// analyzer alike.
+package structtag
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// options are the options the json package reads, and whether each takes
+// a value.
+var options = map[string]bool{
+	"omitempty": false,
+	"string":    false,
+	"coerce":    false,
+	"required":  false,
+	"unknown":   false,
+	"transform": true,
+}
+
+// reserved are options kept for future use. Their values are parsed, so
+// that quoted commas in them split nothing, but they have no effect yet.
+var reserved = map[string]bool{
+	"format":  true,
+	"default": true,
+}
+
+// Vet returns the problems with tag, a json struct tag, as messages such
+// as `unknown option "omitempy" (did you mean "omitempty"?)`. typ is the
+// type of the field, and stringMap tells whether it is a map with string
+// keys, the only type the "unknown" option applies to.
+func Vet(tag, typ string, stringMap bool) []string {
+	if tag == "-" {
+		return nil
+	}
+	var msgs []string
+	_, opts, _ := strings.Cut(tag, ",")
+	seen := map[string]bool{}
+	for opts != "" {
+		var opt string
+		opt, opts = fastparser.NextTagOption(opts)
+		key, _, hasValue, err := fastparser.TagOptionValue(opt)
+		takesValue, known := options[key]
+		switch {
+		case opt == "":
+			msgs = append(msgs, "empty option")
+			continue
+		case err != nil:
+			msgs = append(msgs, fmt.Sprintf("option %q: %v", opt, err))
+		case reserved[key]:
+			msgs = append(msgs, fmt.Sprintf("option %q is reserved and has no effect", key))
+		case !known:
+			msg := fmt.Sprintf("unknown option %q", key)
+			if s := suggest(key); s != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", s)
+			}
+			msgs = append(msgs, msg)
+		case takesValue && !hasValue:
+			msgs = append(msgs, fmt.Sprintf("option %q needs a value, as in %s:<name>", key, key))
+		case !takesValue && hasValue:
+			msgs = append(msgs, fmt.Sprintf("option %q takes no value", key))
+		case key == "unknown" && !stringMap:
+			msgs = append(msgs, fmt.Sprintf("option \"unknown\" is ignored on a field of type %s; it needs a map with string keys", typ))
+		}
+		if seen[key] {
+			msgs = append(msgs, fmt.Sprintf("option %q repeated", key))
+		}
+		seen[key] = true
+	}
+	return msgs
+}
+
+// Name returns the JSON name a field named field gets from tag, and false
+// if the tag is "-", which skips the field.
+func Name(field, tag string) (string, bool) {
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field
+	}
+	return name, true
+}
+
+// suggest returns the known option closest to key, if it is within two
+// edits of it, or "".
+func suggest(key string) string {
+	best, bestDist := "", 3
+	for opt := range options {
+		if d := editDistance(key, opt); d < bestDist || d == bestDist && opt < best {
+			best, bestDist = opt, d
+		}
+	}
+	return best
+}
+
+// editDistance is the number of insertions, deletions, substitutions, and
+// transpositions of adjacent bytes that turn a into b.
+func editDistance(a, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,63 @@
+package structtag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVet(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{"name,omitempty", nil},
+		{"-", nil},
+		{"name,transform:'vault,v2',required", nil},
+		{"name,omitemtpy", []string{`unknown option "omitemtpy" (did you mean "omitempty"?)`}},
+		{"name,bogus", []string{`unknown option "bogus"`}},
+		{"name,unknown", []string{`option "unknown" is ignored on a field of type int; it needs a map with string keys`}},
+		{"name,string,string", []string{`option "string" repeated`}},
+	}
+	for _, tt := range tests {
+		if got := Vet(tt.tag, "int", false); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Vet(%q) = %q, want %q", tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		field, tag, want string
+		ok               bool
+	}{
+		{"ID", "", "ID", true},
+		{"ID", "id,omitempty", "id", true},
+		{"ID", ",omitempty", "ID", true},
+		{"ID", "-", "", false},
+		{"ID", "-,", "-", true},
+	}
+	for _, tt := range tests {
+		if got, ok := Name(tt.field, tt.tag); got != tt.want || ok != tt.ok {
+			t.Errorf("Name(%q, %q) = %q, %v; want %q, %v", tt.field, tt.tag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"omitempty", "omitempty", 0},
+		{"omitempy", "omitempty", 1},
+		{"omitemtpy", "omitempty", 1},
+		{"requierd", "required", 1},
+		{"", "string", 6},
+		{"trasnfrom", "transform", 2},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
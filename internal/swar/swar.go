@@ -0,0 +1,48 @@
+// Package swar tests the eight bytes of a 64-bit word at once (SIMD within
+// a register), for the byte scanners of the parser and the encoder. Words
+// are read little-endian, so the lowest set bit of a result marks the
+// first matching byte in memory: bits.TrailingZeros64(m)>>3 is its index.
+package swar
+
+// Masks with the low or the high bit of each byte set.
+const (
+	Low  = 0x0101010101010101
+	High = 0x8080808080808080
+)
+
+// Below returns a word with the high bit set in exactly those of the
+// eight bytes of w that are less than c, which must be below 0x80, and
+// every other bit clear.
+func Below(w uint64, c byte) uint64 {
+	return ^((w&^High + Low*uint64(0x80-c)) | w) & High
+}
+
+// Above returns a word with the high bit set in exactly those of the
+// eight bytes of w that are greater than c, which must be below 0x80, and
+// every other bit clear.
+func Above(w uint64, c byte) uint64 {
+	return ((w&^High + Low*uint64(0x7f-c)) | w) & High
+}
+
+// Equal returns a word with the high bit set in exactly those of the
+// eight bytes of w that are c, which must be below 0x80, and every other
+// bit clear.
+func Equal(w uint64, c byte) uint64 {
+	return Below(w^(Low*uint64(c)), 1)
+}
+
+// Has is a cheaper Equal for telling whether any byte of w is c: it is
+// zero exactly when Equal is and has the same lowest set bit, but the bits
+// above that may be spurious.
+func Has(w uint64, c byte) uint64 {
+	x := w ^ (Low * uint64(c))
+	return (x - Low) &^ x & High
+}
+
+// StringSpecial is nonzero exactly when one of the eight bytes of w ends a
+// plain run of string contents: a quote, a backslash, or a control
+// character. Its lowest set bit is the high bit of the first such byte;
+// the bits above that may be spurious.
+func StringSpecial(w uint64) uint64 {
+	return Has(w, '"') | Has(w, '\\') | (w-Low*0x20)&^w&High
+}
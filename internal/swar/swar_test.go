@@ -0,0 +1,59 @@
+package swar
+
+import (
+	"encoding/binary"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+// TestExact checks Below, Above, and Equal byte by byte against plain
+// comparisons.
+func TestExact(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	var b [8]byte
+	for n := 0; n < 20000; n++ {
+		for i := range b {
+			b[i] = byte(rng.Intn(256))
+		}
+		// Bias towards the bytes the scanners look for
+		b[rng.Intn(8)] = " \"\\/\x00\x1f\x7f\x80"[rng.Intn(8)]
+		w := binary.LittleEndian.Uint64(b[:])
+		c := byte(rng.Intn(0x80))
+
+		var below, above, equal uint64
+		for i, x := range b {
+			bit := uint64(0x80) << (8 * i)
+			if x < c {
+				below |= bit
+			}
+			if x > c {
+				above |= bit
+			}
+			if x == c {
+				equal |= bit
+			}
+		}
+		if got := Below(w, c); got != below {
+			t.Fatalf("Below(%q, %q) = %#x, want %#x", b, c, got, below)
+		}
+		if got := Above(w, c); got != above {
+			t.Fatalf("Above(%q, %q) = %#x, want %#x", b, c, got, above)
+		}
+		if got := Equal(w, c); got != equal {
+			t.Fatalf("Equal(%q, %q) = %#x, want %#x", b, c, got, equal)
+		}
+		if got := Has(w, c); lowest(got) != lowest(equal) {
+			t.Fatalf("Has(%q, %q) = %#x, want lowest bit of %#x", b, c, got, equal)
+		}
+
+		special := Below(w, 0x20) | Equal(w, '"') | Equal(w, '\\')
+		if got := StringSpecial(w); lowest(got) != lowest(special) {
+			t.Fatalf("StringSpecial(%q) = %#x, want lowest bit of %#x", b, got, special)
+		}
+	}
+}
+
+func lowest(m uint64) int {
+	return bits.TrailingZeros64(m)
+}
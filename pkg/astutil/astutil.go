@@ -0,0 +1,227 @@
+// Package astutil queries the ASTs built by the json package's parsers
+// without a type assertion at every step.
+//
+// Values are reached by a path of member names and array indexes, and the
+// typed getters report whether the value exists and has the expected kind:
+//
+//	node, _ := json.Parse(`{"user": {"tags": ["go", "json"]}}`)
+//	tag, ok := astutil.GetString(node, "user", "tags", 0) // "go", true
+//
+// FindAll walks a whole tree for the nodes matching a predicate, and
+// KindOf classifies a node by the JSON value it holds.
+package astutil
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+// Kind is the kind of JSON value an AST node holds.
+type Kind int
+
+const (
+	// Invalid is the kind of a nil node, and of nodes that hold no JSON
+	// value, such as schema nodes.
+	Invalid Kind = iota
+	Null
+	Bool
+	Number
+	String
+	Object
+	Array
+)
+
+// String returns the JSON name of the kind, such as "object".
+func (k Kind) String() string {
+	switch k {
+	case Null:
+		return "null"
+	case Bool:
+		return "boolean"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Object:
+		return "object"
+	case Array:
+		return "array"
+	case Invalid:
+		return "invalid"
+	default:
+		return "Kind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// KindOf returns the kind of value node holds.
+func KindOf(node ast.SchemaNode) Kind {
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		if n != nil {
+			return Object
+		}
+	case *ast.ArrayDataNode:
+		if n != nil {
+			return Array
+		}
+	case *ast.LiteralNode:
+		if n == nil {
+			return Invalid
+		}
+		switch n.Value().(type) {
+		case nil:
+			return Null
+		case bool:
+			return Bool
+		case string:
+			return String
+		case int64, float64:
+			return Number
+		}
+	}
+	return Invalid
+}
+
+// FindAll returns the nodes of the tree rooted at node, node included, for
+// which match returns true. Parents come before their descendants, array
+// elements in order, and object members sorted by name.
+func FindAll(node ast.SchemaNode, match func(ast.SchemaNode) bool) []ast.SchemaNode {
+	var found []ast.SchemaNode
+	var walk func(ast.SchemaNode)
+	walk = func(n ast.SchemaNode) {
+		if n == nil {
+			return
+		}
+		if match(n) {
+			found = append(found, n)
+		}
+		switch n := n.(type) {
+		case *ast.ObjectNode:
+			props := n.Properties()
+			keys := make([]string, 0, len(props))
+			for k := range props {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(props[k])
+			}
+		case *ast.ArrayDataNode:
+			for _, elem := range n.Elements() {
+				walk(elem)
+			}
+		}
+	}
+	walk(node)
+	return found
+}
+
+// Get returns the node reached from node by path, whose elements are
+// member names (strings) of objects and indexes (ints) into arrays. It
+// reports false if a step does not exist, or names a member of something
+// other than an object or an index into something other than an array.
+// An empty path returns node itself.
+func Get(node ast.SchemaNode, path ...interface{}) (ast.SchemaNode, bool) {
+	for _, step := range path {
+		switch step := step.(type) {
+		case string:
+			obj, ok := node.(*ast.ObjectNode)
+			if !ok || obj == nil {
+				return nil, false
+			}
+			if node, ok = obj.GetProperty(step); !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := node.(*ast.ArrayDataNode)
+			if !ok || arr == nil {
+				return nil, false
+			}
+			if node = arr.Get(step); node == nil {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+	return node, node != nil
+}
+
+// literal returns the value of the literal node at path.
+func literal(node ast.SchemaNode, path []interface{}) (interface{}, bool) {
+	n, ok := Get(node, path...)
+	if !ok {
+		return nil, false
+	}
+	lit, ok := n.(*ast.LiteralNode)
+	if !ok || lit == nil {
+		return nil, false
+	}
+	return lit.Value(), true
+}
+
+// GetString returns the string at path; see Get. It reports false if there
+// is no value there or it is not a string.
+func GetString(node ast.SchemaNode, path ...interface{}) (string, bool) {
+	v, _ := literal(node, path)
+	s, ok := v.(string)
+	return s, ok
+}
+
+// GetBool returns the boolean at path; see Get.
+func GetBool(node ast.SchemaNode, path ...interface{}) (bool, bool) {
+	v, _ := literal(node, path)
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// GetInt returns the number at path as an int64; see Get. It reports false
+// if the number has a fraction or is out of the range of int64.
+func GetInt(node ast.SchemaNode, path ...interface{}) (int64, bool) {
+	v, _ := literal(node, path)
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// GetFloat returns the number at path as a float64; see Get.
+func GetFloat(node ast.SchemaNode, path ...interface{}) (float64, bool) {
+	v, _ := literal(node, path)
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// GetObject returns the object at path; see Get.
+func GetObject(node ast.SchemaNode, path ...interface{}) (*ast.ObjectNode, bool) {
+	n, _ := Get(node, path...)
+	obj, ok := n.(*ast.ObjectNode)
+	return obj, ok && obj != nil
+}
+
+// GetArray returns the array at path; see Get.
+func GetArray(node ast.SchemaNode, path ...interface{}) (*ast.ArrayDataNode, bool) {
+	n, _ := Get(node, path...)
+	arr, ok := n.(*ast.ArrayDataNode)
+	return arr, ok && arr != nil
+}
+
+// IsNull reports whether the value at path is null; see Get. It is false
+// if there is no value there.
+func IsNull(node ast.SchemaNode, path ...interface{}) bool {
+	n, _ := Get(node, path...)
+	return KindOf(n) == Null
+}
@@ -0,0 +1,155 @@
+package astutil_test
+
+import (
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-json/pkg/astutil"
+	"github.com/shapestone/shape-json/pkg/json"
+)
+
+const doc = `{
+	"user": {"name": "Alice", "age": 30, "score": 9.5, "admin": false, "manager": null},
+	"tags": ["go", "json"],
+	"orders": [{"id": 1, "total": 20.0}, {"id": 2, "total": 1e20}]
+}`
+
+func parse(t *testing.T) ast.SchemaNode {
+	t.Helper()
+	node, err := json.Parse(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return node
+}
+
+func TestGet(t *testing.T) {
+	node := parse(t)
+
+	if got, ok := astutil.Get(node); !ok || got != node {
+		t.Error("Get with an empty path should return node")
+	}
+	if s, ok := astutil.GetString(node, "user", "name"); !ok || s != "Alice" {
+		t.Errorf(`GetString(user, name) = %q, %v`, s, ok)
+	}
+	if s, ok := astutil.GetString(node, "tags", 1); !ok || s != "json" {
+		t.Errorf(`GetString(tags, 1) = %q, %v`, s, ok)
+	}
+	if n, ok := astutil.GetInt(node, "orders", 1, "id"); !ok || n != 2 {
+		t.Errorf(`GetInt(orders, 1, id) = %d, %v`, n, ok)
+	}
+	if n, ok := astutil.GetInt(node, "orders", 0, "total"); !ok || n != 20 {
+		t.Errorf(`GetInt(orders, 0, total) = %d, %v; a whole float converts`, n, ok)
+	}
+	if _, ok := astutil.GetInt(node, "user", "score"); ok {
+		t.Error(`GetInt(user, score) of 9.5 should fail`)
+	}
+	if _, ok := astutil.GetInt(node, "orders", 1, "total"); ok {
+		t.Error(`GetInt(orders, 1, total) of 1e20 should fail`)
+	}
+	if f, ok := astutil.GetFloat(node, "user", "age"); !ok || f != 30 {
+		t.Errorf(`GetFloat(user, age) = %v, %v`, f, ok)
+	}
+	if b, ok := astutil.GetBool(node, "user", "admin"); !ok || b {
+		t.Errorf(`GetBool(user, admin) = %v, %v`, b, ok)
+	}
+	if !astutil.IsNull(node, "user", "manager") || astutil.IsNull(node, "user", "missing") {
+		t.Error("IsNull is wrong")
+	}
+	if obj, ok := astutil.GetObject(node, "orders", 0); !ok || len(obj.Properties()) != 2 {
+		t.Errorf("GetObject(orders, 0) = %v, %v", obj, ok)
+	}
+	if arr, ok := astutil.GetArray(node, "tags"); !ok || arr.Len() != 2 {
+		t.Errorf("GetArray(tags) = %v, %v", arr, ok)
+	}
+
+	missing := [][]interface{}{
+		{"nope"},
+		{"tags", 2},
+		{"tags", -1},
+		{"tags", "0"},
+		{"user", 0},
+		{"user", "name", "x"},
+		{"user", 1.5},
+	}
+	for _, path := range missing {
+		if _, ok := astutil.Get(node, path...); ok {
+			t.Errorf("Get(%v) found a value", path)
+		}
+	}
+	if _, ok := astutil.GetString(node, "user", "age"); ok {
+		t.Error("GetString of a number should fail")
+	}
+	if _, ok := astutil.GetArray(node, "user"); ok {
+		t.Error("GetArray of an object should fail")
+	}
+	if _, ok := astutil.Get(nil, "a"); ok {
+		t.Error("Get(nil) found a value")
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	node := parse(t)
+	tests := []struct {
+		path []interface{}
+		want astutil.Kind
+	}{
+		{nil, astutil.Object},
+		{[]interface{}{"tags"}, astutil.Array},
+		{[]interface{}{"tags", 0}, astutil.String},
+		{[]interface{}{"user", "age"}, astutil.Number},
+		{[]interface{}{"user", "score"}, astutil.Number},
+		{[]interface{}{"user", "admin"}, astutil.Bool},
+		{[]interface{}{"user", "manager"}, astutil.Null},
+	}
+	for _, tt := range tests {
+		n, _ := astutil.Get(node, tt.path...)
+		if got := astutil.KindOf(n); got != tt.want {
+			t.Errorf("KindOf(%v) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if got := astutil.KindOf(nil); got != astutil.Invalid {
+		t.Errorf("KindOf(nil) = %v, want invalid", got)
+	}
+	if got := astutil.Array.String(); got != "array" {
+		t.Errorf("Array.String() = %q", got)
+	}
+}
+
+func TestFindAll(t *testing.T) {
+	node := parse(t)
+
+	ids := astutil.FindAll(node, func(n ast.SchemaNode) bool {
+		obj, ok := n.(*ast.ObjectNode)
+		if !ok {
+			return false
+		}
+		_, ok = obj.GetProperty("id")
+		return ok
+	})
+	if len(ids) != 2 {
+		t.Fatalf("found %d objects with an id, want 2", len(ids))
+	}
+	for i, n := range ids {
+		if id, _ := astutil.GetInt(n, "id"); id != int64(i+1) {
+			t.Errorf("match %d has id %d, want %d", i, id, i+1)
+		}
+	}
+
+	strs := astutil.FindAll(node, func(n ast.SchemaNode) bool {
+		return astutil.KindOf(n) == astutil.String
+	})
+	var got []string
+	for _, n := range strs {
+		s, _ := astutil.GetString(n)
+		got = append(got, s)
+	}
+	// Members are visited sorted by name: tags before user
+	if want := []string{"go", "json", "Alice"}; len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("strings = %v, want %v", got, want)
+	}
+
+	if all := astutil.FindAll(node, func(ast.SchemaNode) bool { return true }); len(all) != 17 {
+		t.Errorf("FindAll(true) = %d nodes, want 17", len(all))
+	}
+}
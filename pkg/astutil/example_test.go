@@ -0,0 +1,39 @@
+package astutil_test
+
+import (
+	"fmt"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-json/pkg/astutil"
+	"github.com/shapestone/shape-json/pkg/json"
+)
+
+func Example() {
+	node, _ := json.Parse(`{"user": {"name": "Alice", "tags": ["go", "json"]}}`)
+
+	name, _ := astutil.GetString(node, "user", "name")
+	tag, _ := astutil.GetString(node, "user", "tags", 1)
+	fmt.Println(name, tag)
+
+	_, ok := astutil.GetString(node, "user", "email")
+	fmt.Println(ok)
+	// Output:
+	// Alice json
+	// false
+}
+
+func ExampleFindAll() {
+	node, _ := json.Parse(`{"a": [1, "x", 2.5], "b": {"c": 3}}`)
+
+	numbers := astutil.FindAll(node, func(n ast.SchemaNode) bool {
+		return astutil.KindOf(n) == astutil.Number
+	})
+	for _, n := range numbers {
+		f, _ := astutil.GetFloat(n)
+		fmt.Println(f)
+	}
+	// Output:
+	// 1
+	// 2.5
+	// 3
+}
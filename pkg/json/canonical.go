@@ -0,0 +1,84 @@
+package json
+
+import (
+	"math"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// canonicalize rewrites the JSON text produced by the encoder in the form
+// described at MarshalOptions.Canonical. Going through the text rather than
+// the encoders means the output of Marshaler implementations is canonicalized too.
+func canonicalize(data []byte) ([]byte, error) {
+	v, err := fastparser.NewParser(data).Parse()
+	if err != nil {
+		return nil, err
+	}
+	w := &canonicalWriter{buf: make([]byte, 0, len(data)), jcs: true}
+	if err := w.value(v); err != nil {
+		return nil, err
+	}
+	return w.buf, nil
+}
+
+// appendMinimalEscapedString is appendEscapedString without the escaping of
+// '/': only '"', '\\', and control characters are escaped, as RFC 8785
+// requires.
+func appendMinimalEscapedString(buf []byte, s string) []byte {
+	return appendEscaped(buf, s, &needsMinimalEscape)
+}
+
+// appendES6Float appends f the way ECMAScript's Number.prototype.toString
+// formats it: the shortest representation that round-trips, in plain
+// notation from 1e-6 up to 1e21 and in exponent notation ("1e-7", "1e+21")
+// outside that range. Negative zero is written as 0.
+func appendES6Float(buf []byte, f float64) []byte {
+	if f == 0 {
+		return append(buf, '0')
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, 64)
+	if format == 'e' {
+		// Go writes a two-digit exponent: 1e-07 becomes 1e-7
+		n := len(buf)
+		if n >= 4 && buf[n-4] == 'e' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf
+}
+
+// lessUTF16 orders strings by their UTF-16 code units, the member order
+// RFC 8785 prescribes. It differs from byte order only for characters above
+// U+FFFF, which sort before U+E000 through U+FFFF in UTF-16.
+func lessUTF16(a, b string) bool {
+	for a != "" && b != "" {
+		ra, na := utf8.DecodeRuneInString(a)
+		rb, nb := utf8.DecodeRuneInString(b)
+		if ra != rb || (ra == utf8.RuneError && a[0] != b[0]) {
+			if ua, ub := utf16Unit(ra), utf16Unit(rb); ua != ub {
+				return ua < ub
+			}
+			// Same high surrogate, or invalid UTF-8: byte order agrees
+			return a < b
+		}
+		a, b = a[na:], b[nb:]
+	}
+	return len(a) < len(b)
+}
+
+// utf16Unit returns the first UTF-16 code unit of r.
+func utf16Unit(r rune) rune {
+	if r >= 0x10000 {
+		hi, _ := utf16.EncodeRune(r)
+		return hi
+	}
+	return r
+}
@@ -0,0 +1,125 @@
+package json
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+type canonicalClaims struct {
+	Subject  string                 `json:"sub"`
+	Issuer   string                 `json:"iss"`
+	Expiry   int64                  `json:"exp"`
+	Audience []string               `json:"aud,omitempty"`
+	Extra    map[string]interface{} `json:"extra,omitempty"`
+	Scope    scopeList              `json:"scope"`
+}
+
+// scopeList marshals itself with whitespace and unsorted keys.
+type scopeList []string
+
+func (s scopeList) MarshalJSON() ([]byte, error) {
+	return []byte(`{ "z": 1.50, "a": ` + `"` + strings.Join(s, " ") + `" }`), nil
+}
+
+// rawJSON marshals as its own text.
+type rawJSON string
+
+func (r rawJSON) MarshalJSON() ([]byte, error) { return []byte(r), nil }
+
+func TestMarshalWithOptions_Canonical(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	claims := canonicalClaims{
+		Subject: "user/1",
+		Issuer:  "https://issuer.example.com/<a&b>",
+		Expiry:  1700000000,
+		Extra:   map[string]interface{}{"n": 1e21, "f": 1e-7, "g": 100.0, "neg": math.Copysign(0, -1)},
+		Scope:   scopeList{"read", "write"},
+	}
+
+	got, err := MarshalWithOptions(claims, MarshalOptions{Canonical: true})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	want := `{"exp":1700000000,"extra":{"f":1e-7,"g":100,"n":1e+21,"neg":0},"iss":"https://issuer.example.com/<a&b>","scope":{"a":"read write","z":1.5},"sub":"user/1"}`
+	if string(got) != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// Without the option the default encoder output is unchanged
+	plain, err := MarshalWithOptions(claims, MarshalOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(plain), `"user\/1"`) {
+		t.Errorf("plain output = %s", plain)
+	}
+}
+
+func TestMarshalWithOptions_CanonicalRFC8785(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		// Number vectors from RFC 8785 Appendix B and section 3.2.2.3
+		{name: "numbers", input: `[333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001, -0, 1e-6, 1e20, 9007199254740992, 5e-324]`,
+			want: `[333333333.3333333,1e+30,4.5,0.002,1e-27,0,0.000001,100000000000000000000,9007199254740992,5e-324]`},
+		{name: "strings", input: `"\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/"`,
+			want: `"€$\u000f\nA'B\"\\\\\"/"`},
+		{name: "member order", input: `{"\u20ac":"Euro Sign","\r":"Carriage Return","\ufb33":"Hebrew Letter Dalet With Dagesh","1":"One","\ud83d\ude00":"Emoji: Grinning Face","\u0080":"Control","\u00f6":"Latin Small Letter O With Diaeresis"}`,
+			want: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"\u00f6\":\"Latin Small Letter O With Diaeresis\",\"\u20ac\":\"Euro Sign\",\"\U0001f600\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"},
+		{name: "whitespace", input: " { \"b\" : [ 1 , true , null ] , \"a\" : { } } ", want: `{"a":{},"b":[1,true,null]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalWithOptions(rawJSON(tt.input), MarshalOptions{Canonical: true})
+			if err != nil {
+				t.Fatalf("MarshalWithOptions() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalWithOptions_CanonicalDeterministic(t *testing.T) {
+	m := map[string]interface{}{}
+	for _, k := range []string{"b", "a", "\xff", "\xfe", "😀", "\uffff", "c"} {
+		m[k] = len(k)
+	}
+	first, err := MarshalWithOptions(m, MarshalOptions{Canonical: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		got, _ := MarshalWithOptions(m, MarshalOptions{Canonical: true})
+		if string(got) != string(first) {
+			t.Fatalf("output differs between runs:\n%s\n%s", first, got)
+		}
+	}
+}
+
+func TestLessUTF16(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"a", "b", true},
+		{"a", "ab", true},
+		{"ab", "a", false},
+		{"😀", "\uffff", true}, // surrogates sort below U+E000
+		{"😀", "😁", true},
+		{"\xfe", "\xff", true},
+		{"x", "x", false},
+	}
+	for _, tt := range tests {
+		if got := lessUTF16(tt.a, tt.b); got != tt.want {
+			t.Errorf("lessUTF16(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
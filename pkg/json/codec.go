@@ -0,0 +1,40 @@
+package json
+
+import (
+	"reflect"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Marshaler is the interface implemented by types that can marshal themselves into valid JSON.
+type Marshaler interface {
+	MarshalJSON() ([]byte, error)
+}
+
+// PathError is returned by Unmarshal and its variants when a value nested
+// inside the document fails to decode. Path locates the value, and Err is
+// the underlying error:
+//
+//	json: users[3].address.zip: cannot unmarshal number into Go value of type string
+//
+// Use errors.As to get the path, and errors.Is or errors.As on the
+// PathError to inspect Err. Errors decoding the top-level value itself are
+// returned without a PathError.
+type PathError = fastparser.PathError
+
+// Unmarshaler is the interface implemented by types that can unmarshal a JSON description of themselves.
+type Unmarshaler interface {
+	UnmarshalJSON([]byte) error
+}
+
+// TypeResolver selects the concrete type to decode into for values of an
+// interface type. Resolvers are registered through UnmarshalOptions.Resolvers;
+// Polymorphic is the standard implementation.
+type TypeResolver interface {
+	// InterfaceType returns the interface type the resolver handles.
+	InterfaceType() reflect.Type
+
+	// ResolveType returns the concrete type for the JSON value in data.
+	// The type must implement InterfaceType.
+	ResolveType(data []byte) (reflect.Type, error)
+}
@@ -0,0 +1,285 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"encoding"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// This file holds the encoding rules of encoding/json that Marshal follows
+// under StrictStdlib where its own defaults differ.
+
+// appendStdlibFloat appends f, a float of the given bit size, as
+// encoding/json writes it: without an exponent from 1e-6 up to 1e21, and
+// with a two-digit exponent shortened to one ("1e-7", not "1e-07") outside
+// that range. NaN and infinities are an error.
+func appendStdlibFloat(buf []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return buf, fmt.Errorf("json: unsupported value: %s", strconv.FormatFloat(f, 'g', -1, bits))
+	}
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		if n := len(buf); n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+	return buf, nil
+}
+
+// appendStdlibString appends s escaped as encoding/json escapes it, without
+// the surrounding quotes: '<', '>', and '&' are written as \u003c, \u003e,
+// and \u0026, U+2028 and U+2029 as \u2028 and \u2029, invalid UTF-8 as
+// U+FFFD, and '/' as is.
+func appendStdlibString(buf []byte, s string) []byte {
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			buf = append(append(buf, s[start:i]...), string(utf8.RuneError)...)
+		case r == '\u2028' || r == '\u2029':
+			buf = append(append(buf, s[start:i]...), '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+		default:
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+	return append(buf, s[start:]...)
+}
+
+// appendStdlibBytes appends b as encoding/json writes a []byte: a base64
+// string, or null for a nil slice.
+func appendStdlibBytes(buf []byte, b []byte) []byte {
+	if b == nil {
+		return append(buf, "null"...)
+	}
+	buf = append(buf, '"')
+	buf = base64.StdEncoding.AppendEncode(buf, b)
+	return append(buf, '"')
+}
+
+// appendStdlibMarshaled appends out, the result of the MarshalJSON method
+// of a value of type t, as encoding/json does: without whitespace between
+// tokens, with '<', '>', '&', U+2028, and U+2029 escaped, and an error if
+// it is not valid JSON.
+func appendStdlibMarshaled(buf []byte, out []byte, t reflect.Type) ([]byte, error) {
+	if err := fastparser.Validate(out); err != nil {
+		return buf, fmt.Errorf("json: error calling MarshalJSON for type %s: %w", t, err)
+	}
+	// Valid JSON has these characters only inside strings, so they can be
+	// escaped wherever they appear
+	inString := false
+	start := 0
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case !inString && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			buf = append(buf, out[start:i]...)
+			start = i + 1
+		case c == '<' || c == '>' || c == '&':
+			buf = append(append(buf, out[start:i]...), '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+			start = i + 1
+		case c == 0xE2 && i+2 < len(out) && out[i+1] == 0x80 && out[i+2]&^1 == 0xA8:
+			buf = append(append(buf, out[start:i]...), '\\', 'u', '2', '0', '2', hexDigits[out[i+2]&0xF])
+			i += 2
+			start = i + 1
+		}
+	}
+	return append(buf, out[start:]...), nil
+}
+
+// buildTextMarshalerEnc encodes a type that implements
+// encoding.TextMarshaler, or whose pointer does if addr is set, as
+// encoding/json does under StrictStdlib: as a JSON string of the text it
+// marshals to. Otherwise, and for values that cannot be addressed, it uses
+// plain.
+func buildTextMarshalerEnc(plain encoderFunc, addr bool) encoderFunc {
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+		if !e.stdlib {
+			return plain(e, buf, rv)
+		}
+		if addr {
+			if !rv.CanAddr() {
+				return plain(e, buf, rv)
+			}
+			rv = rv.Addr()
+		}
+		if (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+			return append(buf, "null"...), nil
+		}
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return buf, fmt.Errorf("json: error calling MarshalText for type %s: %w", rv.Type(), err)
+		}
+		buf = append(buf, '"')
+		buf = appendStdlibString(buf, string(text))
+		return append(buf, '"'), nil
+	}
+}
+
+// buildStdlibMapEncoder encodes maps whose keys are not strings, which
+// only StrictStdlib supports. As in encoding/json, a key that implements
+// encoding.TextMarshaler is written as its text and an integer key in
+// decimal, and members are sorted by those names.
+func buildStdlibMapEncoder(t reflect.Type) encoderFunc {
+	keyName := stdlibMapKeyName(t.Key())
+	valEnc := encoderForType(t.Elem())
+
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+		if !e.stdlib || keyName == nil {
+			return buf, fmt.Errorf("json: unsupported map key type %s", t.Key())
+		}
+		if rv.IsNil() {
+			return append(buf, "null"...), nil
+		}
+		if rv.Len() == 0 {
+			return append(buf, "{}"...), nil
+		}
+
+		if err := e.enter(rv); err != nil {
+			return buf, err
+		}
+		buf, err := appendStdlibMap(e, buf, rv, keyName, valEnc)
+		e.leave(rv, err)
+		return buf, err
+	}
+}
+
+// stdlibMapKeyName returns the function that names the map keys of type kt
+// as encoding/json does, or nil if encoding/json does not support them.
+func stdlibMapKeyName(kt reflect.Type) func(reflect.Value) (string, error) {
+	if kt.Implements(textMarshalerType) {
+		return func(k reflect.Value) (string, error) {
+			if k.Kind() == reflect.Ptr && k.IsNil() {
+				return "", nil
+			}
+			text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return "", fmt.Errorf("json: encoding error for type %q: %q", kt.String(), err.Error())
+			}
+			return string(text), nil
+		}
+	}
+	switch kt.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(k reflect.Value) (string, error) {
+			return strconv.FormatInt(k.Int(), 10), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return func(k reflect.Value) (string, error) {
+			return strconv.FormatUint(k.Uint(), 10), nil
+		}
+	}
+	return nil
+}
+
+// appendStdlibMap writes the non-empty map rv with its keys named by
+// keyName, sorted by name in the order of e.
+func appendStdlibMap(e *encodeState, buf []byte, rv reflect.Value, keyName func(reflect.Value) (string, error), valEnc encoderFunc) ([]byte, error) {
+	type member struct {
+		name  string
+		value reflect.Value
+	}
+	members := make([]member, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		name, err := keyName(iter.Key())
+		if err != nil {
+			return buf, err
+		}
+		members = append(members, member{name, iter.Value()})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return e.less(members[i].name, members[j].name)
+	})
+
+	buf = append(buf, '{')
+	for i, m := range members {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '"')
+		buf = e.appendString(buf, m.name)
+		buf = append(buf, '"', ':')
+
+		var err error
+		buf, err = valEnc(e, buf, m.value)
+		if err != nil {
+			cycleMember(err, m.name)
+			return buf, err
+		}
+		if err := e.checkSize(buf); err != nil {
+			return buf, err
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+// stdlibStructFields returns the fields that encoding/json writes for the
+// struct type t, with those of embedded structs promoted as
+// fastparser.FlatFields describes. The ",unknown" option is honored on the
+// struct's own fields, which buildStructEncoder handles.
+func stdlibStructFields(t reflect.Type) []structField {
+	var fields []structField
+	for _, ff := range fastparser.FlatFields(t) {
+		info := getFieldInfo(ff.Field)
+		if info.unknown && len(ff.Index) == 1 {
+			continue
+		}
+		info.name = ff.Name
+		f := newStructField(ff.Index[len(ff.Index)-1], ff.Field, info)
+		if len(ff.Index) > 1 {
+			f.path = ff.Index
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
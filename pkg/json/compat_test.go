@@ -0,0 +1,292 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"database/sql"
+	stdjson "encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type compatInner struct {
+	Note string `json:"note"`
+}
+
+type compatRaw string
+
+func (r compatRaw) MarshalJSON() ([]byte, error) {
+	return []byte(" { \"html\" : \"<b>" + string(r) + "</b>\" } "), nil
+}
+
+type compatDoc struct {
+	Name     string
+	Data     []byte            `json:"data"`
+	NilData  []byte            `json:"nil_data"`
+	Small    float64           `json:"small"`
+	Large    float64           `json:"large"`
+	Huge     float64           `json:"huge"`
+	Single   float32           `json:"single"`
+	Quoted   float64           `json:"quoted,string"`
+	HTML     string            `json:"html"`
+	Slash    string            `json:"a/b"`
+	Bad      string            `json:"bad"`
+	Timeout  time.Duration     `json:"timeout"`
+	Raw      compatRaw         `json:"raw"`
+	Any      interface{}       `json:"any"`
+	Labels   map[string]string `json:"labels"`
+	Inner    compatInner       `json:"inner"`
+	Optional *int              `json:"optional,omitempty"`
+}
+
+func TestStrictStdlibMarshal(t *testing.T) {
+	doc := compatDoc{
+		Name:    "x",
+		Data:    []byte("hello"),
+		Small:   1e-7,
+		Large:   1e20,
+		Huge:    1e21,
+		Single:  0.1,
+		Quoted:  1e-7,
+		HTML:    "<a href=\"x\">&</a>\u2028\u2029",
+		Slash:   "a/b",
+		Bad:     "a\xffb\x01",
+		Timeout: 1500 * time.Millisecond,
+		Raw:     "r&d",
+		Any:     []interface{}{1e6, 0.000001, "<>", []byte{1, 2}},
+		Labels:  map[string]string{"b<": "1", "a": "2"},
+		Inner:   compatInner{Note: "a & b"},
+	}
+	values := []interface{}{
+		doc, &doc, 1e-7, float32(1e-7), -1e21, 123456789.0, 0.0, math.Copysign(0, -1),
+		"</script>", []byte(nil), []byte{}, map[string]interface{}{"k": []byte("v")},
+		[]interface{}{nil, true, 3.5e-9},
+	}
+	opts := MarshalOptions{Compatibility: StrictStdlib}
+	for _, v := range values {
+		want, err := stdjson.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MarshalWithOptions(v, opts)
+		if err != nil {
+			t.Errorf("MarshalWithOptions(%T): %v", v, err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("MarshalWithOptions(%T) =\n%s\nwant\n%s", v, got, want)
+		}
+	}
+
+	// The default output differs
+	got, err := Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := stdjson.Marshal(doc); string(got) == string(want) {
+		t.Error("Marshal matches encoding/json; the test no longer covers a difference")
+	}
+}
+
+func TestStrictStdlibMarshalErrors(t *testing.T) {
+	opts := MarshalOptions{Compatibility: StrictStdlib}
+	for _, v := range []interface{}{math.NaN(), math.Inf(1), float32(math.Inf(-1)), compatBroken{}} {
+		if _, err := MarshalWithOptions(v, opts); err == nil {
+			t.Errorf("MarshalWithOptions(%v): expected error", v)
+		}
+	}
+}
+
+type compatBroken struct{}
+
+func (compatBroken) MarshalJSON() ([]byte, error) { return []byte(`{"a":`), nil }
+
+func TestStrictStdlibUnmarshal(t *testing.T) {
+	type target struct {
+		Name    string
+		UserID  int `json:"userId"`
+		Data    []byte
+		Any     interface{}
+		Numbers []interface{}
+		Extra   map[string]interface{}
+	}
+	doc := []byte(`{"NAME":"a","userid":7,"data":"aGVsbG8=","any":1,"numbers":[1,-0,2.5,12345678901234567890],"extra":{"n":3}}`)
+
+	var want target
+	if err := stdjson.Unmarshal(doc, &want); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []DecodePath{FastPath, ASTPath} {
+		var got target
+		opts := UnmarshalOptions{Compatibility: StrictStdlib, Path: path}
+		if err := UnmarshalWithOptions(doc, &got, opts); err != nil {
+			t.Fatalf("path %d: %v", path, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("path %d: got %#v\nwant %#v", path, got, want)
+		}
+		if n := got.Numbers[1].(float64); !math.Signbit(n) {
+			t.Errorf("path %d: -0 decoded as %v", path, n)
+		}
+	}
+
+	// The defaults differ
+	var plain target
+	if err := Unmarshal([]byte(`{"name":"a","Any":1}`), &plain); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := plain.Any.(int64); !ok || plain.Name != "" {
+		t.Errorf("Unmarshal = %#v, want int64 numbers and exact key matching", plain)
+	}
+}
+
+func TestStrictStdlibExactMatchFirst(t *testing.T) {
+	type target struct {
+		Upper string `json:"KEY"`
+		Lower string `json:"key"`
+		Other string `json:"Key2"`
+	}
+	var got target
+	opts := UnmarshalOptions{Compatibility: StrictStdlib}
+	if err := UnmarshalWithOptions([]byte(`{"key":"a","Key":"b","KEY2":"c"}`), &got, opts); err != nil {
+		t.Fatal(err)
+	}
+	var want target
+	if err := stdjson.Unmarshal([]byte(`{"key":"a","Key":"b","KEY2":"c"}`), &want); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+// CompatBase and CompatMeta are embedded by compatEmbedded. Their Kind
+// fields hide each other, and the tagged Heading of CompatMeta wins over
+// the Title of CompatBase.
+type CompatBase struct {
+	ID      int `json:"id"`
+	Kind    string
+	Title   string
+	Created string `json:"created,omitempty"`
+}
+
+type CompatMeta struct {
+	Kind    string
+	Heading string `json:"Title"`
+	Owner   string `json:"owner"`
+}
+
+type compatHidden struct {
+	Secret string `json:"secret"`
+}
+
+// compatPoint is a map key that marshals itself as text.
+type compatPoint struct{ X, Y int }
+
+func (p compatPoint) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil
+}
+
+func (p *compatPoint) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+type compatEmbedded struct {
+	CompatBase
+	*CompatMeta
+	compatHidden
+	ID     string                `json:"id"`
+	IP     net.IP                `json:"ip"`
+	Addr   netip.Addr            `json:"addr"`
+	Ptr    *netip.Addr           `json:"ptr"`
+	Counts map[int]string        `json:"counts"`
+	Bytes  map[uint8]bool        `json:"bytes"`
+	Points map[compatPoint]int   `json:"points"`
+	Hosts  map[netip.Addr]string `json:"hosts"`
+	Null   sql.NullString        `json:"null,omitempty"`
+}
+
+func TestStrictStdlibEmbeddedAndText(t *testing.T) {
+	addr := netip.MustParseAddr("2001:db8::1")
+	full := compatEmbedded{
+		CompatBase:   CompatBase{ID: 1, Kind: "base", Title: "t"},
+		CompatMeta:   &CompatMeta{Kind: "meta", Heading: "h", Owner: "o"},
+		compatHidden: compatHidden{Secret: "s"},
+		ID:           "outer",
+		IP:           net.IPv4(192, 0, 2, 1),
+		Addr:         netip.MustParseAddr("192.0.2.2"),
+		Ptr:          &addr,
+		Counts:       map[int]string{10: "ten", -2: "minus two", 9: "nine"},
+		Bytes:        map[uint8]bool{255: true, 0: false},
+		Points:       map[compatPoint]int{{1, 2}: 3, {-1, 0}: 4},
+		Hosts:        map[netip.Addr]string{addr: "v6", netip.MustParseAddr("10.0.0.1"): "v4"},
+	}
+	values := []interface{}{
+		full, &full, compatEmbedded{}, []compatEmbedded{{CompatMeta: nil, ID: "x"}},
+		map[int]compatPoint{1: {2, 3}}, net.IP(nil), netip.Addr{}, &addr,
+	}
+	opts := MarshalOptions{Compatibility: StrictStdlib}
+	for _, v := range values {
+		want, err := stdjson.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MarshalWithOptions(v, opts)
+		if err != nil {
+			t.Errorf("MarshalWithOptions(%T): %v", v, err)
+			continue
+		}
+		if string(got) != string(want) {
+			t.Errorf("MarshalWithOptions(%T) =\n%s\nwant\n%s", v, got, want)
+		}
+	}
+
+	// Without StrictStdlib, maps must have string keys
+	if _, err := Marshal(map[int]string{1: "a"}); err == nil {
+		t.Error("Marshal(map[int]string): expected error")
+	}
+
+	encoded, err := stdjson.Marshal(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := [][]byte{
+		encoded,
+		[]byte(`{"Kind":"dropped","TITLE":"folded","owner":"allocated","secret":"s","ip":"::1","addr":null,` +
+			`"counts":{"-5":"x"},"bytes":{"7":true},"points":{"0,0":1},"null":{"String":"n","Valid":true}}`),
+	}
+	for _, doc := range docs {
+		var want compatEmbedded
+		if err := stdjson.Unmarshal(doc, &want); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range []DecodePath{FastPath, ASTPath} {
+			var got compatEmbedded
+			opts := UnmarshalOptions{Compatibility: StrictStdlib, Path: path}
+			if err := UnmarshalWithOptions(doc, &got, opts); err != nil {
+				t.Errorf("path %d: %s: %v", path, doc, err)
+				continue
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("path %d: %s:\ngot  %+v\nwant %+v", path, doc, got, want)
+			}
+		}
+	}
+
+	// encoding/json rejects these too
+	for _, doc := range []string{`{"counts":{"x":"y"}}`, `{"bytes":{"256":true}}`, `{"addr":1}`, `{"ip":{}}`} {
+		for _, path := range []DecodePath{FastPath, ASTPath} {
+			var got compatEmbedded
+			opts := UnmarshalOptions{Compatibility: StrictStdlib, Path: path}
+			if err := UnmarshalWithOptions([]byte(doc), &got, opts); err == nil {
+				t.Errorf("path %d: %s: expected error", path, doc)
+			}
+		}
+	}
+}
@@ -0,0 +1,300 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// EncodePlan marshals values of one Go type. CompileEncoder builds it ahead
+// of time, so that a framework can pay for reflecting over its types at
+// startup, and learn then about types that cannot be encoded, rather than
+// on the first request that uses them.
+//
+// An EncodePlan is safe for concurrent use. It stays valid if a
+// TypeRegistry is later given an encoder for a type it contains.
+type EncodePlan struct {
+	t reflect.Type
+}
+
+// CompileEncoder builds the encoders for t and every type it contains, and
+// returns an EncodePlan for it. It returns an error naming the offending
+// field if any of them, such as a channel or a map with int keys, cannot
+// be encoded. The contents of interface values are not known until they
+// are encoded, so they are not checked.
+//
+// Example:
+//
+//	plan, err := json.CompileEncoder(reflect.TypeOf(Order{}))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	data, err := plan.Marshal(&order)
+func CompileEncoder(t reflect.Type) (*EncodePlan, error) {
+	if t == nil {
+		return nil, fmt.Errorf("json: CompileEncoder(nil)")
+	}
+	if err := checkEncodable(t, t.String(), map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+	encoderForType(t)
+	return &EncodePlan{t: t}, nil
+}
+
+// Type returns the type the plan encodes.
+func (p *EncodePlan) Type() reflect.Type {
+	return p.t
+}
+
+// Marshal returns the JSON encoding of v, which must be of the plan's type
+// or a pointer to it. The output is the same as that of Marshal.
+func (p *EncodePlan) Marshal(v interface{}) ([]byte, error) {
+	m, start := startMetrics(nil)
+	data, err := p.marshal(defaultEncodeState, v)
+	observe(m, OpMarshal, len(data), start, err)
+	return data, err
+}
+
+// MarshalWithOptions is like Marshal but applies opts, as
+// MarshalWithOptions does.
+func (p *EncodePlan) MarshalWithOptions(v interface{}, opts MarshalOptions) ([]byte, error) {
+	m, start := startMetrics(opts.Metrics)
+	data, err := p.marshalWithOptions(v, opts)
+	observe(m, OpMarshal, len(data), start, err)
+	return data, err
+}
+
+// marshalWithOptions implements MarshalWithOptions.
+func (p *EncodePlan) marshalWithOptions(v interface{}, opts MarshalOptions) ([]byte, error) {
+	e := opts.encodeState()
+	data, err := p.marshal(e, v)
+	if err != nil || !opts.Canonical {
+		return data, err
+	}
+	if data, err = canonicalize(data); err != nil {
+		return nil, err
+	}
+	// Canonical numbers can be longer than the ones written
+	if err := e.checkSize(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// marshal implements Marshal and MarshalWithOptions.
+func (p *EncodePlan) marshal(e *encodeState, v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.IsValid() && rv.Type() != p.t && rv.Kind() == reflect.Ptr && rv.Type().Elem() == p.t {
+		if rv.IsNil() {
+			return []byte("null"), nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() || rv.Type() != p.t {
+		return nil, fmt.Errorf("json: EncodePlan for %s cannot marshal %T", p.t, v)
+	}
+
+	t := reflect.TypeOf(v)
+	bp := getBuf(encodedSizes.Get(t))
+	st := e.acquire()
+	buf, err := encoderForType(p.t)(st, (*bp)[:0], rv)
+	st.release()
+	if err == nil {
+		err = e.checkSize(buf)
+	}
+	if err != nil {
+		finishCycle(err, v)
+		putBuf(bp, buf)
+		return nil, err
+	}
+	return finishMarshal(bp, buf, t), nil
+}
+
+// checkEncodable reports the first type reachable from t, which is at path,
+// that the encoders built by buildEncoder reject.
+func checkEncodable(t reflect.Type, path string, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return nil
+	}
+	if _, ok := overridden.Load(t); ok {
+		return nil
+	}
+	if t == timeType || t == durationType {
+		return nil
+	}
+	if fastparser.IsSQLNull(t) {
+		return checkEncodable(t.Field(0).Type, path, seen)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Interface,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return nil
+	case reflect.Ptr:
+		return checkEncodable(t.Elem(), path, seen)
+	case reflect.Slice, reflect.Array:
+		return checkEncodable(t.Elem(), path+"[*]", seen)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("json: unsupported map key type %s at %s", t.Key(), path)
+		}
+		return checkEncodable(t.Elem(), path+"[*]", seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			info := getFieldInfo(sf)
+			if info.skip {
+				continue
+			}
+			ft := sf.Type
+			if info.unknown {
+				ft = ft.Elem()
+			}
+			if err := checkEncodable(ft, path+"."+sf.Name, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("json: unsupported type %s at %s", t, path)
+	}
+}
+
+// DecodePlan unmarshals into values of one Go type. CompileDecoder checks
+// the type ahead of time, so that a framework learns at startup about
+// types that cannot be decoded rather than on the first request that uses
+// them.
+//
+// A DecodePlan is safe for concurrent use.
+type DecodePlan struct {
+	t reflect.Type
+}
+
+// CompileDecoder checks that JSON can be decoded into t and every type it
+// contains, and returns a DecodePlan for it. It returns an error naming
+// the offending field if any of them, such as a func or a map with struct
+// keys, cannot be decoded. Interface types are not checked, since the
+// value decoded into them depends on the document or on
+// UnmarshalOptions.Resolvers.
+//
+// Example:
+//
+//	plan, err := json.CompileDecoder(reflect.TypeOf(Order{}))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	var order Order
+//	err = plan.Unmarshal(data, &order)
+func CompileDecoder(t reflect.Type) (*DecodePlan, error) {
+	if t == nil {
+		return nil, fmt.Errorf("json: CompileDecoder(nil)")
+	}
+	if err := checkDecodable(t, t.String(), map[reflect.Type]bool{}); err != nil {
+		return nil, err
+	}
+	return &DecodePlan{t: t}, nil
+}
+
+// Type returns the type the plan decodes into.
+func (p *DecodePlan) Type() reflect.Type {
+	return p.t
+}
+
+// Unmarshal decodes data into v, which must be a non-nil pointer to the
+// plan's type, as Unmarshal does.
+func (p *DecodePlan) Unmarshal(data []byte, v interface{}) error {
+	if err := p.check(v); err != nil {
+		return err
+	}
+	return Unmarshal(data, v)
+}
+
+// UnmarshalWithOptions is like Unmarshal but applies opts, as
+// UnmarshalWithOptions does.
+func (p *DecodePlan) UnmarshalWithOptions(data []byte, v interface{}, opts UnmarshalOptions) error {
+	if err := p.check(v); err != nil {
+		return err
+	}
+	return UnmarshalWithOptions(data, v, opts)
+}
+
+// check returns an error unless v is a pointer to the plan's type.
+func (p *DecodePlan) check(v interface{}) error {
+	if t := reflect.TypeOf(v); t == nil || t.Kind() != reflect.Ptr || t.Elem() != p.t {
+		return fmt.Errorf("json: DecodePlan for %s cannot unmarshal into %T", p.t, v)
+	}
+	return nil
+}
+
+var slotType = reflect.TypeOf((*fastparser.Slot)(nil)).Elem()
+
+// checkDecodable reports the first type reachable from t, which is at path,
+// that the decoder cannot store a JSON value in.
+func checkDecodable(t reflect.Type, path string, seen map[reflect.Type]bool) error {
+	if seen[t] {
+		return nil
+	}
+	seen[t] = true
+
+	if t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return nil
+	}
+	if t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(slotType) {
+		return nil
+	}
+	if fastparser.IsSQLNull(t) {
+		return checkDecodable(t.Field(0).Type, path, seen)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.String, reflect.Interface,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return nil
+	case reflect.Ptr:
+		return checkDecodable(t.Elem(), path, seen)
+	case reflect.Slice, reflect.Array:
+		return checkDecodable(t.Elem(), path+"[*]", seen)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return fmt.Errorf("json: unsupported map key type %s at %s", t.Key(), path)
+		}
+		return checkDecodable(t.Elem(), path+"[*]", seen)
+	case reflect.Struct:
+		fields := fastparser.NewStructFields(t)
+		decoded := make(map[int]bool, len(fields.Index))
+		for _, i := range fields.Index {
+			decoded[i] = true
+		}
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			ft := sf.Type
+			switch {
+			case i == fields.Unknown:
+				ft = ft.Elem()
+			case !decoded[i]:
+				continue
+			}
+			if err := checkDecodable(ft, path+"."+sf.Name, seen); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("json: unsupported type %s at %s", t, path)
+	}
+}
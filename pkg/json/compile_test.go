@@ -0,0 +1,172 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type planOrder struct {
+	ID       int64             `json:"id"`
+	Items    []planItem        `json:"items"`
+	Placed   time.Time         `json:"placed"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Parent   *planOrder        `json:"parent,omitempty"`
+	internal chan int
+}
+
+type planItem struct {
+	SKU string  `json:"sku"`
+	Qty float64 `json:"qty"`
+}
+
+func TestCompileEncoder(t *testing.T) {
+	plan, err := CompileEncoder(reflect.TypeOf(planOrder{}))
+	if err != nil {
+		t.Fatalf("CompileEncoder: %v", err)
+	}
+	if plan.Type() != reflect.TypeOf(planOrder{}) {
+		t.Errorf("Type() = %v", plan.Type())
+	}
+
+	order := planOrder{ID: 7, Items: []planItem{{SKU: "a", Qty: 2}}, Placed: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	want, err := Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []interface{}{order, &order} {
+		got, err := plan.Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal(%T): %v", v, err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("Marshal(%T) = %s, want %s", v, got, want)
+		}
+	}
+
+	got, err := plan.Marshal((*planOrder)(nil))
+	if err != nil || string(got) != "null" {
+		t.Errorf("Marshal(nil pointer) = %s, %v; want null", got, err)
+	}
+
+	got, err = plan.MarshalWithOptions(order, MarshalOptions{KeyOrder: KeyOrderSorted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(got), `{"id":7,"items":`) {
+		t.Errorf("MarshalWithOptions(KeyOrderSorted) = %s", got)
+	}
+
+	if _, err := plan.Marshal(planItem{}); err == nil {
+		t.Error("Marshal of another type: expected error")
+	}
+	if _, err := plan.Marshal(nil); err == nil {
+		t.Error("Marshal(nil): expected error")
+	}
+}
+
+func TestCompileEncoderUnsupported(t *testing.T) {
+	type withChan struct {
+		Name   string
+		Events []chan int
+	}
+	type withIntKeys struct {
+		Counts map[int]int `json:"counts"`
+	}
+	type skipped struct {
+		Events chan int `json:"-"`
+		Fn     func()   `json:"-"`
+	}
+
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{withChan{}, "json: unsupported type chan int at json.withChan.Events[*]"},
+		{withIntKeys{}, "json: unsupported map key type int at json.withIntKeys.Counts"},
+		{complex64(0), "json: unsupported type complex64 at complex64"},
+		{skipped{}, ""},
+	}
+	for _, tt := range tests {
+		_, err := CompileEncoder(reflect.TypeOf(tt.v))
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("CompileEncoder(%T): %v", tt.v, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("CompileEncoder(%T) error = %v, want %q", tt.v, err, tt.want)
+		}
+	}
+
+	if _, err := CompileEncoder(nil); err == nil {
+		t.Error("CompileEncoder(nil): expected error")
+	}
+}
+
+func TestCompileDecoder(t *testing.T) {
+	plan, err := CompileDecoder(reflect.TypeOf(planOrder{}))
+	if err != nil {
+		t.Fatalf("CompileDecoder: %v", err)
+	}
+	if plan.Type() != reflect.TypeOf(planOrder{}) {
+		t.Errorf("Type() = %v", plan.Type())
+	}
+
+	var order planOrder
+	if err := plan.Unmarshal([]byte(`{"id":7,"items":[{"sku":"a","qty":2}]}`), &order); err != nil {
+		t.Fatal(err)
+	}
+	if order.ID != 7 || len(order.Items) != 1 || order.Items[0].SKU != "a" {
+		t.Errorf("Unmarshal = %+v", order)
+	}
+
+	err = plan.UnmarshalWithOptions([]byte(`{"id":"8"}`), &order, UnmarshalOptions{CoerceStrings: true})
+	if err != nil || order.ID != 8 {
+		t.Errorf("UnmarshalWithOptions = %d, %v; want 8", order.ID, err)
+	}
+
+	for _, v := range []interface{}{order, &planItem{}, nil} {
+		if err := plan.Unmarshal([]byte(`{}`), v); err == nil {
+			t.Errorf("Unmarshal into %T: expected error", v)
+		}
+	}
+}
+
+func TestCompileDecoderUnsupported(t *testing.T) {
+	type withFunc struct {
+		Hooks map[string]func() `json:"hooks"`
+	}
+	type withStructKeys struct {
+		ByItem map[planItem]int
+	}
+	type custom struct {
+		Raw     RawMessage
+		Ignored chan int `json:"-"`
+	}
+
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{withFunc{}, "json: unsupported type func() at json.withFunc.Hooks[*]"},
+		{withStructKeys{}, "json: unsupported map key type json.planItem at json.withStructKeys.ByItem"},
+		{custom{}, ""},
+	}
+	for _, tt := range tests {
+		_, err := CompileDecoder(reflect.TypeOf(tt.v))
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("CompileDecoder(%T): %v", tt.v, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("CompileDecoder(%T) error = %v, want %q", tt.v, err, tt.want)
+		}
+	}
+}
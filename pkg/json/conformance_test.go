@@ -0,0 +1,245 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// conformanceCase is a document decoded into a new value of the type of
+// target, by every decode path.
+type conformanceCase struct {
+	input  string
+	target interface{}
+	opts   UnmarshalOptions
+}
+
+type confAddress struct {
+	Street string `json:"street"`
+	Zip    *int   `json:"zip,omitempty"`
+}
+
+type confPerson struct {
+	Name     string                 `json:"name"`
+	Age      int                    `json:"age"`
+	Height   float32                `json:"height"`
+	Admin    bool                   `json:"admin"`
+	Tags     []string               `json:"tags"`
+	Scores   [3]int                 `json:"scores"`
+	Address  *confAddress           `json:"address"`
+	Friends  []confAddress          `json:"friends"`
+	Meta     map[string]interface{} `json:"meta"`
+	Any      interface{}            `json:"any"`
+	ID       int64                  `json:"id,string"`
+	Count    uint8                  `json:"count,coerce"`
+	Ignored  string                 `json:"-"`
+	Untagged string
+	private  int
+}
+
+type confRequired struct {
+	ID   string `json:"id,required"`
+	Name string `json:"name"`
+}
+
+type confUnknown struct {
+	ID    string                `json:"id"`
+	Extra map[string]RawMessage `json:",unknown"`
+}
+
+type confNullable struct {
+	A Nullable[int]    `json:"a"`
+	B Nullable[string] `json:"b"`
+}
+
+type confCelsius float64
+
+func (c *confCelsius) UnmarshalJSON(data []byte) error {
+	var f float64
+	if err := Unmarshal(data, &f); err != nil {
+		return fmt.Errorf("celsius: %w", err)
+	}
+	*c = confCelsius(f)
+	return nil
+}
+
+type confShape interface{ Area() float64 }
+
+type confSquare struct {
+	Side float64 `json:"side"`
+}
+
+func (s confSquare) Area() float64 { return s.Side * s.Side }
+
+// conformanceCases returns the corpus: every kind of value and target,
+// the options, and the errors in each.
+func conformanceCases() []conformanceCase {
+	person := `{"name": "Ada", "age": 36, "height": 1.65, "admin": true, "tags": ["a", "b"],
+		"scores": [1, 2, 3], "address": {"street": "Main", "zip": 12345},
+		"friends": [{"street": "x"}, {"street": "y", "zip": null}], "meta": {"k": [1, 2.5, "s", null, true, {}]},
+		"any": {"nested": [[]]}, "id": "9007199254740993", "count": "7", "Untagged": "u", "extra": {"skip": [1, 2]}}`
+
+	var cases []conformanceCase
+	add := func(target interface{}, inputs ...string) {
+		for _, input := range inputs {
+			cases = append(cases, conformanceCase{input: input, target: target})
+		}
+	}
+	addOpts := func(target interface{}, opts UnmarshalOptions, inputs ...string) {
+		for _, input := range inputs {
+			cases = append(cases, conformanceCase{input: input, target: target, opts: opts})
+		}
+	}
+
+	scalars := []string{
+		`0`, `-0`, `1`, `-1`, `255`, `256`, `-129`, `1.5`, `-1.5`, `1e3`, `1E-2`, `2.0`, `1e400`, `-1e400`,
+		`9223372036854775807`, `9223372036854775808`, `-9223372036854775808`, `-9223372036854775809`,
+		`18446744073709551615`, `18446744073709551616`, `9007199254740993`, `0.1`, `123456789012345678901234567890`,
+		`"s"`, `""`, `"é\n\t\"\\\/"`, `"😀"`, `"é✓"`, `"12"`, `"true"`,
+		`true`, `false`, `null`, `[]`, `{}`, `[1]`, `{"a": 1}`,
+	}
+	add(new(interface{}), scalars...)
+	add(new(int), scalars...)
+	add(new(int8), scalars...)
+	add(new(uint), scalars...)
+	add(new(uint8), scalars...)
+	add(new(int64), scalars...)
+	add(new(uint64), scalars...)
+	add(new(float32), scalars...)
+	add(new(float64), scalars...)
+	add(new(string), scalars...)
+	add(new(bool), scalars...)
+	add(new(*int), scalars...)
+	add(new([]int), scalars...)
+	add(new([2]int), scalars...)
+	add(new(map[string]int), scalars...)
+	add(new(confCelsius), scalars...)
+	add(new(RawMessage), scalars...)
+	add(new(time.Duration), scalars...)
+
+	add(new(confPerson), person,
+		`{}`, `{"name": 1}`, `{"age": "x"}`, `{"age": 1.5}`, `{"tags": [1]}`, `{"tags": {}}`,
+		`{"scores": [1, 2, 3, 4]}`, `{"scores": [1]}`, `{"address": {"zip": "z"}}`, `{"address": null}`,
+		`{"friends": [{"street": "a"}, {"zip": true}]}`, `{"meta": []}`, `{"id": 5}`, `{"id": "x"}`,
+		`{"count": "300"}`, `{"count": 3}`, `{"Ignored": "x", "-": "y"}`, `{"private": 1}`,
+		`{"name": "a", "name": "b"}`, `{"age": 1, "name": 2, "admin": 3}`, `{"NAME": "case"}`,
+		`[]`, `"s"`, `null`, `{"name": null, "age": null, "tags": null, "address": null}`,
+	)
+	add(new(confRequired), `{"id": "1"}`, `{"name": "n"}`, `{"id": null}`, `{"name": 1}`, `{}`)
+	add(new(confUnknown), `{"id": "1", "b": [1, 2], "a": {"x": "y"}}`, `{"a": 1}`, `{}`)
+	add(new(confNullable), `{"a": 1, "b": null}`, `{}`, `{"a": "x"}`)
+	add(new([]confAddress), `[{"street": "a"}, null, {}]`, `[{"street": 1}]`)
+	add(new(map[string][]int), `{"a": [1], "b": [], "c": null}`, `{"a": [1, "x"]}`)
+	add(new(map[string]*confAddress), `{"a": {"zip": 1}, "b": null}`)
+	add(new(struct{ Deep [][]map[string][]interface{} }), `{"Deep": [[{"k": [1, [2], {"m": null}]}]]}`)
+	add(new(map[string]map[string]int), `{"é": {"✓": 1, "😀": 2}, "b": {"c": 3}}`, `{"é": {"✓": 1}, "😀": {"c": "x"}}`)
+	add(new([]map[string]string), `[{"é": "😀"}, {"b": "✓", "c": "d"}]`, `[{"é": "😀"}, {"b": 1}]`)
+
+	// Syntax errors, alone and after a value that also fails to decode
+	add(new(interface{}), ``, ` `, `{`, `[1,]`, `{"a":1,}`, `{"a" 1}`, `[1 2]`, `nul`, `"abc`,
+		`"\x"`, `{"a": 01}`, `1 2`, `[1]]`, `{"a": 1}}`, `{"a": [1, {"b": }]}`)
+	add(new(confPerson), `{"age": "x", "name": }`, `{"name": "a", "age": [}`, `{"tags": [1, 2`)
+	add(new([]int), `[1, "x", ]`, `["x", 1, ]`)
+
+	// Options
+	addOpts(new(confPerson), UnmarshalOptions{RejectNull: true}, `{"age": null}`, `{"address": null}`, person)
+	addOpts(new([]float64), UnmarshalOptions{StrictNumbers: true}, `[0.1, 1e400]`, `[19.999999999999999]`, `[1, 2.5]`)
+	addOpts(new([]int64), UnmarshalOptions{StrictNumbers: true}, `[1.5]`, `[1e19]`, `[100]`)
+	addOpts(new([]int64), UnmarshalOptions{Int64AsString: true}, `["1", 2]`, `["x"]`)
+	addOpts(new(confPerson), UnmarshalOptions{CoerceStrings: true}, `{"age": "4", "admin": "true", "height": "1.5"}`, `{"age": "four"}`)
+	shapes := NewPolymorphic[confShape]("type").Register("square", confSquare{})
+	addOpts(new(map[string]confShape), UnmarshalOptions{Resolvers: []TypeResolver{shapes}},
+		`{"a": {"type": "square", "side": 2}}`, `{"a": {"type": "circle"}}`, `{"a": null}`, `{"a": 1}`)
+	addOpts(new(struct {
+		Secret string `json:"secret,transform:rot"`
+	}), UnmarshalOptions{Transforms: map[string]Transformer{"rot": reverseTransformer{}}}, `{"secret": "\"cba\""}`, `{"secret": 1}`)
+	addOpts(new(struct {
+		Secret string `json:"secret,transform:rot"`
+	}), UnmarshalOptions{}, `{"secret": "x"}`)
+	addOpts(new(struct{ At time.Time }), UnmarshalOptions{Types: RegisterType(NewTypeRegistry(), nil,
+		func(data []byte, t *time.Time) error {
+			var sec int64
+			if err := Unmarshal(data, &sec); err != nil {
+				return err
+			}
+			*t = time.Unix(sec, 0).UTC()
+			return nil
+		})}, `{"At": 86400}`, `{"At": "x"}`)
+	orders := []string{
+		`{"id":1,"items":[{"sku":"ABC","qty":2}]}`,
+		`{"id":2,"note":"too long","items":[{"sku":"ab","qty":0},{"qty":1}],"extra":true}`,
+		`{"id":3,"note":null,"items":[{"sku":"XYZ"}],"meta":{"a":"1","B":"2","c":"3"}}`,
+		`{"id":4,"items":[{"sku":"ABC"}],"tags":["x","y","x"],"owner":{"id":1}}`,
+		`{"id":"x","items":[]}`, `[]`,
+	}
+	addOpts(new(schemaOrder), UnmarshalOptions{Schema: orderSchema}, orders...)
+	addOpts(new(map[string]interface{}), UnmarshalOptions{Schema: orderSchema}, orders...)
+	return cases
+}
+
+// reverseTransformer decodes by reversing the string it is given.
+type reverseTransformer struct{}
+
+func (reverseTransformer) Encode(field string, data []byte) ([]byte, error) { return data, nil }
+
+func (reverseTransformer) Decode(field string, data []byte) ([]byte, error) {
+	var s string
+	if err := Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return Marshal(string(r))
+}
+
+// TestDecodePathConformance decodes the corpus by the fast path and by the
+// AST path, and requires the same values and the same errors. The AST path
+// shares the fast path's code for everything but objects and arrays, so
+// the corpus is chosen to exercise the walking of containers: struct
+// fields and their options, maps, slices, arrays, pointers, and errors
+// inside them.
+func TestDecodePathConformance(t *testing.T) {
+	for _, c := range conformanceCases() {
+		typ := reflect.TypeOf(c.target).Elem()
+		name := fmt.Sprintf("%s/%s", typ, c.input)
+
+		fast := reflect.New(typ)
+		fastOpts := c.opts
+		fastOpts.Path = FastPath
+		fastErr := UnmarshalWithOptions([]byte(c.input), fast.Interface(), fastOpts)
+
+		slow := reflect.New(typ)
+		astOpts := c.opts
+		astOpts.Path = ASTPath
+		astErr := UnmarshalWithOptions([]byte(c.input), slow.Interface(), astOpts)
+
+		if errString(fastErr) != errString(astErr) {
+			t.Errorf("%s: errors differ:\nfast: %v\nast:  %v", name, fastErr, astErr)
+			continue
+		}
+		if fastErr == nil && !conformanceEqual(fast.Elem(), slow.Elem()) {
+			t.Errorf("%s: values differ:\nfast: %#v\nast:  %#v", name, fast.Elem(), slow.Elem())
+		}
+	}
+}
+
+// conformanceEqual is reflect.DeepEqual, except that NaNs are equal.
+func conformanceEqual(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Float32 || a.Kind() == reflect.Float64 {
+		return a.Float() == b.Float() || math.IsNaN(a.Float()) && math.IsNaN(b.Float())
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return err.Error()
+}
@@ -3,6 +3,7 @@ package json
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 
 	"github.com/shapestone/shape-core/pkg/ast"
@@ -18,6 +19,11 @@ import (
 //
 // This function recursively processes nested structures.
 //
+// Every parser in this package builds arrays as *ast.ArrayDataNode. An
+// *ast.ObjectNode whose keys are exactly "0", "1", ... is still read as an
+// array, so that trees built by hand in the legacy form keep working; such
+// trees should be converted once with NormalizeArrays.
+//
 // Example:
 //
 //	node, _ := json.Parse(`{"name":"Alice","tags":["go","json"]}`)
@@ -30,7 +36,7 @@ func NodeToInterface(node ast.SchemaNode) interface{} {
 		// Ensure numbers are returned as appropriate types
 		if f, ok := val.(float64); ok {
 			// Check if it's a whole number
-			if f == float64(int64(f)) {
+			if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
 				return int64(f)
 			}
 		}
@@ -212,3 +218,83 @@ func InterfaceToNode(v interface{}) (ast.SchemaNode, error) {
 		return nil, fmt.Errorf("unsupported type: %T", v)
 	}
 }
+
+// NormalizeArrays returns node with every *ast.ObjectNode in the legacy
+// array form, whose keys are exactly "0", "1", ..., replaced by an
+// *ast.ArrayDataNode holding the same elements in index order, at the same
+// position. It is a migration helper for trees built before arrays had
+// their own node kind; the parsers in this package never produce the
+// legacy form.
+//
+// node itself is not modified. Containers with nothing to replace beneath
+// them are returned as they are, so an already normalized tree comes back
+// unchanged.
+//
+// Example:
+//
+//	node = json.NormalizeArrays(node)
+//	tags := node.(*ast.ObjectNode).Properties()["tags"].(*ast.ArrayDataNode)
+func NormalizeArrays(node ast.SchemaNode) ast.SchemaNode {
+	switch n := node.(type) {
+	case *ast.ArrayDataNode:
+		elements := n.Elements()
+		var out []ast.SchemaNode
+		for i, elem := range elements {
+			norm := NormalizeArrays(elem)
+			if norm != elem && out == nil {
+				out = append(make([]ast.SchemaNode, 0, len(elements)), elements[:i]...)
+			}
+			if out != nil {
+				out = append(out, norm)
+			}
+		}
+		if out == nil {
+			return n
+		}
+		return ast.NewArrayDataNode(out, n.Position())
+
+	case *ast.ObjectNode:
+		props := n.Properties()
+		if isArray(props) {
+			elements := make([]ast.SchemaNode, len(props))
+			for i := range elements {
+				elements[i] = NormalizeArrays(props[strconv.Itoa(i)])
+			}
+			return ast.NewArrayDataNode(elements, n.Position())
+		}
+
+		var out map[string]ast.SchemaNode
+		for key, child := range props {
+			if norm := NormalizeArrays(child); norm != child {
+				if out == nil {
+					out = make(map[string]ast.SchemaNode, len(props))
+					for k, v := range props {
+						out[k] = v
+					}
+				}
+				out[key] = norm
+			}
+		}
+		if out == nil {
+			return n
+		}
+		return ast.NewObjectNode(out, n.Position())
+
+	default:
+		return node
+	}
+}
+
+// isArray checks if the object node represents a JSON array (numeric string keys)
+func isArray(props map[string]ast.SchemaNode) bool {
+	if len(props) == 0 {
+		return false
+	}
+
+	for i := 0; i < len(props); i++ {
+		if _, ok := props[strconv.Itoa(i)]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -1,6 +1,7 @@
 package json
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/shapestone/shape-core/pkg/ast"
@@ -31,6 +32,15 @@ func TestNodeToInterface_LiteralNode(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:  "whole float beyond int64 range",
+			input: `9.223372036854775808e18`,
+			check: func(t *testing.T, result interface{}) {
+				if f, ok := result.(float64); !ok || f != 1<<63 {
+					t.Errorf("expected float64 2^63, got %T: %v", result, result)
+				}
+			},
+		},
 		{
 			name:  "float literal that's a whole number",
 			input: `42.0`,
@@ -650,3 +660,116 @@ func TestRoundTrip_ConversionCycle(t *testing.T) {
 		})
 	}
 }
+
+// TestParseEntryPointsArrayNodes checks that every function building an AST
+// represents arrays as *ast.ArrayDataNode.
+func TestParseEntryPointsArrayNodes(t *testing.T) {
+	const input = `{"tags":["a","b"],"matrix":[[1,2],[]]}`
+
+	tree, err := ParseTree(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var elements []ast.SchemaNode
+	for node, err := range ParseElements(strings.NewReader("[" + input + "]")) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		elements = append(elements, node)
+	}
+	fromInterface, err := InterfaceToNode(map[string]interface{}{
+		"tags":   []interface{}{"a", "b"},
+		"matrix": []interface{}{[]interface{}{1, 2}, []interface{}{}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsers := map[string]func() (ast.SchemaNode, error){
+		"Parse":            func() (ast.SchemaNode, error) { return Parse(input) },
+		"ParseReader":      func() (ast.SchemaNode, error) { return ParseReader(strings.NewReader(input)) },
+		"ParseWithOptions": func() (ast.SchemaNode, error) { return ParseWithOptions(input, ParseOptions{}) },
+		"ParseReaderWithOptions": func() (ast.SchemaNode, error) {
+			return ParseReaderWithOptions(strings.NewReader(input), ParseOptions{})
+		},
+		"ParseTree":       func() (ast.SchemaNode, error) { return tree.Root(), nil },
+		"ParseElements":   func() (ast.SchemaNode, error) { return elements[0], nil },
+		"InterfaceToNode": func() (ast.SchemaNode, error) { return fromInterface, nil },
+	}
+	for name, parse := range parsers {
+		node, err := parse()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		props := node.(*ast.ObjectNode).Properties()
+		if _, ok := props["tags"].(*ast.ArrayDataNode); !ok {
+			t.Errorf("%s: tags is %T, want *ast.ArrayDataNode", name, props["tags"])
+		}
+		matrix, ok := props["matrix"].(*ast.ArrayDataNode)
+		if !ok {
+			t.Errorf("%s: matrix is %T, want *ast.ArrayDataNode", name, props["matrix"])
+			continue
+		}
+		for i, row := range matrix.Elements() {
+			if _, ok := row.(*ast.ArrayDataNode); !ok {
+				t.Errorf("%s: matrix[%d] is %T, want *ast.ArrayDataNode", name, i, row)
+			}
+		}
+	}
+}
+
+func TestNormalizeArrays(t *testing.T) {
+	pos := ast.Position{Offset: 3, Line: 1, Column: 4}
+	legacy := ast.NewObjectNode(map[string]ast.SchemaNode{
+		"0": ast.NewLiteralNode("a", pos),
+		"1": ast.NewObjectNode(map[string]ast.SchemaNode{
+			"0": ast.NewLiteralNode(int64(1), pos),
+		}, pos),
+	}, pos)
+	plain := ast.NewObjectNode(map[string]ast.SchemaNode{"k": ast.NewLiteralNode(true, pos)}, pos)
+	root := ast.NewObjectNode(map[string]ast.SchemaNode{
+		"tags":  legacy,
+		"plain": plain,
+		"list":  ast.NewArrayDataNode([]ast.SchemaNode{legacy}, pos),
+	}, pos)
+
+	got := NormalizeArrays(root)
+	props := got.(*ast.ObjectNode).Properties()
+
+	tags, ok := props["tags"].(*ast.ArrayDataNode)
+	if !ok {
+		t.Fatalf("tags is %T, want *ast.ArrayDataNode", props["tags"])
+	}
+	if tags.Position() != pos {
+		t.Errorf("tags position = %v, want %v", tags.Position(), pos)
+	}
+	if _, ok := tags.Get(1).(*ast.ArrayDataNode); !ok {
+		t.Errorf("tags[1] is %T, want *ast.ArrayDataNode", tags.Get(1))
+	}
+	list := props["list"].(*ast.ArrayDataNode)
+	if _, ok := list.Get(0).(*ast.ArrayDataNode); !ok {
+		t.Errorf("list[0] is %T, want *ast.ArrayDataNode", list.Get(0))
+	}
+	if props["plain"] != plain {
+		t.Error("plain object was copied, want it returned as is")
+	}
+	if _, ok := root.Properties()["tags"].(*ast.ObjectNode); !ok {
+		t.Error("NormalizeArrays modified its input")
+	}
+
+	out, err := Render(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"list":[["a",[1]]],"plain":{"k":true},"tags":["a",[1]]}`; string(out) != want {
+		t.Errorf("Render = %s, want %s", out, want)
+	}
+
+	parsed, err := Parse(`{"a":[1,{"b":[]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if NormalizeArrays(parsed) != parsed {
+		t.Error("normalized tree was copied, want it returned as is")
+	}
+}
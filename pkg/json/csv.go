@@ -0,0 +1,285 @@
+package json
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// CSVOptions configures ToCSV and FromCSV.
+type CSVOptions struct {
+	// Columns lists the column paths. Nested members are addressed with
+	// dot-separated paths such as "address.city".
+	//
+	// For ToCSV, a nil Columns infers the columns from the data: every leaf
+	// path found in any element, sorted. For FromCSV, a nil Columns takes
+	// the columns from the header row.
+	Columns []string
+
+	// NoHeader omits the header row in ToCSV. In FromCSV it means the first
+	// row is data, in which case Columns is required.
+	NoHeader bool
+
+	// Comma is the field delimiter. Zero means ','.
+	Comma rune
+
+	// NullValue is the cell text ToCSV writes for JSON null.
+	// Missing members are always written as empty cells.
+	NullValue string
+
+	// InferTypes makes FromCSV convert cells that look like JSON literals:
+	// numbers become numbers, "true" and "false" become booleans, and
+	// "null" (or NullValue, if set) and empty cells become null. Without it
+	// every cell is stored as a string.
+	InferTypes bool
+}
+
+// ToCSV writes an array of objects to w as CSV, one row per element.
+//
+// Nested objects are flattened into dot-separated columns; arrays and any
+// object addressed directly by a column are written as compact JSON text.
+// Strings are written without quotes, and numbers and booleans as they
+// would be marshaled.
+//
+// Example:
+//
+//	users, _ := doc.GetArray("users")
+//	err := json.ToCSV(os.Stdout, users, json.CSVOptions{
+//	    Columns: []string{"id", "name", "address.city"},
+//	})
+//
+// Rows are written as they are produced, so memory use does not grow with
+// the size of the output. Every element of arr must be an object.
+func ToCSV(w io.Writer, arr *Array, opts CSVOptions) error {
+	rows := make([]map[string]interface{}, len(arr.data))
+	for i, elem := range arr.data {
+		obj, ok := asObject(elem)
+		if !ok {
+			return fmt.Errorf("json: ToCSV: element %d is not an object", i)
+		}
+		rows[i] = obj
+	}
+
+	columns := opts.Columns
+	if columns == nil {
+		columns = inferCSVColumns(rows)
+	}
+	paths := make([][]string, len(columns))
+	for i, col := range columns {
+		paths[i] = strings.Split(col, ".")
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+
+	if !opts.NoHeader {
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+	}
+
+	record := make([]string, len(columns))
+	for i, row := range rows {
+		for j, path := range paths {
+			cell, err := csvCell(row, path, opts.NullValue)
+			if err != nil {
+				return fmt.Errorf("json: ToCSV: element %d, column %q: %w", i, columns[j], err)
+			}
+			record[j] = cell
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// FromCSV reads CSV from r and returns an Array with one Document per row.
+// Dot-separated column names ("address.city") produce nested objects.
+//
+// Example:
+//
+//	arr, err := json.FromCSV(file, json.CSVOptions{InferTypes: true})
+//
+// Rows must have the same number of fields as there are columns.
+func FromCSV(r io.Reader, opts CSVOptions) (*Array, error) {
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.ReuseRecord = true
+
+	columns := opts.Columns
+	if !opts.NoHeader {
+		header, err := cr.Read()
+		if err == io.EOF {
+			return NewArray(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if columns == nil {
+			columns = append([]string(nil), header...)
+		}
+	}
+	if columns == nil {
+		return nil, errors.New("json: FromCSV: Columns is required when NoHeader is set")
+	}
+	cr.FieldsPerRecord = len(columns)
+
+	paths := make([][]string, len(columns))
+	for i, col := range columns {
+		paths[i] = strings.Split(col, ".")
+	}
+
+	arr := NewArray()
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return arr, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, path := range paths {
+			if err := setCSVPath(row, path, csvValue(record[i], opts)); err != nil {
+				return nil, fmt.Errorf("json: FromCSV: column %q: %w", columns[i], err)
+			}
+		}
+		arr.data = append(arr.data, row)
+	}
+}
+
+// asObject returns the map behind an object value.
+func asObject(v interface{}) (map[string]interface{}, bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return val, true
+	case *Document:
+		if val != nil {
+			return val.data, true
+		}
+	}
+	return nil, false
+}
+
+// inferCSVColumns returns every leaf path found in rows, sorted.
+func inferCSVColumns(rows []map[string]interface{}) []string {
+	seen := make(map[string]bool)
+	var columns []string
+	var walk func(prefix string, obj map[string]interface{})
+	walk = func(prefix string, obj map[string]interface{}) {
+		for k, v := range obj {
+			path := prefix + k
+			if nested, ok := asObject(v); ok && len(nested) > 0 {
+				walk(path+".", nested)
+				continue
+			}
+			if !seen[path] {
+				seen[path] = true
+				columns = append(columns, path)
+			}
+		}
+	}
+	for _, row := range rows {
+		walk("", row)
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// csvCell formats the value at path within row as cell text.
+func csvCell(row map[string]interface{}, path []string, nullValue string) (string, error) {
+	var v interface{} = row
+	for _, key := range path {
+		obj, ok := asObject(v)
+		if !ok {
+			return "", nil
+		}
+		if v, ok = obj[key]; !ok {
+			return "", nil
+		}
+	}
+
+	switch val := v.(type) {
+	case nil:
+		return nullValue, nil
+	case string:
+		return val, nil
+	}
+	data, err := Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// csvValue converts cell text to a value according to opts.
+func csvValue(cell string, opts CSVOptions) interface{} {
+	if !opts.InferTypes {
+		return cell
+	}
+
+	switch {
+	case cell == "" || cell == "null" || (opts.NullValue != "" && cell == opts.NullValue):
+		return nil
+	case cell == "true":
+		return true
+	case cell == "false":
+		return false
+	}
+	if !isJSONNumber(cell) {
+		return cell
+	}
+	if n, err := strconv.ParseInt(cell, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(cell, 64); err == nil {
+		return f
+	}
+	return cell
+}
+
+// isJSONNumber reports whether s is a number in JSON syntax. Leading zeros,
+// "+1", ".5", "Inf", and similar forms accepted by strconv remain strings.
+func isJSONNumber(s string) bool {
+	end, err := fastparser.SkipValue([]byte(s), 0)
+	return err == nil && end == len(s) && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9'))
+}
+
+// setCSVPath stores v at the dot-separated path, creating nested objects.
+func setCSVPath(row map[string]interface{}, path []string, v interface{}) error {
+	for _, key := range path[:len(path)-1] {
+		next, exists := row[key]
+		if !exists {
+			m := make(map[string]interface{})
+			row[key] = m
+			row = m
+			continue
+		}
+		m, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%q is both a value and an object", key)
+		}
+		row = m
+	}
+
+	last := path[len(path)-1]
+	if _, exists := row[last]; exists {
+		return fmt.Errorf("duplicate column for %q", last)
+	}
+	row[last] = v
+	return nil
+}
@@ -0,0 +1,162 @@
+package json
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestToCSV(t *testing.T) {
+	arr, err := ParseArray(`[
+		{"id": 1, "name": "Alice", "address": {"city": "Paris", "zip": "75001"}, "tags": ["a", "b"]},
+		{"id": 2.5, "name": "Bob, Jr.", "address": {"city": null}, "active": true},
+		{"id": 3, "name": "Carol \"C\""}
+	]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("explicit columns", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := ToCSV(&buf, arr, CSVOptions{
+			Columns:   []string{"id", "name", "address.city", "tags", "address"},
+			NullValue: "NULL",
+		})
+		if err != nil {
+			t.Fatalf("ToCSV() error = %v", err)
+		}
+		want := "id,name,address.city,tags,address\n" +
+			`1,Alice,Paris,"[""a"",""b""]","{""city"":""Paris"",""zip"":""75001""}"` + "\n" +
+			`2.5,"Bob, Jr.",NULL,,"{""city"":null}"` + "\n" +
+			`3,"Carol ""C""",,,` + "\n"
+		if buf.String() != want {
+			t.Errorf("ToCSV() =\n%s\nwant\n%s", buf.String(), want)
+		}
+	})
+
+	t.Run("inferred columns", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := ToCSV(&buf, arr, CSVOptions{Comma: ';', NoHeader: false}); err != nil {
+			t.Fatalf("ToCSV() error = %v", err)
+		}
+		header := strings.SplitN(buf.String(), "\n", 2)[0]
+		if header != "active;address.city;address.zip;id;name;tags" {
+			t.Errorf("header = %q", header)
+		}
+	})
+
+	t.Run("no header", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := ToCSV(&buf, arr, CSVOptions{Columns: []string{"id"}, NoHeader: true}); err != nil {
+			t.Fatalf("ToCSV() error = %v", err)
+		}
+		if buf.String() != "1\n2.5\n3\n" {
+			t.Errorf("ToCSV() = %q", buf.String())
+		}
+	})
+}
+
+func TestToCSV_Errors(t *testing.T) {
+	arr := NewArray().AddInt(1)
+	if err := ToCSV(&bytes.Buffer{}, arr, CSVOptions{}); err == nil {
+		t.Error("expected error for non-object element")
+	}
+
+	arr = NewArray().AddObject(NewDocument().SetString("a", "x"))
+	if err := ToCSV(&failingWriter{failAfter: 0}, arr, CSVOptions{}); err == nil {
+		t.Error("expected write error")
+	}
+}
+
+func TestFromCSV(t *testing.T) {
+	input := "id,name,address.city,active,score\n" +
+		"1,Alice,Paris,true,\n" +
+		"002,\"Bob, Jr.\",,false,1.5e3\n"
+
+	t.Run("strings", func(t *testing.T) {
+		arr, err := FromCSV(strings.NewReader(input), CSVOptions{})
+		if err != nil {
+			t.Fatalf("FromCSV() error = %v", err)
+		}
+		got, _ := arr.JSON()
+		want := `[{"active":"true","address":{"city":"Paris"},"id":"1","name":"Alice","score":""},` +
+			`{"active":"false","address":{"city":""},"id":"002","name":"Bob, Jr.","score":"1.5e3"}]`
+		if got != want {
+			t.Errorf("FromCSV() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("infer types", func(t *testing.T) {
+		arr, err := FromCSV(strings.NewReader(input), CSVOptions{InferTypes: true})
+		if err != nil {
+			t.Fatalf("FromCSV() error = %v", err)
+		}
+		got, _ := arr.JSON()
+		want := `[{"active":true,"address":{"city":"Paris"},"id":1,"name":"Alice","score":null},` +
+			`{"active":false,"address":{"city":null},"id":"002","name":"Bob, Jr.","score":1500.0}]`
+		if got != want {
+			t.Errorf("FromCSV() = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("columns without header", func(t *testing.T) {
+		arr, err := FromCSV(strings.NewReader("1;x\n"), CSVOptions{
+			Columns:  []string{"a", "b.c"},
+			NoHeader: true,
+			Comma:    ';',
+		})
+		if err != nil {
+			t.Fatalf("FromCSV() error = %v", err)
+		}
+		if got, _ := arr.JSON(); got != `[{"a":"1","b":{"c":"x"}}]` {
+			t.Errorf("FromCSV() = %s", got)
+		}
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		arr, err := FromCSV(strings.NewReader(""), CSVOptions{})
+		if err != nil || arr.Len() != 0 {
+			t.Errorf("FromCSV() = %v, %v", arr, err)
+		}
+	})
+}
+
+func TestCSV_RoundTrip(t *testing.T) {
+	arr, err := ParseArray(`[{"a":{"b":1,"c":"x"},"d":null},{"a":{"b":2.5,"c":"y,z"},"d":true}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := ToCSV(&buf, arr, CSVOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	back, err := FromCSV(&buf, CSVOptions{InferTypes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := arr.JSON()
+	if got, _ := back.JSON(); got != want {
+		t.Errorf("round trip = %s, want %s", got, want)
+	}
+}
+
+func TestFromCSV_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  CSVOptions
+	}{
+		{"no header and no columns", "1,2\n", CSVOptions{NoHeader: true}},
+		{"ragged row", "a,b\n1\n", CSVOptions{}},
+		{"value and object", "a,a.b\n1,2\n", CSVOptions{}},
+		{"duplicate column", "a,a\n1,2\n", CSVOptions{}},
+		{"bad quoting", "a\n\"x\n", CSVOptions{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromCSV(strings.NewReader(tt.input), tt.opts); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
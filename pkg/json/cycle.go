@@ -0,0 +1,183 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrCycleDetected is reported, through a *CycleError, when Marshal meets a
+// value that contains itself, such as a circular linked list or a child
+// holding a pointer back to its parent. Test for it with errors.Is.
+var ErrCycleDetected = errors.New("json: cycle detected")
+
+// CycleError is returned by Marshal for a value that refers back to a map,
+// slice, or pointer enclosing it.
+//
+// Example:
+//
+//	_, err := json.Marshal(list)
+//	var cycle *json.CycleError
+//	if errors.As(err, &cycle) {
+//	    log.Printf("cannot encode list: back reference at %s", cycle.Path)
+//	}
+type CycleError struct {
+	// Path is the JSON Pointer (RFC 6901) of the first value that refers
+	// back to one enclosing it. The root is "".
+	Path string
+	// Type is the type of that value.
+	Type reflect.Type
+
+	// Recorded while the error unwinds out of the encoders, innermost
+	// first, and resolved into Path by finishCycle
+	segs  []string     // path tokens
+	trail []cycleEntry // containers passed
+	done  bool         // Path is set
+}
+
+// cycleEntry is a container a *CycleError unwound through, with the
+// number of path tokens recorded below it.
+type cycleEntry struct {
+	key   visit
+	below int
+}
+
+func (e *CycleError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return "json: cycle detected at " + path + " via " + e.Type.String()
+}
+
+// Unwrap returns ErrCycleDetected.
+func (e *CycleError) Unwrap() error {
+	return ErrCycleDetected
+}
+
+// containerKey identifies the pointer, map, or slice rv. Slices sharing an
+// array differ by length.
+func containerKey(rv reflect.Value) visit {
+	key := visit{ptr: rv.Pointer(), typ: rv.Type()}
+	if rv.Kind() == reflect.Slice {
+		key.len = rv.Len()
+	}
+	return key
+}
+
+// enter is called before encoding the contents of the non-nil pointer,
+// map, or slice rv, and reports a *CycleError if rv is already being
+// encoded further up. Every successful enter is paired with a leave.
+func (e *encodeState) enter(rv reflect.Value) error {
+	e.ptrLevel++
+	if e.ptrLevel <= startDetectingCyclesAfter {
+		return nil
+	}
+	key := containerKey(rv)
+	if _, ok := e.ptrSeen[key]; ok {
+		e.ptrLevel--
+		return &CycleError{trail: []cycleEntry{{key: key}}}
+	}
+	if e.ptrSeen == nil {
+		e.ptrSeen = make(map[visit]struct{})
+	}
+	e.ptrSeen[key] = struct{}{}
+	return nil
+}
+
+// leave ends what enter started, recording rv in a *CycleError err
+// unwinding through it.
+func (e *encodeState) leave(rv reflect.Value, err error) {
+	if e.ptrLevel > startDetectingCyclesAfter {
+		delete(e.ptrSeen, containerKey(rv))
+	}
+	e.ptrLevel--
+	if err != nil {
+		cycleUnwind(err, rv)
+	}
+}
+
+// cycleUnwind records the container rv in a *CycleError unwinding
+// through it.
+func cycleUnwind(err error, rv reflect.Value) {
+	if ce, ok := err.(*CycleError); ok && !ce.done {
+		ce.trail = append(ce.trail, cycleEntry{key: containerKey(rv), below: len(ce.segs)})
+	}
+}
+
+// cycleMember records the member name in a *CycleError unwinding out of
+// the member's value.
+func cycleMember(err error, name string) {
+	if ce, ok := err.(*CycleError); ok && !ce.done {
+		ce.segs = append(ce.segs, escapePointerToken(name))
+	}
+}
+
+// cycleIndex records the array index i in a *CycleError unwinding out of
+// the element.
+func cycleIndex(err error, i int) {
+	if ce, ok := err.(*CycleError); ok && !ce.done {
+		ce.segs = append(ce.segs, strconv.Itoa(i))
+	}
+}
+
+// finishCycle sets the Path and Type of a *CycleError returned for v: of
+// the containers from the root down, the first one that repeats one above
+// it. The error is found only levels below that, where checks begin.
+func finishCycle(err error, v interface{}) {
+	ce, ok := err.(*CycleError)
+	if !ok || ce.done {
+		return
+	}
+	// Marshal dereferences the pointers to the root value itself
+	var ptrs []reflect.Value
+	for rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil(); rv = rv.Elem() {
+		ptrs = append(ptrs, rv)
+	}
+	for i := len(ptrs) - 1; i >= 0; i-- {
+		cycleUnwind(err, ptrs[i])
+	}
+
+	seen := make(map[visit]bool, len(ce.trail))
+	for i := len(ce.trail) - 1; i >= 0; i-- {
+		entry := ce.trail[i]
+		if !seen[entry.key] {
+			seen[entry.key] = true
+			continue
+		}
+		var path strings.Builder
+		for j := len(ce.segs) - 1; j >= entry.below; j-- {
+			path.WriteByte('/')
+			path.WriteString(ce.segs[j])
+		}
+		ce.Path = path.String()
+		ce.Type = entry.key.typ
+		break
+	}
+	ce.segs, ce.trail, ce.done = nil, nil, true
+}
+
+var encodeStatePool = sync.Pool{New: func() interface{} { return new(encodeState) }}
+
+// acquire returns a copy of e for one call, with its own cycle tracking,
+// since e may be shared (see defaultEncodeState). Pass it to release when
+// the call is done.
+func (e *encodeState) acquire() *encodeState {
+	st := encodeStatePool.Get().(*encodeState)
+	seen := st.ptrSeen
+	*st = *e
+	st.ptrLevel = 0
+	st.ptrSeen = seen
+	return st
+}
+
+// release returns a state from acquire to the pool. leave has removed
+// every entry from ptrSeen by then, so the map can be reused.
+func (e *encodeState) release() {
+	*e = encodeState{ptrSeen: e.ptrSeen}
+	encodeStatePool.Put(e)
+}
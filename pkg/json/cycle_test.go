@@ -0,0 +1,130 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type cycleNode struct {
+	Name string     `json:"name"`
+	Next *cycleNode `json:"next"`
+}
+
+type cycleTree struct {
+	Name     string       `json:"name"`
+	Parent   *cycleTree   `json:"parent,omitempty"`
+	Children []*cycleTree `json:"children,omitempty"`
+}
+
+func TestMarshal_Cycle(t *testing.T) {
+	self := &cycleNode{Name: "a"}
+	self.Next = self
+
+	ring := &cycleNode{Name: "a", Next: &cycleNode{Name: "b"}}
+	ring.Next.Next = ring
+
+	tail := &cycleNode{Name: "c"}
+	lasso := &cycleNode{Name: "a", Next: &cycleNode{Name: "b", Next: tail}}
+	tail.Next = lasso.Next
+
+	tree := &cycleTree{Name: "root"}
+	tree.Children = []*cycleTree{{Name: "leaf"}, {Name: "child", Parent: tree}}
+
+	m := map[string]interface{}{"name": "m"}
+	m["inner"] = map[string]interface{}{"self": m}
+
+	s := []interface{}{1, nil}
+	s[1] = s
+
+	tests := []struct {
+		name string
+		in   interface{}
+		path string
+		typ  string
+	}{
+		{"self", self, "/next", "*json.cycleNode"},
+		{"ring", ring, "/next/next", "*json.cycleNode"},
+		{"lasso", lasso, "/next/next/next", "*json.cycleNode"},
+		{"parent", tree, "/children/1/parent", "*json.cycleTree"},
+		{"map", m, "/inner/self", "map[string]interface {}"},
+		{"slice", s, "/1", "[]interface {}"},
+		{"struct in interface", map[string]interface{}{"list": ring}, "/list/next/next", "*json.cycleNode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Marshal(tt.in)
+			if !errors.Is(err, ErrCycleDetected) {
+				t.Fatalf("Marshal() error = %v, want ErrCycleDetected", err)
+			}
+			var cycle *CycleError
+			if !errors.As(err, &cycle) {
+				t.Fatalf("Marshal() error = %T, want *CycleError", err)
+			}
+			if cycle.Path != tt.path || cycle.Type.String() != tt.typ {
+				t.Errorf("CycleError = %q via %s, want %q via %s", cycle.Path, cycle.Type, tt.path, tt.typ)
+			}
+		})
+	}
+}
+
+func TestMarshal_CycleErrorMessage(t *testing.T) {
+	n := &cycleNode{Name: "a"}
+	n.Next = n
+	_, err := Marshal(n)
+	if want := "json: cycle detected at /next via *json.cycleNode"; err == nil || err.Error() != want {
+		t.Errorf("Marshal() error = %v, want %q", err, want)
+	}
+}
+
+func TestMarshal_CycleWithOptions(t *testing.T) {
+	tree := &cycleTree{Name: "root"}
+	tree.Children = []*cycleTree{{Name: "child", Parent: tree}}
+
+	var cycle *CycleError
+	_, err := MarshalWithOptions(tree, MarshalOptions{KeyOrder: KeyOrderSorted, UnsortedMaps: true})
+	if !errors.As(err, &cycle) || cycle.Path != "/children/0/parent" {
+		t.Errorf("MarshalWithOptions() error = %v", err)
+	}
+
+	plan, err := CompileEncoder(reflect.TypeOf(cycleTree{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plan.Marshal(tree); !errors.As(err, &cycle) || cycle.Path != "/children/0/parent" {
+		t.Errorf("EncodePlan.Marshal() error = %v", err)
+	}
+
+	// The shared state of Marshal is left as it was
+	if defaultEncodeState.ptrLevel != 0 || defaultEncodeState.ptrSeen != nil {
+		t.Errorf("defaultEncodeState changed: %+v", defaultEncodeState)
+	}
+}
+
+func TestMarshal_DeepWithoutCycle(t *testing.T) {
+	// Deeper than startDetectingCyclesAfter, with a value shared by every
+	// node, which is not a cycle
+	shared := &cycleTree{Name: "shared"}
+	var head *cycleTree
+	for i := 0; i < 3*startDetectingCyclesAfter; i++ {
+		head = &cycleTree{Name: "n", Children: []*cycleTree{head, {Name: "x", Parent: shared}}}
+	}
+	data, err := Marshal(head)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if n := strings.Count(string(data), `"name":"shared"`); n != 3*startDetectingCyclesAfter {
+		t.Errorf("shared value written %d times", n)
+	}
+
+	var nested interface{} = "leaf"
+	for i := 0; i < 2*startDetectingCyclesAfter; i++ {
+		nested = map[string]interface{}{"a": []interface{}{nested}}
+	}
+	if _, err := Marshal(nested); err != nil {
+		t.Errorf("Marshal(nested maps) error = %v", err)
+	}
+}
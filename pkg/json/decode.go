@@ -1,12 +1,27 @@
 package json
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
 	"io"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
 )
 
 // A Decoder reads and decodes JSON values from an input stream.
 type Decoder struct {
-	r io.Reader
+	r   *bufio.Reader
+	buf bytes.Buffer // text of the value being decoded
+	pos int64        // input offset of the next byte
+	err error        // sticky error that ended the stream
+
+	maxToken int64        // see SetMaxTokenSize; 0 is no limit
+	maxValue int64        // see SetMaxValueSize; 0 is no limit
+	numbers  NumberPolicy // see SetNumberPolicy
+	hash     hash.Hash    // see SetHash
 }
 
 // NewDecoder returns a new decoder that reads from r.
@@ -14,21 +29,283 @@ type Decoder struct {
 // The decoder introduces its own buffering and may read data from r
 // beyond the JSON values requested.
 func NewDecoder(r io.Reader) *Decoder {
-	return &Decoder{r: r}
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// SetMaxTokenSize limits the size of a single string, number, or literal in
+// the input to n bytes, counted as written, without the quotes of strings.
+// Decode returns a *LimitError for a longer token as soon as it has read
+// n+1 bytes of it. Zero, the default, means no limit.
+func (dec *Decoder) SetMaxTokenSize(n int64) {
+	dec.maxToken = n
+}
+
+// SetMaxValueSize limits the text of each value read by Decode, including
+// whitespace within it, to n bytes. Decode returns a *LimitError for a
+// larger value as soon as it has read n+1 bytes of it, so that one
+// oversized record in a stream is never held in memory. Zero, the default,
+// means no limit.
+func (dec *Decoder) SetMaxValueSize(n int64) {
+	dec.maxValue = n
+}
+
+// SetNumberPolicy selects the Go type of the numbers Decode stores in
+// interface{} values; see NumberPolicy. The default is int64 for integers
+// that fit and float64 for other numbers.
+func (dec *Decoder) SetNumberPolicy(p NumberPolicy) {
+	dec.numbers = p
+}
+
+// SetHash makes the decoder write to h every byte it consumes from its
+// input, whitespace between values included, so that a checksum of the
+// stream, such as a SHA-256 for an audit log, is computed while decoding
+// rather than by reading the stream again:
+//
+//	sum := sha256.New()
+//	dec := json.NewDecoder(r)
+//	dec.SetHash(sum)
+//	for {
+//	    if err := dec.Decode(&event); err == io.EOF {
+//	        break
+//	    } else if err != nil {
+//	        return err
+//	    }
+//	    // ...
+//	}
+//	log.Printf("sha256 %x", sum.Sum(nil))
+//
+// Bytes the decoder has buffered but not yet consumed are not written, so
+// once Decode returns io.EOF, h has seen exactly the input. Set the hash
+// before the first call to Decode.
+func (dec *Decoder) SetHash(h hash.Hash) {
+	dec.hash = h
+}
+
+// UseNumber makes Decode store numbers in interface{} values as a Number
+// instead of an int64 or float64, as in encoding/json. It is
+// SetNumberPolicy(NumberPolicyNumber).
+func (dec *Decoder) UseNumber() {
+	dec.numbers = NumberPolicyNumber
+}
+
+// A LimitError reports a value that exceeds a limit set on a Decoder or
+// passed to ParseUntrusted. The decoder stops at the limit, and later
+// calls to Decode return the same error, since the rest of the value was
+// not read. Marshal reports output beyond MarshalOptions.MaxOutputSize
+// the same way.
+type LimitError struct {
+	// Limit is "token size" or "value size" for a Decoder, "document
+	// size", "nesting depth", "string length", or "value count" for
+	// ParseUntrusted, and "output size" for Marshal and Encoder.
+	Limit string
+
+	Max    int64 // the limit, in bytes or in the units Limit counts
+	Offset int64 // input offset, or output offset, of the byte or value that exceeded it
+}
+
+func (e *LimitError) Error() string {
+	unit := "bytes"
+	switch e.Limit {
+	case "nesting depth":
+		unit = "levels"
+	case "value count":
+		unit = "values"
+	}
+	return fmt.Sprintf("json: %s exceeds the limit of %d %s at offset %d", e.Limit, e.Max, unit, e.Offset)
 }
 
 // Decode reads the next JSON-encoded value from its input and stores it
-// in the value pointed to by v.
+// in the value pointed to by v. Values in the input may be separated by
+// whitespace; Decode returns io.EOF when there are no more.
 //
 // See the documentation for Unmarshal for details about the conversion
 // of JSON into a Go value.
 func (dec *Decoder) Decode(v interface{}) error {
-	// Use ParseReader to parse JSON from the stream
-	node, err := ParseReader(dec.r)
+	if err := dec.readValue(); err != nil {
+		return err
+	}
+	return unmarshalAST(dec.buf.Bytes(), v, fastparser.Options{Numbers: dec.numbers.mode(ShapeDefaults)})
+}
+
+// readValue reads the text of the next value into buf. It tracks strings
+// and brackets only as far as needed to find the value's end and to apply
+// the limits; the parser checks the rest.
+func (dec *Decoder) readValue() error {
+	if dec.err != nil {
+		return dec.err
+	}
+	dec.buf.Reset()
+	if err := dec.skipSpace(); err != nil {
+		return err
+	}
+
+	c, err := dec.next()
+	if err == nil {
+		switch c {
+		case '"':
+			err = dec.readString()
+		case '{', '[':
+			err = dec.readContainer()
+		default:
+			err = dec.readBare()
+		}
+	}
+	if dec.hash != nil {
+		dec.hash.Write(dec.buf.Bytes())
+	}
 	if err != nil {
+		return dec.fail(err)
+	}
+	return nil
+}
+
+// fail records err as the error that ends the stream.
+func (dec *Decoder) fail(err error) error {
+	dec.err = err
+	return err
+}
+
+// skipSpace consumes whitespace, returning io.EOF at the end of the input.
+func (dec *Decoder) skipSpace() error {
+	var space [64]byte // whitespace not yet written to hash
+	n := 0
+	for {
+		c, err := dec.r.ReadByte()
+		if err == nil {
+			switch c {
+			case ' ', '\t', '\n', '\r':
+				dec.pos++
+				if dec.hash != nil {
+					if n == len(space) {
+						dec.hash.Write(space[:])
+						n = 0
+					}
+					space[n] = c
+					n++
+				}
+				continue
+			}
+			err = dec.r.UnreadByte()
+		}
+		if n > 0 {
+			dec.hash.Write(space[:n])
+		}
 		return err
 	}
+}
+
+// next reads one byte of the value into buf, reporting the end of the
+// input as a syntax error.
+func (dec *Decoder) next() (byte, error) {
+	c, err := dec.r.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			return 0, errors.New("unexpected end of JSON input")
+		}
+		return 0, err
+	}
+	if dec.maxValue > 0 && int64(dec.buf.Len()) >= dec.maxValue {
+		return 0, &LimitError{Limit: "value size", Max: dec.maxValue, Offset: dec.pos}
+	}
+	dec.pos++
+	dec.buf.WriteByte(c)
+	return c, nil
+}
 
-	// Use the same unmarshal logic as Unmarshal
-	return unmarshalFromNode(node, v)
+// token counts one more byte of a token of n bytes so far.
+func (dec *Decoder) token(n int64) (int64, error) {
+	n++
+	if dec.maxToken > 0 && n > dec.maxToken {
+		return n, &LimitError{Limit: "token size", Max: dec.maxToken, Offset: dec.pos - 1}
+	}
+	return n, nil
+}
+
+// readString reads the rest of a string whose opening quote has been read.
+func (dec *Decoder) readString() error {
+	var n int64
+	for {
+		c, err := dec.next()
+		if err != nil {
+			return err
+		}
+		if c == '"' {
+			return nil
+		}
+		if n, err = dec.token(n); err != nil {
+			return err
+		}
+		if c == '\\' {
+			if _, err := dec.next(); err != nil {
+				return err
+			}
+			if n, err = dec.token(n); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readContainer reads the rest of an object or array whose opening
+// bracket has been read.
+func (dec *Decoder) readContainer() error {
+	depth := 1
+	var n int64 // length of the number or literal being read
+	for {
+		c, err := dec.next()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case '"':
+			n = 0
+			if err := dec.readString(); err != nil {
+				return err
+			}
+		case '{', '[':
+			n = 0
+			depth++
+		case '}', ']':
+			n = 0
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		case ',', ':', ' ', '\t', '\n', '\r':
+			n = 0
+		default:
+			if n, err = dec.token(n); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readBare reads the rest of a top-level number or literal, which ends at
+// the first byte that cannot continue it or at the end of the input.
+func (dec *Decoder) readBare() error {
+	n, err := dec.token(0)
+	if err != nil {
+		return err
+	}
+	for {
+		c, err := dec.r.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		_ = dec.r.UnreadByte()
+		switch c {
+		case ' ', '\t', '\n', '\r', ',', ':', '"', '{', '}', '[', ']':
+			return nil
+		}
+		if _, err := dec.next(); err != nil {
+			return err
+		}
+		if n, err = dec.token(n); err != nil {
+			return err
+		}
+	}
 }
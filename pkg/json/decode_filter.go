@@ -0,0 +1,293 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/internal/pathplan"
+	_ "github.com/shapestone/shape-json/pkg/jsonpath" // sets pathplan.ForStream
+)
+
+// errFilterStopped ends the stream of a Decoder whose Filter loop was
+// broken out of in the middle of a value.
+var errFilterStopped = errors.New("json: Decoder used after breaking out of Filter")
+
+// Filter evaluates the JSONPath query against each of the remaining values
+// in the decoder's input and yields the values it selects, decoded as for
+// Decode into an interface{}:
+//
+//	dec := json.NewDecoder(file)
+//	for event, err := range dec.Filter("$.events[?(@.level=='error')]") {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    alert(event)
+//	}
+//
+// The input is read as it is walked rather than decoded first. The leading
+// member names, indexes, and wildcards of the query, and a filter after
+// them, are followed through the text, so that only the values they reach
+// are decoded, one at a time, and the members and elements they pass over
+// are skipped without being decoded. Memory use is therefore bounded by the
+// largest value the query reaches, not by the input. A query such as
+// $..name that cannot be followed this way is evaluated against each whole
+// value.
+//
+// An object in which a member the query follows appears twice is an
+// error, as it is for Parse: Decode would keep only the last of them, and
+// Filter cannot know that a later one is coming without buffering. Values
+// yielded before the repeated name is reached are not taken back. Names
+// the query does not follow may repeat, and are not remembered.
+//
+// A query that does not parse, a syntax error in the input, and a
+// duplicate name are yielded once with a nil value and end the iteration. Limits set with
+// SetMaxTokenSize and SetMaxValueSize apply to each member and element
+// read or skipped, and SetHash sees the whole input. Once the loop has
+// run to the end, Decode returns io.EOF; breaking out of the loop early
+// leaves the decoder within a value, and later calls to Decode return an
+// error.
+func (dec *Decoder) Filter(query string) iter.Seq2[interface{}, error] {
+	return func(yield func(interface{}, error) bool) {
+		plan, err := pathplan.ForStream(query)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		f := &decodeFilter{dec: dec, plan: plan, yield: yield}
+		for {
+			if dec.err != nil {
+				yield(nil, dec.err)
+				return
+			}
+			if err := dec.skipSpace(); err != nil {
+				if err != io.EOF {
+					yield(nil, dec.fail(err))
+				}
+				return
+			}
+			if err := f.value(0); err != nil {
+				if err == errFilterStopped {
+					dec.fail(err)
+				} else {
+					yield(nil, dec.fail(err))
+				}
+				return
+			}
+		}
+	}
+}
+
+// decodeFilter walks the values of a Decoder for Filter.
+type decodeFilter struct {
+	dec   *Decoder
+	plan  pathplan.Plan
+	yield func(interface{}, error) bool
+}
+
+// value handles the value at the start of the input, at depth d below the
+// top-level value, whose location the first d steps of the plan select.
+// It returns errFilterStopped once yield has returned false.
+func (f *decodeFilter) value(d int) error {
+	dec := f.dec
+	if d == f.plan.Len() {
+		if err := dec.readValue(); err != nil {
+			return err
+		}
+		var v interface{}
+		if err := unmarshalAST(dec.buf.Bytes(), &v, fastparser.Options{Numbers: dec.numbers.mode(ShapeDefaults)}); err != nil {
+			return err
+		}
+		for _, match := range f.plan.Apply(v) {
+			if !f.yield(match, nil) {
+				return errFilterStopped
+			}
+		}
+		return nil
+	}
+
+	c, err := dec.peek()
+	if err != nil {
+		return err
+	}
+	switch c {
+	case '{':
+		return f.object(d)
+	case '[':
+		return f.array(d)
+	}
+	return dec.skipValue()
+}
+
+// object walks the members of an object, descending into those step d
+// selects and skipping the others.
+func (f *decodeFilter) object(d int) error {
+	dec := f.dec
+	var followed map[string]bool // the names selected so far
+	if _, err := dec.step(); err != nil {
+		return err
+	}
+	c, err := dec.peek()
+	if err != nil {
+		return err
+	}
+	if c == '}' {
+		_, err := dec.step()
+		return err
+	}
+	for {
+		if c != '"' {
+			return fmt.Errorf("expected string key in object at position %d", dec.pos)
+		}
+		if err := dec.readValue(); err != nil {
+			return err
+		}
+		var name string
+		if err := unmarshalAST(dec.buf.Bytes(), &name, fastparser.Options{}); err != nil {
+			return err
+		}
+		follow := f.plan.Member(d, name)
+		if follow {
+			if followed[name] {
+				return fmt.Errorf("duplicate key %q in object at position %d", name, dec.pos-int64(dec.buf.Len()))
+			}
+			if followed == nil {
+				followed = make(map[string]bool)
+			}
+			followed[name] = true
+		}
+		if c, err = dec.peek(); err != nil {
+			return err
+		}
+		if c != ':' {
+			return fmt.Errorf("expected ':' after object key at position %d", dec.pos)
+		}
+		if _, err := dec.step(); err != nil {
+			return err
+		}
+		if _, err := dec.peek(); err != nil {
+			return err
+		}
+		if follow {
+			err = f.value(d + 1)
+		} else {
+			err = dec.skipValue()
+		}
+		if err != nil {
+			return err
+		}
+
+		if c, err = dec.peek(); err != nil {
+			return err
+		}
+		if _, err := dec.step(); err != nil {
+			return err
+		}
+		switch c {
+		case '}':
+			return nil
+		case ',':
+		default:
+			return fmt.Errorf("expected ',' or '}' in object at position %d", dec.pos-1)
+		}
+		if c, err = dec.peek(); err != nil {
+			return err
+		}
+	}
+}
+
+// array walks the elements of an array, descending into those step d
+// selects and skipping the others.
+func (f *decodeFilter) array(d int) error {
+	dec := f.dec
+	if _, err := dec.step(); err != nil {
+		return err
+	}
+	c, err := dec.peek()
+	if err != nil {
+		return err
+	}
+	if c == ']' {
+		_, err := dec.step()
+		return err
+	}
+	for i := 0; ; i++ {
+		if c == ']' {
+			return fmt.Errorf("unexpected character ']' at position %d", dec.pos)
+		}
+		if f.plan.Element(d, i) {
+			err = f.value(d + 1)
+		} else {
+			err = dec.skipValue()
+		}
+		if err != nil {
+			return err
+		}
+
+		if c, err = dec.peek(); err != nil {
+			return err
+		}
+		if _, err := dec.step(); err != nil {
+			return err
+		}
+		switch c {
+		case ']':
+			return nil
+		case ',':
+		default:
+			return fmt.Errorf("expected ',' or ']' in array at position %d", dec.pos-1)
+		}
+		if c, err = dec.peek(); err != nil {
+			return err
+		}
+	}
+}
+
+// skipValue reads the next value and checks its syntax without decoding
+// it.
+func (dec *Decoder) skipValue() error {
+	if err := dec.readValue(); err != nil {
+		return err
+	}
+	data := dec.buf.Bytes()
+	end, err := fastparser.SkipValue(data, 0)
+	if err != nil {
+		return err
+	}
+	if end != len(data) {
+		return fmt.Errorf("unexpected data after JSON value at position %d", dec.pos-int64(len(data)-end))
+	}
+	return nil
+}
+
+// peek skips whitespace and returns the next byte without consuming it,
+// reporting the end of the input as a syntax error.
+func (dec *Decoder) peek() (byte, error) {
+	if err := dec.skipSpace(); err != nil {
+		if err == io.EOF {
+			return 0, errors.New("unexpected end of JSON input")
+		}
+		return 0, err
+	}
+	b, err := dec.r.Peek(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// step consumes one bracket, comma, or colon of a value walked by Filter,
+// which is written to the hash but not kept in buf.
+func (dec *Decoder) step() (byte, error) {
+	c, err := dec.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	dec.pos++
+	if dec.hash != nil {
+		dec.hash.Write([]byte{c})
+	}
+	return c, nil
+}
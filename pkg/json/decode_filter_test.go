@@ -0,0 +1,218 @@
+package json
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-json/pkg/jsonpath"
+)
+
+func filterAll(t *testing.T, dec *Decoder, query string) ([]interface{}, error) {
+	t.Helper()
+	var out []interface{}
+	for v, err := range dec.Filter(query) {
+		if err != nil {
+			return out, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func TestDecoder_Filter(t *testing.T) {
+	input := `{
+		"service": "api",
+		"events": [
+			{"level": "info", "msg": "started", "n": 1},
+			{"level": "error", "msg": "failed", "n": 2},
+			{"level": "warn", "msg": "slow", "n": 3},
+			{"level": "error", "msg": "lost \"conn\"", "n": 4}
+		],
+		"meta": {"events": [{"level": "error"}]}
+	}`
+
+	tests := []struct {
+		query string
+		want  []interface{}
+	}{
+		{"$.events[?(@.level=='error')].msg", []interface{}{"failed", `lost "conn"`}},
+		{"$.events[?(@.n > 2)].n", []interface{}{int64(3), int64(4)}},
+		{"$.events[1].level", []interface{}{"error"}},
+		{"$.events[*].n", []interface{}{int64(1), int64(2), int64(3), int64(4)}},
+		{"$.service", []interface{}{"api"}},
+		{"$.events[-1].n", []interface{}{int64(4)}},
+		{"$.missing", nil},
+		{"$.service.name", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := filterAll(t, NewDecoder(strings.NewReader(input)), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+
+			// The same values as decoding everything and then querying
+			var doc interface{}
+			if err := Unmarshal([]byte(input), &doc); err != nil {
+				t.Fatal(err)
+			}
+			e, _ := jsonpath.ParseString(tt.query)
+			if want := e.Get(doc); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %#v, Get returns %#v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecoder_FilterStream(t *testing.T) {
+	input := `{"level":"info","id":1}
+{"level":"error","id":2}
+[{"level":"error","id":3}]
+{"level":"error","id":4}
+`
+	dec := NewDecoder(strings.NewReader(input))
+	got, err := filterAll(t, dec, "$[?(@.level=='error')].id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{int64(3)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	dec = NewDecoder(strings.NewReader(input))
+	got, err = filterAll(t, dec, "$.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{int64(1), int64(2), int64(4)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	var v interface{}
+	if err := dec.Decode(&v); err != io.EOF {
+		t.Errorf("Decode after Filter = %v, want io.EOF", err)
+	}
+}
+
+func TestDecoder_FilterErrors(t *testing.T) {
+	tests := []struct {
+		name, input, query string
+	}{
+		{"bad query", `{}`, "$.a["},
+		{"skipped member", `{"a": [1, 2,], "b": 1}`, "$.b"},
+		{"trailing comma", `{"a": [1, 2,]}`, "$.a[*]"},
+		{"missing colon", `{"a" 1}`, "$.a"},
+		{"missing comma", `{"a": 1 "b": 2}`, "$.b"},
+		{"unterminated", `{"a": [1, 2`, "$.a[*]"},
+		{"bad key", `{1: 2}`, "$.a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			if _, err := filterAll(t, dec, tt.query); err == nil {
+				t.Fatal("Filter yielded no error")
+			}
+			var v interface{}
+			if tt.name != "bad query" && dec.Decode(&v) == nil {
+				t.Error("Decode succeeded after a syntax error")
+			}
+		})
+	}
+
+	var syn *jsonpath.SyntaxError
+	_, err := filterAll(t, NewDecoder(strings.NewReader(`{}`)), "$.a[")
+	if !errors.As(err, &syn) {
+		t.Errorf("error = %T, want *jsonpath.SyntaxError", err)
+	}
+}
+
+func TestDecoder_FilterDuplicateKeys(t *testing.T) {
+	input := `{"a": 1, "b": {"x": 1}, "a": 2, "b": {"x": 2}}`
+	tests := []struct {
+		query string
+		want  []interface{}
+	}{
+		{"$.a", []interface{}{int64(1)}},
+		{"$.b.x", []interface{}{int64(1)}},
+		{"$.*", []interface{}{int64(1), map[string]interface{}{"x": int64(1)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := filterAll(t, NewDecoder(strings.NewReader(input)), tt.query)
+			if err == nil || !strings.Contains(err.Error(), `duplicate key`) {
+				t.Fatalf("error = %v, want a duplicate key error", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("yielded %#v before the error, want %#v", got, tt.want)
+			}
+		})
+	}
+
+	// Names the query does not follow may repeat, and those inside the
+	// values it decodes keep the last value, as Decode does
+	input = `{"a": [{"k": 1, "k": 2}], "z": 0, "z": 1}`
+	var decoded interface{}
+	if err := Unmarshal([]byte(input), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	expr, err := jsonpath.ParseString("$.a[0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := filterAll(t, NewDecoder(strings.NewReader(input)), "$.a[0]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := expr.Get(decoded); !reflect.DeepEqual(got, want) {
+		t.Errorf("Filter = %#v, decoding then Get = %#v", got, want)
+	}
+}
+
+func TestDecoder_FilterBreak(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a": [1, 2, 3]} {"a": [4]}`))
+	for v := range dec.Filter("$.a[*]") {
+		if v != int64(1) {
+			t.Errorf("first value = %v, want 1", v)
+		}
+		break
+	}
+	var v interface{}
+	if err := dec.Decode(&v); err == nil || err == io.EOF {
+		t.Errorf("Decode after break = %v, want an error", err)
+	}
+}
+
+func TestDecoder_FilterOptions(t *testing.T) {
+	input := ` {"big": [{"blob": "` + strings.Repeat("x", 100) + `"}, {"n": 1.5}]} `
+
+	// Limits apply to skipped values too
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetMaxTokenSize(50)
+	var limit *LimitError
+	if _, err := filterAll(t, dec, "$.big[1].n"); !errors.As(err, &limit) {
+		t.Errorf("error = %v, want a *LimitError", err)
+	}
+
+	// The hash sees every byte
+	sum := sha256.New()
+	dec = NewDecoder(strings.NewReader(input))
+	dec.SetHash(sum)
+	dec.UseNumber()
+	got, err := filterAll(t, dec, "$.big[1].n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []interface{}{Number("1.5")}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+	if got, want := sum.Sum(nil), sha256.Sum256([]byte(input)); !bytes.Equal(got, want[:]) {
+		t.Error("hash differs from the hash of the input")
+	}
+}
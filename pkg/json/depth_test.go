@@ -0,0 +1,71 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMaxDepth(t *testing.T) {
+	ops := map[string]func([]byte) error{
+		"Parse":       func(data []byte) error { _, err := Parse(string(data)); return err },
+		"ParseReader": func(data []byte) error { _, err := ParseReader(bytes.NewReader(data)); return err },
+		"Repair":      func(data []byte) error { _, err := RepairBytes(data); return err },
+		"Unmarshal":   func(data []byte) error { var v interface{}; return Unmarshal(data, &v) },
+		"UnmarshalWithAST": func(data []byte) error {
+			var v interface{}
+			return UnmarshalWithAST(data, &v)
+		},
+		"Validate":    ValidateBytes,
+		"Equal":       func(data []byte) error { _, err := Equal(data, data); return err },
+		"Redact":      func(data []byte) error { _, err := Redact(data, []string{"$.x"}, nil); return err },
+		"PrettyPrint": func(data []byte) error { return PrettyPrint(io.Discard, data, StyleOptions{Compact: true}) },
+		"Profile":     func(data []byte) error { _, err := Profile(data); return err },
+		"FormatSource": func(data []byte) error {
+			_, err := FormatSource(data, FormatOptions{Lenient: true})
+			return err
+		},
+		"StreamEvents": func(data []byte) error {
+			return StreamEvents(bytes.NewReader(data), EventHandler{}, StreamOptions{})
+		},
+		"Diagnose": func(data []byte) error {
+			if diags := Diagnose(data); len(diags) > 0 {
+				return errors.New(diags[0].Message)
+			}
+			return nil
+		},
+		"CheckShape": func(data []byte) error {
+			type tree []tree
+			if issues := CheckShape(data, tree{}); len(issues) > 0 {
+				return errors.New(issues[0].Message)
+			}
+			return nil
+		},
+	}
+
+	nested := func(depth int) []byte {
+		return []byte(strings.Repeat("[", depth) + strings.Repeat("]", depth))
+	}
+	// Comparing and indenting take time quadratic in the depth
+	slow := map[string]bool{"Equal": true, "FormatSource": true}
+	for name, op := range ops {
+		if !slow[name] {
+			if err := op(nested(MaxDepth)); err != nil {
+				t.Errorf("%s at MaxDepth: %v", name, err)
+			}
+		}
+		err := op(nested(MaxDepth + 1))
+		if err == nil || !strings.Contains(err.Error(), ErrDepthExceeded.Error()) {
+			t.Errorf("%s beyond MaxDepth: error = %v, want ErrDepthExceeded", name, err)
+		}
+	}
+
+	_, err := Parse(strings.Repeat(`{"a":`, MaxDepth+1))
+	if !errors.Is(err, ErrDepthExceeded) {
+		t.Errorf("Parse error = %v, want ErrDepthExceeded", err)
+	}
+}
@@ -0,0 +1,441 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/pkg/jsonlex"
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+// Severity is the severity of a Diagnostic. The values are those of the
+// Language Server Protocol's DiagnosticSeverity.
+type Severity int
+
+const (
+	SeverityError       Severity = 1
+	SeverityWarning     Severity = 2
+	SeverityInformation Severity = 3
+	SeverityHint        Severity = 4
+)
+
+// String returns the name of the severity, such as "error".
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInformation:
+		return "information"
+	case SeverityHint:
+		return "hint"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Position is a location in a document, counted the way the Language Server
+// Protocol counts: Line and Character are zero-based, and Character is in
+// UTF-16 code units. Offset is the byte offset.
+type Position struct {
+	Line      int
+	Character int
+	Offset    int
+}
+
+// Range is the part of a document from Start up to, but not including, End.
+type Range struct {
+	Start Position
+	End   Position
+}
+
+// Diagnostic is a problem found in a document by Diagnose. Its fields map
+// one to one onto an LSP Diagnostic.
+type Diagnostic struct {
+	Range    Range
+	Severity Severity
+
+	// Code classifies the problem: "syntax", "duplicate-key", "depth"
+	// (nested more than MaxDepth levels deep), or "schema".
+	Code string
+
+	// Source is always "shape-json".
+	Source string
+
+	Message string
+}
+
+// String formats the diagnostic as "<line>:<column>: <severity>: <message>"
+// with a 1-based line and column, as compilers report errors.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Range.Start.Line+1, d.Range.Start.Character+1, d.Severity, d.Message)
+}
+
+// DiagnoseOptions configures DiagnoseWithOptions.
+type DiagnoseOptions struct {
+	// Schema, if set, reports the values that violate it. Schema validation
+	// only runs on documents without syntax errors or too deep nesting.
+	Schema *jsonschema.Schema
+}
+
+// Diagnose checks a document and reports every problem found, for backing
+// a language server or an editor's lint pass. It returns nil for a valid
+// document.
+//
+// Unlike Validate, which stops at the first error, Diagnose recovers after
+// a syntax error at the next ',' or closing bracket and keeps going, so one
+// typo does not hide the problems after it. Duplicate object keys are
+// reported at the repeated key. A container nested more than MaxDepth
+// levels deep is reported once and stepped over unchecked.
+//
+// Example:
+//
+//	for _, d := range json.Diagnose(data) {
+//	    fmt.Println(d) // 3:12: error: expected ',' or '}' after object member, found String
+//	}
+func Diagnose(input []byte) []Diagnostic {
+	return DiagnoseWithOptions(input, DiagnoseOptions{})
+}
+
+// DiagnoseWithOptions is Diagnose with options, such as a JSON Schema to
+// check the document against.
+func DiagnoseWithOptions(input []byte, opts DiagnoseOptions) []Diagnostic {
+	d := &diagnoser{
+		data:    input,
+		scanner: jsonlex.NewScanner(input),
+	}
+	if opts.Schema != nil {
+		d.ranges = make(map[string][2]int)
+	}
+	d.lineStarts = append(d.lineStarts, 0)
+	for i, c := range input {
+		if c == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+
+	d.next()
+	if d.tok.Kind == jsonlex.TokenEOF {
+		d.report(d.tok.Offset, d.tok.Offset, "syntax", "empty document")
+		return d.diags
+	}
+	if d.value("") && d.tok.Kind != jsonlex.TokenEOF {
+		d.reportToken("syntax", fmt.Sprintf("unexpected %s after JSON value", d.tok.Kind))
+	}
+
+	if opts.Schema != nil && !d.syntaxErrors {
+		d.schema(opts.Schema)
+	}
+	return d.diags
+}
+
+// diagnoser is a recursive descent parser that records problems instead of
+// stopping at them.
+type diagnoser struct {
+	data       []byte
+	scanner    *jsonlex.Scanner
+	tok        jsonlex.Token
+	lineStarts []int // byte offset of the start of each line
+
+	// ranges maps the JSON Pointer of each value to its byte range, to
+	// place schema violations. It is nil, and pointers are not built,
+	// without a schema.
+	ranges map[string][2]int
+	depth  int // containers entered
+
+	diags        []Diagnostic
+	syntaxErrors bool
+}
+
+func (d *diagnoser) next() {
+	d.tok = d.scanner.Next()
+}
+
+// value checks the value at the current token, found at the JSON Pointer
+// ptr. It reports false if the value is malformed, leaving the current
+// token where parsing should resume.
+func (d *diagnoser) value(ptr string) bool {
+	start := d.tok.Offset
+	switch d.tok.Kind {
+	case jsonlex.TokenLBrace, jsonlex.TokenLBracket:
+		if d.depth >= fastparser.MaxDepth {
+			d.reportToken("depth", fmt.Sprintf("%s of %d", ErrDepthExceeded, MaxDepth))
+			d.skipContainer()
+			return true
+		}
+		d.depth++
+		var ok bool
+		if d.tok.Kind == jsonlex.TokenLBrace {
+			ok = d.object(ptr)
+		} else {
+			ok = d.array(ptr)
+		}
+		d.depth--
+		if !ok {
+			return false
+		}
+	case jsonlex.TokenString, jsonlex.TokenNumber, jsonlex.TokenTrue, jsonlex.TokenFalse, jsonlex.TokenNull:
+		// The lexer is more forgiving than the decoder about escapes
+		if _, err := fastparser.SkipValue(d.tok.Raw, 0); err != nil {
+			d.reportToken("syntax", "invalid "+d.tok.Kind+": "+err.Error())
+		}
+		d.next()
+	case jsonlex.TokenInvalid:
+		d.reportToken("syntax", fmt.Sprintf("invalid token %q", d.tok.Raw))
+		d.next()
+		return false
+	case jsonlex.TokenEOF:
+		d.reportToken("syntax", "unexpected end of input, expected a value")
+		return false
+	default:
+		d.reportToken("syntax", "expected a value, found "+d.tok.Kind)
+		return false
+	}
+	if d.ranges != nil {
+		d.ranges[ptr] = [2]int{start, d.end()}
+	}
+	return true
+}
+
+// skipContainer steps over the container at the current token, and those
+// nested in it, without recursing or checking them.
+func (d *diagnoser) skipContainer() {
+	depth := 0
+	for {
+		switch d.tok.Kind {
+		case jsonlex.TokenEOF:
+			return
+		case jsonlex.TokenLBrace, jsonlex.TokenLBracket:
+			depth++
+		case jsonlex.TokenRBrace, jsonlex.TokenRBracket:
+			depth--
+		}
+		d.next()
+		if depth == 0 {
+			return
+		}
+	}
+}
+
+// child returns the JSON Pointer of a member or element of the value at
+// ptr, or "" when pointers are not needed.
+func (d *diagnoser) child(ptr, token string) string {
+	if d.ranges == nil {
+		return ""
+	}
+	return ptr + "/" + token
+}
+
+// object checks an object starting at the current '{' token. Like value, it
+// reports whether it was well formed.
+func (d *diagnoser) object(ptr string) bool {
+	ok := true
+	seen := make(map[string]int) // key -> line of its first occurrence
+	d.next()
+	if d.tok.Kind == jsonlex.TokenRBrace {
+		d.next()
+		return true
+	}
+	for {
+		if d.member(ptr, seen) {
+			switch d.tok.Kind {
+			case jsonlex.TokenComma:
+				d.next()
+				if d.tok.Kind == jsonlex.TokenRBrace {
+					d.reportToken("syntax", "trailing comma in object")
+					d.next()
+					return false
+				}
+				continue
+			case jsonlex.TokenRBrace:
+				d.next()
+				return ok
+			}
+			d.reportToken("syntax", "expected ',' or '}' after object member, found "+d.tok.Kind)
+		}
+		ok = false
+		if !d.recover(jsonlex.TokenRBrace) {
+			return false
+		}
+		if d.tok.Kind == jsonlex.TokenRBrace {
+			d.next()
+			return false
+		}
+		d.next() // ','
+	}
+}
+
+// member checks an object member, recording its key in seen.
+func (d *diagnoser) member(ptr string, seen map[string]int) bool {
+	switch d.tok.Kind {
+	case jsonlex.TokenString:
+	case jsonlex.TokenInvalid:
+		d.reportToken("syntax", fmt.Sprintf("invalid token %q", d.tok.Raw))
+		return false
+	default:
+		d.reportToken("syntax", "expected string object key, found "+d.tok.Kind)
+		return false
+	}
+	key, err := decodeKey(d.tok.Raw)
+	if err != nil {
+		d.reportToken("syntax", "invalid object key: "+err.Error())
+		return false
+	}
+	if line, dup := seen[key]; dup {
+		d.reportToken("duplicate-key", fmt.Sprintf("duplicate key %q, first defined on line %d", key, line))
+	} else {
+		seen[key] = d.tok.Line
+	}
+	d.next()
+
+	if d.tok.Kind != jsonlex.TokenColon {
+		d.reportToken("syntax", fmt.Sprintf("expected ':' after object key %q, found %s", key, d.tok.Kind))
+		return false
+	}
+	d.next()
+	return d.value(d.child(ptr, escapePointerToken(key)))
+}
+
+// array checks an array starting at the current '[' token. Like value, it
+// reports whether it was well formed.
+func (d *diagnoser) array(ptr string) bool {
+	ok := true
+	d.next()
+	if d.tok.Kind == jsonlex.TokenRBracket {
+		d.next()
+		return true
+	}
+	for i := 0; ; i++ {
+		if d.value(d.child(ptr, strconv.Itoa(i))) {
+			switch d.tok.Kind {
+			case jsonlex.TokenComma:
+				d.next()
+				if d.tok.Kind == jsonlex.TokenRBracket {
+					d.reportToken("syntax", "trailing comma in array")
+					d.next()
+					return false
+				}
+				continue
+			case jsonlex.TokenRBracket:
+				d.next()
+				return ok
+			}
+			d.reportToken("syntax", "expected ',' or ']' after array element, found "+d.tok.Kind)
+		}
+		ok = false
+		if !d.recover(jsonlex.TokenRBracket) {
+			return false
+		}
+		if d.tok.Kind == jsonlex.TokenRBracket {
+			d.next()
+			return false
+		}
+		d.next() // ','
+	}
+}
+
+// recover skips to the next ',' or closing bracket of the container being
+// checked, stepping over nested containers. It reports false, with nothing
+// consumed, if it meets end of input or the closing bracket of an
+// enclosing container instead.
+func (d *diagnoser) recover(closer string) bool {
+	depth := 0
+	for {
+		switch d.tok.Kind {
+		case jsonlex.TokenEOF:
+			return false
+		case jsonlex.TokenLBrace, jsonlex.TokenLBracket:
+			depth++
+		case jsonlex.TokenRBrace, jsonlex.TokenRBracket:
+			if depth == 0 {
+				return d.tok.Kind == closer
+			}
+			depth--
+		case jsonlex.TokenComma:
+			if depth == 0 {
+				return true
+			}
+		}
+		d.next()
+	}
+}
+
+// schema reports the violations of schema by the document, which has no
+// syntax errors.
+func (d *diagnoser) schema(schema *jsonschema.Schema) {
+	err := schema.ValidateBytes(d.data)
+	var violations jsonschema.ValidationErrors
+	if !errors.As(err, &violations) {
+		return
+	}
+	for _, v := range violations {
+		r, ok := d.ranges[v.InstancePath]
+		if !ok {
+			r = d.ranges[""]
+		}
+		d.report(r[0], r[1], "schema", v.Keyword+": "+v.Message)
+	}
+}
+
+// end returns the byte offset just past the last token consumed.
+func (d *diagnoser) end() int {
+	// The current token is the one after the value, so scan back over the
+	// whitespace between them
+	end := d.tok.Offset
+	for end > 0 {
+		switch d.data[end-1] {
+		case ' ', '\t', '\n', '\r':
+			end--
+			continue
+		}
+		break
+	}
+	return end
+}
+
+// reportToken records a problem with the current token.
+func (d *diagnoser) reportToken(code, msg string) {
+	d.report(d.tok.Offset, d.tok.Offset+len(d.tok.Raw), code, msg)
+}
+
+// report records a problem with the bytes [start, end) of the document.
+func (d *diagnoser) report(start, end int, code, msg string) {
+	if code == "syntax" || code == "depth" {
+		d.syntaxErrors = true
+	}
+	d.diags = append(d.diags, Diagnostic{
+		Range:    Range{Start: d.position(start), End: d.position(end)},
+		Severity: SeverityError,
+		Code:     code,
+		Source:   "shape-json",
+		Message:  msg,
+	})
+}
+
+// position converts a byte offset into a Position.
+func (d *diagnoser) position(offset int) Position {
+	// Find the last line starting at or before offset
+	lo, hi := 0, len(d.lineStarts)
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if d.lineStarts[mid] <= offset {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	char := 0
+	for line := d.data[d.lineStarts[lo]:offset]; len(line) > 0; {
+		r, size := utf8.DecodeRune(line)
+		line = line[size:]
+		char++
+		if r >= 0x10000 {
+			char++ // a surrogate pair in UTF-16
+		}
+	}
+	return Position{Line: lo, Character: char, Offset: offset}
+}
@@ -0,0 +1,133 @@
+package json
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string // Diagnostic.String() of each diagnostic
+	}{
+		{"valid", `{"a": [1, 2, {"b": null}]}`, nil},
+		{"empty", "  ", []string{"1:3: error: empty document"}},
+		{"missing comma", "{\n  \"a\": 1\n  \"b\": 2\n}",
+			[]string{"3:3: error: expected ',' or '}' after object member, found String"}},
+		{"trailing comma", `[1, 2,]`, []string{"1:7: error: trailing comma in array"}},
+		{"duplicate key", "{\"a\": 1,\n \"a\": 2}",
+			[]string{`2:2: error: duplicate key "a", first defined on line 1`}},
+		{"invalid token", `{"a": tru}`, []string{`1:7: error: invalid token "tru"`}},
+		{"invalid escape", `["\y"]`, []string{`1:2: error: invalid String: invalid escape sequence '\y'`}},
+		{"unquoted key", `{a: 1}`, []string{`1:2: error: invalid token "a"`}},
+		{"unterminated", `{"a": [1, 2`, []string{"1:12: error: expected ',' or ']' after array element, found EOF"}},
+		{"trailing content", `{} {}`, []string{"1:4: error: unexpected LBrace after JSON value"}},
+		{"recovers", `{"a": [1 2], "b": {"c" 3}, "b": 1, "d": }`, []string{
+			"1:10: error: expected ',' or ']' after array element, found Number",
+			"1:24: error: expected ':' after object key \"c\", found Number",
+			`1:28: error: duplicate key "b", first defined on line 1`,
+			"1:41: error: expected a value, found RBrace",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags := Diagnose([]byte(tt.input))
+			var got []string
+			for _, d := range diags {
+				got = append(got, d.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Diagnose() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDiagnoseRange(t *testing.T) {
+	// 😀 is two UTF-16 code units, é one
+	input := "{\"😀é\": 1,\n \"x\": [true, fals]}"
+	diags := Diagnose([]byte(input))
+	if len(diags) != 1 {
+		t.Fatalf("Diagnose() = %v, want 1 diagnostic", diags)
+	}
+	d := diags[0]
+	want := Range{
+		Start: Position{Line: 1, Character: 13, Offset: strings.Index(input, "fals")},
+		End:   Position{Line: 1, Character: 17, Offset: strings.Index(input, "fals") + 4},
+	}
+	if d.Range != want {
+		t.Errorf("Range = %+v, want %+v", d.Range, want)
+	}
+	if d.Severity != SeverityError || d.Code != "syntax" || d.Source != "shape-json" {
+		t.Errorf("Diagnostic = %+v", d)
+	}
+
+	diags = Diagnose([]byte(`{"😀é": 1, "😀é": 2}`))
+	if len(diags) != 1 || diags[0].Range.Start.Character != 11 || diags[0].Range.End.Character != 16 {
+		t.Errorf("duplicate key diagnostics = %+v, want characters 11-16", diags)
+	}
+}
+
+func TestDiagnoseSchema(t *testing.T) {
+	schema := jsonschema.MustCompile([]byte(`{
+		"type": "object",
+		"required": ["id"],
+		"properties": {"tags": {"items": {"type": "string"}}}
+	}`))
+	input := "{\n  \"tags\": [\"a\", 2]\n}"
+
+	diags := DiagnoseWithOptions([]byte(input), DiagnoseOptions{Schema: schema})
+	got := make(map[string]Range)
+	for _, d := range diags {
+		if d.Code != "schema" {
+			t.Errorf("unexpected diagnostic %v", d)
+		}
+		got[d.Message[:strings.Index(d.Message, ":")]] = d.Range
+	}
+	if r := got["required"]; r.Start.Offset != 0 || r.End.Offset != len(input) {
+		t.Errorf("required range = %+v, want the whole document", r)
+	}
+	if r := got["type"]; r.Start.Line != 1 || r.Start.Character != 16 || r.End.Character != 17 {
+		t.Errorf("type range = %+v, want line 1, characters 16-17", r)
+	}
+
+	// Syntax errors suppress schema validation
+	diags = DiagnoseWithOptions([]byte(`{"tags": [2,]}`), DiagnoseOptions{Schema: schema})
+	if len(diags) != 1 || diags[0].Code != "syntax" {
+		t.Errorf("DiagnoseWithOptions() = %v, want only the syntax error", diags)
+	}
+}
+
+func TestDiagnoseAgreesWithValidate(t *testing.T) {
+	inputs := []string{
+		`{}`, `[]`, `"s"`, `-1.5e3`, `{"a": {"b": [1, {"c": null}]}}`,
+		`{`, `[1,]`, `{"a" 1}`, `[1 2]`, `nul`, `{"a": 1}}`, `["\u12"]`, `{"a": 01}`,
+	}
+	for _, input := range inputs {
+		valid := Validate(input) == nil
+		if diags := Diagnose([]byte(input)); (len(diags) == 0) != valid {
+			t.Errorf("Diagnose(%q) = %v, Validate valid = %v", input, diags, valid)
+		}
+	}
+}
+
+func TestDiagnoseDepth(t *testing.T) {
+	deep := strings.Repeat("[", MaxDepth+5) + strings.Repeat("]", MaxDepth+5)
+	diags := Diagnose([]byte(`{"deep": ` + deep + `, "next": tru}`))
+	if len(diags) != 2 {
+		t.Fatalf("Diagnose() = %v, want a depth and a syntax diagnostic", diags)
+	}
+	// The object is the first level, so the bracket before the last
+	// MaxDepth-1 is the one too deep
+	if d := diags[0]; d.Code != "depth" || d.Range.Start.Offset != len(`{"deep": `)+MaxDepth-1 {
+		t.Errorf("first diagnostic = %+v, want code depth at the container beyond MaxDepth", d)
+	}
+	// Checking resumes after the container that was stepped over
+	if d := diags[1]; d.Code != "syntax" || !strings.Contains(d.Message, `"tru"`) {
+		t.Errorf("second diagnostic = %+v, want the invalid token after it", d)
+	}
+}
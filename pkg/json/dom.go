@@ -44,12 +44,14 @@ package json
 
 import (
 	"fmt"
+	"reflect"
 )
 
 // Document represents a JSON object with a fluent API for manipulation.
 // All setter methods return *Document to enable method chaining.
 type Document struct {
-	data map[string]interface{}
+	data  map[string]interface{}
+	rules *documentRules // validators attached with WithValidator and friends
 }
 
 // Array represents a JSON array with a fluent API for manipulation.
@@ -110,56 +112,47 @@ func ParseArray(input string) (*Array, error) {
 
 // Set sets a string value and returns the Document for chaining.
 func (d *Document) Set(key string, value interface{}) *Document {
-	d.data[key] = value
-	return d
+	return d.set(key, value)
 }
 
 // SetString sets a string value and returns the Document for chaining.
 func (d *Document) SetString(key, value string) *Document {
-	d.data[key] = value
-	return d
+	return d.set(key, value)
 }
 
 // SetInt sets an int value and returns the Document for chaining.
 func (d *Document) SetInt(key string, value int) *Document {
-	d.data[key] = value
-	return d
+	return d.set(key, value)
 }
 
 // SetInt64 sets an int64 value and returns the Document for chaining.
 func (d *Document) SetInt64(key string, value int64) *Document {
-	d.data[key] = value
-	return d
+	return d.set(key, value)
 }
 
 // SetBool sets a bool value and returns the Document for chaining.
 func (d *Document) SetBool(key string, value bool) *Document {
-	d.data[key] = value
-	return d
+	return d.set(key, value)
 }
 
 // SetFloat sets a float64 value and returns the Document for chaining.
 func (d *Document) SetFloat(key string, value float64) *Document {
-	d.data[key] = value
-	return d
+	return d.set(key, value)
 }
 
 // SetNull sets a null value and returns the Document for chaining.
 func (d *Document) SetNull(key string) *Document {
-	d.data[key] = nil
-	return d
+	return d.set(key, nil)
 }
 
 // SetObject sets a nested Document and returns the parent Document for chaining.
 func (d *Document) SetObject(key string, value *Document) *Document {
-	d.data[key] = value.data
-	return d
+	return d.set(key, value.data)
 }
 
 // SetArray sets an Array and returns the Document for chaining.
 func (d *Document) SetArray(key string, value *Array) *Document {
-	d.data[key] = value.data
-	return d
+	return d.set(key, value.data)
 }
 
 // ============================================================================
@@ -274,7 +267,12 @@ func (d *Document) Has(key string) bool {
 
 // Remove removes a key and returns the Document for chaining.
 func (d *Document) Remove(key string) *Document {
+	old, ok := d.data[key]
+	if !ok {
+		return d
+	}
 	delete(d.data, key)
+	d.notify("/"+escapePointerToken(key), old, nil)
 	return d
 }
 
@@ -297,6 +295,13 @@ func (d *Document) ToMap() map[string]interface{} {
 	return d.data
 }
 
+// Clone returns a deep copy of the Document. Nested objects and arrays,
+// including Documents, Arrays, and Go slices and maps stored with Set, are
+// copied recursively; other values are copied as-is.
+func (d *Document) Clone() *Document {
+	return &Document{data: deepCopyMap(d.data)}
+}
+
 // JSON marshals the Document to a JSON string.
 func (d *Document) JSON() (string, error) {
 	// Convert map to AST
@@ -366,7 +371,9 @@ func (d *Document) UnmarshalJSON(data []byte) error {
 	if !ok {
 		return fmt.Errorf("expected JSON object, got %T", value)
 	}
+	old := d.data
 	d.data = m
+	d.notify("", old, m)
 	return nil
 }
 
@@ -428,6 +435,46 @@ func (a *Array) AddArray(value *Array) *Array {
 	return a
 }
 
+// AddStrings appends strings and returns the Array for chaining.
+func (a *Array) AddStrings(values ...string) *Array {
+	for _, v := range values {
+		a.data = append(a.data, v)
+	}
+	return a
+}
+
+// AddInts appends ints and returns the Array for chaining.
+func (a *Array) AddInts(values ...int) *Array {
+	for _, v := range values {
+		a.data = append(a.data, v)
+	}
+	return a
+}
+
+// AddInt64s appends int64s and returns the Array for chaining.
+func (a *Array) AddInt64s(values ...int64) *Array {
+	for _, v := range values {
+		a.data = append(a.data, v)
+	}
+	return a
+}
+
+// AddBools appends bools and returns the Array for chaining.
+func (a *Array) AddBools(values ...bool) *Array {
+	for _, v := range values {
+		a.data = append(a.data, v)
+	}
+	return a
+}
+
+// AddFloats appends float64s and returns the Array for chaining.
+func (a *Array) AddFloats(values ...float64) *Array {
+	for _, v := range values {
+		a.data = append(a.data, v)
+	}
+	return a
+}
+
 // ============================================================================
 // Array Getter Methods (type-safe indexed access)
 // ============================================================================
@@ -553,6 +600,13 @@ func (a *Array) ToSlice() []interface{} {
 	return a.data
 }
 
+// Clone returns a deep copy of the Array. Nested objects and arrays,
+// including Documents, Arrays, and Go slices and maps added with Add, are
+// copied recursively; other values are copied as-is.
+func (a *Array) Clone() *Array {
+	return &Array{data: deepCopySlice(a.data)}
+}
+
 // JSON marshals the Array to a JSON string.
 func (a *Array) JSON() (string, error) {
 	// Convert slice to AST
@@ -627,3 +681,101 @@ func (a *Array) UnmarshalJSON(data []byte) error {
 	a.data = slice
 	return nil
 }
+
+// deepCopyValue recursively copies the objects and arrays in v, so that
+// the copy shares no memory with v that either could modify. Nested
+// Documents and Arrays, which Set stores as given, and Go slices, arrays,
+// and string-keyed maps of other types become the maps and slices the DOM
+// uses for them, and encode as they did. Other values are copied as-is.
+func deepCopyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case nil, string, bool, int, int64, float64:
+		return v
+	case map[string]interface{}:
+		return deepCopyMap(val)
+	case []interface{}:
+		return deepCopySlice(val)
+	case *Document:
+		if val == nil {
+			return nil
+		}
+		return deepCopyMap(val.data)
+	case *Array:
+		if val == nil {
+			return nil
+		}
+		return deepCopySlice(val.data)
+	}
+	return deepCopyReflect(reflect.ValueOf(v))
+}
+
+// deepCopyReflect copies the slice, array, or map rv as deepCopyValue
+// does. Byte slices, which encode as base64 strings, and slices of types
+// that encode by a method of their own, such as net.IP, are copied as
+// slices of the same type. Other types with such methods and maps with
+// keys other than strings, which have no JSON object form, are copied
+// as-is.
+func deepCopyReflect(rv reflect.Value) interface{} {
+	t := rv.Type()
+	special := encodesItself(rv)
+	switch t.Kind() {
+	case reflect.Slice:
+		if rv.IsNil() {
+			break
+		}
+		if special || t.Elem().Kind() == reflect.Uint8 {
+			return copySlice(rv)
+		}
+		return deepCopyElements(rv)
+	case reflect.Array:
+		if !special {
+			return deepCopyElements(rv)
+		}
+	case reflect.Map:
+		if special || t.Key().Kind() != reflect.String || rv.IsNil() {
+			break
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = deepCopyValue(iter.Value().Interface())
+		}
+		return out
+	}
+	return rv.Interface()
+}
+
+// copySlice returns a copy of the non-nil slice rv, of the same type.
+func copySlice(rv reflect.Value) interface{} {
+	out := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+	reflect.Copy(out, rv)
+	return out.Interface()
+}
+
+// deepCopyElements returns a recursive copy of the slice or array rv as a
+// []interface{}.
+func deepCopyElements(rv reflect.Value) []interface{} {
+	out := make([]interface{}, rv.Len())
+	for i := range out {
+		out[i] = deepCopyValue(rv.Index(i).Interface())
+	}
+	return out
+}
+
+// deepCopyMap returns a recursive copy of m.
+func deepCopyMap(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = deepCopyValue(v)
+	}
+	return out
+}
+
+// deepCopySlice returns a recursive copy of s.
+func deepCopySlice(s []interface{}) []interface{} {
+	out := make([]interface{}, len(s))
+	for i, v := range s {
+		out[i] = deepCopyValue(v)
+	}
+	return out
+}
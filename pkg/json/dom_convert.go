@@ -0,0 +1,195 @@
+package json
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// DocumentFromMap creates a Document from m, deep-converting its values the
+// way DocumentFromValue does. m itself is not modified or retained.
+func DocumentFromMap(m map[string]interface{}) (*Document, error) {
+	data, err := domMap(m)
+	if err != nil {
+		return nil, fmt.Errorf("json: DocumentFromMap: %w", err)
+	}
+	return &Document{data: data}, nil
+}
+
+// DocumentFromValue creates a Document from any Go value that encodes as a
+// JSON object: a map, a struct, or a pointer to one. It replaces the pattern
+// of calling Marshal and then ParseDocument:
+//
+//	doc, err := json.DocumentFromValue(user)
+//	doc.SetString("role", "admin")
+//
+// The conversion is deep, and the result holds the same types as a parsed
+// document: strings, bools, nil, int64 for whole numbers, float64 for other
+// numbers, and maps and slices for nested objects and arrays. Nothing in the
+// result is shared with v. Structs and other types follow the rules of
+// Marshal, including struct tags and MarshalJSON methods.
+func DocumentFromValue(v interface{}) (*Document, error) {
+	converted, err := domValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("json: DocumentFromValue: %w", err)
+	}
+	data, ok := converted.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("json: DocumentFromValue: %T does not encode as a JSON object", v)
+	}
+	return &Document{data: data}, nil
+}
+
+// ArrayFromSlice creates an Array holding the elements of s, so that an
+// existing Go slice needs no element-by-element loop:
+//
+//	tags, err := json.ArrayFromSlice([]string{"go", "json"})
+//	users, err := json.ArrayFromSlice(users) // []User becomes an array of objects
+//
+// Elements are deep-converted as by DocumentFromValue; *Document and *Array
+// elements are copied. It returns an error if an element cannot be
+// represented in JSON.
+func ArrayFromSlice[T any](s []T) (*Array, error) {
+	a := &Array{data: make([]interface{}, len(s))}
+	for i, v := range s {
+		converted, err := domValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("json: ArrayFromSlice: element %d: %w", i, err)
+		}
+		a.data[i] = converted
+	}
+	return a, nil
+}
+
+// domValue deep-converts v into the representation used by Document and
+// Array. Common types are converted directly; the rest go through Marshal.
+func domValue(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case nil, string, bool:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		return domUint(uint64(v)), nil
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		return domUint(v), nil
+	case float32:
+		return domFloat(float64(v))
+	case float64:
+		return domFloat(v)
+	case map[string]interface{}:
+		return domMap(v)
+	case []interface{}:
+		return domSlice(v)
+	case *Document:
+		return domMap(v.data)
+	case *Array:
+		return domSlice(v.data)
+	}
+
+	rv := reflect.ValueOf(v)
+	t := rv.Type()
+	if !hasMarshalJSON(rv) {
+		switch {
+		case (t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8) || t.Kind() == reflect.Array:
+			if t.Kind() == reflect.Slice && rv.IsNil() {
+				return nil, nil
+			}
+			out := make([]interface{}, rv.Len())
+			for i := range out {
+				elem, err := domValue(rv.Index(i).Interface())
+				if err != nil {
+					return nil, fmt.Errorf("index %d: %w", i, err)
+				}
+				out[i] = elem
+			}
+			return out, nil
+		case t.Kind() == reflect.Map && t.Key() == reflect.TypeOf(""):
+			if rv.IsNil() {
+				return nil, nil
+			}
+			out := make(map[string]interface{}, rv.Len())
+			iter := rv.MapRange()
+			for iter.Next() {
+				elem, err := domValue(iter.Value().Interface())
+				if err != nil {
+					return nil, fmt.Errorf("key %q: %w", iter.Key().String(), err)
+				}
+				out[iter.Key().String()] = elem
+			}
+			return out, nil
+		}
+	}
+
+	// Structs, named types, and custom marshalers
+	data, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := fastparser.NewParser(data).Parse()
+	if err != nil {
+		return nil, err
+	}
+	return domValue(parsed)
+}
+
+func domMap(m map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		converted, err := domValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k, err)
+		}
+		out[k] = converted
+	}
+	return out, nil
+}
+
+func domSlice(s []interface{}) ([]interface{}, error) {
+	out := make([]interface{}, len(s))
+	for i, v := range s {
+		converted, err := domValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		out[i] = converted
+	}
+	return out, nil
+}
+
+// domUint converts an unsigned integer, falling back to float64 above the
+// int64 range as a parsed document would.
+func domUint(u uint64) interface{} {
+	if u > math.MaxInt64 {
+		return float64(u)
+	}
+	return int64(u)
+}
+
+// domFloat stores whole numbers as int64, as parsing does, and rejects the
+// values JSON cannot represent.
+func domFloat(f float64) (interface{}, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("unsupported value: %v", f)
+	}
+	if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		return int64(f), nil
+	}
+	return f, nil
+}
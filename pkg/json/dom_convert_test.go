@@ -0,0 +1,166 @@
+package json
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocumentFromMap(t *testing.T) {
+	inner := map[string]interface{}{"n": 1}
+	m := map[string]interface{}{
+		"s":     "x",
+		"i":     42,
+		"u8":    uint8(7),
+		"whole": 3.0,
+		"frac":  float32(0.5),
+		"big":   uint64(math.MaxUint64),
+		"nil":   nil,
+		"obj":   inner,
+		"list":  []interface{}{1, "a", []int{2, 3}},
+		"typed": map[string]int{"k": 1},
+		"doc":   NewDocument().SetString("k", "v"),
+		"arr":   NewArray().AddInts(1),
+	}
+	doc, err := DocumentFromMap(m)
+	if err != nil {
+		t.Fatalf("DocumentFromMap() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"s":     "x",
+		"i":     int64(42),
+		"u8":    int64(7),
+		"whole": int64(3),
+		"frac":  0.5,
+		"big":   float64(math.MaxUint64),
+		"nil":   nil,
+		"obj":   map[string]interface{}{"n": int64(1)},
+		"list":  []interface{}{int64(1), "a", []interface{}{int64(2), int64(3)}},
+		"typed": map[string]interface{}{"k": int64(1)},
+		"doc":   map[string]interface{}{"k": "v"},
+		"arr":   []interface{}{int64(1)},
+	}
+	if !reflect.DeepEqual(doc.ToMap(), want) {
+		t.Errorf("DocumentFromMap() =\n%#v\nwant\n%#v", doc.ToMap(), want)
+	}
+
+	// The result does not share the input's maps
+	inner["n"] = 2
+	obj, _ := doc.GetObject("obj")
+	if n, _ := obj.GetInt("n"); n != 1 {
+		t.Errorf("nested value changed to %d with the input", n)
+	}
+
+	// Same types as a parsed document
+	text, _ := doc.JSON()
+	parsed, err := ParseDocument(text)
+	if err != nil {
+		t.Fatalf("ParseDocument() error = %v", err)
+	}
+	delete(want, "big") // 2^64 does not fit the int64 that parsing converts it to
+	got := parsed.ToMap()
+	delete(got, "big")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parsed =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+func TestDocumentFromValue(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	type Address struct {
+		City string `json:"city"`
+		Zip  string `json:"zip,omitempty"`
+	}
+	type User struct {
+		Name    string         `json:"name"`
+		Age     int            `json:"age"`
+		Score   float64        `json:"score"`
+		Tags    []string       `json:"tags"`
+		Address *Address       `json:"address"`
+		Created time.Time      `json:"created"`
+		Timeout time.Duration  `json:"timeout"`
+		Extra   map[string]any `json:"extra,omitempty"`
+		secret  string
+	}
+	u := User{
+		Name:    "Alice",
+		Age:     30,
+		Score:   9.5,
+		Tags:    []string{"a"},
+		Address: &Address{City: "Zürich"},
+		Created: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Timeout: time.Second,
+		secret:  "x",
+	}
+	doc, err := DocumentFromValue(&u)
+	if err != nil {
+		t.Fatalf("DocumentFromValue() error = %v", err)
+	}
+	marshaled, _ := Marshal(u)
+	want, _ := ParseDocument(string(marshaled))
+	if !reflect.DeepEqual(doc.ToMap(), want.ToMap()) {
+		t.Errorf("DocumentFromValue() =\n%#v\nwant the same as Marshal then ParseDocument\n%#v", doc.ToMap(), want.ToMap())
+	}
+	if age, ok := doc.GetInt("age"); !ok || age != 30 {
+		t.Errorf("GetInt(age) = %d, %v", age, ok)
+	}
+	if addr, ok := doc.GetObject("address"); !ok || addr.Has("zip") {
+		t.Errorf("GetObject(address) = %v, %v", addr, ok)
+	}
+
+	for _, v := range []interface{}{[]int{1}, "s", 1, nil} {
+		if _, err := DocumentFromValue(v); err == nil {
+			t.Errorf("DocumentFromValue(%#v) succeeded", v)
+		}
+	}
+}
+
+func TestArrayFromSlice(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	ids := []int64{7, 8, 9}
+	arr, err := ArrayFromSlice(ids)
+	if err != nil {
+		t.Fatalf("ArrayFromSlice() error = %v", err)
+	}
+	ids[0] = 0 // the Array holds copies
+	if v, ok := arr.GetInt64(0); !ok || v != 7 || arr.Len() != 3 {
+		t.Errorf("GetInt64(0) = %d, %v, Len() = %d", v, ok, arr.Len())
+	}
+
+	type point struct{ X, Y int }
+	pts, err := ArrayFromSlice([]point{{1, 2}})
+	if err != nil {
+		t.Fatalf("ArrayFromSlice() error = %v", err)
+	}
+	if obj, ok := pts.GetObject(0); !ok || obj.Size() != 2 {
+		t.Errorf("GetObject(0) = %v, %v", obj, ok)
+	}
+
+	nested, _ := ArrayFromSlice([]*Document{NewDocument().SetString("k", "v")})
+	if obj, ok := nested.GetObject(0); !ok || obj.Size() != 1 {
+		t.Errorf("GetObject(0) = %v, %v", obj, ok)
+	}
+	if a, _ := ArrayFromSlice([]*Array{NewArray().AddInts(1)}); a != nil {
+		if got, _ := a.JSON(); got != `[[1]]` {
+			t.Errorf("JSON() = %s, want [[1]]", got)
+		}
+	}
+	if a, _ := ArrayFromSlice([]string(nil)); a == nil || a.Len() != 0 {
+		t.Errorf("ArrayFromSlice(nil) = %v", a)
+	}
+
+	_, err = ArrayFromSlice([]interface{}{1, map[string]interface{}{"f": math.NaN()}})
+	if err == nil || !strings.Contains(err.Error(), `element 1: key "f": unsupported value: NaN`) {
+		t.Errorf("ArrayFromSlice() error = %v", err)
+	}
+	if _, err := ArrayFromSlice([]interface{}{make(chan int)}); err == nil {
+		t.Error("ArrayFromSlice() of a channel succeeded")
+	}
+}
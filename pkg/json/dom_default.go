@@ -0,0 +1,217 @@
+package json
+
+// ============================================================================
+// Getters with defaults
+// ============================================================================
+
+// GetStringOr returns the string at key, or def if it is missing or not a
+// string. It shortens config-reading code to one line per field:
+//
+//	host := cfg.GetStringOr("host", "localhost")
+//	port := cfg.GetIntOr("port", 8080)
+func (d *Document) GetStringOr(key, def string) string {
+	if v, ok := d.GetString(key); ok {
+		return v
+	}
+	return def
+}
+
+// GetIntOr returns the int at key, or def if it is missing or not a number.
+func (d *Document) GetIntOr(key string, def int) int {
+	if v, ok := d.GetInt(key); ok {
+		return v
+	}
+	return def
+}
+
+// GetInt64Or returns the int64 at key, or def if it is missing or not a
+// number.
+func (d *Document) GetInt64Or(key string, def int64) int64 {
+	if v, ok := d.GetInt64(key); ok {
+		return v
+	}
+	return def
+}
+
+// GetBoolOr returns the bool at key, or def if it is missing or not a bool.
+func (d *Document) GetBoolOr(key string, def bool) bool {
+	if v, ok := d.GetBool(key); ok {
+		return v
+	}
+	return def
+}
+
+// GetFloatOr returns the float64 at key, or def if it is missing or not a
+// number.
+func (d *Document) GetFloatOr(key string, def float64) float64 {
+	if v, ok := d.GetFloat(key); ok {
+		return v
+	}
+	return def
+}
+
+// GetStringOr returns the string at index, or def if index is out of bounds
+// or the element is not a string.
+func (a *Array) GetStringOr(index int, def string) string {
+	if v, ok := a.GetString(index); ok {
+		return v
+	}
+	return def
+}
+
+// GetIntOr returns the int at index, or def if index is out of bounds or
+// the element is not a number.
+func (a *Array) GetIntOr(index int, def int) int {
+	if v, ok := a.GetInt(index); ok {
+		return v
+	}
+	return def
+}
+
+// GetInt64Or returns the int64 at index, or def if index is out of bounds or
+// the element is not a number.
+func (a *Array) GetInt64Or(index int, def int64) int64 {
+	if v, ok := a.GetInt64(index); ok {
+		return v
+	}
+	return def
+}
+
+// GetBoolOr returns the bool at index, or def if index is out of bounds or
+// the element is not a bool.
+func (a *Array) GetBoolOr(index int, def bool) bool {
+	if v, ok := a.GetBool(index); ok {
+		return v
+	}
+	return def
+}
+
+// GetFloatOr returns the float64 at index, or def if index is out of bounds
+// or the element is not a number.
+func (a *Array) GetFloatOr(index int, def float64) float64 {
+	if v, ok := a.GetFloat(index); ok {
+		return v
+	}
+	return def
+}
+
+// ============================================================================
+// Path getters
+// ============================================================================
+
+// GetPath returns the value at path, which is a JSON Pointer ("/server/port"),
+// a JSONPath naming a single value ("$.server.ports[0]"), or the same
+// without the leading "$." ("server.ports[0]"). Values are returned as Get
+// returns them. It reports false if nothing is at path, or if path is
+// malformed or uses wildcards.
+func (d *Document) GetPath(path string) (interface{}, bool) {
+	return lookupPath(d.data, path)
+}
+
+// GetPathStringOr returns the string at path, or def if there is none.
+// Paths are as for GetPath:
+//
+//	level := cfg.GetPathStringOr("logging.level", "info")
+func (d *Document) GetPathStringOr(path, def string) string {
+	return pathView(d.data, path).GetStringOr(0, def)
+}
+
+// GetPathIntOr returns the int at path, or def if there is none.
+func (d *Document) GetPathIntOr(path string, def int) int {
+	return pathView(d.data, path).GetIntOr(0, def)
+}
+
+// GetPathInt64Or returns the int64 at path, or def if there is none.
+func (d *Document) GetPathInt64Or(path string, def int64) int64 {
+	return pathView(d.data, path).GetInt64Or(0, def)
+}
+
+// GetPathBoolOr returns the bool at path, or def if there is none.
+func (d *Document) GetPathBoolOr(path string, def bool) bool {
+	return pathView(d.data, path).GetBoolOr(0, def)
+}
+
+// GetPathFloatOr returns the float64 at path, or def if there is none.
+func (d *Document) GetPathFloatOr(path string, def float64) float64 {
+	return pathView(d.data, path).GetFloatOr(0, def)
+}
+
+// GetPath returns the value at path, as Document.GetPath does. A path into
+// an array starts with an index: "/0/name", "$[0].name", or "[0].name".
+func (a *Array) GetPath(path string) (interface{}, bool) {
+	return lookupPath(a.data, path)
+}
+
+// GetPathStringOr returns the string at path, or def if there is none.
+func (a *Array) GetPathStringOr(path, def string) string {
+	return pathView(a.data, path).GetStringOr(0, def)
+}
+
+// GetPathIntOr returns the int at path, or def if there is none.
+func (a *Array) GetPathIntOr(path string, def int) int {
+	return pathView(a.data, path).GetIntOr(0, def)
+}
+
+// GetPathInt64Or returns the int64 at path, or def if there is none.
+func (a *Array) GetPathInt64Or(path string, def int64) int64 {
+	return pathView(a.data, path).GetInt64Or(0, def)
+}
+
+// GetPathBoolOr returns the bool at path, or def if there is none.
+func (a *Array) GetPathBoolOr(path string, def bool) bool {
+	return pathView(a.data, path).GetBoolOr(0, def)
+}
+
+// GetPathFloatOr returns the float64 at path, or def if there is none.
+func (a *Array) GetPathFloatOr(path string, def float64) float64 {
+	return pathView(a.data, path).GetFloatOr(0, def)
+}
+
+// pathView returns an Array holding the value at path as its only element,
+// or no elements if there is none, so that the path getters convert values
+// exactly as the Array getters do.
+func pathView(root interface{}, path string) *Array {
+	if v, ok := lookupPath(root, path); ok {
+		return &Array{data: []interface{}{v}}
+	}
+	return &Array{}
+}
+
+// lookupPath finds the value at path under root.
+func lookupPath(root interface{}, path string) (interface{}, bool) {
+	if path != "" && path[0] != '/' && path[0] != '$' {
+		if path[0] == '[' {
+			path = "$" + path
+		} else {
+			path = "$." + path
+		}
+	}
+	patterns, err := compileRedactPath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	v := root
+	for _, p := range patterns {
+		switch c := v.(type) {
+		case map[string]interface{}:
+			if p.kind != patternName {
+				return nil, false
+			}
+			child, ok := c[p.name]
+			if !ok {
+				return nil, false
+			}
+			v = child
+		case []interface{}:
+			// A pointer token that is a number names an index too
+			if (p.kind != patternIndex && p.kind != patternName) || p.index < 0 || p.index >= len(c) {
+				return nil, false
+			}
+			v = c[p.index]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
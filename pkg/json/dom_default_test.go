@@ -0,0 +1,156 @@
+package json
+
+import "testing"
+
+func TestDocument_GetOr(t *testing.T) {
+	doc, err := ParseDocument(`{"host": "db", "port": 5432, "ratio": 0.25, "debug": true, "none": null}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := doc.GetStringOr("host", "localhost"); got != "db" {
+		t.Errorf("GetStringOr(host) = %q", got)
+	}
+	if got := doc.GetStringOr("missing", "localhost"); got != "localhost" {
+		t.Errorf("GetStringOr(missing) = %q", got)
+	}
+	if got := doc.GetStringOr("port", "x"); got != "x" {
+		t.Errorf("GetStringOr(port) = %q, want the default for a number", got)
+	}
+	if got := doc.GetIntOr("port", 1); got != 5432 {
+		t.Errorf("GetIntOr(port) = %d", got)
+	}
+	if got := doc.GetIntOr("none", 1); got != 1 {
+		t.Errorf("GetIntOr(none) = %d, want the default for null", got)
+	}
+	if got := doc.GetInt64Or("missing", 7); got != 7 {
+		t.Errorf("GetInt64Or(missing) = %d", got)
+	}
+	if got := doc.GetFloatOr("ratio", 1); got != 0.25 {
+		t.Errorf("GetFloatOr(ratio) = %v", got)
+	}
+	if got := doc.GetFloatOr("port", 1); got != 5432 {
+		t.Errorf("GetFloatOr(port) = %v", got)
+	}
+	if got := doc.GetBoolOr("debug", false); !got {
+		t.Errorf("GetBoolOr(debug) = %v", got)
+	}
+	if got := doc.GetBoolOr("host", true); !got {
+		t.Errorf("GetBoolOr(host) = %v, want the default for a string", got)
+	}
+}
+
+func TestArray_GetOr(t *testing.T) {
+	arr := NewArray().AddString("a").AddInt(2).AddBool(true).AddFloat(1.5)
+
+	if got := arr.GetStringOr(0, "x"); got != "a" {
+		t.Errorf("GetStringOr(0) = %q", got)
+	}
+	if got := arr.GetStringOr(9, "x"); got != "x" {
+		t.Errorf("GetStringOr(9) = %q", got)
+	}
+	if got := arr.GetIntOr(-1, 5); got != 5 {
+		t.Errorf("GetIntOr(-1) = %d", got)
+	}
+	if got := arr.GetInt64Or(1, 5); got != 2 {
+		t.Errorf("GetInt64Or(1) = %d", got)
+	}
+	if got := arr.GetBoolOr(2, false); !got {
+		t.Errorf("GetBoolOr(2) = %v", got)
+	}
+	if got := arr.GetFloatOr(3, 0); got != 1.5 {
+		t.Errorf("GetFloatOr(3) = %v", got)
+	}
+}
+
+func TestDocument_GetPath(t *testing.T) {
+	doc, err := ParseDocument(`{
+		"server": {"host": "example.com", "ports": [80, 443], "tls": {"enabled": true}},
+		"a/b": {"c.d": 1.5},
+		"list": [{"name": "first"}]
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"server.host", "example.com"},
+		{"$.server.host", "example.com"},
+		{"/server/host", "example.com"},
+		{"server.ports[1]", int64(443)},
+		{"/server/ports/0", int64(80)},
+		{"server.tls.enabled", true},
+		{"/a~1b/c.d", 1.5},
+		{"$['a/b']['c.d']", 1.5},
+		{"list[0].name", "first"},
+		{"", doc.ToMap()},
+	}
+	for _, tt := range tests {
+		got, ok := doc.GetPath(tt.path)
+		if !ok {
+			t.Errorf("GetPath(%q) not found", tt.path)
+			continue
+		}
+		if tt.path != "" && got != tt.want {
+			t.Errorf("GetPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	for _, path := range []string{"server.missing", "server.ports[2]", "server.host.x", "$..host", "server.ports[*]", "/server/ports/-", "server[0]", "$.server.ports.0"} {
+		if got, ok := doc.GetPath(path); ok {
+			t.Errorf("GetPath(%q) = %v, want not found", path, got)
+		}
+	}
+
+	if got := doc.GetPathStringOr("server.host", "localhost"); got != "example.com" {
+		t.Errorf("GetPathStringOr() = %q", got)
+	}
+	if got := doc.GetPathStringOr("server.name", "localhost"); got != "localhost" {
+		t.Errorf("GetPathStringOr(missing) = %q", got)
+	}
+	if got := doc.GetPathIntOr("server.ports[0]", 0); got != 80 {
+		t.Errorf("GetPathIntOr() = %d", got)
+	}
+	if got := doc.GetPathInt64Or("server.host", 9); got != 9 {
+		t.Errorf("GetPathInt64Or(string) = %d, want the default", got)
+	}
+	if got := doc.GetPathBoolOr("server.tls.enabled", false); !got {
+		t.Errorf("GetPathBoolOr() = %v", got)
+	}
+	if got := doc.GetPathFloatOr("/a~1b/c.d", 0); got != 1.5 {
+		t.Errorf("GetPathFloatOr() = %v", got)
+	}
+}
+
+func TestArray_GetPath(t *testing.T) {
+	arr, err := ParseArray(`[{"name": "a", "tags": ["x"]}, 2]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/0/name", "$[0].name", "[0].name"} {
+		if got := arr.GetPathStringOr(path, ""); got != "a" {
+			t.Errorf("GetPathStringOr(%q) = %q", path, got)
+		}
+	}
+	if got := arr.GetPathStringOr("[0].tags[0]", ""); got != "x" {
+		t.Errorf("GetPathStringOr() = %q", got)
+	}
+	if got := arr.GetPathIntOr("[1]", 0); got != 2 {
+		t.Errorf("GetPathIntOr() = %d", got)
+	}
+	if got := arr.GetPathInt64Or("[2]", 3); got != 3 {
+		t.Errorf("GetPathInt64Or(out of bounds) = %d", got)
+	}
+	if got := arr.GetPathBoolOr("[0].name", true); !got {
+		t.Errorf("GetPathBoolOr(string) = %v, want the default", got)
+	}
+	if got := arr.GetPathFloatOr("[1]", 0); got != 2 {
+		t.Errorf("GetPathFloatOr() = %v", got)
+	}
+	if _, ok := arr.GetPath("name"); ok {
+		t.Error("GetPath(name) on an array found a value")
+	}
+}
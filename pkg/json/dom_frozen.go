@@ -0,0 +1,347 @@
+package json
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ErrFrozen is returned by the mutation methods of FrozenDocument and
+// FrozenArray.
+var ErrFrozen = errors.New("json: cannot modify frozen document")
+
+// FrozenDocument is an immutable view of a JSON object.
+//
+// It is created by Document.Freeze, which takes a deep copy, so nothing can
+// modify it afterwards: mutation methods return ErrFrozen and nested objects
+// and arrays are handed out as frozen views too. A FrozenDocument can
+// therefore be shared between goroutines without locking, which makes it a
+// good fit for parsed configuration.
+//
+// FrozenDocument is a small value type. Its typed getters (GetString, GetInt,
+// GetObject, ...) do not allocate.
+//
+// The zero value is an empty frozen document.
+type FrozenDocument struct {
+	data map[string]interface{}
+}
+
+// FrozenArray is an immutable view of a JSON array. See FrozenDocument.
+type FrozenArray struct {
+	data []interface{}
+}
+
+// Freeze returns an immutable deep copy of the Document.
+// Later changes to d do not affect the returned FrozenDocument, including
+// changes to Documents, Arrays, and Go slices and maps stored in it with
+// Set: those are copied as the nested objects and arrays they encode as.
+// Pointers and structs are the exception, and are shared as they are.
+func (d *Document) Freeze() FrozenDocument {
+	return FrozenDocument{data: deepCopyMap(d.data)}
+}
+
+// Freeze returns an immutable deep copy of the Array.
+// Later changes to a do not affect the returned FrozenArray. See
+// Document.Freeze.
+func (a *Array) Freeze() FrozenArray {
+	return FrozenArray{data: deepCopySlice(a.data)}
+}
+
+// Thaw returns a mutable deep copy of the frozen document.
+func (f FrozenDocument) Thaw() *Document {
+	return &Document{data: deepCopyMap(f.data)}
+}
+
+// Thaw returns a mutable deep copy of the frozen array.
+func (f FrozenArray) Thaw() *Array {
+	return &Array{data: deepCopySlice(f.data)}
+}
+
+// ============================================================================
+// FrozenDocument getters
+// ============================================================================
+
+// view returns a Document sharing f's data for use by read-only methods.
+// The returned value must never be mutated or escape.
+func (f FrozenDocument) view() Document {
+	return Document{data: f.data}
+}
+
+// Get gets a value. Nested objects and arrays are returned as FrozenDocument
+// and FrozenArray so the underlying data cannot be modified.
+func (f FrozenDocument) Get(key string) (interface{}, bool) {
+	val, ok := f.data[key]
+	return freezeValue(val), ok
+}
+
+// GetString gets a string value. Returns empty string and false if not found or wrong type.
+func (f FrozenDocument) GetString(key string) (string, bool) {
+	d := f.view()
+	return d.GetString(key)
+}
+
+// GetInt gets an int value. Returns 0 and false if not found or wrong type.
+func (f FrozenDocument) GetInt(key string) (int, bool) {
+	d := f.view()
+	return d.GetInt(key)
+}
+
+// GetInt64 gets an int64 value. Returns 0 and false if not found or wrong type.
+func (f FrozenDocument) GetInt64(key string) (int64, bool) {
+	d := f.view()
+	return d.GetInt64(key)
+}
+
+// GetBool gets a bool value. Returns false and false if not found or wrong type.
+func (f FrozenDocument) GetBool(key string) (bool, bool) {
+	d := f.view()
+	return d.GetBool(key)
+}
+
+// GetFloat gets a float64 value. Returns 0.0 and false if not found or wrong type.
+func (f FrozenDocument) GetFloat(key string) (float64, bool) {
+	d := f.view()
+	return d.GetFloat(key)
+}
+
+// GetObject gets a nested frozen object. Returns false if not found or wrong type.
+func (f FrozenDocument) GetObject(key string) (FrozenDocument, bool) {
+	if m, ok := f.data[key].(map[string]interface{}); ok {
+		return FrozenDocument{data: m}, true
+	}
+	return FrozenDocument{}, false
+}
+
+// GetArray gets a nested frozen array. Returns false if not found or wrong type.
+func (f FrozenDocument) GetArray(key string) (FrozenArray, bool) {
+	if arr, ok := f.data[key].([]interface{}); ok {
+		return FrozenArray{data: arr}, true
+	}
+	return FrozenArray{}, false
+}
+
+// IsNull checks if a key exists and has a null value.
+func (f FrozenDocument) IsNull(key string) bool {
+	val, ok := f.data[key]
+	return ok && val == nil
+}
+
+// Has checks if a key exists (including null values).
+func (f FrozenDocument) Has(key string) bool {
+	_, ok := f.data[key]
+	return ok
+}
+
+// Keys returns all keys in the document.
+func (f FrozenDocument) Keys() []string {
+	d := f.view()
+	return d.Keys()
+}
+
+// Size returns the number of properties in the document.
+func (f FrozenDocument) Size() int {
+	return len(f.data)
+}
+
+// Range calls fn for each property until fn returns false. Nested objects
+// and arrays are passed as FrozenDocument and FrozenArray. Iteration order
+// is unspecified.
+func (f FrozenDocument) Range(fn func(key string, value interface{}) bool) {
+	for k, v := range f.data {
+		if !fn(k, freezeValue(v)) {
+			return
+		}
+	}
+}
+
+// JSON marshals the document to a JSON string.
+func (f FrozenDocument) JSON() (string, error) {
+	d := f.view()
+	return d.JSON()
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (f FrozenDocument) MarshalJSON() ([]byte, error) {
+	d := f.view()
+	return d.MarshalJSON()
+}
+
+// ============================================================================
+// FrozenDocument mutation methods (always fail)
+// ============================================================================
+
+// Set always returns ErrFrozen.
+func (f FrozenDocument) Set(string, interface{}) error { return ErrFrozen }
+
+// SetString always returns ErrFrozen.
+func (f FrozenDocument) SetString(string, string) error { return ErrFrozen }
+
+// SetInt always returns ErrFrozen.
+func (f FrozenDocument) SetInt(string, int) error { return ErrFrozen }
+
+// SetInt64 always returns ErrFrozen.
+func (f FrozenDocument) SetInt64(string, int64) error { return ErrFrozen }
+
+// SetBool always returns ErrFrozen.
+func (f FrozenDocument) SetBool(string, bool) error { return ErrFrozen }
+
+// SetFloat always returns ErrFrozen.
+func (f FrozenDocument) SetFloat(string, float64) error { return ErrFrozen }
+
+// SetNull always returns ErrFrozen.
+func (f FrozenDocument) SetNull(string) error { return ErrFrozen }
+
+// SetObject always returns ErrFrozen.
+func (f FrozenDocument) SetObject(string, *Document) error { return ErrFrozen }
+
+// SetArray always returns ErrFrozen.
+func (f FrozenDocument) SetArray(string, *Array) error { return ErrFrozen }
+
+// Remove always returns ErrFrozen.
+func (f FrozenDocument) Remove(string) error { return ErrFrozen }
+
+// UnmarshalJSON always returns ErrFrozen.
+func (f FrozenDocument) UnmarshalJSON([]byte) error { return ErrFrozen }
+
+// ============================================================================
+// FrozenArray getters
+// ============================================================================
+
+// view returns an Array sharing f's data for use by read-only methods.
+// The returned value must never be mutated or escape.
+func (f FrozenArray) view() Array {
+	return Array{data: f.data}
+}
+
+// Get gets a value at index. Nested objects and arrays are returned as
+// FrozenDocument and FrozenArray.
+func (f FrozenArray) Get(index int) (interface{}, bool) {
+	if index < 0 || index >= len(f.data) {
+		return nil, false
+	}
+	return freezeValue(f.data[index]), true
+}
+
+// GetString gets a string at index. Returns empty string and false if not found or wrong type.
+func (f FrozenArray) GetString(index int) (string, bool) {
+	a := f.view()
+	return a.GetString(index)
+}
+
+// GetInt gets an int at index. Returns 0 and false if not found or wrong type.
+func (f FrozenArray) GetInt(index int) (int, bool) {
+	a := f.view()
+	return a.GetInt(index)
+}
+
+// GetInt64 gets an int64 at index. Returns 0 and false if not found or wrong type.
+func (f FrozenArray) GetInt64(index int) (int64, bool) {
+	a := f.view()
+	return a.GetInt64(index)
+}
+
+// GetBool gets a bool at index. Returns false and false if not found or wrong type.
+func (f FrozenArray) GetBool(index int) (bool, bool) {
+	a := f.view()
+	return a.GetBool(index)
+}
+
+// GetFloat gets a float64 at index. Returns 0.0 and false if not found or wrong type.
+func (f FrozenArray) GetFloat(index int) (float64, bool) {
+	a := f.view()
+	return a.GetFloat(index)
+}
+
+// GetObject gets a frozen object at index. Returns false if not found or wrong type.
+func (f FrozenArray) GetObject(index int) (FrozenDocument, bool) {
+	if index < 0 || index >= len(f.data) {
+		return FrozenDocument{}, false
+	}
+	if m, ok := f.data[index].(map[string]interface{}); ok {
+		return FrozenDocument{data: m}, true
+	}
+	return FrozenDocument{}, false
+}
+
+// GetArray gets a frozen array at index. Returns false if not found or wrong type.
+func (f FrozenArray) GetArray(index int) (FrozenArray, bool) {
+	if index < 0 || index >= len(f.data) {
+		return FrozenArray{}, false
+	}
+	if arr, ok := f.data[index].([]interface{}); ok {
+		return FrozenArray{data: arr}, true
+	}
+	return FrozenArray{}, false
+}
+
+// IsNull checks if the value at index is null.
+func (f FrozenArray) IsNull(index int) bool {
+	return index >= 0 && index < len(f.data) && f.data[index] == nil
+}
+
+// Len returns the length of the array.
+func (f FrozenArray) Len() int {
+	return len(f.data)
+}
+
+// JSON marshals the array to a JSON string.
+func (f FrozenArray) JSON() (string, error) {
+	a := f.view()
+	return a.JSON()
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (f FrozenArray) MarshalJSON() ([]byte, error) {
+	a := f.view()
+	return a.MarshalJSON()
+}
+
+// ============================================================================
+// FrozenArray mutation methods (always fail)
+// ============================================================================
+
+// Add always returns ErrFrozen.
+func (f FrozenArray) Add(interface{}) error { return ErrFrozen }
+
+// AddString always returns ErrFrozen.
+func (f FrozenArray) AddString(string) error { return ErrFrozen }
+
+// AddInt always returns ErrFrozen.
+func (f FrozenArray) AddInt(int) error { return ErrFrozen }
+
+// AddInt64 always returns ErrFrozen.
+func (f FrozenArray) AddInt64(int64) error { return ErrFrozen }
+
+// AddBool always returns ErrFrozen.
+func (f FrozenArray) AddBool(bool) error { return ErrFrozen }
+
+// AddFloat always returns ErrFrozen.
+func (f FrozenArray) AddFloat(float64) error { return ErrFrozen }
+
+// AddNull always returns ErrFrozen.
+func (f FrozenArray) AddNull() error { return ErrFrozen }
+
+// AddObject always returns ErrFrozen.
+func (f FrozenArray) AddObject(*Document) error { return ErrFrozen }
+
+// AddArray always returns ErrFrozen.
+func (f FrozenArray) AddArray(*Array) error { return ErrFrozen }
+
+// UnmarshalJSON always returns ErrFrozen.
+func (f FrozenArray) UnmarshalJSON([]byte) error { return ErrFrozen }
+
+// freezeValue wraps nested containers so they cannot be modified through
+// Get. The only other slices Freeze keeps, such as []byte, are copied.
+func freezeValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return FrozenDocument{data: val}
+	case []interface{}:
+		return FrozenArray{data: val}
+	case nil, string, bool, int, int64, float64:
+		return v
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && !rv.IsNil() {
+		return copySlice(rv)
+	}
+	return v
+}
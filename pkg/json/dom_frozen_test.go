@@ -0,0 +1,253 @@
+package json
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestDocument_Freeze(t *testing.T) {
+	doc, err := ParseDocument(`{"name": "svc", "port": 8080, "ratio": 0.5, "debug": false, "none": null,
+		"db": {"host": "localhost"}, "tags": ["a", {"k": "v"}, [1]]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frozen := doc.Freeze()
+	doc.SetString("name", "changed").Remove("db")
+
+	if v, ok := frozen.GetString("name"); !ok || v != "svc" {
+		t.Errorf("GetString = %q, %v (freeze must copy)", v, ok)
+	}
+	if v, ok := frozen.GetInt("port"); !ok || v != 8080 {
+		t.Errorf("GetInt = %d, %v", v, ok)
+	}
+	if v, ok := frozen.GetInt64("port"); !ok || v != 8080 {
+		t.Errorf("GetInt64 = %d, %v", v, ok)
+	}
+	if v, ok := frozen.GetFloat("ratio"); !ok || v != 0.5 {
+		t.Errorf("GetFloat = %v, %v", v, ok)
+	}
+	if v, ok := frozen.GetBool("debug"); !ok || v {
+		t.Errorf("GetBool = %v, %v", v, ok)
+	}
+	if !frozen.IsNull("none") || !frozen.Has("db") || frozen.Size() != 7 || len(frozen.Keys()) != 7 {
+		t.Error("unexpected IsNull/Has/Size/Keys results")
+	}
+
+	db, ok := frozen.GetObject("db")
+	if !ok {
+		t.Fatal("GetObject(db) failed")
+	}
+	if host, _ := db.GetString("host"); host != "localhost" {
+		t.Errorf("db.host = %q", host)
+	}
+
+	tags, ok := frozen.GetArray("tags")
+	if !ok || tags.Len() != 3 {
+		t.Fatalf("GetArray(tags) = %v, %v", tags, ok)
+	}
+	if s, _ := tags.GetString(0); s != "a" {
+		t.Errorf("tags[0] = %q", s)
+	}
+	if obj, ok := tags.GetObject(1); !ok || !obj.Has("k") {
+		t.Error("tags[1] should be an object with k")
+	}
+	if inner, ok := tags.GetArray(2); !ok || inner.Len() != 1 {
+		t.Error("tags[2] should be a 1-element array")
+	}
+	if _, ok := tags.GetObject(9); ok {
+		t.Error("out of range GetObject should fail")
+	}
+
+	// Get wraps nested containers so they cannot be mutated.
+	if v, _ := frozen.Get("db"); v != nil {
+		if _, isMap := v.(map[string]interface{}); isMap {
+			t.Error("Get exposed mutable map")
+		}
+		if _, isFrozen := v.(FrozenDocument); !isFrozen {
+			t.Errorf("Get(db) type = %T, want FrozenDocument", v)
+		}
+	}
+	if v, _ := tags.Get(2); v != nil {
+		if _, isFrozen := v.(FrozenArray); !isFrozen {
+			t.Errorf("Get(2) type = %T, want FrozenArray", v)
+		}
+	}
+
+	out, err := frozen.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != `{"db":{"host":"localhost"},"debug":false,"name":"svc","none":null,"port":8080,"ratio":0.5,"tags":["a",{"k":"v"},[1]]}` {
+		t.Errorf("JSON() = %s", out)
+	}
+	if data, err := Marshal(map[string]interface{}{"cfg": frozen}); err != nil || string(data) != `{"cfg":`+out+`}` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+}
+
+// TestDocument_FreezeNested checks that Freeze copies the Documents,
+// Arrays, and typed containers stored with Set rather than sharing them.
+func TestDocument_FreezeNested(t *testing.T) {
+	inner := NewDocument().SetString("k", "v")
+	list := NewArray().AddObject(NewDocument().SetInt("n", 1))
+	tags := []string{"a", "b"}
+	counts := map[string][]int{"x": {1}}
+	raw := []byte("hi")
+	frozen := NewDocument().
+		Set("inner", inner).
+		Set("list", list).
+		Set("tags", tags).
+		Set("counts", counts).
+		Set("raw", raw).
+		Freeze()
+
+	inner.SetString("k", "MUTATED")
+	first, _ := list.GetObject(0)
+	first.SetInt("n", 2)
+	list.AddString("late")
+	tags[0] = "MUTATED"
+	counts["x"][0] = 2
+	raw[0] = 'X'
+
+	// JSON does not encode byte slices, so raw is checked on its own
+	check := func() {
+		t.Helper()
+		frozenRaw, _ := frozen.Get("raw")
+		if string(frozenRaw.([]byte)) != "hi" {
+			t.Errorf("Get(raw) = %q, want hi", frozenRaw)
+		}
+		rest := frozen.Thaw().Remove("raw")
+		want := `{"counts":{"x":[1]},"inner":{"k":"v"},"list":[{"n":1}],"tags":["a","b"]}`
+		if got, err := rest.JSON(); err != nil || got != want {
+			t.Errorf("JSON() = %s, %v, want %s", got, err, want)
+		}
+	}
+	check()
+
+	// Nothing handed out by Get can change the frozen data either
+	v, _ := frozen.Get("inner")
+	if _, ok := v.(FrozenDocument); !ok {
+		t.Errorf("Get(inner) = %T, want FrozenDocument", v)
+	}
+	v, _ = frozen.Get("tags")
+	if _, ok := v.(FrozenArray); !ok {
+		t.Errorf("Get(tags) = %T, want FrozenArray", v)
+	}
+	v, _ = frozen.Get("raw")
+	v.([]byte)[0] = 'X'
+	check()
+}
+
+func TestFrozen_MutationsFail(t *testing.T) {
+	f := NewDocument().SetString("a", "b").Freeze()
+	errs := []error{
+		f.Set("x", 1), f.SetString("x", ""), f.SetInt("x", 1), f.SetInt64("x", 1),
+		f.SetBool("x", true), f.SetFloat("x", 1), f.SetNull("x"),
+		f.SetObject("x", NewDocument()), f.SetArray("x", NewArray()), f.Remove("a"),
+		f.UnmarshalJSON([]byte(`{}`)),
+	}
+	a := NewArray().AddInt(1).Freeze()
+	errs = append(errs,
+		a.Add(1), a.AddString(""), a.AddInt(1), a.AddInt64(1), a.AddBool(true),
+		a.AddFloat(1), a.AddNull(), a.AddObject(NewDocument()), a.AddArray(NewArray()),
+		a.UnmarshalJSON([]byte(`[]`)),
+	)
+	for i, err := range errs {
+		if !errors.Is(err, ErrFrozen) {
+			t.Errorf("mutation %d returned %v, want ErrFrozen", i, err)
+		}
+	}
+	if !f.Has("a") || f.Size() != 1 || a.Len() != 1 {
+		t.Error("frozen values changed")
+	}
+}
+
+func TestFrozen_Thaw(t *testing.T) {
+	f := NewDocument().SetObject("o", NewDocument().SetInt("n", 1)).Freeze()
+
+	thawed := f.Thaw()
+	o, _ := thawed.GetObject("o")
+	o.SetInt("n", 2)
+
+	fo, _ := f.GetObject("o")
+	if n, _ := fo.GetInt("n"); n != 1 {
+		t.Errorf("Thaw shares data with frozen document: n = %d", n)
+	}
+
+	arr := NewArray().AddString("x").Freeze().Thaw()
+	arr.AddString("y")
+	if arr.Len() != 2 {
+		t.Errorf("thawed array len = %d, want 2", arr.Len())
+	}
+}
+
+func TestFrozen_Range(t *testing.T) {
+	f := NewDocument().SetInt("a", 1).SetInt("b", 2).SetArray("c", NewArray()).Freeze()
+
+	seen := 0
+	f.Range(func(key string, value interface{}) bool {
+		seen++
+		if key == "c" {
+			if _, ok := value.(FrozenArray); !ok {
+				t.Errorf("Range value for c = %T, want FrozenArray", value)
+			}
+		}
+		return true
+	})
+	if seen != 3 {
+		t.Errorf("seen = %d, want 3", seen)
+	}
+
+	stopped := 0
+	f.Range(func(string, interface{}) bool {
+		stopped++
+		return false
+	})
+	if stopped != 1 {
+		t.Errorf("Range did not stop early: %d", stopped)
+	}
+}
+
+func TestFrozen_GettersDoNotAllocate(t *testing.T) {
+	doc, _ := ParseDocument(`{"s": "x", "n": 5, "f": 1.5, "b": true, "o": {"k": "v"}, "a": [1, "two"]}`)
+	f := doc.Freeze()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		f.GetString("s")
+		f.GetInt("n")
+		f.GetInt64("n")
+		f.GetFloat("f")
+		f.GetBool("b")
+		o, _ := f.GetObject("o")
+		o.GetString("k")
+		a, _ := f.GetArray("a")
+		a.GetInt(0)
+		a.GetString(1)
+		f.Has("s")
+		f.IsNull("s")
+	})
+	if allocs != 0 {
+		t.Errorf("allocs = %v, want 0", allocs)
+	}
+}
+
+func TestFrozen_ConcurrentReads(t *testing.T) {
+	f := NewDocument().SetInt("n", 1).SetObject("o", NewDocument().SetString("k", "v")).Freeze()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				f.GetInt("n")
+				o, _ := f.GetObject("o")
+				o.GetString("k")
+				_, _ = f.JSON()
+			}
+		}()
+	}
+	wg.Wait()
+}
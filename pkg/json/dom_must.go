@@ -0,0 +1,169 @@
+package json
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ============================================================================
+// Must getters
+// ============================================================================
+//
+// The Must* getters are for test code and payloads whose shape is
+// guaranteed, where a missing or mistyped value is a bug. Instead of
+// returning false they panic with a message naming the key or index and what
+// was found there:
+//
+//	json: MustGetString("port"): found number (int64), want string
+
+// MustGetString returns the string at key, panicking if it is missing or not
+// a string.
+func (d *Document) MustGetString(key string) string {
+	v, ok := d.GetString(key)
+	mustFound(ok, "MustGetString", d.data, key, TypeString)
+	return v
+}
+
+// MustGetInt returns the int at key, panicking if it is missing or not a
+// number.
+func (d *Document) MustGetInt(key string) int {
+	v, ok := d.GetInt(key)
+	mustFound(ok, "MustGetInt", d.data, key, TypeNumber)
+	return v
+}
+
+// MustGetInt64 returns the int64 at key, panicking if it is missing or not a
+// number.
+func (d *Document) MustGetInt64(key string) int64 {
+	v, ok := d.GetInt64(key)
+	mustFound(ok, "MustGetInt64", d.data, key, TypeNumber)
+	return v
+}
+
+// MustGetBool returns the bool at key, panicking if it is missing or not a
+// bool.
+func (d *Document) MustGetBool(key string) bool {
+	v, ok := d.GetBool(key)
+	mustFound(ok, "MustGetBool", d.data, key, TypeBoolean)
+	return v
+}
+
+// MustGetFloat returns the float64 at key, panicking if it is missing or not
+// a number.
+func (d *Document) MustGetFloat(key string) float64 {
+	v, ok := d.GetFloat(key)
+	mustFound(ok, "MustGetFloat", d.data, key, TypeNumber)
+	return v
+}
+
+// MustGetObject returns the object at key, panicking if it is missing or not
+// an object.
+func (d *Document) MustGetObject(key string) *Document {
+	v, ok := d.GetObject(key)
+	mustFound(ok, "MustGetObject", d.data, key, TypeObject)
+	return v
+}
+
+// MustGetArray returns the array at key, panicking if it is missing or not
+// an array.
+func (d *Document) MustGetArray(key string) *Array {
+	v, ok := d.GetArray(key)
+	mustFound(ok, "MustGetArray", d.data, key, TypeArray)
+	return v
+}
+
+// MustGetString returns the string at index, panicking if index is out of
+// bounds or the element is not a string.
+func (a *Array) MustGetString(index int) string {
+	v, ok := a.GetString(index)
+	mustFound(ok, "MustGetString", a.data, index, TypeString)
+	return v
+}
+
+// MustGetInt returns the int at index, panicking if index is out of bounds
+// or the element is not a number.
+func (a *Array) MustGetInt(index int) int {
+	v, ok := a.GetInt(index)
+	mustFound(ok, "MustGetInt", a.data, index, TypeNumber)
+	return v
+}
+
+// MustGetInt64 returns the int64 at index, panicking if index is out of
+// bounds or the element is not a number.
+func (a *Array) MustGetInt64(index int) int64 {
+	v, ok := a.GetInt64(index)
+	mustFound(ok, "MustGetInt64", a.data, index, TypeNumber)
+	return v
+}
+
+// MustGetBool returns the bool at index, panicking if index is out of bounds
+// or the element is not a bool.
+func (a *Array) MustGetBool(index int) bool {
+	v, ok := a.GetBool(index)
+	mustFound(ok, "MustGetBool", a.data, index, TypeBoolean)
+	return v
+}
+
+// MustGetFloat returns the float64 at index, panicking if index is out of
+// bounds or the element is not a number.
+func (a *Array) MustGetFloat(index int) float64 {
+	v, ok := a.GetFloat(index)
+	mustFound(ok, "MustGetFloat", a.data, index, TypeNumber)
+	return v
+}
+
+// MustGetObject returns the object at index, panicking if index is out of
+// bounds or the element is not an object.
+func (a *Array) MustGetObject(index int) *Document {
+	v, ok := a.GetObject(index)
+	mustFound(ok, "MustGetObject", a.data, index, TypeObject)
+	return v
+}
+
+// MustGetArray returns the array at index, panicking if index is out of
+// bounds or the element is not an array.
+func (a *Array) MustGetArray(index int) *Array {
+	v, ok := a.GetArray(index)
+	mustFound(ok, "MustGetArray", a.data, index, TypeArray)
+	return v
+}
+
+// mustFound panics, unless ok, with a message describing what is at key (a
+// string) or index (an int) of container instead of a value of type want.
+func mustFound(ok bool, method string, container interface{}, at interface{}, want string) {
+	if ok {
+		return
+	}
+
+	var (
+		where  string
+		val    interface{}
+		exists bool
+	)
+	switch at := at.(type) {
+	case string:
+		where = strconv.Quote(at)
+		val, exists = container.(map[string]interface{})[at]
+	case int:
+		where = strconv.Itoa(at)
+		s := container.([]interface{})
+		if at >= 0 && at < len(s) {
+			val, exists = s[at], true
+		}
+	}
+
+	switch {
+	case !exists:
+		if s, isArray := container.([]interface{}); isArray {
+			panic(fmt.Sprintf("json: %s(%s): index out of range with length %d", method, where, len(s)))
+		}
+		panic(fmt.Sprintf("json: %s(%s): key not found", method, where))
+	case val == nil:
+		panic(fmt.Sprintf("json: %s(%s): found null, want %s", method, where, want))
+	}
+	found := fmt.Sprintf("%T", val)
+	if v, err := ValueOf(val); err == nil {
+		found = fmt.Sprintf("%s (%T)", v.Kind(), val)
+	}
+	panic(fmt.Sprintf("json: %s(%s): found %s, want %s", method, where, found, want))
+}
@@ -0,0 +1,72 @@
+package json
+
+import "testing"
+
+// panicMessage returns the message fn panics with, or "" if it does not.
+func panicMessage(fn func()) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg, _ = r.(string)
+		}
+	}()
+	fn()
+	return ""
+}
+
+func TestDocument_MustGet(t *testing.T) {
+	doc, err := ParseDocument(`{"name": "a", "port": 8080, "ratio": 0.5, "on": true, "obj": {"k": 1}, "list": [1], "none": null}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if doc.MustGetString("name") != "a" || doc.MustGetInt("port") != 8080 || doc.MustGetInt64("port") != 8080 ||
+		doc.MustGetFloat("ratio") != 0.5 || !doc.MustGetBool("on") || doc.MustGetObject("obj").Size() != 1 ||
+		doc.MustGetArray("list").Len() != 1 {
+		t.Error("Must getters returned wrong values")
+	}
+
+	tests := []struct {
+		fn   func()
+		want string
+	}{
+		{func() { doc.MustGetString("port") }, `json: MustGetString("port"): found number (int64), want string`},
+		{func() { doc.MustGetInt("missing") }, `json: MustGetInt("missing"): key not found`},
+		{func() { doc.MustGetInt64("name") }, `json: MustGetInt64("name"): found string (string), want number`},
+		{func() { doc.MustGetFloat("none") }, `json: MustGetFloat("none"): found null, want number`},
+		{func() { doc.MustGetBool("ratio") }, `json: MustGetBool("ratio"): found number (float64), want boolean`},
+		{func() { doc.MustGetObject("list") }, `json: MustGetObject("list"): found array ([]interface {}), want object`},
+		{func() { doc.MustGetArray("obj") }, `json: MustGetArray("obj"): found object (map[string]interface {}), want array`},
+	}
+	for _, tt := range tests {
+		if got := panicMessage(tt.fn); got != tt.want {
+			t.Errorf("panic = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestArray_MustGet(t *testing.T) {
+	arr := NewArray().AddString("a").AddInt(2).AddBool(true).AddFloat(1.5).AddObject(NewDocument()).AddArray(NewArray())
+
+	if arr.MustGetString(0) != "a" || arr.MustGetInt(1) != 2 || arr.MustGetInt64(1) != 2 || !arr.MustGetBool(2) ||
+		arr.MustGetFloat(3) != 1.5 || arr.MustGetObject(4).Size() != 0 || arr.MustGetArray(5).Len() != 0 {
+		t.Error("Must getters returned wrong values")
+	}
+
+	tests := []struct {
+		fn   func()
+		want string
+	}{
+		{func() { arr.MustGetString(1) }, `json: MustGetString(1): found number (int), want string`},
+		{func() { arr.MustGetInt(6) }, `json: MustGetInt(6): index out of range with length 6`},
+		{func() { arr.MustGetInt64(-1) }, `json: MustGetInt64(-1): index out of range with length 6`},
+		{func() { arr.MustGetBool(0) }, `json: MustGetBool(0): found string (string), want boolean`},
+		{func() { arr.MustGetFloat(2) }, `json: MustGetFloat(2): found boolean (bool), want number`},
+		{func() { arr.MustGetObject(5) }, `json: MustGetObject(5): found array ([]interface {}), want object`},
+		{func() { arr.MustGetArray(4) }, `json: MustGetArray(4): found object (map[string]interface {}), want array`},
+	}
+	for _, tt := range tests {
+		if got := panicMessage(tt.fn); got != tt.want {
+			t.Errorf("panic = %q, want %q", got, tt.want)
+		}
+	}
+}
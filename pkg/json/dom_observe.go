@@ -0,0 +1,69 @@
+package json
+
+// changeObserver is a function attached with OnChange. It is referred to
+// by pointer so that it can be detached.
+type changeObserver struct {
+	fn func(path string, old, new interface{})
+}
+
+// OnChange attaches fn to be called after each change made through d, so
+// that caches and live-reload code can react to the keys they depend on
+// without diffing whole documents:
+//
+//	stop := config.OnChange(func(path string, old, new interface{}) {
+//	    if path == "/server/port" {
+//	        restartListener(new)
+//	    }
+//	})
+//	defer stop()
+//
+// path is the JSON Pointer of the member or element that changed, such as
+// /timeout for Set("timeout", ...) or /server/port for a Tx.Set, and "" when
+// UnmarshalJSON replaces the whole document. old is the value before the
+// change and new the value after it, in the form Get returns them; old is
+// nil when a member or element was added and new is nil when one was
+// removed (Has tells these apart from null). A value rejected by a set
+// validator is not stored and not reported.
+//
+// The setters, SetValue, Remove, UnmarshalJSON, and the operations of a
+// Tx, including those its Rollback undoes, report their changes; a move
+// reports a removal and an addition. Changes made through a nested
+// Document returned by GetObject, or to maps and slices obtained from d,
+// are not seen. Observers are called in the order they were attached, on
+// the goroutine making the change; they must not modify d.
+//
+// OnChange returns a function that detaches fn.
+func (d *Document) OnChange(fn func(path string, old, new interface{})) (cancel func()) {
+	r := d.rulesOrNew()
+	o := &changeObserver{fn: fn}
+	r.observers = append(r.observers, o)
+	return func() {
+		for i, other := range r.observers {
+			if other == o {
+				r.observers = append(r.observers[:i:i], r.observers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notify calls the observers attached with OnChange.
+func (d *Document) notify(path string, old, new interface{}) {
+	if d.rules == nil {
+		return
+	}
+	for _, o := range d.rules.observers {
+		o.fn(path, old, new)
+	}
+}
+
+// store sets key to value and notifies the observers.
+func (d *Document) store(key string, value interface{}) {
+	if d.rules == nil || len(d.rules.observers) == 0 {
+		d.data[key] = value
+		return
+	}
+	old := d.data[key]
+	d.data[key] = value
+	d.notify("/"+escapePointerToken(key), old, value)
+}
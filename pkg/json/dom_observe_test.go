@@ -0,0 +1,131 @@
+package json
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type change struct {
+	path     string
+	old, new interface{}
+}
+
+func recordChanges(doc *Document) (*[]change, func()) {
+	var got []change
+	cancel := doc.OnChange(func(path string, old, new interface{}) {
+		got = append(got, change{path, old, new})
+	})
+	return &got, cancel
+}
+
+func TestDocument_OnChange(t *testing.T) {
+	doc := NewDocument().SetInt("port", 80)
+	got, cancel := recordChanges(doc)
+
+	doc.SetInt("port", 443).
+		SetString("a/b", "x").
+		SetNull("n").
+		Remove("n").
+		Remove("missing").
+		SetValue("v", IntValue(1))
+
+	want := []change{
+		{"/port", 80, 443},
+		{"/a~1b", nil, "x"},
+		{"/n", nil, nil},
+		{"/n", nil, nil},
+		{"/v", nil, int64(1)},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("changes:\n got %v\nwant %v", *got, want)
+	}
+
+	*got = nil
+	if err := doc.UnmarshalJSON([]byte(`{"port": 8080}`)); err != nil {
+		t.Fatal(err)
+	}
+	if len(*got) != 1 || (*got)[0].path != "" {
+		t.Errorf("UnmarshalJSON changes = %v, want one at \"\"", *got)
+	}
+
+	cancel()
+	cancel()
+	doc.SetInt("port", 1)
+	if len(*got) != 1 {
+		t.Errorf("observer called after cancel: %v", *got)
+	}
+}
+
+func TestDocument_OnChangeValidator(t *testing.T) {
+	doc := NewDocument().WithSetValidator(func(key string, value interface{}) error {
+		if value == "" {
+			return errors.New("must not be empty")
+		}
+		return nil
+	})
+	got, _ := recordChanges(doc)
+	doc.SetString("name", "").SetString("name", "ok")
+	if want := []change{{"/name", nil, "ok"}}; !reflect.DeepEqual(*got, want) {
+		t.Errorf("changes = %v, want %v", *got, want)
+	}
+}
+
+func TestDocument_OnChangeCancelDuringNotify(t *testing.T) {
+	doc := NewDocument()
+	var calls []string
+	var cancelFirst func()
+	cancelFirst = doc.OnChange(func(string, interface{}, interface{}) {
+		calls = append(calls, "first")
+		cancelFirst()
+	})
+	doc.OnChange(func(string, interface{}, interface{}) {
+		calls = append(calls, "second")
+	})
+	doc.SetInt("a", 1).SetInt("a", 2)
+	if want := []string{"first", "second", "second"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestTx_OnChange(t *testing.T) {
+	doc := mustParseDocument(t, `{"server": {"port": 80}, "tags": ["a"], "debug": true}`)
+	got, _ := recordChanges(doc)
+
+	tx := doc.Begin()
+	for _, err := range []error{
+		tx.Set("/server/port", 443),
+		tx.Set("/tags/-", "b"),
+		tx.Remove("/debug"),
+		tx.Apply(Patch{{Op: "move", From: "/tags/0", Path: "/first"}}),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []change{
+		{"/server/port", int64(80), int64(443)},
+		{"/tags/1", nil, "b"},
+		{"/debug", true, nil},
+		{"/tags/0", "a", nil},
+		{"/first", nil, "a"},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Fatalf("changes:\n got %v\nwant %v", *got, want)
+	}
+
+	*got = nil
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	want = []change{
+		{"/first", "a", nil},
+		{"/tags/0", nil, "a"},
+		{"/debug", nil, true},
+		{"/tags/1", "b", nil},
+		{"/server/port", int64(443), int64(80)},
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("Rollback changes:\n got %v\nwant %v", *got, want)
+	}
+}
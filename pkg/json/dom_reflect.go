@@ -0,0 +1,20 @@
+//go:build !tinygo
+
+package json
+
+import "reflect"
+
+// encodesItself reports whether the slice, array, or map rv is written by
+// a method of its own rather than element by element, so that the DOM
+// copies it whole.
+func encodesItself(rv reflect.Value) bool {
+	t := rv.Type()
+	return encodedBySpecial(t) || t.Implements(textMarshalerType)
+}
+
+// hasMarshalJSON reports whether rv, or a pointer to it, implements
+// Marshaler.
+func hasMarshalJSON(rv reflect.Value) bool {
+	t := rv.Type()
+	return t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)
+}
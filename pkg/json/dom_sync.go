@@ -0,0 +1,322 @@
+package json
+
+import (
+	"sync"
+)
+
+// SyncDocument is a Document that is safe for concurrent use.
+//
+// Document is backed by a plain map, so reading it from several goroutines
+// while another one modifies it is a data race. SyncDocument guards the map
+// with a read/write lock and uses copy-on-read semantics: every value handed
+// out (nested objects, arrays, snapshots) is a deep copy, so callers can
+// never observe or cause a concurrent modification.
+//
+// A typical use is a shared configuration that request handlers read while a
+// background goroutine hot-reloads it:
+//
+//	cfg := json.NewSyncDocument(initial)
+//
+//	// Reload goroutine
+//	go func() {
+//	    for range reloadSignal {
+//	        doc, err := json.ParseDocument(readConfig())
+//	        if err == nil {
+//	            cfg.Store(doc)
+//	        }
+//	    }
+//	}()
+//
+//	// Request handlers
+//	timeout, _ := cfg.GetInt("timeout")
+//
+// The zero value is an empty document ready to use.
+type SyncDocument struct {
+	mu  sync.RWMutex
+	doc *Document
+}
+
+// NewSyncDocument returns a SyncDocument holding a deep copy of doc.
+// A nil doc yields an empty document.
+func NewSyncDocument(doc *Document) *SyncDocument {
+	s := &SyncDocument{}
+	if doc != nil {
+		s.doc = doc.Clone()
+	}
+	return s
+}
+
+// ParseSyncDocument parses a JSON object string into a SyncDocument.
+func ParseSyncDocument(input string) (*SyncDocument, error) {
+	doc, err := ParseDocument(input)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncDocument{doc: doc}, nil
+}
+
+// ============================================================================
+// Whole-document access
+// ============================================================================
+
+// Load returns a deep copy of the current document.
+// The snapshot is independent: later changes to s do not affect it.
+func (s *SyncDocument) Load() *Document {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().Clone()
+}
+
+// Store atomically replaces the document with a deep copy of doc.
+// A nil doc clears the document.
+func (s *SyncDocument) Store(doc *Document) {
+	var next *Document
+	if doc != nil {
+		next = doc.Clone()
+	}
+	s.mu.Lock()
+	s.doc = next
+	s.mu.Unlock()
+}
+
+// Swap atomically replaces the document with a deep copy of doc and returns
+// the previous document. The returned Document is no longer shared and may be
+// modified freely.
+func (s *SyncDocument) Swap(doc *Document) *Document {
+	var next *Document
+	if doc != nil {
+		next = doc.Clone()
+	}
+	s.mu.Lock()
+	prev := s.current()
+	s.doc = next
+	s.mu.Unlock()
+	return prev
+}
+
+// Read calls fn with the current document while holding the read lock.
+// It avoids the copy made by Load, but fn must not modify the document or
+// retain references to it (or to any nested map or slice) after returning.
+func (s *SyncDocument) Read(fn func(doc *Document)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.current())
+}
+
+// Update calls fn with the current document while holding the write lock,
+// so a group of modifications is applied atomically with respect to readers.
+// fn must not retain references to the document after returning.
+func (s *SyncDocument) Update(fn func(doc *Document)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.doc == nil {
+		s.doc = NewDocument()
+	}
+	fn(s.doc)
+}
+
+// ============================================================================
+// Setters (each acquires the write lock)
+// ============================================================================
+
+// Set sets a value. Objects and arrays, including Documents, Arrays, and Go
+// maps and slices, are deep-copied before being stored, as maps and slices.
+func (s *SyncDocument) Set(key string, value interface{}) *SyncDocument {
+	value = deepCopyValue(value)
+	s.Update(func(d *Document) { d.Set(key, value) })
+	return s
+}
+
+// SetString sets a string value.
+func (s *SyncDocument) SetString(key, value string) *SyncDocument {
+	s.Update(func(d *Document) { d.SetString(key, value) })
+	return s
+}
+
+// SetInt sets an int value.
+func (s *SyncDocument) SetInt(key string, value int) *SyncDocument {
+	s.Update(func(d *Document) { d.SetInt(key, value) })
+	return s
+}
+
+// SetInt64 sets an int64 value.
+func (s *SyncDocument) SetInt64(key string, value int64) *SyncDocument {
+	s.Update(func(d *Document) { d.SetInt64(key, value) })
+	return s
+}
+
+// SetBool sets a bool value.
+func (s *SyncDocument) SetBool(key string, value bool) *SyncDocument {
+	s.Update(func(d *Document) { d.SetBool(key, value) })
+	return s
+}
+
+// SetFloat sets a float64 value.
+func (s *SyncDocument) SetFloat(key string, value float64) *SyncDocument {
+	s.Update(func(d *Document) { d.SetFloat(key, value) })
+	return s
+}
+
+// SetNull sets a null value.
+func (s *SyncDocument) SetNull(key string) *SyncDocument {
+	s.Update(func(d *Document) { d.SetNull(key) })
+	return s
+}
+
+// SetObject sets a deep copy of a nested Document.
+func (s *SyncDocument) SetObject(key string, value *Document) *SyncDocument {
+	value = value.Clone()
+	s.Update(func(d *Document) { d.SetObject(key, value) })
+	return s
+}
+
+// SetArray sets a deep copy of an Array.
+func (s *SyncDocument) SetArray(key string, value *Array) *SyncDocument {
+	value = value.Clone()
+	s.Update(func(d *Document) { d.SetArray(key, value) })
+	return s
+}
+
+// Remove removes a key.
+func (s *SyncDocument) Remove(key string) *SyncDocument {
+	s.Update(func(d *Document) { d.Remove(key) })
+	return s
+}
+
+// ============================================================================
+// Getters (each acquires the read lock)
+// ============================================================================
+
+// Get gets a value. Maps and slices are returned as deep copies.
+func (s *SyncDocument) Get(key string) (interface{}, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	val, ok := s.current().Get(key)
+	return deepCopyValue(val), ok
+}
+
+// GetString gets a string value.
+func (s *SyncDocument) GetString(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().GetString(key)
+}
+
+// GetInt gets an int value.
+func (s *SyncDocument) GetInt(key string) (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().GetInt(key)
+}
+
+// GetInt64 gets an int64 value.
+func (s *SyncDocument) GetInt64(key string) (int64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().GetInt64(key)
+}
+
+// GetBool gets a bool value.
+func (s *SyncDocument) GetBool(key string) (bool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().GetBool(key)
+}
+
+// GetFloat gets a float64 value.
+func (s *SyncDocument) GetFloat(key string) (float64, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().GetFloat(key)
+}
+
+// GetObject gets a deep copy of a nested Document.
+func (s *SyncDocument) GetObject(key string) (*Document, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	obj, ok := s.current().GetObject(key)
+	if !ok {
+		return nil, false
+	}
+	return obj.Clone(), true
+}
+
+// GetArray gets a deep copy of an Array.
+func (s *SyncDocument) GetArray(key string) (*Array, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	arr, ok := s.current().GetArray(key)
+	if !ok {
+		return nil, false
+	}
+	return arr.Clone(), true
+}
+
+// IsNull checks if a key exists and has a null value.
+func (s *SyncDocument) IsNull(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().IsNull(key)
+}
+
+// Has checks if a key exists (including null values).
+func (s *SyncDocument) Has(key string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().Has(key)
+}
+
+// Keys returns all keys in the document.
+func (s *SyncDocument) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().Keys()
+}
+
+// Size returns the number of properties in the document.
+func (s *SyncDocument) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().Size()
+}
+
+// ============================================================================
+// Serialization
+// ============================================================================
+
+// JSON marshals the document to a JSON string.
+func (s *SyncDocument) JSON() (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().JSON()
+}
+
+// MarshalJSON implements json.Marshaler interface.
+func (s *SyncDocument) MarshalJSON() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current().MarshalJSON()
+}
+
+// UnmarshalJSON implements json.Unmarshaler interface.
+// The input is parsed before the lock is taken, and the document is replaced
+// only if parsing succeeds.
+func (s *SyncDocument) UnmarshalJSON(data []byte) error {
+	doc := NewDocument()
+	if err := doc.UnmarshalJSON(data); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.doc = doc
+	s.mu.Unlock()
+	return nil
+}
+
+// current returns the held document, or an empty one for the zero value.
+// The caller must hold s.mu.
+func (s *SyncDocument) current() *Document {
+	if s.doc == nil {
+		return NewDocument()
+	}
+	return s.doc
+}
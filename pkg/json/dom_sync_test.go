@@ -0,0 +1,239 @@
+package json
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSyncDocument_Basic(t *testing.T) {
+	var s SyncDocument // zero value is usable
+
+	s.SetString("name", "Alice").SetInt("age", 30).SetBool("active", true).SetNull("manager")
+
+	if v, ok := s.GetString("name"); !ok || v != "Alice" {
+		t.Errorf("GetString = %q, %v", v, ok)
+	}
+	if v, ok := s.GetInt("age"); !ok || v != 30 {
+		t.Errorf("GetInt = %d, %v", v, ok)
+	}
+	if !s.IsNull("manager") || !s.Has("active") || s.Size() != 4 {
+		t.Errorf("unexpected state: size=%d", s.Size())
+	}
+
+	s.Remove("manager")
+	if s.Has("manager") {
+		t.Error("Remove did not remove key")
+	}
+
+	out, err := s.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if out != `{"active":true,"age":30,"name":"Alice"}` {
+		t.Errorf("JSON() = %s", out)
+	}
+}
+
+func TestSyncDocument_CopyOnRead(t *testing.T) {
+	src := NewDocument().
+		SetObject("db", NewDocument().SetString("host", "localhost")).
+		SetArray("ports", NewArray().AddInt(80))
+
+	s := NewSyncDocument(src)
+
+	// Mutating the source after construction must not leak in.
+	src.SetString("late", "x")
+	if s.Has("late") {
+		t.Error("NewSyncDocument did not copy its input")
+	}
+
+	// Mutating values handed out must not leak back.
+	db, _ := s.GetObject("db")
+	db.SetString("host", "evil")
+	ports, _ := s.GetArray("ports")
+	ports.AddInt(443)
+	snapshot := s.Load()
+	snapshot.SetString("extra", "y")
+	raw, _ := s.Get("db")
+	raw.(map[string]interface{})["host"] = "also evil"
+
+	db2, _ := s.GetObject("db")
+	if host, _ := db2.GetString("host"); host != "localhost" {
+		t.Errorf("host = %q, want localhost", host)
+	}
+	ports2, _ := s.GetArray("ports")
+	if ports2.Len() != 1 {
+		t.Errorf("ports len = %d, want 1", ports2.Len())
+	}
+	if s.Has("extra") {
+		t.Error("Load() snapshot is not independent")
+	}
+}
+
+// TestSyncDocument_CopyNested checks that Documents, Arrays, and typed
+// containers passed to Set are copied in and out rather than shared; run
+// with -race to detect the unsynchronized access sharing would allow.
+func TestSyncDocument_CopyNested(t *testing.T) {
+	inner := NewDocument().SetString("k", "v")
+	tags := []string{"a"}
+	var s SyncDocument
+	s.Set("inner", inner).
+		Set("list", NewArray().AddString("x")).
+		Set("tags", tags).
+		Set("counts", map[string]int{"n": 1})
+
+	inner.SetString("k", "MUTATED")
+	tags[0] = "MUTATED"
+	if got, _ := s.JSON(); got != `{"counts":{"n":1},"inner":{"k":"v"},"list":["x"],"tags":["a"]}` {
+		t.Errorf("JSON() = %s after changing the values set", got)
+	}
+
+	var wg sync.WaitGroup
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+		go func(r int) {
+			defer wg.Done()
+			// Each goroutine changes its own Document after setting it
+			mine := NewDocument().SetInt("r", r)
+			for i := 0; i < 100; i++ {
+				s.Set("mine", mine)
+				mine.SetInt("i", i)
+				v, _ := s.Get("inner")
+				obj, ok := v.(map[string]interface{})
+				if !ok {
+					t.Errorf("Get(inner) = %T, want map[string]interface{}", v)
+					return
+				}
+				obj["k"] = r
+				v, _ = s.Get("tags")
+				v.([]interface{})[0] = r
+			}
+		}(r)
+	}
+	wg.Wait()
+
+	if v, _ := s.Get("inner"); v.(map[string]interface{})["k"] != "v" {
+		t.Errorf("inner = %v, want k: v", v)
+	}
+	if v, _ := s.Get("tags"); v.([]interface{})[0] != "a" {
+		t.Errorf("tags = %v, want [a]", v)
+	}
+}
+
+func TestSyncDocument_StoreSwapUnmarshal(t *testing.T) {
+	s, err := ParseSyncDocument(`{"version": 1}`)
+	if err != nil {
+		t.Fatalf("ParseSyncDocument() error = %v", err)
+	}
+
+	prev := s.Swap(NewDocument().SetInt("version", 2))
+	if v, _ := prev.GetInt("version"); v != 1 {
+		t.Errorf("Swap returned version %d, want 1", v)
+	}
+
+	s.Store(nil)
+	if s.Size() != 0 {
+		t.Errorf("Store(nil) size = %d, want 0", s.Size())
+	}
+
+	if err := s.UnmarshalJSON([]byte(`{"version": 3}`)); err != nil {
+		t.Fatalf("UnmarshalJSON() error = %v", err)
+	}
+	if err := s.UnmarshalJSON([]byte(`[1]`)); err == nil {
+		t.Error("expected error for non-object input")
+	}
+	if v, _ := s.GetInt("version"); v != 3 {
+		t.Errorf("version = %d, want 3 (failed unmarshal must not replace)", v)
+	}
+
+	data, err := Marshal(s)
+	if err != nil || string(data) != `{"version":3}` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+
+	if _, err := ParseSyncDocument(`[]`); err == nil {
+		t.Error("expected error for array input")
+	}
+}
+
+func TestSyncDocument_ReadUpdate(t *testing.T) {
+	s := NewSyncDocument(nil)
+	s.Update(func(d *Document) {
+		d.SetInt("a", 1).SetInt("b", 2)
+	})
+
+	sum := 0
+	s.Read(func(d *Document) {
+		a, _ := d.GetInt("a")
+		b, _ := d.GetInt("b")
+		sum = a + b
+	})
+	if sum != 3 {
+		t.Errorf("sum = %d, want 3", sum)
+	}
+}
+
+// TestSyncDocument_Concurrent exercises concurrent readers and a reloading
+// writer; run with -race to detect unsynchronized access.
+func TestSyncDocument_Concurrent(t *testing.T) {
+	s := NewSyncDocument(NewDocument().SetInt("gen", 0))
+
+	var wg sync.WaitGroup
+	for r := 0; r < 8; r++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				s.GetInt("gen")
+				s.Keys()
+				if obj, ok := s.GetObject("nested"); ok {
+					obj.SetString("mutated", "local only")
+				}
+				_, _ = s.JSON()
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 200; i++ {
+			doc, err := ParseDocument(fmt.Sprintf(`{"gen": %d, "nested": {"i": %d}}`, i, i))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			s.Store(doc)
+			s.SetInt("gen", i)
+		}
+	}()
+
+	wg.Wait()
+
+	if v, _ := s.GetInt("gen"); v != 200 {
+		t.Errorf("gen = %d, want 200", v)
+	}
+}
+
+func TestDocument_Clone(t *testing.T) {
+	doc, _ := ParseDocument(`{"a": {"b": [1, {"c": 2}]}}`)
+	clone := doc.Clone()
+
+	inner, _ := clone.GetObject("a")
+	arr, _ := inner.GetArray("b")
+	obj, _ := arr.GetObject(1)
+	obj.SetInt("c", 99)
+
+	orig, _ := doc.JSON()
+	if orig != `{"a":{"b":[1,{"c":2}]}}` {
+		t.Errorf("original modified through clone: %s", orig)
+	}
+
+	arr2 := NewArray().AddArray(NewArray().AddInt(1))
+	arrClone := arr2.Clone()
+	arrClone.ToSlice()[0].([]interface{})[0] = 99
+	if v, _ := arr2.ToSlice()[0].([]interface{})[0].(int); v != 1 {
+		t.Errorf("Array.Clone shares nested slice: got %d", v)
+	}
+}
@@ -438,6 +438,30 @@ func TestArray_AddArray(t *testing.T) {
 	}
 }
 
+func TestArray_BulkAdd(t *testing.T) {
+	arr := NewArray().
+		AddStrings("a", "b").
+		AddInts(1, 2).
+		AddInt64s(3).
+		AddBools(true).
+		AddFloats(0.5, 1.5).
+		AddStrings()
+
+	got, err := arr.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if want := `["a","b",1,2,3,true,0.5,1.5]`; got != want {
+		t.Errorf("JSON() = %s, want %s", got, want)
+	}
+	if v, ok := arr.GetString(1); !ok || v != "b" {
+		t.Errorf("GetString(1) = %q, %v", v, ok)
+	}
+	if v, ok := arr.GetInt(3); !ok || v != 2 {
+		t.Errorf("GetInt(3) = %d, %v", v, ok)
+	}
+}
+
 // ============================================================================
 // Array Tests - Getter Methods
 // ============================================================================
@@ -0,0 +1,26 @@
+//go:build tinygo
+
+package json
+
+import (
+	"encoding"
+	"reflect"
+)
+
+// encodesItself reports whether the slice, array, or map rv is written by
+// a method of its own rather than element by element, so that the DOM
+// copies it whole. TinyGo builds check the value's own methods only.
+func encodesItself(rv reflect.Value) bool {
+	switch rv.Interface().(type) {
+	case Marshaler, encoding.TextMarshaler:
+		return true
+	}
+	return false
+}
+
+// hasMarshalJSON reports whether rv implements Marshaler. TinyGo builds
+// check the value's own methods only.
+func hasMarshalJSON(rv reflect.Value) bool {
+	_, ok := rv.Interface().(Marshaler)
+	return ok
+}
@@ -0,0 +1,316 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrTxDone is returned by the methods of a Tx that has already been
+// committed or rolled back.
+var ErrTxDone = errors.New("json: transaction has already been committed or rolled back")
+
+// Tx is a transaction on a Document, started by Document.Begin. Its
+// mutations change the document at once, so reads in between see them,
+// and each is journaled with what it replaced, so that Rollback can undo
+// them all and Patch can return them as a JSON Patch to replay elsewhere:
+//
+//	tx := config.Begin()
+//	if err := tx.Set("/server/port", 8443); err != nil {
+//	    tx.Rollback()
+//	    return err
+//	}
+//	if err := tx.Remove("/debug"); err != nil {
+//	    tx.Rollback()
+//	    return err
+//	}
+//	replicate(tx.Patch())
+//	return tx.Commit()
+//
+// Locations are JSON Pointers (RFC 6901), and "" cannot be changed, since
+// a Document is always an object. While a transaction is open, the
+// document must be changed only through it. Rollback puts back the values
+// that were replaced or removed themselves, not copies, so a nested object
+// obtained with GetObject before the transaction is part of the document
+// again afterwards.
+//
+// Observers attached with OnChange are told of each change, and of each
+// change Rollback undoes. A Tx is not safe for concurrent use; see
+// SyncDocument.Update for atomic updates shared between goroutines.
+type Tx struct {
+	doc  *Document
+	ops  Patch            // the operations applied, for Patch
+	undo []PatchOperation // the inverse of each step, in the order applied
+	done bool
+}
+
+// Begin starts a transaction on d.
+func (d *Document) Begin() *Tx {
+	return &Tx{doc: d}
+}
+
+// Set stores value at path, adding an object member or replacing it, or
+// replacing an array element; "-" as the last token of path appends to an
+// array. The parent of the location must exist. value is deep-converted as
+// by DocumentFromValue, so *Document, *Array, structs, and maps may be
+// passed.
+func (tx *Tx) Set(path string, value interface{}) error {
+	converted, err := domValue(value)
+	if err != nil {
+		return fmt.Errorf("json: Set %q: %w", path, err)
+	}
+	op := PatchOperation{Op: "add", Path: path, Value: converted}
+	if _, err := tx.get(path); err == nil && !strings.HasSuffix(path, "/-") {
+		op.Op = "replace"
+	}
+	return tx.Apply(Patch{op})
+}
+
+// Remove deletes the object member or array element at path, which must
+// exist. Later elements of an array move down by one.
+func (tx *Tx) Remove(path string) error {
+	return tx.Apply(Patch{{Op: "remove", Path: path}})
+}
+
+// Apply applies the operations of patch (RFC 6902) to the document, in
+// order, as part of the transaction. It is atomic: if an operation fails,
+// the ones before it are undone and the error is returned, and the
+// transaction continues as it was before the call.
+func (tx *Tx) Apply(patch Patch) error {
+	if tx.done {
+		return ErrTxDone
+	}
+	mark, applied := len(tx.undo), len(tx.ops)
+	for i, op := range patch {
+		if err := tx.apply(op); err != nil {
+			tx.undoTo(mark)
+			tx.ops = tx.ops[:applied]
+			return fmt.Errorf("json: patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+		op.Value = deepCopyValue(op.Value)
+		tx.ops = append(tx.ops, op)
+	}
+	return nil
+}
+
+// Patch returns the operations the transaction has applied so far, tests
+// included, which turn the document as it was at Begin into the document
+// as it is. After Rollback it returns nil.
+func (tx *Tx) Patch() Patch {
+	return append(Patch(nil), tx.ops...)
+}
+
+// Commit ends the transaction, keeping its changes.
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.undo = nil
+	return nil
+}
+
+// Rollback ends the transaction, undoing its changes in reverse order.
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+	tx.undoTo(0)
+	tx.ops = nil
+	return nil
+}
+
+// apply applies one operation, journaling the inverse of each step.
+func (tx *Tx) apply(op PatchOperation) error {
+	switch op.Op {
+	case "add":
+		return tx.add(op.Path, deepCopyValue(op.Value))
+	case "remove":
+		_, err := tx.remove(op.Path)
+		return err
+	case "replace":
+		return tx.replace(op.Path, deepCopyValue(op.Value))
+	case "move":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return err
+		}
+		path, err := parsePointer(op.Path)
+		if err != nil {
+			return err
+		}
+		if len(path) > len(from) && isPointerPrefix(from, path) {
+			return errors.New("cannot move a value into one of its children")
+		}
+		value, err := tx.remove(op.From)
+		if err != nil {
+			return err
+		}
+		return tx.add(op.Path, value)
+	case "copy":
+		value, err := tx.get(op.From)
+		if err != nil {
+			return err
+		}
+		return tx.add(op.Path, deepCopyValue(value))
+	case "test":
+		value, err := tx.get(op.Path)
+		if err != nil {
+			return err
+		}
+		if !valuesEqual(value, op.Value, &CompareOptions{}) {
+			return errors.New("test failed: values differ")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// get returns the value at ptr.
+func (tx *Tx) get(ptr string) (interface{}, error) {
+	path, err := parsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	return pointerGet(tx.doc.data, path)
+}
+
+// add stores value at ptr as a JSON Patch add does, journaling how to
+// undo it. Adding an object member that exists replaces it.
+func (tx *Tx) add(ptr string, value interface{}) error {
+	path, err := tx.member(ptr)
+	if err != nil {
+		return err
+	}
+	parent, err := pointerGet(tx.doc.data, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := v[path[len(path)-1]]; ok {
+			return tx.replace(ptr, value)
+		}
+	case []interface{}:
+		if path[len(path)-1] == "-" {
+			ptr = ptr[:len(ptr)-1] + strconv.Itoa(len(v))
+		}
+	}
+	if err := tx.insert(ptr, path, value); err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, PatchOperation{Op: "remove", Path: ptr})
+	return nil
+}
+
+// remove deletes the value at ptr and returns it, journaling how to put it
+// back.
+func (tx *Tx) remove(ptr string) (interface{}, error) {
+	path, err := tx.member(ptr)
+	if err != nil {
+		return nil, err
+	}
+	old, err := tx.delete(ptr, path)
+	if err != nil {
+		return nil, err
+	}
+	tx.undo = append(tx.undo, PatchOperation{Op: "add", Path: ptr, Value: old})
+	return old, nil
+}
+
+// replace stores value in place of the existing value at ptr, journaling
+// the value it replaced.
+func (tx *Tx) replace(ptr string, value interface{}) error {
+	path, err := tx.member(ptr)
+	if err != nil {
+		return err
+	}
+	old, err := tx.swap(ptr, path, value)
+	if err != nil {
+		return err
+	}
+	tx.undo = append(tx.undo, PatchOperation{Op: "replace", Path: ptr, Value: old})
+	return nil
+}
+
+// insert adds a new member or element, and tells the document's observers.
+func (tx *Tx) insert(ptr string, path []string, value interface{}) error {
+	if _, err := pointerAdd(tx.doc.data, path, value); err != nil {
+		return err
+	}
+	tx.doc.notify(ptr, nil, value)
+	return nil
+}
+
+// delete removes a member or element, and tells the document's observers.
+func (tx *Tx) delete(ptr string, path []string) (interface{}, error) {
+	_, old, err := pointerRemove(tx.doc.data, path)
+	if err != nil {
+		return nil, err
+	}
+	tx.doc.notify(ptr, old, nil)
+	return old, nil
+}
+
+// swap replaces an existing member or element, and tells the document's
+// observers.
+func (tx *Tx) swap(ptr string, path []string, value interface{}) (interface{}, error) {
+	parent, err := pointerGet(tx.doc.data, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	var old interface{}
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		var ok bool
+		if old, ok = v[last]; !ok {
+			return nil, fmt.Errorf("member %q not found", last)
+		}
+		v[last] = value
+	case []interface{}:
+		i, err := arrayIndex(last, len(v))
+		if err != nil {
+			return nil, err
+		}
+		old, v[i] = v[i], value
+	default:
+		return nil, fmt.Errorf("cannot replace in scalar at %q", last)
+	}
+	tx.doc.notify(ptr, old, value)
+	return old, nil
+}
+
+// member parses ptr, which must refer to a member or element rather than
+// to the document itself.
+func (tx *Tx) member(ptr string) ([]string, error) {
+	path, err := parsePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, errors.New("cannot replace or remove the document itself")
+	}
+	return path, nil
+}
+
+// undoTo undoes the journaled steps after the first n, newest first. The
+// journaled values are put back as they are, not copied.
+func (tx *Tx) undoTo(n int) {
+	for i := len(tx.undo) - 1; i >= n; i-- {
+		op := tx.undo[i]
+		path, _ := parsePointer(op.Path)
+		switch op.Op {
+		case "add":
+			tx.insert(op.Path, path, op.Value)
+		case "remove":
+			tx.delete(op.Path, path)
+		case "replace":
+			tx.swap(op.Path, path, op.Value)
+		}
+	}
+	tx.undo = tx.undo[:n]
+}
@@ -0,0 +1,157 @@
+package json
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const txConfig = `{"server": {"host": "localhost", "port": 8080}, "debug": true, "tags": ["a", "b", "c"]}`
+
+func mustParseDocument(t *testing.T, s string) *Document {
+	t.Helper()
+	doc, err := ParseDocument(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestTx_Rollback(t *testing.T) {
+	doc := mustParseDocument(t, txConfig)
+	before := doc.Clone().ToMap()
+	server, _ := doc.GetObject("server")
+
+	tx := doc.Begin()
+	steps := []error{
+		tx.Set("/server/port", 8443),
+		tx.Set("/server", map[string]interface{}{"host": "example.com"}),
+		tx.Set("/server/tls", true),
+		tx.Remove("/debug"),
+		tx.Set("/tags/-", "d"),
+		tx.Set("/tags/0", "z"),
+		tx.Remove("/tags/1"),
+		tx.Set("/owner", NewDocument().SetString("name", "ops")),
+	}
+	for i, err := range steps {
+		if err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	if got, _ := doc.GetPath("/server/host"); got != "example.com" {
+		t.Errorf("host during transaction = %v, want example.com", got)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(doc.ToMap(), before) {
+		t.Errorf("after Rollback:\n got %v\nwant %v", doc.ToMap(), before)
+	}
+	if got, _ := doc.GetObject("server"); !reflect.DeepEqual(got.ToMap(), server.ToMap()) {
+		t.Error("server object was not restored")
+	}
+	server.SetString("host", "changed")
+	if got, _ := doc.GetPath("/server/host"); got != "changed" {
+		t.Error("a nested object obtained before the transaction was not put back itself")
+	}
+	if tx.Patch() != nil {
+		t.Errorf("Patch() after Rollback = %v, want nil", tx.Patch())
+	}
+}
+
+func TestTx_CommitReplay(t *testing.T) {
+	doc := mustParseDocument(t, txConfig)
+	replica := mustParseDocument(t, txConfig).ToMap()
+
+	tx := doc.Begin()
+	if err := tx.Set("/server/port", 9000); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Set("/tags/-", "d"); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Apply(Patch{
+		{Op: "test", Path: "/debug", Value: true},
+		{Op: "move", From: "/debug", Path: "/server/debug"},
+		{Op: "copy", From: "/server/host", Path: "/host"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	patch := tx.Patch()
+	if len(patch) != 5 {
+		t.Errorf("Patch() has %d operations, want 5: %v", len(patch), patch)
+	}
+	got, err := patch.Apply(replica)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, doc.ToMap()) {
+		t.Errorf("replayed patch:\n got %v\nwant %v", got, doc.ToMap())
+	}
+
+	if err := tx.Set("/x", 1); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Set after Commit = %v, want ErrTxDone", err)
+	}
+	if err := tx.Rollback(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Rollback after Commit = %v, want ErrTxDone", err)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("Commit after Commit = %v, want ErrTxDone", err)
+	}
+}
+
+func TestTx_ApplyAtomic(t *testing.T) {
+	doc := mustParseDocument(t, txConfig)
+	tx := doc.Begin()
+	if err := tx.Set("/server/port", 1); err != nil {
+		t.Fatal(err)
+	}
+	after := doc.Clone().ToMap()
+
+	err := tx.Apply(Patch{
+		{Op: "remove", Path: "/tags/0"},
+		{Op: "add", Path: "/server/name", Value: "web"},
+		{Op: "test", Path: "/debug", Value: false},
+	})
+	if err == nil {
+		t.Fatal("Apply succeeded with a failing test")
+	}
+	if !reflect.DeepEqual(doc.ToMap(), after) {
+		t.Errorf("failed Apply changed the document:\n got %v\nwant %v", doc.ToMap(), after)
+	}
+	if n := len(tx.Patch()); n != 1 {
+		t.Errorf("Patch() has %d operations after a failed Apply, want 1", n)
+	}
+}
+
+func TestTx_Errors(t *testing.T) {
+	doc := mustParseDocument(t, txConfig)
+	before := doc.Clone().ToMap()
+	tx := doc.Begin()
+
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"missing parent", tx.Set("/missing/child", 1)},
+		{"not a pointer", tx.Set("server", 1)},
+		{"root", tx.Set("", map[string]interface{}{})},
+		{"remove missing", tx.Remove("/nope")},
+		{"index out of range", tx.Remove("/tags/9")},
+		{"unencodable", tx.Set("/f", func() {})},
+		{"move into child", tx.Apply(Patch{{Op: "move", From: "/server", Path: "/server/x"}})},
+	}
+	for _, tt := range tests {
+		if tt.err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+	if !reflect.DeepEqual(doc.ToMap(), before) {
+		t.Errorf("failed operations changed the document: %v", doc.ToMap())
+	}
+}
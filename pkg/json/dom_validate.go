@@ -0,0 +1,118 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+// documentRules holds the validators attached to a Document, the errors
+// they have reported so far, and the observers attached with OnChange.
+type documentRules struct {
+	onSet     []func(key string, value interface{}) error
+	onBuild   []func(d *Document) error
+	errs      []error
+	observers []*changeObserver
+}
+
+func (d *Document) rulesOrNew() *documentRules {
+	if d.rules == nil {
+		d.rules = &documentRules{}
+	}
+	return d.rules
+}
+
+// set stores value under key, unless a validator attached with
+// WithSetValidator rejects it.
+func (d *Document) set(key string, value interface{}) *Document {
+	if d.rules != nil {
+		for _, fn := range d.rules.onSet {
+			if err := fn(key, value); err != nil {
+				d.rules.errs = append(d.rules.errs, fmt.Errorf("json: invalid value for %q: %w", key, err))
+				return d
+			}
+		}
+	}
+	d.store(key, value)
+	return d
+}
+
+// WithSetValidator attaches a validator that checks every value stored by
+// Set and the typed setters (SetString, SetObject, ...). The value is passed
+// in the form Get returns it: a nested Document or Array arrives as a
+// map[string]interface{} or []interface{}.
+//
+// A rejected value is not stored. Its error is kept, and reported by Err and
+// Build, so the fluent chain never has to be broken to check errors:
+//
+//	doc := json.NewDocument().
+//	    WithSetValidator(func(key string, value interface{}) error {
+//	        if s, ok := value.(string); ok && s == "" {
+//	            return errors.New("must not be empty")
+//	        }
+//	        return nil
+//	    }).
+//	    SetString("id", "").
+//	    SetInt("count", 3)
+//	_, err := doc.Build() // json: invalid value for "id": must not be empty
+func (d *Document) WithSetValidator(fn func(key string, value interface{}) error) *Document {
+	r := d.rulesOrNew()
+	r.onSet = append(r.onSet, fn)
+	return d
+}
+
+// WithValidator attaches a validator that checks the whole document when
+// Build is called, for constraints that involve several keys or that only
+// hold once the document is complete, such as required keys.
+//
+// Validators belong to the Document they are attached to: copies made by
+// Clone and nested Documents returned by GetObject have none.
+func (d *Document) WithValidator(fn func(d *Document) error) *Document {
+	r := d.rulesOrNew()
+	r.onBuild = append(r.onBuild, fn)
+	return d
+}
+
+// WithSchema attaches a JSON Schema that Build checks the document against.
+// Violations are reported as a jsonschema.ValidationErrors.
+//
+//	resp, err := json.NewDocument().
+//	    WithSchema(userSchema).
+//	    SetString("name", name).
+//	    SetInt("age", age).
+//	    Build()
+func (d *Document) WithSchema(schema *jsonschema.Schema) *Document {
+	return d.WithValidator(func(d *Document) error {
+		return schema.Validate(d.data)
+	})
+}
+
+// Err returns the errors reported so far by validators attached with
+// WithSetValidator, joined with errors.Join, or nil if there are none.
+func (d *Document) Err() error {
+	if d.rules == nil {
+		return nil
+	}
+	return errors.Join(d.rules.errs...)
+}
+
+// Build finishes a document built with the fluent setters. It runs the
+// validators attached with WithValidator and WithSchema, and returns d with
+// every error found, both by them and by the set validators, joined with
+// errors.Join. A Document without validators is returned with a nil error.
+//
+// Build does not clear errors: calling it again reports them again, along
+// with whatever the validators find then.
+func (d *Document) Build() (*Document, error) {
+	if d.rules == nil {
+		return d, nil
+	}
+	errs := append([]error(nil), d.rules.errs...)
+	for _, fn := range d.rules.onBuild {
+		if err := fn(d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return d, errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+package json
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+func TestDocument_WithSetValidator(t *testing.T) {
+	errEmpty := errors.New("must not be empty")
+	var seen []interface{}
+	doc := NewDocument().
+		WithSetValidator(func(key string, value interface{}) error {
+			seen = append(seen, value)
+			if s, ok := value.(string); ok && s == "" {
+				return errEmpty
+			}
+			return nil
+		}).
+		SetString("id", "").
+		SetString("name", "Alice").
+		SetObject("meta", NewDocument().SetInt("v", 1)).
+		SetString("nick", "")
+
+	if doc.Has("id") || doc.Has("nick") {
+		t.Error("rejected values were stored")
+	}
+	if v, _ := doc.GetString("name"); v != "Alice" {
+		t.Errorf("GetString(name) = %q", v)
+	}
+	if _, ok := seen[2].(map[string]interface{}); !ok {
+		t.Errorf("SetObject passed %T, want map[string]interface{}", seen[2])
+	}
+
+	err := doc.Err()
+	if !errors.Is(err, errEmpty) {
+		t.Fatalf("Err() = %v, want it to wrap %v", err, errEmpty)
+	}
+	want := "json: invalid value for \"id\": must not be empty\njson: invalid value for \"nick\": must not be empty"
+	if err.Error() != want {
+		t.Errorf("Err() = %q, want %q", err, want)
+	}
+	if _, buildErr := doc.Build(); buildErr == nil || buildErr.Error() != want {
+		t.Errorf("Build() error = %v, want %q", buildErr, want)
+	}
+}
+
+func TestDocument_WithValidator(t *testing.T) {
+	requireID := func(d *Document) error {
+		if !d.Has("id") {
+			return errors.New("id is required")
+		}
+		return nil
+	}
+
+	doc := NewDocument().WithValidator(requireID).SetString("name", "x")
+	if doc.Err() != nil {
+		t.Errorf("Err() = %v before Build", doc.Err())
+	}
+	if _, err := doc.Build(); err == nil || err.Error() != "id is required" {
+		t.Errorf("Build() error = %v", err)
+	}
+
+	got, err := doc.SetString("id", "1").Build()
+	if err != nil || got != doc {
+		t.Errorf("Build() = %p, %v, want %p, nil", got, err, doc)
+	}
+
+	// Documents without validators always build
+	if _, err := NewDocument().SetNull("a").Build(); err != nil {
+		t.Errorf("Build() error = %v", err)
+	}
+	// Clones do not carry validators
+	if _, err := NewDocument().WithValidator(requireID).Clone().Build(); err != nil {
+		t.Errorf("Clone().Build() error = %v", err)
+	}
+}
+
+func TestDocument_WithSchema(t *testing.T) {
+	schema := jsonschema.MustCompile([]byte(`{
+		"type": "object",
+		"required": ["name"],
+		"properties": {
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "items": {"type": "string"}}
+		}
+	}`))
+
+	_, err := NewDocument().
+		WithSchema(schema).
+		SetInt("age", -1).
+		SetArray("tags", NewArray().AddString("a").AddInt(2)).
+		Build()
+	var violations jsonschema.ValidationErrors
+	if !errors.As(err, &violations) {
+		t.Fatalf("Build() error = %v, want ValidationErrors", err)
+	}
+	var paths []string
+	for _, v := range violations {
+		paths = append(paths, v.InstancePath+" "+v.Keyword)
+	}
+	sort.Strings(paths)
+	if got, want := strings.Join(paths, ", "), " required, /age minimum, /tags/1 type"; got != want {
+		t.Errorf("violations = %q, want %q", got, want)
+	}
+
+	if _, err := NewDocument().WithSchema(schema).SetString("name", "Alice").SetInt64("age", 30).Build(); err != nil {
+		t.Errorf("Build() error = %v", err)
+	}
+}
@@ -0,0 +1,242 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Edit is one change to a JSON document, made by ApplyEdits. Edits can be
+// read from JSON or YAML files that describe configuration changes:
+//
+//	[{"op": "set", "path": "/server/tls/port", "value": 8443},
+//	 {"op": "append", "path": "/server/origins", "value": "https://example.com"},
+//	 {"op": "delete", "path": "/debug"}]
+type Edit struct {
+	Op    EditOp      `json:"op"`
+	Path  string      `json:"path"`            // JSON Pointer to the value to change
+	Value interface{} `json:"value,omitempty"` // see EditOp
+}
+
+// EditOp is the kind of change an Edit makes.
+type EditOp string
+
+const (
+	// EditSet stores Value at Path, replacing the value there or adding
+	// it, and creating the objects on the way to it that are missing. In
+	// an array, Path must name an existing element, or "-" to append.
+	EditSet EditOp = "set"
+
+	// EditDelete removes the value at Path. A value that is already
+	// absent is not an error, so that running the same edits twice gives
+	// the same document.
+	EditDelete EditOp = "delete"
+
+	// EditAppend appends Value to the array at Path, creating the array,
+	// and the objects on the way to it, if it is missing.
+	EditAppend EditOp = "append"
+
+	// EditTest changes nothing, but fails the edits unless the value at
+	// Path equals Value, comparing numbers by value. It guards the edits
+	// after it against a document that is not in the expected state.
+	EditTest EditOp = "test"
+)
+
+// EditError reports the edit that stopped ApplyEdits or DryRunEdits.
+type EditError struct {
+	Index int  // index of the edit in the slice
+	Edit  Edit // the edit
+	Err   error
+}
+
+func (e *EditError) Error() string {
+	return fmt.Sprintf("json: edit %d (%s %s): %v", e.Index, e.Edit.Op, e.Edit.Path, e.Err)
+}
+
+func (e *EditError) Unwrap() error { return e.Err }
+
+// ApplyEdits makes edits to doc, in order, and returns the edited
+// document. It is a higher-level JSON Patch for tools that automate
+// changes to configuration files: parents are created as needed, deleting
+// what is absent is not an error, and the edits apply all or nothing. If
+// any edit fails, ApplyEdits returns an *EditError and no document, so
+// the caller never writes out a half-edited file.
+//
+// The document keeps its layout: the member order of its objects, its
+// indentation style, its trailing newline, and the text of the numbers it
+// holds. Members added to an object are written after the existing ones,
+// sorted by name. If the edits change nothing, ApplyEdits returns doc
+// itself.
+//
+// Values in edits may be any Go value that Marshal accepts.
+//
+// Example:
+//
+//	out, err := json.ApplyEdits(config, []json.Edit{
+//	    {Op: json.EditTest, Path: "/version", Value: 2},
+//	    {Op: json.EditSet, Path: "/server/port", Value: 8443},
+//	    {Op: json.EditDelete, Path: "/debug"},
+//	})
+//
+// Use DryRunEdits to see the changes edits would make first.
+func ApplyEdits(doc []byte, edits []Edit) ([]byte, error) {
+	l, before, after, err := runEdits(doc, edits)
+	if err != nil {
+		return nil, err
+	}
+	if valuesEqual(before, after, &CompareOptions{Numbers: NumbersByLexeme}) {
+		return doc, nil
+	}
+	return l.render(after)
+}
+
+// DryRunEdits checks edits against doc as ApplyEdits would and returns the
+// changes they would make, as a JSON Patch from doc to the edited
+// document, without producing it. The patch is empty if the edits would
+// change nothing, and the error is that ApplyEdits would return.
+//
+// Example:
+//
+//	changes, err := json.DryRunEdits(config, edits)
+//	for _, op := range changes {
+//	    fmt.Println(op.Op, op.Path) // replace /server/port
+//	}
+func DryRunEdits(doc []byte, edits []Edit) (Patch, error) {
+	_, before, after, err := runEdits(doc, edits)
+	if err != nil {
+		return nil, err
+	}
+	return DiffWithOptions(before, after, CompareOptions{Numbers: NumbersByLexeme}), nil
+}
+
+// runEdits parses doc and makes edits to a copy of it. It returns the
+// layout of doc, its value, and the edited value.
+func runEdits(doc []byte, edits []Edit) (l layout, before, after interface{}, err error) {
+	opts := UnmarshalOptions{NumberPolicy: NumberPolicyNumber}
+	if err := UnmarshalWithOptions(doc, &before, opts); err != nil {
+		return l, nil, nil, err
+	}
+	if l, err = readLayout(doc); err != nil {
+		return l, nil, nil, err
+	}
+
+	after = deepCopyValue(before)
+	for i, e := range edits {
+		if after, err = applyEdit(after, e); err != nil {
+			return l, nil, nil, &EditError{Index: i, Edit: e, Err: err}
+		}
+	}
+	return l, before, after, nil
+}
+
+// applyEdit makes e to doc, which the caller owns, and returns the result.
+func applyEdit(doc interface{}, e Edit) (interface{}, error) {
+	path, err := parsePointer(e.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.Op {
+	case EditSet:
+		value, err := editValue(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		if err := makeParents(doc, path[:len(path)-1]); err != nil {
+			return nil, err
+		}
+		parent, _ := pointerGet(doc, path[:len(path)-1])
+		if last := path[len(path)-1]; last != "-" {
+			if _, ok := parent.([]interface{}); ok {
+				return replaceAt(doc, path, value)
+			}
+		}
+		return pointerAdd(doc, path, value)
+
+	case EditDelete:
+		if len(path) == 0 {
+			return nil, errors.New("cannot delete the whole document")
+		}
+		if _, err := pointerGet(doc, path); err != nil {
+			return doc, nil
+		}
+		doc, _, err = pointerRemove(doc, path)
+		return doc, err
+
+	case EditAppend:
+		value, err := editValue(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		target, err := pointerGet(doc, path)
+		if err != nil {
+			if len(path) == 0 {
+				return nil, err
+			}
+			if err := makeParents(doc, path[:len(path)-1]); err != nil {
+				return nil, err
+			}
+			return pointerAdd(doc, path, []interface{}{value})
+		}
+		if _, ok := target.([]interface{}); !ok {
+			return nil, errors.New("value is not an array")
+		}
+		return pointerAdd(doc, append(path, "-"), value)
+
+	case EditTest:
+		value, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		want, err := editValue(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !valuesEqual(value, want, &CompareOptions{}) {
+			return nil, errors.New("test failed: values differ")
+		}
+		return doc, nil
+
+	default:
+		return nil, fmt.Errorf("unknown op %q", e.Op)
+	}
+}
+
+// makeParents adds an empty object for each member on path that doc lacks.
+// Array elements on path must exist.
+func makeParents(doc interface{}, path []string) error {
+	for _, tok := range path {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			child, ok := v[tok]
+			if !ok {
+				child = map[string]interface{}{}
+				v[tok] = child
+			}
+			doc = child
+		case []interface{}:
+			i, err := arrayIndex(tok, len(v))
+			if err != nil {
+				return err
+			}
+			doc = v[i]
+		default:
+			return fmt.Errorf("cannot index scalar with %q", tok)
+		}
+	}
+	return nil
+}
+
+// editValue converts v to a native JSON value, with numbers as Number so
+// that they are written as Marshal writes them.
+func editValue(v interface{}) (interface{}, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var native interface{}
+	err = UnmarshalWithOptions(data, &native, UnmarshalOptions{NumberPolicy: NumberPolicyNumber})
+	return native, err
+}
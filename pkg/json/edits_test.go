@@ -0,0 +1,115 @@
+package json
+
+import (
+	"errors"
+	"testing"
+)
+
+const editsConfig = "{\n  \"version\": 2,\n  \"server\": {\n    \"port\": 8080,\n    \"ratio\": 1.50\n  },\n  \"origins\": [\"a\"],\n  \"debug\": true\n}\n"
+
+func TestApplyEdits(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	got, err := ApplyEdits([]byte(editsConfig), []Edit{
+		{Op: EditTest, Path: "/version", Value: 2},
+		{Op: EditSet, Path: "/server/port", Value: 8443},
+		{Op: EditSet, Path: "/server/tls/cert", Value: "c.pem"},
+		{Op: EditAppend, Path: "/origins", Value: "b"},
+		{Op: EditAppend, Path: "/server/hosts", Value: "h"},
+		{Op: EditSet, Path: "/origins/0", Value: "a2"},
+		{Op: EditDelete, Path: "/debug"},
+		{Op: EditDelete, Path: "/missing/member"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyEdits() error = %v", err)
+	}
+	want := "{\n  \"version\": 2,\n  \"server\": {\n    \"port\": 8443,\n    \"ratio\": 1.50,\n    \"hosts\": [\n      \"h\"\n    ],\n    \"tls\": {\n      \"cert\": \"c.pem\"\n    }\n  },\n  \"origins\": [\n    \"a2\",\n    \"b\"\n  ]\n}\n"
+	if string(got) != want {
+		t.Errorf("ApplyEdits() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestApplyEdits_NoChange(t *testing.T) {
+	doc := []byte(editsConfig)
+	got, err := ApplyEdits(doc, []Edit{
+		{Op: EditSet, Path: "/version", Value: 2},
+		{Op: EditDelete, Path: "/absent"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != editsConfig {
+		t.Errorf("ApplyEdits() =\n%s\nwant the document unchanged", got)
+	}
+}
+
+func TestApplyEdits_AllOrNothing(t *testing.T) {
+	doc := []byte(editsConfig)
+	tests := []struct {
+		name  string
+		edits []Edit
+		index int
+	}{
+		{"test fails", []Edit{{Op: EditSet, Path: "/debug", Value: false}, {Op: EditTest, Path: "/version", Value: 3}}, 1},
+		{"index out of range", []Edit{{Op: EditSet, Path: "/origins/5", Value: "x"}}, 0},
+		{"append to object", []Edit{{Op: EditAppend, Path: "/server", Value: 1}}, 0},
+		{"through a scalar", []Edit{{Op: EditSet, Path: "/version/major", Value: 1}}, 0},
+		{"delete root", []Edit{{Op: EditDelete, Path: ""}}, 0},
+		{"bad pointer", []Edit{{Op: EditSet, Path: "server", Value: 1}}, 0},
+		{"unknown op", []Edit{{Op: "move", Path: "/debug"}}, 0},
+		{"unsupported value", []Edit{{Op: EditSet, Path: "/f", Value: func() {}}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyEdits(doc, tt.edits)
+			var editErr *EditError
+			if !errors.As(err, &editErr) {
+				t.Fatalf("ApplyEdits() error = %v, want *EditError", err)
+			}
+			if editErr.Index != tt.index {
+				t.Errorf("Index = %d, want %d", editErr.Index, tt.index)
+			}
+			if got != nil {
+				t.Errorf("ApplyEdits() = %s, want nil", got)
+			}
+			if string(doc) != editsConfig {
+				t.Error("input document was modified")
+			}
+		})
+	}
+
+	if _, err := ApplyEdits([]byte(`{"a":`), nil); err == nil {
+		t.Error("ApplyEdits(invalid JSON) returned no error")
+	}
+}
+
+func TestDryRunEdits(t *testing.T) {
+	patch, err := DryRunEdits([]byte(editsConfig), []Edit{
+		{Op: EditSet, Path: "/server/port", Value: 8443},
+		{Op: EditSet, Path: "/server/ratio", Value: 1.5},
+		{Op: EditDelete, Path: "/debug"},
+	})
+	if err != nil {
+		t.Fatalf("DryRunEdits() error = %v", err)
+	}
+	want := []string{"remove /debug", "replace /server/port", "replace /server/ratio"}
+	if len(patch) != len(want) {
+		t.Fatalf("DryRunEdits() = %v, want %v", patch, want)
+	}
+	for i, op := range patch {
+		if got := op.Op + " " + op.Path; got != want[i] {
+			t.Errorf("operation %d = %q, want %q", i, got, want[i])
+		}
+	}
+
+	patch, err = DryRunEdits([]byte(editsConfig), []Edit{{Op: EditSet, Path: "/version", Value: 2}})
+	if err != nil || len(patch) != 0 {
+		t.Errorf("DryRunEdits(no change) = %v, %v; want empty", patch, err)
+	}
+
+	_, err = DryRunEdits([]byte(editsConfig), []Edit{{Op: EditTest, Path: "/debug", Value: false}})
+	if err == nil || err.Error() != "json: edit 0 (test /debug): test failed: values differ" {
+		t.Errorf("DryRunEdits() error = %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package json
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-json/internal/parser"
+)
+
+// ParseElements parses a JSON array from r one element at a time, yielding
+// the AST of each element as soon as it has been read:
+//
+//	for node, err := range json.ParseElements(file) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    process(node)
+//	}
+//
+// Only the element being yielded is held in memory. The next one is not
+// read until the loop body returns, and by then the nodes of the current
+// one have been released to their pools, as ReleaseTree does, so that the
+// next element reuses them. Memory use is therefore bounded by the largest
+// element, not by the array. A node must not be used after the loop body
+// it was yielded to returns; convert what needs to be kept, for example
+// with NodeToInterface, before then.
+//
+// Positions in the yielded nodes are relative to the start of the element.
+// A syntax error, in an element or in the array around it, is yielded once
+// with a nil node and ends the iteration. Breaking out of the loop stops
+// reading r.
+func ParseElements(r io.Reader) iter.Seq2[ast.SchemaNode, error] {
+	return func(yield func(ast.SchemaNode, error) bool) {
+		er := &elementReader{r: bufio.NewReader(r), buf: getBuffer()}
+		defer putBuffer(er.buf)
+
+		if err := er.start(); err != nil {
+			yield(nil, err)
+			return
+		}
+		for {
+			more, err := er.next()
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !more {
+				return
+			}
+			node, err := parser.NewParser(er.buf.String()).Parse()
+			if err != nil {
+				yield(nil, fmt.Errorf("element at position %d: %w", er.elemPos, err))
+				return
+			}
+			more = yield(node, nil)
+			ReleaseTree(node)
+			if !more {
+				return
+			}
+		}
+	}
+}
+
+// elementReader splits the elements of a JSON array read from r, copying
+// the text of one element at a time into buf. It checks the array's own
+// syntax and finds where each element ends; the elements themselves are
+// checked by the parser.
+type elementReader struct {
+	r       *bufio.Reader
+	buf     *bytes.Buffer
+	pos     int64 // offset of the next byte, for error messages
+	elemPos int64 // input offset of the element in buf
+	first   bool  // no element has been read yet
+	done    bool  // the closing bracket has been read
+}
+
+// readByte reads one byte, reporting io.EOF as a syntax error.
+func (er *elementReader) readByte() (byte, error) {
+	c, err := er.r.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			return 0, errors.New("unexpected end of JSON input in array")
+		}
+		return 0, err
+	}
+	er.pos++
+	return c, nil
+}
+
+// skipSpace consumes whitespace and returns the next byte, which it leaves
+// unread. It returns io.EOF at the end of the input.
+func (er *elementReader) skipSpace() (byte, error) {
+	for {
+		c, err := er.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			er.pos++
+			continue
+		}
+		_ = er.r.UnreadByte()
+		return c, nil
+	}
+}
+
+// start consumes the opening bracket of the array.
+func (er *elementReader) start() error {
+	c, err := er.skipSpace()
+	if err == io.EOF {
+		return errors.New("unexpected end of JSON input")
+	}
+	if err != nil {
+		return err
+	}
+	if c != '[' {
+		return fmt.Errorf("expected '[' at position %d", er.pos)
+	}
+	er.consume()
+	er.first = true
+	return nil
+}
+
+// next reads the next element into buf. It reports false once the array
+// has ended, after checking that nothing but whitespace follows it.
+func (er *elementReader) next() (bool, error) {
+	if er.done {
+		return false, nil
+	}
+
+	// The separator before the element, or the end of an empty array
+	c, err := er.peek()
+	if err != nil {
+		return false, err
+	}
+	if er.first {
+		if c == ']' {
+			er.consume()
+			return false, er.end()
+		}
+	} else {
+		if c != ',' {
+			return false, fmt.Errorf("expected ',' or ']' in array at position %d", er.pos)
+		}
+		er.consume()
+		if c, err = er.peek(); err != nil {
+			return false, err
+		}
+		if c == ']' {
+			return false, fmt.Errorf("unexpected character ']' at position %d", er.pos)
+		}
+	}
+	er.first = false
+
+	er.buf.Reset()
+	er.elemPos = er.pos
+	if err := er.copyValue(); err != nil {
+		return false, err
+	}
+
+	// Read a closing bracket right away, so that the end of the input is
+	// checked before the last element is yielded
+	if c, err := er.skipSpace(); err == nil && c == ']' {
+		er.consume()
+		if err := er.end(); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// peek skips whitespace and returns the next byte, reporting the end of
+// the input as a syntax error.
+func (er *elementReader) peek() (byte, error) {
+	c, err := er.skipSpace()
+	if err == io.EOF {
+		return 0, errors.New("unexpected end of JSON input in array")
+	}
+	return c, err
+}
+
+// consume reads the byte returned by peek.
+func (er *elementReader) consume() {
+	_, _ = er.r.ReadByte()
+	er.pos++
+}
+
+// end records the end of the array and checks that only whitespace
+// follows it.
+func (er *elementReader) end() error {
+	er.done = true
+	if _, err := er.skipSpace(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("unexpected data after JSON value at position %d", er.pos)
+	}
+	return nil
+}
+
+// copyValue copies the text of the value at the current position into buf.
+// It tracks strings and brackets only as far as needed to find the value's
+// end; the parser checks the rest.
+func (er *elementReader) copyValue() error {
+	depth := 0
+	inString := false
+	for {
+		c, err := er.readByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case inString:
+			switch c {
+			case '\\':
+				er.buf.WriteByte(c)
+				if c, err = er.readByte(); err != nil {
+					return err
+				}
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			if depth == 0 {
+				// The end of the array after a scalar
+				_ = er.r.UnreadByte()
+				er.pos--
+				return nil
+			}
+			depth--
+		case depth == 0 && (c == ',' || c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			_ = er.r.UnreadByte()
+			er.pos--
+			return nil
+		}
+		er.buf.WriteByte(c)
+		if depth == 0 && !inString && (c == '"' || c == '}' || c == ']') {
+			return nil
+		}
+	}
+}
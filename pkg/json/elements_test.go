@@ -0,0 +1,86 @@
+package json
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseElements(t *testing.T) {
+	input := ` [ {"id": 1, "tags": ["a", "]"]}, "x,y", -1.5e3, true, null, [[]], {"s": "\"}"} ] `
+
+	var got []interface{}
+	for node, err := range ParseElements(iotest.OneByteReader(strings.NewReader(input))) {
+		if err != nil {
+			t.Fatalf("ParseElements() error = %v", err)
+		}
+		got = append(got, NodeToInterface(node))
+	}
+
+	node, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NodeToInterface(node); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseElements() = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseElements_Empty(t *testing.T) {
+	for node, err := range ParseElements(strings.NewReader(" [ ] ")) {
+		t.Errorf("ParseElements() yielded %v, %v", node, err)
+	}
+}
+
+func TestParseElements_Break(t *testing.T) {
+	// Iteration stops at the break, before the malformed element is read
+	n := 0
+	for _, err := range ParseElements(strings.NewReader(`[1, 2, {oops`)) {
+		if err != nil {
+			t.Fatalf("ParseElements() error = %v", err)
+		}
+		if n++; n == 2 {
+			break
+		}
+	}
+	if n != 2 {
+		t.Errorf("got %d elements, want 2", n)
+	}
+}
+
+func TestParseElements_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		yielded int // elements yielded before the error
+		errMsg  string
+	}{
+		{"empty input", ``, 0, "unexpected end of JSON input"},
+		{"not an array", `{"a": 1}`, 0, "expected '['"},
+		{"unterminated", `[1, 2`, 1, "unexpected end of JSON input in array"},
+		{"trailing comma", `[1,]`, 1, "unexpected character ']'"},
+		{"missing comma", `[1 2]`, 1, "expected ',' or ']'"},
+		{"bad element", `[1, {"a" 1}]`, 1, "element at position 4"},
+		{"trailing data", `[1] 2`, 0, "unexpected data after JSON value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			yielded := 0
+			var last error
+			for _, err := range ParseElements(strings.NewReader(tt.input)) {
+				if err != nil {
+					last = err
+					continue
+				}
+				yielded++
+			}
+			if last == nil || !strings.Contains(last.Error(), tt.errMsg) {
+				t.Fatalf("ParseElements() error = %v, want containing %q", last, tt.errMsg)
+			}
+			if yielded != tt.yielded {
+				t.Errorf("yielded %d elements before the error, want %d", yielded, tt.yielded)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 // An Encoder writes JSON values to an output stream.
 type Encoder struct {
 	w io.Writer
+
+	maxSize int64 // see SetMaxOutputSize; 0 is no limit
 }
 
 // NewEncoder returns a new encoder that writes to w.
@@ -14,12 +16,20 @@ func NewEncoder(w io.Writer) *Encoder {
 	return &Encoder{w: w}
 }
 
+// SetMaxOutputSize limits the encoding of each value written by Encode to
+// n bytes. Encode returns a *LimitError, having written nothing, for a
+// value whose encoding would be longer, and stops encoding it soon after
+// the limit is passed. Zero, the default, means no limit.
+func (enc *Encoder) SetMaxOutputSize(n int64) {
+	enc.maxSize = n
+}
+
 // Encode writes the JSON encoding of v to the stream, followed by a newline character.
 //
 // See the documentation for Marshal for details about the conversion of Go values to JSON.
 func (enc *Encoder) Encode(v interface{}) error {
 	// Marshal the value
-	data, err := Marshal(v)
+	data, err := MarshalWithOptions(v, MarshalOptions{MaxOutputSize: enc.maxSize})
 	if err != nil {
 		return err
 	}
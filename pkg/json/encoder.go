@@ -1,6 +1,9 @@
+//go:build !tinygo
+
 package json
 
 import (
+	"database/sql/driver"
 	"fmt"
 	"reflect"
 	"sort"
@@ -8,10 +11,88 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
 )
 
 // encoderFunc appends the JSON encoding of rv to buf, returning the extended buffer.
-type encoderFunc func(buf []byte, rv reflect.Value) ([]byte, error)
+type encoderFunc func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error)
+
+// encodeState carries per-call settings through the compiled encoders.
+// Encoders are cached per type and shared by every Marshal call, so anything
+// that can differ between calls (see MarshalOptions) must be read from here
+// rather than captured at build time.
+type encodeState struct {
+	transforms      map[string]Transformer
+	keyOrder        KeyOrder
+	keyLess         func(a, b string) bool
+	unsortedMaps    bool
+	omitEmptyNested bool
+	valuers         bool
+	int64AsString   bool
+	numbers         *NumberFormat
+	types           *TypeRegistry
+	stdlib          bool  // see StrictStdlib
+	maxSize         int64 // see MaxOutputSize; 0 is no limit
+
+	// Per-call state, set on the copy each call makes (see acquire)
+	ptrLevel uint               // pointers, maps, and slices being encoded
+	ptrSeen  map[visit]struct{} // those past startDetectingCyclesAfter
+}
+
+// encodeState returns the encoder state for these options.
+func (o MarshalOptions) encodeState() *encodeState {
+	return &encodeState{
+		transforms:      o.Transforms,
+		keyOrder:        o.KeyOrder,
+		keyLess:         o.KeyLess,
+		unsortedMaps:    o.UnsortedMaps,
+		omitEmptyNested: o.OmitEmptyNested,
+		valuers:         o.Valuers,
+		int64AsString:   o.Int64AsString,
+		numbers:         o.Numbers,
+		types:           o.Types,
+		stdlib:          o.Compatibility == StrictStdlib,
+		maxSize:         o.MaxOutputSize,
+	}
+}
+
+// typeSwitchOK reports whether appendInterface, which writes map keys
+// sorted or in iteration order and numbers in their default form, produces
+// the output these settings ask for.
+func (e *encodeState) typeSwitchOK() bool {
+	return e.keyLess == nil && !e.int64AsString && e.numbers == nil && e.types == nil && !e.stdlib && e.maxSize == 0
+}
+
+// checkSize reports a *LimitError if buf, the output so far, is longer
+// than MaxOutputSize allows. The encoders call it after each member and
+// element, so an oversized value fails before much more is written.
+func (e *encodeState) checkSize(buf []byte) error {
+	if e.maxSize > 0 && int64(len(buf)) > e.maxSize {
+		return &LimitError{Limit: "output size", Max: e.maxSize, Offset: e.maxSize}
+	}
+	return nil
+}
+
+// appendString appends s escaped, without the surrounding quotes, as
+// encoding/json escapes it under StrictStdlib.
+func (e *encodeState) appendString(buf []byte, s string) []byte {
+	if e.stdlib {
+		return appendStdlibString(buf, s)
+	}
+	return appendEscapedString(buf, s)
+}
+
+// less orders object keys by keyLess, or by byte order if it is nil.
+func (e *encodeState) less(a, b string) bool {
+	if e.keyLess != nil {
+		return e.keyLess(a, b)
+	}
+	return a < b
+}
+
+// defaultEncodeState is used by Marshal. It must never be modified.
+var defaultEncodeState = &encodeState{}
 
 // encoderCache stores compiled encoders keyed by reflect.Type.
 // Uses copy-on-write map behind atomic.Value for lock-free reads.
@@ -25,6 +106,7 @@ func init() {
 // Pre-computed reflect types for special handling.
 var (
 	marshalerType = reflect.TypeOf((*Marshaler)(nil)).Elem()
+	valuerType    = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
 	timeType      = reflect.TypeOf(time.Time{})
 	durationType  = reflect.TypeOf(time.Duration(0))
 )
@@ -52,9 +134,9 @@ func encoderForType(t reflect.Type) encoderFunc {
 	var wg sync.WaitGroup
 	wg.Add(1)
 	var realEnc encoderFunc
-	placeholder := func(buf []byte, rv reflect.Value) ([]byte, error) {
+	placeholder := func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 		wg.Wait()
-		return realEnc(buf, rv)
+		return realEnc(e, buf, rv)
 	}
 
 	// Store placeholder and release lock before building
@@ -67,7 +149,7 @@ func encoderForType(t reflect.Type) encoderFunc {
 	encoderMu.Unlock()
 
 	// Build the real encoder (may recursively call encoderForType for sub-types)
-	realEnc = buildEncoder(t)
+	realEnc = wrapTypeOverride(t, buildEncoder(t))
 
 	// Replace placeholder with real encoder
 	encoderMu.Lock()
@@ -94,7 +176,19 @@ func buildEncoder(t reflect.Type) encoderFunc {
 	if t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(marshalerType) {
 		return buildAddrMarshalerEnc(t)
 	}
+	// encoding.TextMarshaler is used under StrictStdlib only
+	if t.Implements(textMarshalerType) {
+		return buildTextMarshalerEnc(buildPlainEncoder(t), false)
+	}
+	if t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(textMarshalerType) {
+		return buildTextMarshalerEnc(buildPlainEncoder(t), true)
+	}
+	return buildPlainEncoder(t)
+}
 
+// buildPlainEncoder creates an encoder for the given type, which does not
+// marshal itself.
+func buildPlainEncoder(t reflect.Type) encoderFunc {
 	// Special types
 	if t == timeType {
 		return timeEnc
@@ -102,7 +196,20 @@ func buildEncoder(t reflect.Type) encoderFunc {
 	if t == durationType {
 		return durationEnc
 	}
+	if t == fastparser.NumberType {
+		return numberEnc
+	}
+	if fastparser.IsSQLNull(t) {
+		return buildSQLNullEncoder(t)
+	}
+	if t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface && t.Implements(valuerType) {
+		return buildValuerEncoder(buildKindEncoder(t))
+	}
+	return buildKindEncoder(t)
+}
 
+// buildKindEncoder creates an encoder for the given type by its kind alone.
+func buildKindEncoder(t reflect.Type) encoderFunc {
 	switch t.Kind() {
 	case reflect.Ptr:
 		return buildPtrEncoder(t)
@@ -112,9 +219,13 @@ func buildEncoder(t reflect.Type) encoderFunc {
 		return stringEnc
 	case reflect.Bool:
 		return boolEnc
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Int, reflect.Int64:
+		return wideIntEnc
+	case reflect.Int8, reflect.Int16, reflect.Int32:
 		return intEnc
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	case reflect.Uint, reflect.Uint64:
+		return wideUintEnc
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
 		return uintEnc
 	case reflect.Float32:
 		return float32Enc
@@ -137,32 +248,65 @@ func buildEncoder(t reflect.Type) encoderFunc {
 // Primitive Encoders (zero allocation)
 // ================================
 
-func boolEnc(buf []byte, rv reflect.Value) ([]byte, error) {
+func boolEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 	if rv.Bool() {
 		return append(buf, "true"...), nil
 	}
 	return append(buf, "false"...), nil
 }
 
-func intEnc(buf []byte, rv reflect.Value) ([]byte, error) {
+func intEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 	return strconv.AppendInt(buf, rv.Int(), 10), nil
 }
 
-func uintEnc(buf []byte, rv reflect.Value) ([]byte, error) {
+func uintEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 	return strconv.AppendUint(buf, rv.Uint(), 10), nil
 }
 
-func float32Enc(buf []byte, rv reflect.Value) ([]byte, error) {
+// wideIntEnc encodes 64-bit signed integers, quoting them under
+// MarshalOptions.Int64AsString.
+func wideIntEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+	if e.int64AsString {
+		buf = append(buf, '"')
+		buf = strconv.AppendInt(buf, rv.Int(), 10)
+		return append(buf, '"'), nil
+	}
+	return strconv.AppendInt(buf, rv.Int(), 10), nil
+}
+
+// wideUintEnc is wideIntEnc for unsigned integers.
+func wideUintEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+	if e.int64AsString {
+		buf = append(buf, '"')
+		buf = strconv.AppendUint(buf, rv.Uint(), 10)
+		return append(buf, '"'), nil
+	}
+	return strconv.AppendUint(buf, rv.Uint(), 10), nil
+}
+
+func float32Enc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+	if e.numbers != nil {
+		return e.numbers.appendFloat(buf, rv.Float(), 32), nil
+	}
+	if e.stdlib {
+		return appendStdlibFloat(buf, rv.Float(), 32)
+	}
 	return strconv.AppendFloat(buf, rv.Float(), 'g', -1, 32), nil
 }
 
-func float64Enc(buf []byte, rv reflect.Value) ([]byte, error) {
+func float64Enc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+	if e.numbers != nil {
+		return e.numbers.appendFloat(buf, rv.Float(), 64), nil
+	}
+	if e.stdlib {
+		return appendStdlibFloat(buf, rv.Float(), 64)
+	}
 	return strconv.AppendFloat(buf, rv.Float(), 'g', -1, 64), nil
 }
 
-func stringEnc(buf []byte, rv reflect.Value) ([]byte, error) {
+func stringEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 	buf = append(buf, '"')
-	buf = appendEscapedString(buf, rv.String())
+	buf = e.appendString(buf, rv.String())
 	buf = append(buf, '"')
 	return buf, nil
 }
@@ -171,7 +315,7 @@ func stringEnc(buf []byte, rv reflect.Value) ([]byte, error) {
 // Special Type Encoders
 // ================================
 
-func timeEnc(buf []byte, rv reflect.Value) ([]byte, error) {
+func timeEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 	t := rv.Interface().(time.Time)
 	buf = append(buf, '"')
 	buf = t.AppendFormat(buf, time.RFC3339Nano)
@@ -179,8 +323,11 @@ func timeEnc(buf []byte, rv reflect.Value) ([]byte, error) {
 	return buf, nil
 }
 
-func durationEnc(buf []byte, rv reflect.Value) ([]byte, error) {
+func durationEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 	d := time.Duration(rv.Int())
+	if e.stdlib {
+		return strconv.AppendInt(buf, int64(d), 10), nil
+	}
 	buf = append(buf, '"')
 	buf = appendISO8601Duration(buf, d)
 	buf = append(buf, '"')
@@ -191,7 +338,7 @@ func durationEnc(buf []byte, rv reflect.Value) ([]byte, error) {
 // Marshaler Interface Encoders
 // ================================
 
-func marshalerEnc(buf []byte, rv reflect.Value) ([]byte, error) {
+func marshalerEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 	if rv.Kind() == reflect.Ptr && rv.IsNil() {
 		return append(buf, "null"...), nil
 	}
@@ -200,22 +347,63 @@ func marshalerEnc(buf []byte, rv reflect.Value) ([]byte, error) {
 	if err != nil {
 		return buf, err
 	}
+	if e.stdlib {
+		return appendStdlibMarshaled(buf, b, rv.Type())
+	}
 	return append(buf, b...), nil
 }
 
 func buildAddrMarshalerEnc(t reflect.Type) encoderFunc {
 	// Fallback encoder for when we can't take address
 	fallback := buildEncoderNoMarshaler(t)
-	return func(buf []byte, rv reflect.Value) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 		if rv.CanAddr() {
 			m := rv.Addr().Interface().(Marshaler)
 			b, err := m.MarshalJSON()
 			if err != nil {
 				return buf, err
 			}
+			if e.stdlib {
+				return appendStdlibMarshaled(buf, b, rv.Addr().Type())
+			}
 			return append(buf, b...), nil
 		}
-		return fallback(buf, rv)
+		return fallback(e, buf, rv)
+	}
+}
+
+// buildSQLNullEncoder encodes the nullable types of database/sql, such as
+// sql.NullString, as their value or as null when it is not Valid.
+func buildSQLNullEncoder(t reflect.Type) encoderFunc {
+	valueEnc := encoderForType(t.Field(0).Type)
+	// encoding/json encodes them as the structs they are
+	plain := buildStructEncoder(t)
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+		if e.stdlib {
+			return plain(e, buf, rv)
+		}
+		if !rv.Field(1).Bool() {
+			return append(buf, "null"...), nil
+		}
+		return valueEnc(e, buf, rv.Field(0))
+	}
+}
+
+// buildValuerEncoder encodes a driver.Valuer as the value it returns when
+// MarshalOptions.Valuers is set, and with plain otherwise.
+func buildValuerEncoder(plain encoderFunc) encoderFunc {
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+		if !e.valuers {
+			return plain(e, buf, rv)
+		}
+		v, err := rv.Interface().(driver.Valuer).Value()
+		if err != nil {
+			return buf, err
+		}
+		if v == nil {
+			return append(buf, "null"...), nil
+		}
+		return encoderForType(reflect.TypeOf(v))(e, buf, reflect.ValueOf(v))
 	}
 }
 
@@ -234,9 +422,13 @@ func buildEncoderNoMarshaler(t reflect.Type) encoderFunc {
 		return stringEnc
 	case reflect.Bool:
 		return boolEnc
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Int, reflect.Int64:
+		return wideIntEnc
+	case reflect.Int8, reflect.Int16, reflect.Int32:
 		return intEnc
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	case reflect.Uint, reflect.Uint64:
+		return wideUintEnc
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
 		return uintEnc
 	case reflect.Float32:
 		return float32Enc
@@ -253,25 +445,36 @@ func buildEncoderNoMarshaler(t reflect.Type) encoderFunc {
 
 func buildPtrEncoder(t reflect.Type) encoderFunc {
 	elemEnc := encoderForType(t.Elem())
-	return func(buf []byte, rv reflect.Value) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 		if rv.IsNil() {
 			return append(buf, "null"...), nil
 		}
-		return elemEnc(buf, rv.Elem())
+		if err := e.enter(rv); err != nil {
+			return buf, err
+		}
+		buf, err := elemEnc(e, buf, rv.Elem())
+		e.leave(rv, err)
+		return buf, err
 	}
 }
 
-func interfaceEnc(buf []byte, rv reflect.Value) ([]byte, error) {
+func interfaceEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 	if rv.IsNil() {
 		return append(buf, "null"...), nil
 	}
+	if !e.typeSwitchOK() || e.ptrLevel >= startDetectingCyclesAfter {
+		elem := rv.Elem()
+		return encoderForType(elem.Type())(e, buf, elem)
+	}
 	// Try the fast path (type switch) before falling back to reflect
 	v := rv.Interface()
-	buf, err := appendInterface(buf, v)
+	start := len(buf)
+	buf, err := appendInterface(buf, v, !e.unsortedMaps, e.ptrLevel)
 	if err == errNeedReflect {
+		buf = buf[:start]
 		elem := rv.Elem()
 		enc := encoderForType(elem.Type())
-		return enc(buf, elem)
+		return enc(e, buf, elem)
 	}
 	return buf, err
 }
@@ -282,15 +485,88 @@ func interfaceEnc(buf []byte, rv reflect.Value) ([]byte, error) {
 
 // structField holds pre-computed info for a single struct field.
 type structField struct {
-	index     int                      // field index in struct
-	nameBytes []byte                   // pre-encoded `"fieldName":` including quotes and colon
-	encoder   encoderFunc              // pre-resolved encoder for this field's type
-	omitEmpty bool                     // whether to skip empty values
-	emptyFn   func(reflect.Value) bool // pre-resolved empty checker (nil if !omitEmpty)
+	index      int                      // field index in struct
+	path       []int                    // index sequence of a field promoted under StrictStdlib
+	name       string                   // JSON member name
+	nameBytes  []byte                   // pre-encoded `"fieldName":` including quotes and colon
+	stdName    []byte                   // nameBytes as escaped under StrictStdlib
+	encoder    encoderFunc              // pre-resolved encoder for this field's type
+	omitEmpty  bool                     // whether to skip empty values
+	emptyFn    func(reflect.Value) bool // pre-resolved empty checker (nil if !omitEmpty)
+	stdEmptyFn func(reflect.Value) bool // emptyFn under StrictStdlib
+}
+
+// newStructField pre-computes the field sf, at index i of its struct.
+func newStructField(i int, sf reflect.StructField, info fieldInfo) structField {
+	// Pre-encode the JSON key: "fieldName":
+	nameBytes := make([]byte, 0, len(info.name)+4)
+	nameBytes = append(nameBytes, '"')
+	nameBytes = appendEscapedString(nameBytes, info.name)
+	nameBytes = append(nameBytes, '"', ':')
+	stdName := append(appendStdlibString([]byte{'"'}, info.name), '"', ':')
+
+	enc := encoderForType(sf.Type)
+	if info.asString {
+		if sf.Type.Kind() == reflect.Ptr {
+			enc = buildPtrStringEncoder(enc, sf.Type.Elem().Kind())
+		} else {
+			enc = wrapStringEncoder(enc, sf.Type.Kind())
+		}
+	}
+	if info.transform != "" {
+		enc = wrapTransformEncoder(enc, info.transform, info.name)
+	}
+
+	f := structField{
+		index:     i,
+		name:      info.name,
+		nameBytes: nameBytes,
+		stdName:   stdName,
+		encoder:   enc,
+		omitEmpty: info.omitEmpty,
+	}
+
+	if info.omitEmpty {
+		f.emptyFn = emptyFuncForKind(sf.Type)
+		f.stdEmptyFn = f.emptyFn
+		if fastparser.IsSQLNull(sf.Type) {
+			// encoding/json never omits a struct
+			f.stdEmptyFn = func(reflect.Value) bool { return false }
+		}
+	}
+	return f
+}
+
+// value returns the field f of the struct rv, and false if f is promoted
+// through an embedded pointer that is nil, which leaves it out.
+func (f *structField) value(rv reflect.Value) (reflect.Value, bool) {
+	if f.path == nil {
+		return rv.Field(f.index), true
+	}
+	for _, i := range f.path[:len(f.path)-1] {
+		rv = rv.Field(i)
+		if rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+	}
+	return rv.Field(f.index), true
+}
+
+// sortFields returns a copy of fields sorted by name, for KeyOrderSorted.
+func sortFields(fields []structField) []structField {
+	sorted := append([]structField(nil), fields...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].name < sorted[j].name
+	})
+	return sorted
 }
 
 func buildStructEncoder(t reflect.Type) encoderFunc {
 	var fields []structField
+	var extra *unknownMembers
 
 	for i := 0; i < t.NumField(); i++ {
 		sf := t.Field(i)
@@ -302,58 +578,99 @@ func buildStructEncoder(t reflect.Type) encoderFunc {
 		if info.skip {
 			continue
 		}
+		if info.unknown {
+			extra = &unknownMembers{index: i, encoder: encoderForType(sf.Type.Elem())}
+			continue
+		}
+		fields = append(fields, newStructField(i, sf, info))
+	}
 
-		// Pre-encode the JSON key: "fieldName":
-		nameBytes := make([]byte, 0, len(info.name)+4)
-		nameBytes = append(nameBytes, '"')
-		nameBytes = appendEscapedString(nameBytes, info.name)
-		nameBytes = append(nameBytes, '"', ':')
+	// fields is in declaration order; sort a copy by name ONCE at build time
+	// for KeyOrderSorted
+	sorted := sortFields(fields)
 
-		enc := encoderForType(sf.Type)
-		if info.asString {
-			enc = wrapStringEncoder(enc, sf.Type.Kind())
-		}
+	// Under StrictStdlib, the fields of embedded structs are promoted
+	stdFields, stdSorted := fields, sorted
+	if fastparser.HasEmbedded(t) {
+		stdFields = stdlibStructFields(t)
+		stdSorted = sortFields(stdFields)
+	}
 
-		f := structField{
-			index:     i,
-			nameBytes: nameBytes,
-			encoder:   enc,
-			omitEmpty: info.omitEmpty,
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+		own, ownSorted := fields, sorted
+		if e.stdlib {
+			own, ownSorted = stdFields, stdSorted
 		}
-
-		if info.omitEmpty {
-			f.emptyFn = emptyFuncForKind(sf.Type)
+		order := own
+		switch {
+		case e.keyLess != nil:
+			order = append([]structField(nil), own...)
+			sort.SliceStable(order, func(i, j int) bool {
+				return e.keyLess(order[i].name, order[j].name)
+			})
+		case e.keyOrder == KeyOrderSorted:
+			order = ownSorted
 		}
 
-		fields = append(fields, f)
-	}
-
-	// Sort fields by name ONCE at build time
-	sort.Slice(fields, func(i, j int) bool {
-		return string(fields[i].nameBytes) < string(fields[j].nameBytes)
-	})
+		var keys []reflect.Value
+		if extra != nil {
+			keys = extra.keys(e, rv, own)
+		}
 
-	return func(buf []byte, rv reflect.Value) ([]byte, error) {
 		buf = append(buf, '{')
 		first := true
-		for i := range fields {
-			f := &fields[i]
-			fv := rv.Field(f.index)
+		for i := range order {
+			f := &order[i]
+			fv, ok := f.value(rv)
+			if !ok {
+				continue
+			}
 
-			if f.omitEmpty && f.emptyFn(fv) {
+			empty := f.emptyFn
+			if e.stdlib {
+				empty = f.stdEmptyFn
+			}
+			if f.omitEmpty && (empty(fv) || e.omitEmptyNested && isNestedEmpty(fv)) {
 				continue
 			}
 
+			// With a key order, unknown members go among the fields
+			var err error
+			if len(keys) > 0 && (e.keyLess != nil || e.keyOrder == KeyOrderSorted) {
+				n := 0
+				for n < len(keys) && e.less(keys[n].String(), f.name) {
+					n++
+				}
+				if buf, err = extra.append(e, buf, rv, keys[:n], first); err != nil {
+					return buf, err
+				}
+				first = first && n == 0
+				keys = keys[n:]
+			}
+
 			if !first {
 				buf = append(buf, ',')
 			}
 			first = false
 
-			buf = append(buf, f.nameBytes...)
+			if e.stdlib {
+				buf = append(buf, f.stdName...)
+			} else {
+				buf = append(buf, f.nameBytes...)
+			}
 
-			var err error
-			buf, err = f.encoder(buf, fv)
+			buf, err = f.encoder(e, buf, fv)
 			if err != nil {
+				cycleMember(err, f.name)
+				return buf, err
+			}
+			if err := e.checkSize(buf); err != nil {
+				return buf, err
+			}
+		}
+		if len(keys) > 0 {
+			var err error
+			if buf, err = extra.append(e, buf, rv, keys, first); err != nil {
 				return buf, err
 			}
 		}
@@ -362,8 +679,80 @@ func buildStructEncoder(t reflect.Type) encoderFunc {
 	}
 }
 
+// unknownMembers describes the field of a struct with the "unknown" option,
+// a map whose entries are written as members of the struct's object.
+type unknownMembers struct {
+	index   int         // field index in struct
+	encoder encoderFunc // encoder for the map's values
+}
+
+// keys returns the keys of the map in rv's field that name none of fields,
+// in the order of e.
+func (u *unknownMembers) keys(e *encodeState, rv reflect.Value, fields []structField) []reflect.Value {
+	m := rv.Field(u.index)
+	if m.Len() == 0 {
+		return nil
+	}
+	keys := make([]reflect.Value, 0, m.Len())
+	for _, key := range m.MapKeys() {
+		if !hasField(fields, key.String()) {
+			keys = append(keys, key)
+		}
+	}
+	if e.keyLess != nil {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return e.keyLess(keys[i].String(), keys[j].String())
+		})
+	} else {
+		sortReflectStringKeys(keys)
+	}
+	return keys
+}
+
+// append writes the entries of the map in rv's field for keys as members,
+// preceding the first with a comma unless first is set.
+func (u *unknownMembers) append(e *encodeState, buf []byte, rv reflect.Value, keys []reflect.Value, first bool) ([]byte, error) {
+	m := rv.Field(u.index)
+	for _, key := range keys {
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = append(buf, '"')
+		buf = e.appendString(buf, key.String())
+		buf = append(buf, '"', ':')
+
+		var err error
+		buf, err = u.encoder(e, buf, m.MapIndex(key))
+		if err != nil {
+			cycleMember(err, key.String())
+			return buf, err
+		}
+		if err := e.checkSize(buf); err != nil {
+			return buf, err
+		}
+	}
+	return buf, nil
+}
+
+// hasField reports whether one of fields is named name.
+func hasField(fields []structField, name string) bool {
+	for i := range fields {
+		if fields[i].name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // emptyFuncForKind returns a specialized empty checker for the given type.
 func emptyFuncForKind(t reflect.Type) func(reflect.Value) bool {
+	if t.Kind() == reflect.Struct && t.Implements(absenterType) {
+		return func(v reflect.Value) bool { return v.Interface().(absenter).isAbsent() }
+	}
+	if fastparser.IsSQLNull(t) {
+		return func(v reflect.Value) bool { return !v.Field(1).Bool() }
+	}
 	switch t.Kind() {
 	case reflect.Bool:
 		return func(v reflect.Value) bool { return !v.Bool() }
@@ -388,35 +777,39 @@ func emptyFuncForKind(t reflect.Type) func(reflect.Value) bool {
 func wrapStringEncoder(inner encoderFunc, kind reflect.Kind) encoderFunc {
 	switch kind {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return func(buf []byte, rv reflect.Value) ([]byte, error) {
+		return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 			buf = append(buf, '"')
 			buf = strconv.AppendInt(buf, rv.Int(), 10)
 			buf = append(buf, '"')
 			return buf, nil
 		}
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return func(buf []byte, rv reflect.Value) ([]byte, error) {
+		return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 			buf = append(buf, '"')
 			buf = strconv.AppendUint(buf, rv.Uint(), 10)
 			buf = append(buf, '"')
 			return buf, nil
 		}
-	case reflect.Float32:
-		return func(buf []byte, rv reflect.Value) ([]byte, error) {
-			buf = append(buf, '"')
-			buf = strconv.AppendFloat(buf, rv.Float(), 'g', -1, 32)
-			buf = append(buf, '"')
-			return buf, nil
+	case reflect.Float32, reflect.Float64:
+		bits := 64
+		if kind == reflect.Float32 {
+			bits = 32
 		}
-	case reflect.Float64:
-		return func(buf []byte, rv reflect.Value) ([]byte, error) {
+		return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 			buf = append(buf, '"')
-			buf = strconv.AppendFloat(buf, rv.Float(), 'g', -1, 64)
+			if e.stdlib {
+				var err error
+				if buf, err = appendStdlibFloat(buf, rv.Float(), bits); err != nil {
+					return buf, err
+				}
+			} else {
+				buf = strconv.AppendFloat(buf, rv.Float(), 'g', -1, bits)
+			}
 			buf = append(buf, '"')
 			return buf, nil
 		}
 	case reflect.Bool:
-		return func(buf []byte, rv reflect.Value) ([]byte, error) {
+		return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 			if rv.Bool() {
 				return append(buf, `"true"`...), nil
 			}
@@ -427,19 +820,32 @@ func wrapStringEncoder(inner encoderFunc, kind reflect.Kind) encoderFunc {
 	}
 }
 
+// buildPtrStringEncoder applies the string option to the value a pointer
+// field points to.
+func buildPtrStringEncoder(inner encoderFunc, elemKind reflect.Kind) encoderFunc {
+	elemEnc := wrapStringEncoder(nil, elemKind)
+	if elemEnc == nil {
+		return inner
+	}
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+		if rv.IsNil() {
+			return append(buf, "null"...), nil
+		}
+		return elemEnc(e, buf, rv.Elem())
+	}
+}
+
 // ================================
 // Map Encoder
 // ================================
 
 func buildMapEncoder(t reflect.Type) encoderFunc {
 	if t.Key().Kind() != reflect.String {
-		return func(buf []byte, rv reflect.Value) ([]byte, error) {
-			return buf, fmt.Errorf("json: unsupported map key type %s", t.Key())
-		}
+		return buildStdlibMapEncoder(t)
 	}
 	valEnc := encoderForType(t.Elem())
 
-	return func(buf []byte, rv reflect.Value) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 		if rv.IsNil() {
 			return append(buf, "null"...), nil
 		}
@@ -452,27 +858,79 @@ func buildMapEncoder(t reflect.Type) encoderFunc {
 			return buf, nil
 		}
 
-		keys := rv.MapKeys()
-		sortReflectStringKeys(keys)
+		if err := e.enter(rv); err != nil {
+			return buf, err
+		}
+		buf, err := appendMap(e, buf, rv, valEnc)
+		e.leave(rv, err)
+		return buf, err
+	}
+}
 
-		for i, key := range keys {
-			if i > 0 {
-				buf = append(buf, ',')
-			}
-			buf = append(buf, '"')
-			buf = appendEscapedString(buf, key.String())
-			buf = append(buf, '"', ':')
+// appendMap writes the members of the non-empty map rv, whose opening
+// brace has been written, in the order of e.
+func appendMap(e *encodeState, buf []byte, rv reflect.Value, valEnc encoderFunc) ([]byte, error) {
+	if e.unsortedMaps && e.keyLess == nil {
+		return appendMapUnsorted(e, buf, rv, valEnc)
+	}
 
-			var err error
-			buf, err = valEnc(buf, rv.MapIndex(key))
-			if err != nil {
-				return buf, err
-			}
+	keys := rv.MapKeys()
+	if e.keyLess != nil {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return e.keyLess(keys[i].String(), keys[j].String())
+		})
+	} else {
+		sortReflectStringKeys(keys)
+	}
+
+	for i, key := range keys {
+		if i > 0 {
+			buf = append(buf, ',')
 		}
+		buf = append(buf, '"')
+		buf = e.appendString(buf, key.String())
+		buf = append(buf, '"', ':')
+
+		var err error
+		buf, err = valEnc(e, buf, rv.MapIndex(key))
+		if err != nil {
+			cycleMember(err, key.String())
+			return buf, err
+		}
+		if err := e.checkSize(buf); err != nil {
+			return buf, err
+		}
+	}
 
-		buf = append(buf, '}')
-		return buf, nil
+	buf = append(buf, '}')
+	return buf, nil
+}
+
+// appendMapUnsorted writes the members of the non-empty map rv, whose
+// opening brace has been written, in iteration order.
+func appendMapUnsorted(e *encodeState, buf []byte, rv reflect.Value, valEnc encoderFunc) ([]byte, error) {
+	iter := rv.MapRange()
+	first := true
+	for iter.Next() {
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = append(buf, '"')
+		buf = e.appendString(buf, iter.Key().String())
+		buf = append(buf, '"', ':')
+
+		var err error
+		buf, err = valEnc(e, buf, iter.Value())
+		if err != nil {
+			cycleMember(err, iter.Key().String())
+			return buf, err
+		}
+		if err := e.checkSize(buf); err != nil {
+			return buf, err
+		}
 	}
+	return append(buf, '}'), nil
 }
 
 // ================================
@@ -481,48 +939,53 @@ func buildMapEncoder(t reflect.Type) encoderFunc {
 
 func buildSliceEncoder(t reflect.Type) encoderFunc {
 	elemEnc := encoderForType(t.Elem())
+	// encoding/json writes byte slices as base64, unless their elements
+	// marshal themselves
+	bytesAsBase64 := t.Elem().Kind() == reflect.Uint8 && !reflect.PointerTo(t.Elem()).Implements(marshalerType)
 
-	return func(buf []byte, rv reflect.Value) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+		if e.stdlib && bytesAsBase64 {
+			return appendStdlibBytes(buf, rv.Bytes()), nil
+		}
 		if rv.IsNil() {
 			return append(buf, "null"...), nil
 		}
-
-		buf = append(buf, '[')
-		n := rv.Len()
-		for i := 0; i < n; i++ {
-			if i > 0 {
-				buf = append(buf, ',')
-			}
-			var err error
-			buf, err = elemEnc(buf, rv.Index(i))
-			if err != nil {
-				return buf, err
-			}
+		if err := e.enter(rv); err != nil {
+			return buf, err
 		}
-		buf = append(buf, ']')
-		return buf, nil
+		buf, err := appendElements(e, buf, rv, elemEnc)
+		e.leave(rv, err)
+		return buf, err
 	}
 }
 
 func buildArrayEncoder(t reflect.Type) encoderFunc {
 	elemEnc := encoderForType(t.Elem())
 
-	return func(buf []byte, rv reflect.Value) ([]byte, error) {
-		buf = append(buf, '[')
-		n := rv.Len()
-		for i := 0; i < n; i++ {
-			if i > 0 {
-				buf = append(buf, ',')
-			}
-			var err error
-			buf, err = elemEnc(buf, rv.Index(i))
-			if err != nil {
-				return buf, err
-			}
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+		return appendElements(e, buf, rv, elemEnc)
+	}
+}
+
+// appendElements writes the slice or array rv as a JSON array.
+func appendElements(e *encodeState, buf []byte, rv reflect.Value, elemEnc encoderFunc) ([]byte, error) {
+	buf = append(buf, '[')
+	n := rv.Len()
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		var err error
+		buf, err = elemEnc(e, buf, rv.Index(i))
+		if err != nil {
+			cycleIndex(err, i)
+			return buf, err
+		}
+		if err := e.checkSize(buf); err != nil {
+			return buf, err
 		}
-		buf = append(buf, ']')
-		return buf, nil
 	}
+	return append(buf, ']'), nil
 }
 
 // ================================
@@ -530,7 +993,45 @@ func buildArrayEncoder(t reflect.Type) encoderFunc {
 // ================================
 
 func unsupportedEnc(t reflect.Type) encoderFunc {
-	return func(buf []byte, rv reflect.Value) ([]byte, error) {
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
 		return buf, fmt.Errorf("json: unsupported type %s", t)
 	}
 }
+
+// numberEnc writes a Number as the number it holds, and an empty one as 0.
+func numberEnc(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+	s := rv.String()
+	if s == "" {
+		return append(buf, '0'), nil
+	}
+	if !fastparser.IsNumber(s) {
+		return buf, fmt.Errorf("json: invalid number literal %q", s)
+	}
+	return append(buf, s...), nil
+}
+
+// wrapTransformEncoder wraps an encoder so that its output is passed through
+// the named transformer from the encodeState.
+func wrapTransformEncoder(inner encoderFunc, name, field string) encoderFunc {
+	return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+		t, ok := e.transforms[name]
+		if !ok {
+			return buf, fmt.Errorf("json: field %q requires transform %q, which is not registered", field, name)
+		}
+
+		start := len(buf)
+		buf, err := inner(e, buf, rv)
+		if err != nil {
+			return buf, err
+		}
+
+		out, err := t.Encode(field, buf[start:len(buf):len(buf)])
+		if err != nil {
+			return buf[:start], fmt.Errorf("json: transform %q on field %q: %w", name, field, err)
+		}
+		if end, err := fastparser.SkipValue(out, 0); err != nil || skipSpace(out, end) != len(out) {
+			return buf[:start], fmt.Errorf("json: transform %q on field %q: output is not a single JSON value", name, field)
+		}
+		return append(buf[:start], out...), nil
+	}
+}
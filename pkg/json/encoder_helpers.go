@@ -3,7 +3,9 @@ package json
 import (
 	"errors"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -12,14 +14,25 @@ import (
 // the caller should fall back to reflect-based encoding.
 var errNeedReflect = errors.New("need reflect")
 
+// startDetectingCyclesAfter is the nesting depth, counting pointers, maps,
+// and slices, from which the encoders check for cycles. Checking from the
+// top would cost every Marshal call a map operation per container; a cycle
+// is caught all the same, only this many levels down.
+const startDetectingCyclesAfter = 1000
+
 // appendInterface encodes a Go interface value to JSON using a type-switch
 // over common concrete types. This avoids reflect entirely for the most
 // frequent types seen in JSON data (primitives, []interface{},
 // map[string]interface{}, time.Time, time.Duration, and Marshaler).
 //
+// Map keys are sorted if sortKeys is set, and written in iteration order
+// otherwise (see MarshalOptions.UnsortedMaps).
+//
 // Returns errNeedReflect for types not covered by the switch so the
-// caller can fall back to the compiled encoder cache.
-func appendInterface(buf []byte, v interface{}) ([]byte, error) {
+// caller can fall back to the compiled encoder cache. It does the same
+// for a map or slice depth levels down, past startDetectingCyclesAfter,
+// since only the compiled encoders detect cycles.
+func appendInterface(buf []byte, v interface{}, sortKeys bool, depth uint) ([]byte, error) {
 	switch val := v.(type) {
 	case nil:
 		return append(buf, "null"...), nil
@@ -68,13 +81,16 @@ func appendInterface(buf []byte, v interface{}) ([]byte, error) {
 		buf = append(buf, '"')
 		return buf, nil
 	case []interface{}:
+		if depth >= startDetectingCyclesAfter {
+			return buf, errNeedReflect
+		}
 		buf = append(buf, '[')
 		for i, elem := range val {
 			if i > 0 {
 				buf = append(buf, ',')
 			}
 			var err error
-			buf, err = appendInterface(buf, elem)
+			buf, err = appendInterface(buf, elem, sortKeys, depth+1)
 			if err != nil {
 				return buf, err
 			}
@@ -82,7 +98,29 @@ func appendInterface(buf []byte, v interface{}) ([]byte, error) {
 		buf = append(buf, ']')
 		return buf, nil
 	case map[string]interface{}:
+		if depth >= startDetectingCyclesAfter {
+			return buf, errNeedReflect
+		}
 		buf = append(buf, '{')
+		if !sortKeys {
+			first := true
+			for k, elem := range val {
+				if !first {
+					buf = append(buf, ',')
+				}
+				first = false
+				buf = append(buf, '"')
+				buf = appendEscapedString(buf, k)
+				buf = append(buf, '"', ':')
+				var err error
+				buf, err = appendInterface(buf, elem, sortKeys, depth+1)
+				if err != nil {
+					return buf, err
+				}
+			}
+			buf = append(buf, '}')
+			return buf, nil
+		}
 		// For consistent output, sort the keys.
 		// Use a simple insertion sort for small maps (common case).
 		keys := make([]string, 0, len(val))
@@ -98,7 +136,7 @@ func appendInterface(buf []byte, v interface{}) ([]byte, error) {
 			buf = appendEscapedString(buf, k)
 			buf = append(buf, '"', ':')
 			var err error
-			buf, err = appendInterface(buf, val[k])
+			buf, err = appendInterface(buf, val[k], sortKeys, depth+1)
 			if err != nil {
 				return buf, err
 			}
@@ -116,10 +154,19 @@ func appendInterface(buf []byte, v interface{}) ([]byte, error) {
 	}
 }
 
+// insertionSortMax is the largest slice the key sorts below sort by
+// insertion; larger ones, for which insertion sort is quadratic, fall back
+// to the standard library.
+const insertionSortMax = 16
+
 // sortStrings sorts a string slice in-place using insertion sort.
 // For the small key counts typical in JSON maps (< 20 keys) this is
 // faster than sort.Strings because it avoids the interface overhead.
 func sortStrings(s []string) {
+	if len(s) > insertionSortMax {
+		slices.Sort(s)
+		return
+	}
 	for i := 1; i < len(s); i++ {
 		key := s[i]
 		j := i - 1
@@ -136,6 +183,10 @@ func sortStrings(s []string) {
 // maps (< 20 keys) this is faster than sort.Slice because it avoids
 // the interface boxing and closure allocations.
 func sortReflectStringKeys(keys []reflect.Value) {
+	if len(keys) > insertionSortMax {
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(a.String(), b.String()) })
+		return
+	}
 	for i := 1; i < len(keys); i++ {
 		key := keys[i]
 		keyStr := key.String()
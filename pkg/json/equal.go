@@ -0,0 +1,241 @@
+package json
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Equal reports whether the JSON documents a and b hold the same data,
+// however each is written:
+//
+//   - object members may come in any order; if a key repeats, its last
+//     value counts, as Unmarshal does
+//   - strings compare by the text they decode to, so "\u00e9" and "é" are
+//     equal
+//   - numbers compare by exact decimal value, so 1, 1.0, 10e-1, and 1E0
+//     are equal, -0 equals 0, and 9007199254740993 does not equal
+//     9007199254740992 even though they are the same float64
+//   - whitespace between tokens is ignored
+//
+// Equal returns an error if either document is not valid JSON:
+//
+//	same, err := json.Equal(got, want)
+//
+// EqualWithOptions compares numbers with a tolerance or by their text.
+func Equal(a, b []byte) (bool, error) {
+	return EqualWithOptions(a, b, CompareOptions{})
+}
+
+// EqualWithOptions is like Equal but compares numbers as opts says:
+//
+//	same, err := json.EqualWithOptions(got, want, json.CompareOptions{FloatTolerance: 1e-9})
+func EqualWithOptions(a, b []byte, opts CompareOptions) (bool, error) {
+	if err := fastparser.Validate(a); err != nil {
+		return false, fmt.Errorf("json: Equal: first document: %w", err)
+	}
+	if err := fastparser.Validate(b); err != nil {
+		return false, fmt.Errorf("json: Equal: second document: %w", err)
+	}
+	return equalRaw(bytes.TrimSpace(a), bytes.TrimSpace(b), &opts)
+}
+
+// CompareOptions configures EqualWithOptions and DiffWithOptions.
+//
+// The zero value compares as Equal and Diff do.
+type CompareOptions struct {
+	// Numbers selects when two numbers are the same. The default,
+	// NumbersByValue, compares their values, so 1 and 1.0 are equal;
+	// NumbersByLexeme requires them to be written alike.
+	Numbers NumberComparison
+
+	// FloatTolerance, if positive, also treats two numbers as equal when
+	// their float64 values differ by no more than it, so that readings
+	// such as 0.30000000000000004 and 0.3 match with a tolerance of 1e-9.
+	// It applies whichever Numbers is chosen.
+	FloatTolerance float64
+}
+
+// NumberComparison selects how CompareOptions compares numbers.
+type NumberComparison uint8
+
+const (
+	// NumbersByValue compares numbers by value: by exact decimal value in
+	// EqualWithOptions, and as float64 in DiffWithOptions.
+	NumbersByValue NumberComparison = iota
+
+	// NumbersByLexeme compares numbers by their text in EqualWithOptions,
+	// so 1, 1.0, and 1e0 all differ. DiffWithOptions, whose numbers have
+	// already been decoded, compares their Go types as well as their
+	// values, so int64(1), decoded from 1, differs from 1.0.
+	NumbersByLexeme
+)
+
+// rawNumbersEqual compares the text of two valid JSON numbers.
+func (o *CompareOptions) rawNumbersEqual(a, b []byte) bool {
+	var eq bool
+	if o.Numbers == NumbersByLexeme {
+		eq = bytes.Equal(a, b)
+	} else {
+		eq = parseDecimal(a) == parseDecimal(b)
+	}
+	if eq || !(o.FloatTolerance > 0) {
+		return eq
+	}
+	// Out-of-range numbers parse as ±Inf, which is within no tolerance
+	// of anything
+	fa, _ := strconv.ParseFloat(string(a), 64)
+	fb, _ := strconv.ParseFloat(string(b), 64)
+	return math.Abs(fa-fb) <= o.FloatTolerance
+}
+
+// equalRaw compares the text of two valid JSON values.
+func equalRaw(a, b []byte, opts *CompareOptions) (bool, error) {
+	if a[0] != b[0] && !(isNumberStart(a[0]) && isNumberStart(b[0])) {
+		return false, nil
+	}
+	switch a[0] {
+	case '{':
+		return equalObjects(a, b, opts)
+	case '[':
+		return equalArrays(a, b, opts)
+	case '"':
+		sa, err := decodeKey(a)
+		if err != nil {
+			return false, err
+		}
+		sb, err := decodeKey(b)
+		if err != nil {
+			return false, err
+		}
+		return sa == sb, nil
+	case 't', 'f', 'n':
+		return bytes.Equal(a, b), nil
+	default:
+		return opts.rawNumbersEqual(a, b), nil
+	}
+}
+
+// equalObjects compares the text of two objects.
+func equalObjects(a, b []byte, opts *CompareOptions) (bool, error) {
+	am, err := rawMembers(a)
+	if err != nil {
+		return false, err
+	}
+	bm, err := rawMembers(b)
+	if err != nil {
+		return false, err
+	}
+
+	bValues := make(map[string][]byte, len(bm))
+	for _, m := range bm {
+		bValues[m.key] = b[m.valueStart:m.end]
+	}
+	aValues := make(map[string][]byte, len(am))
+	for _, m := range am {
+		aValues[m.key] = a[m.valueStart:m.end]
+	}
+	if len(aValues) != len(bValues) {
+		return false, nil
+	}
+	for key, av := range aValues {
+		bv, ok := bValues[key]
+		if !ok {
+			return false, nil
+		}
+		if eq, err := equalRaw(av, bv, opts); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// equalArrays compares the text of two arrays.
+func equalArrays(a, b []byte, opts *CompareOptions) (bool, error) {
+	ae, err := rawElements(a)
+	if err != nil {
+		return false, err
+	}
+	be, err := rawElements(b)
+	if err != nil {
+		return false, err
+	}
+	if len(ae) != len(be) {
+		return false, nil
+	}
+	for i := range ae {
+		if eq, err := equalRaw(a[ae[i].start:ae[i].end], b[be[i].start:be[i].end], opts); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func isNumberStart(c byte) bool {
+	return c == '-' || c >= '0' && c <= '9'
+}
+
+// decimal is the exact value of a JSON number: digits × 10^exp, negated if
+// neg. Digits has no leading or trailing zeros, so each value has one
+// form; zero has no digits and is never negative.
+type decimal struct {
+	neg    bool
+	digits string
+	exp    int
+}
+
+// maxExponent bounds the exponents parseDecimal reads, far beyond any
+// float, so that absurd exponents cannot overflow an int.
+const maxExponent = 1 << 30
+
+// parseDecimal reads the text of a valid JSON number.
+func parseDecimal(text []byte) decimal {
+	var d decimal
+	i := 0
+	if text[i] == '-' {
+		d.neg = true
+		i++
+	}
+
+	var digits strings.Builder
+	for ; i < len(text) && text[i] >= '0' && text[i] <= '9'; i++ {
+		digits.WriteByte(text[i])
+	}
+	if i < len(text) && text[i] == '.' {
+		for i++; i < len(text) && text[i] >= '0' && text[i] <= '9'; i++ {
+			digits.WriteByte(text[i])
+			d.exp--
+		}
+	}
+	if i < len(text) && (text[i] == 'e' || text[i] == 'E') {
+		i++
+		negExp := false
+		if text[i] == '+' || text[i] == '-' {
+			negExp = text[i] == '-'
+			i++
+		}
+		e := 0
+		for ; i < len(text); i++ {
+			if e < maxExponent {
+				e = e*10 + int(text[i]-'0')
+			}
+		}
+		if negExp {
+			e = -e
+		}
+		d.exp += e
+	}
+
+	s := strings.TrimLeft(digits.String(), "0")
+	trimmed := strings.TrimRight(s, "0")
+	d.exp += len(s) - len(trimmed)
+	d.digits = trimmed
+	if d.digits == "" {
+		return decimal{}
+	}
+	return d
+}
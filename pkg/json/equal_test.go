@@ -0,0 +1,145 @@
+package json
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`{"a": 1, "b": [true, null]}`, `{"b":[true,null],"a":1}`, true},
+		{`{"a": 1}`, `{"a": 1, "b": 2}`, false},
+		{`{"a": 1, "b": 2}`, `{"a": 1, "c": 2}`, false},
+		{`{"a": 1, "a": 2}`, `{"a": 2}`, true},
+		{`[1, 2]`, `[2, 1]`, false},
+		{`[1, 2]`, `[1, 2, 3]`, false},
+		{`"é\n"`, `"é\u000a"`, true},
+		{`"😀"`, `"😀"`, true},
+		{`"a"`, `"b"`, false},
+		{`1`, `1.0`, true},
+		{`1`, `10e-1`, true},
+		{`100`, `1E2`, true},
+		{`0.5`, `5e-1`, true},
+		{`-0`, `0.0`, true},
+		{`0`, `0e5`, true},
+		{`9007199254740993`, `9007199254740992`, false},
+		{`1e400`, `10e399`, true},
+		{`-1`, `1`, false},
+		{`1.5`, `1.50`, true},
+		{`0.001`, `1e-3`, true},
+		{`1`, `"1"`, false},
+		{`null`, `false`, false},
+		{`true`, `true`, true},
+		{" \n{}\t", `{}`, true},
+		{`{}`, `[]`, false},
+		{`{"a": {"b": [1, {"c": 2.0}]}}`, `{"a": {"b": [1, {"c": 2}]}}`, true},
+	}
+	for _, tt := range tests {
+		got, err := Equal([]byte(tt.a), []byte(tt.b))
+		if err != nil {
+			t.Errorf("Equal(%s, %s): %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Equal(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if back, _ := Equal([]byte(tt.b), []byte(tt.a)); back != got {
+			t.Errorf("Equal(%s, %s) is not symmetric", tt.a, tt.b)
+		}
+	}
+}
+
+func TestEqualWithOptions(t *testing.T) {
+	tolerant := CompareOptions{FloatTolerance: 1e-9}
+	lexeme := CompareOptions{Numbers: NumbersByLexeme}
+
+	tests := []struct {
+		a, b string
+		opts CompareOptions
+		want bool
+	}{
+		{`{"t": 0.30000000000000004}`, `{"t": 0.3}`, CompareOptions{}, false},
+		{`{"t": 0.30000000000000004}`, `{"t": 0.3}`, tolerant, true},
+		{`[0.1, 20.5]`, `[0.1000001, 20.5]`, tolerant, false},
+		{`[0.1, 20.5]`, `[0.1000001, 20.5]`, CompareOptions{FloatTolerance: 1e-6}, true},
+		{`1e400`, `2e400`, CompareOptions{FloatTolerance: 1}, false},
+		{`1`, `1.0`, lexeme, false},
+		{`1e2`, `1E2`, lexeme, false},
+		{`{"a": 1.50, "b": 2}`, `{"b": 2, "a": 1.50}`, lexeme, true},
+		{`1.0`, `1`, CompareOptions{Numbers: NumbersByLexeme, FloatTolerance: 1e-9}, true},
+		{`"0.3"`, `0.3`, tolerant, false},
+	}
+	for _, tt := range tests {
+		got, err := EqualWithOptions([]byte(tt.a), []byte(tt.b), tt.opts)
+		if err != nil {
+			t.Errorf("EqualWithOptions(%s, %s): %v", tt.a, tt.b, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("EqualWithOptions(%s, %s, %+v) = %v, want %v", tt.a, tt.b, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestEqual_Invalid(t *testing.T) {
+	if _, err := Equal([]byte(`{"a":}`), []byte(`{}`)); err == nil {
+		t.Error("expected an error for an invalid first document")
+	}
+	if _, err := Equal([]byte(`1`), []byte(`1 2`)); err == nil {
+		t.Error("expected an error for an invalid second document")
+	}
+}
+
+// roundTripCorpus lists documents that Unmarshal into interface{} and
+// Marshal back without loss.
+var roundTripCorpus = []string{
+	`null`, `true`, `false`, `0`, `-0`, `1`, `-1`, `1.5`, `1e3`, `1E-2`, `0.1`, `2.0`,
+	`9007199254740991`, `-9223372036854775808`, `1.7976931348623157e308`, `5e-324`,
+	`""`, `"plain"`, `"é\"\\\/\b\f\n\r\t"`, `"😀 😀 ✓"`, `"\u0000\u001f"`, `"<&> "`,
+	`[]`, `{}`, `[[]]`, `[{}]`, `{"": ""}`,
+	`{"b": 1, "a": [1, "x", null, true, {"c": [2.5, -3]}], "é": {"😀": "\t"}}`,
+	`[1, [2, [3, [4, [5, [6, [7, [8]]]]]]]]`,
+	`{"a": 1, "a": 2}`,
+}
+
+// TestRoundTrip checks that Marshal(Unmarshal(x)) is the same document as
+// x, by both decode paths, across the corpus and the benchmark data.
+func TestRoundTrip(t *testing.T) {
+	docs := make(map[string][]byte)
+	for i, doc := range roundTripCorpus {
+		docs[filepath.Join("corpus", string(rune('a'+i)))] = []byte(doc)
+	}
+	files, err := filepath.Glob(filepath.Join("..", "..", "testdata", "benchmarks", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		docs[filepath.Base(file)] = data
+	}
+
+	for name, doc := range docs {
+		for _, path := range []DecodePath{FastPath, ASTPath} {
+			var v interface{}
+			if err := UnmarshalWithOptions(doc, &v, UnmarshalOptions{Path: path}); err != nil {
+				t.Errorf("%s (path %d): Unmarshal: %v", name, path, err)
+				continue
+			}
+			out, err := Marshal(v)
+			if err != nil {
+				t.Errorf("%s (path %d): Marshal: %v", name, path, err)
+				continue
+			}
+			if eq, err := Equal(out, doc); err != nil || !eq {
+				t.Errorf("%s (path %d): round trip changed the document (err %v):\n%.200s\nbecame\n%.200s", name, path, err, doc, out)
+			}
+		}
+	}
+}
@@ -1,11 +1,23 @@
 package json
 
+import (
+	"math/bits"
+	"slices"
+
+	"github.com/shapestone/shape-json/internal/swar"
+)
+
 // escapeTable maps ASCII bytes to their JSON escape character.
 // 0 means no escape needed. Non-zero is the byte to write after backslash.
 // Control characters (0x00-0x1F) that don't have named escapes use 0x01
 // as a sentinel to indicate \u00XX encoding is needed.
 var escapeTable [256]byte
 
+// needsEscape and needsMinimalEscape report, for each byte, whether
+// appendEscapedString and appendMinimalEscapedString escape it. They differ
+// only in '/'.
+var needsEscape, needsMinimalEscape [256]bool
+
 const hexDigits = "0123456789abcdef"
 
 func init() {
@@ -25,31 +37,95 @@ func init() {
 			escapeTable[i] = 0x01 // sentinel: needs \u00XX encoding
 		}
 	}
+
+	for i, esc := range escapeTable {
+		needsEscape[i] = esc != 0
+		needsMinimalEscape[i] = esc != 0 && i != '/'
+	}
 }
 
 // appendEscapedString appends a JSON-escaped string to buf (without surrounding quotes).
 // This is a zero-allocation function: it writes directly to the provided buffer.
 func appendEscapedString(buf []byte, s string) []byte {
-	start := 0
-	for i := 0; i < len(s); i++ {
-		c := s[i]
-		if c >= 0x20 && c != '"' && c != '\\' && c != '/' {
+	return appendEscaped(buf, s, &needsEscape)
+}
+
+// appendEscaped appends s to buf, escaping the bytes table marks. It tests
+// eight bytes at a time, visits only the bytes that need escaping, and
+// copies each clean run between them with a single append, so a string with
+// nothing to escape costs one scan and one copy.
+func appendEscaped(buf []byte, s string, table *[256]bool) []byte {
+	buf = slices.Grow(buf, len(s))
+	slash := table['/']
+	start, i := 0, 0
+	for i+8 <= len(s) {
+		w := load64(s, i)
+		if escapeSpecial(w, slash) == 0 {
+			i += 8
 			continue
 		}
+		// Escapes cluster, so test the words that follow exactly until
+		// one comes up clean
+		for {
+			dirty := escapeBytes(w, slash)
+			for m := dirty; m != 0; m &= m - 1 {
+				j := i + bits.TrailingZeros64(m)>>3
+				// Flush the clean run before s[j]
+				buf = appendByteEscape(append(buf, s[start:j]...), s[j])
+				start = j + 1
+			}
+			i += 8
+			if dirty == 0 || i+8 > len(s) {
+				break
+			}
+			w = load64(s, i)
+		}
+	}
+	for ; i < len(s); i++ {
+		if c := s[i]; table[c] {
+			buf = appendByteEscape(append(buf, s[start:i]...), c)
+			start = i + 1
+		}
+	}
+	return append(buf, s[start:]...)
+}
 
-		// Flush unescaped run
-		buf = append(buf, s[start:i]...)
+// appendByteEscape appends the escape sequence for c, which escapeTable
+// marks.
+func appendByteEscape(buf []byte, c byte) []byte {
+	if esc := escapeTable[c]; esc != 0x01 {
+		return append(buf, '\\', esc)
+	}
+	// Control character: \u00XX
+	return append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0x0F])
+}
 
-		esc := escapeTable[c]
-		if esc == 0x01 {
-			// Control character: \u00XX
-			buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0x0F])
-		} else {
-			buf = append(buf, '\\', esc)
-		}
-		start = i + 1
+// escapeSpecial is a cheaper escapeBytes for telling whether a word needs
+// escaping at all: it is zero exactly when escapeBytes is, but its nonzero
+// results may have spurious bits.
+func escapeSpecial(w uint64, slash bool) uint64 {
+	m := swar.StringSpecial(w)
+	if slash {
+		m |= swar.Has(w, '/')
 	}
-	// Flush remaining
-	buf = append(buf, s[start:]...)
-	return buf
+	return m
+}
+
+// escapeBytes returns a word with the high bit set in exactly those of the
+// eight little-endian bytes in w that need escaping: a quote, a backslash,
+// a control character, or, if slash is set, a '/'.
+func escapeBytes(w uint64, slash bool) uint64 {
+	m := swar.Below(w, 0x20) | swar.Equal(w, '"') | swar.Equal(w, '\\')
+	if slash {
+		m |= swar.Equal(w, '/')
+	}
+	return m
+}
+
+// load64 returns the eight bytes of s starting at i as a little-endian
+// word. The compiler merges the byte loads into one.
+func load64(s string, i int) uint64 {
+	s = s[i : i+8]
+	return uint64(s[0]) | uint64(s[1])<<8 | uint64(s[2])<<16 | uint64(s[3])<<24 |
+		uint64(s[4])<<32 | uint64(s[5])<<40 | uint64(s[6])<<48 | uint64(s[7])<<56
 }
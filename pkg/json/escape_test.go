@@ -0,0 +1,91 @@
+package json
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// escapeByByte is the reference for appendEscapedString: each byte on its
+// own, through escapeTable.
+func escapeByByte(s string, slash bool) string {
+	var buf []byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch esc := escapeTable[c]; {
+		case esc == 0 || c == '/' && !slash:
+			buf = append(buf, c)
+		case esc == 0x01:
+			buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0x0F])
+		default:
+			buf = append(buf, '\\', esc)
+		}
+	}
+	return string(buf)
+}
+
+// TestAppendEscapedString_Words checks the word-at-a-time scan against the
+// reference at every length and alignment, with escapes placed at every
+// position of a word and next to bytes above 0x7F.
+func TestAppendEscapedString_Words(t *testing.T) {
+	alphabet := []byte("ab/\"\\\x00\x1f\x7f\x80\xff \n!0\xc3\xa9")
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 40; n++ {
+		for trial := 0; trial < 200; trial++ {
+			b := make([]byte, n)
+			for i := range b {
+				b[i] = alphabet[r.Intn(len(alphabet))]
+			}
+			s := string(b)
+			prefix := "x"
+			if got, want := string(appendEscapedString([]byte(prefix), s)), prefix+escapeByByte(s, true); got != want {
+				t.Fatalf("appendEscapedString(%q) = %q, want %q", s, got, want)
+			}
+			if got, want := string(appendMinimalEscapedString(nil, s)), escapeByByte(s, false); got != want {
+				t.Fatalf("appendMinimalEscapedString(%q) = %q, want %q", s, got, want)
+			}
+		}
+	}
+}
+
+// escapeBenchInputs are the strings the escape benchmarks encode: long
+// clean ASCII, clean text outside ASCII, and text that is mostly escapes.
+var escapeBenchInputs = []struct {
+	name string
+	s    string
+}{
+	{"ASCII", strings.Repeat("The quick brown fox jumps over the lazy dog. ", 24)},
+	{"Short", "user12345"},
+	{"UTF8", strings.Repeat("Größe café naïve 日本語 ✓ ", 24)},
+	{"Escapes", strings.Repeat("a\"b\\c/d\ne\tf\x01", 48)},
+	{"Mixed", strings.Repeat(`{"path": "/usr/local/bin", "msg": "line1\nline2"} `, 16)},
+}
+
+func BenchmarkAppendEscapedString(b *testing.B) {
+	for _, in := range escapeBenchInputs {
+		b.Run(in.name, func(b *testing.B) {
+			buf := make([]byte, 0, 4*len(in.s))
+			b.SetBytes(int64(len(in.s)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				buf = appendEscapedString(buf[:0], in.s)
+			}
+		})
+	}
+}
+
+func BenchmarkMarshal_Strings(b *testing.B) {
+	for _, in := range escapeBenchInputs {
+		b.Run(in.name, func(b *testing.B) {
+			v := []string{in.s, in.s, in.s, in.s}
+			b.SetBytes(int64(4 * len(in.s)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := Marshal(v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
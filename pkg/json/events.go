@@ -0,0 +1,75 @@
+package json
+
+import (
+	"io"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// SkipSubtree can be returned from an EventHandler callback to skip the
+// current value without receiving any events for its contents:
+//   - from OnKey, the member's value is skipped;
+//   - from OnObjectStart or OnArrayStart, the container's contents and its
+//     matching end event are skipped.
+//
+// Skipped values are still fully validated.
+var SkipSubtree = fastparser.ErrSkipSubtree
+
+// EventHandler receives SAX-style events from ParseEvents.
+//
+// Every callback is optional. Values whose callback is nil are validated but
+// never decoded, so a handler that only cares about a few keys pays no
+// allocation cost for the rest of the document.
+//
+// Returning a non-nil error (other than SkipSubtree) from any callback stops
+// parsing, and ParseEvents returns that error unchanged.
+//
+// OnNumber receives the number's literal text (e.g. "-1.5e3") so the handler
+// can choose how to convert it with the strconv package.
+type EventHandler struct {
+	OnObjectStart func() error
+	OnObjectEnd   func() error
+	OnArrayStart  func() error
+	OnArrayEnd    func() error
+	OnKey         func(key string) error
+	OnString      func(s string) error
+	OnNumber      func(raw string) error
+	OnBool        func(b bool) error
+	OnNull        func() error
+}
+
+// ParseEvents parses a single JSON value from r and reports it to h as a
+// sequence of events, without building an AST or any Go values.
+//
+// This is the fastest way to filter or aggregate over large documents:
+//
+//	var total float64
+//	inPrice := false
+//	err := json.ParseEvents(file, json.EventHandler{
+//	    OnKey: func(key string) error {
+//	        inPrice = key == "price"
+//	        return nil
+//	    },
+//	    OnNumber: func(raw string) error {
+//	        if inPrice {
+//	            f, err := strconv.ParseFloat(raw, 64)
+//	            total += f
+//	            return err
+//	        }
+//	        return nil
+//	    },
+//	})
+//
+// Like ValidateReader, this reads the entire input from r before parsing;
+// StreamEvents reads incrementally and can stream huge strings instead.
+// If the input is not valid JSON, ParseEvents returns a parse error; events
+// already delivered before the error are not retracted.
+func ParseEvents(r io.Reader, h EventHandler) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	internal := fastparser.Handler(h)
+	return fastparser.NewParser(data).Walk(&internal)
+}
@@ -0,0 +1,121 @@
+package json
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestParseEvents(t *testing.T) {
+	input := `{"items": [{"name": "a", "price": 1.5}, {"name": "b", "price": 2}], "count": 2}`
+
+	var total float64
+	var names []string
+	inPrice := false
+	err := ParseEvents(strings.NewReader(input), EventHandler{
+		OnKey: func(key string) error {
+			inPrice = key == "price"
+			return nil
+		},
+		OnString: func(s string) error {
+			names = append(names, s)
+			return nil
+		},
+		OnNumber: func(raw string) error {
+			if !inPrice {
+				return nil
+			}
+			f, err := strconv.ParseFloat(raw, 64)
+			total += f
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseEvents() error = %v", err)
+	}
+
+	if total != 3.5 {
+		t.Errorf("total = %v, want 3.5", total)
+	}
+	if strings.Join(names, ",") != "a,b" {
+		t.Errorf("names = %v, want [a b]", names)
+	}
+}
+
+func TestParseEvents_Structure(t *testing.T) {
+	var depth, maxDepth int
+	enter := func() error {
+		depth++
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		return nil
+	}
+	leave := func() error {
+		depth--
+		return nil
+	}
+
+	err := ParseEvents(strings.NewReader(`[[{"a": [true, null]}], {}]`), EventHandler{
+		OnObjectStart: enter,
+		OnObjectEnd:   leave,
+		OnArrayStart:  enter,
+		OnArrayEnd:    leave,
+	})
+	if err != nil {
+		t.Fatalf("ParseEvents() error = %v", err)
+	}
+	if depth != 0 || maxDepth != 4 {
+		t.Errorf("depth = %d, maxDepth = %d, want 0 and 4", depth, maxDepth)
+	}
+}
+
+func TestParseEvents_SkipSubtree(t *testing.T) {
+	var keys []string
+	err := ParseEvents(strings.NewReader(`{"meta": {"x": 1, "y": 2}, "id": 7}`), EventHandler{
+		OnKey: func(key string) error {
+			keys = append(keys, key)
+			if key == "meta" {
+				return SkipSubtree
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("ParseEvents() error = %v", err)
+	}
+	if strings.Join(keys, ",") != "meta,id" {
+		t.Errorf("keys = %v, want [meta id]", keys)
+	}
+}
+
+func TestParseEvents_Errors(t *testing.T) {
+	t.Run("invalid JSON", func(t *testing.T) {
+		if err := ParseEvents(strings.NewReader(`{"a": }`), EventHandler{}); err == nil {
+			t.Error("expected error for invalid JSON")
+		}
+	})
+
+	t.Run("handler error is returned", func(t *testing.T) {
+		stop := errors.New("found it")
+		err := ParseEvents(strings.NewReader(`["x", "y"]`), EventHandler{
+			OnString: func(s string) error {
+				if s == "x" {
+					return stop
+				}
+				return nil
+			},
+		})
+		if err != stop {
+			t.Errorf("ParseEvents() error = %v, want %v", err, stop)
+		}
+	})
+
+	t.Run("reader error", func(t *testing.T) {
+		if err := ParseEvents(iotest.ErrReader(errors.New("boom")), EventHandler{}); err == nil {
+			t.Error("expected reader error")
+		}
+	})
+}
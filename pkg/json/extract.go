@@ -0,0 +1,41 @@
+package json
+
+import "fmt"
+
+// ExtractPath returns the value at path exactly as it appears in data,
+// without decoding and re-encoding it, so that whitespace, key order,
+// number formatting, and escapes are passed through unchanged. It suits
+// proxies that forward part of a request, or code that verifies a
+// signature over the bytes of a sub-object.
+//
+// path is a JSON Pointer or a JSONPath expression of the form Redact
+// accepts. If it matches more than one value, the first in document order
+// is returned; a path that matches nothing is an error. The result is a
+// slice of data and must not be modified.
+//
+// Like Redact, ExtractPath validates the whole document in a single pass
+// without building a tree.
+//
+// Example:
+//
+//	payload, err := json.ExtractPath(body, "$.payload")
+func ExtractPath(data []byte, path string) ([]byte, error) {
+	pattern, err := compileRedactPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("json: ExtractPath path %q: %w", path, err)
+	}
+
+	r := &redactor{data: data, patterns: [][]pathPattern{pattern}, collect: true}
+	pos, err := r.value(skipSpace(data, 0))
+	if err != nil {
+		return nil, err
+	}
+	if pos = skipSpace(data, pos); pos < len(data) {
+		return nil, fmt.Errorf("unexpected data after JSON value at position %d", pos)
+	}
+	if len(r.spans) == 0 {
+		return nil, fmt.Errorf("json: ExtractPath: no value at %q", path)
+	}
+	sp := r.spans[0]
+	return data[sp.Start:sp.End:sp.End], nil
+}
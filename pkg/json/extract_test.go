@@ -0,0 +1,59 @@
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtractPath(t *testing.T) {
+	input := `{
+  "id": "m-1",
+  "payload": { "amount" : 1.50e+2, "note": "café",
+               "items": [ 1,2 , 3 ] },
+  "events": [{"type": "a", "at": 1}, {"type": "b", "at": 2}]
+}`
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$.payload", `{ "amount" : 1.50e+2, "note": "café",
+               "items": [ 1,2 , 3 ] }`},
+		{"/payload", `{ "amount" : 1.50e+2, "note": "café",
+               "items": [ 1,2 , 3 ] }`},
+		{"$.payload.amount", `1.50e+2`},
+		{"$.payload.note", `"café"`},
+		{"$.payload.items[2]", `3`},
+		{"$.events[*].type", `"a"`},
+		{"$..at", `1`},
+		{"", input},
+	}
+	for _, tt := range tests {
+		got, err := ExtractPath([]byte(input), tt.path)
+		if err != nil {
+			t.Errorf("ExtractPath(%q): %v", tt.path, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("ExtractPath(%q) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestExtractPathErrors(t *testing.T) {
+	tests := []struct {
+		data, path, want string
+	}{
+		{`{"a": 1}`, "$.b", "no value"},
+		{`{"a": 1}`, "$.a[?(@ > 1)]", "filter expressions"},
+		{`{"a": 1}`, "a", "path must be"},
+		{`{"a": 1, "b": [}`, "$.a", "expected"},
+		{`{"a": 1} x`, "$.a", "unexpected data"},
+	}
+	for _, tt := range tests {
+		_, err := ExtractPath([]byte(tt.data), tt.path)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ExtractPath(%s, %q) error = %v, want %q", tt.data, tt.path, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package json
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONFile is a JSON object stored in a file, for programs that edit
+// configuration files in place.
+//
+// It loads the file into a Document, reports whether the Document has been
+// changed, and writes it back atomically: the new contents go to a temporary
+// file in the same directory, which then replaces the original, so readers
+// never see a half-written file.
+//
+// Saving keeps the file's layout where it can: objects keep the member
+// order they had on disk (new members are appended in sorted order), the
+// indentation is detected from the original text (or the file stays compact),
+// and a trailing newline is kept or omitted as before. The file mode is
+// preserved too.
+//
+// Example:
+//
+//	f, err := json.OpenJSONFile("settings.json")
+//	if err != nil {
+//	    return err
+//	}
+//	f.Document().SetBool("telemetry", false)
+//	return f.Save()
+//
+// A JSONFile is not safe for concurrent use.
+type JSONFile struct {
+	path  string
+	perm  fs.FileMode
+	doc   *Document
+	saved [sha256.Size]byte // canonical hash of the contents on disk
+
+	layout // of the file on disk
+}
+
+// layout is the formatting of a JSON text that JSONFile and ApplyEdits
+// keep when they write it anew.
+type layout struct {
+	indent      string // "" for compact
+	newline     bool   // ends with a newline
+	memberOrder map[string][]string
+}
+
+// readLayout returns the layout of data, which must be valid JSON.
+func readLayout(data []byte) (layout, error) {
+	l := layout{
+		indent:      detectIndent(data),
+		newline:     bytes.HasSuffix(data, []byte("\n")),
+		memberOrder: map[string][]string{},
+	}
+	return l, l.recordMemberOrder(data)
+}
+
+// OpenJSONFile loads the JSON object stored at path. If the file does not
+// exist, the document starts empty and Save creates the file.
+func OpenJSONFile(path string) (*JSONFile, error) {
+	f := &JSONFile{
+		path:   path,
+		perm:   0o644,
+		layout: layout{indent: "  ", newline: true, memberOrder: map[string][]string{}},
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		f.doc = NewDocument()
+	case err != nil:
+		return nil, err
+	default:
+		if info, err := os.Stat(path); err == nil {
+			f.perm = info.Mode().Perm()
+		}
+		if err := f.load(data); err != nil {
+			return nil, fmt.Errorf("json: %s: %w", path, err)
+		}
+	}
+
+	sum, err := f.hash()
+	if err != nil {
+		return nil, err
+	}
+	f.saved = sum
+	return f, nil
+}
+
+// load parses data and records its layout.
+func (f *JSONFile) load(data []byte) error {
+	doc := NewDocument()
+	if err := Unmarshal(data, &doc.data); err != nil {
+		return err
+	}
+	if doc.data == nil {
+		return errors.New("expected a JSON object, got null")
+	}
+	f.doc = doc
+
+	l, err := readLayout(data)
+	if err != nil {
+		return err
+	}
+	f.layout = l
+	return nil
+}
+
+// Path returns the file's path.
+func (f *JSONFile) Path() string { return f.path }
+
+// Document returns the file's contents. Changes made to it are written by
+// the next Save.
+func (f *JSONFile) Document() *Document { return f.doc }
+
+// Dirty reports whether the document differs from the file's contents as
+// last loaded or saved. Changes are detected by content, so a value that is
+// modified and then restored does not count.
+func (f *JSONFile) Dirty() bool {
+	sum, err := f.hash()
+	return err != nil || sum != f.saved
+}
+
+// Save writes the document back to the file if it is dirty.
+func (f *JSONFile) Save() error {
+	sum, err := f.hash()
+	if err != nil {
+		return err
+	}
+	if sum == f.saved {
+		return nil
+	}
+
+	data, err := f.render(f.doc.data)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(f.path, data, f.perm); err != nil {
+		return err
+	}
+	f.saved = sum
+	return nil
+}
+
+// hash returns the canonical hash of the current document.
+func (f *JSONFile) hash() ([sha256.Size]byte, error) {
+	var sum [sha256.Size]byte
+	h, err := HashValue(f.doc, sha256.New)
+	if err != nil {
+		return sum, err
+	}
+	copy(sum[:], h)
+	return sum, nil
+}
+
+// render encodes v, a native JSON value, in layout l.
+func (l *layout) render(v interface{}) ([]byte, error) {
+	compact, err := l.appendOrdered(nil, v, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = PrettyPrint(&buf, compact, StyleOptions{
+		Indent:  l.indent,
+		Compact: l.indent == "",
+		Color:   ColorNever,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := buf.Bytes()
+	if !l.newline {
+		out = bytes.TrimSuffix(out, []byte("\n"))
+	}
+	return out, nil
+}
+
+// appendOrdered appends v as compact JSON, writing the members of the object
+// at JSON Pointer ptr in their original order.
+func (l *layout) appendOrdered(buf []byte, v interface{}, ptr string) ([]byte, error) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(val))
+		seen := make(map[string]bool, len(val))
+		for _, k := range l.memberOrder[ptr] {
+			if _, ok := val[k]; ok && !seen[k] {
+				keys = append(keys, k)
+				seen[k] = true
+			}
+		}
+		added := make([]string, 0, len(val)-len(keys))
+		for k := range val {
+			if !seen[k] {
+				added = append(added, k)
+			}
+		}
+		sort.Strings(added)
+		keys = append(keys, added...)
+
+		buf = append(buf, '{')
+		for i, k := range keys {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendQuoted(buf, k)
+			buf = append(buf, ':')
+			var err error
+			if buf, err = l.appendOrdered(buf, val[k], ptr+"/"+escapePointerToken(k)); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, '}'), nil
+
+	case []interface{}:
+		buf = append(buf, '[')
+		for i, elem := range val {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			var err error
+			if buf, err = l.appendOrdered(buf, elem, ptr+"/"+strconv.Itoa(i)); err != nil {
+				return nil, err
+			}
+		}
+		return append(buf, ']'), nil
+	}
+
+	data, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, data...), nil
+}
+
+// recordMemberOrder records the member order of every object in data, keyed
+// by the object's JSON Pointer.
+func (l *layout) recordMemberOrder(data []byte) error {
+	type frame struct {
+		ptr   string
+		array bool
+		index int
+		key   string
+	}
+	var stack []frame
+
+	// childPointer returns the pointer of the value about to start
+	childPointer := func() string {
+		if len(stack) == 0 {
+			return ""
+		}
+		top := &stack[len(stack)-1]
+		if top.array {
+			top.index++
+			return top.ptr + "/" + strconv.Itoa(top.index-1)
+		}
+		return top.ptr + "/" + escapePointerToken(top.key)
+	}
+	push := func(array bool) func() error {
+		return func() error {
+			stack = append(stack, frame{ptr: childPointer(), array: array})
+			return nil
+		}
+	}
+	pop := func() error {
+		stack = stack[:len(stack)-1]
+		return nil
+	}
+	scalar := func() error {
+		childPointer()
+		return nil
+	}
+
+	return ParseEvents(bytes.NewReader(data), EventHandler{
+		OnObjectStart: push(false),
+		OnObjectEnd:   pop,
+		OnArrayStart:  push(true),
+		OnArrayEnd:    pop,
+		OnKey: func(key string) error {
+			top := &stack[len(stack)-1]
+			top.key = key
+			l.memberOrder[top.ptr] = append(l.memberOrder[top.ptr], key)
+			return nil
+		},
+		OnString: func(string) error { return scalar() },
+		OnNumber: func(string) error { return scalar() },
+		OnBool:   func(bool) error { return scalar() },
+		OnNull:   scalar,
+	})
+}
+
+// detectIndent returns the indentation of the first indented line in data,
+// or "" if data has no line breaks inside the value.
+func detectIndent(data []byte) string {
+	text := strings.TrimSpace(string(data))
+	for {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			return ""
+		}
+		text = text[i+1:]
+		line := strings.TrimLeft(text, " \t")
+		if indent := text[:len(text)-len(line)]; indent != "" {
+			return indent
+		}
+	}
+}
+
+// writeFileAtomic replaces the file at path with data by writing a temporary
+// file in the same directory and renaming it over the original.
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
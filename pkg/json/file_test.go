@@ -0,0 +1,168 @@
+package json
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestJSONFile_PreservesLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	original := "{\n    \"zeta\": 1,\n    \"alpha\": {\n        \"b\": [1, {\"y\": 1, \"x\": 2}],\n        \"a\": \"s\"\n    },\n    \"url\": \"a/b\"\n}\n"
+	if err := os.WriteFile(path, []byte(original), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenJSONFile(path)
+	if err != nil {
+		t.Fatalf("OpenJSONFile() error = %v", err)
+	}
+	if f.Path() != path || f.Dirty() {
+		t.Fatalf("Path = %q, Dirty = %v", f.Path(), f.Dirty())
+	}
+
+	f.Document().SetInt("zeta", 2)
+	f.Document().SetBool("new", true)
+	if !f.Dirty() {
+		t.Fatal("Dirty() = false after modification")
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if f.Dirty() {
+		t.Error("Dirty() = true after Save")
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n    \"zeta\": 2,\n    \"alpha\": {\n        \"b\": [\n            1,\n            {\n                \"y\": 1,\n                \"x\": 2\n            }\n        ],\n        \"a\": \"s\"\n    },\n    \"url\": \"a\\/b\",\n    \"new\": true\n}\n"
+	if string(got) != want {
+		t.Errorf("saved file =\n%s\nwant\n%s", got, want)
+	}
+
+	if runtime.GOOS != "windows" {
+		if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+			t.Errorf("file mode = %v, %v; want 0600", info.Mode().Perm(), err)
+		}
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want 1", len(entries))
+	}
+}
+
+func TestJSONFile_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.json")
+	if err := os.WriteFile(path, []byte(`{"b":1,"a":2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenJSONFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Document().Remove("b").SetString("c", "x")
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != `{"a":2,"c":"x"}` {
+		t.Errorf("saved file = %s", got)
+	}
+}
+
+func TestJSONFile_CleanSaveIsNoOp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.json")
+	original := `{ "keep":   "my spacing" }`
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := OpenJSONFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A change that is undone does not make the file dirty
+	f.Document().SetString("keep", "other")
+	f.Document().SetString("keep", "my spacing")
+	if f.Dirty() {
+		t.Error("Dirty() = true after restoring the value")
+	}
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != original {
+		t.Errorf("clean Save rewrote the file: %s", got)
+	}
+}
+
+func TestJSONFile_Create(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.json")
+	f, err := OpenJSONFile(path)
+	if err != nil {
+		t.Fatalf("OpenJSONFile(missing) error = %v", err)
+	}
+	if f.Document().Size() != 0 || f.Dirty() {
+		t.Fatal("new file should start empty and clean")
+	}
+	f.Document().SetArray("list", NewArray().AddInt(1))
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != "{\n  \"list\": [\n    1\n  ]\n}\n" {
+		t.Errorf("created file = %q", got)
+	}
+}
+
+func TestJSONFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"array.json":   `[1]`,
+		"null.json":    `null`,
+		"invalid.json": `{"a":`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenJSONFile(path); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("OpenJSONFile(%s) error = %v", name, err)
+		}
+	}
+
+	if _, err := OpenJSONFile(dir); err == nil {
+		t.Error("OpenJSONFile(directory) expected error")
+	}
+
+	// Saving into a missing directory fails and leaves nothing behind
+	f, err := OpenJSONFile(filepath.Join(dir, "missing", "x.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Document().SetInt("a", 1)
+	if err := f.Save(); err == nil {
+		t.Error("Save() into missing directory expected error")
+	}
+	if !f.Dirty() {
+		t.Error("failed Save cleared Dirty")
+	}
+}
+
+func TestDetectIndent(t *testing.T) {
+	tests := map[string]string{
+		`{"a":1}`:               "",
+		"{\n\t\"a\": 1\n}":      "\t",
+		"{\n  \"a\": 1\n}\n":    "  ",
+		"\n\n{\n\n   \"a\": 1}": "   ",
+		"{\n\"a\": 1\n}":        "",
+	}
+	for input, want := range tests {
+		if got := detectIndent([]byte(input)); got != want {
+			t.Errorf("detectIndent(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -20,8 +20,8 @@ import (
 //	data, _ := json.MarshalIndent(person, "", "  ")
 //	// Output:
 //	// {
-//	//   "age": 30,
-//	//   "name": "Alice"
+//	//   "name": "Alice",
+//	//   "age": 30
 //	// }
 func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
 	// First marshal to compact JSON
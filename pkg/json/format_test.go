@@ -6,6 +6,9 @@ import (
 )
 
 func TestMarshalIndent(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
 	tests := []struct {
 		name   string
 		input  interface{}
@@ -445,6 +448,9 @@ func TestRoundTrip_IndentCompact(t *testing.T) {
 }
 
 func TestMarshalIndent_Struct(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
 	type Address struct {
 		Street string `json:"street"`
 		City   string `json:"city"`
@@ -0,0 +1,391 @@
+package json
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shapestone/shape-json/pkg/jsonlex"
+)
+
+// FormatOptions configures FormatSource.
+type FormatOptions struct {
+	// Indent is the indentation for each nesting level. Empty means two
+	// spaces.
+	Indent string
+
+	// SortKeys sorts the members of every object by key. Comments move
+	// with the member they belong to. Off by default, so that key order is
+	// kept.
+	SortKeys bool
+
+	// Lenient accepts JSONC and the JSON5 extensions that Repair accepts:
+	// comments, trailing commas, single-quoted strings, and unquoted keys.
+	// Strings and keys keep their quoting; trailing commas are dropped.
+	Lenient bool
+}
+
+// FormatSource reformats a document with one member or element per line, for
+// editors and pre-commit hooks. It is a source formatter rather than a
+// serializer: keys keep their order unless SortKeys is set, strings and
+// numbers keep their spelling, and in lenient mode comments are kept next
+// to the values they annotate. Single blank lines between members are kept
+// too, as a way of grouping them.
+//
+// The output ends with a newline and is stable: formatting it again
+// returns it unchanged. Invalid input returns an error and no output.
+//
+// Example:
+//
+//	out, err := json.FormatSource(src, json.FormatOptions{Lenient: true})
+//	// {
+//	//   // Listen address
+//	//   "addr": ":8080",
+//	//   "debug": true // remove before release
+//	// }
+func FormatSource(src []byte, opts FormatOptions) ([]byte, error) {
+	if !opts.Lenient {
+		if err := Validate(string(src)); err != nil {
+			return nil, err
+		}
+	}
+
+	p := &formatParser{}
+	var scanner *jsonlex.Scanner
+	if opts.Lenient {
+		scanner = jsonlex.NewLenientScanner(src)
+	} else {
+		scanner = jsonlex.NewScanner(src)
+	}
+	scanner.Whitespace = true
+	newlines := 0
+	for {
+		tok := scanner.Next()
+		switch tok.Kind {
+		case jsonlex.TokenWhitespace:
+			newlines += bytes.Count(tok.Raw, []byte{'\n'})
+			continue
+		case jsonlex.TokenInvalid:
+			return nil, fmt.Errorf("json: invalid token %q at line %d, column %d", tok.Raw, tok.Line, tok.Column)
+		}
+		p.toks = append(p.toks, formatToken{Token: tok, newlines: newlines})
+		newlines = 0
+		if tok.Kind == jsonlex.TokenEOF {
+			break
+		}
+	}
+
+	leading := p.comments()
+	root, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	trailing := p.trailing()
+	rest := p.comments()
+	if tok := p.peek(); tok.Kind != jsonlex.TokenEOF {
+		return nil, p.unexpected("end of input")
+	}
+
+	f := &formatter{indent: opts.Indent, sort: opts.SortKeys}
+	if f.indent == "" {
+		f.indent = "  "
+	}
+	for _, c := range leading {
+		f.comment(c, 0)
+	}
+	f.value(root, 0)
+	f.trailing(trailing)
+	for _, c := range rest {
+		f.out = append(f.out, '\n')
+		if c.blankBefore {
+			f.out = append(f.out, '\n')
+		}
+		f.out = append(f.out, c.raw...)
+	}
+	return append(f.out, '\n'), nil
+}
+
+// formatToken is a token with the number of line breaks before it.
+type formatToken struct {
+	jsonlex.Token
+	newlines int
+}
+
+// formatComment is a comment kept by FormatSource.
+type formatComment struct {
+	raw         []byte
+	blankBefore bool // preceded by an empty line
+	inline      bool // followed by more code on the same line
+}
+
+// formatNode is a value: a scalar with its source text, or a container.
+type formatNode struct {
+	raw      []byte
+	open     byte // '{' or '[' for containers
+	items    []*formatItem
+	dangling []formatComment // comments before the closing bracket
+}
+
+// formatItem is an object member or array element.
+type formatItem struct {
+	blankBefore bool
+	leading     []formatComment // comments on the lines before it
+	key         []byte
+	sortKey     string
+	value       *formatNode
+	trailing    []formatComment // comments after it on the same line
+}
+
+// formatParser builds formatNodes from tokens, keeping comments.
+type formatParser struct {
+	toks  []formatToken
+	pos   int
+	depth int // containers entered
+}
+
+func (p *formatParser) peek() formatToken {
+	return p.toks[p.pos]
+}
+
+func (p *formatParser) next() formatToken {
+	tok := p.toks[p.pos]
+	if tok.Kind != jsonlex.TokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// comments consumes the comments at the current position.
+func (p *formatParser) comments() []formatComment {
+	var out []formatComment
+	for p.peek().Kind == jsonlex.TokenComment {
+		tok := p.next()
+		out = append(out, formatComment{
+			raw:         tok.Raw,
+			blankBefore: tok.newlines > 1,
+			inline:      bytes.HasPrefix(tok.Raw, []byte("/*")) && p.peek().newlines == 0 && p.peek().Kind != jsonlex.TokenEOF,
+		})
+	}
+	return out
+}
+
+// trailing consumes the comments on the same line as the previous token,
+// unless more code follows them on that line.
+func (p *formatParser) trailing() []formatComment {
+	start := p.pos
+	for p.peek().Kind == jsonlex.TokenComment && p.peek().newlines == 0 {
+		p.next()
+	}
+	switch next := p.peek(); {
+	case p.pos == start:
+		return nil
+	case next.newlines == 0 && next.Kind != jsonlex.TokenComma && next.Kind != jsonlex.TokenRBrace &&
+		next.Kind != jsonlex.TokenRBracket && next.Kind != jsonlex.TokenEOF:
+		// They introduce what comes next
+		p.pos = start
+		return nil
+	}
+	out := make([]formatComment, 0, p.pos-start)
+	for _, tok := range p.toks[start:p.pos] {
+		out = append(out, formatComment{raw: tok.Raw})
+	}
+	return out
+}
+
+func (p *formatParser) unexpected(want string) error {
+	tok := p.peek()
+	if tok.Kind == jsonlex.TokenEOF {
+		return fmt.Errorf("json: unexpected end of input, expected %s", want)
+	}
+	return fmt.Errorf("json: expected %s at line %d, column %d, found %q", want, tok.Line, tok.Column, tok.Raw)
+}
+
+// value parses the value at the current token.
+func (p *formatParser) value() (*formatNode, error) {
+	switch tok := p.peek(); tok.Kind {
+	case jsonlex.TokenLBrace, jsonlex.TokenLBracket:
+		if p.depth++; p.depth > MaxDepth {
+			return nil, fmt.Errorf("%w of %d at line %d, column %d", ErrDepthExceeded, MaxDepth, tok.Line, tok.Column)
+		}
+		n, err := p.container()
+		p.depth--
+		return n, err
+	case jsonlex.TokenString, jsonlex.TokenSingleString, jsonlex.TokenNumber,
+		jsonlex.TokenTrue, jsonlex.TokenFalse, jsonlex.TokenNull:
+		p.next()
+		return &formatNode{raw: tok.Raw}, nil
+	}
+	return nil, p.unexpected("a value")
+}
+
+// container parses an object or array.
+func (p *formatParser) container() (*formatNode, error) {
+	n := &formatNode{open: p.next().Raw[0]}
+	closer := jsonlex.TokenRBracket
+	if n.open == '{' {
+		closer = jsonlex.TokenRBrace
+	}
+
+	for {
+		blank := p.peek().newlines > 1
+		leading := p.comments()
+		if p.peek().Kind == closer {
+			p.next()
+			n.dangling = leading
+			return n, nil
+		}
+
+		item := &formatItem{blankBefore: blank, leading: leading}
+		if n.open == '{' {
+			if err := p.key(item); err != nil {
+				return nil, err
+			}
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		item.value = value
+		item.trailing = p.trailing()
+		n.items = append(n.items, item)
+
+		if p.peek().Kind != jsonlex.TokenComma {
+			n.dangling = p.comments()
+			if p.peek().Kind != closer {
+				if n.open == '{' {
+					return nil, p.unexpected("',' or '}'")
+				}
+				return nil, p.unexpected("',' or ']'")
+			}
+			p.next()
+			return n, nil
+		}
+		p.next()
+		item.trailing = append(item.trailing, p.trailing()...)
+	}
+}
+
+// key parses a member's key and colon into item. Comments around the colon
+// join the comments before the member.
+func (p *formatParser) key(item *formatItem) error {
+	tok := p.peek()
+	switch tok.Kind {
+	case jsonlex.TokenString:
+		key, err := decodeKey(tok.Raw)
+		if err != nil {
+			return fmt.Errorf("json: invalid key %s at line %d, column %d: %w", tok.Raw, tok.Line, tok.Column, err)
+		}
+		item.sortKey = key
+	case jsonlex.TokenSingleString:
+		item.sortKey = string(tok.Raw[1 : len(tok.Raw)-1])
+	case jsonlex.TokenIdentifier:
+		item.sortKey = string(tok.Raw)
+	default:
+		return p.unexpected("object key")
+	}
+	item.key = tok.Raw
+	p.next()
+
+	item.leading = append(item.leading, p.comments()...)
+	if p.peek().Kind != jsonlex.TokenColon {
+		return p.unexpected("':'")
+	}
+	p.next()
+	item.leading = append(item.leading, p.comments()...)
+	return nil
+}
+
+// formatter writes formatNodes.
+type formatter struct {
+	indent string
+	sort   bool
+	out    []byte
+}
+
+func (f *formatter) newline(depth int) {
+	f.out = append(f.out, '\n')
+	f.out = append(f.out, strings.Repeat(f.indent, depth)...)
+}
+
+// blankLine turns the indentation just written by newline into an empty
+// line followed by the indentation.
+func (f *formatter) blankLine(depth int) {
+	f.out = f.out[:len(f.out)-depth*len(f.indent)]
+	f.newline(depth)
+}
+
+// comment writes a comment on its own line, or before the code following
+// it if it is inline, and starts the line after it.
+func (f *formatter) comment(c formatComment, depth int) {
+	if c.blankBefore && len(f.out) > 0 {
+		f.blankLine(depth)
+	}
+	f.out = append(f.out, c.raw...)
+	if c.inline {
+		f.out = append(f.out, ' ')
+	} else {
+		f.newline(depth)
+	}
+}
+
+func (f *formatter) trailing(comments []formatComment) {
+	for _, c := range comments {
+		f.out = append(f.out, ' ')
+		f.out = append(f.out, c.raw...)
+	}
+}
+
+func (f *formatter) value(n *formatNode, depth int) {
+	if n.open == 0 {
+		f.out = append(f.out, n.raw...)
+		return
+	}
+	closer := byte(']')
+	if n.open == '{' {
+		closer = '}'
+	}
+	f.out = append(f.out, n.open)
+	if len(n.items) == 0 && len(n.dangling) == 0 {
+		f.out = append(f.out, closer)
+		return
+	}
+
+	items := n.items
+	if f.sort && n.open == '{' {
+		items = append([]*formatItem(nil), items...)
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].sortKey < items[j].sortKey
+		})
+	}
+	for i, item := range items {
+		f.newline(depth + 1)
+		if item.blankBefore && i > 0 {
+			f.blankLine(depth + 1)
+		}
+		for j, c := range item.leading {
+			if j == 0 {
+				c.blankBefore = false // written above
+			}
+			f.comment(c, depth+1)
+		}
+		if item.key != nil {
+			f.out = append(f.out, item.key...)
+			f.out = append(f.out, ':', ' ')
+		}
+		f.value(item.value, depth+1)
+		if i < len(items)-1 {
+			f.out = append(f.out, ',')
+		}
+		f.trailing(item.trailing)
+	}
+	for i, c := range n.dangling {
+		f.newline(depth + 1)
+		if c.blankBefore && (i > 0 || len(items) > 0) {
+			f.blankLine(depth + 1)
+		}
+		f.out = append(f.out, c.raw...)
+	}
+	f.newline(depth)
+	f.out = append(f.out, closer)
+}
@@ -0,0 +1,98 @@
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatSource(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  FormatOptions
+		want  string
+	}{
+		{"reflow", `{"b":1,"a":[true,null,{}],"c":{"d":"x"}}`, FormatOptions{},
+			"{\n  \"b\": 1,\n  \"a\": [\n    true,\n    null,\n    {}\n  ],\n  \"c\": {\n    \"d\": \"x\"\n  }\n}\n"},
+		{"spelling kept", `[1.50, 1e3, "é\/"]`, FormatOptions{},
+			"[\n  1.50,\n  1e3,\n  \"é\\/\"\n]\n"},
+		{"indent", `{"a":[1]}`, FormatOptions{Indent: "\t"}, "{\n\t\"a\": [\n\t\t1\n\t]\n}\n"},
+		{"sort keys", `{"b":1,"a":{"z":0,"y":0}}`, FormatOptions{SortKeys: true},
+			"{\n  \"a\": {\n    \"y\": 0,\n    \"z\": 0\n  },\n  \"b\": 1\n}\n"},
+		{"blank lines", "{\n\n\"a\": 1,\n\n\n\"b\": 2\n\n}", FormatOptions{},
+			"{\n  \"a\": 1,\n\n  \"b\": 2\n}\n"},
+		{"scalar", ` "x" `, FormatOptions{}, "\"x\"\n"},
+		{"comments", `// config
+{
+  // Listen address
+  "addr": ":8080", // default
+  /* flags */ "debug": true,
+  "list": [1, /* two */ 2,
+    3 // three
+  ],
+
+  // the end
+} // done`, FormatOptions{Lenient: true}, `// config
+{
+  // Listen address
+  "addr": ":8080", // default
+  /* flags */ "debug": true,
+  "list": [
+    1,
+    /* two */ 2,
+    3 // three
+  ]
+
+  // the end
+} // done
+`},
+		{"json5", `{unquoted: 'single', "trailing": [1, 2,],}`, FormatOptions{Lenient: true},
+			"{\n  unquoted: 'single',\n  \"trailing\": [\n    1,\n    2\n  ]\n}\n"},
+		{"sorted comments move", "{\n  // about b\n  b: 1, // b\n  a: 2 // a\n}", FormatOptions{Lenient: true, SortKeys: true},
+			"{\n  a: 2, // a\n  // about b\n  b: 1 // b\n}\n"},
+		{"only comments", "[\n  // nothing yet\n]", FormatOptions{Lenient: true}, "[\n  // nothing yet\n]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatSource([]byte(tt.input), tt.opts)
+			if err != nil {
+				t.Fatalf("FormatSource() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("FormatSource() =\n%s\nwant\n%s", got, tt.want)
+			}
+			// Formatting is stable
+			again, err := FormatSource(got, tt.opts)
+			if err != nil || string(again) != string(got) {
+				t.Errorf("FormatSource() of its output =\n%s\nerror = %v", again, err)
+			}
+		})
+	}
+}
+
+func TestFormatSourceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		lenient bool
+		want    string
+	}{
+		{"invalid JSON", `{"a": 1,}`, false, ""},
+		{"comment in strict mode", `[1] // no`, false, ""},
+		{"missing comma", "{\n  a: 1\n  b: 2\n}", true, "line 3, column 3"},
+		{"unterminated", `[1, 2`, true, "end of input"},
+		{"invalid token", `{"a": @}`, true, "invalid token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := FormatSource([]byte(tt.input), FormatOptions{Lenient: tt.lenient})
+			if err == nil || out != nil {
+				t.Fatalf("FormatSource() = %q, %v, want error", out, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/shapestone/shape-json/internal/fake"
+	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/internal/structtag"
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+// generateDepth is the nesting depth past which Generate leaves pointers
+// nil and slices and maps empty, so that recursive types produce finite
+// documents.
+const generateDepth = 6
+
+// Generate returns a random JSON document for fuzz corpora, load tests, and
+// example responses. source describes the document and is either:
+//
+//   - a *jsonschema.Schema: the document is one the schema accepts, with
+//     its enum, const, minimum and maximum, length, format, and pattern
+//     keywords respected (see Schema.Generate)
+//   - a reflect.Type: the document is a random value of the type, encoded
+//     by Marshal, so struct tags apply; numbers are small, slices and maps
+//     hold one to three entries, and time.Time fields hold a timestamp
+//
+// Strings left open are made to look like what their member holds, going
+// by its name: an e-mail address for "email", a timestamp for
+// "createdAt", a person's name for "name", and a few words otherwise.
+//
+// The same source and seed give the same document.
+//
+// Example:
+//
+//	doc, err := json.Generate(reflect.TypeOf(User{}), 1)
+//	// {"id":"8c1e…","name":"Grace Hopper","email":"ines.maple@example.com","age":412}
+func Generate(source interface{}, seed int64) ([]byte, error) {
+	switch s := source.(type) {
+	case *jsonschema.Schema:
+		v, err := s.Generate(seed)
+		if err != nil {
+			return nil, err
+		}
+		return Marshal(v)
+	case reflect.Type:
+		v := reflect.New(s).Elem()
+		generateValue(rand.New(rand.NewSource(seed)), v, "", 0)
+		return Marshal(v.Interface())
+	default:
+		return nil, fmt.Errorf("json: Generate: source must be a *jsonschema.Schema or a reflect.Type, not %T", source)
+	}
+}
+
+// generateValue sets v, found at a member called name, depth levels down,
+// to a random value. Values it cannot make up, such as channels and
+// unexported fields, are left as they are.
+func generateValue(r *rand.Rand, v reflect.Value, name string, depth int) {
+	switch v.Type() {
+	case timeType:
+		v.Set(reflect.ValueOf(fake.Time(r)))
+		return
+	case durationType:
+		v.SetInt(int64(time.Duration(1+r.Intn(3600)) * time.Second))
+		return
+	case fastparser.NumberType:
+		v.SetString(strconv.Itoa(r.Intn(1000)))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 1)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n := r.Int63n(1000)
+		for v.OverflowInt(n) {
+			n /= 8
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := uint64(r.Int63n(1000))
+		for v.OverflowUint(n) {
+			n /= 8
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(math.Round(r.Float64()*100000) / 100)
+	case reflect.String:
+		v.SetString(fake.ForName(r, name))
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf(fake.ForName(r, name)))
+		}
+	case reflect.Pointer:
+		if depth < generateDepth {
+			p := reflect.New(v.Type().Elem())
+			generateValue(r, p.Elem(), name, depth+1)
+			v.Set(p)
+		}
+	case reflect.Slice:
+		if depth < generateDepth {
+			n := 1 + r.Intn(3)
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+			for i := 0; i < n; i++ {
+				generateValue(r, v.Index(i), name, depth+1)
+			}
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			generateValue(r, v.Index(i), name, depth+1)
+		}
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || depth >= generateDepth {
+			return
+		}
+		v.Set(reflect.MakeMap(v.Type()))
+		for n := 1 + r.Intn(3); n > 0; n-- {
+			key := reflect.New(v.Type().Key()).Elem()
+			key.SetString(fake.Word(r))
+			elem := reflect.New(v.Type().Elem()).Elem()
+			generateValue(r, elem, key.String(), depth+1)
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name, ok := structtag.Name(sf.Name, sf.Tag.Get("json"))
+			if !ok {
+				continue
+			}
+			generateValue(r, v.Field(i), name, depth+1)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+type generateUser struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Email     string            `json:"email,omitempty"`
+	Age       int8              `json:"age"`
+	Balance   float64           `json:"balance"`
+	Active    bool              `json:"active"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Tags      []string          `json:"tags"`
+	Limits    map[string]uint16 `json:"limits"`
+	Parent    *generateUser     `json:"parent,omitempty"`
+	Extra     interface{}       `json:"extra"`
+	Count     Number            `json:"count"`
+	Secret    string            `json:"-"`
+	internal  string
+	Scores    [2]float32         `json:"scores"`
+	Nested    struct{ A string } `json:"nested"`
+}
+
+func TestGenerate_Type(t *testing.T) {
+	data, err := Generate(reflect.TypeOf(generateUser{}), 1)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	var u generateUser
+	if err := Unmarshal(data, &u); err != nil {
+		t.Fatalf("Unmarshal(%s) error = %v", data, err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-`).MatchString(u.ID) || u.Name == "" ||
+		!regexp.MustCompile(`@example\.`).MatchString(u.Email) || u.CreatedAt.Year() < 2020 ||
+		len(u.Tags) == 0 || len(u.Limits) == 0 || u.Parent == nil || u.Extra == nil ||
+		u.Count == "" || u.Secret != "" || u.Nested.A == "" {
+		t.Errorf("Generate() = %s", data)
+	}
+
+	again, _ := Generate(reflect.TypeOf(generateUser{}), 1)
+	if string(again) != string(data) {
+		t.Errorf("Generate(seed 1) =\n%s\nthen\n%s", data, again)
+	}
+	other, _ := Generate(reflect.TypeOf(generateUser{}), 2)
+	if string(other) == string(data) {
+		t.Error("Generate returns the same document for seeds 1 and 2")
+	}
+
+	if data, err := Generate(reflect.TypeOf([]int{}), 3); err != nil || !regexp.MustCompile(`^\[\d+(,\d+)*\]$`).Match(data) {
+		t.Errorf("Generate([]int) = %s, %v", data, err)
+	}
+}
+
+func TestGenerate_Schema(t *testing.T) {
+	schema := jsonschema.MustCompile([]byte(`{
+		"type": "object",
+		"required": ["sku", "price", "status"],
+		"properties": {
+			"sku": {"type": "string", "pattern": "^[A-Z]{2}-\\d{3}$"},
+			"price": {"type": "number", "minimum": 1, "maximum": 5},
+			"status": {"enum": ["draft", "live"]}
+		}
+	}`))
+	for seed := int64(0); seed < 20; seed++ {
+		data, err := Generate(schema, seed)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if err := schema.ValidateBytes(data); err != nil {
+			t.Errorf("Generate() = %s, which is invalid: %v", data, err)
+		}
+	}
+}
+
+func TestGenerate_BadSource(t *testing.T) {
+	if _, err := Generate(generateUser{}, 1); err == nil {
+		t.Error("Generate(struct value) returned no error")
+	}
+	if _, err := Generate(jsonschema.MustCompile([]byte(`false`)), 1); err == nil {
+		t.Error("Generate(false schema) returned no error")
+	}
+}
@@ -0,0 +1,49 @@
+package json
+
+import (
+	"io"
+)
+
+// Decode unmarshals data into a new value of type T and returns it, so call
+// sites need neither a variable declaration nor a pointer:
+//
+//	user, err := json.Decode[User](data)
+//	ids, err := json.Decode[[]int64](data)
+//
+// It follows the same rules as Unmarshal. On error the zero T is returned.
+func Decode[T any](data []byte) (T, error) {
+	var v T
+	if err := Unmarshal(data, &v); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
+
+// MustDecode is like Decode but panics if data cannot be decoded. It is
+// intended for embedded or test data known to be valid.
+func MustDecode[T any](data []byte) T {
+	v, err := Decode[T](data)
+	if err != nil {
+		panic("json: MustDecode: " + err.Error())
+	}
+	return v
+}
+
+// DecodeReader reads r to EOF and decodes the contents like Decode.
+//
+//	cfg, err := json.DecodeReader[Config](file)
+func DecodeReader[T any](r io.Reader) (T, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return Decode[T](data)
+}
+
+// Encode marshals v like Marshal. It is the counterpart of Decode and
+// documents the encoded type at the call site.
+func Encode[T any](v T) ([]byte, error) {
+	return Marshal(v)
+}
@@ -0,0 +1,95 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	type user struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+
+	u, err := Decode[user]([]byte(`{"name":"Alice","tags":["a","b"]}`))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if want := (user{Name: "Alice", Tags: []string{"a", "b"}}); !reflect.DeepEqual(u, want) {
+		t.Errorf("Decode() = %+v, want %+v", u, want)
+	}
+
+	ids, err := Decode[[]int64]([]byte(`[1,2,3]`))
+	if err != nil || !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+		t.Errorf("Decode[[]int64]() = %v, %v", ids, err)
+	}
+
+	p, err := Decode[*user]([]byte(`{"name":"Bob"}`))
+	if err != nil || p == nil || p.Name != "Bob" {
+		t.Errorf("Decode[*user]() = %v, %v", p, err)
+	}
+
+	m, err := Decode[map[string]interface{}]([]byte(`{"n":1}`))
+	if err != nil || m["n"] != int64(1) {
+		t.Errorf("Decode[map]() = %v, %v", m, err)
+	}
+
+	// Partially decoded values are not returned on error
+	u, err = Decode[user]([]byte(`{"name":"Alice","tags":[1]}`))
+	if err == nil {
+		t.Error("Decode() expected type error")
+	}
+	if !reflect.DeepEqual(u, user{}) {
+		t.Errorf("Decode() on error = %+v, want zero value", u)
+	}
+}
+
+func TestMustDecode(t *testing.T) {
+	if got := MustDecode[int]([]byte(`42`)); got != 42 {
+		t.Errorf("MustDecode() = %d", got)
+	}
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("MustDecode did not panic")
+		}
+		if msg, _ := r.(string); !strings.HasPrefix(msg, "json: MustDecode: ") {
+			t.Errorf("panic = %v", r)
+		}
+	}()
+	MustDecode[int]([]byte(`"x"`))
+}
+
+func TestDecodeReader(t *testing.T) {
+	got, err := DecodeReader[map[string]string](strings.NewReader(`{"a":"b"}`))
+	if err != nil || got["a"] != "b" {
+		t.Errorf("DecodeReader() = %v, %v", got, err)
+	}
+
+	readErr := errors.New("boom")
+	if _, err := DecodeReader[int](&errReader{err: readErr}); !errors.Is(err, readErr) {
+		t.Errorf("DecodeReader() error = %v, want %v", err, readErr)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	data, err := Encode([]string{"a", "b"})
+	if err != nil || string(data) != `["a","b"]` {
+		t.Errorf("Encode() = %s, %v", data, err)
+	}
+
+	back, err := Decode[[]string](data)
+	if err != nil || !reflect.DeepEqual(back, []string{"a", "b"}) {
+		t.Errorf("round trip = %v, %v", back, err)
+	}
+}
+
+// errReader fails every Read with err.
+type errReader struct{ err error }
+
+func (r *errReader) Read([]byte) (int, error) { return 0, r.err }
@@ -0,0 +1,281 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// hashFlushThreshold is the buffered size at which canonical output is
+// written to the hash.
+const hashFlushThreshold = 4 * 1024
+
+// HashValue hashes the canonical JSON form of v with a hash created by
+// newHash and returns the digest.
+//
+// Two values that represent the same JSON data hash identically, regardless
+// of how they are held in memory or the order in which object members were
+// inserted. This makes HashValue suitable for deduplication, cache keys, and
+// change detection:
+//
+//	sum, err := json.HashValue(doc, sha256.New)
+//
+// v may be any value accepted by Marshal, as well as a *Document, *Array,
+// FrozenDocument, FrozenArray, or AST node from Parse. Native JSON values
+// (maps, slices, primitives) and the DOM and AST types are walked directly;
+// other types are marshaled first.
+//
+// The canonical form is compact JSON with object members sorted by key,
+// strings escaped as Marshal does, and numbers in their shortest exact form,
+// with integral floats written as integers (so 1, 1.0, and int64(1) hash the
+// same). The digest is therefore the hash of that text, but the text itself
+// is streamed into the hash in chunks and never assembled in full.
+//
+// NaN and infinite floats have no JSON form and return an error.
+func HashValue(v interface{}, newHash func() hash.Hash) ([]byte, error) {
+	h := newHash()
+	w := &canonicalWriter{h: h, buf: make([]byte, 0, 256)}
+	if err := w.value(v); err != nil {
+		return nil, err
+	}
+	w.flush()
+	return h.Sum(nil), nil
+}
+
+// canonicalWriter streams the canonical form of a value into a hash. With
+// a nil hash it only accumulates buf.
+type canonicalWriter struct {
+	h   hash.Hash
+	buf []byte
+
+	// jcs selects the RFC 8785 form used by MarshalOptions.Canonical
+	// instead of the HashValue form; see canonical.go.
+	jcs bool
+}
+
+// flush writes buffered output to the hash. hash.Hash never returns an error.
+func (w *canonicalWriter) flush() {
+	w.h.Write(w.buf)
+	w.buf = w.buf[:0]
+}
+
+// value writes the canonical form of v.
+func (w *canonicalWriter) value(v interface{}) error {
+	if w.h != nil && len(w.buf) >= hashFlushThreshold {
+		w.flush()
+	}
+
+	switch val := v.(type) {
+	case nil:
+		w.buf = append(w.buf, "null"...)
+	case bool:
+		if val {
+			w.buf = append(w.buf, "true"...)
+		} else {
+			w.buf = append(w.buf, "false"...)
+		}
+	case string:
+		w.str(val)
+	case int:
+		w.buf = strconv.AppendInt(w.buf, int64(val), 10)
+	case int8:
+		w.buf = strconv.AppendInt(w.buf, int64(val), 10)
+	case int16:
+		w.buf = strconv.AppendInt(w.buf, int64(val), 10)
+	case int32:
+		w.buf = strconv.AppendInt(w.buf, int64(val), 10)
+	case int64:
+		w.buf = strconv.AppendInt(w.buf, val, 10)
+	case uint:
+		w.buf = strconv.AppendUint(w.buf, uint64(val), 10)
+	case uint8:
+		w.buf = strconv.AppendUint(w.buf, uint64(val), 10)
+	case uint16:
+		w.buf = strconv.AppendUint(w.buf, uint64(val), 10)
+	case uint32:
+		w.buf = strconv.AppendUint(w.buf, uint64(val), 10)
+	case uint64:
+		w.buf = strconv.AppendUint(w.buf, val, 10)
+	case float32:
+		// Use the float32 shortest form so float32(0.1) hashes like 0.1
+		f, _ := strconv.ParseFloat(strconv.FormatFloat(float64(val), 'g', -1, 32), 64)
+		return w.float(f)
+	case float64:
+		return w.float(val)
+	case map[string]interface{}:
+		return w.object(val)
+	case []interface{}:
+		return w.array(val)
+	case *Document:
+		if val == nil {
+			w.buf = append(w.buf, "null"...)
+			return nil
+		}
+		return w.object(val.data)
+	case *Array:
+		if val == nil {
+			w.buf = append(w.buf, "null"...)
+			return nil
+		}
+		return w.array(val.data)
+	case FrozenDocument:
+		return w.object(val.data)
+	case FrozenArray:
+		return w.array(val.data)
+	case ast.SchemaNode:
+		return w.node(val)
+	default:
+		data, err := Marshal(v)
+		if err != nil {
+			return err
+		}
+		native, err := fastparser.NewParser(data).Parse()
+		if err != nil {
+			return err
+		}
+		return w.value(native)
+	}
+	return nil
+}
+
+// str writes a quoted, escaped string.
+func (w *canonicalWriter) str(s string) {
+	w.buf = append(w.buf, '"')
+	if w.jcs {
+		w.buf = appendMinimalEscapedString(w.buf, s)
+	} else {
+		w.buf = appendEscapedString(w.buf, s)
+	}
+	w.buf = append(w.buf, '"')
+}
+
+// float writes a float64 in canonical form.
+func (w *canonicalWriter) float(f float64) error {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("json: HashValue: unsupported value %v", f)
+	}
+	if w.jcs {
+		w.buf = appendES6Float(w.buf, f)
+		return nil
+	}
+	if f == 0 {
+		// Covers negative zero
+		w.buf = append(w.buf, '0')
+		return nil
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		w.buf = strconv.AppendFloat(w.buf, f, 'f', -1, 64)
+		return nil
+	}
+	w.buf = strconv.AppendFloat(w.buf, f, 'g', -1, 64)
+	return nil
+}
+
+// object writes a map with its keys sorted.
+func (w *canonicalWriter) object(m map[string]interface{}) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	if w.jcs {
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+	} else {
+		sort.Strings(keys)
+	}
+
+	w.buf = append(w.buf, '{')
+	for i, k := range keys {
+		if i > 0 {
+			w.buf = append(w.buf, ',')
+		}
+		w.str(k)
+		w.buf = append(w.buf, ':')
+		if err := w.value(m[k]); err != nil {
+			return err
+		}
+	}
+	w.buf = append(w.buf, '}')
+	return nil
+}
+
+// array writes a slice in order.
+func (w *canonicalWriter) array(a []interface{}) error {
+	w.buf = append(w.buf, '[')
+	for i, elem := range a {
+		if i > 0 {
+			w.buf = append(w.buf, ',')
+		}
+		if err := w.value(elem); err != nil {
+			return err
+		}
+	}
+	w.buf = append(w.buf, ']')
+	return nil
+}
+
+// node writes an AST node without converting it to native values first.
+func (w *canonicalWriter) node(node ast.SchemaNode) error {
+	switch n := node.(type) {
+	case *ast.LiteralNode:
+		return w.value(n.Value())
+
+	case *ast.ArrayDataNode:
+		w.buf = append(w.buf, '[')
+		for i, elem := range n.Elements() {
+			if i > 0 {
+				w.buf = append(w.buf, ',')
+			}
+			if err := w.value(elem); err != nil {
+				return err
+			}
+		}
+		w.buf = append(w.buf, ']')
+		return nil
+
+	case *ast.ObjectNode:
+		props := n.Properties()
+		if isArray(props) {
+			// Legacy array representation with "0", "1", ... keys
+			w.buf = append(w.buf, '[')
+			for i := 0; i < len(props); i++ {
+				if i > 0 {
+					w.buf = append(w.buf, ',')
+				}
+				if err := w.value(props[strconv.Itoa(i)]); err != nil {
+					return err
+				}
+			}
+			w.buf = append(w.buf, ']')
+			return nil
+		}
+
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		w.buf = append(w.buf, '{')
+		for i, k := range keys {
+			if i > 0 {
+				w.buf = append(w.buf, ',')
+			}
+			w.str(k)
+			w.buf = append(w.buf, ':')
+			if err := w.value(props[k]); err != nil {
+				return err
+			}
+		}
+		w.buf = append(w.buf, '}')
+		return nil
+
+	default:
+		return errors.New("json: HashValue: unsupported AST node type")
+	}
+}
@@ -0,0 +1,134 @@
+package json
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestHashValue_CanonicalForm(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	tests := []struct {
+		name string
+		v    interface{}
+		want string
+	}{
+		{"null", nil, `null`},
+		{"string", "a\"b", `"a\"b"`},
+		{"integral float", 1.0, `1`},
+		{"negative zero", math.Copysign(0, -1), `0`},
+		{"fraction", 0.5, `0.5`},
+		{"float32", float32(0.1), `0.1`},
+		{"large float", 1e300, `1e+300`},
+		{"uint64", uint64(math.MaxUint64), `18446744073709551615`},
+		{"sorted keys", map[string]interface{}{"b": 1, "a": []interface{}{true, nil}}, `{"a":[true,null],"b":1}`},
+		{"struct", struct {
+			Z string `json:"z"`
+			A int    `json:"a"`
+		}{"x", 2}, `{"a":2,"z":"x"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HashValue(tt.v, sha256.New)
+			if err != nil {
+				t.Fatalf("HashValue() error = %v", err)
+			}
+			want := sha256.Sum256([]byte(tt.want))
+			if !bytes.Equal(got, want[:]) {
+				t.Errorf("HashValue() does not match hash of %s", tt.want)
+			}
+		})
+	}
+}
+
+func TestHashValue_RepresentationIndependent(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	input := `{"name": "Alice", "tags": ["go", "json"], "age": 30, "address": {"zip": "12345", "city": "Paris"}}`
+	reordered := `{"address":{"city":"Paris","zip":"12345"},"age":30.0,"tags":["go","json"],"name":"Alice"}`
+
+	node, err := Parse(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := ParseDocument(reordered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var native interface{}
+	if err := Unmarshal([]byte(input), &native); err != nil {
+		t.Fatal(err)
+	}
+	type address struct {
+		City string `json:"city"`
+		Zip  string `json:"zip"`
+	}
+	typed := struct {
+		Name    string   `json:"name"`
+		Tags    []string `json:"tags"`
+		Age     int      `json:"age"`
+		Address address  `json:"address"`
+	}{"Alice", []string{"go", "json"}, 30, address{"Paris", "12345"}}
+
+	want, err := HashValue(native, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, v := range map[string]interface{}{
+		"ast":      node,
+		"document": doc,
+		"frozen":   doc.Freeze(),
+		"struct":   typed,
+	} {
+		got, err := HashValue(v, sha256.New)
+		if err != nil {
+			t.Fatalf("%s: HashValue() error = %v", name, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: hash differs from native value", name)
+		}
+	}
+}
+
+func TestHashValue_DetectsChanges(t *testing.T) {
+	a, _ := HashValue(map[string]interface{}{"a": []interface{}{1, 2}}, sha256.New)
+	b, _ := HashValue(map[string]interface{}{"a": []interface{}{2, 1}}, sha256.New)
+	c, _ := HashValue(map[string]interface{}{"a": "[1,2]"}, sha256.New)
+	if bytes.Equal(a, b) || bytes.Equal(a, c) || bytes.Equal(b, c) {
+		t.Error("different values produced the same hash")
+	}
+}
+
+func TestHashValue_LargeValue(t *testing.T) {
+	arr := NewArray()
+	for i := 0; i < 2000; i++ {
+		arr.AddString(strings.Repeat("x", i%50))
+	}
+	got, err := HashValue(arr, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := arr.MarshalJSON()
+	want := sha256.Sum256(data)
+	if !bytes.Equal(got, want[:]) {
+		t.Error("hash of large array does not match hash of its JSON")
+	}
+}
+
+func TestHashValue_Errors(t *testing.T) {
+	for _, v := range []interface{}{
+		math.NaN(),
+		[]interface{}{math.Inf(1)},
+		make(chan int),
+	} {
+		if _, err := HashValue(v, sha256.New); err == nil {
+			t.Errorf("HashValue(%T) expected error", v)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package json
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/internal/parser"
+)
+
+// TextEdit replaces the bytes [Start, End) of a document with Text.
+// Start == End inserts, and an empty Text deletes.
+type TextEdit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+// Tree is a parsed document that is kept up to date as its text is edited,
+// for editors and language servers that need an AST after every keystroke.
+//
+// Edit reparses only the innermost object or array enclosing the edit and
+// splices the result into the tree. Nodes before the edit are kept as they
+// are, and nodes after it get their positions shifted without being parsed
+// again. The result is always the tree Parse would return for the new text:
+//
+//	tree, err := json.ParseTree(`{"name": "Alice", "tags": ["a", "b"]}`)
+//	err = tree.Edit(json.TextEdit{Start: 32, End: 32, Text: `"x", "y", `})
+//	root := tree.Root() // {"name": "Alice", "tags": ["a", "x", "y", "b"]}
+//
+// SetProperty, RemoveProperty, and ReplaceChild change the tree by node
+// rather than by offset, for refactoring tools that work on the AST; they
+// are carried out as edits of the text, so text and tree never disagree.
+//
+// Edit updates the tree in place: object and array nodes reachable from an
+// earlier Root may see new children, so use the result of Root after each
+// edit. A Tree is not safe for concurrent use.
+type Tree struct {
+	text []byte
+	root *span // nil while the text is not valid JSON
+}
+
+// span records where a node of the tree lies in the text.
+type span struct {
+	node       ast.SchemaNode
+	start, end int    // byte offsets of the value
+	key        string // member name, for members of an object
+	keyStart   int    // byte offset of the member name's opening quote
+	children   []*span
+}
+
+// ParseTree parses text like Parse and returns a Tree for editing it.
+func ParseTree(text string) (*Tree, error) {
+	t := &Tree{text: []byte(text)}
+	if err := t.reparse(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Root returns the root node of the tree, or nil if the text is not valid
+// JSON after the last Edit.
+func (t *Tree) Root() ast.SchemaNode {
+	if t.root == nil {
+		return nil
+	}
+	return t.root.node
+}
+
+// Text returns the current text of the document.
+func (t *Tree) Text() string {
+	return string(t.text)
+}
+
+// Edit applies an edit to the text and updates the tree.
+//
+// If the new text is not valid JSON, Edit returns the error Parse would
+// return and Root returns nil until a later edit makes the text valid again;
+// the text itself is always updated. An edit whose range is outside the
+// text is rejected without changing anything.
+func (t *Tree) Edit(e TextEdit) error {
+	if e.Start < 0 || e.End < e.Start || e.End > len(t.text) {
+		return fmt.Errorf("json: edit range [%d, %d) out of bounds for text of length %d", e.Start, e.End, len(t.text))
+	}
+	old := t.text
+	text := make([]byte, 0, len(old)-(e.End-e.Start)+len(e.Text))
+	text = append(text, old[:e.Start]...)
+	text = append(text, e.Text...)
+	text = append(text, old[e.End:]...)
+	t.text = text
+
+	if t.root == nil {
+		return t.reparse()
+	}
+	c := t.root.enclosing(e)
+	if c == nil || !t.reparseSpan(c, old, e) {
+		return t.reparse()
+	}
+	return nil
+}
+
+// SetProperty sets the member key of obj, an object node of the tree, to
+// value, replacing the member's value if it has one and appending the
+// member otherwise. The change is made as an Edit of the text with value
+// rendered compactly, so the text, the positions of all nodes, and the
+// nodes reachable from Root stay consistent; obj itself may be replaced by
+// a new node.
+//
+//	root := tree.Root().(*ast.ObjectNode)
+//	err := tree.SetProperty(root, "active", ast.NewLiteralNode(false, ast.Position{}))
+func (t *Tree) SetProperty(obj *ast.ObjectNode, key string, value ast.SchemaNode) error {
+	s, err := t.find(obj)
+	if err != nil {
+		return err
+	}
+	text, err := Render(value)
+	if err != nil {
+		return err
+	}
+	for _, child := range s.children {
+		if child.key == key {
+			return t.Edit(TextEdit{Start: child.start, End: child.end, Text: string(text)})
+		}
+	}
+	member := `"` + escapeString(key) + `":` + string(text)
+	if n := len(s.children); n > 0 {
+		end := s.children[n-1].end
+		return t.Edit(TextEdit{Start: end, End: end, Text: "," + member})
+	}
+	return t.Edit(TextEdit{Start: s.start + 1, End: s.start + 1, Text: member})
+}
+
+// RemoveProperty removes the member key, and the comma separating it from
+// its neighbours, from obj, an object node of the tree. It returns an error
+// if obj has no such member. Like SetProperty, it edits the text, so the
+// tree stays consistent.
+func (t *Tree) RemoveProperty(obj *ast.ObjectNode, key string) error {
+	s, err := t.find(obj)
+	if err != nil {
+		return err
+	}
+	for i, child := range s.children {
+		if child.key != key {
+			continue
+		}
+		switch {
+		case i > 0:
+			return t.Edit(TextEdit{Start: s.children[i-1].end, End: child.end})
+		case len(s.children) > 1:
+			return t.Edit(TextEdit{Start: child.keyStart, End: s.children[1].keyStart})
+		default:
+			return t.Edit(TextEdit{Start: child.keyStart, End: child.end})
+		}
+	}
+	return fmt.Errorf("json: object has no member %q", key)
+}
+
+// ReplaceChild replaces old, a member value or element of parent, with
+// repl. parent must be an object or array node of the tree. Like
+// SetProperty, it edits the text, so the tree stays consistent.
+func (t *Tree) ReplaceChild(parent, old, repl ast.SchemaNode) error {
+	s, err := t.find(parent)
+	if err != nil {
+		return err
+	}
+	text, err := Render(repl)
+	if err != nil {
+		return err
+	}
+	for _, child := range s.children {
+		if child.node == old {
+			return t.Edit(TextEdit{Start: child.start, End: child.end, Text: string(text)})
+		}
+	}
+	return fmt.Errorf("json: node is not a child of parent")
+}
+
+// find returns the span of node, which must be an object or array node of
+// the tree whose extent in the text is known.
+func (t *Tree) find(node ast.SchemaNode) (*span, error) {
+	if t.root == nil {
+		return nil, fmt.Errorf("json: tree text is not valid JSON")
+	}
+	if s := t.root.find(node); s != nil && s.children != nil {
+		return s, nil
+	}
+	return nil, fmt.Errorf("json: node is not an object or array of the tree")
+}
+
+// find returns the span of node among s and its descendants, or nil.
+func (s *span) find(node ast.SchemaNode) *span {
+	if s.node == node {
+		return s
+	}
+	for _, child := range s.children {
+		if c := child.find(node); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// reparse parses the whole text.
+func (t *Tree) reparse() error {
+	node, err := Parse(string(t.text))
+	if err != nil {
+		t.root = nil
+		return err
+	}
+	// Parse accepts some text the span walk does not, such as trailing
+	// garbage or unknown escapes; edits to it are always reparsed in full
+	start := skipSpace(t.text, 0)
+	if end, err := fastparser.SkipValue(t.text, start); err != nil || skipSpace(t.text, end) != len(t.text) {
+		t.root = &span{node: node}
+		return nil
+	}
+	t.root = buildSpan(t.text, start, node)
+	return nil
+}
+
+// enclosing returns the innermost object or array span whose brackets
+// enclose the edit, which is given in the coordinates of the text before
+// it, or nil if there is none.
+func (s *span) enclosing(e TextEdit) *span {
+	if s.children == nil || e.Start <= s.start || e.End >= s.end {
+		return nil
+	}
+	for _, child := range s.children {
+		if c := child.enclosing(e); c != nil {
+			return c
+		}
+	}
+	return s
+}
+
+// reparseSpan parses the new text of the container c, which encloses the
+// edit e of old, and splices it into the tree. It reports false if the
+// container no longer parses on its own, in which case the tree must be
+// rebuilt from scratch.
+func (t *Tree) reparseSpan(c *span, old []byte, e TextEdit) bool {
+	delta := len(e.Text) - (e.End - e.Start)
+	sub := t.text[c.start : c.end+delta]
+
+	// The parser ignores trailing input it cannot tokenize, so check that
+	// the container is still exactly one value before trusting it
+	if end, err := fastparser.SkipValue(sub, 0); err != nil || end != len(sub) {
+		return false
+	}
+	node, err := parser.NewParser(string(sub)).Parse()
+	if err != nil {
+		return false
+	}
+
+	// Positions from the sub-parse are relative to the container, whose own
+	// position is unchanged since it starts before the edit
+	anchor := c.node.Position()
+	node = reposition(node, func(p ast.Position) ast.Position {
+		p.Offset += anchor.Offset
+		if p.Line == 1 {
+			p.Column += anchor.Column - 1
+		}
+		p.Line += anchor.Line - 1
+		return p
+	})
+	repl := buildSpan(t.text, c.start, node)
+	repl.key, repl.keyStart = c.key, c.keyStart
+
+	// Nodes after the edit move by the change in characters and lines, and
+	// those on the line where the edit ended also by the change in columns
+	oldEnd := advance(anchor, old[c.start:e.End])
+	newEnd := advance(anchor, t.text[c.start:e.Start+len(e.Text)])
+	runes := utf8.RuneCountInString(e.Text) - utf8.RuneCount(old[e.Start:e.End])
+	shift := func(p ast.Position) ast.Position {
+		if p.Line == oldEnd.Line {
+			p.Column += newEnd.Column - oldEnd.Column
+		}
+		p.Offset += runes
+		p.Line += newEnd.Line - oldEnd.Line
+		return p
+	}
+
+	if c == t.root {
+		t.root = repl
+	} else {
+		t.root.splice(c, repl, delta, shift)
+	}
+	return true
+}
+
+// splice replaces target, a descendant of s, with repl, and moves the
+// spans after it by delta bytes and their nodes' positions by shift.
+func (s *span) splice(target, repl *span, delta int, shift func(ast.Position) ast.Position) {
+	s.end += delta
+	for i, child := range s.children {
+		switch {
+		case child.end <= target.start:
+			continue
+		case child == target:
+			s.children[i] = repl
+		case child.start > target.start:
+			child.move(delta, shift)
+		default:
+			child.splice(target, repl, delta, shift)
+		}
+		s.setChild(i)
+	}
+}
+
+// move shifts s and its descendants by delta bytes and their positions by
+// shift.
+func (s *span) move(delta int, shift func(ast.Position) ast.Position) {
+	s.start += delta
+	s.end += delta
+	s.keyStart += delta
+	for i, child := range s.children {
+		child.move(delta, shift)
+		s.setChild(i)
+	}
+	if s.node != nil {
+		s.node = withPosition(s.node, shift(s.node.Position()))
+	}
+}
+
+// setChild stores the node of the i-th child span in the node of s.
+func (s *span) setChild(i int) {
+	child := s.children[i]
+	if child.node == nil {
+		return
+	}
+	switch n := s.node.(type) {
+	case *ast.ObjectNode:
+		n.Properties()[child.key] = child.node
+	case *ast.ArrayDataNode:
+		n.Elements()[i] = child.node
+	}
+}
+
+// buildSpan returns the span tree of the value starting at pos in data,
+// which node was parsed from. data must hold valid JSON at pos.
+func buildSpan(data []byte, pos int, node ast.SchemaNode) *span {
+	s := &span{node: node, start: pos}
+
+	switch data[pos] {
+	case '{':
+		obj, _ := node.(*ast.ObjectNode)
+		s.children = []*span{}
+		pos = skipSpace(data, pos+1)
+		for data[pos] != '}' {
+			keyStart := pos
+			keyEnd, _ := fastparser.SkipValue(data, pos)
+			key, _ := decodeKey(data[pos:keyEnd])
+			pos = skipSpace(data, skipSpace(data, keyEnd)+1) // past ':'
+
+			var child *span
+			if value, ok := obj.GetProperty(key); ok {
+				child = buildSpan(data, pos, value)
+			} else {
+				// Not in the AST; keep only the extent so offsets stay right
+				end, _ := fastparser.SkipValue(data, pos)
+				child = &span{start: pos, end: end}
+			}
+			child.key, child.keyStart = key, keyStart
+			s.children = append(s.children, child)
+			pos = nextMember(data, child.end)
+		}
+		s.end = pos + 1
+
+	case '[':
+		arr, _ := node.(*ast.ArrayDataNode)
+		s.children = []*span{}
+		pos = skipSpace(data, pos+1)
+		for data[pos] != ']' {
+			child := buildSpan(data, pos, arr.Get(len(s.children)))
+			s.children = append(s.children, child)
+			pos = nextMember(data, child.end)
+		}
+		s.end = pos + 1
+
+	default:
+		s.end, _ = fastparser.SkipValue(data, pos)
+	}
+	return s
+}
+
+// nextMember returns the offset of the next member or element after a
+// value ending at pos, or of the closing bracket.
+func nextMember(data []byte, pos int) int {
+	pos = skipSpace(data, pos)
+	if data[pos] == ',' {
+		pos = skipSpace(data, pos+1)
+	}
+	return pos
+}
+
+// reposition returns node with f applied to its position and to those of
+// all its descendants. Object and array nodes are rebuilt around their
+// existing, updated children.
+func reposition(node ast.SchemaNode, f func(ast.Position) ast.Position) ast.SchemaNode {
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		props := n.Properties()
+		for k, child := range props {
+			props[k] = reposition(child, f)
+		}
+	case *ast.ArrayDataNode:
+		elems := n.Elements()
+		for i, child := range elems {
+			elems[i] = reposition(child, f)
+		}
+	}
+	return withPosition(node, f(node.Position()))
+}
+
+// withPosition returns a copy of node at pos, sharing its children.
+func withPosition(node ast.SchemaNode, pos ast.Position) ast.SchemaNode {
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		return ast.NewObjectNode(n.Properties(), pos)
+	case *ast.ArrayDataNode:
+		return ast.NewArrayDataNode(n.Elements(), pos)
+	case *ast.LiteralNode:
+		return ast.NewLiteralNode(n.Value(), pos)
+	}
+	return node
+}
+
+// advance returns the position reached by moving from p over text, the
+// way the tokenizer counts: offsets and columns in characters, lines at
+// each '\n'.
+func advance(p ast.Position, text []byte) ast.Position {
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		text = text[size:]
+		p.Offset++
+		if r == '\n' {
+			p.Line++
+			p.Column = 1
+		} else {
+			p.Column++
+		}
+	}
+	return p
+}
@@ -0,0 +1,346 @@
+package json
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+// dumpTree renders a node and its descendants with their positions, so two
+// trees compare equal only if they agree on both.
+func dumpTree(n ast.SchemaNode) string {
+	var b strings.Builder
+	var walk func(ast.SchemaNode)
+	walk = func(n ast.SchemaNode) {
+		p := n.Position()
+		fmt.Fprintf(&b, "@%d:%d:%d", p.Offset, p.Line, p.Column)
+		switch n := n.(type) {
+		case *ast.ObjectNode:
+			props := n.Properties()
+			keys := make([]string, 0, len(props))
+			for k := range props {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			b.WriteByte('{')
+			for _, k := range keys {
+				fmt.Fprintf(&b, "%q:", k)
+				walk(props[k])
+				b.WriteByte(',')
+			}
+			b.WriteByte('}')
+		case *ast.ArrayDataNode:
+			b.WriteByte('[')
+			for _, e := range n.Elements() {
+				walk(e)
+				b.WriteByte(',')
+			}
+			b.WriteByte(']')
+		case *ast.LiteralNode:
+			fmt.Fprintf(&b, "%#v", n.Value())
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+// checkTree compares the tree with a full parse of its text.
+func checkTree(t *testing.T, tree *Tree) {
+	t.Helper()
+	want, err := Parse(tree.Text())
+	if err != nil {
+		if tree.Root() != nil {
+			t.Fatalf("Root() != nil for invalid text %q", tree.Text())
+		}
+		return
+	}
+	if tree.Root() == nil {
+		t.Fatalf("Root() = nil for valid text %q", tree.Text())
+	}
+	if got, want := dumpTree(tree.Root()), dumpTree(want); got != want {
+		t.Fatalf("tree for %q:\n got %s\nwant %s", tree.Text(), got, want)
+	}
+}
+
+func TestTreeEdit(t *testing.T) {
+	doc := "{\n  \"name\": \"Alice\",\n  \"tags\": [\"a\", \"b\"],\n  \"address\": {\"city\": \"Zürich\", \"zip\": 8000},\n  \"active\": true\n}"
+
+	tests := []struct {
+		name  string
+		old   string // text replaced, at its first occurrence
+		text  string
+		valid bool
+	}{
+		{"replace scalar", `"Alice"`, `"Bob"`, true},
+		{"insert element", `"a", `, `"a", "x", "y", `, true},
+		{"delete element", `, "b"`, ``, true},
+		{"insert lines", `"a", `, "\"a\",\n    \"x\",\n    ", true},
+		{"multibyte", `"Zürich"`, `"Genève, 日本"`, true},
+		{"new member", `"zip": 8000`, `"zip": 8000, "country": "CH"`, true},
+		{"rename key", `"city"`, `"town"`, true},
+		{"nested value", `8000`, `{"code": [8000, 8001]}`, true},
+		{"break syntax", `"b"]`, `"b"`, false},
+		{"duplicate key", `"zip"`, `"city"`, false},
+		{"root edit", `"active": true`, `"active": false, "n": null`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := ParseTree(doc)
+			if err != nil {
+				t.Fatalf("ParseTree() error = %v", err)
+			}
+			start := strings.Index(doc, tt.old)
+			err = tree.Edit(TextEdit{Start: start, End: start + len(tt.old), Text: tt.text})
+			if (err == nil) != tt.valid {
+				t.Fatalf("Edit() error = %v, want valid = %v", err, tt.valid)
+			}
+			if want := doc[:start] + tt.text + doc[start+len(tt.old):]; tree.Text() != want {
+				t.Fatalf("Text() = %q, want %q", tree.Text(), want)
+			}
+			checkTree(t, tree)
+		})
+	}
+}
+
+func TestTreeEditKeepsUnchangedNodes(t *testing.T) {
+	doc := `{"before": {"x": 1}, "list": [1, 2, 3], "after": {"y": 2}}`
+	tree, err := ParseTree(doc)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+	before, _ := tree.Root().(*ast.ObjectNode).GetProperty("before")
+
+	start := strings.Index(doc, "2,")
+	if err := tree.Edit(TextEdit{Start: start, End: start + 1, Text: "20"}); err != nil {
+		t.Fatalf("Edit() error = %v", err)
+	}
+	checkTree(t, tree)
+
+	root := tree.Root().(*ast.ObjectNode)
+	if got, _ := root.GetProperty("before"); got != before {
+		t.Error("node before the edit was replaced")
+	}
+	after, _ := root.GetProperty("after")
+	if got := after.Position().Offset; got != strings.Index(tree.Text(), `{"y"`) {
+		t.Errorf("node after the edit at offset %d, want %d", got, strings.Index(tree.Text(), `{"y"`))
+	}
+}
+
+func TestTreeEditRecovers(t *testing.T) {
+	tree, err := ParseTree(`{"a": [1, 2]}`)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+	if err := tree.Edit(TextEdit{Start: 11, End: 12}); err == nil {
+		t.Fatal("Edit() removing ']' succeeded")
+	}
+	if tree.Root() != nil {
+		t.Fatal("Root() != nil for invalid text")
+	}
+	if err := tree.Edit(TextEdit{Start: 11, End: 11, Text: "]"}); err != nil {
+		t.Fatalf("Edit() restoring ']' error = %v", err)
+	}
+	checkTree(t, tree)
+}
+
+func TestTreeEditOutOfBounds(t *testing.T) {
+	tree, err := ParseTree(`[1]`)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+	for _, e := range []TextEdit{{Start: -1, End: 0}, {Start: 2, End: 1}, {Start: 0, End: 4}} {
+		if err := tree.Edit(e); err == nil {
+			t.Errorf("Edit(%+v) succeeded", e)
+		}
+	}
+	if tree.Text() != `[1]` {
+		t.Errorf("Text() = %q after rejected edits", tree.Text())
+	}
+}
+
+// TestTreeEditRandom types, deletes, and replaces values at random places,
+// checking after every edit that the tree matches a full parse.
+func TestTreeEditRandom(t *testing.T) {
+	doc := "{\r\n\t\"id\": 1,\n\t\"items\": [\n\t\t{\"name\": \"ä\", \"qty\": [1, 2]},\n\t\t{\"name\": \"b\", \"qty\": []}\n\t],\n\t\"meta\": {\"tags\": [\"x\", \"😀\"], \"ok\": null}\n}"
+	values := []string{"0", "-12.5", "\"s\"", "\"ü\\n\"", "true", "null", "[]", "{}", "[1,\n 2]", "{\"k\": [\"v\"]}", "\"😀\""}
+	snippets := []string{"1", ", ", "\"k\"", ": ", "[", "]", "{", "}", "\n", "é", "null", ", \"z\": 0", "[1, {\"q\": 2}]"}
+
+	rng := rand.New(rand.NewSource(1))
+	tree, err := ParseTree(doc)
+	if err != nil {
+		t.Fatalf("ParseTree() error = %v", err)
+	}
+	for i := 0; i < 2000; i++ {
+		text := tree.Text()
+		if len(text) > 400 {
+			tree, _ = ParseTree(doc)
+			text = doc
+		}
+		var e TextEdit
+		if tokens := scalarPattern.FindAllStringIndex(text, -1); len(tokens) > 0 && rng.Intn(2) == 0 {
+			// Replace a value with another, which mostly keeps the text valid
+			tok := tokens[rng.Intn(len(tokens))]
+			e = TextEdit{Start: tok[0], End: tok[1], Text: values[rng.Intn(len(values))]}
+		} else {
+			e.Start = runeBoundary(text, rng.Intn(len(text)+1))
+			e.End = e.Start
+			if rng.Intn(2) == 0 {
+				e.End = runeBoundary(text, e.Start+rng.Intn(6))
+			}
+			if rng.Intn(3) > 0 {
+				e.Text = snippets[rng.Intn(len(snippets))]
+			}
+		}
+		if err := tree.Edit(e); err != nil {
+			// Undo edits that break the text so that most edits apply to a
+			// valid document
+			checkTree(t, tree)
+			_ = tree.Edit(TextEdit{Start: e.Start, End: e.Start + len(e.Text), Text: text[e.Start:e.End]})
+		}
+		checkTree(t, tree)
+	}
+}
+
+var scalarPattern = regexp.MustCompile(`"[^"\\]*"|-?[0-9]+(\.[0-9]+)?|true|null`)
+
+// runeBoundary moves i forward to the start of a character in s.
+func runeBoundary(s string, i int) int {
+	if i > len(s) {
+		return len(s)
+	}
+	for i < len(s) && s[i]&0xC0 == 0x80 {
+		i++
+	}
+	return i
+}
+
+func TestTreeSetProperty(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		path []string // members leading to the object to change
+		key  string
+		want string
+	}{
+		{"replace", `{"a": 1, "b": 2}`, nil, "a", `{"a": [true], "b": 2}`},
+		{"append", `{"a": 1, "b": 2}`, nil, "c", `{"a": 1, "b": 2,"c":[true]}`},
+		{"empty", `{ }`, nil, "a", `{"a":[true] }`},
+		{"nested", "{\"x\": {\"y\": 1},\n \"z\": 2}", []string{"x"}, "k\"", "{\"x\": {\"y\": 1,\"k\\\"\":[true]},\n \"z\": 2}"},
+	}
+	value := ast.NewArrayDataNode([]ast.SchemaNode{ast.NewLiteralNode(true, ast.Position{})}, ast.Position{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := ParseTree(tt.doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			obj := tree.Root().(*ast.ObjectNode)
+			for _, k := range tt.path {
+				child, _ := obj.GetProperty(k)
+				obj = child.(*ast.ObjectNode)
+			}
+			if err := tree.SetProperty(obj, tt.key, value); err != nil {
+				t.Fatalf("SetProperty() error = %v", err)
+			}
+			if tree.Text() != tt.want {
+				t.Errorf("Text() = %q, want %q", tree.Text(), tt.want)
+			}
+			checkTree(t, tree)
+		})
+	}
+}
+
+func TestTreeRemoveProperty(t *testing.T) {
+	tests := []struct {
+		doc  string
+		key  string
+		want string
+	}{
+		{`{"a": 1, "b": 2, "c": 3}`, "a", `{"b": 2, "c": 3}`},
+		{`{"a": 1, "b": 2, "c": 3}`, "b", `{"a": 1, "c": 3}`},
+		{`{"a": 1, "b": 2, "c": 3}`, "c", `{"a": 1, "b": 2}`},
+		{`{ "a": {"x": [1]} }`, "a", `{  }`},
+		{"{\n  \"a\": 1,\n  \"b\": 2\n}", "a", "{\n  \"b\": 2\n}"},
+	}
+	for _, tt := range tests {
+		tree, err := ParseTree(tt.doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tree.RemoveProperty(tree.Root().(*ast.ObjectNode), tt.key); err != nil {
+			t.Fatalf("RemoveProperty(%q) on %q: %v", tt.key, tt.doc, err)
+		}
+		if tree.Text() != tt.want {
+			t.Errorf("RemoveProperty(%q) on %q: Text() = %q, want %q", tt.key, tt.doc, tree.Text(), tt.want)
+		}
+		checkTree(t, tree)
+	}
+
+	// Key offsets of members after an edit move with it
+	tree, _ := ParseTree(`{"a": [1], "b": {"x": 1, "y": 2}}`)
+	start := strings.Index(tree.Text(), "1]")
+	if err := tree.Edit(TextEdit{Start: start, End: start + 1, Text: "1, 2, 3"}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := tree.Root().(*ast.ObjectNode).GetProperty("b")
+	if err := tree.RemoveProperty(b.(*ast.ObjectNode), "x"); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a": [1, 2, 3], "b": {"y": 2}}`; tree.Text() != want {
+		t.Errorf("Text() = %q, want %q", tree.Text(), want)
+	}
+	checkTree(t, tree)
+
+	tree, _ = ParseTree(`{"a": 1}`)
+	if err := tree.RemoveProperty(tree.Root().(*ast.ObjectNode), "b"); err == nil {
+		t.Error("RemoveProperty of a missing member: expected error")
+	}
+	if tree.Text() != `{"a": 1}` {
+		t.Errorf("failed RemoveProperty changed text to %q", tree.Text())
+	}
+}
+
+func TestTreeReplaceChild(t *testing.T) {
+	doc := "{\"list\": [1, {\"n\": 2}, 3],\n \"after\": {\"y\": 2}}"
+	tree, err := ParseTree(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tree.Root().(*ast.ObjectNode)
+	listNode, _ := root.GetProperty("list")
+	list := listNode.(*ast.ArrayDataNode)
+
+	repl, err := Parse(`{"n": "two", "m": null}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.ReplaceChild(list, list.Get(1), repl); err != nil {
+		t.Fatalf("ReplaceChild() error = %v", err)
+	}
+	if want := "{\"list\": [1, {\"m\":null,\"n\":\"two\"}, 3],\n \"after\": {\"y\": 2}}"; tree.Text() != want {
+		t.Errorf("Text() = %q, want %q", tree.Text(), want)
+	}
+	checkTree(t, tree)
+
+	// The old root is stale; a node from it is no longer in the tree
+	if err := tree.ReplaceChild(list, list.Get(0), repl); err == nil {
+		t.Error("ReplaceChild with a stale parent: expected error")
+	}
+
+	root = tree.Root().(*ast.ObjectNode)
+	after, _ := root.GetProperty("after")
+	if err := tree.ReplaceChild(root, after, ast.NewLiteralNode(int64(5), ast.Position{})); err != nil {
+		t.Fatalf("ReplaceChild() error = %v", err)
+	}
+	if want := "{\"list\": [1, {\"m\":null,\"n\":\"two\"}, 3],\n \"after\": 5}"; tree.Text() != want {
+		t.Errorf("Text() = %q, want %q", tree.Text(), want)
+	}
+	checkTree(t, tree)
+}
@@ -0,0 +1,329 @@
+package json
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// IndentReader copies the JSON read from r to w with each member and
+// element on its own line, indented by one copy of indent per level of
+// nesting, in the layout of encoding/json.Indent. It is meant for making
+// dumps too large to load human-inspectable:
+//
+//	f, err := os.Open("dump.json")
+//	if err != nil {
+//	    return err
+//	}
+//	defer f.Close()
+//	return json.IndentReader(f, os.Stdout, "  ")
+//
+// The input is formatted as it is read, so memory use is bounded by the
+// nesting depth rather than by the size of the input or of the strings in
+// it, and output appears from the first byte on. Only whitespace changes:
+// members keep their order and strings and numbers are copied as written,
+// escapes and all, so the same input always gives the same output.
+//
+// r may hold a sequence of values, such as JSON Lines; each is followed by
+// a newline in the output. The input is checked as it is copied, except
+// that invalid UTF-8 in strings is passed through. A syntax error is
+// returned with its position, and the output before it has been written
+// to w. A write error stops the copy without reading the rest of r.
+func IndentReader(r io.Reader, w io.Writer, indent string) error {
+	c := &indentCopier{
+		in:     streamWalker{r: bufio.NewReader(r)},
+		out:    bufio.NewWriter(w),
+		indent: indent,
+	}
+	err := c.values()
+	if flushErr := c.out.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// indentCopier reformats the input of IndentReader. It reads with a
+// streamWalker, whose handler is not used.
+type indentCopier struct {
+	in     streamWalker
+	out    *bufio.Writer
+	indent string
+	num    []byte // scratch space for numbers
+}
+
+// values copies each top-level value, ending it with a newline.
+func (c *indentCopier) values() error {
+	for {
+		if err := c.in.skipSpace(); err != nil {
+			if err == errStreamEOF {
+				return nil
+			}
+			return err
+		}
+		if err := c.value(); err != nil {
+			return err
+		}
+		if err := c.out.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+}
+
+// newline starts a new line indented to depth. Its error, which the
+// writer keeps, is checked once per line rather than for each write.
+func (c *indentCopier) newline(depth int) error {
+	if err := c.out.WriteByte('\n'); err != nil {
+		return err
+	}
+	_, err := c.out.WriteString(strings.Repeat(c.indent, depth))
+	return err
+}
+
+// value copies the value at the current position.
+func (c *indentCopier) value() error {
+	b, err := c.in.readByte()
+	if err != nil {
+		return err
+	}
+
+	switch b {
+	case '{', '[':
+		if c.in.depth++; c.in.depth > fastparser.MaxDepth {
+			return fastparser.DepthError(int(c.in.pos - 1))
+		}
+		if b == '{' {
+			err = c.object()
+		} else {
+			err = c.array()
+		}
+		c.in.depth--
+		return err
+	case '"':
+		return c.str()
+	case 't':
+		return c.literal("true")
+	case 'f':
+		return c.literal("false")
+	case 'n':
+		return c.literal("null")
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return c.number(b)
+	}
+	return fmt.Errorf("unexpected character '%c' at position %d", b, c.in.pos-1)
+}
+
+// object copies an object after its opening brace.
+func (c *indentCopier) object() error {
+	c.out.WriteByte('{')
+	if err := c.in.skipSpace(); err != nil {
+		return err
+	}
+	if b, _ := c.in.readByte(); b == '}' {
+		c.out.WriteByte('}')
+		return nil
+	}
+	c.in.unreadByte()
+
+	for {
+		if err := c.newline(c.in.depth); err != nil {
+			return err
+		}
+		if err := c.in.skipSpace(); err != nil {
+			return err
+		}
+		if b, _ := c.in.readByte(); b != '"' {
+			return errors.New("expected string key in object")
+		}
+		if err := c.str(); err != nil {
+			return err
+		}
+
+		if err := c.in.skipSpace(); err != nil {
+			return err
+		}
+		if b, _ := c.in.readByte(); b != ':' {
+			return errors.New("expected ':' after object key")
+		}
+		c.out.WriteString(": ")
+		if err := c.in.skipSpace(); err != nil {
+			return err
+		}
+		if err := c.value(); err != nil {
+			return err
+		}
+
+		if err := c.in.skipSpace(); err != nil {
+			if err == errStreamEOF {
+				return errors.New("unexpected end of JSON input in object")
+			}
+			return err
+		}
+		switch b, _ := c.in.readByte(); b {
+		case '}':
+			if err := c.newline(c.in.depth - 1); err != nil {
+				return err
+			}
+			c.out.WriteByte('}')
+			return nil
+		case ',':
+			c.out.WriteByte(',')
+		default:
+			return fmt.Errorf("expected ',' or '}' in object at position %d", c.in.pos-1)
+		}
+	}
+}
+
+// array copies an array after its opening bracket.
+func (c *indentCopier) array() error {
+	c.out.WriteByte('[')
+	if err := c.in.skipSpace(); err != nil {
+		return err
+	}
+	if b, _ := c.in.readByte(); b == ']' {
+		c.out.WriteByte(']')
+		return nil
+	}
+	c.in.unreadByte()
+
+	for {
+		if err := c.newline(c.in.depth); err != nil {
+			return err
+		}
+		if err := c.in.skipSpace(); err != nil {
+			return err
+		}
+		if err := c.value(); err != nil {
+			return err
+		}
+
+		if err := c.in.skipSpace(); err != nil {
+			if err == errStreamEOF {
+				return errors.New("unexpected end of JSON input in array")
+			}
+			return err
+		}
+		switch b, _ := c.in.readByte(); b {
+		case ']':
+			if err := c.newline(c.in.depth - 1); err != nil {
+				return err
+			}
+			c.out.WriteByte(']')
+			return nil
+		case ',':
+			c.out.WriteByte(',')
+		default:
+			return fmt.Errorf("expected ',' or ']' in array at position %d", c.in.pos-1)
+		}
+	}
+}
+
+// literal copies true, false, or null after its first byte.
+func (c *indentCopier) literal(word string) error {
+	return c.in.literal(word[1:], func() error {
+		_, err := c.out.WriteString(word)
+		return err
+	})
+}
+
+// number copies a number whose first byte is first.
+func (c *indentCopier) number(first byte) error {
+	raw := append(c.num[:0], first)
+	for {
+		b, err := c.in.r.ReadByte()
+		if err != nil {
+			if err != io.EOF {
+				return err
+			}
+			break
+		}
+		if (b >= '0' && b <= '9') || b == '.' || b == 'e' || b == 'E' || b == '+' || b == '-' {
+			raw = append(raw, b)
+			c.in.pos++
+			continue
+		}
+		_ = c.in.r.UnreadByte()
+		break
+	}
+	c.num = raw[:0]
+
+	if end, err := fastparser.SkipValue(raw, 0); err != nil || end != len(raw) {
+		return fmt.Errorf("invalid number %q at position %d", raw, c.in.pos-int64(len(raw)))
+	}
+	_, err := c.out.Write(raw)
+	return err
+}
+
+// str copies a string after its opening quote. Runs of plain bytes are
+// copied straight from the read buffer, so a string of any length needs no
+// memory of its own.
+func (c *indentCopier) str() error {
+	c.out.WriteByte('"')
+	r := c.in.r
+	for {
+		chunk, err := r.Peek(max(r.Buffered(), 1))
+		if len(chunk) == 0 {
+			if err == io.EOF {
+				return errors.New("unexpected end of JSON input in string")
+			}
+			return err
+		}
+		n := 0
+		for n < len(chunk) && chunk[n] >= 0x20 && chunk[n] != '"' && chunk[n] != '\\' {
+			n++
+		}
+		if _, err := c.out.Write(chunk[:n]); err != nil {
+			return err
+		}
+		r.Discard(n)
+		c.in.pos += int64(n)
+		if n == len(chunk) {
+			continue
+		}
+
+		switch b, _ := c.in.readByte(); {
+		case b == '"':
+			return c.out.WriteByte('"')
+		case b == '\\':
+			if err := c.escape(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid control character in string at position %d", c.in.pos-1)
+		}
+	}
+}
+
+// escape checks and copies the escape sequence after a backslash.
+func (c *indentCopier) escape() error {
+	b, err := c.in.readByte()
+	if err != nil {
+		return errors.New("unexpected end of JSON input after backslash")
+	}
+	c.out.WriteByte('\\')
+	switch b {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		return c.out.WriteByte(b)
+	case 'u':
+		c.out.WriteByte('u')
+		for i := 0; i < 4; i++ {
+			h, err := c.in.readByte()
+			if err != nil {
+				return errors.New("incomplete unicode escape")
+			}
+			if !isHexDigit(h) {
+				return fmt.Errorf("invalid unicode escape at position %d", c.in.pos-1)
+			}
+			c.out.WriteByte(h)
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid escape sequence '\\%c'", b)
+}
+
+func isHexDigit(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
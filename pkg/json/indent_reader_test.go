@@ -0,0 +1,111 @@
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestIndentReader_MatchesIndent(t *testing.T) {
+	inputs := []string{
+		`{"a": [1, -2.5E+3, true, false, null, "x\né\"\\\/é"], "b": {}, "c": [ ], "": {"d": [[]]}}`,
+		` "scalar" `,
+		`0`,
+		`[{"k":"v"},{"k":"w"}]`,
+		`{"z": 1, "a": 2, "m": {"nested": [1.50, 1e400]}}`,
+	}
+	for _, indent := range []string{"  ", "\t", ""} {
+		for _, input := range inputs {
+			var want bytes.Buffer
+			if err := stdjson.Indent(&want, []byte(input), "", indent); err != nil {
+				t.Fatalf("encoding/json.Indent(%s) error = %v", input, err)
+			}
+			var got bytes.Buffer
+			// One byte at a time exercises every buffer boundary
+			if err := IndentReader(iotest.OneByteReader(strings.NewReader(input)), &got, indent); err != nil {
+				t.Fatalf("IndentReader(%s) error = %v", input, err)
+			}
+			if got.String() != strings.TrimSpace(want.String())+"\n" {
+				t.Errorf("IndentReader(%s, %q) =\n%s\nwant\n%s", input, indent, got.String(), want.String())
+			}
+		}
+	}
+}
+
+func TestIndentReader_Sequence(t *testing.T) {
+	var out bytes.Buffer
+	err := IndentReader(strings.NewReader("{\"a\":1}\n[2]\n3 \"x\"\n"), &out, " ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n \"a\": 1\n}\n[\n 2\n]\n3\n\"x\"\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	if err := IndentReader(strings.NewReader(" \n"), &out, " "); err != nil || out.Len() != 0 {
+		t.Errorf("empty input: output %q, error %v", out.String(), err)
+	}
+}
+
+func TestIndentReader_LargeString(t *testing.T) {
+	const size = 4 << 20
+	var n countingWriter
+	if err := IndentReader(newBlobReader(size), &n, "  "); err != nil {
+		t.Fatal(err)
+	}
+	overhead := len("{\n  \"id\": 1,\n  \"blob\": \"\",\n  \"after\": true\n}\n")
+	if int(n) != size+overhead {
+		t.Errorf("wrote %d bytes, want %d", n, size+overhead)
+	}
+}
+
+// countingWriter counts and discards what is written to it.
+type countingWriter int
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+func TestIndentReader_Errors(t *testing.T) {
+	tests := []struct {
+		input   string
+		wantErr string
+	}{
+		{`{"a" 1}`, "expected ':'"},
+		{`{1: 2}`, "expected string key"},
+		{`[1 2]`, "expected ',' or ']' in array at position 3"},
+		{`{"a": 1 "b": 2}`, "expected ',' or '}' in object"},
+		{`[1,`, "unexpected end of JSON input"},
+		{`{"a": 1`, "unexpected end of JSON input in object"},
+		{`"abc`, "unexpected end of JSON input in string"},
+		{"\"a\tb\"", "invalid control character in string at position 2"},
+		{`"\x"`, `invalid escape sequence '\x'`},
+		{`"\u12G4"`, "invalid unicode escape"},
+		{`[01]`, "invalid number"},
+		{`[tru]`, "invalid literal"},
+		{`{"a": 1} }`, "unexpected character '}'"},
+		{strings.Repeat("[", 20000), "depth"},
+	}
+	for _, tt := range tests {
+		err := IndentReader(strings.NewReader(tt.input), io.Discard, "  ")
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("IndentReader(%.20s) error = %v, want containing %q", tt.input, err, tt.wantErr)
+		}
+	}
+}
+
+func TestIndentReader_WriteErrorStopsReading(t *testing.T) {
+	// An endless array of strings: the copy can only end because the
+	// writer fails
+	endless := io.MultiReader(strings.NewReader(`["`), repeatReader('x'))
+	err := IndentReader(endless, &failingWriter{failAfter: 1 << 20}, "  ")
+	if err == nil || err.Error() != "write error" {
+		t.Errorf("error = %v, want the write error", err)
+	}
+}
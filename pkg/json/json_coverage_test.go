@@ -1,3 +1,5 @@
+//go:build !tinygo
+
 package json
 
 import (
@@ -433,7 +435,7 @@ func TestEncode_Comprehensive(t *testing.T) {
 				Name: "Alice",
 				Age:  30,
 			},
-			expected: `{"age":30,"name":"Alice"}`,
+			expected: `{"name":"Alice","age":30}`,
 		},
 		{
 			name:     "encode map",
@@ -1,9 +1,12 @@
+//go:build !tinygo
+
 package json
 
 import (
-	"bytes"
 	"reflect"
 	"sync"
+
+	"github.com/shapestone/shape-json/internal/sizehint"
 )
 
 // bufPool pools []byte slices for the compiled-encoder fast path.
@@ -14,27 +17,24 @@ var bufPool = sync.Pool{
 	},
 }
 
-// bufferPool is a pool of bytes.Buffer instances to reduce allocations during marshaling.
-// Buffers are returned to the pool after use to minimize GC pressure.
-var bufferPool = sync.Pool{
-	New: func() interface{} {
-		return bytes.NewBuffer(make([]byte, 0, 1024))
-	},
-}
+// encodedSizes estimates the encoded length of each type Marshal sees, so
+// it can start from a buffer that already fits instead of growing a 1KB one
+// through every doubling.
+var encodedSizes = sizehint.Table{Max: 16 << 20}
 
-// getBuffer retrieves a buffer from the pool and resets it for use.
-func getBuffer() *bytes.Buffer {
-	buf := bufferPool.Get().(*bytes.Buffer)
-	buf.Reset()
-	return buf
+// getBuf returns a pooled buffer with room for about hint bytes.
+func getBuf(hint int) *[]byte {
+	bp := bufPool.Get().(*[]byte)
+	if hint += hint / 8; cap(*bp) < hint {
+		*bp = make([]byte, 0, hint)
+	}
+	return bp
 }
 
-// putBuffer returns a buffer to the pool if it's not too large.
-// Buffers larger than 64KB are not pooled to avoid holding excessive memory.
-func putBuffer(buf *bytes.Buffer) {
-	if buf.Cap() <= 64*1024 {
-		bufferPool.Put(buf)
-	}
+// putBuf stores buf in bp and returns bp to bufPool.
+func putBuf(bp *[]byte, buf []byte) {
+	*bp = buf
+	bufPool.Put(bp)
 }
 
 // Marshal returns the JSON encoding of v.
@@ -50,12 +50,15 @@ func putBuffer(buf *bytes.Buffer) {
 //
 // String values encode as JSON strings coerced to valid UTF-8.
 //
-// Array and slice values encode as JSON arrays, except that []byte encodes
-// as a base64-encoded string, and a nil slice encodes as the null JSON value.
+// Array and slice values encode as JSON arrays, and a nil slice encodes as
+// the null JSON value. Unlike encoding/json, []byte encodes as an array of
+// numbers; MarshalOptions.Compatibility set to StrictStdlib encodes it as a
+// base64-encoded string.
 //
 // Struct values encode as JSON objects. Each exported struct field becomes
 // a member of the object, using the field name as the object key, unless the
-// field is omitted for one of the reasons given below.
+// field is omitted for one of the reasons given below. Members are written in
+// field declaration order; MarshalOptions.KeyOrder and KeyLess choose another.
 //
 // The encoding of each struct field can be customized by the format string
 // stored under the "json" key in the struct field's tag. The format string
@@ -69,9 +72,22 @@ func putBuffer(buf *bytes.Buffer) {
 //
 // As a special case, if the field tag is "-", the field is always omitted.
 //
+// The "transform:<name>" option passes the field's encoding through a
+// Transformer registered under that name in MarshalOptions. Marshal has no
+// transformers, so it returns an error for such fields; use
+// MarshalWithOptions.
+//
+// The "unknown" option on a map field with string keys, such as
+// map[string]RawMessage, writes its entries as members of the struct's own
+// object, after the other fields and sorted by key, instead of as a member
+// named after the field. Entries whose key names another field are left
+// out. Unmarshal fills such a field with the members that match no other
+// field, so decoding and encoding a document keeps the members the struct
+// does not model.
+//
 // Map values encode as JSON objects. The map's key type must be a string;
 // the map keys are used as JSON object keys, subject to the UTF-8 coercion
-// described for string values above.
+// described for string values above, and are sorted.
 //
 // Pointer values encode as the value pointed to. A nil pointer encodes as
 // the null JSON value.
@@ -82,61 +98,118 @@ func putBuffer(buf *bytes.Buffer) {
 // Channel, complex, and function values cannot be encoded in JSON.
 // Attempting to encode such a value causes Marshal to return an error.
 //
-// JSON cannot represent cyclic data structures and Marshal does not handle them.
-// Passing cyclic structures to Marshal will result in an error.
+// JSON cannot represent cyclic data structures. Marshal returns a
+// *CycleError, which matches ErrCycleDetected, for a value that refers back
+// to one enclosing it, such as a parent pointer in a tree; it notices
+// after a thousand levels of nesting. Sanitize replaces such references,
+// and unsupported values such as NaN, with null before encoding.
 func Marshal(v interface{}) ([]byte, error) {
+	m, start := startMetrics(nil)
+	data, err := marshal(defaultEncodeState, v)
+	observe(m, OpMarshal, len(data), start, err)
+	return data, err
+}
+
+// MarshalWithOptions is like Marshal but applies opts.
+//
+// Example:
+//
+//	data, err := json.MarshalWithOptions(user, json.MarshalOptions{
+//	    Transforms: map[string]json.Transformer{"encrypt": vault},
+//	})
+//
+// Golden files written before field declaration order became the default
+// can keep their sorted members:
+//
+//	data, err := json.MarshalWithOptions(v, json.MarshalOptions{KeyOrder: json.KeyOrderSorted})
+//
+// Signing code should set Canonical:
+//
+//	payload, err := json.MarshalWithOptions(claims, json.MarshalOptions{Canonical: true})
+func MarshalWithOptions(v interface{}, opts MarshalOptions) ([]byte, error) {
+	m, start := startMetrics(opts.Metrics)
+	data, err := marshalWithOptions(v, opts)
+	observe(m, OpMarshal, len(data), start, err)
+	return data, err
+}
+
+// marshalWithOptions implements MarshalWithOptions.
+func marshalWithOptions(v interface{}, opts MarshalOptions) ([]byte, error) {
+	e := opts.encodeState()
+	data, err := marshal(e, v)
+	if err != nil || !opts.Canonical {
+		return data, err
+	}
+	if data, err = canonicalize(data); err != nil {
+		return nil, err
+	}
+	// Canonical numbers can be longer than the ones written
+	if err := e.checkSize(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// marshal implements Marshal and MarshalWithOptions.
+func marshal(e *encodeState, v interface{}) ([]byte, error) {
 	if v == nil {
 		return []byte("null"), nil
 	}
 
-	// Fast path: try the type-switch encoder first (no reflect at all)
-	bp := bufPool.Get().(*[]byte)
+	// Fast path: try the type-switch encoder first (no reflect at all). It
+	// sorts map keys or leaves them unsorted, so a custom order needs the
+	// compiled encoders
+	t := reflect.TypeOf(v)
+	bp := getBuf(encodedSizes.Get(t))
 	buf := (*bp)[:0]
 
-	buf, err := appendInterface(buf, v)
+	err := errNeedReflect
+	if e.typeSwitchOK() {
+		buf, err = appendInterface(buf, v, !e.unsortedMaps, 0)
+	}
 	if err == nil {
-		// Success — copy result so pooled buffer can be reused
-		result := make([]byte, len(buf))
-		copy(result, buf)
-		*bp = buf
-		bufPool.Put(bp)
-		return result, nil
+		return finishMarshal(bp, buf, t), nil
 	}
 
 	if err != errNeedReflect {
 		// Real error from appendInterface (e.g. Marshaler failed)
-		*bp = buf
-		bufPool.Put(bp)
+		putBuf(bp, buf)
 		return nil, err
 	}
 
-	// Fall back to the compiled encoder cache
+	// Fall back to the compiled encoder cache, discarding any partial output
+	// the fast path wrote before reaching an unsupported type
+	buf = buf[:0]
 	rv := reflect.ValueOf(v)
 	for rv.Kind() == reflect.Ptr {
 		if rv.IsNil() {
-			*bp = buf
-			bufPool.Put(bp)
+			putBuf(bp, buf)
 			return []byte("null"), nil
 		}
 		rv = rv.Elem()
 	}
 
 	enc := encoderForType(rv.Type())
-	buf, err = enc(buf, rv)
+	st := e.acquire()
+	buf, err = enc(st, buf, rv)
+	st.release()
+	if err == nil {
+		err = e.checkSize(buf)
+	}
 	if err != nil {
-		*bp = buf
-		bufPool.Put(bp)
+		finishCycle(err, v)
+		putBuf(bp, buf)
 		return nil, err
 	}
+	return finishMarshal(bp, buf, t), nil
+}
 
+// finishMarshal records the encoded length of t, copies buf out so the
+// pooled buffer can be reused, and returns it to the pool.
+func finishMarshal(bp *[]byte, buf []byte, t reflect.Type) []byte {
+	encodedSizes.Observe(t, len(buf))
 	result := make([]byte, len(buf))
 	copy(result, buf)
-	*bp = buf
-	bufPool.Put(bp)
-	return result, nil
-}
-
-// Marshaler is the interface implemented by types that can marshal themselves into valid JSON.
-type Marshaler interface {
-	MarshalJSON() ([]byte, error)
+	putBuf(bp, buf)
+	return result
 }
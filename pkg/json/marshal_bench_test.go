@@ -1,7 +1,10 @@
+//go:build !tinygo
+
 package json_test
 
 import (
 	"encoding/json"
+	"fmt"
 	"testing"
 	"time"
 
@@ -112,6 +115,34 @@ func BenchmarkShapeJSON_Marshal_Map(b *testing.B) {
 	}
 }
 
+// benchLargeMap is a map large enough for sorting its keys to dominate.
+var benchLargeMap = func() map[string]interface{} {
+	m := make(map[string]interface{}, 5000)
+	for i := 0; i < 5000; i++ {
+		m[fmt.Sprintf("key-%d", i)] = i
+	}
+	return m
+}()
+
+func BenchmarkShapeJSON_Marshal_LargeMap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := shapejson.Marshal(benchLargeMap); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkShapeJSON_Marshal_LargeMap_Unsorted(b *testing.B) {
+	opts := shapejson.MarshalOptions{UnsortedMaps: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := shapejson.MarshalWithOptions(benchLargeMap, opts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkShapeJSON_Marshal_Large(b *testing.B) {
 	if err := loadBenchmarkData(); err != nil {
 		b.Fatalf("Failed to load benchmark data: %v", err)
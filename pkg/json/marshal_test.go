@@ -1,3 +1,5 @@
+//go:build !tinygo
+
 package json
 
 import (
@@ -83,17 +85,17 @@ func TestMarshal_Struct(t *testing.T) {
 		{
 			name:     "simple struct",
 			value:    Person{Name: "Alice", Age: 30},
-			expected: `{"Age":30,"Name":"Alice"}`,
+			expected: `{"Name":"Alice","Age":30}`,
 		},
 		{
 			name:     "struct pointer",
 			value:    &Person{Name: "Bob", Age: 25},
-			expected: `{"Age":25,"Name":"Bob"}`,
+			expected: `{"Name":"Bob","Age":25}`,
 		},
 		{
 			name:     "empty struct",
 			value:    Person{},
-			expected: `{"Age":0,"Name":""}`,
+			expected: `{"Name":"","Age":0}`,
 		},
 	}
 
@@ -137,7 +139,7 @@ func TestMarshal_StructTags(t *testing.T) {
 				Empty:       "not empty",
 				ZeroInt:     5,
 			},
-			expected: `{"NoTag":"visible","age":30,"empty":"not empty","name":"Alice","zero":5}`,
+			expected: `{"name":"Alice","age":30,"NoTag":"visible","empty":"not empty","zero":5}`,
 		},
 		{
 			name: "omitempty with empty values",
@@ -146,7 +148,7 @@ func TestMarshal_StructTags(t *testing.T) {
 				Empty:      "",
 				ZeroInt:    0,
 			},
-			expected: `{"NoTag":"","age":0,"name":"Bob"}`,
+			expected: `{"name":"Bob","age":0,"NoTag":""}`,
 		},
 		{
 			name: "ignored field not in output",
@@ -154,7 +156,7 @@ func TestMarshal_StructTags(t *testing.T) {
 				PublicName: "Charlie",
 				Ignored:    "this should not appear",
 			},
-			expected: `{"NoTag":"","age":0,"name":"Charlie"}`,
+			expected: `{"name":"Charlie","age":0,"NoTag":""}`,
 		},
 	}
 
@@ -193,7 +195,7 @@ func TestMarshal_StringOption(t *testing.T) {
 		t.Fatalf("Marshal() error = %v", err)
 	}
 
-	expected := `{"normal":42,"stringBool":"true","stringInt":"42","stringNum":"3.14"}`
+	expected := `{"normal":42,"stringInt":"42","stringBool":"true","stringNum":"3.14"}`
 	if string(result) != expected {
 		t.Errorf("Marshal() = %s, want %s", string(result), expected)
 	}
@@ -226,7 +228,7 @@ func TestMarshal_NestedStruct(t *testing.T) {
 		t.Fatalf("Marshal() error = %v", err)
 	}
 
-	expected := `{"address":{"city":"Seattle","state":"WA"},"age":30,"name":"Alice"}`
+	expected := `{"name":"Alice","age":30,"address":{"city":"Seattle","state":"WA"}}`
 	if string(result) != expected {
 		t.Errorf("Marshal() = %s, want %s", string(result), expected)
 	}
@@ -292,7 +294,7 @@ func TestMarshal_StructSlice(t *testing.T) {
 		t.Fatalf("Marshal() error = %v", err)
 	}
 
-	expected := `[{"age":30,"name":"Alice"},{"age":25,"name":"Bob"}]`
+	expected := `[{"name":"Alice","age":30},{"name":"Bob","age":25}]`
 	if string(result) != expected {
 		t.Errorf("Marshal() = %s, want %s", string(result), expected)
 	}
@@ -427,7 +429,7 @@ func TestMarshal_Pointers(t *testing.T) {
 				Name: &strVal,
 				Age:  &intVal,
 			},
-			expected: `{"age":30,"name":"Alice"}`,
+			expected: `{"name":"Alice","age":30}`,
 		},
 		{
 			name: "nil pointers",
@@ -435,7 +437,7 @@ func TestMarshal_Pointers(t *testing.T) {
 				Name: nil,
 				Age:  nil,
 			},
-			expected: `{"age":null,"name":null}`,
+			expected: `{"name":null,"age":null}`,
 		},
 	}
 
@@ -574,3 +576,23 @@ func TestMarshal_RoundTrip(t *testing.T) {
 		t.Errorf("Round trip failed: got %+v, want %+v", decoded, original)
 	}
 }
+
+// TestMarshal_MixedInterfaceMap covers maps whose values are only partly
+// handled by the type-switch fast path.
+func TestMarshal_MixedInterfaceMap(t *testing.T) {
+	type point struct{ X int }
+	v := map[string]interface{}{
+		"a": 1,
+		"b": point{X: 2},
+		"c": []interface{}{"x", point{X: 3}},
+	}
+
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"a":1,"b":{"X":2},"c":["x",{"X":3}]}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+}
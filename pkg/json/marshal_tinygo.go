@@ -0,0 +1,101 @@
+//go:build tinygo
+
+package json
+
+import "fmt"
+
+// encodeState holds the MarshalOptions that TinyGo builds honor.
+type encodeState struct {
+	unsortedMaps bool
+	maxSize      int64  // see MaxOutputSize; 0 is no limit
+	unsupported  string // the first option set that needs the reflection codec
+}
+
+// defaultEncodeState is the state of Marshal, which has no options.
+var defaultEncodeState = &encodeState{}
+
+// encodeState returns the encoder state for these options.
+func (o MarshalOptions) encodeState() *encodeState {
+	e := &encodeState{unsortedMaps: o.UnsortedMaps, maxSize: o.MaxOutputSize}
+	switch {
+	case o.KeyLess != nil:
+		e.unsupported = "KeyLess"
+	case o.Int64AsString:
+		e.unsupported = "Int64AsString"
+	case o.Numbers != nil:
+		e.unsupported = "Numbers"
+	case o.Types != nil:
+		e.unsupported = "Types"
+	case o.Compatibility == StrictStdlib:
+		e.unsupported = "Compatibility"
+	}
+	return e
+}
+
+// checkSize reports a *LimitError if buf is longer than MaxOutputSize
+// allows.
+func (e *encodeState) checkSize(buf []byte) error {
+	if e.maxSize > 0 && int64(len(buf)) > e.maxSize {
+		return &LimitError{Limit: "output size", Max: e.maxSize, Offset: e.maxSize}
+	}
+	return nil
+}
+
+// Marshal returns the JSON encoding of v.
+//
+// TinyGo builds leave out the reflection-based encoder. They encode nil,
+// booleans, numbers, strings, time.Time, time.Duration, []interface{},
+// map[string]interface{}, and Marshalers such as Document and Array, nested
+// less than a thousand levels deep, and return an error for other values.
+func Marshal(v interface{}) ([]byte, error) {
+	m, start := startMetrics(nil)
+	data, err := marshal(defaultEncodeState, v)
+	observe(m, OpMarshal, len(data), start, err)
+	return data, err
+}
+
+// MarshalWithOptions is like Marshal but applies opts. TinyGo builds
+// honor Canonical, UnsortedMaps, MaxOutputSize, and Metrics, and return an
+// error if KeyLess, Int64AsString, Numbers, Types, or StrictStdlib is set.
+// The other options concern only values they cannot encode.
+func MarshalWithOptions(v interface{}, opts MarshalOptions) ([]byte, error) {
+	m, start := startMetrics(opts.Metrics)
+	data, err := marshalWithOptions(v, opts)
+	observe(m, OpMarshal, len(data), start, err)
+	return data, err
+}
+
+// marshalWithOptions implements MarshalWithOptions.
+func marshalWithOptions(v interface{}, opts MarshalOptions) ([]byte, error) {
+	e := opts.encodeState()
+	data, err := marshal(e, v)
+	if err != nil || !opts.Canonical {
+		return data, err
+	}
+	if data, err = canonicalize(data); err != nil {
+		return nil, err
+	}
+	if err := e.checkSize(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// marshal implements Marshal and MarshalWithOptions with appendInterface
+// alone.
+func marshal(e *encodeState, v interface{}) ([]byte, error) {
+	if e.unsupported != "" {
+		return nil, fmt.Errorf("json: MarshalOptions.%s is not supported in TinyGo builds", e.unsupported)
+	}
+	buf, err := appendInterface(make([]byte, 0, 1024), v, !e.unsortedMaps, 0)
+	if err == errNeedReflect {
+		return nil, fmt.Errorf("json: cannot marshal %T: TinyGo builds encode only the types listed for Marshal, nested less than %d levels deep", v, startDetectingCyclesAfter)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := e.checkSize(buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
@@ -0,0 +1,63 @@
+//go:build tinygo
+
+package json
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+const typedCodec = false
+
+func TestMarshalTinyGo(t *testing.T) {
+	doc := NewDocument().Set("name", "Alice").Set("age", 30)
+	data, err := Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"age":30,"name":"Alice"}` {
+		t.Errorf("Marshal(doc) = %s", data)
+	}
+
+	if _, err := MarshalWithOptions(map[string]interface{}{}, MarshalOptions{Int64AsString: true}); err == nil || !strings.Contains(err.Error(), "Int64AsString") {
+		t.Errorf("Int64AsString: error = %v, want one naming the option", err)
+	}
+	if _, err := MarshalWithOptions("abcdef", MarshalOptions{MaxOutputSize: 4}); err == nil {
+		t.Error("MaxOutputSize: no error")
+	}
+
+	type point struct{ X int }
+	if _, err := Marshal(point{1}); err == nil || !strings.Contains(err.Error(), "TinyGo") {
+		t.Errorf("Marshal(struct): error = %v, want one naming TinyGo builds", err)
+	}
+}
+
+func TestUnmarshalTinyGo(t *testing.T) {
+	var v interface{}
+	if err := UnmarshalWithOptions([]byte(`{"n":1}`), &v, UnmarshalOptions{NumberPolicy: NumberPolicyFloat64}); err != nil {
+		t.Fatal(err)
+	}
+	if n := v.(map[string]interface{})["n"]; n != 1.0 {
+		t.Errorf("n = %#v, want float64 1", n)
+	}
+
+	doc := NewDocument()
+	if err := Unmarshal([]byte(`{"a":[1,2]}`), doc); err != nil {
+		t.Fatal(err)
+	}
+	if arr, ok := doc.GetArray("a"); !ok || arr.Len() != 2 {
+		t.Errorf("a = %v, %v", arr, ok)
+	}
+
+	schema := jsonschema.MustCompile([]byte(`{"type":"object","required":["id"]}`))
+	if err := UnmarshalWithOptions([]byte(`{"name":"x"}`), &v, UnmarshalOptions{Schema: schema}); err == nil {
+		t.Error("Schema: no error for a document without the required member")
+	}
+
+	var s string
+	if err := UnmarshalWithOptions([]byte(`null`), &s, UnmarshalOptions{RejectNull: true}); err == nil || !strings.Contains(err.Error(), "RejectNull") {
+		t.Errorf("RejectNull: error = %v, want one naming the option", err)
+	}
+}
@@ -0,0 +1,129 @@
+package json
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics receives a Measurement for every parse, decode, and encode, so
+// that a service can export counters of operations, bytes, and errors, and
+// histograms of durations, to Prometheus, OpenTelemetry, or any other
+// system, without wrapping each call site:
+//
+//	type promMetrics struct{}
+//
+//	func (promMetrics) Observe(m json.Measurement) {
+//	    ops.WithLabelValues(m.Op.String()).Inc()
+//	    bytes.WithLabelValues(m.Op.String()).Add(float64(m.Bytes))
+//	    if m.Err != nil {
+//	        errs.WithLabelValues(m.Op.String()).Inc()
+//	    }
+//	    durations.WithLabelValues(m.Op.String()).Observe(m.Duration.Seconds())
+//	}
+//
+//	json.SetMetrics(promMetrics{})
+//
+// SetMetrics sets the Metrics for the whole program; the Metrics field of
+// MarshalOptions, UnmarshalOptions, or ParseOptions overrides it for one
+// call. Observe is called on the goroutine that ran the operation, after
+// it returns, and must be safe for concurrent use. With no Metrics set,
+// nothing is measured and the operations cost what they did before.
+//
+// Functions built on these operations, such as Decode and ParseDocument,
+// report as the operation they run. The MarshalJSON and UnmarshalJSON
+// methods of this package's types, such as Nullable, run inside the
+// operation that calls them and are not measured on their own.
+type Metrics interface {
+	Observe(Measurement)
+}
+
+// Measurement describes one finished operation.
+type Measurement struct {
+	Op       Operation
+	Bytes    int           // size of the input, or of the output of OpMarshal
+	Duration time.Duration // wall time of the operation
+	Err      error         // the error the operation returned, if any
+}
+
+// Operation is the kind of operation a Measurement describes.
+type Operation uint8
+
+const (
+	// OpParse is Parse, ParseReader, and their WithOptions variants.
+	OpParse Operation = iota + 1
+
+	// OpUnmarshal is Unmarshal and UnmarshalWithOptions, and the
+	// Unmarshal methods of DecodePlan.
+	OpUnmarshal
+
+	// OpMarshal is Marshal and MarshalWithOptions, and the Marshal
+	// methods of EncodePlan.
+	OpMarshal
+)
+
+// String returns "parse", "unmarshal", or "marshal", for use as a metric
+// label.
+func (op Operation) String() string {
+	switch op {
+	case OpParse:
+		return "parse"
+	case OpUnmarshal:
+		return "unmarshal"
+	case OpMarshal:
+		return "marshal"
+	}
+	return "unknown"
+}
+
+// metricsBox holds the global Metrics, since an interface value cannot be
+// stored atomically by itself.
+type metricsBox struct {
+	m Metrics
+}
+
+var globalMetrics atomic.Pointer[metricsBox]
+
+// SetMetrics sets the Metrics that every operation without its own reports
+// to. nil stops the reporting.
+func SetMetrics(m Metrics) {
+	if m == nil {
+		globalMetrics.Store(nil)
+		return
+	}
+	globalMetrics.Store(&metricsBox{m})
+}
+
+// startMetrics returns the Metrics an operation reports to, m if it is set
+// and otherwise the global one, and the time the operation starts. It
+// returns nil and reads no clock if there is no Metrics.
+func startMetrics(m Metrics) (Metrics, time.Time) {
+	if m == nil {
+		box := globalMetrics.Load()
+		if box == nil {
+			return nil, time.Time{}
+		}
+		m = box.m
+	}
+	return m, time.Now()
+}
+
+// observe reports an operation that began at start to m, if it is not nil.
+func observe(m Metrics, op Operation, bytes int, start time.Time, err error) {
+	if m != nil {
+		m.Observe(Measurement{Op: op, Bytes: bytes, Duration: time.Since(start), Err: err})
+	}
+}
+
+// countingReader counts the bytes read through it, for the Measurement of
+// a parse from a reader.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
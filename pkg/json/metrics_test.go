@@ -0,0 +1,145 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recorder is a Metrics that keeps every Measurement.
+type recorder struct {
+	mu   sync.Mutex
+	seen []Measurement
+}
+
+func (r *recorder) Observe(m Measurement) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seen = append(r.seen, m)
+}
+
+func (r *recorder) take() []Measurement {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := r.seen
+	r.seen = nil
+	return seen
+}
+
+func TestSetMetrics(t *testing.T) {
+	rec := &recorder{}
+	SetMetrics(rec)
+	t.Cleanup(func() { SetMetrics(nil) })
+
+	doc := `{"name":"Alice","tags":["a","b"]}`
+	var v map[string]interface{}
+	if err := Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	out, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Parse(doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseReader(strings.NewReader(doc)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Unmarshal([]byte(`{"name":`), &v); err == nil {
+		t.Fatal("Unmarshal of truncated input: expected error")
+	}
+
+	want := []struct {
+		op    Operation
+		bytes int
+		err   bool
+	}{
+		{OpUnmarshal, len(doc), false},
+		{OpMarshal, len(out), false},
+		{OpParse, len(doc), false},
+		{OpParse, len(doc), false},
+		{OpUnmarshal, len(`{"name":`), true},
+	}
+	seen := rec.take()
+	if len(seen) != len(want) {
+		t.Fatalf("got %d measurements, want %d: %+v", len(seen), len(want), seen)
+	}
+	for i, w := range want {
+		m := seen[i]
+		if m.Op != w.op || m.Bytes != w.bytes || (m.Err != nil) != w.err || m.Duration < 0 {
+			t.Errorf("measurement %d = %+v, want op %v, %d bytes, error %v", i, m, w.op, w.bytes, w.err)
+		}
+	}
+
+	SetMetrics(nil)
+	if _, err := Marshal(v); err != nil {
+		t.Fatal(err)
+	}
+	if seen := rec.take(); len(seen) != 0 {
+		t.Errorf("after SetMetrics(nil): %+v", seen)
+	}
+}
+
+func TestMetricsOption(t *testing.T) {
+	global, local := &recorder{}, &recorder{}
+	SetMetrics(global)
+	t.Cleanup(func() { SetMetrics(nil) })
+
+	data := []byte(`{"sku":"a","qty":2}`)
+	var item planItem
+	if err := UnmarshalWithOptions(data, &item, UnmarshalOptions{Metrics: local}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MarshalWithOptions(item, MarshalOptions{Metrics: local}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseWithOptions(string(data), ParseOptions{Metrics: local}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseReaderWithOptions(strings.NewReader(string(data)), ParseOptions{Metrics: local}); err != nil {
+		t.Fatal(err)
+	}
+
+	var ops []string
+	for _, m := range local.take() {
+		ops = append(ops, m.Op.String())
+	}
+	if got := strings.Join(ops, ","); got != "unmarshal,marshal,parse,parse" {
+		t.Errorf("per-call Metrics saw %s", got)
+	}
+	if seen := global.take(); len(seen) != 0 {
+		t.Errorf("global Metrics saw %+v", seen)
+	}
+}
+
+func TestMetricsNested(t *testing.T) {
+	rec := &recorder{}
+	SetMetrics(rec)
+	t.Cleanup(func() { SetMetrics(nil) })
+
+	type patch struct {
+		Name Nullable[string] `json:"name"`
+		Age  Nullable[int]    `json:"age"`
+	}
+	var p patch
+	if err := Unmarshal([]byte(`{"name":"Bob","age":null}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Marshal(p); err != nil {
+		t.Fatal(err)
+	}
+	if seen := rec.take(); len(seen) != 2 {
+		t.Errorf("got %d measurements, want 2: %+v", len(seen), seen)
+	}
+}
+
+func TestOperationString(t *testing.T) {
+	for op, want := range map[Operation]string{OpParse: "parse", OpUnmarshal: "unmarshal", OpMarshal: "marshal", 0: "unknown"} {
+		if got := op.String(); got != want {
+			t.Errorf("Operation(%d).String() = %q, want %q", op, got, want)
+		}
+	}
+}
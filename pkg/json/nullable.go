@@ -0,0 +1,246 @@
+package json
+
+import (
+	"reflect"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Nullable holds a value of type T that may be absent, explicitly null, or
+// present, so a struct field can tell the three apart. This matters for
+// partial updates, where a missing member leaves a field alone but null
+// clears it:
+//
+//	type UserPatch struct {
+//	    Nickname json.Nullable[string] `json:"nickname,omitempty"`
+//	}
+//
+//	var p UserPatch
+//	json.Unmarshal([]byte(`{"nickname":null}`), &p)
+//	p.Nickname.IsPresent() // true
+//	p.Nickname.IsNull()    // true
+//
+// The zero Nullable is absent. Decoding sets it to null or to a value; it
+// stays absent if its member does not appear. It encodes as null when null
+// or absent, and is omitted when absent if the field is tagged omitempty.
+//
+// Optional and Null track only one of the two distinctions.
+type Nullable[T any] struct {
+	value T
+	state nullableState
+}
+
+type nullableState uint8
+
+const (
+	nullableAbsent nullableState = iota
+	nullableNull
+	nullableSet
+)
+
+// NullableOf returns a Nullable holding v.
+func NullableOf[T any](v T) Nullable[T] {
+	return Nullable[T]{value: v, state: nullableSet}
+}
+
+// NullOf returns a Nullable that is explicitly null.
+func NullOf[T any]() Nullable[T] {
+	return Nullable[T]{state: nullableNull}
+}
+
+// IsPresent reports whether n was set, to null or to a value.
+func (n Nullable[T]) IsPresent() bool {
+	return n.state != nullableAbsent
+}
+
+// IsNull reports whether n is explicitly null.
+func (n Nullable[T]) IsNull() bool {
+	return n.state == nullableNull
+}
+
+// Get returns the value held by n, and whether there is one. It returns the
+// zero T and false if n is absent or null.
+func (n Nullable[T]) Get() (T, bool) {
+	return n.value, n.state == nullableSet
+}
+
+// ValueOr returns the value held by n, or def if n is absent or null.
+func (n Nullable[T]) ValueOr(def T) T {
+	if n.state != nullableSet {
+		return def
+	}
+	return n.value
+}
+
+// MarshalJSON encodes the value held by n, or null if there is none.
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if n.state != nullableSet {
+		return []byte("null"), nil
+	}
+	return marshal(defaultEncodeState, n.value)
+}
+
+// UnmarshalJSON sets n to null if data is null, and otherwise decodes data
+// into the value held by n.
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = Nullable[T]{state: nullableNull}
+		return nil
+	}
+	if err := fastparser.Unmarshal(data, &n.value); err != nil {
+		return err
+	}
+	n.state = nullableSet
+	return nil
+}
+
+// JSONSlot lets Unmarshal decode into n in place. It is not meant to be
+// called directly.
+func (n *Nullable[T]) JSONSlot(null bool) interface{} {
+	if null {
+		*n = Nullable[T]{state: nullableNull}
+		return nil
+	}
+	n.state = nullableSet
+	return &n.value
+}
+
+func (n Nullable[T]) isAbsent() bool {
+	return n.state == nullableAbsent
+}
+
+// Optional holds a value of type T that may be absent, for members that
+// PATCH-style requests leave out to mean "unchanged":
+//
+//	type UserPatch struct {
+//	    Email json.Optional[string]  `json:"email,omitempty"`
+//	    Phone json.Optional[*string] `json:"phone,omitempty"`
+//	}
+//
+// A member that appears, even as null, sets the Optional; null is decoded
+// into T as usual, so Optional[*string] sees it as a nil pointer and
+// Optional[string] as "" (or an error under UnmarshalOptions.RejectNull).
+// The zero Optional is absent. It encodes as its value, or as null when
+// absent, and is omitted when absent if the field is tagged omitempty.
+type Optional[T any] struct {
+	value T
+	set   bool
+}
+
+// OptionalOf returns an Optional holding v.
+func OptionalOf[T any](v T) Optional[T] {
+	return Optional[T]{value: v, set: true}
+}
+
+// IsSet reports whether o holds a value.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// Get returns the value held by o, and whether there is one.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set
+}
+
+// ValueOr returns the value held by o, or def if it is absent.
+func (o Optional[T]) ValueOr(def T) T {
+	if !o.set {
+		return def
+	}
+	return o.value
+}
+
+// MarshalJSON encodes the value held by o, or null if it is absent.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	if !o.set {
+		return []byte("null"), nil
+	}
+	return marshal(defaultEncodeState, o.value)
+}
+
+// UnmarshalJSON decodes data into the value held by o and marks it set.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if err := fastparser.Unmarshal(data, &o.value); err != nil {
+		return err
+	}
+	o.set = true
+	return nil
+}
+
+// JSONSlot lets Unmarshal decode into o in place. It is not meant to be
+// called directly.
+func (o *Optional[T]) JSONSlot(null bool) interface{} {
+	o.set = true
+	return &o.value
+}
+
+func (o Optional[T]) isAbsent() bool {
+	return !o.set
+}
+
+// Null holds a value of type T or null, like sql.Null, without telling a
+// null member from a missing one; both leave Valid false:
+//
+//	type Reading struct {
+//	    Celsius json.Null[float64] `json:"celsius"`
+//	}
+//
+// It encodes as V when Valid and as null otherwise, and is omitted when not
+// Valid if the field is tagged omitempty.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// ValueOr returns V, or def if n is not Valid.
+func (n Null[T]) ValueOr(def T) T {
+	if !n.Valid {
+		return def
+	}
+	return n.V
+}
+
+// MarshalJSON encodes V, or null if n is not Valid.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if !n.Valid {
+		return []byte("null"), nil
+	}
+	return marshal(defaultEncodeState, n.V)
+}
+
+// UnmarshalJSON sets n to null if data is null, and otherwise decodes data
+// into V and marks n Valid.
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*n = Null[T]{}
+		return nil
+	}
+	if err := fastparser.Unmarshal(data, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// JSONSlot lets Unmarshal decode into n in place. It is not meant to be
+// called directly.
+func (n *Null[T]) JSONSlot(null bool) interface{} {
+	if null {
+		*n = Null[T]{}
+		return nil
+	}
+	n.Valid = true
+	return &n.V
+}
+
+func (n Null[T]) isAbsent() bool {
+	return !n.Valid
+}
+
+// absenter is implemented by the wrapper types, such as Nullable, whose
+// omitempty rule depends on their state rather than on a zero value.
+type absenter interface {
+	isAbsent() bool
+}
+
+var absenterType = reflect.TypeOf((*absenter)(nil)).Elem()
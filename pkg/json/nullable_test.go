@@ -0,0 +1,248 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+type nullablePatch struct {
+	Name Nullable[string] `json:"name,omitempty"`
+	Age  Nullable[int]    `json:"age"`
+}
+
+func TestNullable_Unmarshal(t *testing.T) {
+	decoders := map[string]func(string, interface{}) error{
+		"Unmarshal": func(s string, v interface{}) error { return Unmarshal([]byte(s), v) },
+		"UnmarshalWithAST": func(s string, v interface{}) error {
+			return UnmarshalWithAST([]byte(s), v)
+		},
+		"Decoder": func(s string, v interface{}) error { return NewDecoder(strings.NewReader(s)).Decode(v) },
+	}
+	for name, decode := range decoders {
+		t.Run(name, func(t *testing.T) {
+			var p nullablePatch
+			if err := decode(`{"name":null}`, &p); err != nil {
+				t.Fatal(err)
+			}
+			if !p.Name.IsPresent() || !p.Name.IsNull() {
+				t.Errorf("name: present=%v null=%v, want explicit null", p.Name.IsPresent(), p.Name.IsNull())
+			}
+			if p.Age.IsPresent() {
+				t.Error("age: present, want absent")
+			}
+
+			p = nullablePatch{}
+			if err := decode(`{"name":"ann","age":30}`, &p); err != nil {
+				t.Fatal(err)
+			}
+			if v, ok := p.Name.Get(); !ok || v != "ann" {
+				t.Errorf("name.Get() = %q, %v, want \"ann\", true", v, ok)
+			}
+			if got := p.Age.ValueOr(-1); got != 30 {
+				t.Errorf("age.ValueOr(-1) = %d, want 30", got)
+			}
+		})
+	}
+}
+
+func TestNullable_UnmarshalError(t *testing.T) {
+	var p nullablePatch
+	if err := Unmarshal([]byte(`{"age":"old"}`), &p); err == nil {
+		t.Fatal("expected an error decoding a string into Nullable[int]")
+	}
+}
+
+func TestNullable_Marshal(t *testing.T) {
+	tests := []struct {
+		name string
+		in   nullablePatch
+		want string
+	}{
+		{"absent", nullablePatch{}, `{"age":null}`},
+		{"null", nullablePatch{Name: NullOf[string](), Age: NullOf[int]()}, `{"name":null,"age":null}`},
+		{"set", nullablePatch{Name: NullableOf(""), Age: NullableOf(0)}, `{"name":"","age":0}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Marshal = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNullable_Accessors(t *testing.T) {
+	var absent Nullable[string]
+	if absent.IsPresent() || absent.IsNull() {
+		t.Error("zero Nullable should be absent")
+	}
+	if got := absent.ValueOr("def"); got != "def" {
+		t.Errorf("ValueOr = %q, want \"def\"", got)
+	}
+	if _, ok := NullOf[string]().Get(); ok {
+		t.Error("NullOf().Get() reported a value")
+	}
+	if got := NullOf[string]().ValueOr("def"); got != "def" {
+		t.Errorf("NullOf().ValueOr = %q, want \"def\"", got)
+	}
+}
+
+type nullSeen struct{ null bool }
+
+func (n *nullSeen) UnmarshalJSON(data []byte) error {
+	n.null = string(data) == "null"
+	return nil
+}
+
+func TestUnmarshal_NullReachesUnmarshaler(t *testing.T) {
+	var v struct{ X nullSeen }
+	if err := Unmarshal([]byte(`{"X":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !v.X.null {
+		t.Error("UnmarshalJSON was not called with null")
+	}
+}
+
+func TestUnmarshalWithOptions_RejectNull(t *testing.T) {
+	type target struct {
+		Age   int                    `json:"age"`
+		Tags  []string               `json:"tags"`
+		Meta  map[string]interface{} `json:"meta"`
+		Ptr   *int                   `json:"ptr"`
+		Any   interface{}            `json:"any"`
+		Maybe Nullable[int]          `json:"maybe"`
+	}
+	opts := UnmarshalOptions{RejectNull: true}
+
+	var v target
+	err := UnmarshalWithOptions([]byte(`{"tags":null,"meta":null,"ptr":null,"any":null,"maybe":null}`), &v, opts)
+	if err != nil {
+		t.Fatalf("nullable kinds rejected null: %v", err)
+	}
+	if !v.Maybe.IsNull() {
+		t.Error("maybe: want explicit null")
+	}
+
+	err = UnmarshalWithOptions([]byte(`{"age":null}`), &v, opts)
+	want := "json: age: cannot unmarshal null into Go value of type int"
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+
+	err = UnmarshalWithOptions([]byte(`{"tags":["a",null]}`), &v, opts)
+	want = "json: tags[1]: cannot unmarshal null into Go value of type string"
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+
+	// Without the option, null leaves the zero value as before
+	v = target{Age: 5}
+	if err := UnmarshalWithOptions([]byte(`{"age":null}`), &v, UnmarshalOptions{}); err != nil || v.Age != 0 {
+		t.Errorf("age = %d, err = %v, want 0, nil", v.Age, err)
+	}
+}
+
+type optionalPatch struct {
+	Email Optional[string]  `json:"email,omitempty"`
+	Phone Optional[*string] `json:"phone,omitempty"`
+}
+
+func TestOptional_Unmarshal(t *testing.T) {
+	for _, decode := range []func([]byte, interface{}) error{Unmarshal, UnmarshalWithAST} {
+		var p optionalPatch
+		if err := decode([]byte(`{"phone":null}`), &p); err != nil {
+			t.Fatal(err)
+		}
+		if p.Email.IsSet() {
+			t.Error("email: set, want absent")
+		}
+		if v, ok := p.Phone.Get(); !ok || v != nil {
+			t.Errorf("phone.Get() = %v, %v, want nil, true", v, ok)
+		}
+
+		p = optionalPatch{}
+		if err := decode([]byte(`{"email":"a@b.c","phone":"555"}`), &p); err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Email.ValueOr("none"); got != "a@b.c" {
+			t.Errorf("email = %q, want \"a@b.c\"", got)
+		}
+		if v, _ := p.Phone.Get(); v == nil || *v != "555" {
+			t.Errorf("phone = %v, want \"555\"", v)
+		}
+	}
+}
+
+func TestOptional_Marshal(t *testing.T) {
+	phone := "555"
+	tests := []struct {
+		in   optionalPatch
+		want string
+	}{
+		{optionalPatch{}, `{}`},
+		{optionalPatch{Email: OptionalOf(""), Phone: OptionalOf[*string](nil)}, `{"email":"","phone":null}`},
+		{optionalPatch{Phone: OptionalOf(&phone)}, `{"phone":"555"}`},
+	}
+	for _, tt := range tests {
+		got, err := Marshal(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Marshal = %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestOptional_RejectNull(t *testing.T) {
+	var p optionalPatch
+	err := UnmarshalWithOptions([]byte(`{"email":null}`), &p, UnmarshalOptions{RejectNull: true})
+	want := "json: email: cannot unmarshal null into Go value of type string"
+	if err == nil || err.Error() != want {
+		t.Errorf("err = %v, want %q", err, want)
+	}
+}
+
+func TestNull(t *testing.T) {
+	type reading struct {
+		Celsius Null[float64] `json:"celsius"`
+		Note    Null[string]  `json:"note,omitempty"`
+	}
+	r := reading{Celsius: Null[float64]{V: 1, Valid: true}}
+	if err := Unmarshal([]byte(`{"celsius":null}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Celsius.Valid {
+		t.Error("celsius: Valid after null")
+	}
+	if got := r.Celsius.ValueOr(-273); got != -273 {
+		t.Errorf("ValueOr = %v, want -273", got)
+	}
+
+	r = reading{}
+	if err := Unmarshal([]byte(`{"celsius":21.5}`), &r); err != nil {
+		t.Fatal(err)
+	}
+	if !r.Celsius.Valid || r.Celsius.V != 21.5 {
+		t.Errorf("celsius = %+v, want 21.5", r.Celsius)
+	}
+
+	got, err := Marshal(reading{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"celsius":null}` {
+		t.Errorf("Marshal = %s, want {\"celsius\":null}", got)
+	}
+	got, _ = Marshal(reading{Note: Null[string]{V: "ok", Valid: true}})
+	if string(got) != `{"celsius":null,"note":"ok"}` {
+		t.Errorf("Marshal = %s", got)
+	}
+}
@@ -0,0 +1,19 @@
+package json
+
+import "github.com/shapestone/shape-json/internal/fastparser"
+
+// Number is the literal text of a JSON number. UnmarshalOptions with
+// NumberPolicy set to NumberPolicyNumber, and a Decoder after UseNumber,
+// store numbers decoded into interface{} values as a Number, so that
+// large integers and decimal fractions keep every digit until the caller
+// converts them. Marshal writes a Number as the number it holds.
+//
+// Its methods are:
+//
+//	func (n Number) String() string            // the literal text
+//	func (n Number) Float64() (float64, error) // strconv.ParseFloat
+//	func (n Number) Int64() (int64, error)     // strconv.ParseInt
+//
+// Struct fields of type Number decode from JSON numbers, and from strings
+// holding a number, as in encoding/json.
+type Number = fastparser.Number
@@ -0,0 +1,103 @@
+package json
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNumberPolicy(t *testing.T) {
+	doc := []byte(`{"id":9007199254740993,"ratio":0.5,"n":[1,1.0,-0,1e2]}`)
+	tests := []struct {
+		policy NumberPolicy
+		compat CompatibilityLevel
+		want   map[string]interface{}
+	}{
+		{NumberPolicyDefault, ShapeDefaults, map[string]interface{}{
+			"id": int64(9007199254740993), "ratio": 0.5, "n": []interface{}{int64(1), 1.0, int64(0), 100.0},
+		}},
+		{NumberPolicyInt64, StrictStdlib, map[string]interface{}{
+			"id": int64(9007199254740993), "ratio": 0.5, "n": []interface{}{int64(1), 1.0, int64(0), 100.0},
+		}},
+		{NumberPolicyDefault, StrictStdlib, map[string]interface{}{
+			"id": 9007199254740992.0, "ratio": 0.5, "n": []interface{}{1.0, 1.0, 0.0, 100.0},
+		}},
+		{NumberPolicyFloat64, ShapeDefaults, map[string]interface{}{
+			"id": 9007199254740992.0, "ratio": 0.5, "n": []interface{}{1.0, 1.0, 0.0, 100.0},
+		}},
+		{NumberPolicyNumber, ShapeDefaults, map[string]interface{}{
+			"id": Number("9007199254740993"), "ratio": Number("0.5"), "n": []interface{}{Number("1"), Number("1.0"), Number("-0"), Number("1e2")},
+		}},
+	}
+	for _, tt := range tests {
+		for _, path := range []DecodePath{FastPath, ASTPath} {
+			var got interface{}
+			opts := UnmarshalOptions{NumberPolicy: tt.policy, Compatibility: tt.compat, Path: path}
+			if err := UnmarshalWithOptions(doc, &got, opts); err != nil {
+				t.Fatalf("policy %d, path %d: %v", tt.policy, path, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("policy %d, compat %d, path %d:\ngot  %#v\nwant %#v", tt.policy, tt.compat, path, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestDecoderUseNumber(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"a":12345678901234567890} {"a":2}`))
+	dec.UseNumber()
+	var v map[string]interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v["a"] != Number("12345678901234567890") {
+		t.Errorf("a = %#v", v["a"])
+	}
+
+	dec.SetNumberPolicy(NumberPolicyFloat64)
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v["a"] != 2.0 {
+		t.Errorf("a = %#v", v["a"])
+	}
+}
+
+func TestNumberField(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	var v struct {
+		Price Number `json:"price"`
+		Code  Number `json:"code"`
+	}
+	if err := Unmarshal([]byte(`{"price":19.99,"code":"42"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Price != "19.99" || v.Code != "42" {
+		t.Errorf("got %+v", v)
+	}
+	if f, err := v.Price.Float64(); err != nil || f != 19.99 {
+		t.Errorf("Float64() = %v, %v", f, err)
+	}
+	if i, err := v.Code.Int64(); err != nil || i != 42 {
+		t.Errorf("Int64() = %v, %v", i, err)
+	}
+	if err := Unmarshal([]byte(`{"code":"forty"}`), &v); err == nil {
+		t.Error("expected error for a string that is not a number")
+	}
+
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"price":19.99,"code":42}` {
+		t.Errorf("Marshal = %s", data)
+	}
+	if data, err := Marshal([]interface{}{Number(""), Number("1e400")}); err != nil || string(data) != `[0,1e400]` {
+		t.Errorf("Marshal = %s, %v", data, err)
+	}
+	if _, err := Marshal(Number("1.")); err == nil {
+		t.Error("expected error for an invalid Number")
+	}
+}
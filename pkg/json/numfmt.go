@@ -0,0 +1,91 @@
+package json
+
+import (
+	"math"
+	"strconv"
+)
+
+// NumberFormat controls how Marshal (through MarshalOptions.Numbers) and
+// Render (through RenderOptions.Numbers) write floating-point numbers, so
+// that output such as golden files does not depend on where Go happens to
+// switch to exponent notation:
+//
+//	opts := json.MarshalOptions{Numbers: &json.NumberFormat{
+//	    IntegerWhenExact:  true, // 1000000, not 1e+06
+//	    ExponentThreshold: 21,   // 1e-7 and 1e+21, but 0.000001 and 123456789012
+//	}}
+//
+// Integers held in integer types are always written in full.
+type NumberFormat struct {
+	// Format is 'g' (the default), 'f', or 'e', as for strconv.FormatFloat:
+	// 'f' never uses an exponent, 'e' always does, and 'g' uses one for
+	// large and small magnitudes.
+	Format byte
+
+	// Precision, if positive, is the number of digits to write: after the
+	// decimal point for 'f' and 'e', and in total for 'g'. Otherwise the
+	// shortest representation that reads back as the same float is used.
+	Precision int
+
+	// IntegerWhenExact writes floats that hold a whole number below 1e21 as
+	// an integer ("1000000", "-3") whatever Format and Precision say.
+	IntegerWhenExact bool
+
+	// ExponentThreshold, if positive, replaces the rule 'g' uses to choose
+	// exponent notation: it is used when the decimal exponent of the
+	// number is below -ExponentThreshold or at least ExponentThreshold.
+	// Zero keeps Go's rule, which writes 1000000 as "1e+06".
+	ExponentThreshold int
+}
+
+// appendFloat appends f, a float of the given bit size, formatted as nf
+// describes.
+func (nf *NumberFormat) appendFloat(buf []byte, f float64, bits int) []byte {
+	if nf.IntegerWhenExact && f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		if f == 0 {
+			return append(buf, '0')
+		}
+		return strconv.AppendFloat(buf, f, 'f', 0, bits)
+	}
+
+	format := nf.Format
+	if format == 0 {
+		format = 'g'
+	}
+	prec := nf.Precision
+	if prec <= 0 {
+		prec = -1
+	}
+	if format != 'g' || nf.ExponentThreshold <= 0 || f == 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return strconv.AppendFloat(buf, f, format, prec, bits)
+	}
+
+	// Write the number in exponent form to learn its exponent after
+	// rounding to the requested digits, then keep that form only if the
+	// exponent is outside the threshold
+	ePrec := prec
+	if prec > 0 {
+		ePrec = prec - 1
+	}
+	start := len(buf)
+	buf = strconv.AppendFloat(buf, f, 'e', ePrec, bits)
+	mark := start
+	for buf[mark] != 'e' {
+		mark++
+	}
+	exp, _ := strconv.Atoi(string(buf[mark+1:]))
+	if exp < -nf.ExponentThreshold || exp >= nf.ExponentThreshold {
+		return buf
+	}
+
+	if prec < 0 {
+		return strconv.AppendFloat(buf[:start], f, 'f', -1, bits)
+	}
+	// Rewrite the rounded digits in plain form
+	rounded, _ := strconv.ParseFloat(string(buf[start:]), bits)
+	decimals := prec - 1 - exp
+	if decimals < 0 {
+		decimals = 0
+	}
+	return strconv.AppendFloat(buf[:start], rounded, 'f', decimals, bits)
+}
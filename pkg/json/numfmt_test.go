@@ -0,0 +1,95 @@
+package json
+
+import (
+	"testing"
+)
+
+func TestNumberFormat_AppendFloat(t *testing.T) {
+	tests := []struct {
+		name string
+		nf   NumberFormat
+		f    float64
+		want string
+	}{
+		{"default", NumberFormat{}, 1e6, "1e+06"},
+		{"default fraction", NumberFormat{}, 0.5, "0.5"},
+		{"integer when exact", NumberFormat{IntegerWhenExact: true}, 1e6, "1000000"},
+		{"integer when exact negative", NumberFormat{IntegerWhenExact: true}, -3, "-3"},
+		{"integer when exact zero", NumberFormat{IntegerWhenExact: true}, 0, "0"},
+		{"integer when exact large", NumberFormat{IntegerWhenExact: true}, 1e21, "1e+21"},
+		{"integer when exact fraction", NumberFormat{IntegerWhenExact: true}, 2.5, "2.5"},
+		{"fixed", NumberFormat{Format: 'f'}, 1e6, "1000000"},
+		{"fixed precision", NumberFormat{Format: 'f', Precision: 2}, 3.14159, "3.14"},
+		{"exponent", NumberFormat{Format: 'e'}, 1500, "1.5e+03"},
+		{"exponent precision", NumberFormat{Format: 'e', Precision: 2}, 1500, "1.50e+03"},
+		{"threshold plain", NumberFormat{ExponentThreshold: 21}, 123456789012, "123456789012"},
+		{"threshold plain small", NumberFormat{ExponentThreshold: 7}, 0.000001, "0.000001"},
+		{"threshold exponent", NumberFormat{ExponentThreshold: 21}, 1e21, "1e+21"},
+		{"threshold exponent small", NumberFormat{ExponentThreshold: 6}, 1e-7, "1e-07"},
+		{"threshold precision", NumberFormat{ExponentThreshold: 10, Precision: 3}, 1234567, "1230000"},
+		{"threshold precision fraction", NumberFormat{ExponentThreshold: 10, Precision: 3}, 3.14159, "3.14"},
+		{"threshold rounding", NumberFormat{ExponentThreshold: 3, Precision: 2}, 999.9, "1.0e+03"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(tt.nf.appendFloat(nil, tt.f, 64)); got != tt.want {
+				t.Errorf("appendFloat(%v) = %s, want %s", tt.f, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarshalWithOptions_Numbers(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	v := struct {
+		Total float64            `json:"total"`
+		Ratio float32            `json:"ratio"`
+		Count int                `json:"count"`
+		Extra map[string]float64 `json:"extra"`
+		Any   interface{}        `json:"any"`
+	}{Total: 1e6, Ratio: 0.25, Count: 1000000, Extra: map[string]float64{"x": 2e7}, Any: 3e6}
+
+	got, err := MarshalWithOptions(v, MarshalOptions{Numbers: &NumberFormat{IntegerWhenExact: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"total":1000000,"ratio":0.25,"count":1000000,"extra":{"x":20000000},"any":3000000}`
+	if string(got) != want {
+		t.Errorf("MarshalWithOptions =\n%s\nwant\n%s", got, want)
+	}
+
+	// The type-switch fast path honors the format too
+	got, err = MarshalWithOptions([]interface{}{1e6}, MarshalOptions{Numbers: &NumberFormat{Format: 'f'}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `[1000000]` {
+		t.Errorf("MarshalWithOptions = %s, want [1000000]", got)
+	}
+}
+
+func TestRenderWithOptions(t *testing.T) {
+	node, err := Parse(`{"big":1e6,"small":0.0000001,"n":3}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := RenderWithOptions(node, RenderOptions{Numbers: &NumberFormat{IntegerWhenExact: true, ExponentThreshold: 6}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"big":1000000,"n":3,"small":1e-07}`; string(got) != want {
+		t.Errorf("RenderWithOptions = %s, want %s", got, want)
+	}
+
+	got, err = RenderWithOptions(node, RenderOptions{Indent: "  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := RenderIndent(node, "", "  ")
+	if string(got) != string(want) {
+		t.Errorf("RenderWithOptions with Indent =\n%s\nwant\n%s", got, want)
+	}
+}
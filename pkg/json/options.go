@@ -0,0 +1,396 @@
+package json
+
+import (
+	"hash"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+// MarshalOptions configures MarshalWithOptions.
+//
+// The zero value produces the same output as Marshal.
+type MarshalOptions struct {
+	// Transforms maps transform names, as used in "transform:<name>" struct
+	// tag options, to the Transformer applied to those fields.
+	Transforms map[string]Transformer
+
+	// Canonical produces the JSON Canonicalization Scheme form of RFC 8785,
+	// for payloads that are signed or hashed, such as JWT claims and other
+	// JOSE headers: no whitespace, object members sorted by key (including
+	// maps and the output of Marshaler implementations), no escaping beyond
+	// what JSON requires (so '/', '<', '>', and '&' are written as is), and
+	// numbers in ECMAScript form (1e+21, 1e-7, 0.5; integral floats as
+	// integers). The same value therefore always yields the same bytes.
+	//
+	// Integers that fit in an int64 are written exactly, even beyond 2^53
+	// where RFC 8785 would round them through a float64.
+	Canonical bool
+
+	// KeyOrder selects the order of struct fields in objects. The default,
+	// KeyOrderDeclared, follows the struct declaration; KeyOrderSorted sorts
+	// them by name. Map keys are sorted unless UnsortedMaps is set.
+	KeyOrder KeyOrder
+
+	// UnsortedMaps writes the members of maps in Go's map iteration order
+	// instead of sorting their keys, which saves a large share of the time
+	// spent marshaling big maps. The output is then not deterministic: the
+	// same map may be written differently each time. Use it where nothing
+	// compares or hashes the output, such as log pipelines. KeyLess and
+	// Canonical take precedence over it.
+	UnsortedMaps bool
+
+	// KeyLess, if set, orders the members of every object, from structs and
+	// maps alike, overriding KeyOrder. It reports whether the member named a
+	// sorts before the one named b; members it considers equal keep their
+	// declaration order.
+	KeyLess func(a, b string) bool
+
+	// OmitEmptyNested widens "omitempty" to values that would only add
+	// empty structure, such as {} or {"a": {}}, to the output:
+	//
+	//	field value                        omitempty   with OmitEmptyNested
+	//	false, 0, ""                       omitted     omitted
+	//	nil pointer, interface, map, slice omitted     omitted
+	//	empty map, slice, or array         omitted     omitted
+	//	non-empty slice or array           kept        kept
+	//	struct                             kept        omitted if all its fields are empty
+	//	non-empty map                      kept        omitted if all its values are empty structs or maps
+	//	pointer or interface to the above  kept        omitted if the struct or map is
+	//	pointer to false, 0, or ""         kept        kept
+	//	Marshaler, such as time.Time       by kind     omitted if the zero value
+	//
+	// "Empty" applies recursively, so a struct holding only an empty map
+	// is empty. Exported fields count whether or not they are omitempty
+	// themselves; fields tagged "-" are ignored.
+	OmitEmptyNested bool
+
+	// Valuers encodes types that implement driver.Valuer, and not
+	// Marshaler, as the value their Value method returns, so that a
+	// database model encodes as it is stored. The nullable types of
+	// database/sql, such as sql.NullString, are encoded as their value or
+	// null regardless.
+	Valuers bool
+
+	// Int64AsString writes 64-bit integers (int64, uint64, int, and uint)
+	// as JSON strings, as the protobuf JSON mapping does, so that clients
+	// that read numbers as float64, such as JavaScript, never lose
+	// precision above 2^53. It applies to integers held in interface
+	// values too. Single fields can be quoted with the ",string" tag
+	// option instead. UnmarshalOptions.Int64AsString reads both forms back.
+	Int64AsString bool
+
+	// Numbers, if set, controls how floating-point numbers are written, for
+	// example to write 1e6 as 1000000 rather than 1e+06. Canonical
+	// overrides it.
+	Numbers *NumberFormat
+
+	// Types, if set, replaces the encoding of the Go types registered in
+	// it, such as writing every time.Time as Unix seconds.
+	Types *TypeRegistry
+
+	// Compatibility, if StrictStdlib, writes what encoding/json would
+	// where Marshal differs from it; see CompatibilityLevel. The other
+	// options apply on top of it.
+	Compatibility CompatibilityLevel
+
+	// Metrics, if set, receives the Measurement of this call instead of
+	// the Metrics set with SetMetrics.
+	Metrics Metrics
+
+	// MaxOutputSize, if positive, limits the output to that many bytes.
+	// Encoding stops with a *LimitError soon after the output passes the
+	// limit, checked after each object member and array element, so that
+	// a runaway value, such as a slice appended to in a loop, never builds
+	// a buffer of gigabytes. Zero means no limit.
+	MaxOutputSize int64
+}
+
+// KeyOrder is the order of struct fields written by Marshal.
+type KeyOrder uint8
+
+const (
+	// KeyOrderDeclared writes struct fields in the order they are declared,
+	// as encoding/json does.
+	KeyOrderDeclared KeyOrder = iota
+
+	// KeyOrderSorted writes struct fields sorted by their JSON name, the
+	// order Marshal used before declaration order became the default.
+	KeyOrderSorted
+)
+
+// CompatibilityLevel selects whether MarshalWithOptions and
+// UnmarshalWithOptions follow this package's defaults or encoding/json
+// where the two differ, so that a codebase can switch packages without a
+// byte of its output changing and then adopt the defaults deliberately:
+//
+//	opts := json.MarshalOptions{Compatibility: json.StrictStdlib}
+//	data, err := json.MarshalWithOptions(v, opts) // same bytes as encoding/json
+//
+// Under StrictStdlib, Marshal
+//
+//   - writes floats without an exponent from 1e-6 up to 1e21, and with a
+//     one-digit exponent where possible ("1e-7", not "1e-07"), and returns
+//     an error for NaN and infinities
+//   - escapes '<', '>', '&', U+2028, and U+2029 in strings, replaces
+//     invalid UTF-8 with U+FFFD, and does not escape '/'
+//   - writes []byte as a base64 string and time.Duration as an integer
+//     count of nanoseconds
+//   - compacts the output of MarshalJSON methods, escaping it the same
+//     way, and returns an error if it is not valid JSON
+//   - writes values that implement encoding.TextMarshaler, such as net.IP
+//     and netip.Addr, as the string they marshal to
+//   - writes maps with integer and encoding.TextMarshaler keys, naming
+//     the members by the decimal or text form of the keys
+//   - promotes the fields of embedded structs into the outer object,
+//     following Go's rules for conflicting names, instead of writing an
+//     embedded struct as a member
+//   - writes the database/sql null types as the structs they are
+//
+// and Unmarshal
+//
+//   - stores numbers in interface{} values as float64, never int64,
+//     unless NumberPolicy says otherwise
+//   - matches object keys to struct fields case-insensitively when no
+//     field name matches exactly
+//   - decodes base64 strings into []byte, and strings into values that
+//     implement encoding.TextUnmarshaler
+//   - accepts the same map keys and embedded fields as Marshal, and the
+//     database/sql null types as objects
+//   - leaves values that cannot be nil unchanged on null
+//
+// The defaults already match encoding/json in the order of members:
+// struct fields in declaration order and map keys sorted.
+type CompatibilityLevel uint8
+
+const (
+	// ShapeDefaults follows this package's own rules.
+	ShapeDefaults CompatibilityLevel = iota
+
+	// StrictStdlib follows encoding/json where the defaults differ.
+	StrictStdlib
+)
+
+// UnmarshalOptions configures UnmarshalWithOptions.
+//
+// The zero value decodes the same way as Unmarshal.
+type UnmarshalOptions struct {
+	// Transforms maps transform names, as used in "transform:<name>" struct
+	// tag options, to the Transformer applied to those fields.
+	Transforms map[string]Transformer
+
+	// Resolvers choose the concrete type for values of interface types,
+	// such as a Polymorphic keyed by a discriminator member. Without a
+	// resolver, only empty interfaces can be decoded into.
+	Resolvers []TypeResolver
+
+	// Schema, if set, is checked while the document is decoded, without a
+	// separate pass over it. If decoding succeeds but the document violates
+	// the schema, UnmarshalWithOptions returns jsonschema.ValidationErrors
+	// with a JSON Pointer to each offending value; the target has been
+	// filled in regardless. Errors that stop decoding, such as syntax errors
+	// and type mismatches, are returned instead of schema violations.
+	Schema *jsonschema.Schema
+
+	// RejectNull makes a JSON null an error when it is decoded into a value
+	// that cannot hold it, such as an int, string, or struct field of those
+	// types, instead of quietly leaving the zero value:
+	//
+	//	json: age: cannot unmarshal null into Go value of type int
+	//
+	// Pointers, interfaces, maps, and slices still accept null, as do types
+	// that decode themselves, such as Nullable.
+	RejectNull bool
+
+	// StrictNumbers makes a number an error, instead of being rounded or
+	// truncated, when the integer or float it is decoded into cannot hold
+	// it exactly: 1.5 or 1e19 into an int64, 16777217 into a float32, or
+	// 9007199254740993 into a float64:
+	//
+	//	json: price: number 19.999999999999999 cannot be represented exactly in Go value of type float64
+	//
+	// A float holds a number exactly if the number is the float's value or
+	// its shortest decimal form, so 0.1 is accepted even though no binary
+	// float equals it. Numbers decoded into interface{} are not checked.
+	StrictNumbers bool
+
+	// Int64AsString accepts 64-bit integers (int64, uint64, int, and uint)
+	// written as JSON strings, as MarshalOptions.Int64AsString writes them,
+	// as well as plain numbers. Fields with the ",string" tag option accept
+	// both forms without it.
+	Int64AsString bool
+
+	// CoerceStrings accepts numbers and bools written as JSON strings, such
+	// as "42" or "true", wherever an integer, float, or bool is expected,
+	// as well as plain numbers and bools. The ",coerce" tag option does the
+	// same for one field. A string that does not hold a value of the
+	// target type is an error:
+	//
+	//	json: age: cannot unmarshal string "forty" into Go value of type int
+	CoerceStrings bool
+
+	// Scanners decodes types that implement sql.Scanner, and not
+	// Unmarshaler, by passing Scan the value as database/sql would: a
+	// string, int64, float64, bool, or nil, with objects and arrays as
+	// their JSON text. The nullable types of database/sql, such as
+	// sql.NullString, are decoded natively regardless.
+	Scanners bool
+
+	// Types, if set, replaces the decoding of the Go types registered in
+	// it.
+	Types *TypeRegistry
+
+	// NumberPolicy selects the Go type of the numbers stored in
+	// interface{} values; see NumberPolicy.
+	NumberPolicy NumberPolicy
+
+	// Compatibility, if StrictStdlib, decodes as encoding/json would where
+	// Unmarshal differs from it; see CompatibilityLevel.
+	Compatibility CompatibilityLevel
+
+	// Path selects how the document is decoded; see DecodePath. The zero
+	// value is FastPath.
+	Path DecodePath
+
+	// Metrics, if set, receives the Measurement of this call instead of
+	// the Metrics set with SetMetrics.
+	Metrics Metrics
+}
+
+// NumberPolicy selects the Go type Unmarshal stores JSON numbers as when
+// it decodes them into interface{} values, as the elements of
+// []interface{} and the values of map[string]interface{} included. Numbers
+// decoded into typed fields, such as int or float64, are not affected.
+type NumberPolicy uint8
+
+const (
+	// NumberPolicyDefault is NumberPolicyInt64, or NumberPolicyFloat64
+	// when Compatibility is StrictStdlib.
+	NumberPolicyDefault NumberPolicy = iota
+
+	// NumberPolicyInt64 stores numbers without a fraction or exponent that
+	// fit in an int64 as int64, so that IDs and counts above 2^53 keep
+	// their value, and other numbers as float64: 42 is int64(42), while
+	// 42.0, 4.2e1, and 1e19 are float64. Code that expects float64, as
+	// encoding/json stores, must handle both.
+	NumberPolicyInt64
+
+	// NumberPolicyFloat64 stores every number as float64, as encoding/json
+	// does. Integers beyond 2^53 lose precision.
+	NumberPolicyFloat64
+
+	// NumberPolicyNumber stores every number as a Number holding its
+	// text, as encoding/json does after Decoder.UseNumber.
+	NumberPolicyNumber
+)
+
+// mode returns the fastparser.NumberMode for p under compat.
+func (p NumberPolicy) mode(compat CompatibilityLevel) fastparser.NumberMode {
+	switch p {
+	case NumberPolicyFloat64:
+		return fastparser.NumbersFloat64
+	case NumberPolicyNumber:
+		return fastparser.NumbersAsNumber
+	case NumberPolicyDefault:
+		if compat == StrictStdlib {
+			return fastparser.NumbersFloat64
+		}
+	}
+	return fastparser.NumbersPreferInt64
+}
+
+// DecodePath selects how UnmarshalWithOptions decodes a document. The two
+// paths are not independent decoders: ASTPath changes only how objects and
+// arrays are walked, and everything else is decoded by the code FastPath
+// uses. They therefore store the same values and return the same errors,
+// with the same messages, and differ in cost.
+type DecodePath int
+
+const (
+	// FastPath decodes straight from the document text in one pass, as
+	// Unmarshal does. It is several times faster than ASTPath and
+	// allocates only the values it stores.
+	FastPath DecodePath = iota
+
+	// ASTPath parses the document into an AST, as Parse does, and then
+	// decodes the tree, as UnmarshalWithAST and Decoder.Decode do. It
+	// walks the objects and arrays of the tree itself, and hands scalars,
+	// interface{} values, Unmarshalers, and types registered in the options
+	// to the fast path at their offset in the text. A document the AST
+	// parser rejects is decoded by the fast path as a whole, so its syntax
+	// errors are the fast path's. It builds and releases a node for every
+	// value in the document.
+	ASTPath
+)
+
+// ParseOptions configures ParseWithOptions and ParseReaderWithOptions.
+//
+// The zero value parses the same way as Parse.
+type ParseOptions struct {
+	// OnUsage, if set, is called with the running totals of a parse every
+	// UsageInterval nodes, and once more when the parse succeeds, so that
+	// services can track which documents drive memory use. Returning an
+	// error stops the parse, which returns an error wrapping it:
+	//
+	//	opts := json.ParseOptions{OnUsage: func(u json.ParseUsage) error {
+	//	    if u.Bytes > tenant.Quota {
+	//	        return ErrQuotaExceeded
+	//	    }
+	//	    return nil
+	//	}}
+	OnUsage func(ParseUsage) error
+
+	// UsageInterval is the number of nodes created between calls to
+	// OnUsage. Zero means 1024.
+	UsageInterval int
+	// Metrics, if set, receives the Measurement of this call instead of
+	// the Metrics set with SetMetrics.
+	Metrics Metrics
+
+	// Hash, if set, is written the input as it is parsed: the bytes
+	// ParseReaderWithOptions reads from its reader, or the input string of
+	// ParseWithOptions. A successful parse reads its reader to the end, so
+	// h then holds a checksum of the whole document without reading it
+	// twice; after an error, it has seen what was read up to that point.
+	Hash hash.Hash
+}
+
+// ParseUsage reports the memory a parse has used so far.
+type ParseUsage struct {
+	// Nodes is the number of AST nodes created.
+	Nodes int64
+
+	// Bytes estimates the memory allocated for the nodes and the values
+	// they hold: strings, numbers, member maps, and element slices. It is
+	// computed from the shape of the document rather than measured, so
+	// it is cheap to track and the same on every run.
+	Bytes int64
+}
+
+// defaultUsageInterval is the UsageInterval used when it is zero.
+const defaultUsageInterval = 1024
+
+// schemaValidator adapts a jsonschema.Cursor to the fast parser's Validator.
+type schemaValidator struct {
+	c *jsonschema.Cursor
+}
+
+// newSchemaValidator returns a Validator for c, or nil if c is nil.
+func newSchemaValidator(c *jsonschema.Cursor) fastparser.Validator {
+	if c == nil {
+		return nil
+	}
+	return schemaValidator{c}
+}
+
+func (v schemaValidator) Member(key string) fastparser.Validator {
+	return newSchemaValidator(v.c.Member(key))
+}
+
+func (v schemaValidator) Element(index int) fastparser.Validator {
+	return newSchemaValidator(v.c.Element(index))
+}
+
+func (v schemaValidator) Done(raw []byte, walked bool) {
+	v.c.Done(raw, walked)
+}
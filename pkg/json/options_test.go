@@ -0,0 +1,541 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+var orderSchema = jsonschema.MustCompile([]byte(`{
+	"type": "object",
+	"required": ["id", "items"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "integer", "minimum": 1},
+		"status": {"enum": ["open", "closed"]},
+		"note": {"type": ["string", "null"], "maxLength": 5},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"items": {"$ref": "#/$defs/item"}
+		},
+		"meta": {"type": "object", "propertyNames": {"pattern": "^[a-z]+$"}, "maxProperties": 2},
+		"tags": {"type": "array", "uniqueItems": true},
+		"owner": {"anyOf": [{"type": "string"}, {"type": "object", "required": ["name"]}]}
+	},
+	"$defs": {
+		"item": {
+			"type": "object",
+			"required": ["sku"],
+			"properties": {
+				"sku": {"type": "string", "pattern": "^[A-Z]{3}$"},
+				"qty": {"type": "integer", "exclusiveMinimum": 0}
+			}
+		}
+	}
+}`))
+
+type schemaItem struct {
+	SKU string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+type schemaOrder struct {
+	ID     int               `json:"id"`
+	Status string            `json:"status"`
+	Note   *string           `json:"note"`
+	Items  []schemaItem      `json:"items"`
+	Meta   map[string]string `json:"meta"`
+	Tags   []string          `json:"tags"`
+	Owner  interface{}       `json:"owner"`
+	Extra  interface{}       `json:"extra"`
+}
+
+// violations returns the sorted messages of a ValidationErrors.
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var verrs jsonschema.ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("error %v (%T) is not ValidationErrors", err, err)
+	}
+	msgs := make([]string, len(verrs))
+	for i, e := range verrs {
+		msgs[i] = e.Error()
+	}
+	sort.Strings(msgs)
+	return msgs
+}
+
+func TestUnmarshalWithOptions_Schema(t *testing.T) {
+	inputs := []string{
+		`{"id":1,"items":[{"sku":"ABC","qty":2}]}`,
+		`{"id":0,"status":"lost","items":[]}`,
+		`{"id":2,"note":"too long","items":[{"sku":"ab","qty":0},{"qty":1}],"extra":true}`,
+		`{"id":3,"note":null,"items":[{"sku":"XYZ"}],"meta":{"a":"1","B":"2","c":"3"}}`,
+		`{"id":4,"items":[{"sku":"ABC"}],"tags":["x","y","x"],"owner":{"id":1}}`,
+		`{"id":5,"items":[{"sku":"ABC"}],"owner":"ann"}`,
+		`{"items":{}}`,
+		`[]`,
+	}
+
+	targets := map[string]func() interface{}{
+		"struct":    func() interface{} { return new(schemaOrder) },
+		"map":       func() interface{} { return new(map[string]interface{}) },
+		"interface": func() interface{} { return new(interface{}) },
+	}
+
+	for _, input := range inputs {
+		want := violations(t, orderSchema.ValidateBytes([]byte(input)))
+		for name, target := range targets {
+			err := UnmarshalWithOptions([]byte(input), target(), UnmarshalOptions{Schema: orderSchema})
+			if decodeErr := Unmarshal([]byte(input), target()); decodeErr != nil {
+				// Errors that stop decoding take precedence over the schema
+				if err == nil || err.Error() != decodeErr.Error() {
+					t.Errorf("%s %s: error = %v, want %v", name, input, err, decodeErr)
+				}
+				continue
+			}
+			if got := violations(t, err); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s %s:\ngot  %q\nwant %q", name, input, got, want)
+			}
+		}
+	}
+}
+
+func TestUnmarshalWithOptions_SchemaPaths(t *testing.T) {
+	var o schemaOrder
+	err := UnmarshalWithOptions([]byte(`{"id":1,"items":[{"sku":"ABC"},{"sku":"a/b"}]}`), &o, UnmarshalOptions{Schema: orderSchema})
+
+	var verrs jsonschema.ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 1 {
+		t.Fatalf("error = %v, want one violation", err)
+	}
+	if verrs[0].InstancePath != "/items/1/sku" || verrs[0].Keyword != "pattern" {
+		t.Errorf("violation = %+v", verrs[0])
+	}
+	// The target is filled in even when the schema is violated
+	if len(o.Items) != 2 || o.Items[1].SKU != "a/b" {
+		t.Errorf("decoded = %+v", o)
+	}
+}
+
+func TestUnmarshalWithOptions_SchemaWithTransformsAndResolvers(t *testing.T) {
+	schema := jsonschema.MustCompile([]byte(`{
+		"properties": {
+			"ssn": {"type": "string", "minLength": 3},
+			"shape": {"type": "object", "required": ["type"]}
+		}
+	}`))
+	type record struct {
+		SSN   string    `json:"ssn,transform:upper"`
+		Shape polyShape `json:"shape"`
+	}
+	opts := UnmarshalOptions{
+		Schema: schema,
+		Transforms: map[string]Transformer{"upper": TransformFuncs{
+			DecodeFunc: func(_ string, data []byte) ([]byte, error) { return []byte(strings.ToUpper(string(data))), nil },
+		}},
+		Resolvers: []TypeResolver{newShapes()},
+	}
+
+	var r record
+	err := UnmarshalWithOptions([]byte(`{"ssn":"ab","shape":{"type":"circle","radius":1}}`), &r, opts)
+	got := violations(t, err)
+	want := []string{"/ssn: minLength: must be at least 3 characters"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if r.SSN != "AB" || r.Shape != (polyCircle{Radius: 1}) {
+		t.Errorf("decoded = %+v", r)
+	}
+}
+
+func TestUnmarshalWithOptions_SchemaDecodeErrorWins(t *testing.T) {
+	var o schemaOrder
+	err := UnmarshalWithOptions([]byte(`{"id":"x","items":[]}`), &o, UnmarshalOptions{Schema: orderSchema})
+	var verrs jsonschema.ValidationErrors
+	if err == nil || errors.As(err, &verrs) {
+		t.Errorf("error = %v, want decode error", err)
+	}
+}
+
+func TestMarshalWithOptions_KeyOrder(t *testing.T) {
+	type inner struct {
+		Zed   int `json:"zed"`
+		Alpha int `json:"alpha"`
+	}
+	type outer struct {
+		Name  string                 `json:"name"`
+		Inner inner                  `json:"inner"`
+		Extra map[string]interface{} `json:"extra"`
+		Any   interface{}            `json:"any"`
+	}
+	v := outer{
+		Name:  "x",
+		Inner: inner{Zed: 1, Alpha: 2},
+		Extra: map[string]interface{}{"b": 1, "aa": 2},
+		Any:   map[string]interface{}{"ccc": 1, "d": 2},
+	}
+
+	byLength := func(a, b string) bool { return len(a) < len(b) }
+	tests := []struct {
+		name string
+		opts MarshalOptions
+		want string
+	}{
+		{"declared by default", MarshalOptions{},
+			`{"name":"x","inner":{"zed":1,"alpha":2},"extra":{"aa":2,"b":1},"any":{"ccc":1,"d":2}}`},
+		{"sorted", MarshalOptions{KeyOrder: KeyOrderSorted},
+			`{"any":{"ccc":1,"d":2},"extra":{"aa":2,"b":1},"inner":{"alpha":2,"zed":1},"name":"x"}`},
+		{"custom", MarshalOptions{KeyLess: byLength},
+			`{"any":{"d":2,"ccc":1},"name":"x","inner":{"zed":1,"alpha":2},"extra":{"b":1,"aa":2}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MarshalWithOptions(v, tt.opts)
+			if err != nil {
+				t.Fatalf("MarshalWithOptions() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("MarshalWithOptions() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Marshal matches the zero options
+	if got, _ := Marshal(v); string(got) != tests[0].want {
+		t.Errorf("Marshal() = %s, want %s", got, tests[0].want)
+	}
+	// KeyLess also reaches maps on the reflection-free path
+	got, err := MarshalWithOptions(map[string]interface{}{"bb": []interface{}{map[string]interface{}{"yy": 1, "z": 2}}, "a": 1}, MarshalOptions{KeyLess: byLength})
+	if err != nil || string(got) != `{"a":1,"bb":[{"z":2,"yy":1}]}` {
+		t.Errorf("MarshalWithOptions(map) = %s, %v", got, err)
+	}
+}
+
+func TestMarshalWithOptions_UnsortedMaps(t *testing.T) {
+	type holder struct {
+		Counts map[string]int         `json:"counts"`
+		Any    map[string]interface{} `json:"any"`
+	}
+	big := make(map[string]interface{})
+	for i := 0; i < 100; i++ {
+		big[fmt.Sprintf("k%02d", i)] = []interface{}{i, map[string]interface{}{"b": i, "a": "x"}}
+	}
+	values := []interface{}{
+		big,
+		map[string]int{"z": 1, "y": 2, "x": 3},
+		holder{Counts: map[string]int{"b": 1, "a": 2}, Any: big},
+		map[string]interface{}{},
+		map[string]int(nil),
+	}
+	unsorted := MarshalOptions{UnsortedMaps: true}
+	for _, v := range values {
+		want, err := Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MarshalWithOptions(v, unsorted)
+		if err != nil {
+			t.Fatalf("MarshalWithOptions() error = %v", err)
+		}
+		if eq, err := Equal(got, want); err != nil || !eq {
+			t.Errorf("MarshalWithOptions() = %.100s, want the data of %.100s (err %v)", got, want, err)
+		}
+	}
+
+	// KeyLess and Canonical still order the members
+	byLength := func(a, b string) bool { return len(a) < len(b) }
+	m := map[string]int{"ccc": 1, "a": 2, "bb": 3}
+	if got, _ := MarshalWithOptions(m, MarshalOptions{UnsortedMaps: true, KeyLess: byLength}); string(got) != `{"a":2,"bb":3,"ccc":1}` {
+		t.Errorf("with KeyLess = %s", got)
+	}
+	if got, _ := MarshalWithOptions(m, MarshalOptions{UnsortedMaps: true, Canonical: true}); string(got) != `{"a":2,"bb":3,"ccc":1}` {
+		t.Errorf("with Canonical = %s", got)
+	}
+}
+
+func TestMarshalWithOptions_OmitEmptyNested(t *testing.T) {
+	type leaf struct {
+		A int    `json:"a,omitempty"`
+		B string `json:"b"`
+	}
+	type holder struct {
+		Leaf leaf              `json:"leaf,omitempty"`
+		Tags map[string]string `json:"tags"`
+	}
+	zero, one := 0, 1
+
+	// Each case marshals struct{ F T `json:"f,omitempty"` } with F set to
+	// value, without and with OmitEmptyNested.
+	tests := []struct {
+		name       string
+		value      interface{}
+		off, onOut string
+	}{
+		{"zero int", 0, `{}`, `{}`},
+		{"int", 1, `{"f":1}`, `{"f":1}`},
+		{"empty string", "", `{}`, `{}`},
+		{"nil slice", []int(nil), `{}`, `{}`},
+		{"empty slice", []int{}, `{}`, `{}`},
+		{"slice of zeros", []int{0}, `{"f":[0]}`, `{"f":[0]}`},
+		{"empty map", map[string]int{}, `{}`, `{}`},
+		{"map of zeros", map[string]int{"x": 0}, `{"f":{"x":0}}`, `{"f":{"x":0}}`},
+		{"map of empty maps", map[string]map[string]int{"x": {}}, `{"f":{"x":{}}}`, `{}`},
+		{"map of empty structs", map[string]leaf{"x": {}}, `{"f":{"x":{"b":""}}}`, `{}`},
+		{"map of nil", map[string]*leaf{"x": nil}, `{"f":{"x":null}}`, `{"f":{"x":null}}`},
+		{"zero struct", leaf{}, `{"f":{"b":""}}`, `{}`},
+		{"struct", leaf{B: "x"}, `{"f":{"b":"x"}}`, `{"f":{"b":"x"}}`},
+		{"nested empty struct", holder{Tags: map[string]string{}}, `{"f":{"leaf":{"b":""},"tags":{}}}`, `{}`},
+		{"nested struct", holder{Leaf: leaf{A: 1}}, `{"f":{"leaf":{"a":1,"b":""},"tags":null}}`, `{"f":{"leaf":{"a":1,"b":""},"tags":null}}`},
+		{"nil pointer", (*leaf)(nil), `{}`, `{}`},
+		{"pointer to zero struct", &leaf{}, `{"f":{"b":""}}`, `{}`},
+		{"pointer to zero int", &zero, `{"f":0}`, `{"f":0}`},
+		{"pointer to int", &one, `{"f":1}`, `{"f":1}`},
+		{"interface of empty map", map[string]interface{}{"x": map[string]interface{}{}}, `{"f":{"x":{}}}`, `{}`},
+		{"zero time", time.Time{}, `{"f":"0001-01-01T00:00:00Z"}`, `{}`},
+		{"time", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), `{"f":"2024-01-02T00:00:00Z"}`, `{"f":"2024-01-02T00:00:00Z"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Build struct{ F T `json:"f,omitempty"` } for the value's type
+			typ := reflect.StructOf([]reflect.StructField{{
+				Name: "F",
+				Type: reflect.TypeOf(tt.value),
+				Tag:  `json:"f,omitempty"`,
+			}})
+			v := reflect.New(typ).Elem()
+			v.Field(0).Set(reflect.ValueOf(tt.value))
+
+			for _, nested := range []bool{false, true} {
+				want := tt.off
+				if nested {
+					want = tt.onOut
+				}
+				got, err := MarshalWithOptions(v.Interface(), MarshalOptions{OmitEmptyNested: nested})
+				if err != nil {
+					t.Fatalf("MarshalWithOptions(OmitEmptyNested: %v) error = %v", nested, err)
+				}
+				if string(got) != want {
+					t.Errorf("MarshalWithOptions(OmitEmptyNested: %v) = %s, want %s", nested, got, want)
+				}
+			}
+		})
+	}
+
+	// Fields without omitempty are always kept
+	type config struct {
+		Server leaf `json:"server"`
+		Proxy  leaf `json:"proxy,omitempty"`
+	}
+	got, err := MarshalWithOptions(config{}, MarshalOptions{OmitEmptyNested: true})
+	if err != nil || string(got) != `{"server":{"b":""}}` {
+		t.Errorf("MarshalWithOptions(config) = %s, %v", got, err)
+	}
+}
+
+func TestMarshalWithOptions_Int64AsString(t *testing.T) {
+	type event struct {
+		ID    int64                  `json:"id"`
+		Count int32                  `json:"count"`
+		Seq   uint                   `json:"seq"`
+		Extra map[string]interface{} `json:"extra"`
+	}
+	in := event{ID: 1 << 60, Count: 3, Seq: 9, Extra: map[string]interface{}{"n": int64(5), "f": 1.5}}
+	opts := MarshalOptions{Int64AsString: true}
+
+	data, err := MarshalWithOptions(in, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"1152921504606846976","count":3,"seq":"9","extra":{"f":1.5,"n":"5"}}`
+	if string(data) != want {
+		t.Fatalf("MarshalWithOptions =\n%s\nwant\n%s", data, want)
+	}
+
+	// Top-level values from the type-switch fast path are quoted too
+	if data, _ := MarshalWithOptions([]interface{}{int64(1), 2.5}, opts); string(data) != `["1",2.5]` {
+		t.Errorf("MarshalWithOptions = %s", data)
+	}
+
+	var out event
+	if err := UnmarshalWithOptions(data, &out, UnmarshalOptions{Int64AsString: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnmarshalWithOptions([]byte(`{"id":"1152921504606846976","count":3,"seq":9}`), &out, UnmarshalOptions{Int64AsString: true}); err != nil {
+		t.Fatal(err)
+	}
+	if out.ID != 1<<60 || out.Seq != 9 {
+		t.Errorf("decoded %+v", out)
+	}
+
+	// Narrow integers and the default options still want numbers
+	if err := UnmarshalWithOptions([]byte(`{"count":"3"}`), &out, UnmarshalOptions{Int64AsString: true}); err == nil {
+		t.Error("expected an error for a quoted int32")
+	}
+	if err := Unmarshal([]byte(`{"id":"1"}`), &out); err == nil {
+		t.Error("expected an error for a quoted int64 without Int64AsString")
+	}
+}
+
+// countingMarshaler counts the calls to its MarshalJSON.
+type countingMarshaler struct{ calls *int }
+
+func (m countingMarshaler) MarshalJSON() ([]byte, error) {
+	*m.calls++
+	return []byte(`"0123456789"`), nil
+}
+
+func TestMarshalWithOptions_MaxOutputSize(t *testing.T) {
+	type item struct {
+		Name string                 `json:"name"`
+		Tags []string               `json:"tags"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	in := item{Name: "a", Tags: []string{"x", "y"}, Meta: map[string]interface{}{"k": []interface{}{1, "v"}}}
+	full, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	max := int64(len(full))
+
+	for _, opts := range []MarshalOptions{{MaxOutputSize: max}, {MaxOutputSize: max, Canonical: true}} {
+		if data, err := MarshalWithOptions(in, opts); err != nil || len(data) != len(full) {
+			t.Errorf("MarshalWithOptions(%+v) = %s, %v", opts, data, err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		in   interface{}
+		opts MarshalOptions
+	}{
+		{"struct", in, MarshalOptions{MaxOutputSize: max - 1}},
+		{"interface tree", map[string]interface{}{"a": []interface{}{"0123456789", "0123456789"}}, MarshalOptions{MaxOutputSize: 20}},
+		{"one long string", strings.Repeat("x", 100), MarshalOptions{MaxOutputSize: 50}},
+		{"canonical", []float64{1e20}, MarshalOptions{MaxOutputSize: 10, Canonical: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := MarshalWithOptions(tt.in, tt.opts)
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || limitErr.Limit != "output size" || limitErr.Max != tt.opts.MaxOutputSize || data != nil {
+				t.Fatalf("MarshalWithOptions() = %s, %v", data, err)
+			}
+			want := fmt.Sprintf("json: output size exceeds the limit of %d bytes at offset %d", tt.opts.MaxOutputSize, tt.opts.MaxOutputSize)
+			if err.Error() != want {
+				t.Errorf("error = %q, want %q", err, want)
+			}
+		})
+	}
+
+	// Encoding stops soon after the limit, not at the end of the value
+	calls := 0
+	big := make([]countingMarshaler, 100000)
+	for i := range big {
+		big[i].calls = &calls
+	}
+	if _, err := MarshalWithOptions(big, MarshalOptions{MaxOutputSize: 1000}); err == nil {
+		t.Fatal("MarshalWithOptions(big) returned no error")
+	}
+	if calls > 100 {
+		t.Errorf("MarshalJSON called %d times, want the encoding to stop near the limit", calls)
+	}
+	plan, err := CompileEncoder(reflect.TypeOf(big))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var limitErr *LimitError
+	if _, err := plan.MarshalWithOptions(big, MarshalOptions{MaxOutputSize: 1000}); !errors.As(err, &limitErr) {
+		t.Errorf("EncodePlan.MarshalWithOptions(big) error = %v", err)
+	}
+}
+
+func TestUnmarshalWithOptions_CoerceStrings(t *testing.T) {
+	type item struct {
+		ID      uint8          `json:"id"`
+		Price   float32        `json:"price"`
+		InStock bool           `json:"inStock"`
+		Name    string         `json:"name"`
+		Sizes   []int          `json:"sizes"`
+		Extra   map[string]int `json:"extra"`
+		Any     interface{}    `json:"any"`
+	}
+	opts := UnmarshalOptions{CoerceStrings: true}
+
+	var out item
+	data := `{"id":"7","price":"1.25","inStock":"false","name":"12","sizes":["1",2],"extra":{"n":"3"},"any":"4"}`
+	if err := UnmarshalWithOptions([]byte(data), &out, opts); err != nil {
+		t.Fatal(err)
+	}
+	want := item{ID: 7, Price: 1.25, Name: "12", Sizes: []int{1, 2}, Extra: map[string]int{"n": 3}, Any: "4"}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("decoded %+v, want %+v", out, want)
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"id":"300"}`, `json: id: cannot unmarshal string "300" into Go value of type uint8`},
+		{`{"inStock":"yes"}`, `json: inStock: cannot unmarshal string "yes" into Go value of type bool`},
+		{`{"sizes":[1," 2x"]}`, `json: sizes[1]: cannot unmarshal string " 2x" into Go value of type int`},
+		{`{"price":""}`, `json: price: cannot unmarshal string "" into Go value of type float32`},
+	}
+	for _, tt := range tests {
+		err := UnmarshalWithOptions([]byte(tt.input), &out, opts)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("UnmarshalWithOptions(%s) error = %v, want %s", tt.input, err, tt.want)
+		}
+	}
+
+	if err := Unmarshal([]byte(`{"id":"7"}`), &out); err == nil {
+		t.Error("expected an error for a quoted number without CoerceStrings")
+	}
+}
+
+func TestUnmarshalWithOptions_StrictNumbers(t *testing.T) {
+	type order struct {
+		Qty   int     `json:"qty"`
+		Price float64 `json:"price"`
+		Ratio float32 `json:"ratio"`
+	}
+	opts := UnmarshalOptions{StrictNumbers: true}
+
+	var o order
+	if err := UnmarshalWithOptions([]byte(`{"qty":3,"price":19.99,"ratio":0.1}`), &o, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"qty":1.5}`, "json: qty: number 1.5 cannot be represented exactly in Go value of type int"},
+		{`{"price":19.999999999999999}`, "json: price: number 19.999999999999999 cannot be represented exactly in Go value of type float64"},
+		{`{"price":9007199254740993}`, "json: price: number 9007199254740993 cannot be represented exactly in Go value of type float64"},
+		{`{"ratio":1e308}`, "json: ratio: number 1e308 cannot be represented exactly in Go value of type float32"},
+	}
+	for _, tt := range tests {
+		err := UnmarshalWithOptions([]byte(tt.in), &o, opts)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%s: err = %v, want %q", tt.in, err, tt.want)
+		}
+	}
+
+	// Without the option, precision is lost quietly
+	if err := Unmarshal([]byte(`{"price":9007199254740993}`), &o); err != nil || o.Price != 9007199254740992 {
+		t.Errorf("price = %v, err = %v", o.Price, err)
+	}
+}
@@ -54,6 +54,14 @@
 //	// node is now a *ast.ObjectNode representing the JSON data
 //
 // For more examples, see the examples/parse_reader directory.
+//
+// # TinyGo
+//
+// TinyGo builds, which set the tinygo build tag, leave out the
+// reflection-based codec. Parsing, validation, and the DOM are unchanged;
+// Marshal and Unmarshal handle only the types their TinyGo documentation
+// lists, and the compiled codecs, Polymorphic, Sanitize, EstimateSize, and
+// the Vet functions are not available.
 package json
 
 import (
@@ -65,12 +73,28 @@ import (
 	"github.com/shapestone/shape-json/internal/parser"
 )
 
+// MaxDepth is the deepest nesting of objects and arrays that the parsers,
+// decoders, and validators in this package accept. Deeper documents are
+// rejected with an error wrapping ErrDepthExceeded, so that hostile input
+// such as a long run of '[' cannot overflow the stack and crash the
+// process.
+const MaxDepth = fastparser.MaxDepth
+
+// ErrDepthExceeded is wrapped by the error returned for a document nested
+// more than MaxDepth levels deep:
+//
+//	if errors.Is(err, json.ErrDepthExceeded) {
+//	    http.Error(w, "document too deeply nested", http.StatusBadRequest)
+//	}
+var ErrDepthExceeded = fastparser.ErrDepthExceeded
+
 // Parse parses JSON format into an AST from a string.
 //
 // The input is a complete JSON value (object, array, string, number, boolean, or null).
 //
 // Returns an ast.SchemaNode representing the parsed JSON:
-//   - *ast.ObjectNode for objects (and arrays, represented as objects with numeric string keys)
+//   - *ast.ObjectNode for objects
+//   - *ast.ArrayDataNode for arrays
 //   - *ast.LiteralNode for primitives (string, number, boolean, null)
 //
 // For parsing large files or streaming data, use ParseReader instead.
@@ -82,8 +106,7 @@ import (
 //	nameNode, _ := obj.GetProperty("name")
 //	name := nameNode.(*ast.LiteralNode).Value().(string) // "Alice"
 func Parse(input string) (ast.SchemaNode, error) {
-	p := parser.NewParser(input)
-	return p.Parse()
+	return ParseWithOptions(input, ParseOptions{})
 }
 
 // ParseReader parses JSON format into an AST from an io.Reader.
@@ -99,7 +122,8 @@ func Parse(input string) (ast.SchemaNode, error) {
 //   - Network streams, compressed streams, etc.
 //
 // Returns an ast.SchemaNode representing the parsed JSON:
-//   - *ast.ObjectNode for objects (and arrays, represented as objects with numeric string keys)
+//   - *ast.ObjectNode for objects
+//   - *ast.ArrayDataNode for arrays
 //   - *ast.LiteralNode for primitives (string, number, boolean, null)
 //
 // Example parsing from a file:
@@ -121,9 +145,66 @@ func Parse(input string) (ast.SchemaNode, error) {
 //	reader := strings.NewReader(`{"name": "Alice", "age": 30}`)
 //	node, err := json.ParseReader(reader)
 func ParseReader(reader io.Reader) (ast.SchemaNode, error) {
-	stream := tokenizer.NewStreamFromReader(reader)
-	p := parser.NewParserFromStream(stream)
-	return p.Parse()
+	return ParseReaderWithOptions(reader, ParseOptions{})
+}
+
+// ParseWithOptions is like Parse but applies opts.
+func ParseWithOptions(input string, opts ParseOptions) (ast.SchemaNode, error) {
+	m, start := startMetrics(opts.Metrics)
+	if opts.Hash != nil {
+		io.WriteString(opts.Hash, input)
+	}
+	node, err := parseWithOptions(parser.NewParser(input), opts)
+	observe(m, OpParse, len(input), start, err)
+	if err != nil {
+		rejected(OpParse, input, err)
+	}
+	return node, err
+}
+
+// ParseReaderWithOptions is like ParseReader but applies opts.
+func ParseReaderWithOptions(reader io.Reader, opts ParseOptions) (ast.SchemaNode, error) {
+	m, start := startMetrics(opts.Metrics)
+	if opts.Hash != nil {
+		reader = io.TeeReader(reader, opts.Hash)
+	}
+	if m == nil {
+		return parseWithOptions(parser.NewParserFromStream(tokenizer.NewStreamFromReader(reader)), opts)
+	}
+	cr := &countingReader{r: reader}
+	node, err := parseWithOptions(parser.NewParserFromStream(tokenizer.NewStreamFromReader(cr)), opts)
+	observe(m, OpParse, cr.n, start, err)
+	return node, err
+}
+
+// parseWithOptions runs p with the usage reporting of opts.
+func parseWithOptions(p *parser.Parser, opts ParseOptions) (ast.SchemaNode, error) {
+	if opts.OnUsage == nil {
+		return p.Parse()
+	}
+	interval := int64(opts.UsageInterval)
+	if interval <= 0 {
+		interval = defaultUsageInterval
+	}
+	var usage ParseUsage
+	p.SetNodeHook(func(bytes int) error {
+		usage.Nodes++
+		usage.Bytes += int64(bytes)
+		if usage.Nodes%interval == 0 {
+			return opts.OnUsage(usage)
+		}
+		return nil
+	})
+
+	node, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	if err := opts.OnUsage(usage); err != nil {
+		ReleaseTree(node)
+		return nil, err
+	}
+	return node, nil
 }
 
 // Format returns the format identifier for this parser.
@@ -134,7 +215,10 @@ func Format() string {
 
 // Validate checks if the input string is valid JSON.
 //
-// This function uses a high-performance fast path that bypasses AST construction.
+// This function runs a dedicated validation state machine that builds no
+// AST and decodes no values, so it does not allocate for valid input.
+// Numbers are checked against the JSON grammar only: 1e400 is valid even
+// though it overflows a float64.
 //
 // Returns nil if the input is valid JSON.
 // Returns an error with details about why the JSON is invalid.
@@ -155,15 +239,60 @@ func Format() string {
 //   - Booleans: true, false
 //   - Null: null
 func Validate(input string) error {
-	// Fast path: Just parse and discard (4-5x faster than AST construction)
-	parser := fastparser.NewParser([]byte(input))
-	_, err := parser.Parse()
-	return err
+	return fastparser.Validate([]byte(input))
+}
+
+// ValidateBytes is Validate for input held in a byte slice.
+func ValidateBytes(data []byte) error {
+	return fastparser.Validate(data)
+}
+
+// Stats summarizes the structure of a JSON document. See ValidateWithStats.
+type Stats struct {
+	// Objects, Arrays, Strings, Numbers, Bools, and Nulls count the values
+	// of each JSON type, including the root. Object keys are not values.
+	Objects int
+	Arrays  int
+	Strings int
+	Numbers int
+	Bools   int
+	Nulls   int
+
+	// MaxDepth is the deepest container nesting level. A scalar document has
+	// depth 0, {} and [] have depth 1, {"a":[]} has depth 2, and so on.
+	MaxDepth int
+
+	// LongestString is the length in bytes of the longest string, key or
+	// value, as written in the input: escape sequences such as \n count as
+	// written, and the quotes are not counted.
+	LongestString int
+
+	// LongestArray is the number of elements of the longest array.
+	LongestArray int
+}
+
+// ValidateWithStats checks if the input string is valid JSON, like
+// Validate, and summarizes its structure in the same pass, so that a
+// pipeline can enforce limits before decoding:
+//
+//	stats, err := json.ValidateWithStats(input)
+//	if err != nil {
+//	    return err
+//	}
+//	if stats.MaxDepth > 64 || stats.LongestArray > 10000 {
+//	    return errors.New("document too complex")
+//	}
+//
+// If the input is invalid, the returned Stats cover the part of the input
+// before the error.
+func ValidateWithStats(input string) (Stats, error) {
+	st, err := fastparser.ValidateStats([]byte(input))
+	return Stats(st), err
 }
 
 // ValidateReader checks if the input from an io.Reader is valid JSON.
 //
-// This function uses a high-performance fast path that bypasses AST construction.
+// This function uses the same state machine as Validate.
 //
 // Returns nil if the input is valid JSON.
 // Returns an error with details about why the JSON is invalid.
@@ -181,15 +310,33 @@ func Validate(input string) error {
 //
 // For large streams, consider using ParseReader directly and handling errors.
 func ValidateReader(reader io.Reader) error {
-	// Fast path: Read all data and parse without AST
 	data, err := io.ReadAll(reader)
 	if err != nil {
 		return err
 	}
+	return fastparser.Validate(data)
+}
 
-	parser := fastparser.NewParser(data)
-	_, err = parser.Parse()
-	return err
+// SkipValue skips over the JSON value that starts at data[offset] and returns
+// the offset of the first byte after it.
+//
+// Leading whitespace before the value is skipped; whitespace after it is not.
+// The skipped value is fully validated (including string escapes and number
+// syntax) but never decoded, so skipping costs no allocations. This is the
+// same scanner Unmarshal uses for unknown struct fields, and is intended for
+// callers building their own streaming or indexing layers.
+//
+// If the value is malformed, SkipValue returns an error and end is the offset
+// at which scanning stopped.
+//
+// Example:
+//
+//	data := []byte(`{"skip": [1, 2, {"a": 3}], "next": true}`)
+//	start := bytes.IndexByte(data, '[')
+//	end, err := json.SkipValue(data, start)
+//	// data[start:end] is `[1, 2, {"a": 3}]`
+func SkipValue(data []byte, offset int) (end int, err error) {
+	return fastparser.SkipValue(data, offset)
 }
 
 // DetectFormat attempts to detect if the input is valid JSON.
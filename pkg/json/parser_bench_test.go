@@ -1,9 +1,12 @@
+//go:build !tinygo
+
 package json_test
 
 import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -431,6 +434,81 @@ func BenchmarkValidate_ASTPath(b *testing.B) {
 	}
 }
 
+// benchmarkValidateStateMachine benchmarks ValidateBytes, the dedicated
+// validation state machine, over input.
+func benchmarkValidateStateMachine(b *testing.B, input string) {
+	data := []byte(input)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := shapejson.ValidateBytes(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkEncodingJSONValid benchmarks encoding/json.Valid over input.
+func benchmarkEncodingJSONValid(b *testing.B, input string) {
+	data := []byte(input)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !json.Valid(data) {
+			b.Fatal("invalid JSON")
+		}
+	}
+}
+
+// BenchmarkValidate_StateMachine_Small benchmarks the validation state machine on small JSON.
+func BenchmarkValidate_StateMachine_Small(b *testing.B) {
+	if err := loadBenchmarkData(); err != nil {
+		b.Fatalf("Failed to load benchmark data: %v", err)
+	}
+	benchmarkValidateStateMachine(b, smallJSON)
+}
+
+// BenchmarkValidate_StateMachine_Medium benchmarks the validation state machine on medium JSON.
+func BenchmarkValidate_StateMachine_Medium(b *testing.B) {
+	if err := loadBenchmarkData(); err != nil {
+		b.Fatalf("Failed to load benchmark data: %v", err)
+	}
+	benchmarkValidateStateMachine(b, mediumJSON)
+}
+
+// BenchmarkValidate_StateMachine_Large benchmarks the validation state machine on large JSON.
+func BenchmarkValidate_StateMachine_Large(b *testing.B) {
+	if err := loadBenchmarkData(); err != nil {
+		b.Fatalf("Failed to load benchmark data: %v", err)
+	}
+	benchmarkValidateStateMachine(b, largeJSON)
+}
+
+// BenchmarkEncodingJSON_Valid_Small benchmarks encoding/json.Valid on small JSON.
+func BenchmarkEncodingJSON_Valid_Small(b *testing.B) {
+	if err := loadBenchmarkData(); err != nil {
+		b.Fatalf("Failed to load benchmark data: %v", err)
+	}
+	benchmarkEncodingJSONValid(b, smallJSON)
+}
+
+// BenchmarkEncodingJSON_Valid_Medium benchmarks encoding/json.Valid on medium JSON.
+func BenchmarkEncodingJSON_Valid_Medium(b *testing.B) {
+	if err := loadBenchmarkData(); err != nil {
+		b.Fatalf("Failed to load benchmark data: %v", err)
+	}
+	benchmarkEncodingJSONValid(b, mediumJSON)
+}
+
+// BenchmarkEncodingJSON_Valid_Large benchmarks encoding/json.Valid on large JSON.
+func BenchmarkEncodingJSON_Valid_Large(b *testing.B) {
+	if err := loadBenchmarkData(); err != nil {
+		b.Fatalf("Failed to load benchmark data: %v", err)
+	}
+	benchmarkEncodingJSONValid(b, largeJSON)
+}
+
 // BenchmarkUnmarshal_FastPath_Small benchmarks unmarshaling small JSON using fast path.
 func BenchmarkUnmarshal_FastPath_Small(b *testing.B) {
 	if err := loadBenchmarkData(); err != nil {
@@ -506,3 +584,111 @@ func BenchmarkUnmarshal_ASTPath_Medium(b *testing.B) {
 		_ = v
 	}
 }
+
+// ================================
+// Number-Heavy Benchmarks
+// ================================
+
+// intsJSON is an array of 1000 integers of every length up to 19 digits,
+// half of them negative.
+var intsJSON = func() []byte {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	n := int64(7)
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		v := n % 1000000000000000000
+		if i%2 == 1 {
+			v = -v
+		}
+		sb.WriteString(strconv.FormatInt(v, 10))
+		n = n*31 + int64(i)
+		if n > 1e17 {
+			n %= 99991
+		}
+	}
+	sb.WriteByte(']')
+	return []byte(sb.String())
+}()
+
+// BenchmarkUnmarshal_Ints_Slice benchmarks decoding integers into []int64.
+func BenchmarkUnmarshal_Ints_Slice(b *testing.B) {
+	b.SetBytes(int64(len(intsJSON)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v []int64
+		if err := shapejson.Unmarshal(intsJSON, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUnmarshal_Ints_Interface benchmarks decoding integers into
+// interface{}, where each becomes an int64.
+func BenchmarkUnmarshal_Ints_Interface(b *testing.B) {
+	b.SetBytes(int64(len(intsJSON)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v interface{}
+		if err := shapejson.Unmarshal(intsJSON, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkEncodingJSON_Unmarshal_Ints_Slice is the encoding/json baseline
+// for BenchmarkUnmarshal_Ints_Slice.
+func BenchmarkEncodingJSON_Unmarshal_Ints_Slice(b *testing.B) {
+	b.SetBytes(int64(len(intsJSON)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v []int64
+		if err := json.Unmarshal(intsJSON, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// recordsJSON is an array of 1000 objects with the same six keys, as in an
+// API listing or a log export.
+var recordsJSON = func() []byte {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(`{"id":` + strconv.Itoa(i) + `,"name":"user` + strconv.Itoa(i) +
+			`","active":true,"score":` + strconv.Itoa(i%97) + `,"createdAt":"2026-10-16T00:00:00Z","tags":["a"]}`)
+	}
+	sb.WriteByte(']')
+	return []byte(sb.String())
+}()
+
+// BenchmarkUnmarshal_RepeatedKeys_Interface benchmarks decoding objects
+// with repeated keys into interface{}, whose maps share interned keys.
+func BenchmarkUnmarshal_RepeatedKeys_Interface(b *testing.B) {
+	b.SetBytes(int64(len(recordsJSON)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var v interface{}
+		if err := shapejson.Unmarshal(recordsJSON, &v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkParse_RepeatedKeys benchmarks parsing objects with repeated keys
+// into an AST.
+func BenchmarkParse_RepeatedKeys(b *testing.B) {
+	input := string(recordsJSON)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := shapejson.Parse(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -2,8 +2,11 @@ package json
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -449,11 +452,13 @@ func TestValidate(t *testing.T) {
 		{name: "valid object", input: `{"name": "Alice"}`, expectErr: false},
 		{name: "valid array", input: `[1, 2, 3]`, expectErr: false},
 		{name: "valid primitive", input: `42`, expectErr: false},
+		{name: "valid number beyond float64", input: `[1e400]`, expectErr: false},
 
 		// Invalid JSON - should return error
 		{name: "invalid empty", input: ``, expectErr: true},
 		{name: "invalid syntax", input: `{invalid}`, expectErr: true},
 		{name: "invalid unclosed", input: `{"name": "Alice"`, expectErr: true},
+		{name: "invalid mismatched brackets", input: `{"a": [1}]`, expectErr: true},
 	}
 
 	for _, tt := range tests {
@@ -462,6 +467,9 @@ func TestValidate(t *testing.T) {
 			if (err != nil) != tt.expectErr {
 				t.Errorf("Validate() error = %v, expectErr %v", err, tt.expectErr)
 			}
+			if bytesErr := ValidateBytes([]byte(tt.input)); (bytesErr != nil) != (err != nil) {
+				t.Errorf("ValidateBytes() error = %v, Validate() error = %v", bytesErr, err)
+			}
 			if tt.expectErr && err == nil {
 				t.Error("Validate() expected error but got nil")
 			}
@@ -894,3 +902,155 @@ func TestParseReader_PositionTracking(t *testing.T) {
 			pos.Offset, pos.Line, pos.Column)
 	}
 }
+
+// TestSkipValue tests the public value-skipping scanner
+func TestSkipValue(t *testing.T) {
+	data := []byte(`{"skip": [1, 2, {"a": "]"}], "next": true}`)
+	start := strings.IndexByte(string(data), '[')
+
+	end, err := SkipValue(data, start)
+	if err != nil {
+		t.Fatalf("SkipValue() error = %v", err)
+	}
+	if got := string(data[start:end]); got != `[1, 2, {"a": "]"}]` {
+		t.Errorf("skipped %q", got)
+	}
+
+	if _, err := SkipValue([]byte(`[1, 2`), 0); err == nil {
+		t.Error("expected error for truncated value")
+	}
+}
+
+func TestValidateWithStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Stats
+	}{
+		{"scalar", `42`, Stats{Numbers: 1}},
+		{"empty containers", `[{}, []]`, Stats{Objects: 1, Arrays: 2, MaxDepth: 2, LongestArray: 2}},
+		{
+			"mixed",
+			`{"name": "Ada", "tags": ["a", "bb", "c\n"], "age": 36, "ok": true, "next": null, "nested": {"deep": [[1, 2, 3, 4]]}}`,
+			Stats{Objects: 2, Arrays: 3, Strings: 4, Numbers: 5, Bools: 1, Nulls: 1, MaxDepth: 4, LongestString: 6, LongestArray: 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateWithStats(tt.input)
+			if err != nil {
+				t.Fatalf("ValidateWithStats() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ValidateWithStats() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ValidateWithStats(`{"a": [1, 2}`); err == nil {
+		t.Error("ValidateWithStats() accepted invalid JSON")
+	}
+}
+
+func TestParseWithOptions_Usage(t *testing.T) {
+	input := `{"name": "Alice", "tags": ["a", "b"], "n": 1, "ok": true, "x": null}`
+	var calls []ParseUsage
+	opts := ParseOptions{UsageInterval: 3, OnUsage: func(u ParseUsage) error {
+		calls = append(calls, u)
+		return nil
+	}}
+	node, err := ParseWithOptions(input, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := Parse(input)
+	if !reflect.DeepEqual(NodeToInterface(node), NodeToInterface(want)) {
+		t.Errorf("ParseWithOptions() = %v", NodeToInterface(node))
+	}
+
+	// 8 nodes: three calls at 3 and 6 nodes, and one at the end
+	if len(calls) != 3 || calls[0].Nodes != 3 || calls[1].Nodes != 6 || calls[2].Nodes != 8 {
+		t.Fatalf("OnUsage calls = %+v", calls)
+	}
+	for i := 1; i < len(calls); i++ {
+		if calls[i].Bytes <= calls[i-1].Bytes {
+			t.Errorf("Bytes did not grow: %+v", calls)
+		}
+	}
+
+	// Longer strings cost more
+	short, long := usageOf(t, `["a"]`), usageOf(t, `["`+strings.Repeat("a", 1000)+`"]`)
+	if long.Bytes-short.Bytes != 999 {
+		t.Errorf("Bytes = %d and %d, want a difference of 999", short.Bytes, long.Bytes)
+	}
+
+	// The reader form reports the same totals
+	var fromReader ParseUsage
+	if _, err := ParseReaderWithOptions(strings.NewReader(input), ParseOptions{OnUsage: func(u ParseUsage) error {
+		fromReader = u
+		return nil
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if fromReader != calls[2] {
+		t.Errorf("ParseReaderWithOptions usage = %+v, want %+v", fromReader, calls[2])
+	}
+}
+
+func TestParseWithOptions_Hash(t *testing.T) {
+	input := `{"items": [` + strings.Repeat(`{"id": 1, "name": "pen"}, `, 2000) + `null]}` + "\n"
+	want := sha256.Sum256([]byte(input))
+
+	sum := sha256.New()
+	if _, err := ParseReaderWithOptions(strings.NewReader(input), ParseOptions{Hash: sum}); err != nil {
+		t.Fatal(err)
+	}
+	if got := sum.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Errorf("ParseReaderWithOptions hash = %x, want %x", got, want)
+	}
+
+	sum.Reset()
+	if _, err := ParseWithOptions(input, ParseOptions{Hash: sum}); err != nil {
+		t.Fatal(err)
+	}
+	if got := sum.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Errorf("ParseWithOptions hash = %x, want %x", got, want)
+	}
+}
+
+func TestParseWithOptions_Quota(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+	input := "[" + strings.Repeat(`{"k": "v"},`, 1000) + "1]"
+	var last ParseUsage
+	_, err := ParseWithOptions(input, ParseOptions{UsageInterval: 10, OnUsage: func(u ParseUsage) error {
+		last = u
+		if u.Nodes >= 100 {
+			return errQuota
+		}
+		return nil
+	}})
+	if !errors.Is(err, errQuota) {
+		t.Fatalf("ParseWithOptions() error = %v, want %v", err, errQuota)
+	}
+	if last.Nodes != 100 {
+		t.Errorf("parse continued to %d nodes", last.Nodes)
+	}
+
+	// The final report can reject a document too
+	if _, err := ParseWithOptions(`[1]`, ParseOptions{OnUsage: func(ParseUsage) error { return errQuota }}); !errors.Is(err, errQuota) {
+		t.Errorf("ParseWithOptions() error = %v, want %v", err, errQuota)
+	}
+}
+
+// usageOf returns the usage reported at the end of parsing input.
+func usageOf(t *testing.T, input string) ParseUsage {
+	t.Helper()
+	var usage ParseUsage
+	if _, err := ParseWithOptions(input, ParseOptions{OnUsage: func(u ParseUsage) error {
+		usage = u
+		return nil
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	return usage
+}
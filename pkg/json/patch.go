@@ -0,0 +1,505 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// PatchOperation is a single RFC 6902 JSON Patch operation.
+type PatchOperation struct {
+	Op    string      `json:"op"`              // add, remove, replace, move, copy, or test
+	Path  string      `json:"path"`            // JSON Pointer to the target location
+	From  string      `json:"from,omitempty"`  // source pointer for move and copy
+	Value interface{} `json:"value,omitempty"` // value for add, replace, and test
+}
+
+// Patch is an RFC 6902 JSON Patch: a sequence of operations applied in order.
+type Patch []PatchOperation
+
+// DecodePatch parses a JSON Patch document.
+func DecodePatch(data []byte) (Patch, error) {
+	var raw []map[string]interface{}
+	if err := Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("json: invalid patch: %w", err)
+	}
+
+	patch := make(Patch, len(raw))
+	for i, m := range raw {
+		op := &patch[i]
+		var ok bool
+		if op.Op, ok = m["op"].(string); !ok {
+			return nil, fmt.Errorf("json: invalid patch: operation %d: missing \"op\"", i)
+		}
+		if op.Path, ok = m["path"].(string); !ok {
+			return nil, fmt.Errorf("json: invalid patch: operation %d: missing \"path\"", i)
+		}
+		switch op.Op {
+		case "add", "replace", "test":
+			// "value" may legitimately be null, so check presence
+			if op.Value, ok = m["value"]; !ok {
+				return nil, fmt.Errorf("json: invalid patch: operation %d: missing \"value\"", i)
+			}
+		case "move", "copy":
+			if op.From, ok = m["from"].(string); !ok {
+				return nil, fmt.Errorf("json: invalid patch: operation %d: missing \"from\"", i)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("json: invalid patch: operation %d: unknown op %q", i, op.Op)
+		}
+	}
+	return patch, nil
+}
+
+// MarshalJSON encodes the patch, always including "value" for the
+// operations that require it, even when it is null.
+func (p Patch) MarshalJSON() ([]byte, error) {
+	buf := []byte{'['}
+	for i, op := range p {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"op":`...)
+		buf = appendQuoted(buf, op.Op)
+		buf = append(buf, `,"path":`...)
+		buf = appendQuoted(buf, op.Path)
+		switch op.Op {
+		case "move", "copy":
+			buf = append(buf, `,"from":`...)
+			buf = appendQuoted(buf, op.From)
+		case "add", "replace", "test":
+			value, err := Marshal(op.Value)
+			if err != nil {
+				return nil, err
+			}
+			buf = append(buf, `,"value":`...)
+			buf = append(buf, value...)
+		}
+		buf = append(buf, '}')
+	}
+	return append(buf, ']'), nil
+}
+
+// appendQuoted appends s as a JSON string.
+func appendQuoted(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	buf = appendEscapedString(buf, s)
+	return append(buf, '"')
+}
+
+// Apply applies the patch to a native JSON value (as produced by Unmarshal
+// into interface{}) and returns the result. The patch is atomic: if any
+// operation fails, the error is returned and doc is left unchanged.
+func (p Patch) Apply(doc interface{}) (interface{}, error) {
+	result := deepCopyValue(doc)
+	for i, op := range p {
+		var err error
+		result, err = applyPatchOp(result, op)
+		if err != nil {
+			return nil, fmt.Errorf("json: patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return result, nil
+}
+
+// ApplyPatch applies a JSON Patch document to a JSON document and returns
+// the patched document in compact form.
+//
+// Example:
+//
+//	out, err := json.ApplyPatch(config, []byte(`[
+//	    {"op": "replace", "path": "/server/port", "value": 8443},
+//	    {"op": "remove", "path": "/debug"}
+//	]`))
+func ApplyPatch(doc, patch []byte) ([]byte, error) {
+	p, err := DecodePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+	v, err := fastparser.NewParser(doc).Parse()
+	if err != nil {
+		return nil, err
+	}
+	result, err := p.Apply(v)
+	if err != nil {
+		return nil, err
+	}
+	return Marshal(result)
+}
+
+// Diff returns a JSON Patch that transforms a into b. Both are native JSON
+// values. Objects are compared member by member; arrays are compared index
+// by index, with trailing elements added or removed. Numbers are compared
+// by value, so int64(1) and 1.0 are equal.
+//
+// Applying the result to a yields a value equal to b.
+func Diff(a, b interface{}) Patch {
+	return DiffWithOptions(a, b, CompareOptions{})
+}
+
+// DiffWithOptions is like Diff but compares numbers as opts says; numbers
+// it considers equal produce no operation. With a tolerance, applying the
+// result to a yields a value equal to b within that tolerance:
+//
+//	patch := json.DiffWithOptions(before, after, json.CompareOptions{FloatTolerance: 1e-6})
+func DiffWithOptions(a, b interface{}, opts CompareOptions) Patch {
+	var patch Patch
+	diffValues(&patch, "", a, b, &opts)
+	return patch
+}
+
+// diffValues appends the operations turning a into b at path.
+func diffValues(patch *Patch, path string, a, b interface{}, opts *CompareOptions) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		if bv, ok := b.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(av))
+			for k := range av {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				child := path + "/" + escapePointerToken(k)
+				if bChild, ok := bv[k]; ok {
+					diffValues(patch, child, av[k], bChild, opts)
+				} else {
+					*patch = append(*patch, PatchOperation{Op: "remove", Path: child})
+				}
+			}
+
+			keys = keys[:0]
+			for k := range bv {
+				if _, ok := av[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				*patch = append(*patch, PatchOperation{Op: "add", Path: path + "/" + escapePointerToken(k), Value: deepCopyValue(bv[k])})
+			}
+			return
+		}
+
+	case []interface{}:
+		if bv, ok := b.([]interface{}); ok {
+			common := len(av)
+			if len(bv) < common {
+				common = len(bv)
+			}
+			for i := 0; i < common; i++ {
+				diffValues(patch, path+"/"+strconv.Itoa(i), av[i], bv[i], opts)
+			}
+			// Remove from the end so earlier indexes stay valid
+			for i := len(av) - 1; i >= common; i-- {
+				*patch = append(*patch, PatchOperation{Op: "remove", Path: path + "/" + strconv.Itoa(i)})
+			}
+			for i := common; i < len(bv); i++ {
+				*patch = append(*patch, PatchOperation{Op: "add", Path: path + "/-", Value: deepCopyValue(bv[i])})
+			}
+			return
+		}
+	}
+
+	if !valuesEqual(a, b, opts) {
+		*patch = append(*patch, PatchOperation{Op: "replace", Path: path, Value: deepCopyValue(b)})
+	}
+}
+
+// valuesEqual reports whether two native JSON values are equal, comparing
+// numbers as opts says.
+func valuesEqual(a, b interface{}, opts *CompareOptions) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			w, ok := bv[k]
+			if !ok || !valuesEqual(v, w, opts) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !valuesEqual(av[i], bv[i], opts) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if af, ok := numberValue(a); ok {
+		bf, ok := numberValue(b)
+		if !ok {
+			return false
+		}
+		eq := af == bf
+		if opts.Numbers == NumbersByLexeme {
+			eq = a == b
+		}
+		return eq || opts.FloatTolerance > 0 && math.Abs(af-bf) <= opts.FloatTolerance
+	}
+	return a == b
+}
+
+// numberValue converts any Go numeric type to float64.
+func numberValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// escapePointerToken escapes a member name for use in a JSON Pointer.
+func escapePointerToken(s string) string {
+	if !strings.ContainsAny(s, "~/") {
+		return s
+	}
+	s = strings.ReplaceAll(s, "~", "~0")
+	return strings.ReplaceAll(s, "/", "~1")
+}
+
+// parsePointer splits a JSON Pointer into unescaped reference tokens.
+func parsePointer(ptr string) ([]string, error) {
+	if ptr != "" && ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON Pointer %q", ptr)
+	}
+	patterns, err := compilePointer(ptr)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]string, len(patterns))
+	for i, p := range patterns {
+		tokens[i] = p.name
+	}
+	return tokens, nil
+}
+
+// applyPatchOp applies one operation to doc, which the caller owns.
+func applyPatchOp(doc interface{}, op PatchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return pointerAdd(doc, path, deepCopyValue(op.Value))
+	case "remove":
+		doc, _, err = pointerRemove(doc, path)
+		return doc, err
+	case "replace":
+		if doc, _, err = pointerRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, deepCopyValue(op.Value))
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if op.Op == "move" {
+			if len(path) > len(from) && isPointerPrefix(from, path) {
+				return nil, errors.New("cannot move a value into one of its children")
+			}
+			if doc, value, err = pointerRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			if value, err = pointerGet(doc, from); err != nil {
+				return nil, err
+			}
+			value = deepCopyValue(value)
+		}
+		return pointerAdd(doc, path, value)
+	case "test":
+		value, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !valuesEqual(value, op.Value, &CompareOptions{}) {
+			return nil, errors.New("test failed: values differ")
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// isPointerPrefix reports whether prefix is a leading part of path.
+func isPointerPrefix(prefix, path []string) bool {
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// pointerGet returns the value at path.
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, tok := range path {
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			child, ok := v[tok]
+			if !ok {
+				return nil, fmt.Errorf("member %q not found", tok)
+			}
+			doc = child
+		case []interface{}:
+			i, err := arrayIndex(tok, len(v))
+			if err != nil {
+				return nil, err
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("cannot index scalar with %q", tok)
+		}
+	}
+	return doc, nil
+}
+
+// pointerAdd inserts value at path and returns the updated document.
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		v[last] = value
+		return doc, nil
+	case []interface{}:
+		i := len(v)
+		if last != "-" {
+			if i, err = arrayIndex(last, len(v)+1); err != nil {
+				return nil, err
+			}
+		}
+		v = append(v, nil)
+		copy(v[i+1:], v[i:])
+		v[i] = value
+		return replaceAt(doc, path[:len(path)-1], v)
+	default:
+		return nil, fmt.Errorf("cannot add to scalar at %q", last)
+	}
+}
+
+// pointerRemove deletes the value at path and returns the updated document
+// and the removed value.
+func pointerRemove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, doc, nil
+	}
+
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, nil, err
+	}
+	last := path[len(path)-1]
+
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		old, ok := v[last]
+		if !ok {
+			return nil, nil, fmt.Errorf("member %q not found", last)
+		}
+		delete(v, last)
+		return doc, old, nil
+	case []interface{}:
+		i, err := arrayIndex(last, len(v))
+		if err != nil {
+			return nil, nil, err
+		}
+		old := v[i]
+		v = append(v[:i], v[i+1:]...)
+		doc, err = replaceAt(doc, path[:len(path)-1], v)
+		return doc, old, err
+	default:
+		return nil, nil, fmt.Errorf("cannot remove from scalar at %q", last)
+	}
+}
+
+// replaceAt stores value at path, which must already exist, and returns the
+// updated document. It is used after resizing a slice.
+func replaceAt(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := pointerGet(doc, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch v := parent.(type) {
+	case map[string]interface{}:
+		v[last] = value
+	case []interface{}:
+		i, err := arrayIndex(last, len(v))
+		if err != nil {
+			return nil, err
+		}
+		v[i] = value
+	}
+	return doc, nil
+}
+
+// arrayIndex parses an RFC 6901 array index, which must be below limit.
+func arrayIndex(tok string, limit int) (int, error) {
+	if tok == "" || (len(tok) > 1 && tok[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	if i >= limit {
+		return 0, fmt.Errorf("array index %d out of range", i)
+	}
+	return i, nil
+}
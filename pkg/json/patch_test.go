@@ -0,0 +1,175 @@
+package json
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	doc := `{"a": {"b": 1, "c": [1, 2, 3]}, "d~/e": "x", "n": null}`
+
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{"add member", `[{"op": "add", "path": "/a/z", "value": {"k": true}}]`,
+			`{"a":{"b":1,"c":[1,2,3],"z":{"k":true}},"d~\/e":"x","n":null}`},
+		{"add array insert and append", `[{"op": "add", "path": "/a/c/1", "value": 9}, {"op": "add", "path": "/a/c/-", "value": null}]`,
+			`{"a":{"b":1,"c":[1,9,2,3,null]},"d~\/e":"x","n":null}`},
+		{"remove", `[{"op": "remove", "path": "/a/c/0"}, {"op": "remove", "path": "/d~0~1e"}]`,
+			`{"a":{"b":1,"c":[2,3]},"n":null}`},
+		{"replace", `[{"op": "replace", "path": "/a/b", "value": "two"}, {"op": "replace", "path": "/a/c/2", "value": 0}]`,
+			`{"a":{"b":"two","c":[1,2,0]},"d~\/e":"x","n":null}`},
+		{"move", `[{"op": "move", "from": "/a/c", "path": "/list"}]`,
+			`{"a":{"b":1},"d~\/e":"x","list":[1,2,3],"n":null}`},
+		{"copy", `[{"op": "copy", "from": "/a/b", "path": "/a/c/0"}]`,
+			`{"a":{"b":1,"c":[1,1,2,3]},"d~\/e":"x","n":null}`},
+		{"test", `[{"op": "test", "path": "/a/b", "value": 1.0}, {"op": "test", "path": "/n", "value": null}]`,
+			`{"a":{"b":1,"c":[1,2,3]},"d~\/e":"x","n":null}`},
+		{"replace root", `[{"op": "replace", "path": "", "value": [1]}]`, `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyPatch([]byte(doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("ApplyPatch() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ApplyPatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyPatch_Errors(t *testing.T) {
+	doc := `{"a": {"b": 1}, "arr": [1]}`
+	tests := []struct {
+		name  string
+		patch string
+	}{
+		{"not a patch", `{}`},
+		{"missing op", `[{"path": "/a"}]`},
+		{"unknown op", `[{"op": "merge", "path": "/a"}]`},
+		{"missing value", `[{"op": "add", "path": "/a"}]`},
+		{"missing from", `[{"op": "move", "path": "/a"}]`},
+		{"bad pointer", `[{"op": "remove", "path": "a"}]`},
+		{"missing member", `[{"op": "remove", "path": "/x"}]`},
+		{"missing parent", `[{"op": "add", "path": "/x/y", "value": 1}]`},
+		{"index out of range", `[{"op": "add", "path": "/arr/5", "value": 1}]`},
+		{"leading zero index", `[{"op": "replace", "path": "/arr/01", "value": 1}]`},
+		{"scalar parent", `[{"op": "add", "path": "/a/b/c", "value": 1}]`},
+		{"move into child", `[{"op": "move", "from": "/a", "path": "/a/b/c"}]`},
+		{"failed test", `[{"op": "test", "path": "/a/b", "value": "1"}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ApplyPatch([]byte(doc), []byte(tt.patch)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestPatch_ApplyIsAtomic(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	var doc interface{}
+	if err := Unmarshal([]byte(`{"a": 1}`), &doc); err != nil {
+		t.Fatal(err)
+	}
+	patch, err := DecodePatch([]byte(`[{"op": "add", "path": "/b", "value": 2}, {"op": "remove", "path": "/missing"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := patch.Apply(doc); err == nil || !strings.Contains(err.Error(), "operation 1") {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if _, ok := doc.(map[string]interface{})["b"]; ok {
+		t.Error("failed Apply() modified its input")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", `{"a": [1, {"b": 2}]}`, `{"a": [1.0, {"b": 2}]}`, `[]`},
+		{"members", `{"a": 1, "b~/": 2, "c": {"d": 1}}`, `{"a": 1, "c": {"d": 2, "e": null}, "f": true}`,
+			`[{"op":"remove","path":"\/b~0~1"},{"op":"replace","path":"\/c\/d","value":2},{"op":"add","path":"\/c\/e","value":null},{"op":"add","path":"\/f","value":true}]`},
+		{"array shrink", `[1, 2, 3, 4]`, `[1, 5]`,
+			`[{"op":"replace","path":"\/1","value":5},{"op":"remove","path":"\/3"},{"op":"remove","path":"\/2"}]`},
+		{"array grow", `{"x": []}`, `{"x": [1, [2]]}`,
+			`[{"op":"add","path":"\/x\/-","value":1},{"op":"add","path":"\/x\/-","value":[2]}]`},
+		{"type change", `{"x": {"a": 1}}`, `{"x": [1]}`, `[{"op":"replace","path":"\/x","value":[1]}]`},
+		{"root scalar", `1`, `"1"`, `[{"op":"replace","path":"","value":"1"}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var a, b interface{}
+			if err := Unmarshal([]byte(tt.a), &a); err != nil {
+				t.Fatal(err)
+			}
+			if err := Unmarshal([]byte(tt.b), &b); err != nil {
+				t.Fatal(err)
+			}
+
+			patch := Diff(a, b)
+			got, err := Marshal(patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == `[]` && len(patch) == 0 {
+				got = []byte(`[]`)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Diff() = %s, want %s", got, tt.want)
+			}
+
+			patched, err := patch.Apply(a)
+			if err != nil {
+				t.Fatalf("Apply(Diff()) error = %v", err)
+			}
+			if !valuesEqual(patched, b, &CompareOptions{}) {
+				t.Errorf("Apply(Diff()) = %v, want %v", patched, b)
+			}
+		})
+	}
+}
+
+func TestDiffWithOptions(t *testing.T) {
+	x, y := 0.1, 0.2
+	a := map[string]interface{}{"temp": x + y, "count": int64(1), "id": "s1"}
+	b := map[string]interface{}{"temp": 0.3, "count": 1.0, "id": "s1"}
+
+	tests := []struct {
+		name string
+		opts CompareOptions
+		want string
+	}{
+		{"default", CompareOptions{}, `[{"op":"replace","path":"\/temp","value":0.3}]`},
+		{"tolerance", CompareOptions{FloatTolerance: 1e-9}, `[]`},
+		{"lexeme", CompareOptions{Numbers: NumbersByLexeme},
+			`[{"op":"replace","path":"\/count","value":1},{"op":"replace","path":"\/temp","value":0.3}]`},
+		{"lexeme with tolerance", CompareOptions{Numbers: NumbersByLexeme, FloatTolerance: 1e-9}, `[]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Marshal(DiffWithOptions(a, b, tt.opts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("DiffWithOptions() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Polymorphic decodes JSON objects into one of several concrete types that
+// implement the interface T, chosen by the value of a discriminator member:
+//
+//	type Shape interface{ Area() float64 }
+//
+//	shapes := json.NewPolymorphic[Shape]("type").
+//	    Register("circle", Circle{}).
+//	    Register("rect", &Rect{})
+//
+//	s, err := shapes.Unmarshal([]byte(`{"type":"circle","radius":2}`))
+//	// s is a Circle
+//
+// Each concrete type is decoded from the whole object, so it may declare
+// the discriminator as an ordinary field if it needs it; otherwise the member
+// is ignored like any other unknown key. Registering a pointer prototype
+// stores pointers in the interface.
+//
+// Polymorphic is also a TypeResolver: passing it in UnmarshalOptions.Resolvers
+// makes UnmarshalWithOptions decode every T it encounters the same way,
+// including struct fields, slice elements, and map values.
+//
+// Register the types before first use; a Polymorphic is safe for concurrent
+// decoding once it is no longer modified.
+type Polymorphic[T any] struct {
+	field string
+	types map[string]reflect.Type
+}
+
+// NewPolymorphic returns a Polymorphic for the interface type T that reads
+// the discriminator from the given object member. It panics if T is not an
+// interface type.
+func NewPolymorphic[T any](field string) *Polymorphic[T] {
+	if t := reflect.TypeOf((*T)(nil)).Elem(); t.Kind() != reflect.Interface {
+		panic("json: NewPolymorphic: " + t.String() + " is not an interface type")
+	}
+	return &Polymorphic[T]{field: field, types: make(map[string]reflect.Type)}
+}
+
+// Register maps a discriminator value to the dynamic type of prototype and
+// returns p for chaining. It panics if prototype is nil or the value is
+// already registered.
+func (p *Polymorphic[T]) Register(value string, prototype T) *Polymorphic[T] {
+	t := reflect.TypeOf(prototype)
+	if t == nil {
+		panic(fmt.Sprintf("json: Polymorphic.Register(%q): nil prototype", value))
+	}
+	if _, dup := p.types[value]; dup {
+		panic(fmt.Sprintf("json: Polymorphic.Register(%q): value already registered", value))
+	}
+	p.types[value] = t
+	return p
+}
+
+// Field returns the name of the discriminator member.
+func (p *Polymorphic[T]) Field() string {
+	return p.field
+}
+
+// Unmarshal decodes data into the concrete type selected by its
+// discriminator and returns it as a T. Nested values of type T are resolved
+// the same way. A JSON null yields the zero T.
+func (p *Polymorphic[T]) Unmarshal(data []byte) (T, error) {
+	var v T
+	if err := UnmarshalWithOptions(data, &v, UnmarshalOptions{Resolvers: []TypeResolver{p}}); err != nil {
+		var zero T
+		return zero, err
+	}
+	return v, nil
+}
+
+// InterfaceType returns T. It implements TypeResolver.
+func (p *Polymorphic[T]) InterfaceType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// ResolveType returns the type registered for the discriminator of the JSON
+// object in data. It implements TypeResolver.
+func (p *Polymorphic[T]) ResolveType(data []byte) (reflect.Type, error) {
+	iface := p.InterfaceType()
+	pos := skipSpace(data, 0)
+	if pos >= len(data) || data[pos] != '{' {
+		kind := "empty input"
+		if pos < len(data) {
+			kind = jsonTypeAt(data[pos])
+		}
+		return nil, fmt.Errorf("json: cannot unmarshal %s into Go value of type %s", kind, iface)
+	}
+
+	raw, found, err := findMember(data, pos, p.field)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("json: missing discriminator field %q for %s", p.field, iface)
+	}
+	if raw[0] != '"' {
+		return nil, fmt.Errorf("json: discriminator field %q for %s must be a string, got %s", p.field, iface, jsonTypeAt(raw[0]))
+	}
+	value, err := decodeKey(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := p.types[value]
+	if !ok {
+		return nil, fmt.Errorf("json: unknown %q value %q for %s", p.field, value, iface)
+	}
+	return t, nil
+}
+
+// findMember returns the raw value of the first member named key in the
+// object starting at pos, without decoding the other members.
+func findMember(data []byte, pos int, key string) ([]byte, bool, error) {
+	pos = skipSpace(data, pos+1)
+	if pos < len(data) && data[pos] == '}' {
+		return nil, false, nil
+	}
+
+	for {
+		if pos >= len(data) || data[pos] != '"' {
+			return nil, false, errors.New("expected string key in object")
+		}
+		keyEnd, err := fastparser.SkipValue(data, pos)
+		if err != nil {
+			return nil, false, err
+		}
+		name, err := decodeKey(data[pos:keyEnd])
+		if err != nil {
+			return nil, false, err
+		}
+
+		pos = skipSpace(data, keyEnd)
+		if pos >= len(data) || data[pos] != ':' {
+			return nil, false, errors.New("expected ':' after object key")
+		}
+		pos = skipSpace(data, pos+1)
+
+		end, err := fastparser.SkipValue(data, pos)
+		if err != nil {
+			return nil, false, err
+		}
+		if name == key {
+			return data[pos:end], true, nil
+		}
+
+		pos = skipSpace(data, end)
+		if pos >= len(data) {
+			return nil, false, errors.New("unexpected end of JSON input in object")
+		}
+		switch data[pos] {
+		case '}':
+			return nil, false, nil
+		case ',':
+			pos = skipSpace(data, pos+1)
+		default:
+			return nil, false, fmt.Errorf("expected ',' or '}' in object at position %d", pos)
+		}
+	}
+}
+
+// decodeResolvers converts TypeResolvers to the form used by the fast parser.
+func decodeResolvers(resolvers []TypeResolver) map[reflect.Type]func([]byte) (reflect.Type, error) {
+	if len(resolvers) == 0 {
+		return nil
+	}
+	m := make(map[reflect.Type]func([]byte) (reflect.Type, error), len(resolvers))
+	for _, r := range resolvers {
+		m[r.InterfaceType()] = r.ResolveType
+	}
+	return m
+}
@@ -0,0 +1,166 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type polyShape interface {
+	Area() float64
+}
+
+type polyCircle struct {
+	Radius float64 `json:"radius"`
+}
+
+func (c polyCircle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+type polyRect struct {
+	Kind string  `json:"type"`
+	W    float64 `json:"w"`
+	H    float64 `json:"h"`
+}
+
+func (r *polyRect) Area() float64 { return r.W * r.H }
+
+func newShapes() *Polymorphic[polyShape] {
+	return NewPolymorphic[polyShape]("type").
+		Register("circle", polyCircle{}).
+		Register("rect", &polyRect{})
+}
+
+func TestPolymorphic_Unmarshal(t *testing.T) {
+	shapes := newShapes()
+
+	tests := []struct {
+		name  string
+		input string
+		want  polyShape
+	}{
+		{name: "value type", input: `{"type":"circle","radius":2}`, want: polyCircle{Radius: 2}},
+		{name: "pointer type keeps discriminator field", input: `{"w":2,"h":3,"type":"rect"}`, want: &polyRect{Kind: "rect", W: 2, H: 3}},
+		{name: "escaped discriminator", input: `{"type":"circ\u006ce","radius":1}`, want: polyCircle{Radius: 1}},
+		{name: "null", input: `null`, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := shapes.Unmarshal([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolymorphic_Errors(t *testing.T) {
+	shapes := newShapes()
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "missing discriminator", input: `{"radius":1}`, wantErr: `json: missing discriminator field "type" for json.polyShape`},
+		{name: "unknown value", input: `{"type":"hexagon"}`, wantErr: `json: unknown "type" value "hexagon" for json.polyShape`},
+		{name: "non-string discriminator", input: `{"type":1}`, wantErr: `json: discriminator field "type" for json.polyShape must be a string, got number`},
+		{name: "not an object", input: `[1]`, wantErr: `json: cannot unmarshal array into Go value of type json.polyShape`},
+		{name: "type mismatch in body", input: `{"type":"circle","radius":"big"}`, wantErr: "cannot unmarshal string"},
+		{name: "invalid JSON", input: `{"type":"circle",}`, wantErr: "expected string key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := shapes.Unmarshal([]byte(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Unmarshal() error = %v, want %q", err, tt.wantErr)
+			}
+			if got != nil {
+				t.Errorf("Unmarshal() = %#v on error, want nil", got)
+			}
+		})
+	}
+}
+
+func TestPolymorphic_Resolver(t *testing.T) {
+	type drawing struct {
+		Title  string               `json:"title"`
+		Main   polyShape            `json:"main"`
+		Shapes []polyShape          `json:"shapes"`
+		Named  map[string]polyShape `json:"named"`
+	}
+
+	input := `{
+		"title": "d",
+		"main": {"type": "rect", "w": 1, "h": 1},
+		"shapes": [{"type": "circle", "radius": 1}, null, {"type": "rect", "w": 2, "h": 2}],
+		"named": {"sun": {"type": "circle", "radius": 5}}
+	}`
+
+	var d drawing
+	err := UnmarshalWithOptions([]byte(input), &d, UnmarshalOptions{Resolvers: []TypeResolver{newShapes()}})
+	if err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+
+	want := drawing{
+		Title:  "d",
+		Main:   &polyRect{Kind: "rect", W: 1, H: 1},
+		Shapes: []polyShape{polyCircle{Radius: 1}, nil, &polyRect{Kind: "rect", W: 2, H: 2}},
+		Named:  map[string]polyShape{"sun": polyCircle{Radius: 5}},
+	}
+	if !reflect.DeepEqual(d, want) {
+		t.Errorf("got %#v\nwant %#v", d, want)
+	}
+
+	// Without the resolver the interface fields cannot be decoded.
+	if err := Unmarshal([]byte(input), &d); err == nil {
+		t.Error("Unmarshal() without resolver succeeded, want error")
+	}
+}
+
+type polyBadResolver struct{}
+
+func (polyBadResolver) InterfaceType() reflect.Type {
+	return reflect.TypeOf((*polyShape)(nil)).Elem()
+}
+
+func (polyBadResolver) ResolveType([]byte) (reflect.Type, error) {
+	return reflect.TypeOf(""), nil
+}
+
+func TestPolymorphic_ResolverWrongType(t *testing.T) {
+	var s polyShape
+	err := UnmarshalWithOptions([]byte(`{}`), &s, UnmarshalOptions{Resolvers: []TypeResolver{polyBadResolver{}}})
+	if err == nil || err.Error() != "json: resolved type string does not implement json.polyShape" {
+		t.Errorf("error = %v", err)
+	}
+}
+
+func TestPolymorphic_Panics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{name: "non-interface", fn: func() { NewPolymorphic[polyCircle]("type") }},
+		{name: "nil prototype", fn: func() { NewPolymorphic[polyShape]("type").Register("x", nil) }},
+		{name: "duplicate", fn: func() { newShapes().Register("circle", polyCircle{}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
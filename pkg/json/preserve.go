@@ -0,0 +1,311 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Preserve decodes a document into a value of type T and keeps the
+// original text alongside it, so that encoding writes back the document
+// with only the changes made to Value. Members that T does not model, the
+// order of members, whitespace, and the spelling of unchanged values, such
+// as 1.50 or "é", survive the round trip:
+//
+//	var cfg json.Preserve[Config]
+//	if err := json.Unmarshal(data, &cfg); err != nil {
+//	    return err
+//	}
+//	cfg.Value.Port = 8081
+//	data, err = json.Marshal(cfg) // the original text, with the new port
+//
+// Encoding compares Value with the value as it was decoded, both encoded
+// by Marshal. A member whose encoding changed takes its new value: objects
+// on both sides are merged member by member, and arrays of unchanged
+// length element by element, so that members nested in them are kept too;
+// other values are replaced. A member that Value no longer writes, such as
+// an omitempty field that became empty, is removed, and a new one is added
+// at the end of its object.
+//
+// Value is decoded with Unmarshal and encoded with Marshal, whatever the
+// options of the enclosing call. A Preserve that was not decoded encodes
+// as Value does.
+type Preserve[T any] struct {
+	Value T
+
+	raw  []byte // the decoded document
+	base []byte // the encoding of Value as decoded
+}
+
+// Raw returns the document p was decoded from, or nil if it was not
+// decoded.
+func (p Preserve[T]) Raw() RawMessage {
+	return p.raw
+}
+
+// MarshalJSON encodes p as its original document with the changes made to
+// Value merged in.
+func (p Preserve[T]) MarshalJSON() ([]byte, error) {
+	cur, err := marshal(defaultEncodeState, p.Value)
+	if err != nil || p.raw == nil {
+		return cur, err
+	}
+	if bytes.Equal(cur, p.base) {
+		return p.raw, nil
+	}
+
+	start := skipSpace(p.raw, 0)
+	end := len(bytes.TrimRight(p.raw, " \t\n\r"))
+	buf := append([]byte(nil), p.raw[:start]...)
+	buf, err = mergeRaw(buf, p.raw[start:end], p.base, cur)
+	if err != nil {
+		return nil, err
+	}
+	return append(buf, p.raw[end:]...), nil
+}
+
+// UnmarshalJSON decodes data into Value and keeps a copy of it.
+func (p *Preserve[T]) UnmarshalJSON(data []byte) error {
+	var v T
+	if err := fastparser.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	base, err := marshal(defaultEncodeState, v)
+	if err != nil {
+		return err
+	}
+	p.Value, p.raw, p.base = v, append([]byte(nil), data...), base
+	return nil
+}
+
+// mergeRaw appends raw, the original text of a value, with the changes from
+// base to cur applied. base and cur are the encodings of the value before
+// and after the changes; raw decodes to the same data as base, give or
+// take members base does not model.
+func mergeRaw(buf, raw, base, cur []byte) ([]byte, error) {
+	if bytes.Equal(base, cur) {
+		return append(buf, raw...), nil
+	}
+	if len(raw) > 0 && len(base) > 0 && len(cur) > 0 && raw[0] == base[0] && base[0] == cur[0] {
+		switch raw[0] {
+		case '{':
+			return mergeRawObject(buf, raw, base, cur)
+		case '[':
+			return mergeRawArray(buf, raw, base, cur)
+		}
+	}
+	return append(buf, cur...), nil
+}
+
+// mergeRawObject is mergeRaw for objects. Members of raw keep their text,
+// and the separators around them, unless cur changes them.
+func mergeRawObject(buf, raw, base, cur []byte) ([]byte, error) {
+	rm, err := rawMembers(raw)
+	if err != nil {
+		return nil, err
+	}
+	bm, err := rawMembers(base)
+	if err != nil {
+		return nil, err
+	}
+	cm, err := rawMembers(cur)
+	if err != nil {
+		return nil, err
+	}
+	baseValues := make(map[string][]byte, len(bm))
+	for _, m := range bm {
+		baseValues[m.key] = base[m.valueStart:m.end]
+	}
+	curValues := make(map[string][]byte, len(cm))
+	for _, m := range cm {
+		curValues[m.key] = cur[m.valueStart:m.end]
+	}
+
+	// New members are written in the style of the first ones
+	sep, colon := []byte(","), []byte(":")
+	if len(rm) > 1 {
+		sep = raw[rm[0].end:rm[1].start]
+	}
+	if len(rm) > 0 {
+		colon = raw[rm[0].keyEnd:rm[0].valueStart]
+	}
+
+	start := len(buf)
+	tail := raw[1:]
+	if len(rm) > 0 {
+		buf = append(buf, raw[:rm[0].start]...)
+		tail = raw[rm[len(rm)-1].end:]
+	} else {
+		buf = append(buf, '{')
+	}
+
+	first := true
+	inRaw := make(map[string]bool, len(rm))
+	for i, m := range rm {
+		inRaw[m.key] = true
+		c, inCur := curValues[m.key]
+		b, inBase := baseValues[m.key]
+		if inBase && !inCur {
+			continue // no longer written
+		}
+		if !first {
+			buf = append(buf, raw[rm[i-1].end:m.start]...)
+		}
+		first = false
+
+		buf = append(buf, raw[m.start:m.valueStart]...)
+		value := raw[m.valueStart:m.end]
+		switch {
+		case inCur && inBase:
+			if buf, err = mergeRaw(buf, value, b, c); err != nil {
+				return nil, err
+			}
+		case inCur:
+			buf = append(buf, c...)
+		default:
+			buf = append(buf, value...) // not modeled
+		}
+	}
+
+	for _, m := range cm {
+		if inRaw[m.key] {
+			continue
+		}
+		if !first {
+			buf = append(buf, sep...)
+		}
+		first = false
+		buf = append(buf, cur[m.start:m.keyEnd]...)
+		buf = append(buf, colon...)
+		buf = append(buf, cur[m.valueStart:m.end]...)
+	}
+
+	if first {
+		// Every member was removed
+		return append(buf[:start], "{}"...), nil
+	}
+	return append(buf, tail...), nil
+}
+
+// mergeRawArray is mergeRaw for arrays. Arrays whose length changed are
+// replaced, since their elements cannot be matched up.
+func mergeRawArray(buf, raw, base, cur []byte) ([]byte, error) {
+	re, err := rawElements(raw)
+	if err != nil {
+		return nil, err
+	}
+	be, err := rawElements(base)
+	if err != nil {
+		return nil, err
+	}
+	ce, err := rawElements(cur)
+	if err != nil {
+		return nil, err
+	}
+	if len(re) == 0 || len(re) != len(be) || len(be) != len(ce) {
+		return append(buf, cur...), nil
+	}
+
+	buf = append(buf, raw[:re[0].start]...)
+	for i, e := range re {
+		if i > 0 {
+			buf = append(buf, raw[re[i-1].end:e.start]...)
+		}
+		b, c := be[i], ce[i]
+		if buf, err = mergeRaw(buf, raw[e.start:e.end], base[b.start:b.end], cur[c.start:c.end]); err != nil {
+			return nil, err
+		}
+	}
+	return append(buf, raw[re[len(re)-1].end:]...), nil
+}
+
+// rawMember locates a member in the text of an object.
+type rawMember struct {
+	key        string
+	start      int // offset of the key's opening quote
+	keyEnd     int // offset just past the key's closing quote
+	valueStart int
+	end        int // offset just past the value
+}
+
+// rawSpan locates an element in the text of an array.
+type rawSpan struct {
+	start, end int
+}
+
+// rawMembers lists the members of the object whose text is data.
+func rawMembers(data []byte) ([]rawMember, error) {
+	var members []rawMember
+	pos := skipSpace(data, 1)
+	if pos < len(data) && data[pos] == '}' {
+		return nil, nil
+	}
+	for {
+		if pos >= len(data) || data[pos] != '"' {
+			return nil, errors.New("expected string key in object")
+		}
+		keyEnd, err := fastparser.SkipValue(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		key, err := decodeKey(data[pos:keyEnd])
+		if err != nil {
+			return nil, err
+		}
+		m := rawMember{key: key, start: pos, keyEnd: keyEnd}
+
+		pos = skipSpace(data, keyEnd)
+		if pos >= len(data) || data[pos] != ':' {
+			return nil, errors.New("expected ':' after object key")
+		}
+		m.valueStart = skipSpace(data, pos+1)
+		if m.end, err = fastparser.SkipValue(data, m.valueStart); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+
+		pos = skipSpace(data, m.end)
+		if pos >= len(data) {
+			return nil, errors.New("unexpected end of JSON input in object")
+		}
+		switch data[pos] {
+		case '}':
+			return members, nil
+		case ',':
+			pos = skipSpace(data, pos+1)
+		default:
+			return nil, fmt.Errorf("expected ',' or '}' in object at position %d", pos)
+		}
+	}
+}
+
+// rawElements lists the elements of the array whose text is data.
+func rawElements(data []byte) ([]rawSpan, error) {
+	var elems []rawSpan
+	pos := skipSpace(data, 1)
+	if pos < len(data) && data[pos] == ']' {
+		return nil, nil
+	}
+	for {
+		end, err := fastparser.SkipValue(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		elems = append(elems, rawSpan{pos, end})
+
+		pos = skipSpace(data, end)
+		if pos >= len(data) {
+			return nil, errors.New("unexpected end of JSON input in array")
+		}
+		switch data[pos] {
+		case ']':
+			return elems, nil
+		case ',':
+			pos = skipSpace(data, pos+1)
+		default:
+			return nil, fmt.Errorf("expected ',' or ']' in array at position %d", pos)
+		}
+	}
+}
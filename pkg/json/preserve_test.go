@@ -0,0 +1,197 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"testing"
+)
+
+type preservedConfig struct {
+	Name    string            `json:"name"`
+	Port    int               `json:"port"`
+	Debug   bool              `json:"debug,omitempty"`
+	Tags    []string          `json:"tags"`
+	Servers []preservedServer `json:"servers"`
+}
+
+type preservedServer struct {
+	Host string `json:"host"`
+}
+
+const preservedInput = `{
+  "name": "api",
+  "comment": "not modeled",
+  "port": 8080,
+  "debug": true,
+  "servers": [
+    {"host": "a", "weight": 1.50},
+    {"host": "b", "weight": 2}
+  ],
+  "tags": ["x", "y"]
+}
+`
+
+func TestPreserve_Unchanged(t *testing.T) {
+	var p Preserve[preservedConfig]
+	if err := Unmarshal([]byte(preservedInput), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Value.Name != "api" || p.Value.Port != 8080 || len(p.Value.Servers) != 2 {
+		t.Fatalf("decoded %+v", p.Value)
+	}
+	data, err := Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != preservedInput {
+		t.Errorf("Marshal =\n%s\nwant\n%s", data, preservedInput)
+	}
+	if string(p.Raw()) != preservedInput {
+		t.Errorf("Raw() = %s", p.Raw())
+	}
+}
+
+func TestPreserve_Changes(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *preservedConfig)
+		want   string
+	}{
+		{
+			name:   "scalar",
+			change: func(c *preservedConfig) { c.Port = 9090 },
+			want: `{
+  "name": "api",
+  "comment": "not modeled",
+  "port": 9090,
+  "debug": true,
+  "servers": [
+    {"host": "a", "weight": 1.50},
+    {"host": "b", "weight": 2}
+  ],
+  "tags": ["x", "y"]
+}
+`,
+		},
+		{
+			name:   "nested in array",
+			change: func(c *preservedConfig) { c.Servers[1].Host = "c" },
+			want: `{
+  "name": "api",
+  "comment": "not modeled",
+  "port": 8080,
+  "debug": true,
+  "servers": [
+    {"host": "a", "weight": 1.50},
+    {"host": "c", "weight": 2}
+  ],
+  "tags": ["x", "y"]
+}
+`,
+		},
+		{
+			name:   "array length",
+			change: func(c *preservedConfig) { c.Tags = append(c.Tags, "z") },
+			want: `{
+  "name": "api",
+  "comment": "not modeled",
+  "port": 8080,
+  "debug": true,
+  "servers": [
+    {"host": "a", "weight": 1.50},
+    {"host": "b", "weight": 2}
+  ],
+  "tags": ["x","y","z"]
+}
+`,
+		},
+		{
+			name:   "omitted",
+			change: func(c *preservedConfig) { c.Debug = false },
+			want: `{
+  "name": "api",
+  "comment": "not modeled",
+  "port": 8080,
+  "servers": [
+    {"host": "a", "weight": 1.50},
+    {"host": "b", "weight": 2}
+  ],
+  "tags": ["x", "y"]
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p Preserve[preservedConfig]
+			if err := Unmarshal([]byte(preservedInput), &p); err != nil {
+				t.Fatal(err)
+			}
+			tt.change(&p.Value)
+			data, err := Marshal(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Marshal =\n%s\nwant\n%s", data, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreserve_AddedMember(t *testing.T) {
+	var p Preserve[preservedConfig]
+	if err := Unmarshal([]byte(`{"name": "api", "port": 1, "servers": [], "tags": null}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	p.Value.Debug = true
+	data, err := Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name": "api", "port": 1, "servers": [], "tags": null, "debug": true}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	// Members the value writes but the document lacked are added too
+	if err := Unmarshal([]byte(`{}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	p.Value.Name = "new"
+	data, _ = Marshal(p)
+	if want := `{"name":"new","port":0,"tags":null,"servers":null}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+}
+
+func TestPreserve_Field(t *testing.T) {
+	type envelope struct {
+		Kind   string                    `json:"kind"`
+		Config Preserve[preservedServer] `json:"config"`
+	}
+	var e envelope
+	if err := Unmarshal([]byte(`{"kind":"k","config":{"host":"a","tls":{"on":true}}}`), &e); err != nil {
+		t.Fatal(err)
+	}
+	e.Config.Value.Host = "b"
+	data, err := Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"kind":"k","config":{"host":"b","tls":{"on":true}}}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	// Not decoded: encodes as the value
+	data, _ = Marshal(Preserve[preservedServer]{Value: preservedServer{Host: "h"}})
+	if string(data) != `{"host":"h"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+}
+
+func TestPreserve_Error(t *testing.T) {
+	var p Preserve[preservedConfig]
+	if err := Unmarshal([]byte(`{"port":"x"}`), &p); err == nil {
+		t.Error("expected an error for a mismatched type")
+	}
+}
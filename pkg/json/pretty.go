@@ -0,0 +1,290 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// ColorMode selects whether PrettyPrint emits ANSI color codes.
+type ColorMode uint8
+
+const (
+	// ColorAuto colors the output only when it goes to a terminal and the
+	// NO_COLOR environment variable is not set.
+	ColorAuto ColorMode = iota
+	// ColorAlways always colors the output.
+	ColorAlways
+	// ColorNever never colors the output.
+	ColorNever
+)
+
+// Theme holds the ANSI SGR escape sequences PrettyPrint uses for each kind
+// of token. An empty field leaves that kind of token uncolored.
+type Theme struct {
+	Key         string
+	String      string
+	Number      string
+	Bool        string
+	Null        string
+	Punctuation string
+}
+
+// DefaultTheme is the theme used when StyleOptions.Theme is nil. It follows
+// the colors used by jq.
+var DefaultTheme = Theme{
+	Key:         "\x1b[34;1m", // bold blue
+	String:      "\x1b[32m",   // green
+	Number:      "\x1b[36m",   // cyan
+	Bool:        "\x1b[33m",   // yellow
+	Null:        "\x1b[90m",   // bright black
+	Punctuation: "",
+}
+
+// colorReset ends a colored token.
+const colorReset = "\x1b[0m"
+
+// StyleOptions configures PrettyPrint.
+type StyleOptions struct {
+	// Indent is the indentation for each nesting level. Empty means two
+	// spaces.
+	Indent string
+
+	// Prefix begins every output line after the first.
+	Prefix string
+
+	// Compact removes all insignificant whitespace instead of indenting.
+	Compact bool
+
+	// Color selects whether ANSI colors are used. The default, ColorAuto,
+	// colors only when writing to a terminal.
+	Color ColorMode
+
+	// Theme overrides DefaultTheme.
+	Theme *Theme
+}
+
+// PrettyPrint writes data to w formatted for humans: indented, optionally
+// syntax-colored, and followed by a newline.
+//
+// Unlike Indent, which renders through the AST, PrettyPrint works on the
+// raw bytes: object keys keep their order and strings and numbers keep
+// their original spelling. The whole document is validated before anything
+// is written, so invalid input produces an error and no output.
+//
+// Example:
+//
+//	// Colors on a terminal, plain text when redirected to a file
+//	err := json.PrettyPrint(os.Stdout, body, json.StyleOptions{})
+func PrettyPrint(w io.Writer, data []byte, opts StyleOptions) error {
+	p := &prettyPrinter{
+		data:   data,
+		indent: opts.Indent,
+		prefix: opts.Prefix,
+		out:    make([]byte, 0, len(data)+len(data)/2),
+	}
+	if p.indent == "" {
+		p.indent = "  "
+	}
+	if opts.Compact {
+		p.indent, p.prefix = "", ""
+	}
+	if useColor(w, opts.Color) {
+		p.theme = DefaultTheme
+		if opts.Theme != nil {
+			p.theme = *opts.Theme
+		}
+	}
+
+	pos, err := p.value(skipSpace(data, 0), 0)
+	if err != nil {
+		return err
+	}
+	if pos = skipSpace(data, pos); pos < len(data) {
+		return fmt.Errorf("unexpected data after JSON value at position %d", pos)
+	}
+	p.out = append(p.out, '\n')
+
+	_, err = w.Write(p.out)
+	return err
+}
+
+// useColor decides whether output to w is colored.
+func useColor(w io.Writer, mode ColorMode) bool {
+	switch mode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isTerminal(w)
+}
+
+// isTerminal reports whether w is a character device such as a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// prettyPrinter formats one document into out.
+type prettyPrinter struct {
+	data   []byte
+	indent string
+	prefix string
+	theme  Theme
+	out    []byte
+}
+
+// token appends raw text wrapped in color.
+func (p *prettyPrinter) token(color string, raw []byte) {
+	if color == "" {
+		p.out = append(p.out, raw...)
+		return
+	}
+	p.out = append(p.out, color...)
+	p.out = append(p.out, raw...)
+	p.out = append(p.out, colorReset...)
+}
+
+// punct appends a single structural character.
+func (p *prettyPrinter) punct(c byte) {
+	p.token(p.theme.Punctuation, []byte{c})
+}
+
+// newline starts a new line at the given depth, unless compact.
+func (p *prettyPrinter) newline(depth int) {
+	if p.indent == "" {
+		return
+	}
+	p.out = append(p.out, '\n')
+	p.out = append(p.out, p.prefix...)
+	p.out = append(p.out, strings.Repeat(p.indent, depth)...)
+}
+
+// value formats the value at pos and returns the offset after it.
+func (p *prettyPrinter) value(pos, depth int) (int, error) {
+	if pos >= len(p.data) {
+		return pos, errors.New("unexpected end of JSON input")
+	}
+
+	var color string
+	switch c := p.data[pos]; {
+	case (c == '{' || c == '[') && depth >= fastparser.MaxDepth:
+		return pos, fastparser.DepthError(pos)
+	case c == '{':
+		return p.object(pos, depth)
+	case c == '[':
+		return p.array(pos, depth)
+	case c == '"':
+		color = p.theme.String
+	case c == 't' || c == 'f':
+		color = p.theme.Bool
+	case c == 'n':
+		color = p.theme.Null
+	default:
+		color = p.theme.Number
+	}
+
+	end, err := fastparser.SkipValue(p.data, pos)
+	if err != nil {
+		return end, err
+	}
+	p.token(color, p.data[pos:end])
+	return end, nil
+}
+
+// object formats the object starting at pos.
+func (p *prettyPrinter) object(pos, depth int) (int, error) {
+	p.punct('{')
+	pos = skipSpace(p.data, pos+1)
+	if pos < len(p.data) && p.data[pos] == '}' {
+		p.punct('}')
+		return pos + 1, nil
+	}
+
+	for {
+		if pos >= len(p.data) || p.data[pos] != '"' {
+			return pos, errors.New("expected string key in object")
+		}
+		keyEnd, err := fastparser.SkipValue(p.data, pos)
+		if err != nil {
+			return keyEnd, err
+		}
+		p.newline(depth + 1)
+		p.token(p.theme.Key, p.data[pos:keyEnd])
+
+		pos = skipSpace(p.data, keyEnd)
+		if pos >= len(p.data) || p.data[pos] != ':' {
+			return pos, errors.New("expected ':' after object key")
+		}
+		p.punct(':')
+		if p.indent != "" {
+			p.out = append(p.out, ' ')
+		}
+
+		if pos, err = p.value(skipSpace(p.data, pos+1), depth+1); err != nil {
+			return pos, err
+		}
+
+		pos = skipSpace(p.data, pos)
+		if pos >= len(p.data) {
+			return pos, errors.New("unexpected end of JSON input in object")
+		}
+		switch p.data[pos] {
+		case '}':
+			p.newline(depth)
+			p.punct('}')
+			return pos + 1, nil
+		case ',':
+			p.punct(',')
+			pos = skipSpace(p.data, pos+1)
+		default:
+			return pos, fmt.Errorf("expected ',' or '}' in object at position %d", pos)
+		}
+	}
+}
+
+// array formats the array starting at pos.
+func (p *prettyPrinter) array(pos, depth int) (int, error) {
+	p.punct('[')
+	pos = skipSpace(p.data, pos+1)
+	if pos < len(p.data) && p.data[pos] == ']' {
+		p.punct(']')
+		return pos + 1, nil
+	}
+
+	for {
+		p.newline(depth + 1)
+		var err error
+		if pos, err = p.value(pos, depth+1); err != nil {
+			return pos, err
+		}
+
+		pos = skipSpace(p.data, pos)
+		if pos >= len(p.data) {
+			return pos, errors.New("unexpected end of JSON input in array")
+		}
+		switch p.data[pos] {
+		case ']':
+			p.newline(depth)
+			p.punct(']')
+			return pos + 1, nil
+		case ',':
+			p.punct(',')
+			pos = skipSpace(p.data, pos+1)
+		default:
+			return pos, fmt.Errorf("expected ',' or ']' in array at position %d", pos)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package json
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPrettyPrint(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  StyleOptions
+		want  string
+	}{
+		{
+			name:  "default indent",
+			input: `{"b":1,"a":[true,null,"x"]}`,
+			want:  "{\n  \"b\": 1,\n  \"a\": [\n    true,\n    null,\n    \"x\"\n  ]\n}\n",
+		},
+		{
+			name:  "custom indent and prefix",
+			input: `{"a":[1]}`,
+			opts:  StyleOptions{Indent: "\t", Prefix: "> "},
+			want:  "{\n> \t\"a\": [\n> \t\t1\n> \t]\n> }\n",
+		},
+		{
+			name:  "compact",
+			input: " {\n \"a\" : [ 1 , 2 ] ,\n \"b\" : { } }\n",
+			opts:  StyleOptions{Compact: true},
+			want:  `{"a":[1,2],"b":{}}` + "\n",
+		},
+		{
+			name:  "literals preserved",
+			input: `["café", "a\/b", 1.50, -0, 1E+3]`,
+			opts:  StyleOptions{Compact: true},
+			want:  `["café","a\/b",1.50,-0,1E+3]` + "\n",
+		},
+		{
+			name:  "empty containers",
+			input: `{"a":{},"b":[]}`,
+			want:  "{\n  \"a\": {},\n  \"b\": []\n}\n",
+		},
+		{
+			name:  "scalar",
+			input: ` "x" `,
+			want:  "\"x\"\n",
+		},
+		{
+			name:  "color",
+			input: `{"k":["s",1,false,null]}`,
+			opts:  StyleOptions{Compact: true, Color: ColorAlways},
+			want: "{\x1b[34;1m\"k\"\x1b[0m:[\x1b[32m\"s\"\x1b[0m,\x1b[36m1\x1b[0m," +
+				"\x1b[33mfalse\x1b[0m,\x1b[90mnull\x1b[0m]}\n",
+		},
+		{
+			name:  "custom theme",
+			input: `[1]`,
+			opts:  StyleOptions{Compact: true, Color: ColorAlways, Theme: &Theme{Punctuation: "<p>"}},
+			want:  "<p>[\x1b[0m1<p>]\x1b[0m\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := PrettyPrint(&buf, []byte(tt.input), tt.opts); err != nil {
+				t.Fatalf("PrettyPrint() error = %v", err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("PrettyPrint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrettyPrint_InvalidWritesNothing(t *testing.T) {
+	inputs := []string{
+		``,
+		`{"a":1,}`,
+		`{"a" 1}`,
+		`[1 2]`,
+		`{1:2}`,
+		`[tru]`,
+		`{"a":[1,2]`,
+		`[1] x`,
+	}
+	for _, input := range inputs {
+		var buf bytes.Buffer
+		if err := PrettyPrint(&buf, []byte(input), StyleOptions{}); err == nil {
+			t.Errorf("PrettyPrint(%q) expected error", input)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("PrettyPrint(%q) wrote %q", input, buf.String())
+		}
+	}
+}
+
+func TestPrettyPrint_ColorAuto(t *testing.T) {
+	// A buffer is not a terminal
+	var buf bytes.Buffer
+	if err := PrettyPrint(&buf, []byte(`[1]`), StyleOptions{Compact: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "\x1b") {
+		t.Errorf("ColorAuto colored non-terminal output: %q", buf.String())
+	}
+
+	// Neither is a regular file
+	f, err := os.CreateTemp(t.TempDir(), "pretty")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Error("isTerminal(regular file) = true")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if useColor(os.Stdout, ColorAuto) {
+		t.Error("useColor ignored NO_COLOR")
+	}
+	if !useColor(&buf, ColorAlways) || useColor(&buf, ColorNever) {
+		t.Error("explicit color modes not honored")
+	}
+}
+
+func TestPrettyPrint_WriteError(t *testing.T) {
+	err := PrettyPrint(&failingWriter{}, []byte(`{"a":1}`), StyleOptions{})
+	if err == nil {
+		t.Error("expected write error")
+	}
+}
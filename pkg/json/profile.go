@@ -0,0 +1,357 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// JSON type names used in ProfileReport.
+const (
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeNull    = "null"
+)
+
+// ProfileReport summarizes the structure of a JSON document. See Profile.
+type ProfileReport struct {
+	// TotalValues counts every value in the document, including objects,
+	// arrays, and the root.
+	TotalValues int
+
+	// MaxDepth is the deepest container nesting level. A scalar document has
+	// depth 0, {} and [] have depth 1, {"a":[]} has depth 2, and so on.
+	MaxDepth int
+
+	// Types counts values by JSON type (TypeObject, TypeString, ...).
+	Types map[string]int
+
+	// Keys counts how often each member name occurs, at any depth.
+	Keys map[string]int
+
+	// ArrayLengths summarizes the lengths of all arrays in the document.
+	ArrayLengths LengthStats
+
+	// Paths holds statistics per normalized path. Paths are written in
+	// JSONPath notation with every array index replaced by [*], so
+	// all elements of an array are profiled together: "$", "$.users",
+	// "$.users[*]", "$.users[*].name", "$['first name']".
+	Paths map[string]*PathStats
+}
+
+// PathStats summarizes the values found at one normalized path.
+type PathStats struct {
+	// Count is the number of values at this path.
+	Count int
+
+	// Types counts the values at this path by JSON type. More than one entry
+	// means the path is polymorphic, e.g. sometimes a string, sometimes null.
+	Types map[string]int
+
+	// ArrayLengths summarizes the lengths of the arrays at this path.
+	// Its Count is zero if no array was found here.
+	ArrayLengths LengthStats
+}
+
+// LengthStats summarizes a set of array lengths.
+type LengthStats struct {
+	Count int // number of arrays
+	Min   int
+	Max   int
+	Total int // sum of all lengths
+}
+
+// Mean returns the average length, or 0 if no arrays were seen.
+func (s LengthStats) Mean() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Total) / float64(s.Count)
+}
+
+// add records one array length.
+func (s *LengthStats) add(n int) {
+	if s.Count == 0 || n < s.Min {
+		s.Min = n
+	}
+	if n > s.Max {
+		s.Max = n
+	}
+	s.Count++
+	s.Total += n
+}
+
+// Profile scans a JSON document and reports its structure: value counts,
+// type distribution overall and per path, member name frequencies, maximum
+// depth, and array length statistics.
+//
+// It is meant for getting to know an unfamiliar data source, e.g. finding
+// fields that are optional, polymorphic, or occasionally null:
+//
+//	report, err := json.Profile(data)
+//	if err != nil {
+//	    return err
+//	}
+//	fmt.Print(report)
+//
+// The document is scanned once without building a tree. Strings and numbers
+// are validated but never decoded.
+func Profile(data []byte) (*ProfileReport, error) {
+	p := &profiler{
+		data: data,
+		report: &ProfileReport{
+			Types: make(map[string]int),
+			Keys:  make(map[string]int),
+			Paths: make(map[string]*PathStats),
+		},
+		path: append(make([]byte, 0, 64), '$'),
+	}
+
+	pos, err := p.value(skipSpace(data, 0), 0)
+	if err != nil {
+		return nil, err
+	}
+	if pos = skipSpace(data, pos); pos < len(data) {
+		return nil, fmt.Errorf("unexpected data after JSON value at position %d", pos)
+	}
+	return p.report, nil
+}
+
+// String formats the report as a human-readable table, one line per path
+// in sorted order.
+func (r *ProfileReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "values: %d, max depth: %d, types: %s\n", r.TotalValues, r.MaxDepth, formatTypeCounts(r.Types))
+	if r.ArrayLengths.Count > 0 {
+		fmt.Fprintf(&sb, "arrays: %d, length min/mean/max: %d/%.1f/%d\n",
+			r.ArrayLengths.Count, r.ArrayLengths.Min, r.ArrayLengths.Mean(), r.ArrayLengths.Max)
+	}
+
+	paths := make([]string, 0, len(r.Paths))
+	for path := range r.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		ps := r.Paths[path]
+		fmt.Fprintf(&sb, "%s  count=%d  %s", path, ps.Count, formatTypeCounts(ps.Types))
+		if ps.ArrayLengths.Count > 0 {
+			fmt.Fprintf(&sb, "  len=%d/%.1f/%d", ps.ArrayLengths.Min, ps.ArrayLengths.Mean(), ps.ArrayLengths.Max)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// formatTypeCounts renders type counts as "number:3 string:1", sorted by name.
+func formatTypeCounts(types map[string]int) string {
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s:%d", name, types[name])
+	}
+	return strings.Join(parts, " ")
+}
+
+// profiler walks a document once, collecting statistics into report.
+type profiler struct {
+	data   []byte
+	report *ProfileReport
+	path   []byte // normalized path of the current value
+}
+
+// record counts one value of the given type at the current path.
+func (p *profiler) record(typ string) *PathStats {
+	p.report.TotalValues++
+	p.report.Types[typ]++
+
+	ps, ok := p.report.Paths[string(p.path)]
+	if !ok {
+		ps = &PathStats{Types: make(map[string]int)}
+		p.report.Paths[string(p.path)] = ps
+	}
+	ps.Count++
+	ps.Types[typ]++
+	return ps
+}
+
+// value profiles the value starting at pos, which is nested depth
+// containers deep, and returns the offset after it.
+func (p *profiler) value(pos, depth int) (int, error) {
+	if pos >= len(p.data) {
+		return pos, errors.New("unexpected end of JSON input")
+	}
+
+	if c := p.data[pos]; (c == '{' || c == '[') && depth >= fastparser.MaxDepth {
+		return pos, fastparser.DepthError(pos)
+	}
+
+	var typ string
+	switch p.data[pos] {
+	case '{':
+		p.record(TypeObject)
+		return p.object(pos, depth+1)
+	case '[':
+		ps := p.record(TypeArray)
+		end, n, err := p.array(pos, depth+1)
+		if err == nil {
+			ps.ArrayLengths.add(n)
+			p.report.ArrayLengths.add(n)
+		}
+		return end, err
+	case '"':
+		typ = TypeString
+	case 't', 'f':
+		typ = TypeBoolean
+	case 'n':
+		typ = TypeNull
+	default:
+		typ = TypeNumber
+	}
+
+	end, err := fastparser.SkipValue(p.data, pos)
+	if err != nil {
+		return end, err
+	}
+	p.record(typ)
+	return end, nil
+}
+
+// object profiles the members of the object starting at pos.
+func (p *profiler) object(pos, depth int) (int, error) {
+	if depth > p.report.MaxDepth {
+		p.report.MaxDepth = depth
+	}
+
+	pos = skipSpace(p.data, pos+1)
+	if pos < len(p.data) && p.data[pos] == '}' {
+		return pos + 1, nil
+	}
+
+	for {
+		if pos >= len(p.data) || p.data[pos] != '"' {
+			return pos, errors.New("expected string key in object")
+		}
+		keyEnd, err := fastparser.SkipValue(p.data, pos)
+		if err != nil {
+			return keyEnd, err
+		}
+		key, err := decodeKey(p.data[pos:keyEnd])
+		if err != nil {
+			return pos, err
+		}
+		p.report.Keys[key]++
+
+		pos = skipSpace(p.data, keyEnd)
+		if pos >= len(p.data) || p.data[pos] != ':' {
+			return pos, errors.New("expected ':' after object key")
+		}
+		pos = skipSpace(p.data, pos+1)
+
+		parent := len(p.path)
+		p.path = appendPathKey(p.path, key)
+		pos, err = p.value(pos, depth)
+		p.path = p.path[:parent]
+		if err != nil {
+			return pos, err
+		}
+
+		pos = skipSpace(p.data, pos)
+		if pos >= len(p.data) {
+			return pos, errors.New("unexpected end of JSON input in object")
+		}
+		switch p.data[pos] {
+		case '}':
+			return pos + 1, nil
+		case ',':
+			pos = skipSpace(p.data, pos+1)
+		default:
+			return pos, fmt.Errorf("expected ',' or '}' in object at position %d", pos)
+		}
+	}
+}
+
+// array profiles the elements of the array starting at pos and also
+// returns the number of elements.
+func (p *profiler) array(pos, depth int) (int, int, error) {
+	if depth > p.report.MaxDepth {
+		p.report.MaxDepth = depth
+	}
+
+	pos = skipSpace(p.data, pos+1)
+	if pos < len(p.data) && p.data[pos] == ']' {
+		return pos + 1, 0, nil
+	}
+
+	parent := len(p.path)
+	p.path = append(p.path, "[*]"...)
+	defer func() { p.path = p.path[:parent] }()
+
+	for n := 1; ; n++ {
+		var err error
+		pos, err = p.value(pos, depth)
+		if err != nil {
+			return pos, n, err
+		}
+
+		pos = skipSpace(p.data, pos)
+		if pos >= len(p.data) {
+			return pos, n, errors.New("unexpected end of JSON input in array")
+		}
+		switch p.data[pos] {
+		case ']':
+			return pos + 1, n, nil
+		case ',':
+			pos = skipSpace(p.data, pos+1)
+		default:
+			return pos, n, fmt.Errorf("expected ',' or ']' in array at position %d", pos)
+		}
+	}
+}
+
+// appendPathKey appends a member name to a JSONPath, using dot notation for
+// identifier-like names and bracket notation otherwise.
+func appendPathKey(path []byte, key string) []byte {
+	if isPathIdentifier(key) {
+		path = append(path, '.')
+		return append(path, key...)
+	}
+
+	path = append(path, "['"...)
+	for i := 0; i < len(key); i++ {
+		if key[i] == '\'' || key[i] == '\\' {
+			path = append(path, '\\')
+		}
+		path = append(path, key[i])
+	}
+	return append(path, "']"...)
+}
+
+// isPathIdentifier reports whether key can be written in dot notation.
+func isPathIdentifier(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
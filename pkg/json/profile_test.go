@@ -0,0 +1,111 @@
+package json
+
+import (
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	input := `{
+		"users": [
+			{"name": "alice", "age": 30, "tags": ["a", "b", "c"]},
+			{"name": "bob", "age": null, "tags": []},
+			{"name": "carol", "age": "unknown", "first name": "C"}
+		],
+		"total": 3,
+		"ok": true
+	}`
+
+	r, err := Profile([]byte(input))
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+
+	if r.TotalValues != 19 {
+		t.Errorf("TotalValues = %d, want 19", r.TotalValues)
+	}
+	if r.MaxDepth != 4 {
+		t.Errorf("MaxDepth = %d, want 4", r.MaxDepth)
+	}
+
+	wantTypes := map[string]int{TypeObject: 4, TypeArray: 3, TypeString: 8, TypeNumber: 2, TypeBoolean: 1, TypeNull: 1}
+	for typ, n := range wantTypes {
+		if r.Types[typ] != n {
+			t.Errorf("Types[%s] = %d, want %d", typ, r.Types[typ], n)
+		}
+	}
+
+	if r.Keys["name"] != 3 || r.Keys["tags"] != 2 || r.Keys["first name"] != 1 {
+		t.Errorf("Keys = %v", r.Keys)
+	}
+
+	age := r.Paths["$.users[*].age"]
+	if age == nil || age.Count != 3 || age.Types[TypeNumber] != 1 || age.Types[TypeNull] != 1 || age.Types[TypeString] != 1 {
+		t.Errorf("Paths[$.users[*].age] = %+v", age)
+	}
+
+	tags := r.Paths["$.users[*].tags"]
+	if tags == nil || tags.ArrayLengths != (LengthStats{Count: 2, Min: 0, Max: 3, Total: 3}) {
+		t.Errorf("Paths[$.users[*].tags] = %+v", tags)
+	}
+	if got := tags.ArrayLengths.Mean(); got != 1.5 {
+		t.Errorf("tags mean length = %v, want 1.5", got)
+	}
+	if r.ArrayLengths != (LengthStats{Count: 3, Min: 0, Max: 3, Total: 6}) {
+		t.Errorf("ArrayLengths = %+v", r.ArrayLengths)
+	}
+
+	for _, path := range []string{"$", "$.users", "$.users[*]", "$.users[*].tags[*]", "$.users[*]['first name']", "$.total", "$.ok"} {
+		if r.Paths[path] == nil {
+			t.Errorf("missing path %s", path)
+		}
+	}
+}
+
+func TestProfile_Scalars(t *testing.T) {
+	r, err := Profile([]byte(` "x" `))
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	if r.TotalValues != 1 || r.MaxDepth != 0 || r.Types[TypeString] != 1 {
+		t.Errorf("Profile() = %+v", r)
+	}
+	if (LengthStats{}).Mean() != 0 {
+		t.Error("Mean() of empty stats should be 0")
+	}
+}
+
+func TestProfile_PathNotation(t *testing.T) {
+	r, err := Profile([]byte(`{"a_1": {"it's": 1, "": 2, "1x": 3}}`))
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	for _, path := range []string{`$.a_1`, `$.a_1['it\'s']`, `$.a_1['']`, `$.a_1['1x']`} {
+		if r.Paths[path] == nil {
+			t.Errorf("missing path %s in %v", path, r.Paths)
+		}
+	}
+}
+
+func TestProfileReport_String(t *testing.T) {
+	r, err := Profile([]byte(`{"b": [1, 2], "a": null}`))
+	if err != nil {
+		t.Fatalf("Profile() error = %v", err)
+	}
+	want := "values: 5, max depth: 2, types: array:1 null:1 number:2 object:1\n" +
+		"arrays: 1, length min/mean/max: 2/2.0/2\n" +
+		"$  count=1  object:1\n" +
+		"$.a  count=1  null:1\n" +
+		"$.b  count=1  array:1  len=2/2.0/2\n" +
+		"$.b[*]  count=2  number:2\n"
+	if got := r.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestProfile_Errors(t *testing.T) {
+	for _, input := range []string{``, `{"a": }`, `[1, 2`, `{"a" 1}`, `[1] x`, `{"a": tru}`} {
+		if _, err := Profile([]byte(input)); err == nil {
+			t.Errorf("Profile(%q) expected error", input)
+		}
+	}
+}
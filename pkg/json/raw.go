@@ -0,0 +1,31 @@
+package json
+
+import "errors"
+
+// RawMessage is a raw encoded JSON value. It implements Marshaler and
+// Unmarshaler, so it can delay the decoding of part of a document, or
+// carry JSON that the program does not model, such as the members collected
+// by a field with the "unknown" option:
+//
+//	type Event struct {
+//	    ID    string                `json:"id"`
+//	    Extra map[string]RawMessage `json:",unknown"`
+//	}
+type RawMessage []byte
+
+// MarshalJSON returns m, or null if m is nil.
+func (m RawMessage) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	return m, nil
+}
+
+// UnmarshalJSON sets *m to a copy of data.
+func (m *RawMessage) UnmarshalJSON(data []byte) error {
+	if m == nil {
+		return errors.New("json.RawMessage: UnmarshalJSON on nil pointer")
+	}
+	*m = append((*m)[0:0], data...)
+	return nil
+}
@@ -0,0 +1,431 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Redact replaces the values at the given paths with replacement and returns
+// the modified document. Everything outside the redacted values — key order,
+// whitespace, number formatting, escapes — is preserved byte-for-byte, which
+// makes the output safe to log in place of the original.
+//
+// Each path is either a JSON Pointer (RFC 6901) or a JSONPath expression:
+//
+//	"/user/password"          JSON Pointer
+//	"$.user.password"         child members
+//	"$..token"                any member named "token", at any depth
+//	"$.sessions[*].secret"    every element of an array (or member of an object)
+//	"$.cards[0]['number']"    array index and bracketed names
+//
+// JSONPath filters, slices, and negative indexes depend on data that is not
+// known while streaming and are rejected with an error. Paths that do not
+// match anything are ignored.
+//
+// replacement is encoded with Marshal once and substituted verbatim; a common
+// choice is a string such as "[REDACTED]". When one redacted value contains
+// another, the outer value is replaced as a whole.
+//
+// The document is processed in a single pass without building a tree, and is
+// fully validated along the way.
+//
+// Example:
+//
+//	clean, err := json.Redact(body, []string{"$..password", "/auth/token"}, "***")
+func Redact(data []byte, paths []string, replacement interface{}) ([]byte, error) {
+	patterns := make([][]pathPattern, len(paths))
+	for i, p := range paths {
+		compiled, err := compileRedactPath(p)
+		if err != nil {
+			return nil, fmt.Errorf("json: Redact path %q: %w", p, err)
+		}
+		patterns[i] = compiled
+	}
+
+	repl, err := Marshal(replacement)
+	if err != nil {
+		return nil, fmt.Errorf("json: Redact replacement: %w", err)
+	}
+
+	r := &redactor{
+		data:        data,
+		patterns:    patterns,
+		replacement: repl,
+		out:         make([]byte, 0, len(data)),
+	}
+	pos, err := r.value(skipSpace(data, 0))
+	if err != nil {
+		return nil, err
+	}
+	if pos = skipSpace(data, pos); pos < len(data) {
+		return nil, fmt.Errorf("unexpected data after JSON value at position %d", pos)
+	}
+	r.out = append(r.out, data[r.copied:]...)
+	return r.out, nil
+}
+
+// pathElem is one step of a concrete location in a document: either an
+// object member name or an array index.
+type pathElem struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// patternKind enumerates the kinds of path pattern segments.
+type patternKind uint8
+
+const (
+	patternName           patternKind = iota // member name (or, for pointers, an index spelled as a name)
+	patternIndex                             // array index
+	patternWildcard                          // any single child
+	patternDescendantName                    // member name at any depth
+	patternDescendantAny                     // zero or more levels, for the segment after it
+)
+
+// pathPattern is one compiled segment of a redaction path.
+type pathPattern struct {
+	kind  patternKind
+	name  string
+	index int // patternIndex, or the numeric value of a pointer token (-1 if not numeric)
+}
+
+// matchPath reports whether the concrete path satisfies the pattern.
+func matchPath(pattern []pathPattern, path []pathElem) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+
+	seg := pattern[0]
+	switch seg.kind {
+	case patternDescendantName:
+		for i := range path {
+			if !path[i].isIndex && path[i].key == seg.name && matchPath(pattern[1:], path[i+1:]) {
+				return true
+			}
+		}
+		return false
+	case patternDescendantAny:
+		for i := 0; i <= len(path); i++ {
+			if matchPath(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(path) == 0 {
+		return false
+	}
+	elem := path[0]
+	switch seg.kind {
+	case patternName:
+		if elem.isIndex {
+			if seg.index != elem.index {
+				return false
+			}
+		} else if elem.key != seg.name {
+			return false
+		}
+	case patternIndex:
+		if !elem.isIndex || elem.index != seg.index {
+			return false
+		}
+	}
+	return matchPath(pattern[1:], path[1:])
+}
+
+// compileRedactPath compiles a JSON Pointer or JSONPath expression.
+func compileRedactPath(path string) ([]pathPattern, error) {
+	if path == "" || path[0] == '/' {
+		return compilePointer(path)
+	}
+	if path[0] == '$' {
+		return compileJSONPath(path)
+	}
+	return nil, errors.New("path must be a JSON Pointer (\"/a/b\") or JSONPath (\"$.a.b\")")
+}
+
+// compilePointer compiles an RFC 6901 JSON Pointer.
+func compilePointer(ptr string) ([]pathPattern, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	patterns := make([]pathPattern, len(tokens))
+	for i, tok := range tokens {
+		if strings.Contains(tok, "~") {
+			for j := 0; j < len(tok); j++ {
+				if tok[j] == '~' && (j+1 >= len(tok) || (tok[j+1] != '0' && tok[j+1] != '1')) {
+					return nil, fmt.Errorf("invalid escape in pointer token %q", tok)
+				}
+			}
+			tok = strings.ReplaceAll(tok, "~1", "/")
+			tok = strings.ReplaceAll(tok, "~0", "~")
+		}
+		index := -1
+		if n, err := strconv.Atoi(tok); err == nil && n >= 0 && (tok == "0" || tok[0] != '0') {
+			index = n
+		}
+		patterns[i] = pathPattern{kind: patternName, name: tok, index: index}
+	}
+	return patterns, nil
+}
+
+// compileJSONPath compiles the structural subset of JSONPath supported by Redact.
+func compileJSONPath(path string) ([]pathPattern, error) {
+	var patterns []pathPattern
+	pos := 1 // skip '$'
+
+	for pos < len(path) {
+		switch {
+		case strings.HasPrefix(path[pos:], ".."):
+			pos += 2
+			if pos < len(path) && path[pos] == '*' {
+				// $..* is any child of the root or of a value below it
+				patterns = append(patterns, pathPattern{kind: patternDescendantAny}, pathPattern{kind: patternWildcard})
+				pos++
+				continue
+			}
+			if pos < len(path) && path[pos] == '[' {
+				// $..[...] applies the bracket selector at the root and at any
+				// depth below it
+				patterns = append(patterns, pathPattern{kind: patternDescendantAny})
+				continue
+			}
+			name, next := scanPathName(path, pos)
+			if name == "" {
+				return nil, fmt.Errorf("expected name after '..' at position %d", pos)
+			}
+			patterns = append(patterns, pathPattern{kind: patternDescendantName, name: name})
+			pos = next
+
+		case path[pos] == '.':
+			pos++
+			if pos < len(path) && path[pos] == '*' {
+				patterns = append(patterns, pathPattern{kind: patternWildcard})
+				pos++
+				continue
+			}
+			name, next := scanPathName(path, pos)
+			if name == "" {
+				return nil, fmt.Errorf("expected name after '.' at position %d", pos)
+			}
+			patterns = append(patterns, pathPattern{kind: patternName, name: name, index: -1})
+			pos = next
+
+		case path[pos] == '[':
+			end := strings.IndexByte(path[pos:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed '[' at position %d", pos)
+			}
+			inner := strings.TrimSpace(path[pos+1 : pos+end])
+			seg, err := compileBracket(inner)
+			if err != nil {
+				return nil, err
+			}
+			patterns = append(patterns, seg)
+			pos += end + 1
+
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d", path[pos], pos)
+		}
+	}
+	return patterns, nil
+}
+
+// compileBracket compiles the contents of a bracket selector.
+func compileBracket(inner string) (pathPattern, error) {
+	switch {
+	case inner == "*":
+		return pathPattern{kind: patternWildcard}, nil
+	case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+		return pathPattern{kind: patternName, name: inner[1 : len(inner)-1], index: -1}, nil
+	case strings.HasPrefix(inner, "?"):
+		return pathPattern{}, errors.New("filter expressions are not supported")
+	case strings.Contains(inner, ":"):
+		return pathPattern{}, errors.New("slices are not supported")
+	}
+	n, err := strconv.Atoi(inner)
+	if err != nil {
+		return pathPattern{}, fmt.Errorf("invalid bracket selector [%s]", inner)
+	}
+	if n < 0 {
+		return pathPattern{}, errors.New("negative indexes are not supported")
+	}
+	return pathPattern{kind: patternIndex, index: n}, nil
+}
+
+// scanPathName reads a dot-notation member name starting at pos.
+func scanPathName(path string, pos int) (string, int) {
+	start := pos
+	for pos < len(path) && path[pos] != '.' && path[pos] != '[' {
+		pos++
+	}
+	return path[start:pos], pos
+}
+
+// redactor walks a document once, copying unchanged bytes to out and
+// substituting the replacement for every value whose path matches. If
+// collect is set, it records the spans of those values in spans instead.
+type redactor struct {
+	data        []byte
+	patterns    [][]pathPattern
+	replacement []byte
+	path        []pathElem
+	out         []byte
+	copied      int // data[:copied] has already been written to out
+	collect     bool
+	spans       []Span
+}
+
+// matches reports whether the current path is selected by any pattern.
+func (r *redactor) matches() bool {
+	for _, p := range r.patterns {
+		if matchPath(p, r.path) {
+			return true
+		}
+	}
+	return false
+}
+
+// value processes the value starting at pos and returns the offset after it.
+func (r *redactor) value(pos int) (int, error) {
+	if r.matches() {
+		end, err := fastparser.SkipValue(r.data, pos)
+		if err != nil {
+			return end, err
+		}
+		if r.collect {
+			r.spans = append(r.spans, Span{Start: pos, End: end})
+			return end, nil
+		}
+		r.out = append(r.out, r.data[r.copied:pos]...)
+		r.out = append(r.out, r.replacement...)
+		r.copied = end
+		return end, nil
+	}
+
+	if pos < len(r.data) {
+		switch r.data[pos] {
+		case '{', '[':
+			if len(r.path) >= fastparser.MaxDepth {
+				return pos, fastparser.DepthError(pos)
+			}
+			if r.data[pos] == '{' {
+				return r.object(pos)
+			}
+			return r.array(pos)
+		}
+	}
+	return fastparser.SkipValue(r.data, pos)
+}
+
+// object processes the members of the object starting at pos.
+func (r *redactor) object(pos int) (int, error) {
+	pos = skipSpace(r.data, pos+1)
+	if pos < len(r.data) && r.data[pos] == '}' {
+		return pos + 1, nil
+	}
+
+	for {
+		if pos >= len(r.data) || r.data[pos] != '"' {
+			return pos, errors.New("expected string key in object")
+		}
+		keyEnd, err := fastparser.SkipValue(r.data, pos)
+		if err != nil {
+			return keyEnd, err
+		}
+		key, err := decodeKey(r.data[pos:keyEnd])
+		if err != nil {
+			return pos, err
+		}
+
+		pos = skipSpace(r.data, keyEnd)
+		if pos >= len(r.data) || r.data[pos] != ':' {
+			return pos, errors.New("expected ':' after object key")
+		}
+		pos = skipSpace(r.data, pos+1)
+
+		r.path = append(r.path, pathElem{key: key})
+		pos, err = r.value(pos)
+		r.path = r.path[:len(r.path)-1]
+		if err != nil {
+			return pos, err
+		}
+
+		pos = skipSpace(r.data, pos)
+		if pos >= len(r.data) {
+			return pos, errors.New("unexpected end of JSON input in object")
+		}
+		switch r.data[pos] {
+		case '}':
+			return pos + 1, nil
+		case ',':
+			pos = skipSpace(r.data, pos+1)
+		default:
+			return pos, fmt.Errorf("expected ',' or '}' in object at position %d", pos)
+		}
+	}
+}
+
+// array processes the elements of the array starting at pos.
+func (r *redactor) array(pos int) (int, error) {
+	pos = skipSpace(r.data, pos+1)
+	if pos < len(r.data) && r.data[pos] == ']' {
+		return pos + 1, nil
+	}
+
+	for i := 0; ; i++ {
+		var err error
+		r.path = append(r.path, pathElem{index: i, isIndex: true})
+		pos, err = r.value(pos)
+		r.path = r.path[:len(r.path)-1]
+		if err != nil {
+			return pos, err
+		}
+
+		pos = skipSpace(r.data, pos)
+		if pos >= len(r.data) {
+			return pos, errors.New("unexpected end of JSON input in array")
+		}
+		switch r.data[pos] {
+		case ']':
+			return pos + 1, nil
+		case ',':
+			pos = skipSpace(r.data, pos+1)
+		default:
+			return pos, fmt.Errorf("expected ',' or ']' in array at position %d", pos)
+		}
+	}
+}
+
+// decodeKey decodes a raw, already-validated JSON string including its quotes.
+func decodeKey(raw []byte) (string, error) {
+	inner := raw[1 : len(raw)-1]
+	for _, c := range inner {
+		if c == '\\' {
+			var s string
+			err := fastparser.Unmarshal(raw, &s)
+			return s, err
+		}
+	}
+	return string(inner), nil
+}
+
+// skipSpace returns the offset of the first non-whitespace byte at or after pos.
+func skipSpace(data []byte, pos int) int {
+	for pos < len(data) {
+		switch data[pos] {
+		case ' ', '\t', '\n', '\r':
+			pos++
+		default:
+			return pos
+		}
+	}
+	return pos
+}
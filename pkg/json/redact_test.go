@@ -0,0 +1,164 @@
+package json
+
+import (
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	input := `{
+  "user": {"name": "alice", "password": "hunter2", "tokens": ["t1", "t2"]},
+  "auth": {"token" : 12345, "scheme": "Bearer"},
+  "sessions": [{"id": 1, "secret": {"k": "v"}}, {"id": 2, "secret": null}],
+  "a/b": {"~x": true},
+  "num": 1.50e+2
+}`
+
+	tests := []struct {
+		name  string
+		paths []string
+		want  string
+	}{
+		{
+			name:  "json pointer",
+			paths: []string{"/user/password"},
+			want: `{
+  "user": {"name": "alice", "password": "***", "tokens": ["t1", "t2"]},
+  "auth": {"token" : 12345, "scheme": "Bearer"},
+  "sessions": [{"id": 1, "secret": {"k": "v"}}, {"id": 2, "secret": null}],
+  "a/b": {"~x": true},
+  "num": 1.50e+2
+}`,
+		},
+		{
+			name:  "pointer escapes and array index",
+			paths: []string{"/a~1b/~0x", "/user/tokens/1"},
+			want: `{
+  "user": {"name": "alice", "password": "hunter2", "tokens": ["t1", "***"]},
+  "auth": {"token" : 12345, "scheme": "Bearer"},
+  "sessions": [{"id": 1, "secret": {"k": "v"}}, {"id": 2, "secret": null}],
+  "a/b": {"~x": "***"},
+  "num": 1.50e+2
+}`,
+		},
+		{
+			name:  "recursive descent and wildcard",
+			paths: []string{"$..token", "$.sessions[*].secret"},
+			want: `{
+  "user": {"name": "alice", "password": "hunter2", "tokens": ["t1", "t2"]},
+  "auth": {"token" : "***", "scheme": "Bearer"},
+  "sessions": [{"id": 1, "secret": "***"}, {"id": 2, "secret": "***"}],
+  "a/b": {"~x": true},
+  "num": 1.50e+2
+}`,
+		},
+		{
+			name:  "bracket notation and outer value wins",
+			paths: []string{"$['user']", "$.user.password", "$.sessions[0]['id']"},
+			want: `{
+  "user": "***",
+  "auth": {"token" : 12345, "scheme": "Bearer"},
+  "sessions": [{"id": "***", "secret": {"k": "v"}}, {"id": 2, "secret": null}],
+  "a/b": {"~x": true},
+  "num": 1.50e+2
+}`,
+		},
+		{
+			name:  "no match preserves input exactly",
+			paths: []string{"$.missing", "/sessions/9"},
+			want:  input,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Redact([]byte(input), tt.paths, "***")
+			if err != nil {
+				t.Fatalf("Redact() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Redact() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact_DescendantIncludesRoot(t *testing.T) {
+	tests := []struct {
+		input string
+		path  string
+		want  string
+	}{
+		{`{"a":{"pw":"X"},"pw":2}`, "$..['pw']", `{"a":{"pw":"*"},"pw":"*"}`},
+		{`{"a":{"pw":"X"},"pw":2}`, "$..pw", `{"a":{"pw":"*"},"pw":"*"}`},
+		{`[{"a":1},{"a":2}]`, "$..[0]", `["*",{"a":2}]`},
+		{`{"a":[[1,2]],"b":[3]}`, "$..[1]", `{"a":[[1,"*"]],"b":[3]}`},
+		{`{"a":{"b":1},"c":[2]}`, "$..*", `{"a":"*","c":"*"}`},
+		{`{"a":{"b":1}}`, "$..['b']", `{"a":{"b":"*"}}`},
+		{`5`, "$..*", `5`},
+	}
+	for _, tt := range tests {
+		got, err := Redact([]byte(tt.input), []string{tt.path}, "*")
+		if err != nil {
+			t.Fatalf("Redact(%s, %s) error = %v", tt.input, tt.path, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Redact(%s, %s) = %s, want %s", tt.input, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRedact_Replacement(t *testing.T) {
+	got, err := Redact([]byte(`{"a": [1, 2], "b": 3}`), []string{"$.*"}, nil)
+	if err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+	if string(got) != `{"a": null, "b": null}` {
+		t.Errorf("Redact() = %s", got)
+	}
+
+	got, err = Redact([]byte(` {"a": 1} `), []string{""}, map[string]interface{}{"redacted": true})
+	if err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+	if string(got) != ` {"redacted":true} ` {
+		t.Errorf("Redact(root) = %q", got)
+	}
+}
+
+func TestRedact_EscapedKeys(t *testing.T) {
+	got, err := Redact([]byte(`{"password": "x", "other": "y"}`), []string{"$.password"}, "-")
+	if err != nil {
+		t.Fatalf("Redact() error = %v", err)
+	}
+	if string(got) != `{"password": "-", "other": "y"}` {
+		t.Errorf("Redact() = %s", got)
+	}
+}
+
+func TestRedact_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		paths []string
+		repl  interface{}
+	}{
+		{"filter", `{}`, []string{"$.a[?(@.b)]"}, "x"},
+		{"slice", `{}`, []string{"$.a[0:2]"}, "x"},
+		{"negative index", `{}`, []string{"$.a[-1]"}, "x"},
+		{"bad syntax", `{}`, []string{"a.b"}, "x"},
+		{"unclosed bracket", `{}`, []string{"$.a[0"}, "x"},
+		{"bad pointer escape", `{}`, []string{"/a~2"}, "x"},
+		{"unsupported replacement", `{}`, []string{"/a"}, make(chan int)},
+		{"invalid JSON", `{"a": [1,}`, []string{"/b"}, "x"},
+		{"invalid redacted value", `{"a": tru}`, []string{"/a"}, "x"},
+		{"trailing data", `{} {}`, []string{"/a"}, "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Redact([]byte(tt.data), tt.paths, tt.repl); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package json
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Rejection describes input that an operation rejected because it is not
+// well-formed JSON or exceeds a limit. Its text fields are sanitized for
+// logging: they hold at most a few dozen bytes of the input, with control
+// characters and invalid UTF-8 replaced by '?', so a gateway can record
+// them without logging request bodies or letting a client forge log
+// lines.
+type Rejection struct {
+	Op      Operation
+	Size    int    // size of the input
+	Offset  int    // byte offset of the error in the input
+	Snippet string // the input around Offset
+	Reason  string // what is wrong, such as "expected ',' or '}' in object at position 10"
+	Limit   string // the limit exceeded, such as "nesting depth", or "" for a syntax error
+}
+
+// snippetRadius is the most bytes of input on each side of the offset
+// that a Rejection's Snippet holds.
+const snippetRadius = 16
+
+var rejectionHook atomic.Pointer[func(Rejection)]
+
+// SetRejectionHook sets a function that Parse, ParseWithOptions,
+// Unmarshal, UnmarshalWithOptions, and ParseUntrusted call, on the
+// goroutine that ran them, each time they reject input as malformed or
+// over a limit, so that an API gateway can aggregate the quality of the
+// JSON its clients send:
+//
+//	json.SetRejectionHook(func(r json.Rejection) {
+//	    rejected.WithLabelValues(r.Op.String(), r.Limit).Inc()
+//	    slog.Debug("malformed JSON", "offset", r.Offset, "near", r.Snippet, "reason", r.Reason)
+//	})
+//
+// Errors about well-formed input, such as a string decoded into an int
+// field, are not rejections. ParseReader does not keep its input, and so
+// reports nothing. f must be safe for concurrent use; nil removes the
+// hook. Without a hook, rejected input costs nothing extra.
+func SetRejectionHook(f func(Rejection)) {
+	if f == nil {
+		rejectionHook.Store(nil)
+		return
+	}
+	rejectionHook.Store(&f)
+}
+
+// rejected reports to the rejection hook, if one is set, that op failed
+// with err on input, if that was because input is malformed. It finds the
+// error again with the validator, which knows its offset.
+func rejected[T string | []byte](op Operation, input T, err error) {
+	hook := rejectionHook.Load()
+	if hook == nil {
+		return
+	}
+	var le *LimitError
+	if errors.As(err, &le) {
+		reject(*hook, op, input, int(le.Offset), le.Error(), le.Limit)
+		return
+	}
+	offset, err := fastparser.ValidateOffset([]byte(input))
+	switch {
+	case err == nil:
+		return
+	case errors.Is(err, ErrDepthExceeded):
+		reject(*hook, op, input, offset, err.Error(), "nesting depth")
+	default:
+		reject(*hook, op, input, offset, err.Error(), "")
+	}
+}
+
+// rejectedAt reports to the rejection hook, if one is set, that op
+// rejected input at offset for reason.
+func rejectedAt(op Operation, input []byte, offset int, reason string) {
+	if hook := rejectionHook.Load(); hook != nil {
+		reject(*hook, op, input, offset, reason, "")
+	}
+}
+
+// reject calls hook with the sanitized Rejection.
+func reject[T string | []byte](hook func(Rejection), op Operation, input T, offset int, reason, limit string) {
+	lo, hi := max(offset-snippetRadius, 0), min(offset+snippetRadius, len(input))
+	if lo > hi {
+		lo = hi
+	}
+	hook(Rejection{
+		Op:      op,
+		Size:    len(input),
+		Offset:  offset,
+		Snippet: sanitize(string(input[lo:hi])),
+		Reason:  sanitize(reason),
+		Limit:   limit,
+	})
+}
+
+// sanitize replaces the characters of s that are unsafe to log, control
+// characters and invalid UTF-8, with '?'.
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			return '?'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,121 @@
+package json
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// collectRejections sets a rejection hook for the rest of the test and
+// returns a function that takes the rejections seen so far.
+func collectRejections(t *testing.T) func() []Rejection {
+	var mu sync.Mutex
+	var seen []Rejection
+	SetRejectionHook(func(r Rejection) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, r)
+	})
+	t.Cleanup(func() { SetRejectionHook(nil) })
+	return func() []Rejection {
+		mu.Lock()
+		defer mu.Unlock()
+		got := seen
+		seen = nil
+		return got
+	}
+}
+
+func TestRejectionHook(t *testing.T) {
+	take := collectRejections(t)
+
+	doc := `{"user": "alice", "token": "s3cr3t", "items": [1, 2} and the rest of a long body`
+	var v interface{}
+	if err := Unmarshal([]byte(doc), &v); err == nil {
+		t.Fatal("Unmarshal: expected error")
+	}
+	if _, err := Parse(doc); err == nil {
+		t.Fatal("Parse: expected error")
+	}
+
+	got := take()
+	if len(got) != 2 {
+		t.Fatalf("got %d rejections, want 2: %+v", len(got), got)
+	}
+	for i, op := range []Operation{OpUnmarshal, OpParse} {
+		r := got[i]
+		if r.Op != op || r.Size != len(doc) || r.Offset != 51 || r.Limit != "" {
+			t.Errorf("rejection %d = %+v", i, r)
+		}
+		if r.Snippet != `, "items": [1, 2} and the rest o` {
+			t.Errorf("rejection %d Snippet = %q", i, r.Snippet)
+		}
+		if !strings.Contains(r.Reason, "expected ',' or ']'") {
+			t.Errorf("rejection %d Reason = %q", i, r.Reason)
+		}
+	}
+}
+
+func TestRejectionHookSanitizes(t *testing.T) {
+	take := collectRejections(t)
+
+	var v interface{}
+	if err := Unmarshal([]byte("[\"a\nb\xff\"]"), &v); err == nil {
+		t.Fatal("Unmarshal: expected error")
+	}
+	got := take()
+	if len(got) != 1 {
+		t.Fatalf("got %d rejections, want 1", len(got))
+	}
+	if got[0].Snippet != `["a?b?"]` {
+		t.Errorf("Snippet = %q", got[0].Snippet)
+	}
+}
+
+func TestRejectionHookLimits(t *testing.T) {
+	take := collectRejections(t)
+
+	if _, err := ParseUntrusted([]byte(`[[[1]]]`), Limits{MaxDepth: 2}); err == nil {
+		t.Fatal("ParseUntrusted: expected error")
+	}
+	if _, err := ParseUntrusted([]byte("\"a\xffb\""), Limits{}); err == nil {
+		t.Fatal("ParseUntrusted: expected error")
+	}
+	if _, err := ParseUntrusted([]byte(`{"a":}`), Limits{}); err == nil {
+		t.Fatal("ParseUntrusted: expected error")
+	}
+
+	got := take()
+	if len(got) != 3 {
+		t.Fatalf("got %d rejections, want 3: %+v", len(got), got)
+	}
+	if got[0].Limit != "nesting depth" || got[0].Offset != 2 {
+		t.Errorf("depth rejection = %+v", got[0])
+	}
+	if got[1].Reason != "invalid UTF-8" || got[1].Offset != 2 {
+		t.Errorf("UTF-8 rejection = %+v", got[1])
+	}
+	if got[2].Limit != "" || got[2].Offset != 5 {
+		t.Errorf("syntax rejection = %+v", got[2])
+	}
+}
+
+func TestRejectionHookIgnoresTypeErrors(t *testing.T) {
+	take := collectRejections(t)
+
+	var n int
+	if err := Unmarshal([]byte(`"seven"`), &n); err == nil {
+		t.Fatal("Unmarshal: expected error")
+	}
+	if got := take(); len(got) != 0 {
+		t.Errorf("rejections for well-formed input: %+v", got)
+	}
+
+	SetRejectionHook(nil)
+	if _, err := Parse(`{`); err == nil {
+		t.Fatal("Parse: expected error")
+	}
+	if got := take(); len(got) != 0 {
+		t.Errorf("rejections after SetRejectionHook(nil): %+v", got)
+	}
+}
@@ -10,10 +10,34 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/shapestone/shape-core/pkg/ast"
 )
 
+// bufferPool is a pool of bytes.Buffer instances to reduce allocations during marshaling.
+// Buffers are returned to the pool after use to minimize GC pressure.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return bytes.NewBuffer(make([]byte, 0, 1024))
+	},
+}
+
+// getBuffer retrieves a buffer from the pool and resets it for use.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool if it's not too large.
+// Buffers larger than 64KB are not pooled to avoid holding excessive memory.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= 64*1024 {
+		bufferPool.Put(buf)
+	}
+}
+
 // Render converts an AST node to compact JSON bytes.
 //
 // The node should be the result of Parse() or ParseReader().
@@ -28,7 +52,7 @@ func Render(node ast.SchemaNode) ([]byte, error) {
 	buf := getBuffer()
 	defer putBuffer(buf)
 
-	if err := renderNode(node, buf, false, "", ""); err != nil {
+	if err := renderNode(node, buf, false, "", "", nil); err != nil {
 		return nil, err
 	}
 
@@ -61,7 +85,41 @@ func RenderIndent(node ast.SchemaNode, prefix, indent string) ([]byte, error) {
 	buf := getBuffer()
 	defer putBuffer(buf)
 
-	if err := renderNode(node, buf, true, prefix, indent); err != nil {
+	if err := renderNode(node, buf, true, prefix, indent, nil); err != nil {
+		return nil, err
+	}
+
+	// Must copy since buffer will be returned to pool
+	result := make([]byte, buf.Len())
+	copy(result, buf.Bytes())
+	return result, nil
+}
+
+// RenderOptions configures RenderWithOptions.
+type RenderOptions struct {
+	// Prefix and Indent, if either is set, pretty-print the output as
+	// RenderIndent does.
+	Prefix string
+	Indent string
+
+	// Numbers, if set, controls how floating-point numbers are written.
+	// Without it they are written in plain notation, with ".0" added to
+	// whole numbers.
+	Numbers *NumberFormat
+}
+
+// RenderWithOptions is like Render but applies opts:
+//
+//	out, err := json.RenderWithOptions(node, json.RenderOptions{
+//	    Indent:  "  ",
+//	    Numbers: &json.NumberFormat{IntegerWhenExact: true, ExponentThreshold: 21},
+//	})
+func RenderWithOptions(node ast.SchemaNode, opts RenderOptions) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	pretty := opts.Prefix != "" || opts.Indent != ""
+	if err := renderNode(node, buf, pretty, opts.Prefix, opts.Indent, opts.Numbers); err != nil {
 		return nil, err
 	}
 
@@ -79,12 +137,13 @@ func RenderIndent(node ast.SchemaNode, prefix, indent string) ([]byte, error) {
 //   - prettyPrint: Whether to add whitespace for readability
 //   - prefix: String to add at the start of each line
 //   - indent: Indentation string (spaces or tabs)
-func renderNode(node ast.SchemaNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string) error {
-	return renderNodeWithDepth(node, buf, prettyPrint, prefix, indent, 0)
+//   - nf: How to write floats, or nil for the default
+func renderNode(node ast.SchemaNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string, nf *NumberFormat) error {
+	return renderNodeWithDepth(node, buf, prettyPrint, prefix, indent, 0, nf)
 }
 
 // renderNodeWithDepth renders a node with tracking of indentation depth.
-func renderNodeWithDepth(node ast.SchemaNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string, depth int) error {
+func renderNodeWithDepth(node ast.SchemaNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string, depth int, nf *NumberFormat) error {
 	if node == nil {
 		buf.WriteString("null")
 		return nil
@@ -92,11 +151,11 @@ func renderNodeWithDepth(node ast.SchemaNode, buf *bytes.Buffer, prettyPrint boo
 
 	switch n := node.(type) {
 	case *ast.ObjectNode:
-		return renderObject(n, buf, prettyPrint, prefix, indent, depth)
+		return renderObject(n, buf, prettyPrint, prefix, indent, depth, nf)
 	case *ast.ArrayDataNode:
-		return renderArrayData(n, buf, prettyPrint, prefix, indent, depth)
+		return renderArrayData(n, buf, prettyPrint, prefix, indent, depth, nf)
 	case *ast.LiteralNode:
-		return renderLiteral(n, buf)
+		return renderLiteral(n, buf, nf)
 	default:
 		return fmt.Errorf("unknown node type: %T", node)
 	}
@@ -104,8 +163,10 @@ func renderNodeWithDepth(node ast.SchemaNode, buf *bytes.Buffer, prettyPrint boo
 
 // renderObject renders an ObjectNode as either a JSON object or array.
 //
-// Arrays are detected by checking if all keys are sequential numeric strings ("0", "1", "2", ...).
-func renderObject(node *ast.ObjectNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string, depth int) error {
+// An object whose keys are exactly "0", "1", "2", ... is the legacy form of an
+// array, from before arrays had their own node kind, and is rendered as one;
+// see NormalizeArrays.
+func renderObject(node *ast.ObjectNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string, depth int, nf *NumberFormat) error {
 	props := node.Properties()
 
 	// Empty object
@@ -116,7 +177,7 @@ func renderObject(node *ast.ObjectNode, buf *bytes.Buffer, prettyPrint bool, pre
 
 	// Check if this is an array (all keys are sequential numbers)
 	if isArray(props) {
-		return renderArray(node, buf, prettyPrint, prefix, indent, depth)
+		return renderArray(node, buf, prettyPrint, prefix, indent, depth, nf)
 	}
 
 	// Render as object
@@ -152,7 +213,7 @@ func renderObject(node *ast.ObjectNode, buf *bytes.Buffer, prettyPrint bool, pre
 		}
 
 		// Write value
-		if err := renderNodeWithDepth(props[key], buf, prettyPrint, prefix, indent, depth+1); err != nil {
+		if err := renderNodeWithDepth(props[key], buf, prettyPrint, prefix, indent, depth+1, nf); err != nil {
 			return err
 		}
 	}
@@ -167,7 +228,7 @@ func renderObject(node *ast.ObjectNode, buf *bytes.Buffer, prettyPrint bool, pre
 }
 
 // renderArray renders an ObjectNode with numeric keys as a JSON array.
-func renderArray(node *ast.ObjectNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string, depth int) error {
+func renderArray(node *ast.ObjectNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string, depth int, nf *NumberFormat) error {
 	props := node.Properties()
 
 	buf.WriteString("[")
@@ -197,7 +258,7 @@ func renderArray(node *ast.ObjectNode, buf *bytes.Buffer, prettyPrint bool, pref
 			// Missing element - should not happen in valid arrays
 			buf.WriteString("null")
 		} else {
-			if err := renderNodeWithDepth(value, buf, prettyPrint, prefix, indent, depth+1); err != nil {
+			if err := renderNodeWithDepth(value, buf, prettyPrint, prefix, indent, depth+1, nf); err != nil {
 				return err
 			}
 		}
@@ -213,7 +274,7 @@ func renderArray(node *ast.ObjectNode, buf *bytes.Buffer, prettyPrint bool, pref
 }
 
 // renderArrayData renders an ArrayDataNode as a JSON array.
-func renderArrayData(node *ast.ArrayDataNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string, depth int) error {
+func renderArrayData(node *ast.ArrayDataNode, buf *bytes.Buffer, prettyPrint bool, prefix, indent string, depth int, nf *NumberFormat) error {
 	elements := node.Elements()
 
 	buf.WriteString("[")
@@ -235,7 +296,7 @@ func renderArrayData(node *ast.ArrayDataNode, buf *bytes.Buffer, prettyPrint boo
 			buf.WriteString(strings.Repeat(indent, depth+1))
 		}
 
-		if err := renderNodeWithDepth(elem, buf, prettyPrint, prefix, indent, depth+1); err != nil {
+		if err := renderNodeWithDepth(elem, buf, prettyPrint, prefix, indent, depth+1, nf); err != nil {
 			return err
 		}
 	}
@@ -250,7 +311,7 @@ func renderArrayData(node *ast.ArrayDataNode, buf *bytes.Buffer, prettyPrint boo
 }
 
 // renderLiteral renders a LiteralNode as a JSON primitive.
-func renderLiteral(node *ast.LiteralNode, buf *bytes.Buffer) error {
+func renderLiteral(node *ast.LiteralNode, buf *bytes.Buffer, nf *NumberFormat) error {
 	value := node.Value()
 
 	if value == nil {
@@ -266,6 +327,10 @@ func renderLiteral(node *ast.LiteralNode, buf *bytes.Buffer) error {
 	case int64:
 		buf.WriteString(strconv.FormatInt(v, 10))
 	case float64:
+		if nf != nil {
+			buf.Write(nf.appendFloat(buf.AvailableBuffer(), v, 64))
+			return nil
+		}
 		// Format float, preserving precision
 		s := strconv.FormatFloat(v, 'f', -1, 64)
 		// Avoid scientific notation for readability
@@ -0,0 +1,389 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reshape is a compiled, declarative transformation of Documents, so that
+// ETL-style field mappings can live in configuration rather than Go code.
+//
+// A spec is a JSON array of operations applied in order, in the style of
+// JOLT:
+//
+//	[
+//	  {"operation": "shift", "spec": {
+//	    "user.name":     "customer.name",
+//	    "user.emails[0]": "customer.email",
+//	    "items[*].sku":  ["skus[*]", "audit.items[*].id"]
+//	  }},
+//	  {"operation": "default", "spec": {"customer": {"tier": "basic"}}},
+//	  {"operation": "remove", "spec": ["audit"]}
+//	]
+//
+// Operations:
+//   - "shift" builds a new document containing only the mapped values. The
+//     spec maps a source path to one destination path, or to an array of
+//     them. Wildcards ([*] or .*) in the source match every array element
+//     or object member; each wildcard in a destination is replaced by the
+//     index or key matched by the corresponding source wildcard, in order.
+//     Missing objects and arrays along a destination path are created.
+//   - "default" deep-merges the spec object into the document, filling in
+//     members that are missing or null.
+//   - "remove" deletes the members or array elements at each path in the
+//     spec array. Wildcards are allowed except in the last segment.
+//
+// Paths use JSONPath syntax with an optional leading "$": "a.b[0]",
+// "$.a['odd key'][*]".
+type Reshape struct {
+	ops []reshapeOp
+}
+
+// reshapeOp is one compiled operation.
+type reshapeOp struct {
+	kind     string
+	shifts   []reshapeShift         // shift
+	defaults map[string]interface{} // default
+	removes  [][]pathPattern        // remove
+}
+
+// reshapeShift maps one source path to its destinations.
+type reshapeShift struct {
+	source []pathPattern
+	dests  [][]pathPattern
+}
+
+// CompileReshape compiles a JSON reshape spec. See Reshape for the format.
+func CompileReshape(spec []byte) (*Reshape, error) {
+	var raw []struct {
+		Operation string      `json:"operation"`
+		Spec      interface{} `json:"spec"`
+	}
+	if err := Unmarshal(spec, &raw); err != nil {
+		return nil, fmt.Errorf("json: reshape spec: %w", err)
+	}
+
+	r := &Reshape{}
+	for i, op := range raw {
+		compiled, err := compileReshapeOp(op.Operation, op.Spec)
+		if err != nil {
+			return nil, fmt.Errorf("json: reshape spec operation %d (%s): %w", i, op.Operation, err)
+		}
+		r.ops = append(r.ops, compiled)
+	}
+	return r, nil
+}
+
+// compileReshapeOp validates and compiles a single operation.
+func compileReshapeOp(kind string, spec interface{}) (reshapeOp, error) {
+	op := reshapeOp{kind: kind}
+
+	switch kind {
+	case "shift":
+		m, ok := spec.(map[string]interface{})
+		if !ok {
+			return op, errors.New("spec must be an object")
+		}
+		sources := make([]string, 0, len(m))
+		for src := range m {
+			sources = append(sources, src)
+		}
+		sort.Strings(sources) // deterministic order when destinations collide
+
+		for _, src := range sources {
+			source, err := compileReshapePath(src)
+			if err != nil {
+				return op, err
+			}
+			var destPaths []interface{}
+			switch d := m[src].(type) {
+			case string:
+				destPaths = []interface{}{d}
+			case []interface{}:
+				destPaths = d
+			default:
+				return op, fmt.Errorf("destination for %q must be a path or an array of paths", src)
+			}
+
+			shift := reshapeShift{source: source}
+			for _, d := range destPaths {
+				s, ok := d.(string)
+				if !ok {
+					return op, fmt.Errorf("destination for %q must be a path or an array of paths", src)
+				}
+				dest, err := compileReshapePath(s)
+				if err != nil {
+					return op, err
+				}
+				if len(dest) == 0 {
+					return op, fmt.Errorf("destination for %q must not be the root", src)
+				}
+				if countWildcards(dest) > countWildcards(source) {
+					return op, fmt.Errorf("destination %q has more wildcards than source %q", s, src)
+				}
+				shift.dests = append(shift.dests, dest)
+			}
+			op.shifts = append(op.shifts, shift)
+		}
+
+	case "default":
+		m, ok := spec.(map[string]interface{})
+		if !ok {
+			return op, errors.New("spec must be an object")
+		}
+		op.defaults = m
+
+	case "remove":
+		list, ok := spec.([]interface{})
+		if !ok {
+			return op, errors.New("spec must be an array of paths")
+		}
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return op, errors.New("spec must be an array of paths")
+			}
+			path, err := compileReshapePath(s)
+			if err != nil {
+				return op, err
+			}
+			if len(path) == 0 || path[len(path)-1].kind == patternWildcard {
+				return op, fmt.Errorf("remove path %q must end in a member name or index", s)
+			}
+			op.removes = append(op.removes, path)
+		}
+
+	default:
+		return op, fmt.Errorf("unknown operation %q", kind)
+	}
+	return op, nil
+}
+
+// compileReshapePath compiles a path, which may omit the leading "$".
+func compileReshapePath(path string) ([]pathPattern, error) {
+	expr := path
+	if !strings.HasPrefix(expr, "$") {
+		if strings.HasPrefix(expr, "[") {
+			expr = "$" + expr
+		} else {
+			expr = "$." + expr
+		}
+	}
+	patterns, err := compileJSONPath(expr)
+	if err != nil {
+		return nil, fmt.Errorf("path %q: %w", path, err)
+	}
+	for _, p := range patterns {
+		if p.kind == patternDescendantName || p.kind == patternDescendantAny {
+			return nil, fmt.Errorf("path %q: recursive descent is not supported", path)
+		}
+	}
+	return patterns, nil
+}
+
+// countWildcards returns the number of wildcard segments in a path.
+func countWildcards(path []pathPattern) int {
+	n := 0
+	for _, p := range path {
+		if p.kind == patternWildcard {
+			n++
+		}
+	}
+	return n
+}
+
+// Apply runs the operations on a copy of doc and returns the result.
+// doc itself is not modified.
+func (r *Reshape) Apply(doc *Document) (*Document, error) {
+	var current interface{} = deepCopyMap(doc.data)
+
+	for i, op := range r.ops {
+		var err error
+		switch op.kind {
+		case "shift":
+			current, err = applyShift(op.shifts, current)
+		case "default":
+			current = applyDefaults(current, op.defaults)
+		case "remove":
+			for _, path := range op.removes {
+				current = removePath(current, path)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("json: reshape operation %d (%s): %w", i, op.kind, err)
+		}
+	}
+
+	m, ok := current.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("json: reshape result is not an object")
+	}
+	return &Document{data: m}, nil
+}
+
+// applyShift builds a new value from the shift mappings.
+func applyShift(shifts []reshapeShift, input interface{}) (interface{}, error) {
+	var out interface{} = map[string]interface{}{}
+	var err error
+
+	for _, s := range shifts {
+		matchReshapePath(input, s.source, nil, func(v interface{}, captures []pathElem) {
+			for _, dest := range s.dests {
+				if err != nil {
+					return
+				}
+				out, err = setReshapePath(out, dest, captures, deepCopyValue(v))
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// matchReshapePath calls fn for every value matching path, along with the
+// keys or indexes matched by each wildcard.
+func matchReshapePath(v interface{}, path []pathPattern, captures []pathElem, fn func(interface{}, []pathElem)) {
+	if len(path) == 0 {
+		fn(v, captures)
+		return
+	}
+
+	seg, rest := path[0], path[1:]
+	switch val := v.(type) {
+	case map[string]interface{}:
+		switch seg.kind {
+		case patternName:
+			if child, ok := val[seg.name]; ok {
+				matchReshapePath(child, rest, captures, fn)
+			}
+		case patternWildcard:
+			keys := make([]string, 0, len(val))
+			for k := range val {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				matchReshapePath(val[k], rest, append(captures, pathElem{key: k}), fn)
+			}
+		}
+	case []interface{}:
+		switch seg.kind {
+		case patternIndex:
+			if seg.index < len(val) {
+				matchReshapePath(val[seg.index], rest, captures, fn)
+			}
+		case patternWildcard:
+			for i, elem := range val {
+				matchReshapePath(elem, rest, append(captures, pathElem{index: i, isIndex: true}), fn)
+			}
+		}
+	}
+}
+
+// setReshapePath stores value at path within container, creating objects and
+// arrays as needed, and returns the (possibly new) container.
+func setReshapePath(container interface{}, path []pathPattern, captures []pathElem, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	seg, rest := path[0], path[1:]
+	elem := pathElem{key: seg.name, index: seg.index, isIndex: seg.kind == patternIndex}
+	if seg.kind == patternWildcard {
+		elem, captures = captures[0], captures[1:]
+	}
+
+	if elem.isIndex {
+		arr, ok := container.([]interface{})
+		if container != nil && !ok {
+			return container, fmt.Errorf("cannot set index %d on a non-array value", elem.index)
+		}
+		for len(arr) <= elem.index {
+			arr = append(arr, nil)
+		}
+		child, err := setReshapePath(arr[elem.index], rest, captures, value)
+		if err != nil {
+			return container, err
+		}
+		arr[elem.index] = child
+		return arr, nil
+	}
+
+	obj, ok := container.(map[string]interface{})
+	if container == nil {
+		obj, ok = map[string]interface{}{}, true
+	}
+	if !ok {
+		return container, fmt.Errorf("cannot set member %q on a non-object value", elem.key)
+	}
+	child, err := setReshapePath(obj[elem.key], rest, captures, value)
+	if err != nil {
+		return container, err
+	}
+	obj[elem.key] = child
+	return obj, nil
+}
+
+// applyDefaults fills members of v that are missing or null from defaults.
+func applyDefaults(v interface{}, defaults map[string]interface{}) interface{} {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	for k, def := range defaults {
+		cur, exists := obj[k]
+		if !exists || cur == nil {
+			obj[k] = deepCopyValue(def)
+			continue
+		}
+		if nested, ok := def.(map[string]interface{}); ok {
+			obj[k] = applyDefaults(cur, nested)
+		}
+	}
+	return obj
+}
+
+// removePath deletes the value at path from v and returns v, which for an
+// array parent is the shortened slice.
+func removePath(v interface{}, path []pathPattern) interface{} {
+	seg := path[0]
+	last := len(path) == 1
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		switch seg.kind {
+		case patternName:
+			if last {
+				delete(val, seg.name)
+			} else if child, ok := val[seg.name]; ok {
+				val[seg.name] = removePath(child, path[1:])
+			}
+		case patternWildcard:
+			for k, child := range val {
+				val[k] = removePath(child, path[1:])
+			}
+		}
+		return val
+	case []interface{}:
+		switch seg.kind {
+		case patternIndex:
+			if seg.index >= len(val) {
+				return val
+			}
+			if last {
+				return append(val[:seg.index], val[seg.index+1:]...)
+			}
+			val[seg.index] = removePath(val[seg.index], path[1:])
+		case patternWildcard:
+			for i, child := range val {
+				val[i] = removePath(child, path[1:])
+			}
+		}
+		return val
+	}
+	return v
+}
@@ -0,0 +1,138 @@
+package json
+
+import (
+	"testing"
+)
+
+func TestReshape(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	input := `{
+		"user": {"name": "Ada", "emails": ["ada@example.com", "a@example.org"], "internal": 1},
+		"items": [{"sku": "A1", "qty": 2}, {"sku": "B2", "qty": 1}],
+		"meta": {"source": "web", "region": null}
+	}`
+
+	tests := []struct {
+		name string
+		spec string
+		want string
+	}{
+		{
+			name: "shift",
+			spec: `[{"operation": "shift", "spec": {
+				"user.name": "customer.name",
+				"user.emails[0]": "customer.email",
+				"items[*].sku": ["skus[*]", "lines[*].id"],
+				"items[*].qty": "lines[*].quantity",
+				"meta.*": "tags.*",
+				"missing.path": "never"
+			}}]`,
+			want: `{"customer":{"email":"ada@example.com","name":"Ada"},` +
+				`"lines":[{"id":"A1","quantity":2},{"id":"B2","quantity":1}],` +
+				`"skus":["A1","B2"],"tags":{"region":null,"source":"web"}}`,
+		},
+		{
+			name: "default",
+			spec: `[{"operation": "default", "spec": {"meta": {"region": "eu", "version": 1}, "flags": []}}]`,
+			want: `{"flags":[],"items":[{"qty":2,"sku":"A1"},{"qty":1,"sku":"B2"}],` +
+				`"meta":{"region":"eu","source":"web","version":1},` +
+				`"user":{"emails":["ada@example.com","a@example.org"],"internal":1,"name":"Ada"}}`,
+		},
+		{
+			name: "remove",
+			spec: `[{"operation": "remove", "spec": ["user.internal", "user.emails[0]", "items[*].qty", "meta", "nope.x"]}]`,
+			want: `{"items":[{"sku":"A1"},{"sku":"B2"}],"user":{"emails":["a@example.org"],"name":"Ada"}}`,
+		},
+		{
+			name: "chain",
+			spec: `[
+				{"operation": "shift", "spec": {"$.user['name']": "name", "items[1]": "last"}},
+				{"operation": "default", "spec": {"name": "unknown", "country": "FR"}},
+				{"operation": "remove", "spec": ["last.qty"]}
+			]`,
+			want: `{"country":"FR","last":{"sku":"B2"},"name":"Ada"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseDocument(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := CompileReshape([]byte(tt.spec))
+			if err != nil {
+				t.Fatalf("CompileReshape() error = %v", err)
+			}
+			out, err := r.Apply(doc)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			got, err := Marshal(out.ToMap())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Apply() =\n%s\nwant\n%s", got, tt.want)
+			}
+
+			// The input document is left untouched
+			if name, _ := doc.GetObject("user"); !name.Has("internal") {
+				t.Error("Apply() modified its input")
+			}
+		})
+	}
+}
+
+func TestCompileReshape_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+	}{
+		{"not an array", `{"operation": "shift"}`},
+		{"unknown operation", `[{"operation": "rename", "spec": {}}]`},
+		{"shift spec type", `[{"operation": "shift", "spec": []}]`},
+		{"shift destination type", `[{"operation": "shift", "spec": {"a": 1}}]`},
+		{"shift destination list type", `[{"operation": "shift", "spec": {"a": ["b", 2]}}]`},
+		{"shift root destination", `[{"operation": "shift", "spec": {"a": "$"}}]`},
+		{"extra destination wildcard", `[{"operation": "shift", "spec": {"a": "b[*]"}}]`},
+		{"recursive descent", `[{"operation": "shift", "spec": {"$..a": "b"}}]`},
+		{"bad path", `[{"operation": "shift", "spec": {"a[": "b"}}]`},
+		{"default spec type", `[{"operation": "default", "spec": "x"}]`},
+		{"remove spec type", `[{"operation": "remove", "spec": {"a": 1}}]`},
+		{"remove item type", `[{"operation": "remove", "spec": [1]}]`},
+		{"remove wildcard", `[{"operation": "remove", "spec": ["a[*]"]}]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := CompileReshape([]byte(tt.spec)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestReshape_ApplyErrors(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	doc, _ := ParseDocument(`{"a": 1, "b": [1]}`)
+
+	r, err := CompileReshape([]byte(`[{"operation": "shift", "spec": {"a": "x", "b[0]": "x.y"}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Apply(doc); err == nil {
+		t.Error("expected error setting a member on a number")
+	}
+
+	r, err = CompileReshape([]byte(`[{"operation": "shift", "spec": {"a": "x", "b[0]": "x[0]"}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Apply(doc); err == nil {
+		t.Error("expected error setting an index on a number")
+	}
+}
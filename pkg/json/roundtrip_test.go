@@ -103,6 +103,9 @@ func checkArraysAreArrays(t *testing.T, path string, original, result interface{
 
 // TestEmptyArrayJSONRepresentation verifies that empty arrays render as [] not {}
 func TestEmptyArrayJSONRepresentation(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
 	tests := []struct {
 		name     string
 		input    interface{}
@@ -151,6 +154,9 @@ func TestEmptyArrayJSONRepresentation(t *testing.T) {
 
 // TestArrayTypeFidelity tests that []interface{} and map[string]interface{} are never confused
 func TestArrayTypeFidelity(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
 	// Create a structure with both empty arrays and empty objects
 	data := struct {
 		EmptyArray  []interface{}          `json:"emptyArray"`
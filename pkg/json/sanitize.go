@@ -0,0 +1,317 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"encoding"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Warning describes one change Sanitize made to a value.
+type Warning struct {
+	// Path is the JSON Pointer (RFC 6901) of the changed value. The root
+	// is "".
+	Path    string
+	Message string
+}
+
+// String formats the warning as "path: message", using "(root)" for the
+// root.
+func (w Warning) String() string {
+	path := w.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + w.Message
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// Sanitize returns a copy of v that Marshal can encode, and a Warning for
+// each change it made. It is meant for values assembled at run time, such
+// as interface{} trees built from several sources, that may hold what
+// Marshal rejects:
+//
+//   - NaN and infinite floats become null
+//   - channels, funcs, complex numbers, and unsafe pointers are removed
+//     from objects, and become null in arrays
+//   - a reference back to a map, slice, or pointer that encloses it, for
+//     which Marshal returns a *CycleError, becomes null
+//   - map keys that are integers or implement encoding.TextMarshaler
+//     become strings; entries with other keys are removed
+//
+// Only what changes is copied. A map, slice, or struct with nothing to
+// change inside is returned as is; one that has becomes a
+// map[string]interface{} or []interface{}, and a struct becomes a map of
+// its members by JSON name, honoring "-", omitempty, string, and unknown
+// in its tags but no longer its member order. Values with their own
+// MarshalJSON, time.Time, and sql.Null types are kept as they are.
+//
+// The warnings come in a stable order, with map entries visited by key,
+// so they can be logged or compared in tests:
+//
+//	clean, warnings := json.Sanitize(payload)
+//	for _, w := range warnings {
+//	    log.Printf("sanitize: %s", w) // /stats/ratio: NaN replaced by null
+//	}
+//	data, err := json.Marshal(clean)
+func Sanitize(v interface{}) (interface{}, []Warning) {
+	s := &sanitizer{active: map[visit]bool{}}
+	out, _, keep := s.value(reflect.ValueOf(v), "", false)
+	if !keep {
+		out = nil
+	}
+	return out, s.warnings
+}
+
+// sanitizer walks a value for Sanitize.
+type sanitizer struct {
+	warnings []Warning
+	active   map[visit]bool // containers enclosing the value being walked
+}
+
+// visit identifies a map, slice, or pointer being walked, to detect
+// cycles. Slices sharing an array differ by length.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+func (s *sanitizer) warn(path, msg string) {
+	s.warnings = append(s.warnings, Warning{Path: path, Message: msg})
+}
+
+// value returns the sanitized form of v, found at path; whether it differs
+// from v; and false if v is to be removed from the object holding it.
+// inArray tells whether v is an array element, which is nulled rather
+// than removed.
+func (s *sanitizer) value(v reflect.Value, path string, inArray bool) (out interface{}, changed, keep bool) {
+	if !v.IsValid() {
+		return nil, false, true
+	}
+	t := v.Type()
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) ||
+		t == timeType || t == durationType || fastparser.IsSQLNull(t) {
+		return v.Interface(), false, true
+	}
+
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			s.warn(path, strconv.FormatFloat(f, 'g', -1, 64)+" replaced by null")
+			return nil, true, true
+		}
+
+	case reflect.Complex64, reflect.Complex128, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if inArray {
+			s.warn(path, "unsupported type "+t.String()+" replaced by null")
+			return nil, true, true
+		}
+		s.warn(path, "unsupported type "+t.String()+" removed")
+		return nil, true, false
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, false, true
+		}
+		out, changed, keep := s.value(v.Elem(), path, inArray)
+		if !changed {
+			return v.Interface(), false, true
+		}
+		return out, true, keep
+
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil, false, true
+		}
+		if !s.enter(v, path) {
+			return nil, true, true
+		}
+		defer s.leave(v)
+		out, changed, keep := s.value(v.Elem(), path, inArray)
+		if !changed {
+			return v.Interface(), false, true
+		}
+		return out, true, keep
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v.Interface(), false, true
+		}
+		if !s.enter(v, path) {
+			return nil, true, true
+		}
+		defer s.leave(v)
+		return s.mapValue(v, path)
+
+	case reflect.Slice:
+		if v.IsNil() || t.Elem().Kind() == reflect.Uint8 {
+			return v.Interface(), false, true
+		}
+		if !s.enter(v, path) {
+			return nil, true, true
+		}
+		defer s.leave(v)
+		return s.arrayValue(v, path)
+
+	case reflect.Array:
+		return s.arrayValue(v, path)
+
+	case reflect.Struct:
+		return s.structValue(v, path)
+	}
+	return v.Interface(), false, true
+}
+
+// enter marks the container v, found at path, as being walked, or reports
+// false, with a warning, if it already is: v then refers back to a value
+// that encloses it.
+func (s *sanitizer) enter(v reflect.Value, path string) bool {
+	key := visit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	if s.active[key] {
+		s.warn(path, "cycle: reference to an enclosing value replaced by null")
+		return false
+	}
+	s.active[key] = true
+	return true
+}
+
+func (s *sanitizer) leave(v reflect.Value) {
+	key := visit{ptr: v.Pointer(), typ: v.Type()}
+	if v.Kind() == reflect.Slice {
+		key.len = v.Len()
+	}
+	delete(s.active, key)
+}
+
+// mapValue sanitizes the entries of the map v in the order of their keys.
+func (s *sanitizer) mapValue(v reflect.Value, path string) (interface{}, bool, bool) {
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	entries := make([]entry, 0, v.Len())
+	changed := false
+	iter := v.MapRange()
+	for iter.Next() {
+		key, ok, converted := mapKeyString(iter.Key())
+		if !ok {
+			s.warn(path, "map key of type "+iter.Key().Type().String()+" cannot be written; entry removed")
+			changed = true
+			continue
+		}
+		if converted && !changed {
+			s.warn(path, "map keys of type "+iter.Key().Type().String()+" written as strings")
+		}
+		changed = changed || converted
+		entries = append(entries, entry{key, iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	out := make(map[string]interface{}, len(entries))
+	for _, e := range entries {
+		value, valueChanged, keep := s.value(e.value, path+"/"+escapePointerToken(e.key), false)
+		changed = changed || valueChanged
+		if keep {
+			out[e.key] = value
+		}
+	}
+	if !changed {
+		return v.Interface(), false, true
+	}
+	return out, true, true
+}
+
+// mapKeyString returns the member name for a map key, whether there is
+// one, and whether it had to be converted from a key that is not a
+// string.
+func mapKeyString(k reflect.Value) (name string, ok, converted bool) {
+	if k.Kind() == reflect.String {
+		return k.String(), true, false
+	}
+	if k.Type().Implements(textMarshalerType) {
+		text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil, true
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), true, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), true, true
+	}
+	return "", false, true
+}
+
+// arrayValue sanitizes the elements of the slice or array v.
+func (s *sanitizer) arrayValue(v reflect.Value, path string) (interface{}, bool, bool) {
+	out := make([]interface{}, v.Len())
+	changed := false
+	for i := range out {
+		var elemChanged bool
+		out[i], elemChanged, _ = s.value(v.Index(i), path+"/"+strconv.Itoa(i), true)
+		changed = changed || elemChanged
+	}
+	if !changed {
+		return v.Interface(), false, true
+	}
+	return out, true, true
+}
+
+// structValue sanitizes the exported fields of the struct v.
+func (s *sanitizer) structValue(v reflect.Value, path string) (interface{}, bool, bool) {
+	t := v.Type()
+	out := map[string]interface{}{}
+	changed := false
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		info := getFieldInfo(sf)
+		field := v.Field(i)
+		if info.skip || info.omitEmpty && isEmptyValue(field) {
+			continue
+		}
+		value, fieldChanged, keep := s.value(field, path+"/"+escapePointerToken(info.name), false)
+		changed = changed || fieldChanged
+		if !keep {
+			continue
+		}
+		if info.unknown {
+			// The members of an unknown map are written inline
+			if members := reflect.ValueOf(value); members.Kind() == reflect.Map {
+				iter := members.MapRange()
+				for iter.Next() {
+					if k := iter.Key().String(); out[k] == nil {
+						out[k] = iter.Value().Interface()
+					}
+				}
+			}
+			continue
+		}
+		if info.asString {
+			switch reflect.ValueOf(value).Kind() {
+			case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+				if data, err := Marshal(value); err == nil {
+					value = string(data)
+				}
+			}
+		}
+		out[info.name] = value
+	}
+	if !changed {
+		return v.Interface(), false, true
+	}
+	return out, true, true
+}
@@ -0,0 +1,148 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"math"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type sanitizeNode struct {
+	Name     string        `json:"name"`
+	Next     *sanitizeNode `json:"next"`
+	Ratio    float64       `json:"ratio,string"`
+	Skip     float64       `json:"-"`
+	Optional float64       `json:"optional,omitempty"`
+	OnDone   func()        `json:"onDone"`
+}
+
+type sanitizeClean struct {
+	B int       `json:"b"`
+	A time.Time `json:"a"`
+}
+
+func TestSanitize(t *testing.T) {
+	self := map[string]interface{}{"name": "root"}
+	self["self"] = self
+	list := []interface{}{1, nil}
+	list[1] = list
+	node := &sanitizeNode{Name: "a", Ratio: 0.5, Skip: math.NaN()}
+	node.Next = node
+
+	tests := []struct {
+		name     string
+		in       interface{}
+		want     string
+		warnings []string
+	}{
+		{
+			name:     "floats",
+			in:       map[string]interface{}{"nan": math.NaN(), "inf": []float64{1, math.Inf(-1)}, "ok": 2.5},
+			want:     `{"inf":[1,null],"nan":null,"ok":2.5}`,
+			warnings: []string{"/inf/1: -Inf replaced by null", "/nan: NaN replaced by null"},
+		},
+		{
+			name:     "unsupported types",
+			in:       map[string]interface{}{"ch": make(chan int), "list": []interface{}{func() {}, complex(1, 2)}, "n": 1},
+			want:     `{"list":[null,null],"n":1}`,
+			warnings: []string{"/ch: unsupported type chan int removed", "/list/0: unsupported type func() replaced by null", "/list/1: unsupported type complex128 replaced by null"},
+		},
+		{
+			name:     "map cycle",
+			in:       self,
+			want:     `{"name":"root","self":null}`,
+			warnings: []string{"/self: cycle: reference to an enclosing value replaced by null"},
+		},
+		{
+			name:     "slice cycle",
+			in:       list,
+			want:     `[1,null]`,
+			warnings: []string{"/1: cycle: reference to an enclosing value replaced by null"},
+		},
+		{
+			name: "struct cycle",
+			in:   node,
+			want: `{"name":"a","next":null,"ratio":"0.5"}`,
+			warnings: []string{
+				"/next: cycle: reference to an enclosing value replaced by null",
+				"/onDone: unsupported type func() removed",
+			},
+		},
+		{
+			name:     "map keys",
+			in:       map[int]string{2: "b", 10: "a"},
+			want:     `{"10":"a","2":"b"}`,
+			warnings: []string{"(root): map keys of type int written as strings"},
+		},
+		{
+			name:     "text keys",
+			in:       map[netip.Addr]bool{netip.MustParseAddr("10.0.0.1"): true},
+			want:     `{"10.0.0.1":true}`,
+			warnings: []string{"(root): map keys of type netip.Addr written as strings"},
+		},
+		{
+			name:     "unwritable keys",
+			in:       map[[2]int]int{{1, 2}: 3},
+			want:     `{}`,
+			warnings: []string{"(root): map key of type [2]int cannot be written; entry removed"},
+		},
+		{
+			name:     "root",
+			in:       math.Inf(1),
+			want:     `null`,
+			warnings: []string{"(root): +Inf replaced by null"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, warnings := Sanitize(tt.in)
+			data, err := Marshal(out)
+			if err != nil {
+				t.Fatalf("Marshal(Sanitize()) error = %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("Sanitize() = %s, want %s", data, tt.want)
+			}
+			got := make([]string, len(warnings))
+			for i, w := range warnings {
+				got[i] = w.String()
+			}
+			if !reflect.DeepEqual(got, tt.warnings) {
+				t.Errorf("warnings = %q, want %q", got, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestSanitize_Unchanged(t *testing.T) {
+	shared := []int{1, 2}
+	clean := map[string]interface{}{
+		"a":      shared,
+		"b":      shared, // shared, not a cycle
+		"struct": sanitizeClean{B: 1},
+		"bytes":  []byte("x"),
+		"nil":    nil,
+	}
+	out, warnings := Sanitize(clean)
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v", warnings)
+	}
+	if reflect.ValueOf(out).Pointer() != reflect.ValueOf(clean).Pointer() {
+		t.Error("Sanitize copied a value with nothing to change")
+	}
+	if v := &(sanitizeClean{B: 2}); !reflect.DeepEqual(mustSanitize(t, v), v) {
+		t.Error("Sanitize changed a clean struct pointer")
+	}
+}
+
+func mustSanitize(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	out, warnings := Sanitize(v)
+	if len(warnings) != 0 {
+		t.Fatalf("warnings = %v", warnings)
+	}
+	return out
+}
@@ -0,0 +1,432 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// IssueKind classifies the problems reported by CheckShape.
+type IssueKind int
+
+const (
+	// IssueSyntax means the input is not valid JSON. It is always the last
+	// issue reported, because checking stops at the first syntax error.
+	IssueSyntax IssueKind = iota
+	// IssueTypeMismatch means a value cannot be decoded into the Go type at
+	// its location.
+	IssueTypeMismatch
+	// IssueMissingField means a required struct field has no member.
+	IssueMissingField
+	// IssueUnknownField means an object member matches no struct field.
+	IssueUnknownField
+)
+
+// String returns a short name for the kind, such as "type mismatch".
+func (k IssueKind) String() string {
+	switch k {
+	case IssueSyntax:
+		return "syntax error"
+	case IssueTypeMismatch:
+		return "type mismatch"
+	case IssueMissingField:
+		return "missing field"
+	case IssueUnknownField:
+		return "unknown field"
+	default:
+		return "IssueKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// Issue is one problem found by CheckShape.
+type Issue struct {
+	// Path is the JSON Pointer (RFC 6901) of the offending value, or of the
+	// object that lacks a required member. The root is "".
+	Path    string
+	Kind    IssueKind
+	Message string
+}
+
+// Error formats the issue as "path: message", using "(root)" for the
+// document root, so an Issue can be returned where an error is expected.
+func (i Issue) Error() string {
+	path := i.Path
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + i.Message
+}
+
+// CheckShape reports every way data fails to fit the Go type of prototype,
+// without decoding it. It is meant for validating requests up front and
+// returning the complete list of problems to the caller, rather than
+// stopping at the first one as Unmarshal does.
+//
+// prototype is only used for its type; a nil pointer such as (*Request)(nil)
+// works as well as a value. The rules follow Unmarshal:
+//
+//   - a value whose JSON type cannot be stored in the Go type is a type
+//     mismatch, as is a number that overflows or is not integral for an
+//     integer type;
+//   - a struct field tagged with the required option, or that is not a
+//     pointer, not tagged omitempty, and not excluded with "-", is required,
+//     and its absence is a missing field;
+//   - an object member that matches no struct field is an unknown field;
+//   - null is accepted anywhere, and types implementing Unmarshaler accept
+//     any value.
+//
+// Checking continues past mismatches, missing and unknown fields, but stops
+// at a syntax error, which is reported as the final IssueSyntax issue.
+// A nil result means Unmarshal into the prototype's type would succeed,
+// apart from errors returned by custom UnmarshalJSON methods.
+//
+// Example:
+//
+//	if issues := json.CheckShape(body, CreateUserRequest{}); len(issues) > 0 {
+//	    for _, issue := range issues {
+//	        log.Printf("%s: %s", issue.Kind, issue)
+//	    }
+//	}
+func CheckShape(data []byte, prototype interface{}) []Issue {
+	t := reflect.TypeOf(prototype)
+	if t == nil {
+		t = reflect.TypeOf((*interface{})(nil)).Elem()
+	}
+
+	c := &shapeChecker{data: data}
+	pos, err := c.value(skipSpace(data, 0), t)
+	if err == nil {
+		if pos = skipSpace(data, pos); pos < len(data) {
+			err = fmt.Errorf("unexpected data after JSON value at position %d", pos)
+		}
+	}
+	if err != nil {
+		c.issues = append(c.issues, Issue{Path: c.pointer(), Kind: IssueSyntax, Message: err.Error()})
+	}
+	return c.issues
+}
+
+var unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// shapeChecker walks a document alongside a Go type, recording issues.
+type shapeChecker struct {
+	data   []byte
+	path   []string // unescaped reference tokens of the current location
+	issues []Issue
+}
+
+// pointer returns the JSON Pointer of the current location.
+func (c *shapeChecker) pointer() string {
+	var b strings.Builder
+	for _, tok := range c.path {
+		b.WriteByte('/')
+		b.WriteString(escapePointerToken(tok))
+	}
+	return b.String()
+}
+
+// report records an issue at the current location.
+func (c *shapeChecker) report(kind IssueKind, format string, args ...interface{}) {
+	c.issues = append(c.issues, Issue{Path: c.pointer(), Kind: kind, Message: fmt.Sprintf(format, args...)})
+}
+
+// mismatch records a type mismatch for the value at pos and skips it.
+func (c *shapeChecker) mismatch(pos int, t reflect.Type) (int, error) {
+	end, err := fastparser.SkipValue(c.data, pos)
+	if err != nil {
+		return end, err
+	}
+	c.report(IssueTypeMismatch, "cannot unmarshal %s into Go value of type %s", jsonTypeAt(c.data[pos]), t)
+	return end, nil
+}
+
+// value checks the value at pos against t and returns the offset after it.
+// On a syntax error the path is left pointing at the offending value.
+func (c *shapeChecker) value(pos int, t reflect.Type) (int, error) {
+	if pos >= len(c.data) {
+		return pos, errors.New("unexpected end of JSON input")
+	}
+	ch := c.data[pos]
+
+	if ch == 'n' || t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return fastparser.SkipValue(c.data, pos)
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if reflect.PointerTo(t).Implements(unmarshalerType) {
+			return fastparser.SkipValue(c.data, pos)
+		}
+	}
+	if t.Kind() == reflect.Interface {
+		if t.NumMethod() == 0 {
+			return fastparser.SkipValue(c.data, pos)
+		}
+		return c.mismatch(pos, t)
+	}
+
+	if (ch == '{' || ch == '[') && len(c.path) >= fastparser.MaxDepth {
+		return pos, fastparser.DepthError(pos)
+	}
+	switch ch {
+	case '{':
+		switch {
+		case t.Kind() == reflect.Struct:
+			return c.object(pos, t)
+		case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String:
+			return c.object(pos, t)
+		}
+	case '[':
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			return c.array(pos, t)
+		}
+	case '"':
+		if t.Kind() == reflect.String {
+			return fastparser.SkipValue(c.data, pos)
+		}
+	case 't', 'f':
+		if t.Kind() == reflect.Bool {
+			return fastparser.SkipValue(c.data, pos)
+		}
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		end, err := fastparser.SkipValue(c.data, pos)
+		if err != nil {
+			return end, err
+		}
+		if msg := checkNumber(string(c.data[pos:end]), t); msg != "" {
+			c.report(IssueTypeMismatch, "%s", msg)
+		}
+		return end, nil
+	default:
+		return pos, fmt.Errorf("unexpected character '%c' at position %d", ch, pos)
+	}
+	return c.mismatch(pos, t)
+}
+
+// object checks the members of the object at pos against a struct or map type.
+func (c *shapeChecker) object(pos int, t reflect.Type) (int, error) {
+	var fields []shapeField
+	var seen []bool
+	var extra reflect.Type // values of members that match no field, if kept
+	if t.Kind() == reflect.Struct {
+		fields = shapeFields(t)
+		seen = make([]bool, len(fields))
+		extra = unknownFieldType(t)
+	}
+
+	pos = skipSpace(c.data, pos+1)
+	if pos < len(c.data) && c.data[pos] == '}' {
+		c.missing(fields, seen)
+		return pos + 1, nil
+	}
+
+	for {
+		if pos >= len(c.data) || c.data[pos] != '"' {
+			return pos, errors.New("expected string key in object")
+		}
+		keyEnd, err := fastparser.SkipValue(c.data, pos)
+		if err != nil {
+			return keyEnd, err
+		}
+		key, err := decodeKey(c.data[pos:keyEnd])
+		if err != nil {
+			return pos, err
+		}
+
+		pos = skipSpace(c.data, keyEnd)
+		if pos >= len(c.data) || c.data[pos] != ':' {
+			return pos, errors.New("expected ':' after object key")
+		}
+		pos = skipSpace(c.data, pos+1)
+
+		c.path = append(c.path, key)
+		if t.Kind() == reflect.Map {
+			pos, err = c.value(pos, t.Elem())
+		} else if i := findShapeField(fields, key); i >= 0 {
+			seen[i] = true
+			pos, err = c.value(pos, fields[i].typ)
+		} else if extra != nil {
+			pos, err = c.value(pos, extra)
+		} else {
+			c.report(IssueUnknownField, "unknown field %q in Go value of type %s", key, t)
+			pos, err = fastparser.SkipValue(c.data, pos)
+		}
+		if err != nil {
+			return pos, err
+		}
+		c.path = c.path[:len(c.path)-1]
+
+		pos = skipSpace(c.data, pos)
+		if pos >= len(c.data) {
+			return pos, errors.New("unexpected end of JSON input in object")
+		}
+		switch c.data[pos] {
+		case '}':
+			c.missing(fields, seen)
+			return pos + 1, nil
+		case ',':
+			pos = skipSpace(c.data, pos+1)
+		default:
+			return pos, fmt.Errorf("expected ',' or '}' in object at position %d", pos)
+		}
+	}
+}
+
+// missing reports the required fields that were not seen.
+func (c *shapeChecker) missing(fields []shapeField, seen []bool) {
+	for i, f := range fields {
+		if f.required && !seen[i] {
+			c.report(IssueMissingField, "missing required field %q", f.name)
+		}
+	}
+}
+
+// array checks the elements of the array at pos against a slice or array type.
+func (c *shapeChecker) array(pos int, t reflect.Type) (int, error) {
+	pos = skipSpace(c.data, pos+1)
+	if pos < len(c.data) && c.data[pos] == ']' {
+		return pos + 1, nil
+	}
+
+	for i := 0; ; i++ {
+		var err error
+		c.path = append(c.path, strconv.Itoa(i))
+		if t.Kind() == reflect.Array && i == t.Len() {
+			c.report(IssueTypeMismatch, "array length exceeds target array length %d", t.Len())
+		}
+		if t.Kind() == reflect.Array && i >= t.Len() {
+			pos, err = fastparser.SkipValue(c.data, pos)
+		} else {
+			pos, err = c.value(pos, t.Elem())
+		}
+		if err != nil {
+			return pos, err
+		}
+		c.path = c.path[:len(c.path)-1]
+
+		pos = skipSpace(c.data, pos)
+		if pos >= len(c.data) {
+			return pos, errors.New("unexpected end of JSON input in array")
+		}
+		switch c.data[pos] {
+		case ']':
+			return pos + 1, nil
+		case ',':
+			pos = skipSpace(c.data, pos+1)
+		default:
+			return pos, fmt.Errorf("expected ',' or ']' in array at position %d", pos)
+		}
+	}
+}
+
+// shapeField describes how CheckShape treats one struct field.
+type shapeField struct {
+	name     string
+	typ      reflect.Type
+	required bool
+}
+
+// shapeFields lists the decodable fields of a struct type.
+func shapeFields(t reflect.Type) []shapeField {
+	fields := make([]shapeField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		info := getFieldInfo(field)
+		if info.skip || info.unknown {
+			continue
+		}
+		fields = append(fields, shapeField{
+			name:     info.name,
+			typ:      field.Type,
+			required: info.required || (!info.omitEmpty && field.Type.Kind() != reflect.Ptr),
+		})
+	}
+	return fields
+}
+
+// unknownFieldType returns the value type of the map field of struct type t
+// with the "unknown" option, or nil if it has none.
+func unknownFieldType(t reflect.Type) reflect.Type {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath == "" && getFieldInfo(field).unknown {
+			return field.Type.Elem()
+		}
+	}
+	return nil
+}
+
+// findShapeField returns the index of the field named key, or -1.
+func findShapeField(fields []shapeField, key string) int {
+	for i := range fields {
+		if fields[i].name == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkNumber reports why the JSON number raw cannot be stored in t, or ""
+// if it can.
+func checkNumber(raw string, t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(raw, 64)
+			if ferr != nil || f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+				return fmt.Sprintf("cannot unmarshal number %s into Go value of type %s", raw, t)
+			}
+			i = int64(f)
+		}
+		if bits := uint(t.Bits()); bits < 64 && (i < -1<<(bits-1) || i >= 1<<(bits-1)) {
+			return fmt.Sprintf("value %s overflows %s", raw, t)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			f, ferr := strconv.ParseFloat(raw, 64)
+			if ferr != nil || f < 0 || f != math.Trunc(f) || f >= math.MaxUint64 {
+				return fmt.Sprintf("cannot unmarshal number %s into Go value of type %s", raw, t)
+			}
+			u = uint64(f)
+		}
+		if bits := uint(t.Bits()); bits < 64 && u >= 1<<bits {
+			return fmt.Sprintf("value %s overflows %s", raw, t)
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(raw, t.Bits()); err != nil {
+			return fmt.Sprintf("value %s overflows %s", raw, t)
+		}
+	default:
+		return fmt.Sprintf("cannot unmarshal number into Go value of type %s", t)
+	}
+	return ""
+}
+
+// jsonTypeAt names the JSON type of a value from its first byte.
+func jsonTypeAt(c byte) string {
+	switch c {
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case '"':
+		return "string"
+	case 't', 'f':
+		return "bool"
+	case 'n':
+		return "null"
+	default:
+		return "number"
+	}
+}
@@ -0,0 +1,167 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"reflect"
+	"testing"
+)
+
+type shapeAddress struct {
+	Street string `json:"street"`
+	Zip    string `json:"zip,omitempty"`
+}
+
+type shapeUser struct {
+	Name     string            `json:"name"`
+	Age      int8              `json:"age"`
+	Email    *string           `json:"email"`
+	Tags     []string          `json:"tags,omitempty"`
+	Address  shapeAddress      `json:"address"`
+	Scores   [2]float64        `json:"scores,omitempty"`
+	Meta     map[string]int    `json:"meta,omitempty"`
+	Extra    interface{}       `json:"extra,omitempty"`
+	Count    uint16            `json:"count,omitempty"`
+	Raw      upperText         `json:"raw,omitempty"`
+	Ignored  string            `json:"-"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	internal int
+}
+
+func TestCheckShape(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Issue
+	}{
+		{
+			name:  "valid",
+			input: `{"name":"Ada","age":36,"address":{"street":"Main"},"tags":["a"],"scores":[1,2.5],"meta":{"x":1},"extra":[1,{}],"count":7,"raw":"x"}`,
+		},
+		{
+			name:  "null accepted anywhere",
+			input: `{"name":null,"age":null,"address":null,"email":null}`,
+		},
+		{
+			name:  "missing required fields",
+			input: `{"address":{}}`,
+			want: []Issue{
+				{Path: "/address", Kind: IssueMissingField, Message: `missing required field "street"`},
+				{Path: "", Kind: IssueMissingField, Message: `missing required field "name"`},
+				{Path: "", Kind: IssueMissingField, Message: `missing required field "age"`},
+			},
+		},
+		{
+			name:  "type mismatches",
+			input: `{"name":1,"age":"x","address":[],"tags":[1,"ok",true],"email":false}`,
+			want: []Issue{
+				{Path: "/name", Kind: IssueTypeMismatch, Message: "cannot unmarshal number into Go value of type string"},
+				{Path: "/age", Kind: IssueTypeMismatch, Message: "cannot unmarshal string into Go value of type int8"},
+				{Path: "/address", Kind: IssueTypeMismatch, Message: "cannot unmarshal array into Go value of type json.shapeAddress"},
+				{Path: "/tags/0", Kind: IssueTypeMismatch, Message: "cannot unmarshal number into Go value of type string"},
+				{Path: "/tags/2", Kind: IssueTypeMismatch, Message: "cannot unmarshal bool into Go value of type string"},
+				{Path: "/email", Kind: IssueTypeMismatch, Message: "cannot unmarshal bool into Go value of type string"},
+			},
+		},
+		{
+			name:  "numbers",
+			input: `{"name":"a","age":300,"address":{"street":"s"},"meta":{"a/b":1.5,"c":2.0},"count":-1}`,
+			want: []Issue{
+				{Path: "/age", Kind: IssueTypeMismatch, Message: "value 300 overflows int8"},
+				{Path: "/meta/a~1b", Kind: IssueTypeMismatch, Message: "cannot unmarshal number 1.5 into Go value of type int"},
+				{Path: "/count", Kind: IssueTypeMismatch, Message: "cannot unmarshal number -1 into Go value of type uint16"},
+			},
+		},
+		{
+			name:  "unknown fields",
+			input: `{"name":"a","age":1,"address":{"street":"s","city":"x"},"Ignored":"y","internal":2}`,
+			want: []Issue{
+				{Path: "/address/city", Kind: IssueUnknownField, Message: `unknown field "city" in Go value of type json.shapeAddress`},
+				{Path: "/Ignored", Kind: IssueUnknownField, Message: `unknown field "Ignored" in Go value of type json.shapeUser`},
+				{Path: "/internal", Kind: IssueUnknownField, Message: `unknown field "internal" in Go value of type json.shapeUser`},
+			},
+		},
+		{
+			name:  "fixed array too long",
+			input: `{"name":"a","age":1,"address":{"street":"s"},"scores":[1,2,3,4]}`,
+			want: []Issue{
+				{Path: "/scores/2", Kind: IssueTypeMismatch, Message: "array length exceeds target array length 2"},
+			},
+		},
+		{
+			name:  "syntax error stops checking",
+			input: `{"name":1,"age":}`,
+			want: []Issue{
+				{Path: "/name", Kind: IssueTypeMismatch, Message: "cannot unmarshal number into Go value of type string"},
+				{Path: "/age", Kind: IssueSyntax, Message: "unexpected character '}' at position 16"},
+			},
+		},
+		{
+			name:  "trailing data",
+			input: `{"name":"a","age":1,"address":{"street":"s"}} x`,
+			want: []Issue{
+				{Path: "", Kind: IssueSyntax, Message: "unexpected data after JSON value at position 46"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckShape([]byte(tt.input), shapeUser{})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckShape() =\n%v\nwant\n%v", got, tt.want)
+			}
+
+			// A clean report must agree with Unmarshal.
+			if len(got) == 0 {
+				var u shapeUser
+				if err := Unmarshal([]byte(tt.input), &u); err != nil {
+					t.Errorf("CheckShape found no issues but Unmarshal failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckShape_RequiredTag(t *testing.T) {
+	type request struct {
+		Token *string `json:"token,required"`
+		Limit int     `json:"limit,omitempty,required"`
+	}
+	got := CheckShape([]byte(`{}`), request{})
+	want := []Issue{
+		{Path: "", Kind: IssueMissingField, Message: `missing required field "token"`},
+		{Path: "", Kind: IssueMissingField, Message: `missing required field "limit"`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckShape() = %v, want %v", got, want)
+	}
+}
+
+func TestCheckShape_Prototypes(t *testing.T) {
+	if issues := CheckShape([]byte(`{"name":"a","age":1,"address":{"street":"s"}}`), (*shapeUser)(nil)); issues != nil {
+		t.Errorf("nil pointer prototype: got %v", issues)
+	}
+	if issues := CheckShape([]byte(`[1,2]`), []int(nil)); issues != nil {
+		t.Errorf("slice prototype: got %v", issues)
+	}
+	if issues := CheckShape([]byte(`{"a":[true]}`), nil); issues != nil {
+		t.Errorf("nil prototype: got %v", issues)
+	}
+	issues := CheckShape([]byte(`{"a":1}`), map[int]string{})
+	if len(issues) != 1 || issues[0].Kind != IssueTypeMismatch {
+		t.Errorf("map with int keys: got %v", issues)
+	}
+}
+
+func TestIssue_Error(t *testing.T) {
+	if got := (Issue{Message: "boom"}).Error(); got != "(root): boom" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := (Issue{Path: "/a/0", Message: "boom"}).Error(); got != "/a/0: boom" {
+		t.Errorf("Error() = %q", got)
+	}
+	if got := IssueUnknownField.String(); got != "unknown field" {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,440 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"bytes"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// EstimateSize returns the length in bytes of Marshal(v) without building
+// it, so that a server can set Content-Length, choose whether to compress,
+// or reject an oversized response before encoding it:
+//
+//	n, err := json.EstimateSize(resp)
+//	if err != nil {
+//	    return err
+//	}
+//	if n > maxResponse {
+//	    return errTooLarge
+//	}
+//	w.Header().Set("Content-Length", strconv.Itoa(n))
+//
+// The length is exact. Strings, numbers, maps, slices, and structs are
+// measured as they are walked; values that Marshal writes by calling a
+// method or a special-purpose encoder, such as Marshaler implementations,
+// time.Time, Number, and structs with string or transform options, are
+// encoded alone into a scratch buffer that is reused, so memory use is
+// bounded by the largest of them rather than by the output. EstimateSize
+// returns the error Marshal would, for example for a channel or a cycle.
+func EstimateSize(v interface{}) (int, error) {
+	s := &sizer{}
+	// Marshal dereferences the pointers to the root value itself, and
+	// finishCycle records them
+	for rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && !rv.IsNil(); rv = rv.Elem() {
+		s.rootPtrs++
+	}
+	n, err := s.any(v, 0)
+	if err != nil {
+		finishCycle(err, v)
+		return 0, err
+	}
+	return n, nil
+}
+
+// EstimateJSONSize returns the length in bytes of the text JSON returns for
+// d, without building it. It returns the error JSON would for a value that
+// cannot be encoded.
+func (d *Document) EstimateJSONSize() (int, error) {
+	return renderSize(d.data)
+}
+
+// EstimateJSONSize returns the length in bytes of the text JSON returns for
+// a, without building it. It returns the error JSON would for a value that
+// cannot be encoded.
+func (a *Array) EstimateJSONSize() (int, error) {
+	return renderSize(a.data)
+}
+
+// sizer measures the output of Marshal. Like the encoders, it records the
+// containers a *CycleError unwinds through, for finishCycle.
+type sizer struct {
+	scratch  []byte // for encoding leaves
+	rootPtrs uint   // the depth below the pointers to the root value
+}
+
+// any measures v as appendInterface would encode it, falling back to the
+// reflection walk for the types it does not handle.
+func (s *sizer) any(v interface{}, depth uint) (int, error) {
+	switch val := v.(type) {
+	case nil:
+		return len("null"), nil
+	case bool:
+		return boolSize(val), nil
+	case string:
+		return escapedSize(val), nil
+	case int:
+		return intSize(int64(val)), nil
+	case int8:
+		return intSize(int64(val)), nil
+	case int16:
+		return intSize(int64(val)), nil
+	case int32:
+		return intSize(int64(val)), nil
+	case int64:
+		return intSize(val), nil
+	case uint:
+		return uintSize(uint64(val)), nil
+	case uint8:
+		return uintSize(uint64(val)), nil
+	case uint16:
+		return uintSize(uint64(val)), nil
+	case uint32:
+		return uintSize(uint64(val)), nil
+	case uint64:
+		return uintSize(val), nil
+	case float32:
+		return floatSize(float64(val), 32), nil
+	case float64:
+		return floatSize(val, 64), nil
+	case *Document:
+		if val == nil {
+			break
+		}
+		return renderSize(val.data)
+	case *Array:
+		if val == nil {
+			break
+		}
+		return renderSize(val.data)
+	case []interface{}:
+		if depth >= startDetectingCyclesAfter {
+			break
+		}
+		n := len("[]") + max(len(val)-1, 0)
+		for i, elem := range val {
+			m, err := s.any(elem, depth+1)
+			if err != nil {
+				cycleIndex(err, i)
+				cycleUnwind(err, reflect.ValueOf(val))
+				return 0, err
+			}
+			n += m
+		}
+		return n, nil
+	case map[string]interface{}:
+		if depth >= startDetectingCyclesAfter {
+			break
+		}
+		n := len("{}") + max(len(val)-1, 0)
+		for k, elem := range val {
+			m, err := s.any(elem, depth+1)
+			if err != nil {
+				cycleMember(err, k)
+				cycleUnwind(err, reflect.ValueOf(val))
+				return 0, err
+			}
+			n += escapedSize(k) + len(":") + m
+		}
+		return n, nil
+	}
+	return s.value(reflect.ValueOf(v), depth)
+}
+
+// value measures rv as the compiled encoder for its type would encode it.
+func (s *sizer) value(rv reflect.Value, depth uint) (int, error) {
+	if !rv.IsValid() {
+		return len("null"), nil
+	}
+	t := rv.Type()
+	if depth >= startDetectingCyclesAfter || encodedBySpecial(t) {
+		return s.leaf(rv)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return len("null"), nil
+		}
+		n, err := s.value(rv.Elem(), depth+1)
+		if err != nil && depth >= s.rootPtrs {
+			cycleUnwind(err, rv)
+		}
+		return n, err
+	case reflect.Interface:
+		if rv.IsNil() {
+			return len("null"), nil
+		}
+		return s.any(rv.Interface(), depth+1)
+	case reflect.String:
+		return escapedSize(rv.String()), nil
+	case reflect.Bool:
+		return boolSize(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return intSize(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return uintSize(rv.Uint()), nil
+	case reflect.Float32:
+		return floatSize(rv.Float(), 32), nil
+	case reflect.Float64:
+		return floatSize(rv.Float(), 64), nil
+	case reflect.Slice:
+		if rv.IsNil() {
+			return len("null"), nil
+		}
+		n, err := s.elements(rv, depth)
+		if err != nil {
+			cycleUnwind(err, rv)
+		}
+		return n, err
+	case reflect.Array:
+		return s.elements(rv, depth)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return s.leaf(rv)
+		}
+		if rv.IsNil() {
+			return len("null"), nil
+		}
+		n := len("{}") + max(rv.Len()-1, 0)
+		iter := rv.MapRange()
+		for iter.Next() {
+			m, err := s.value(iter.Value(), depth+1)
+			if err != nil {
+				cycleMember(err, iter.Key().String())
+				cycleUnwind(err, rv)
+				return 0, err
+			}
+			n += escapedSize(iter.Key().String()) + len(":") + m
+		}
+		return n, nil
+	case reflect.Struct:
+		return s.structValue(rv, depth)
+	}
+	return s.leaf(rv)
+}
+
+// elements measures a slice or array.
+func (s *sizer) elements(rv reflect.Value, depth uint) (int, error) {
+	n := len("[]") + max(rv.Len()-1, 0)
+	for i := 0; i < rv.Len(); i++ {
+		m, err := s.value(rv.Index(i), depth+1)
+		if err != nil {
+			cycleIndex(err, i)
+			return 0, err
+		}
+		n += m
+	}
+	return n, nil
+}
+
+// structValue measures a struct as buildStructEncoder writes it. Structs
+// with fields the encoder wraps, for the string, transform, and unknown
+// options, are measured as leaves.
+func (s *sizer) structValue(rv reflect.Value, depth uint) (int, error) {
+	t := rv.Type()
+	n := len("{}")
+	first := true
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		info := getFieldInfo(sf)
+		if info.skip {
+			continue
+		}
+		if info.unknown || info.asString || info.transform != "" {
+			return s.leaf(rv)
+		}
+		fv := rv.Field(i)
+		if info.omitEmpty && emptyFuncForKind(sf.Type)(fv) {
+			continue
+		}
+		m, err := s.value(fv, depth+1)
+		if err != nil {
+			cycleMember(err, info.name)
+			return 0, err
+		}
+		if !first {
+			n++
+		}
+		first = false
+		n += escapedSize(info.name) + len(":") + m
+	}
+	return n, nil
+}
+
+// leaf measures rv by encoding it into the scratch buffer.
+func (s *sizer) leaf(rv reflect.Value) (int, error) {
+	st := defaultEncodeState.acquire()
+	buf, err := encoderForType(rv.Type())(st, s.scratch[:0], rv)
+	st.release()
+	s.scratch = buf
+	if err != nil {
+		return 0, err
+	}
+	return len(buf), nil
+}
+
+// encodedBySpecial reports whether values of t are written by something
+// other than the encoder for their kind.
+func encodedBySpecial(t reflect.Type) bool {
+	return t.Implements(marshalerType) ||
+		(t.Kind() != reflect.Ptr && reflect.PointerTo(t).Implements(marshalerType)) ||
+		t == timeType || t == durationType || t == fastparser.NumberType ||
+		fastparser.IsSQLNull(t)
+}
+
+func boolSize(b bool) int {
+	if b {
+		return len("true")
+	}
+	return len("false")
+}
+
+func intSize(i int64) int {
+	var b [20]byte
+	return len(strconv.AppendInt(b[:0], i, 10))
+}
+
+func uintSize(u uint64) int {
+	var b [20]byte
+	return len(strconv.AppendUint(b[:0], u, 10))
+}
+
+func floatSize(f float64, bits int) int {
+	var b [32]byte
+	return len(strconv.AppendFloat(b[:0], f, 'g', -1, bits))
+}
+
+// escapedSize returns the length of s as appendEscapedString writes it,
+// with quotes.
+func escapedSize(s string) int {
+	n := len(s) + 2
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !needsEscape[c] {
+			continue
+		}
+		if escapeTable[c] == 0x01 {
+			n += len(`\u0000`) - 1
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// renderSize returns the length of v, a value held by a Document or Array,
+// as InterfaceToNode and Render write it.
+func renderSize(v interface{}) (int, error) {
+	switch val := v.(type) {
+	case nil:
+		return len("null"), nil
+	case string:
+		return renderStringSize(val), nil
+	case bool:
+		return boolSize(val), nil
+	case int:
+		return intSize(int64(val)), nil
+	case int64:
+		return intSize(val), nil
+	case int32:
+		return intSize(int64(val)), nil
+	case int16:
+		return intSize(int64(val)), nil
+	case int8:
+		return intSize(int64(val)), nil
+	case uint:
+		return intSize(int64(val)), nil
+	case uint64:
+		return intSize(int64(val)), nil
+	case uint32:
+		return intSize(int64(val)), nil
+	case uint16:
+		return intSize(int64(val)), nil
+	case uint8:
+		return intSize(int64(val)), nil
+	case float64:
+		return renderFloatSize(val), nil
+	case float32:
+		return renderFloatSize(float64(val)), nil
+	case []interface{}:
+		n := len("[]") + max(len(val)-1, 0)
+		for _, elem := range val {
+			m, err := renderSize(elem)
+			if err != nil {
+				return 0, err
+			}
+			n += m
+		}
+		return n, nil
+	case map[string]interface{}:
+		// Keys "0", "1", ... are the legacy form of an array, which Render
+		// writes as one
+		legacy := len(val) > 0
+		for i := 0; legacy && i < len(val); i++ {
+			_, legacy = val[strconv.Itoa(i)]
+		}
+		n := len("{}") + max(len(val)-1, 0)
+		for k, elem := range val {
+			m, err := renderSize(elem)
+			if err != nil {
+				return 0, err
+			}
+			n += m
+			if !legacy {
+				n += renderStringSize(k) + len(":")
+			}
+		}
+		return n, nil
+	case *Document:
+		return renderSize(val.data)
+	case *Array:
+		return renderSize(val.data)
+	}
+	_, err := InterfaceToNode(v)
+	return 0, err
+}
+
+// renderStringSize returns the length of s as Render writes it, with
+// quotes. Like escapeString, it replaces invalid UTF-8 only in strings that
+// have something to escape.
+func renderStringSize(s string) int {
+	if !needsEscaping(s) {
+		return len(s) + 2
+	}
+	n := 2
+	for i, r := range s {
+		switch {
+		case r == '"' || r == '\\' || r == '/' || r == '\b' || r == '\f' || r == '\n' || r == '\r' || r == '\t':
+			n += 2
+		case r < 0x20:
+			n += len(`\u0000`)
+		case r == utf8.RuneError:
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				n += utf8.RuneLen(utf8.RuneError)
+				continue
+			}
+			n += utf8.RuneLen(r)
+		default:
+			n += utf8.RuneLen(r)
+		}
+	}
+	return n
+}
+
+// renderFloatSize returns the length of f as renderLiteral writes it.
+func renderFloatSize(f float64) int {
+	var b [32]byte
+	s := strconv.AppendFloat(b[:0], f, 'f', -1, 64)
+	if bytes.IndexByte(s, '.') < 0 {
+		s = strconv.AppendFloat(b[:0], f, 'f', 1, 64)
+	}
+	return len(s)
+}
@@ -0,0 +1,200 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sizeAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type sizeUser struct {
+	ID       int64             `json:"id"`
+	Name     string            `json:"name"`
+	Email    string            `json:"email,omitempty"`
+	Score    float64           `json:"score"`
+	Tags     []string          `json:"tags"`
+	Address  *sizeAddress      `json:"address"`
+	Extra    map[string]int    `json:"extra"`
+	Created  time.Time         `json:"created"`
+	Timeout  time.Duration     `json:"timeout"`
+	Nick     sql.NullString    `json:"nick"`
+	Raw      Number            `json:"raw"`
+	Any      interface{}       `json:"any"`
+	Skipped  string            `json:"-"`
+	private  int               //nolint:unused
+	Opaque   sizeMarshaler     `json:"opaque"`
+	Quoted   sizeQuoted        `json:"quoted"`
+	Nested   map[string][]bool `json:"nested,omitempty"`
+	Bytes    []byte            `json:"bytes"`
+	Fixed    [2]uint8          `json:"fixed"`
+	Optional Nullable[int]     `json:"optional,omitempty"`
+}
+
+type sizeMarshaler struct{ n int }
+
+func (m sizeMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(strings.Repeat("1", m.n+1)), nil
+}
+
+type sizeQuoted struct {
+	N int `json:"n,string"`
+}
+
+func TestEstimateSize(t *testing.T) {
+	user := sizeUser{
+		ID: 42, Name: "Zoë \"Z\" </script>\x01", Score: 0.1, Tags: []string{"a", "b/c"},
+		Address: &sizeAddress{City: "NYC"}, Extra: map[string]int{"x": -1, "y\n": 1 << 40},
+		Created: time.Date(2024, 5, 6, 7, 8, 9, 123, time.UTC), Timeout: 90 * time.Second,
+		Nick: sql.NullString{String: "zz", Valid: true}, Raw: "1.50",
+		Any:    map[string]interface{}{"k": []interface{}{1, "two", 3.5, nil, true}},
+		Opaque: sizeMarshaler{n: 7}, Quoted: sizeQuoted{N: 12}, Bytes: []byte("hi"),
+		Fixed: [2]uint8{1, 255},
+	}
+	values := []interface{}{
+		nil, true, false, "", "plain", "esc\"\\/\b\f\n\r\t\x00\x1f", "\xff\xfe",
+		0, -7, int8(-128), uint64(math.MaxUint64), float32(0.1), 1e21, 1e-7, math.MaxFloat64,
+		[]interface{}{}, map[string]interface{}{}, []int(nil), map[string]string(nil),
+		[]interface{}{1, []interface{}{2, map[string]interface{}{"a": "b"}}},
+		user, &user, []sizeUser{user, {}}, map[string]*sizeUser{"u": &user, "nil": nil},
+		time.Unix(0, 0).UTC(), 5 * time.Millisecond, Number("12e3"),
+		NewDocument().SetFloat("f", 2).SetString("s", "a/b"), NewArray().AddInt(1).AddNull(),
+	}
+	for _, v := range values {
+		data, err := Marshal(v)
+		if err != nil {
+			t.Fatalf("Marshal(%T): %v", v, err)
+		}
+		n, err := EstimateSize(v)
+		if err != nil {
+			t.Fatalf("EstimateSize(%T): %v", v, err)
+		}
+		if n != len(data) {
+			t.Errorf("EstimateSize(%T) = %d, want %d for %s", v, n, len(data), data)
+		}
+	}
+}
+
+func TestEstimateSize_Errors(t *testing.T) {
+	type withChan struct {
+		C chan int `json:"c"`
+	}
+	cycle := map[string]interface{}{}
+	cycle["self"] = cycle
+
+	for _, v := range []interface{}{withChan{}, map[int]func(){1: nil}, cycle} {
+		_, marshalErr := Marshal(v)
+		_, err := EstimateSize(v)
+		if err == nil || marshalErr == nil {
+			t.Errorf("%T: EstimateSize error %v, Marshal error %v; want both", v, err, marshalErr)
+		}
+	}
+	if _, err := EstimateSize(cycle); !errors.Is(err, ErrCycleDetected) {
+		t.Errorf("EstimateSize(cycle) = %v, want ErrCycleDetected", err)
+	}
+}
+
+func TestEstimateSize_CycleError(t *testing.T) {
+	self := map[string]interface{}{}
+	self["self"] = self
+	list := []interface{}{1, nil}
+	list[1] = list
+	ring := &cycleNode{Name: "a", Next: &cycleNode{Name: "b"}}
+	ring.Next.Next = ring
+	tree := &cycleTree{Name: "root"}
+	tree.Children = []*cycleTree{{Name: "leaf"}, {Name: "child", Parent: tree}}
+
+	tests := []struct {
+		name string
+		v    interface{}
+		path string
+	}{
+		{"map", self, "/self"},
+		{"nested map", map[string]interface{}{"a": []interface{}{self}}, "/a/0/self"},
+		{"slice", list, "/1"},
+		{"ring", ring, "/next/next"},
+		{"pointer to root", &ring, "/next/next"},
+		{"parent", tree, "/children/1/parent"},
+		{"struct in map", map[string]*cycleTree{"t": tree}, "/t/children/1/parent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, marshalErr := Marshal(tt.v)
+			_, err := EstimateSize(tt.v)
+			var cycle *CycleError
+			if !errors.As(err, &cycle) {
+				t.Fatalf("EstimateSize() error = %v, want *CycleError", err)
+			}
+			if cycle.Path != tt.path {
+				t.Errorf("Path = %q, want %q", cycle.Path, tt.path)
+			}
+			if marshalErr == nil || err.Error() != marshalErr.Error() {
+				t.Errorf("EstimateSize() error = %q, want Marshal's %v", err, marshalErr)
+			}
+		})
+	}
+}
+
+func TestDocument_EstimateJSONSize(t *testing.T) {
+	doc := NewDocument().
+		SetString("name", "a/b \"c\"").
+		SetString("bad", "\xff\n").
+		SetString("raw", "\xff").
+		SetInt("n", -3).
+		SetFloat("whole", 3).
+		SetFloat("big", 1e21).
+		SetFloat("frac", 0.25).
+		Set("u", uint64(math.MaxUint64)).
+		SetNull("null").
+		SetBool("ok", true).
+		SetObject("legacy", NewDocument().SetInt("0", 1).SetInt("1", 2)).
+		SetObject("empty", NewDocument()).
+		SetArray("list", NewArray().AddString("x").AddFloat(1.5).AddArray(NewArray()))
+
+	text, err := doc.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := doc.EstimateJSONSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(text) {
+		t.Errorf("EstimateJSONSize() = %d, want %d for %s", n, len(text), text)
+	}
+
+	arr := NewArray().AddObject(doc).AddInt(1)
+	text, _ = arr.JSON()
+	if n, _ := arr.EstimateJSONSize(); n != len(text) {
+		t.Errorf("Array.EstimateJSONSize() = %d, want %d", n, len(text))
+	}
+
+	doc.Set("bad", struct{}{})
+	if _, err := doc.EstimateJSONSize(); err == nil {
+		t.Error("EstimateJSONSize() of an unsupported value returned no error")
+	}
+}
+
+func BenchmarkEstimateSize(b *testing.B) {
+	v := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"id": 1, "name": "widget", "price": 9.99, "tags": []interface{}{"a", "b"}},
+			map[string]interface{}{"id": 2, "name": "gadget", "price": 19.5, "tags": []interface{}{}},
+		},
+		"total": 2,
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := EstimateSize(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package json
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Span is the byte range [Start, End) of a value in the text it was parsed
+// from. It covers the value only, not the member name before it or the
+// whitespace around it.
+type Span struct {
+	Start int
+	End   int
+}
+
+// SourceMap records where each value of a document lies in its text, so
+// that the exact bytes of any subtree can be recovered, for example to
+// verify a signature computed over a sub-object as it was sent.
+//
+// Values are looked up either by AST node, for the tree returned alongside
+// the map, or by JSON Pointer, which also serves a Document or any other
+// representation decoded from the same text:
+//
+//	node, sm, err := json.ParseWithSourceMap(data)
+//	payload, _ := sm.RawAt("/payload")        // the bytes of data["payload"]
+//	obj := node.(*ast.ObjectNode)
+//	sig, _ := obj.GetProperty("signature")
+//	raw := sm.RawOf(sig)                       // the bytes of the signature
+//
+// A SourceMap holds on to the text; its slices must not be modified. It is
+// safe for concurrent use.
+type SourceMap struct {
+	data  []byte
+	root  *span
+	nodes map[ast.SchemaNode]Span
+}
+
+// ParseWithSourceMap parses data like Parse and returns the tree together
+// with a SourceMap of it. Unlike Parse, it rejects text after the value.
+func ParseWithSourceMap(data []byte) (ast.SchemaNode, *SourceMap, error) {
+	node, err := Parse(string(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	start := skipSpace(data, 0)
+	end, err := fastparser.SkipValue(data, start)
+	if err != nil {
+		return nil, nil, err
+	}
+	if end = skipSpace(data, end); end != len(data) {
+		return nil, nil, fmt.Errorf("unexpected data after JSON value at position %d", end)
+	}
+
+	m := &SourceMap{data: data, root: buildSpan(data, start, node), nodes: make(map[ast.SchemaNode]Span)}
+	m.root.walk(func(s *span) {
+		if s.node != nil {
+			m.nodes[s.node] = Span{Start: s.start, End: s.end}
+		}
+	})
+	return node, m, nil
+}
+
+// ParseDocumentWithSourceMap parses data like ParseDocument and returns
+// the Document together with a SourceMap of the text, whose SpanAt and
+// RawAt locate the Document's values by JSON Pointer.
+func ParseDocumentWithSourceMap(data []byte) (*Document, *SourceMap, error) {
+	node, m, err := ParseWithSourceMap(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	value := NodeToInterface(node)
+	doc, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("expected JSON object, got %T", value)
+	}
+	return &Document{data: doc}, m, nil
+}
+
+// walk calls f for s and each of its descendants, parents first.
+func (s *span) walk(f func(*span)) {
+	f(s)
+	for _, child := range s.children {
+		child.walk(f)
+	}
+}
+
+// Span returns the byte range of node, which must be a node of the tree
+// parsed with m.
+func (m *SourceMap) Span(node ast.SchemaNode) (Span, bool) {
+	sp, ok := m.nodes[node]
+	return sp, ok
+}
+
+// RawOf returns the text of node exactly as it appears in the input, or nil
+// if node is not a node of the tree parsed with m.
+func (m *SourceMap) RawOf(node ast.SchemaNode) []byte {
+	sp, ok := m.nodes[node]
+	if !ok {
+		return nil
+	}
+	return m.data[sp.Start:sp.End:sp.End]
+}
+
+// SpanAt returns the byte range of the value at the JSON Pointer (RFC 6901)
+// ptr, such as "/items/0/price". The empty pointer is the whole document.
+// It reports false if ptr is malformed or names no value.
+func (m *SourceMap) SpanAt(ptr string) (Span, bool) {
+	tokens, err := parsePointer(ptr)
+	if err != nil {
+		return Span{}, false
+	}
+	s := m.root
+	for _, tok := range tokens {
+		if s = s.child(m.data, tok); s == nil {
+			return Span{}, false
+		}
+	}
+	return Span{Start: s.start, End: s.end}, true
+}
+
+// RawAt returns the text of the value at the JSON Pointer ptr exactly as it
+// appears in the input, or nil if there is none; see SpanAt.
+func (m *SourceMap) RawAt(ptr string) []byte {
+	sp, ok := m.SpanAt(ptr)
+	if !ok {
+		return nil
+	}
+	return m.data[sp.Start:sp.End:sp.End]
+}
+
+// child returns the span of the member named tok, or of the element at
+// index tok, of the container s in data, or nil.
+func (s *span) child(data []byte, tok string) *span {
+	switch data[s.start] {
+	case '{':
+		for _, c := range s.children {
+			if c.key == tok {
+				return c
+			}
+		}
+	case '[':
+		i, err := strconv.Atoi(tok)
+		if err != nil || tok[0] < '0' || tok[0] > '9' || (tok[0] == '0' && tok != "0") || i >= len(s.children) {
+			return nil
+		}
+		return s.children[i]
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+const sourceMapDoc = "{\n  \"id\": 7,\n  \"payload\": {\"b\": [1, 2.50, \"x\"],  \"a\" : null},\n  \"sig\": \"Zm9v\\u0021\",\n  \"a/b\": true\n}\n"
+
+func TestParseWithSourceMap(t *testing.T) {
+	node, sm, err := ParseWithSourceMap([]byte(sourceMapDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ptr  string
+		want string
+	}{
+		{"", sourceMapDoc[:len(sourceMapDoc)-1]},
+		{"/id", `7`},
+		{"/payload", `{"b": [1, 2.50, "x"],  "a" : null}`},
+		{"/payload/b", `[1, 2.50, "x"]`},
+		{"/payload/b/1", `2.50`},
+		{"/payload/a", `null`},
+		{"/sig", `"Zm9v\u0021"`},
+		{"/a~1b", `true`},
+	}
+	for _, tt := range tests {
+		if got := string(sm.RawAt(tt.ptr)); got != tt.want {
+			t.Errorf("RawAt(%q) = %q, want %q", tt.ptr, got, tt.want)
+		}
+		sp, ok := sm.SpanAt(tt.ptr)
+		if !ok || sourceMapDoc[sp.Start:sp.End] != tt.want {
+			t.Errorf("SpanAt(%q) = %v, %v", tt.ptr, sp, ok)
+		}
+	}
+
+	for _, ptr := range []string{"/nope", "/payload/b/3", "/payload/b/01", "/payload/b/-1", "/payload/b/+1", "/id/x", "id"} {
+		if raw := sm.RawAt(ptr); raw != nil {
+			t.Errorf("RawAt(%q) = %q, want nil", ptr, raw)
+		}
+	}
+
+	payload, _ := node.(*ast.ObjectNode).GetProperty("payload")
+	if got := string(sm.RawOf(payload)); got != `{"b": [1, 2.50, "x"],  "a" : null}` {
+		t.Errorf("RawOf(payload) = %q", got)
+	}
+	b, _ := payload.(*ast.ObjectNode).GetProperty("b")
+	if got := string(sm.RawOf(b.(*ast.ArrayDataNode).Get(2))); got != `"x"` {
+		t.Errorf("RawOf(b[2]) = %q", got)
+	}
+	if sp, ok := sm.Span(node); !ok || sp.Start != 0 {
+		t.Errorf("Span(root) = %v, %v", sp, ok)
+	}
+
+	other, _ := Parse(`{"payload": 1}`)
+	if raw := sm.RawOf(other); raw != nil {
+		t.Errorf("RawOf(node of another tree) = %q, want nil", raw)
+	}
+}
+
+func TestParseWithSourceMapErrors(t *testing.T) {
+	for _, input := range []string{``, `{"a": }`, `{"a": 1} x`, `[1, 2`} {
+		if _, _, err := ParseWithSourceMap([]byte(input)); err == nil {
+			t.Errorf("ParseWithSourceMap(%q): expected error", input)
+		}
+	}
+}
+
+func TestParseDocumentWithSourceMap(t *testing.T) {
+	doc, sm, err := ParseDocumentWithSourceMap([]byte(sourceMapDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := doc.GetInt("id"); id != 7 {
+		t.Errorf("id = %d, want 7", id)
+	}
+	if got := string(sm.RawAt("/payload/b")); got != `[1, 2.50, "x"]` {
+		t.Errorf("RawAt(/payload/b) = %q", got)
+	}
+
+	if _, _, err := ParseDocumentWithSourceMap([]byte(`[1]`)); err == nil {
+		t.Error("ParseDocumentWithSourceMap of an array: expected error")
+	}
+}
@@ -0,0 +1,155 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type dbUser struct {
+	Name     sql.NullString  `json:"name"`
+	Age      sql.NullInt64   `json:"age"`
+	Score    sql.NullFloat64 `json:"score,omitempty"`
+	Active   sql.NullBool    `json:"active"`
+	LastSeen sql.NullTime    `json:"last_seen"`
+	Level    sql.Null[int16] `json:"level"`
+}
+
+func TestMarshal_SQLNull(t *testing.T) {
+	seen := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	u := dbUser{
+		Name:     sql.NullString{String: "ann", Valid: true},
+		Age:      sql.NullInt64{Int64: 30, Valid: true},
+		Active:   sql.NullBool{Valid: false},
+		LastSeen: sql.NullTime{Time: seen, Valid: true},
+		Level:    sql.Null[int16]{V: 3, Valid: true},
+	}
+	got, err := Marshal(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"ann","age":30,"active":null,"last_seen":"2024-05-01T12:00:00Z","level":3}`
+	if string(got) != want {
+		t.Errorf("Marshal =\n%s\nwant\n%s", got, want)
+	}
+
+	got, err = Marshal(dbUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = `{"name":null,"age":null,"active":null,"last_seen":null,"level":null}`
+	if string(got) != want {
+		t.Errorf("Marshal =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUnmarshal_SQLNull(t *testing.T) {
+	in := `{"name":"ann","age":null,"score":1.5,"active":true,"last_seen":"2024-05-01T12:00:00Z","level":3}`
+	for _, decode := range []func([]byte, interface{}) error{Unmarshal, UnmarshalWithAST} {
+		u := dbUser{Age: sql.NullInt64{Int64: 9, Valid: true}}
+		if err := decode([]byte(in), &u); err != nil {
+			t.Fatal(err)
+		}
+		if u.Name != (sql.NullString{String: "ann", Valid: true}) {
+			t.Errorf("name = %+v", u.Name)
+		}
+		if u.Age.Valid {
+			t.Errorf("age = %+v, want not Valid", u.Age)
+		}
+		if u.Score != (sql.NullFloat64{Float64: 1.5, Valid: true}) {
+			t.Errorf("score = %+v", u.Score)
+		}
+		if u.Active != (sql.NullBool{Bool: true, Valid: true}) {
+			t.Errorf("active = %+v", u.Active)
+		}
+		if !u.LastSeen.Valid || !u.LastSeen.Time.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)) {
+			t.Errorf("last_seen = %+v", u.LastSeen)
+		}
+		if u.Level != (sql.Null[int16]{V: 3, Valid: true}) {
+			t.Errorf("level = %+v", u.Level)
+		}
+	}
+
+	var u dbUser
+	err := Unmarshal([]byte(`{"age":"x"}`), &u)
+	if err == nil || !strings.HasPrefix(err.Error(), "json: age: ") {
+		t.Errorf("err = %v, want an error at age", err)
+	}
+}
+
+// cents is stored as an integer number of cents but read as a string.
+type cents int64
+
+func (c cents) Value() (driver.Value, error) {
+	return int64(c), nil
+}
+
+func (c *cents) Scan(src interface{}) error {
+	n, ok := src.(int64)
+	if !ok {
+		return errors.New("cents: not an integer")
+	}
+	*c = cents(n * 100)
+	return nil
+}
+
+type status struct{ code int }
+
+func (s status) Value() (driver.Value, error) {
+	if s.code == 0 {
+		return nil, nil
+	}
+	return []string{"", "active", "closed"}[s.code], nil
+}
+
+func TestMarshalWithOptions_Valuers(t *testing.T) {
+	v := struct {
+		Price  cents  `json:"price"`
+		Status status `json:"status"`
+		Closed status `json:"closed"`
+	}{Price: 250, Closed: status{2}}
+
+	got, err := MarshalWithOptions(v, MarshalOptions{Valuers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"price":250,"status":null,"closed":"closed"}`; string(got) != want {
+		t.Errorf("MarshalWithOptions = %s, want %s", got, want)
+	}
+
+	// Without the option the Valuer is ignored
+	got, err = Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"price":250,"status":{},"closed":{}}`; string(got) != want {
+		t.Errorf("Marshal = %s, want %s", got, want)
+	}
+}
+
+func TestUnmarshalWithOptions_Scanners(t *testing.T) {
+	var v struct {
+		Price cents `json:"price"`
+	}
+	if err := UnmarshalWithOptions([]byte(`{"price":3}`), &v, UnmarshalOptions{Scanners: true}); err != nil {
+		t.Fatal(err)
+	}
+	if v.Price != 300 {
+		t.Errorf("price = %d, want 300 via Scan", v.Price)
+	}
+
+	err := UnmarshalWithOptions([]byte(`{"price":"3"}`), &v, UnmarshalOptions{Scanners: true})
+	if err == nil || !strings.Contains(err.Error(), "cents: not an integer") {
+		t.Errorf("err = %v, want the Scan error", err)
+	}
+
+	// Without the option the value is decoded by kind
+	if err := Unmarshal([]byte(`{"price":3}`), &v); err != nil || v.Price != 3 {
+		t.Errorf("price = %d, err = %v, want 3, nil", v.Price, err)
+	}
+}
@@ -0,0 +1,547 @@
+package json
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// defaultLargeStringThreshold is the StreamOptions.LargeStringThreshold used
+// when none is set.
+const defaultLargeStringThreshold = 64 * 1024
+
+// StreamOptions configures StreamEvents.
+type StreamOptions struct {
+	// LargeStringThreshold is the decoded length in bytes above which a
+	// string value is delivered to OnLargeString instead of OnString.
+	// Zero means 64 KiB.
+	LargeStringThreshold int
+
+	// OnLargeString receives each string value longer than the threshold
+	// as a reader of its decoded contents, so that huge values such as
+	// base64 blobs never have to be held in memory at once:
+	//
+	//	OnLargeString: func(r io.Reader) error {
+	//	    _, err := io.Copy(file, base64.NewDecoder(base64.StdEncoding, r))
+	//	    return err
+	//	}
+	//
+	// The reader is only valid until the callback returns. Whatever the
+	// callback leaves unread is skipped, and still validated.
+	//
+	// When OnLargeString is nil, large strings are delivered to OnString
+	// like any other.
+	OnLargeString func(r io.Reader) error
+}
+
+// StreamEvents is like ParseEvents but reads r incrementally instead of
+// loading the whole input first. Memory use is bounded by the nesting depth,
+// the longest object key, and the string values actually materialized:
+//
+//   - strings whose handler callback is nil are validated and discarded
+//     without being buffered;
+//   - string values longer than opts.LargeStringThreshold are streamed to
+//     opts.OnLargeString.
+//
+// Object keys are always materialized. SkipSubtree works as for ParseEvents.
+func StreamEvents(r io.Reader, h EventHandler, opts StreamOptions) error {
+	if opts.LargeStringThreshold <= 0 {
+		opts.LargeStringThreshold = defaultLargeStringThreshold
+	}
+	w := &streamWalker{
+		r:     bufio.NewReader(r),
+		h:     &h,
+		large: opts.OnLargeString,
+		limit: opts.LargeStringThreshold,
+	}
+
+	if err := w.skipSpace(); err != nil {
+		return err
+	}
+	if err := w.value(); err != nil {
+		return err
+	}
+	if err := w.skipSpace(); err != nil {
+		if err == errStreamEOF {
+			return nil
+		}
+		return err
+	}
+	return fmt.Errorf("unexpected data after JSON value at position %d", w.pos)
+}
+
+// errStreamEOF marks the clean end of the input in skipSpace.
+var errStreamEOF = errors.New("unexpected end of JSON input")
+
+// noEvents is the handler used while skipping a subtree.
+var noEvents = &EventHandler{}
+
+// streamWalker parses JSON from a buffered reader, emitting events.
+type streamWalker struct {
+	r     *bufio.Reader
+	h     *EventHandler
+	large func(io.Reader) error
+	limit int
+	pos   int64  // offset of the next byte, for error messages
+	buf   []byte // scratch space for keys, strings, and numbers
+	depth int    // containers entered
+}
+
+// readByte reads one byte, reporting io.EOF as a syntax error.
+func (w *streamWalker) readByte() (byte, error) {
+	c, err := w.r.ReadByte()
+	if err != nil {
+		if err == io.EOF {
+			return 0, errStreamEOF
+		}
+		return 0, err
+	}
+	w.pos++
+	return c, nil
+}
+
+// unreadByte pushes back the byte just read.
+func (w *streamWalker) unreadByte() {
+	_ = w.r.UnreadByte()
+	w.pos--
+}
+
+// skipSpace consumes whitespace. It returns errStreamEOF at the end of input.
+func (w *streamWalker) skipSpace() error {
+	for {
+		c, err := w.readByte()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		w.unreadByte()
+		return nil
+	}
+}
+
+// skipping runs fn with all events suppressed.
+func (w *streamWalker) skipping(fn func() error) error {
+	h, large := w.h, w.large
+	w.h, w.large = noEvents, nil
+	err := fn()
+	w.h, w.large = h, large
+	return err
+}
+
+// value parses the value at the current position.
+func (w *streamWalker) value() error {
+	c, err := w.readByte()
+	if err != nil {
+		return err
+	}
+
+	switch c {
+	case '{', '[':
+		if w.depth++; w.depth > fastparser.MaxDepth {
+			return fastparser.DepthError(int(w.pos - 1))
+		}
+		if c == '{' {
+			err = w.object()
+		} else {
+			err = w.array()
+		}
+		w.depth--
+		return err
+	case '"':
+		return w.stringValue()
+	case 't':
+		return w.literal("rue", func() error { return callBool(w.h.OnBool, true) })
+	case 'f':
+		return w.literal("alse", func() error { return callBool(w.h.OnBool, false) })
+	case 'n':
+		return w.literal("ull", func() error { return callEvent(w.h.OnNull) })
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return w.number(c)
+	}
+	return fmt.Errorf("unexpected character '%c' at position %d", c, w.pos-1)
+}
+
+// object parses an object after its opening brace.
+func (w *streamWalker) object() error {
+	if err := callEvent(w.h.OnObjectStart); err != nil {
+		if err != SkipSubtree {
+			return err
+		}
+		return w.skipping(w.members)
+	}
+	if err := w.members(); err != nil {
+		return err
+	}
+	return callEvent(w.h.OnObjectEnd)
+}
+
+// members parses the members of an object and its closing brace.
+func (w *streamWalker) members() error {
+	if err := w.skipSpace(); err != nil {
+		return err
+	}
+	if c, _ := w.readByte(); c == '}' {
+		return nil
+	}
+	w.unreadByte()
+
+	for {
+		if err := w.skipSpace(); err != nil {
+			return err
+		}
+		if c, _ := w.readByte(); c != '"' {
+			return errors.New("expected string key in object")
+		}
+
+		skip := false
+		if w.h.OnKey == nil {
+			if err := w.discardString(); err != nil {
+				return err
+			}
+		} else {
+			key, _, err := w.decodeString(w.buf[:0], -1)
+			w.buf = key[:0]
+			if err != nil {
+				return err
+			}
+			if err := w.h.OnKey(string(key)); err != nil {
+				if err != SkipSubtree {
+					return err
+				}
+				skip = true
+			}
+		}
+
+		if err := w.skipSpace(); err != nil {
+			return err
+		}
+		if c, _ := w.readByte(); c != ':' {
+			return errors.New("expected ':' after object key")
+		}
+		if err := w.skipSpace(); err != nil {
+			return err
+		}
+
+		var err error
+		if skip {
+			err = w.skipping(w.value)
+		} else {
+			err = w.value()
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := w.skipSpace(); err != nil {
+			if err == errStreamEOF {
+				return errors.New("unexpected end of JSON input in object")
+			}
+			return err
+		}
+		switch c, _ := w.readByte(); c {
+		case '}':
+			return nil
+		case ',':
+		default:
+			return fmt.Errorf("expected ',' or '}' in object at position %d", w.pos-1)
+		}
+	}
+}
+
+// array parses an array after its opening bracket.
+func (w *streamWalker) array() error {
+	if err := callEvent(w.h.OnArrayStart); err != nil {
+		if err != SkipSubtree {
+			return err
+		}
+		return w.skipping(w.elements)
+	}
+	if err := w.elements(); err != nil {
+		return err
+	}
+	return callEvent(w.h.OnArrayEnd)
+}
+
+// elements parses the elements of an array and its closing bracket.
+func (w *streamWalker) elements() error {
+	if err := w.skipSpace(); err != nil {
+		return err
+	}
+	if c, _ := w.readByte(); c == ']' {
+		return nil
+	}
+	w.unreadByte()
+
+	for {
+		if err := w.skipSpace(); err != nil {
+			return err
+		}
+		if err := w.value(); err != nil {
+			return err
+		}
+
+		if err := w.skipSpace(); err != nil {
+			if err == errStreamEOF {
+				return errors.New("unexpected end of JSON input in array")
+			}
+			return err
+		}
+		switch c, _ := w.readByte(); c {
+		case ']':
+			return nil
+		case ',':
+		default:
+			return fmt.Errorf("expected ',' or ']' in array at position %d", w.pos-1)
+		}
+	}
+}
+
+// literal consumes the rest of true, false, or null and calls emit.
+func (w *streamWalker) literal(rest string, emit func() error) error {
+	for i := 0; i < len(rest); i++ {
+		c, err := w.readByte()
+		if err != nil {
+			return err
+		}
+		if c != rest[i] {
+			return fmt.Errorf("invalid literal at position %d", w.pos-1)
+		}
+	}
+	return emit()
+}
+
+// number reads a number whose first byte is first.
+func (w *streamWalker) number(first byte) error {
+	raw := append(w.buf[:0], first)
+	for {
+		c, err := w.r.ReadByte()
+		if err != nil {
+			if err != io.EOF {
+				return err
+			}
+			break
+		}
+		if (c >= '0' && c <= '9') || c == '.' || c == 'e' || c == 'E' || c == '+' || c == '-' {
+			raw = append(raw, c)
+			w.pos++
+			continue
+		}
+		_ = w.r.UnreadByte()
+		break
+	}
+	w.buf = raw[:0]
+
+	// The scanner does the validation; the characters above are only a superset
+	if end, err := fastparser.SkipValue(raw, 0); err != nil || end != len(raw) {
+		return fmt.Errorf("invalid number %q at position %d", raw, w.pos-int64(len(raw)))
+	}
+	if w.h.OnNumber == nil {
+		return nil
+	}
+	return w.h.OnNumber(string(raw))
+}
+
+// stringValue reads a string value after its opening quote.
+func (w *streamWalker) stringValue() error {
+	if w.h.OnString == nil && w.large == nil {
+		return w.discardString()
+	}
+
+	max := -1
+	if w.large != nil {
+		max = w.limit + 1
+	}
+	s, done, err := w.decodeString(w.buf[:0], max)
+	w.buf = s[:0]
+	if err != nil {
+		return err
+	}
+
+	if len(s) <= w.limit || w.large == nil {
+		if w.h.OnString == nil {
+			return nil
+		}
+		return w.h.OnString(string(s))
+	}
+
+	// The prefix read so far is reused by the next string, so copy it
+	sr := &streamString{w: w, pending: append([]byte(nil), s...), done: done}
+	err = w.large(sr)
+	if drainErr := sr.drain(); drainErr != nil {
+		return drainErr
+	}
+	return err
+}
+
+// discardString validates and skips a string after its opening quote.
+func (w *streamWalker) discardString() error {
+	var scratch [256]byte
+	for {
+		_, done, err := w.decodeString(scratch[:0], len(scratch)-utf8.UTFMax)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// decodeString appends decoded string contents to dst until the closing
+// quote, or until dst holds at least max bytes when max >= 0. It reports
+// whether the closing quote was consumed.
+func (w *streamWalker) decodeString(dst []byte, max int) ([]byte, bool, error) {
+	for max < 0 || len(dst) < max {
+		c, err := w.readByte()
+		if err != nil {
+			if err == errStreamEOF {
+				return dst, false, errors.New("unexpected end of JSON input in string")
+			}
+			return dst, false, err
+		}
+
+		switch {
+		case c == '"':
+			return dst, true, nil
+		case c == '\\':
+			if dst, err = w.decodeEscape(dst); err != nil {
+				return dst, false, err
+			}
+		case c < 0x20:
+			return dst, false, fmt.Errorf("invalid control character in string at position %d", w.pos-1)
+		default:
+			dst = append(dst, c)
+		}
+	}
+	return dst, false, nil
+}
+
+// decodeEscape decodes the escape sequence after a backslash.
+func (w *streamWalker) decodeEscape(dst []byte) ([]byte, error) {
+	c, err := w.readByte()
+	if err != nil {
+		return dst, errors.New("unexpected end of JSON input after backslash")
+	}
+	switch c {
+	case '"', '\\', '/':
+		return append(dst, c), nil
+	case 'b':
+		return append(dst, '\b'), nil
+	case 'f':
+		return append(dst, '\f'), nil
+	case 'n':
+		return append(dst, '\n'), nil
+	case 'r':
+		return append(dst, '\r'), nil
+	case 't':
+		return append(dst, '\t'), nil
+	case 'u':
+		r, err := w.hex4()
+		if err != nil {
+			return dst, err
+		}
+		if utf16.IsSurrogate(r) {
+			// Combine with a following low surrogate if there is one
+			if next, _ := w.r.Peek(2); len(next) == 2 && next[0] == '\\' && next[1] == 'u' {
+				w.r.Discard(2)
+				w.pos += 2
+				r2, err := w.hex4()
+				if err != nil {
+					return dst, err
+				}
+				if combined := utf16.DecodeRune(r, r2); combined != utf8.RuneError {
+					return utf8.AppendRune(dst, combined), nil
+				}
+				dst = utf8.AppendRune(dst, utf8.RuneError)
+				r = r2
+			}
+			if utf16.IsSurrogate(r) {
+				r = utf8.RuneError
+			}
+		}
+		return utf8.AppendRune(dst, r), nil
+	}
+	return dst, fmt.Errorf("invalid escape sequence '\\%c'", c)
+}
+
+// hex4 reads the four hex digits of a \u escape.
+func (w *streamWalker) hex4() (rune, error) {
+	var digits [4]byte
+	for i := range digits {
+		c, err := w.readByte()
+		if err != nil {
+			return 0, errors.New("incomplete unicode escape")
+		}
+		digits[i] = c
+	}
+	n, err := strconv.ParseUint(string(digits[:]), 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid unicode escape: %v", err)
+	}
+	return rune(n), nil
+}
+
+// streamString is the reader handed to StreamOptions.OnLargeString. It
+// decodes the string from the underlying stream as it is read.
+type streamString struct {
+	w       *streamWalker
+	pending []byte // decoded bytes not yet returned
+	done    bool   // the closing quote has been consumed
+	err     error
+}
+
+// Read implements io.Reader.
+func (s *streamString) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for len(s.pending) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.done {
+			return 0, io.EOF
+		}
+		s.pending, s.done, s.err = s.w.decodeString(s.pending[:0], len(p))
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// drain consumes the rest of the string and detaches the reader from the
+// stream, so that later reads fail instead of consuming unrelated input.
+func (s *streamString) drain() error {
+	for !s.done && s.err == nil {
+		s.pending, s.done, s.err = s.w.decodeString(s.pending[:0], 4096)
+	}
+	err := s.err
+	s.pending, s.err = nil, errStringReaderClosed
+	return err
+}
+
+// errStringReaderClosed is returned by reads from a large string reader
+// after its callback has returned.
+var errStringReaderClosed = errors.New("json: large string reader used after its callback returned")
+
+// callEvent invokes an optional callback without arguments.
+func callEvent(fn func() error) error {
+	if fn == nil {
+		return nil
+	}
+	return fn()
+}
+
+// callBool invokes an optional boolean callback.
+func callBool(fn func(bool) error, b bool) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(b)
+}
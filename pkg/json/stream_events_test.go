@@ -0,0 +1,283 @@
+package json
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// eventRecorder returns a handler that records every event as text.
+func eventRecorder(events *[]string) EventHandler {
+	rec := func(format string, args ...interface{}) {
+		*events = append(*events, fmt.Sprintf(format, args...))
+	}
+	return EventHandler{
+		OnObjectStart: func() error { rec("{"); return nil },
+		OnObjectEnd:   func() error { rec("}"); return nil },
+		OnArrayStart:  func() error { rec("["); return nil },
+		OnArrayEnd:    func() error { rec("]"); return nil },
+		OnKey:         func(k string) error { rec("key %q", k); return nil },
+		OnString:      func(s string) error { rec("string %q", s); return nil },
+		OnNumber:      func(raw string) error { rec("number %s", raw); return nil },
+		OnBool:        func(b bool) error { rec("bool %v", b); return nil },
+		OnNull:        func() error { rec("null"); return nil },
+	}
+}
+
+func TestStreamEvents_MatchesParseEvents(t *testing.T) {
+	inputs := []string{
+		`{"a": [1, -2.5e3, true, false, null, "x\né\"\\\/"], "b": {}, "c": [], "": {"d": [[]]}}`,
+		` "scalar" `,
+		`0`,
+		`[{"k":"v"},{"k":"w"}]`,
+		`{"escAped": "😀"}`,
+	}
+	for _, input := range inputs {
+		var want, got []string
+		if err := ParseEvents(strings.NewReader(input), eventRecorder(&want)); err != nil {
+			t.Fatalf("ParseEvents(%s) error = %v", input, err)
+		}
+		// One byte at a time exercises every buffer boundary
+		if err := StreamEvents(iotest.OneByteReader(strings.NewReader(input)), eventRecorder(&got), StreamOptions{}); err != nil {
+			t.Fatalf("StreamEvents(%s) error = %v", input, err)
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("StreamEvents(%s) events =\n%v\nwant\n%v", input, got, want)
+		}
+	}
+}
+
+func TestStreamEvents_SurrogatePair(t *testing.T) {
+	var got string
+	err := StreamEvents(strings.NewReader(`"\ud83d\ude00 \ud83d x"`), EventHandler{
+		OnString: func(s string) error { got = s; return nil },
+	}, StreamOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "\U0001F600 � x"; got != want {
+		t.Errorf("string = %q, want %q", got, want)
+	}
+}
+
+// newBlobReader produces {"id": 1, "blob": "<n base64 bytes>", "after": true}
+// without ever holding the blob in memory.
+func newBlobReader(n int64) io.Reader {
+	return io.MultiReader(
+		strings.NewReader(`{"id": 1, "blob": "`),
+		io.LimitReader(repeatReader('Q'), n),
+		strings.NewReader(`", "after": true}`),
+	)
+}
+
+// repeatReader returns an endless stream of c.
+type repeatReader byte
+
+func (r repeatReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+func TestStreamEvents_LargeString(t *testing.T) {
+	const size = 4 << 20 // 4 MiB of base64
+	var decoded int64
+	var after bool
+	var small []string
+
+	err := StreamEvents(newBlobReader(size), EventHandler{
+		OnString: func(s string) error { small = append(small, s); return nil },
+		OnBool:   func(b bool) error { after = b; return nil },
+	}, StreamOptions{
+		LargeStringThreshold: 1024,
+		OnLargeString: func(r io.Reader) error {
+			n, err := io.Copy(io.Discard, base64.NewDecoder(base64.StdEncoding, r))
+			decoded = n
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatalf("StreamEvents() error = %v", err)
+	}
+	if decoded != size/4*3 {
+		t.Errorf("decoded %d bytes, want %d", decoded, size/4*3)
+	}
+	if !after {
+		t.Error("events after the large string were not delivered")
+	}
+	if len(small) != 0 {
+		t.Errorf("large string also delivered to OnString: %d strings", len(small))
+	}
+}
+
+func TestStreamEvents_LargeStringPartialRead(t *testing.T) {
+	input := `["` + strings.Repeat("a", 100) + `\n` + strings.Repeat("b", 100) + `", "short", "` + strings.Repeat("c", 11) + `"]`
+
+	var prefixes []string
+	var shorts []string
+	var leaked io.Reader
+	err := StreamEvents(strings.NewReader(input), EventHandler{
+		OnString: func(s string) error { shorts = append(shorts, s); return nil },
+	}, StreamOptions{
+		LargeStringThreshold: 10,
+		OnLargeString: func(r io.Reader) error {
+			buf := make([]byte, 5)
+			n, err := io.ReadFull(r, buf)
+			prefixes = append(prefixes, string(buf[:n]))
+			leaked = r
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatalf("StreamEvents() error = %v", err)
+	}
+	if strings.Join(prefixes, ",") != "aaaaa,ccccc" {
+		t.Errorf("prefixes = %v", prefixes)
+	}
+	if strings.Join(shorts, ",") != "short" {
+		t.Errorf("short strings = %v", shorts)
+	}
+	if _, err := leaked.Read(make([]byte, 1)); !errors.Is(err, errStringReaderClosed) {
+		t.Errorf("read after callback error = %v", err)
+	}
+}
+
+func TestStreamEvents_LargeStringExact(t *testing.T) {
+	// Escapes expand across the threshold; the reader must reproduce the
+	// string exactly
+	want := strings.Repeat("é\t\"", 50)
+	data, _ := Marshal(want)
+
+	var got bytes.Buffer
+	err := StreamEvents(bytes.NewReader(data), EventHandler{}, StreamOptions{
+		LargeStringThreshold: 7,
+		OnLargeString: func(r io.Reader) error {
+			_, err := io.Copy(&got, iotest.OneByteReader(r))
+			return err
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != want {
+		t.Errorf("streamed %q, want %q", got.String(), want)
+	}
+}
+
+func TestStreamEvents_NoLargeHandler(t *testing.T) {
+	// Without OnLargeString every string goes to OnString
+	long := strings.Repeat("x", 100)
+	var got string
+	err := StreamEvents(strings.NewReader(`"`+long+`"`), EventHandler{
+		OnString: func(s string) error { got = s; return nil },
+	}, StreamOptions{LargeStringThreshold: 10})
+	if err != nil || got != long {
+		t.Errorf("got %d bytes, err = %v", len(got), err)
+	}
+}
+
+func TestStreamEvents_SkipSubtree(t *testing.T) {
+	input := `{"skip": {"a": ["x"]}, "keep": [1], "drop": [2, {"b": 3}], "last": "y"}`
+	var events []string
+	h := eventRecorder(&events)
+	recordKey := h.OnKey
+	recordArray := h.OnArrayStart
+	inDrop := false
+	h.OnKey = func(k string) error {
+		inDrop = k == "drop"
+		if k == "skip" {
+			return SkipSubtree
+		}
+		return recordKey(k)
+	}
+	h.OnArrayStart = func() error {
+		if inDrop {
+			return SkipSubtree
+		}
+		return recordArray()
+	}
+
+	if err := StreamEvents(strings.NewReader(input), h, StreamOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	want := `{,key "keep",[,number 1,],key "drop",key "last",string "y",}`
+	if got := strings.Join(events, ","); got != want {
+		t.Errorf("events = %s, want %s", got, want)
+	}
+}
+
+func TestStreamEvents_Errors(t *testing.T) {
+	inputs := []string{
+		``,
+		`   `,
+		`{`,
+		`{"a"`,
+		`{"a":}`,
+		`{"a":1,}`,
+		`{"a" 1}`,
+		`{1:2}`,
+		`[1 2]`,
+		`[1,]`,
+		`[`,
+		`"abc`,
+		`"a\x"`,
+		`"a\u12"`,
+		"\"a\tb\"",
+		`tru`,
+		`nul`,
+		`-`,
+		`1.`,
+		`01`,
+		`1e`,
+		`[1] 2`,
+		`@`,
+	}
+	for _, input := range inputs {
+		if err := StreamEvents(strings.NewReader(input), eventRecorder(new([]string)), StreamOptions{}); err == nil {
+			t.Errorf("StreamEvents(%q) expected error", input)
+		}
+		// Discarded strings are validated too
+		if err := StreamEvents(strings.NewReader(input), EventHandler{}, StreamOptions{}); err == nil {
+			t.Errorf("StreamEvents(%q) with empty handler expected error", input)
+		}
+	}
+
+	// Errors in a large string surface after the callback returns
+	err := StreamEvents(strings.NewReader(`"`+strings.Repeat("a", 50)+`\q"`), EventHandler{}, StreamOptions{
+		LargeStringThreshold: 10,
+		OnLargeString:        func(io.Reader) error { return nil },
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid escape") {
+		t.Errorf("invalid escape in large string: error = %v", err)
+	}
+}
+
+func TestStreamEvents_CallbackAndReadErrors(t *testing.T) {
+	stop := errors.New("stop")
+	err := StreamEvents(strings.NewReader(`[1, 2]`), EventHandler{
+		OnNumber: func(string) error { return stop },
+	}, StreamOptions{})
+	if !errors.Is(err, stop) {
+		t.Errorf("callback error = %v, want %v", err, stop)
+	}
+
+	err = StreamEvents(strings.NewReader(`"`+strings.Repeat("a", 50)+`"`), EventHandler{}, StreamOptions{
+		LargeStringThreshold: 10,
+		OnLargeString:        func(io.Reader) error { return stop },
+	})
+	if !errors.Is(err, stop) {
+		t.Errorf("large string callback error = %v, want %v", err, stop)
+	}
+
+	readErr := errors.New("disk on fire")
+	err = StreamEvents(io.MultiReader(strings.NewReader(`[1,`), iotest.ErrReader(readErr)), EventHandler{}, StreamOptions{})
+	if !errors.Is(err, readErr) {
+		t.Errorf("read error = %v, want %v", err, readErr)
+	}
+}
@@ -2,13 +2,19 @@ package json
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"errors"
+	"io"
+	"reflect"
 	"strings"
 	"testing"
 )
 
 // TestDecoder tests the streaming Decoder
 func TestDecoder(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
 	t.Run("decode single value", func(t *testing.T) {
 		type Person struct {
 			Name string `json:"name"`
@@ -109,8 +115,159 @@ func TestDecoder(t *testing.T) {
 	})
 }
 
+func TestDecoder_Stream(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(` {"n": 1}
+[2] "three" 4 true
+null`))
+	var got []interface{}
+	for {
+		var v interface{}
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	want := []interface{}{map[string]interface{}{"n": int64(1)}, []interface{}{int64(2)}, "three", int64(4), true, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded %#v, want %#v", got, want)
+	}
+
+	var v interface{}
+	if err := NewDecoder(strings.NewReader(`{"a": [1, 2}`)).Decode(&v); err == nil || err == io.EOF {
+		t.Errorf("Decode() error = %v, want a syntax error", err)
+	}
+	if err := NewDecoder(strings.NewReader(`{"a": [1, 2]`)).Decode(&v); err == nil || err == io.EOF {
+		t.Errorf("Decode() error = %v, want a syntax error", err)
+	}
+}
+
+func TestDecoder_Limits(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxToken int64
+		maxValue int64
+		decoded  int // values decoded before the error
+		want     string
+	}{
+		{"string", `["abc", "abcdef"]`, 5, 0, 0, "json: token size exceeds the limit of 5 bytes at offset 14"},
+		{"escapes count as written", `"ab\nc"`, 4, 0, 0, "json: token size exceeds the limit of 4 bytes at offset 5"},
+		{"number", `[1, 123456]`, 5, 0, 0, "json: token size exceeds the limit of 5 bytes at offset 9"},
+		{"top-level number", `12345 123456`, 5, 0, 1, "json: token size exceeds the limit of 5 bytes at offset 11"},
+		{"value", `{"a":1} {"a":12}`, 0, 7, 1, "json: value size exceeds the limit of 7 bytes at offset 15"},
+		{"value with whitespace", `[1,  2]`, 0, 6, 0, "json: value size exceeds the limit of 6 bytes at offset 6"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(strings.NewReader(tt.input))
+			dec.SetMaxTokenSize(tt.maxToken)
+			dec.SetMaxValueSize(tt.maxValue)
+			var v interface{}
+			for i := 0; i < tt.decoded; i++ {
+				if err := dec.Decode(&v); err != nil {
+					t.Fatalf("Decode() #%d error = %v", i, err)
+				}
+			}
+			err := dec.Decode(&v)
+			var limitErr *LimitError
+			if !errors.As(err, &limitErr) || err.Error() != tt.want {
+				t.Fatalf("Decode() error = %v, want %s", err, tt.want)
+			}
+			// The error is sticky
+			if err2 := dec.Decode(&v); err2 != err {
+				t.Errorf("second Decode() error = %v, want %v", err2, err)
+			}
+		})
+	}
+
+	// Values within the limits decode
+	dec := NewDecoder(strings.NewReader(`{"name":"abcde","n":12345}`))
+	dec.SetMaxTokenSize(5)
+	dec.SetMaxValueSize(26)
+	var v map[string]interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecoder_LimitStopsReading(t *testing.T) {
+	// An endless string must not be read past the limit
+	r := io.MultiReader(strings.NewReader(`["`), endless('x'))
+	dec := NewDecoder(r)
+	dec.SetMaxValueSize(1 << 16)
+	var v interface{}
+	var limitErr *LimitError
+	if err := dec.Decode(&v); !errors.As(err, &limitErr) || limitErr.Limit != "value size" {
+		t.Fatalf("Decode() error = %v", err)
+	}
+}
+
+// endless is a reader that returns its byte forever.
+type endless byte
+
+func (e endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(e)
+	}
+	return len(p), nil
+}
+
+func TestEncoder_MaxOutputSize(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.SetMaxOutputSize(8)
+	if err := enc.Encode([]int{1, 2, 3}); err != nil {
+		t.Fatal(err)
+	}
+	var limitErr *LimitError
+	if err := enc.Encode([]int{1, 2, 3, 4, 5}); !errors.As(err, &limitErr) || limitErr.Limit != "output size" {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if buf.String() != "[1,2,3]\n" {
+		t.Errorf("output = %q, want only the first value", buf.String())
+	}
+}
+
+func TestDecoder_SetHash(t *testing.T) {
+	input := " {\"a\": [1, 2]}\n\t\"s\" 42 \n" + strings.Repeat(" ", 200) + "[true]\n\n"
+	sum := sha256.New()
+	dec := NewDecoder(strings.NewReader(input))
+	dec.SetHash(sum)
+
+	// After the first value, the hash has seen it and the whitespace
+	// before it, not what the decoder read ahead
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sum.Sum(nil), sha256.Sum256([]byte(` {"a": [1, 2]}`)); !bytes.Equal(got, want[:]) {
+		t.Errorf("hash after one value = %x, want %x", got, want)
+	}
+
+	for {
+		if err := dec.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := sum.Sum(nil), sha256.Sum256([]byte(input)); !bytes.Equal(got, want[:]) {
+		t.Errorf("hash at EOF = %x, want %x", got, want)
+	}
+}
+
 // TestEncoder tests the streaming Encoder
 func TestEncoder(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
 	t.Run("encode single value", func(t *testing.T) {
 		type Person struct {
 			Name string `json:"name"`
@@ -127,7 +284,7 @@ func TestEncoder(t *testing.T) {
 			t.Fatalf("Encode() error = %v", err)
 		}
 
-		expected := `{"age":30,"name":"Alice"}`
+		expected := `{"name":"Alice","age":30}`
 		result := strings.TrimSpace(buf.String())
 		if result != expected {
 			t.Errorf("Encode() = %s, want %s", result, expected)
@@ -183,7 +340,7 @@ func TestEncoder(t *testing.T) {
 			t.Fatalf("Encode() error = %v", err)
 		}
 
-		expected := `[{"age":30,"name":"Alice"},{"age":25,"name":"Bob"}]`
+		expected := `[{"name":"Alice","age":30},{"name":"Bob","age":25}]`
 		result := strings.TrimSpace(buf.String())
 		if result != expected {
 			t.Errorf("Encode() = %s, want %s", result, expected)
@@ -234,6 +391,9 @@ func (w *failingWriter) Write(p []byte) (n int, err error) {
 
 // TestEncoder_WriteErrors tests error handling in Encode
 func TestEncoder_WriteErrors(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
 	t.Run("write error on data", func(t *testing.T) {
 		// Create a writer that fails immediately
 		w := &failingWriter{failAfter: 0}
@@ -267,6 +427,9 @@ func TestEncoder_WriteErrors(t *testing.T) {
 
 // TestDecoder_Encoder_RoundTrip tests round-trip encoding/decoding
 func TestDecoder_Encoder_RoundTrip(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
 	type Person struct {
 		Name string `json:"name"`
 		Age  int    `json:"age"`
@@ -1,8 +1,12 @@
+//go:build !tinygo
+
 package json
 
 import (
 	"reflect"
 	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
 )
 
 // fieldInfo contains parsed information from a struct field's json tag
@@ -10,12 +14,17 @@ type fieldInfo struct {
 	name      string // JSON field name (empty means use Go field name)
 	omitEmpty bool   // omitempty option
 	asString  bool   // string option (marshal numbers/bools as strings)
+	coerce    bool   // coerce option (unmarshal numbers/bools from strings too)
 	skip      bool   // skip this field (tag is "-")
+	required  bool   // required option (unmarshal fails if the key is absent)
+	transform string // transform:<name> option (see Transformer)
+	unknown   bool   // unknown option on a map field (receives unmatched members)
 }
 
 // parseTag parses a struct field's json tag value
 // Format: "fieldname" or "fieldname,option1,option2"
-// Options: omitempty, string
+// Options: omitempty, string, coerce, required, unknown, transform:<name>
+// Option values may be quoted to hold commas (see fastparser.NextTagOption)
 // Special: "-" means skip field
 func parseTag(tag string) fieldInfo {
 	info := fieldInfo{}
@@ -26,18 +35,28 @@ func parseTag(tag string) fieldInfo {
 		return info
 	}
 
-	parts := strings.Split(tag, ",")
-	if len(parts) > 0 {
-		info.name = parts[0]
-	}
+	name, opts, _ := strings.Cut(tag, ",")
+	info.name = name
 
 	// Parse options
-	for i := 1; i < len(parts); i++ {
-		switch strings.TrimSpace(parts[i]) {
+	for opts != "" {
+		var opt string
+		opt, opts = fastparser.NextTagOption(opts)
+		switch opt {
 		case "omitempty":
 			info.omitEmpty = true
 		case "string":
 			info.asString = true
+		case "coerce":
+			info.coerce = true
+		case "required":
+			info.required = true
+		case "unknown":
+			info.unknown = true
+		default:
+			if key, value, _, err := fastparser.TagOptionValue(opt); key == "transform" && err == nil {
+				info.transform = value
+			}
 		}
 	}
 
@@ -50,6 +69,7 @@ func getFieldInfo(field reflect.StructField) fieldInfo {
 	tag := field.Tag.Get("json")
 
 	info := parseTag(tag)
+	info.unknown = info.unknown && fastparser.CapturesUnknown(field)
 
 	// If no name specified in tag, use the Go field name
 	if info.name == "" && !info.skip {
@@ -74,6 +94,68 @@ func isEmptyValue(v reflect.Value) bool {
 		return v.Float() == 0
 	case reflect.Interface, reflect.Ptr:
 		return v.IsNil()
+	case reflect.Struct:
+		if fastparser.IsSQLNull(v.Type()) {
+			return !v.Field(1).Bool()
+		}
+		if v.CanInterface() {
+			if a, ok := v.Interface().(absenter); ok {
+				return a.isAbsent()
+			}
+		}
 	}
 	return false
 }
+
+// isNestedEmpty reports whether v is empty under
+// MarshalOptions.OmitEmptyNested: empty by the omitempty rules, a struct
+// whose fields are all empty, a map whose values are all empty structs or
+// maps, or a pointer or interface holding such a struct or map. Types with
+// their own MarshalJSON are empty only as their zero value.
+func isNestedEmpty(v reflect.Value) bool {
+	if isEmptyValue(v) {
+		return true
+	}
+	t := v.Type()
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		return v.IsZero()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return isEmptyContainer(v)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" || getFieldInfo(sf).skip {
+				continue
+			}
+			if !isNestedEmpty(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if !isEmptyContainer(iter.Value()) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isEmptyContainer reports whether v is, or points to, a struct or map that
+// isNestedEmpty. Scalars are never empty containers, so a zero held in a map
+// or behind a pointer is kept.
+func isEmptyContainer(v reflect.Value) bool {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+	return (v.Kind() == reflect.Struct || v.Kind() == reflect.Map) && isNestedEmpty(v)
+}
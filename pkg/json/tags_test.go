@@ -1,7 +1,10 @@
+//go:build !tinygo
+
 package json
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -102,6 +105,48 @@ func TestParseTag(t *testing.T) {
 				skip:      false,
 			},
 		},
+		{
+			name: "field name with transform",
+			tag:  "ssn,omitempty,transform:encrypt",
+			expected: fieldInfo{
+				name:      "ssn",
+				omitEmpty: true,
+				transform: "encrypt",
+			},
+		},
+		{
+			name: "field name with coerce",
+			tag:  "age,coerce",
+			expected: fieldInfo{
+				name:   "age",
+				coerce: true,
+			},
+		},
+		{
+			name: "field name with required",
+			tag:  "id, required",
+			expected: fieldInfo{
+				name:     "id",
+				required: true,
+			},
+		},
+		{
+			name: "quoted value holding commas",
+			tag:  "when,default:'a,b,omitempty',required",
+			expected: fieldInfo{
+				name:     "when",
+				required: true,
+			},
+		},
+		{
+			name: "quoted transform",
+			tag:  `ssn,transform:"vault,v2",omitempty`,
+			expected: fieldInfo{
+				name:      "ssn",
+				omitEmpty: true,
+				transform: "vault,v2",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -268,3 +313,156 @@ func TestIsEmptyValue(t *testing.T) {
 		})
 	}
 }
+
+func TestStringOption_RoundTrip(t *testing.T) {
+	type ids struct {
+		ID     int64    `json:"id,string"`
+		Parent *uint64  `json:"parent,string"`
+		Ratio  float64  `json:"ratio,string"`
+		OK     bool     `json:"ok,string"`
+		Plain  int64    `json:"plain"`
+		Tags   []string `json:"tags,string"`
+	}
+	parent := uint64(1 << 60)
+	in := ids{ID: 1<<53 + 1, Parent: &parent, Ratio: 0.5, OK: true, Plain: 7, Tags: []string{"a"}}
+
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"id":"9007199254740993","parent":"1152921504606846976","ratio":"0.5","ok":"true","plain":7,"tags":["a"]}`
+	if string(data) != want {
+		t.Fatalf("Marshal =\n%s\nwant\n%s", data, want)
+	}
+
+	for _, decode := range []func([]byte, interface{}) error{Unmarshal, UnmarshalWithAST} {
+		var out ids
+		if err := decode(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(out, in) {
+			t.Errorf("decoded %+v, want %+v", out, in)
+		}
+
+		// Plain numbers are accepted too
+		out = ids{}
+		if err := decode([]byte(`{"id":12,"parent":3,"ok":false}`), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.ID != 12 || out.Parent == nil || *out.Parent != 3 {
+			t.Errorf("decoded %+v", out)
+		}
+
+		if err := decode([]byte(`{"id":"12x"}`), &out); err == nil {
+			t.Error("expected an error for a malformed quoted number")
+		}
+	}
+}
+
+func TestCoerceOption(t *testing.T) {
+	type person struct {
+		Age    int     `json:"age,coerce"`
+		Score  float64 `json:"score,coerce"`
+		Active *bool   `json:"active,coerce"`
+		Count  int     `json:"count"`
+	}
+
+	for _, decode := range []func([]byte, interface{}) error{Unmarshal, UnmarshalWithAST} {
+		var out person
+		if err := decode([]byte(`{"age":"42","score":"9.5","active":"true","count":3}`), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Age != 42 || out.Score != 9.5 || out.Active == nil || !*out.Active || out.Count != 3 {
+			t.Errorf("decoded %+v", out)
+		}
+
+		// Plain values are accepted too
+		out = person{}
+		if err := decode([]byte(`{"age":7,"active":false}`), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.Age != 7 || out.Active == nil || *out.Active {
+			t.Errorf("decoded %+v", out)
+		}
+
+		// Fields without the option still want numbers
+		if err := decode([]byte(`{"count":"3"}`), &out); err == nil {
+			t.Error("expected an error for a quoted number in a field without coerce")
+		}
+	}
+
+	// Unlike ",string", the option does not change Marshal's output
+	data, err := Marshal(person{Age: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"age":42,"score":0,"active":null,"count":0}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+
+	var out person
+	err = Unmarshal([]byte(`{"age":"forty"}`), &out)
+	if want := `json: age: cannot unmarshal string "forty" into Go value of type int`; err == nil || err.Error() != want {
+		t.Errorf("Unmarshal error = %v, want %s", err, want)
+	}
+}
+
+func TestUnknownOption(t *testing.T) {
+	type event struct {
+		ID    string                `json:"id"`
+		Count int                   `json:"count,omitempty"`
+		Extra map[string]RawMessage `json:",unknown"`
+	}
+	input := `{"id":"e1","source":{"host": "a", "port": 80},"tags":["x"],"count":2}`
+
+	for _, decode := range []func([]byte, interface{}) error{Unmarshal, UnmarshalWithAST} {
+		var out event
+		if err := decode([]byte(input), &out); err != nil {
+			t.Fatal(err)
+		}
+		if out.ID != "e1" || out.Count != 2 || len(out.Extra) != 2 || string(out.Extra["tags"]) != `["x"]` {
+			t.Errorf("decoded %+v", out)
+		}
+
+		data, err := Marshal(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got, want interface{}
+		_ = Unmarshal(data, &got)
+		_ = Unmarshal([]byte(input), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("round trip = %s, want %s", data, input)
+		}
+	}
+
+	// Unknown members follow the fields, and never replace one
+	in := event{ID: "e2", Extra: map[string]RawMessage{"b": RawMessage(`1`), "a": RawMessage(`true`), "id": RawMessage(`"shadowed"`)}}
+	data, err := Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":"e2","a":true,"b":1}`; string(data) != want {
+		t.Errorf("Marshal = %s, want %s", data, want)
+	}
+	if data, _ := MarshalWithOptions(in, MarshalOptions{KeyOrder: KeyOrderSorted}); string(data) != `{"a":true,"b":1,"id":"e2"}` {
+		t.Errorf("MarshalWithOptions(KeyOrderSorted) = %s", data)
+	}
+	if data, _ := Marshal(event{ID: "e3"}); string(data) != `{"id":"e3"}` {
+		t.Errorf("Marshal = %s", data)
+	}
+
+	// Errors in unknown members name them
+	type typed struct {
+		Name  string         `json:"name"`
+		Extra map[string]int `json:",unknown"`
+	}
+	var out typed
+	err = Unmarshal([]byte(`{"name":"n","size":"big"}`), &out)
+	if err == nil || !strings.HasPrefix(err.Error(), "json: size: ") {
+		t.Errorf("Unmarshal error = %v", err)
+	}
+	if issues := CheckShape([]byte(`{"name":"n","size":3}`), &out); len(issues) != 0 {
+		t.Errorf("CheckShape = %v", issues)
+	}
+}
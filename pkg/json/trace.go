@@ -0,0 +1,111 @@
+package json
+
+import (
+	"context"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+// TraceSpan is the part of a tracing span that ParseContext and
+// UnmarshalContext annotate. It keeps this package free of a tracing
+// dependency; an OpenTelemetry span fits it with a small adapter:
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) AddEvent(name string, attrs ...json.TraceAttr) {
+//	    kv := make([]attribute.KeyValue, len(attrs))
+//	    for i, a := range attrs {
+//	        switch v := a.Value.(type) {
+//	        case string:
+//	            kv[i] = attribute.String(a.Key, v)
+//	        case int:
+//	            kv[i] = attribute.Int(a.Key, v)
+//	        }
+//	    }
+//	    s.Span.AddEvent(name, trace.WithAttributes(kv...))
+//	}
+//
+//	ctx = json.ContextWithTraceSpan(ctx, otelSpan{trace.SpanFromContext(ctx)})
+//	err := json.UnmarshalContext(ctx, body, &req, json.UnmarshalOptions{})
+//
+// Each operation adds a "json.start" event before it runs and a
+// "json.end" event after, both with the attributes json.op ("parse" or
+// "unmarshal"), json.path ("fast" or "ast"), and json.bytes, the size of
+// the input. The end event adds json.error, the error message, if the
+// operation failed. The time between the two events is the time spent in
+// the operation, so the endpoint that parses 50MB payloads stands out in
+// the trace.
+type TraceSpan interface {
+	AddEvent(name string, attrs ...TraceAttr)
+}
+
+// TraceAttr is an attribute of a TraceSpan event. Value is a string or an
+// int.
+type TraceAttr struct {
+	Key   string
+	Value interface{}
+}
+
+type spanKey struct{}
+
+// ContextWithTraceSpan returns a copy of ctx that carries span, for the
+// operations that take a context to annotate.
+func ContextWithTraceSpan(ctx context.Context, span TraceSpan) context.Context {
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// TraceSpanFromContext returns the TraceSpan ctx carries, or nil.
+func TraceSpanFromContext(ctx context.Context) TraceSpan {
+	span, _ := ctx.Value(spanKey{}).(TraceSpan)
+	return span
+}
+
+// ParseContext is like ParseWithOptions, and annotates the TraceSpan ctx
+// carries, if any, with the start and end of the parse.
+func ParseContext(ctx context.Context, input string, opts ParseOptions) (ast.SchemaNode, error) {
+	span := TraceSpanFromContext(ctx)
+	if span == nil {
+		return ParseWithOptions(input, opts)
+	}
+	attrs := spanAttrs(OpParse, "ast", len(input))
+	span.AddEvent("json.start", attrs...)
+	node, err := ParseWithOptions(input, opts)
+	span.AddEvent("json.end", endAttrs(attrs, err)...)
+	return node, err
+}
+
+// UnmarshalContext is like UnmarshalWithOptions, and annotates the
+// TraceSpan ctx carries, if any, with the start and end of the decode.
+func UnmarshalContext(ctx context.Context, data []byte, v interface{}, opts UnmarshalOptions) error {
+	span := TraceSpanFromContext(ctx)
+	if span == nil {
+		return UnmarshalWithOptions(data, v, opts)
+	}
+	path := "fast"
+	if opts.Path == ASTPath {
+		path = "ast"
+	}
+	attrs := spanAttrs(OpUnmarshal, path, len(data))
+	span.AddEvent("json.start", attrs...)
+	err := UnmarshalWithOptions(data, v, opts)
+	span.AddEvent("json.end", endAttrs(attrs, err)...)
+	return err
+}
+
+// spanAttrs returns the attributes of both events of an operation.
+func spanAttrs(op Operation, path string, bytes int) []TraceAttr {
+	return []TraceAttr{
+		{Key: "json.op", Value: op.String()},
+		{Key: "json.path", Value: path},
+		{Key: "json.bytes", Value: bytes},
+	}
+}
+
+// endAttrs returns the attributes of the end event of an operation that
+// returned err.
+func endAttrs(attrs []TraceAttr, err error) []TraceAttr {
+	if err == nil {
+		return attrs
+	}
+	return append(attrs[:len(attrs):len(attrs)], TraceAttr{Key: "json.error", Value: err.Error()})
+}
@@ -0,0 +1,90 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// eventSpan is a TraceSpan that keeps its events as "name k=v k=v" lines.
+type eventSpan struct {
+	events []string
+}
+
+func (s *eventSpan) AddEvent(name string, attrs ...TraceAttr) {
+	parts := []string{name}
+	for _, a := range attrs {
+		parts = append(parts, fmt.Sprintf("%s=%v", a.Key, a.Value))
+	}
+	s.events = append(s.events, strings.Join(parts, " "))
+}
+
+func TestParseContext(t *testing.T) {
+	span := &eventSpan{}
+	ctx := ContextWithTraceSpan(context.Background(), span)
+
+	if _, err := ParseContext(ctx, `{"a":1}`, ParseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseContext(ctx, `{"a":`, ParseOptions{}); err == nil {
+		t.Fatal("ParseContext of truncated input: expected error")
+	}
+
+	want := []string{
+		"json.start json.op=parse json.path=ast json.bytes=7",
+		"json.end json.op=parse json.path=ast json.bytes=7",
+		"json.start json.op=parse json.path=ast json.bytes=5",
+		"json.end json.op=parse json.path=ast json.bytes=5 json.error=",
+	}
+	if len(span.events) != len(want) {
+		t.Fatalf("events = %q", span.events)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(span.events[i], w) {
+			t.Errorf("event %d = %q, want prefix %q", i, span.events[i], w)
+		}
+	}
+}
+
+func TestUnmarshalContext(t *testing.T) {
+	span := &eventSpan{}
+	ctx := ContextWithTraceSpan(context.Background(), span)
+
+	var item planItem
+	data := []byte(`{"sku":"a","qty":2}`)
+	if err := UnmarshalContext(ctx, data, &item, UnmarshalOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnmarshalContext(ctx, data, &item, UnmarshalOptions{Path: ASTPath}); err != nil {
+		t.Fatal(err)
+	}
+	if item.SKU != "a" || item.Qty != 2 {
+		t.Errorf("item = %+v", item)
+	}
+
+	want := []string{
+		"json.start json.op=unmarshal json.path=fast json.bytes=19",
+		"json.end json.op=unmarshal json.path=fast json.bytes=19",
+		"json.start json.op=unmarshal json.path=ast json.bytes=19",
+		"json.end json.op=unmarshal json.path=ast json.bytes=19",
+	}
+	if strings.Join(span.events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events = %q, want %q", span.events, want)
+	}
+}
+
+func TestContextWithoutSpan(t *testing.T) {
+	if TraceSpanFromContext(context.Background()) != nil {
+		t.Error("TraceSpanFromContext(Background) != nil")
+	}
+	var v interface{}
+	if err := UnmarshalContext(context.Background(), []byte(`[1]`), &v, UnmarshalOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseContext(context.Background(), `[1]`, ParseOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,86 @@
+package json
+
+import (
+	"bufio"
+	"io"
+)
+
+// TokenSource reads a stream of values for Transcode, reporting each one as
+// events. JSONSource reads JSON; other formats plug in by implementing it.
+type TokenSource interface {
+	// NextValue reports the next value in the stream to h. It returns
+	// io.EOF when no values remain.
+	NextValue(h EventHandler) error
+}
+
+// TokenSink writes the values produced by a TokenSource. *Writer implements
+// it for JSON; other formats plug in by implementing it.
+type TokenSink interface {
+	BeginObject() error
+	EndObject() error
+	BeginArray() error
+	EndArray() error
+	Name(name string) error
+	String(s string) error
+	Number(raw string) error
+	Bool(b bool) error
+	Null() error
+	Flush() error
+}
+
+// Transcode copies every value from src to dst without building a tree or
+// any Go values, then flushes dst. Memory use is bounded by the nesting
+// depth and the longest string or key, not by the size of the stream, which
+// suits gateways converting between formats on the fly:
+//
+//	// Compact a stream of documents, such as NDJSON or pretty-printed JSON
+//	w := json.NewWriter(os.Stdout)
+//	err := json.Transcode(w, json.NewJSONSource(os.Stdin))
+//
+// Numbers are passed through as their literal text, so no precision is
+// lost. Transcode stops at the first error from src or dst and returns it.
+func Transcode(dst TokenSink, src TokenSource) error {
+	h := EventHandler{
+		OnObjectStart: dst.BeginObject,
+		OnObjectEnd:   dst.EndObject,
+		OnArrayStart:  dst.BeginArray,
+		OnArrayEnd:    dst.EndArray,
+		OnKey:         dst.Name,
+		OnString:      dst.String,
+		OnNumber:      dst.Number,
+		OnBool:        dst.Bool,
+		OnNull:        dst.Null,
+	}
+	for {
+		if err := src.NextValue(h); err != nil {
+			if err == io.EOF {
+				return dst.Flush()
+			}
+			return err
+		}
+	}
+}
+
+// JSONSource is a TokenSource reading JSON values separated by optional
+// whitespace, which covers single documents, NDJSON, and concatenated JSON.
+// It reads its input incrementally, as StreamEvents does.
+type JSONSource struct {
+	w streamWalker
+}
+
+// NewJSONSource returns a JSONSource reading from r.
+func NewJSONSource(r io.Reader) *JSONSource {
+	return &JSONSource{w: streamWalker{r: bufio.NewReader(r), limit: defaultLargeStringThreshold}}
+}
+
+// NextValue implements TokenSource.
+func (s *JSONSource) NextValue(h EventHandler) error {
+	if err := s.w.skipSpace(); err != nil {
+		if err == errStreamEOF {
+			return io.EOF
+		}
+		return err
+	}
+	s.w.h = &h
+	return s.w.value()
+}
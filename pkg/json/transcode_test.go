@@ -0,0 +1,112 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTranscode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"document", "{\n  \"a\": [1, 2.50, true, null],\n  \"b\": {\"c\": \"\\u00e9\\n\"}\n}",
+			`{"a":[1,2.50,true,null],"b":{"c":"é\n"}}` + "\n"},
+		{"ndjson", "{\"id\":1}\n{\"id\":2}\n", `{"id":1}` + "\n" + `{"id":2}` + "\n"},
+		{"concatenated", `1 "two"[3]{}`, "1\n\"two\"\n[3]\n{}\n"},
+		{"big numbers", `[1e400, 123456789012345678901234567890]`, `[1e400,123456789012345678901234567890]` + "\n"},
+		{"empty", " \n ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := Transcode(NewWriter(&out), NewJSONSource(strings.NewReader(tt.input))); err != nil {
+				t.Fatalf("Transcode() error = %v", err)
+			}
+			// Writer separates top-level values with newlines but does not
+			// end the last one
+			if got := out.String(); strings.TrimSuffix(got, "\n") != strings.TrimSuffix(tt.want, "\n") {
+				t.Errorf("Transcode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscodeErrors(t *testing.T) {
+	for _, input := range []string{`{"a": }`, `[1, 2`, `{"a": 1} x`, `{"a" 1}`} {
+		var out bytes.Buffer
+		if err := Transcode(NewWriter(&out), NewJSONSource(strings.NewReader(input))); err == nil {
+			t.Errorf("Transcode(%q) succeeded with output %q", input, out.String())
+		}
+	}
+
+	// Sink errors stop the copy
+	errFull := errors.New("full")
+	sink := &recordingSink{failAfter: 3, err: errFull}
+	if err := Transcode(sink, NewJSONSource(strings.NewReader(`[1, 2, 3, 4]`))); err != errFull {
+		t.Errorf("Transcode() error = %v, want %v", err, errFull)
+	}
+}
+
+func TestTranscodeCustomSink(t *testing.T) {
+	sink := &recordingSink{}
+	err := Transcode(sink, NewJSONSource(strings.NewReader(`{"k": ["v", -0.5, false]} null`)))
+	if err != nil {
+		t.Fatalf("Transcode() error = %v", err)
+	}
+	want := "{ name(k) [ string(v) number(-0.5) bool(false) ] } null flush"
+	if got := strings.Join(sink.calls, " "); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+// TestTranscodeStreams checks that Transcode reads its input as it goes,
+// rather than reading it all before writing.
+func TestTranscodeStreams(t *testing.T) {
+	pr, pw := io.Pipe()
+	sink := &recordingSink{}
+	done := make(chan error)
+	go func() { done <- Transcode(sink, NewJSONSource(pr)) }()
+
+	fmt.Fprint(pw, `{"a": 1}`+"\n")
+	fmt.Fprint(pw, `{"b": 2}`+"\n")
+	pw.Close()
+	if err := <-done; err != nil {
+		t.Fatalf("Transcode() error = %v", err)
+	}
+	if got := len(sink.calls); got != 9 {
+		t.Errorf("got %d calls, want 9: %v", got, sink.calls)
+	}
+}
+
+// recordingSink is a TokenSink that records its calls, failing with err
+// once failAfter calls have been made if failAfter is set.
+type recordingSink struct {
+	calls     []string
+	failAfter int
+	err       error
+}
+
+func (s *recordingSink) record(call string) error {
+	if s.failAfter > 0 && len(s.calls) >= s.failAfter {
+		return s.err
+	}
+	s.calls = append(s.calls, call)
+	return nil
+}
+
+func (s *recordingSink) BeginObject() error      { return s.record("{") }
+func (s *recordingSink) EndObject() error        { return s.record("}") }
+func (s *recordingSink) BeginArray() error       { return s.record("[") }
+func (s *recordingSink) EndArray() error         { return s.record("]") }
+func (s *recordingSink) Name(name string) error  { return s.record("name(" + name + ")") }
+func (s *recordingSink) String(v string) error   { return s.record("string(" + v + ")") }
+func (s *recordingSink) Number(raw string) error { return s.record("number(" + raw + ")") }
+func (s *recordingSink) Bool(b bool) error       { return s.record(fmt.Sprintf("bool(%v)", b)) }
+func (s *recordingSink) Null() error             { return s.record("null") }
+func (s *recordingSink) Flush() error            { return s.record("flush") }
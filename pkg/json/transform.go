@@ -0,0 +1,74 @@
+package json
+
+// Transformer rewrites struct fields tagged with a "transform:<name>" option
+// as they are marshaled and unmarshaled. It lets encryption, tokenization, or
+// masking be applied transparently, without custom MarshalJSON methods on
+// every type that carries sensitive data:
+//
+//	type Customer struct {
+//	    Name string `json:"name"`
+//	    SSN  string `json:"ssn,transform:encrypt"`
+//	}
+//
+//	opts := json.MarshalOptions{Transforms: map[string]json.Transformer{"encrypt": vault}}
+//	data, err := json.MarshalWithOptions(customer, opts)
+//
+// Transformers are registered by name through MarshalOptions.Transforms and
+// UnmarshalOptions.Transforms, so keys and policies stay out of the data
+// types themselves.
+//
+// Both methods work on JSON text. Encode receives the field's JSON name and
+// the normal encoding of its value, and returns the JSON to write in its
+// place; Decode receives the JSON found in the input and returns the JSON to
+// decode into the field. Each must return exactly one valid JSON value. The
+// data slice is only valid for the duration of the call.
+//
+// A transform-tagged field fails closed: if the named transformer is not
+// registered, Marshal, Unmarshal, and their variants return an error instead
+// of reading or writing the field untransformed.
+type Transformer interface {
+	Encode(field string, data []byte) ([]byte, error)
+	Decode(field string, data []byte) ([]byte, error)
+}
+
+// TransformFuncs adapts a pair of functions to the Transformer interface.
+// A nil function passes data through unchanged, which suits one-way
+// transforms such as masking:
+//
+//	mask := json.TransformFuncs{
+//	    EncodeFunc: func(field string, data []byte) ([]byte, error) {
+//	        return []byte(`"***"`), nil
+//	    },
+//	}
+type TransformFuncs struct {
+	EncodeFunc func(field string, data []byte) ([]byte, error)
+	DecodeFunc func(field string, data []byte) ([]byte, error)
+}
+
+// Encode calls f.EncodeFunc, or returns data unchanged if it is nil.
+func (f TransformFuncs) Encode(field string, data []byte) ([]byte, error) {
+	if f.EncodeFunc == nil {
+		return data, nil
+	}
+	return f.EncodeFunc(field, data)
+}
+
+// Decode calls f.DecodeFunc, or returns data unchanged if it is nil.
+func (f TransformFuncs) Decode(field string, data []byte) ([]byte, error) {
+	if f.DecodeFunc == nil {
+		return data, nil
+	}
+	return f.DecodeFunc(field, data)
+}
+
+// decodeTransforms adapts a transformer registry to the fast parser.
+func decodeTransforms(transforms map[string]Transformer) map[string]func(field string, data []byte) ([]byte, error) {
+	if len(transforms) == 0 {
+		return nil
+	}
+	m := make(map[string]func(field string, data []byte) ([]byte, error), len(transforms))
+	for name, t := range transforms {
+		m[name] = t.Decode
+	}
+	return m
+}
@@ -0,0 +1,158 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// base64Transform stands in for encryption: it wraps the field's JSON in a
+// base64 string and unwraps it again on decode.
+var base64Transform = TransformFuncs{
+	EncodeFunc: func(field string, data []byte) ([]byte, error) {
+		return []byte(`"` + base64.StdEncoding.EncodeToString(data) + `"`), nil
+	},
+	DecodeFunc: func(field string, data []byte) ([]byte, error) {
+		var s string
+		if err := Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return base64.StdEncoding.DecodeString(s)
+	},
+}
+
+type transformCustomer struct {
+	Name    string            `json:"name"`
+	SSN     string            `json:"ssn,transform:encrypt"`
+	Cards   []int             `json:"cards,omitempty,transform:encrypt"`
+	Address *transformAddress `json:"address,omitempty"`
+}
+
+type transformAddress struct {
+	Street string `json:"street,transform:mask"`
+}
+
+func TestTransform_RoundTrip(t *testing.T) {
+	transforms := map[string]Transformer{
+		"encrypt": base64Transform,
+		"mask":    TransformFuncs{},
+	}
+
+	in := transformCustomer{
+		Name:    "Ada",
+		SSN:     "123-45-6789",
+		Cards:   []int{4111, 5500},
+		Address: &transformAddress{Street: "Main St"},
+	}
+
+	data, err := MarshalWithOptions(in, MarshalOptions{Transforms: transforms})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	want := `{"name":"Ada","ssn":"IjEyMy00NS02Nzg5Ig==","cards":"WzQxMTEsNTUwMF0=","address":{"street":"Main St"}}`
+	if string(data) != want {
+		t.Errorf("MarshalWithOptions() = %s, want %s", data, want)
+	}
+
+	var out transformCustomer
+	if err := UnmarshalWithOptions(data, &out, UnmarshalOptions{Transforms: transforms}); err != nil {
+		t.Fatalf("UnmarshalWithOptions() error = %v", err)
+	}
+	if out.SSN != in.SSN || len(out.Cards) != 2 || out.Cards[1] != 5500 || out.Address.Street != "Main St" {
+		t.Errorf("UnmarshalWithOptions() = %+v", out)
+	}
+}
+
+func TestTransform_OmitEmptySkipsTransform(t *testing.T) {
+	calls := 0
+	transforms := map[string]Transformer{
+		"encrypt": TransformFuncs{EncodeFunc: func(field string, data []byte) ([]byte, error) {
+			calls++
+			return data, nil
+		}},
+	}
+
+	data, err := MarshalWithOptions(transformCustomer{Name: "Ada"}, MarshalOptions{Transforms: transforms})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	if string(data) != `{"name":"Ada","ssn":""}` {
+		t.Errorf("MarshalWithOptions() = %s", data)
+	}
+	if calls != 1 {
+		t.Errorf("transform called %d times, want 1", calls)
+	}
+}
+
+func TestTransform_Masking(t *testing.T) {
+	mask := TransformFuncs{EncodeFunc: func(field string, data []byte) ([]byte, error) {
+		return []byte(`"***"`), nil
+	}}
+
+	data, err := MarshalWithOptions(transformAddress{Street: "Main St"}, MarshalOptions{
+		Transforms: map[string]Transformer{"mask": mask},
+	})
+	if err != nil {
+		t.Fatalf("MarshalWithOptions() error = %v", err)
+	}
+	if string(data) != `{"street":"***"}` {
+		t.Errorf("MarshalWithOptions() = %s", data)
+	}
+}
+
+func TestTransform_FailsClosed(t *testing.T) {
+	v := transformAddress{Street: "Main St"}
+
+	if _, err := Marshal(v); err == nil || !strings.Contains(err.Error(), `transform "mask"`) {
+		t.Errorf("Marshal() error = %v, want missing transform error", err)
+	}
+	if _, err := MarshalWithOptions(v, MarshalOptions{}); err == nil {
+		t.Error("MarshalWithOptions() without registry: expected error")
+	}
+
+	input := []byte(`{"street":"Main St"}`)
+	if err := Unmarshal(input, &v); err == nil {
+		t.Error("Unmarshal(): expected error")
+	}
+	if err := UnmarshalWithAST(input, &v); err == nil {
+		t.Error("UnmarshalWithAST(): expected error")
+	}
+	if err := UnmarshalWithOptions(input, &v, UnmarshalOptions{
+		Transforms: map[string]Transformer{"other": TransformFuncs{}},
+	}); err == nil {
+		t.Error("UnmarshalWithOptions() with wrong registry: expected error")
+	}
+}
+
+func TestTransform_Errors(t *testing.T) {
+	errBoom := errors.New("boom")
+	failing := TransformFuncs{
+		EncodeFunc: func(string, []byte) ([]byte, error) { return nil, errBoom },
+		DecodeFunc: func(string, []byte) ([]byte, error) { return nil, errBoom },
+	}
+	invalid := TransformFuncs{
+		EncodeFunc: func(string, []byte) ([]byte, error) { return []byte(`"a" "b"`), nil },
+		DecodeFunc: func(string, []byte) ([]byte, error) { return []byte(`"a" "b"`), nil },
+	}
+	v := transformAddress{Street: "Main St"}
+	input := []byte(`{"street":"Main St"}`)
+
+	_, err := MarshalWithOptions(v, MarshalOptions{Transforms: map[string]Transformer{"mask": failing}})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("MarshalWithOptions() error = %v, want wrapped errBoom", err)
+	}
+	err = UnmarshalWithOptions(input, &v, UnmarshalOptions{Transforms: map[string]Transformer{"mask": failing}})
+	if !errors.Is(err, errBoom) {
+		t.Errorf("UnmarshalWithOptions() error = %v, want wrapped errBoom", err)
+	}
+
+	if _, err := MarshalWithOptions(v, MarshalOptions{Transforms: map[string]Transformer{"mask": invalid}}); err == nil {
+		t.Error("MarshalWithOptions() with invalid transform output: expected error")
+	}
+	if err := UnmarshalWithOptions(input, &v, UnmarshalOptions{Transforms: map[string]Transformer{"mask": invalid}}); err == nil {
+		t.Error("UnmarshalWithOptions() with invalid transform output: expected error")
+	}
+}
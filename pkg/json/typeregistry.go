@@ -0,0 +1,128 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"reflect"
+	"sync"
+)
+
+// TypeRegistry replaces the encoding of whole Go types, for types whose
+// definitions cannot be given MarshalJSON methods or struct tags, such as
+// types from the standard library or vendored packages. One registry
+// typically holds the conventions of one API:
+//
+//	api := json.NewTypeRegistry()
+//	json.RegisterType(api,
+//	    func(t time.Time) ([]byte, error) {
+//	        return strconv.AppendInt(nil, t.Unix(), 10), nil
+//	    },
+//	    func(data []byte, t *time.Time) error {
+//	        sec, err := strconv.ParseInt(string(data), 10, 64)
+//	        *t = time.Unix(sec, 0).UTC()
+//	        return err
+//	    })
+//
+//	data, err := json.MarshalWithOptions(event, json.MarshalOptions{Types: api})
+//	err = json.UnmarshalWithOptions(data, &event, json.UnmarshalOptions{Types: api})
+//
+// A registered type is encoded and decoded by its functions wherever it
+// appears: as a struct field, slice element, map value, or behind a
+// pointer or interface. They take precedence over MarshalJSON and
+// UnmarshalJSON methods. Register types before the registry is first
+// used; it is then safe for concurrent use.
+type TypeRegistry struct {
+	encoders map[reflect.Type]func(rv reflect.Value) ([]byte, error)
+	decoders map[reflect.Type]func(data []byte, rv reflect.Value) error
+}
+
+// NewTypeRegistry returns an empty TypeRegistry.
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{
+		encoders: make(map[reflect.Type]func(rv reflect.Value) ([]byte, error)),
+		decoders: make(map[reflect.Type]func(data []byte, rv reflect.Value) error),
+	}
+}
+
+// RegisterType sets how r encodes and decodes values of type T and returns
+// r. encode returns the JSON for a value, which is written as is; decode
+// receives the JSON of a value, including null, and stores it in the
+// target. A nil function leaves that direction to the default rules.
+func RegisterType[T any](r *TypeRegistry, encode func(v T) ([]byte, error), decode func(data []byte, v *T) error) *TypeRegistry {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if encode != nil {
+		r.encoders[t] = func(rv reflect.Value) ([]byte, error) {
+			return encode(rv.Interface().(T))
+		}
+		markOverridden(t)
+	}
+	if decode != nil {
+		r.decoders[t] = func(data []byte, rv reflect.Value) error {
+			return decode(data, rv.Addr().Interface().(*T))
+		}
+	}
+	return r
+}
+
+// overridden holds every type some TypeRegistry has an encoder for. Only
+// these types pay for the check against the registry of the Marshal call.
+var overridden sync.Map // reflect.Type -> struct{}
+
+// markOverridden adds t to overridden. The first time, it also empties the
+// encoder cache, so that encoders already built for t, or for types that
+// contain it, are rebuilt with the check.
+func markOverridden(t reflect.Type) {
+	if _, loaded := overridden.LoadOrStore(t, struct{}{}); loaded {
+		return
+	}
+	encoderMu.Lock()
+	encoderCache.Store(make(map[reflect.Type]encoderFunc))
+	encoderMu.Unlock()
+}
+
+// wrapTypeOverride makes enc, the default encoder for t, defer to the
+// registry of the Marshal call if it has an encoder for t. Pointers to
+// registered types are wrapped too, since a pointer whose element has a
+// MarshalJSON method gets an encoder that never reaches the element's.
+func wrapTypeOverride(t reflect.Type, enc encoderFunc) encoderFunc {
+	if _, ok := overridden.Load(t); ok {
+		return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+			if e.types != nil {
+				if fn, ok := e.types.encoders[t]; ok {
+					return appendOverride(buf, fn, rv)
+				}
+			}
+			return enc(e, buf, rv)
+		}
+	}
+	if t.Kind() == reflect.Ptr {
+		if _, ok := overridden.Load(t.Elem()); ok {
+			return func(e *encodeState, buf []byte, rv reflect.Value) ([]byte, error) {
+				if e.types != nil && !rv.IsNil() {
+					if fn, ok := e.types.encoders[t.Elem()]; ok {
+						return appendOverride(buf, fn, rv.Elem())
+					}
+				}
+				return enc(e, buf, rv)
+			}
+		}
+	}
+	return enc
+}
+
+// appendOverride appends the output of a registered encoder.
+func appendOverride(buf []byte, fn func(reflect.Value) ([]byte, error), rv reflect.Value) ([]byte, error) {
+	b, err := fn(rv)
+	if err != nil {
+		return buf, err
+	}
+	return append(buf, b...), nil
+}
+
+// decodeTypes adapts a type registry to the fast parser.
+func decodeTypes(r *TypeRegistry) map[reflect.Type]func(data []byte, rv reflect.Value) error {
+	if r == nil || len(r.decoders) == 0 {
+		return nil
+	}
+	return r.decoders
+}
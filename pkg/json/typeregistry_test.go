@@ -0,0 +1,98 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func unixTimes() *TypeRegistry {
+	return RegisterType(NewTypeRegistry(),
+		func(t time.Time) ([]byte, error) {
+			return strconv.AppendInt(nil, t.Unix(), 10), nil
+		},
+		func(data []byte, t *time.Time) error {
+			if string(data) == "null" {
+				*t = time.Time{}
+				return nil
+			}
+			sec, err := strconv.ParseInt(string(data), 10, 64)
+			if err != nil {
+				return errors.New("want Unix seconds")
+			}
+			*t = time.Unix(sec, 0).UTC()
+			return nil
+		})
+}
+
+type typedEvent struct {
+	At      time.Time            `json:"at"`
+	Ends    *time.Time           `json:"ends"`
+	History []time.Time          `json:"history"`
+	ByName  map[string]time.Time `json:"by_name"`
+	Any     interface{}          `json:"any"`
+}
+
+func TestTypeRegistry_Marshal(t *testing.T) {
+	at := time.Unix(1700000000, 0).UTC()
+	ev := typedEvent{At: at, Ends: &at, History: []time.Time{at}, ByName: map[string]time.Time{"a": at}, Any: at}
+
+	got, err := MarshalWithOptions(ev, MarshalOptions{Types: unixTimes()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"at":1700000000,"ends":1700000000,"history":[1700000000],"by_name":{"a":1700000000},"any":1700000000}`
+	if string(got) != want {
+		t.Errorf("MarshalWithOptions =\n%s\nwant\n%s", got, want)
+	}
+
+	// Other calls are unaffected
+	got, err = Marshal(ev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), `"at":"2023-11-14T22:13:20Z"`) {
+		t.Errorf("Marshal = %s, want RFC 3339 times", got)
+	}
+	got, err = MarshalWithOptions(at, MarshalOptions{Types: NewTypeRegistry()})
+	if err != nil || string(got) != `"2023-11-14T22:13:20Z"` {
+		t.Errorf("MarshalWithOptions with an empty registry = %s, %v", got, err)
+	}
+}
+
+func TestTypeRegistry_Unmarshal(t *testing.T) {
+	in := `{"at":1700000000,"ends":1700000000,"history":[1700000000],"by_name":{"a":1700000000}}`
+	var ev typedEvent
+	if err := UnmarshalWithOptions([]byte(in), &ev, UnmarshalOptions{Types: unixTimes()}); err != nil {
+		t.Fatal(err)
+	}
+	at := time.Unix(1700000000, 0).UTC()
+	if !ev.At.Equal(at) || ev.Ends == nil || !ev.Ends.Equal(at) || len(ev.History) != 1 || !ev.History[0].Equal(at) || !ev.ByName["a"].Equal(at) {
+		t.Errorf("decoded %+v", ev)
+	}
+
+	err := UnmarshalWithOptions([]byte(`{"history":[1,"x"]}`), &ev, UnmarshalOptions{Types: unixTimes()})
+	if err == nil || err.Error() != "json: history[1]: want Unix seconds" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestTypeRegistry_OneDirection(t *testing.T) {
+	type celsius float64
+	upper := RegisterType[celsius](NewTypeRegistry(), func(c celsius) ([]byte, error) {
+		return []byte(strconv.FormatFloat(float64(c), 'f', 1, 64)), nil
+	}, nil)
+
+	got, err := MarshalWithOptions([]celsius{20}, MarshalOptions{Types: upper})
+	if err != nil || string(got) != `[20.0]` {
+		t.Errorf("MarshalWithOptions = %s, %v", got, err)
+	}
+	var out []celsius
+	if err := UnmarshalWithOptions([]byte(`[21.5]`), &out, UnmarshalOptions{Types: upper}); err != nil || out[0] != 21.5 {
+		t.Errorf("UnmarshalWithOptions = %v, %v", out, err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build tinygo
+
+package json
+
+// TypeRegistry replaces the encoding of whole Go types. TinyGo builds
+// leave it out with the reflection-based codec; the type remains so that
+// MarshalOptions and UnmarshalOptions keep their fields, and setting them
+// is an error.
+type TypeRegistry struct{}
@@ -1,13 +1,18 @@
+//go:build !tinygo
+
 package json
 
 import (
-	"errors"
+	"database/sql"
+	"encoding"
 	"fmt"
 	"reflect"
-	"strconv"
+	"sort"
+	"unicode/utf8"
 
 	"github.com/shapestone/shape-core/pkg/ast"
 	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/internal/parser"
 )
 
 // Unmarshal parses the JSON-encoded data and stores the result in the value pointed to by v.
@@ -25,391 +30,441 @@ import (
 // is nil, Unmarshal allocates a new value for it to point to.
 //
 // To unmarshal JSON into a struct, Unmarshal matches incoming object keys to the keys
-// used by Marshal (either the struct field name or its tag), preferring an exact match
-// but also accepting a case-insensitive match. Unmarshal will only set exported fields.
+// used by Marshal (either the struct field name or its tag) exactly; unlike encoding/json,
+// it does not fall back to a case-insensitive match unless UnmarshalOptions.Compatibility
+// is StrictStdlib. Unmarshal will only set exported fields.
+// A field tagged with the "required" option (`json:"id,required"`) must have a
+// member in the object, or Unmarshal returns an error; a member whose value is null
+// counts as present. A number or bool field tagged with the "coerce" option
+// (`json:"age,coerce"`) accepts its value written as a JSON string as well,
+// without changing how Marshal writes it. A field of type map[string]RawMessage,
+// or another map with string keys, tagged with the "unknown" option
+// (`json:",unknown"`) receives the members that match no other field, so
+// that Marshal can write them back.
 //
 // To unmarshal JSON into an interface value, Unmarshal stores one of these in the interface value:
 //
 //	bool, for JSON booleans
-//	float64, for JSON numbers
+//	int64, for JSON numbers without a fraction or exponent that fit in an int64
+//	float64, for other JSON numbers
 //	string, for JSON strings
 //	[]interface{}, for JSON arrays
 //	map[string]interface{}, for JSON objects
 //	nil for JSON null
 //
-// If the JSON is not valid, Unmarshal returns a parse error.
+// This differs from encoding/json, which stores every number as float64:
+// code that asserts v.(float64) on a decoded 42 gets an int64 instead.
+// UnmarshalOptions.NumberPolicy selects float64 for every number, as
+// encoding/json does, or Number to keep each number's text; so do
+// Decoder.UseNumber and Decoder.SetNumberPolicy.
+//
+// If the JSON is not valid, Unmarshal returns a parse error. An error
+// inside a member or element is returned as a *PathError naming its
+// location, such as users[3].address.zip.
 func Unmarshal(data []byte, v interface{}) error {
+	m, start := startMetrics(nil)
 	// Fast path: Direct parsing without AST construction (4-5x faster)
-	return fastparser.Unmarshal(data, v)
+	err := fastparser.Unmarshal(data, v)
+	observe(m, OpUnmarshal, len(data), start, err)
+	if err != nil {
+		rejected(OpUnmarshal, data, err)
+	}
+	return err
 }
 
-// UnmarshalWithAST parses the JSON-encoded data into an AST first, then unmarshals into v.
-// This is the slower path but allows access to the AST for advanced features.
-// Most users should use Unmarshal() instead for better performance.
-func UnmarshalWithAST(data []byte, v interface{}) error {
-	// Parse JSON into AST
-	node, err := Parse(string(data))
+// UnmarshalWithOptions is like Unmarshal but applies opts.
+//
+// Example:
+//
+//	err := json.UnmarshalWithOptions(data, &customer, json.UnmarshalOptions{
+//	    Transforms: map[string]json.Transformer{"encrypt": vault},
+//	})
+func UnmarshalWithOptions(data []byte, v interface{}, opts UnmarshalOptions) error {
+	m, start := startMetrics(opts.Metrics)
+	err := unmarshalWithOptions(data, v, opts)
+	observe(m, OpUnmarshal, len(data), start, err)
 	if err != nil {
+		rejected(OpUnmarshal, data, err)
+	}
+	return err
+}
+
+// unmarshalWithOptions implements UnmarshalWithOptions.
+func unmarshalWithOptions(data []byte, v interface{}, opts UnmarshalOptions) error {
+	fopts := fastparser.Options{
+		Transforms:    decodeTransforms(opts.Transforms),
+		Interfaces:    decodeResolvers(opts.Resolvers),
+		RejectNull:    opts.RejectNull,
+		Scanners:      opts.Scanners,
+		StrictNumbers: opts.StrictNumbers,
+		Int64AsString: opts.Int64AsString,
+		CoerceStrings: opts.CoerceStrings,
+		Types:         decodeTypes(opts.Types),
+		Stdlib:        opts.Compatibility == StrictStdlib,
+		Numbers:       opts.NumberPolicy.mode(opts.Compatibility),
+	}
+	decode := fastparser.UnmarshalWithOptions
+	if opts.Path == ASTPath {
+		decode = unmarshalAST
+	}
+	if opts.Schema == nil {
+		return decode(data, v, fopts)
+	}
+
+	cursor := opts.Schema.Cursor()
+	fopts.Validator = newSchemaValidator(cursor)
+	if err := decode(data, v, fopts); err != nil {
 		return err
 	}
+	return cursor.Err()
+}
+
+// UnmarshalFields decodes only selected top-level members of a JSON object.
+//
+// fields maps object keys to the destinations they should be decoded into;
+// each destination must be a non-nil pointer. The document is scanned once:
+// requested members are decoded using the same rules as Unmarshal, and every
+// other member is validated and skipped without being decoded. Keys missing
+// from the document leave their destinations untouched.
+//
+// This is intended for hot paths that need a handful of fields from a large
+// payload:
+//
+//	var id int64
+//	var status string
+//	err := json.UnmarshalFields(data, map[string]interface{}{
+//	    "id":     &id,
+//	    "status": &status,
+//	})
+//
+// The input must be a JSON object. Errors decoding a requested member are
+// wrapped with the member's key.
+func UnmarshalFields(data []byte, fields map[string]interface{}) error {
+	return fastparser.UnmarshalFields(data, fields)
+}
 
-	return unmarshalFromNode(node, v)
+// DecodeColumns decodes a JSON array of objects column by column: columns
+// maps member names to pointers to slices, and each slice receives the
+// member's value from every object, in one pass and without a struct per
+// row. This suits analytics code that aggregates a few members of many
+// records:
+//
+//	var prices []float64
+//	var names []string
+//	err := json.DecodeColumns(data, map[string]interface{}{
+//	    "price": &prices,
+//	    "name":  &names,
+//	})
+//	// prices[i] and names[i] come from the i-th object
+//
+// The slices are truncated first, keeping their capacity, and then hold
+// one element per object: the zero value where an object lacks the member
+// or has null, so every column has the same length. Members not named in
+// columns are validated and skipped without being decoded. Values are
+// decoded using the same rules as Unmarshal.
+//
+// The input must be an array whose elements are all objects. Errors
+// decoding a value are wrapped with its row index and member name.
+func DecodeColumns(data []byte, columns map[string]interface{}) error {
+	return fastparser.DecodeColumns(data, columns)
 }
 
-// Unmarshaler is the interface implemented by types that can unmarshal a JSON description of themselves.
-type Unmarshaler interface {
-	UnmarshalJSON([]byte) error
+// UnmarshalWithAST parses the JSON-encoded data into an AST first, then unmarshals into v.
+// It is UnmarshalWithOptions with Path set to ASTPath: the values stored and the
+// errors returned are those of Unmarshal, at several times the cost, since
+// only objects and arrays are decoded from the tree; see ASTPath.
+// Most users should use Unmarshal() instead for better performance.
+func UnmarshalWithAST(data []byte, v interface{}) error {
+	return unmarshalAST(data, v, fastparser.Options{})
 }
 
-// unmarshalFromNode unmarshals an AST node into a Go value
-// This is used by both Unmarshal and Decoder.Decode
-func unmarshalFromNode(node ast.SchemaNode, v interface{}) error {
-	// Use reflection to populate v from AST
+// unmarshalAST is UnmarshalWithOptions by way of the AST: it parses data
+// with the AST parser and decodes the tree with a nodeDecoder.
+//
+// The fast path is the reference for what a document decodes to, and the
+// tree is decoded to match it. Where the AST parser rejects a document
+// that the fast path reads, or reports a syntax error differently, the
+// document is decoded by the fast path instead, so that both paths agree.
+func unmarshalAST(data []byte, v interface{}, opts fastparser.Options) error {
 	rv := reflect.ValueOf(v)
-	if !rv.IsValid() || v == nil {
-		return errors.New("json: Unmarshal(nil)")
+	if !rv.IsValid() || rv.Kind() != reflect.Ptr || rv.IsNil() ||
+		rv.Type().Implements(reflect.TypeOf((*Unmarshaler)(nil)).Elem()) {
+		// Argument errors, and types that are handed the whole document
+		return fastparser.UnmarshalWithOptions(data, v, opts)
 	}
 
-	if rv.Kind() != reflect.Ptr {
-		return errors.New("json: Unmarshal(non-pointer " + rv.Type().String() + ")")
+	node, err := parser.NewParser(string(data)).Parse()
+	if err != nil {
+		return fastparser.UnmarshalWithOptions(data, v, opts)
 	}
+	defer ReleaseTree(node)
 
-	if rv.IsNil() {
-		return errors.New("json: Unmarshal(nil " + rv.Type().String() + ")")
-	}
+	d := newNodeDecoder(data, opts)
+	return d.decode(node, rv.Elem(), opts.Validator)
+}
 
-	// Check if type implements Unmarshaler interface
-	if rv.Type().Implements(reflect.TypeOf((*Unmarshaler)(nil)).Elem()) {
-		// Render node back to JSON
-		jsonBytes, err := Render(node)
-		if err != nil {
-			return err
+// nodeDecoder decodes an AST into Go values with the rules of the fast
+// path. It walks objects and arrays itself, in document order, and hands
+// every other value to fastparser at the value's offset in data: scalars,
+// nulls, interfaces, and types that decode themselves or are registered in
+// Options. Errors are therefore those of the fast path, positions and
+// paths included.
+type nodeDecoder struct {
+	data []byte
+	opts *fastparser.Options // without a Validator; see at
+
+	// Node positions count runes; offset converts them to byte offsets,
+	// resuming from the last node converted
+	ascii      bool
+	runes, pos int
+}
+
+func newNodeDecoder(data []byte, opts fastparser.Options) *nodeDecoder {
+	opts.Validator = nil
+	d := &nodeDecoder{data: data, opts: &opts, ascii: true}
+	for _, c := range data {
+		if c >= utf8.RuneSelf {
+			d.ascii = false
+			break
 		}
-		unmarshaler := rv.Interface().(Unmarshaler)
-		return unmarshaler.UnmarshalJSON(jsonBytes)
 	}
+	return d
+}
 
-	return unmarshalValue(node, rv.Elem())
+// offset returns the byte offset in data at which node starts.
+func (d *nodeDecoder) offset(node ast.SchemaNode) int {
+	r := node.Position().Offset
+	if d.ascii {
+		return r
+	}
+	if r < d.runes {
+		d.runes, d.pos = 0, 0
+	}
+	for d.runes < r && d.pos < len(d.data) {
+		_, n := utf8.DecodeRune(d.data[d.pos:])
+		d.pos += n
+		d.runes++
+	}
+	return d.pos
 }
 
-// unmarshalValue unmarshals an AST node into a reflect.Value
-func unmarshalValue(node ast.SchemaNode, rv reflect.Value) error {
-	// Handle null
-	if lit, ok := node.(*ast.LiteralNode); ok && lit.Value() == nil {
-		// Set to zero value (nil for pointers, zero for values)
-		rv.Set(reflect.Zero(rv.Type()))
-		return nil
+// at returns the options for decoding a value with fastparser, reporting
+// it to val if val is not nil.
+func (d *nodeDecoder) at(val fastparser.Validator) *fastparser.Options {
+	if val == nil {
+		return d.opts
 	}
+	opts := *d.opts
+	opts.Validator = val
+	return &opts
+}
 
-	// Handle interface{} specially
-	if rv.Kind() == reflect.Interface && rv.NumMethod() == 0 {
-		val := nodeToInterface(node)
-		rv.Set(reflect.ValueOf(val))
-		return nil
+// decode decodes node into rv, reporting it to val if val is not nil.
+func (d *nodeDecoder) decode(node ast.SchemaNode, rv reflect.Value, val fastparser.Validator) error {
+	switch node.(type) {
+	case *ast.ObjectNode, *ast.ArrayDataNode:
+	default:
+		return fastparser.DecodeAt(d.data, d.offset(node), rv, d.at(val))
 	}
 
-	// Handle pointers
+	if _, ok := d.opts.Types[rv.Type()]; ok || rv.Kind() == reflect.Interface {
+		return fastparser.DecodeAt(d.data, d.offset(node), rv, d.at(val))
+	}
 	if rv.Kind() == reflect.Ptr {
 		if rv.IsNil() {
 			rv.Set(reflect.New(rv.Type().Elem()))
 		}
-		return unmarshalValue(node, rv.Elem())
+		return d.decode(node, rv.Elem(), val)
 	}
-
-	switch node.Type() {
-	case ast.NodeTypeLiteral:
-		return unmarshalLiteral(node.(*ast.LiteralNode), rv)
-	case ast.NodeTypeObject:
-		return unmarshalObject(node.(*ast.ObjectNode), rv)
-	case ast.NodeTypeArrayData:
-		return unmarshalArrayData(node.(*ast.ArrayDataNode), rv)
-	default:
-		return fmt.Errorf("json: unsupported node type %s", node.Type())
-	}
-}
-
-// unmarshalLiteral unmarshals a literal node into a reflect.Value
-func unmarshalLiteral(node *ast.LiteralNode, rv reflect.Value) error {
-	val := node.Value()
-
-	switch rv.Kind() {
-	case reflect.String:
-		if s, ok := val.(string); ok {
-			rv.SetString(s)
-			return nil
-		}
-		return fmt.Errorf("json: cannot unmarshal %T into Go value of type string", val)
-
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		switch v := val.(type) {
-		case int64:
-			if rv.OverflowInt(v) {
-				return fmt.Errorf("json: value %d overflows %s", v, rv.Type())
-			}
-			rv.SetInt(v)
-			return nil
-		case float64:
-			// Allow conversion from float to int if it's a whole number
-			if v == float64(int64(v)) {
-				i := int64(v)
-				if rv.OverflowInt(i) {
-					return fmt.Errorf("json: value %v overflows %s", v, rv.Type())
-				}
-				rv.SetInt(i)
-				return nil
+	if rv.CanAddr() && rv.Type().PkgPath() != "" {
+		switch rv.Addr().Interface().(type) {
+		case fastparser.Slot, Unmarshaler, sql.Scanner:
+			return fastparser.DecodeAt(d.data, d.offset(node), rv, d.at(val))
+		case encoding.TextUnmarshaler:
+			if d.opts.Stdlib {
+				return fastparser.DecodeAt(d.data, d.offset(node), rv, d.at(val))
 			}
-			return fmt.Errorf("json: cannot unmarshal number %v into Go value of type %s", v, rv.Type())
 		}
-		return fmt.Errorf("json: cannot unmarshal %T into Go value of type %s", val, rv.Type())
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		switch v := val.(type) {
-		case int64:
-			if v < 0 || rv.OverflowUint(uint64(v)) {
-				return fmt.Errorf("json: value %d overflows %s", v, rv.Type())
-			}
-			rv.SetUint(uint64(v))
-			return nil
-		case float64:
-			if v < 0 || v != float64(uint64(v)) {
-				return fmt.Errorf("json: cannot unmarshal number %v into Go value of type %s", v, rv.Type())
-			}
-			u := uint64(v)
-			if rv.OverflowUint(u) {
-				return fmt.Errorf("json: value %v overflows %s", v, rv.Type())
-			}
-			rv.SetUint(u)
-			return nil
-		}
-		return fmt.Errorf("json: cannot unmarshal %T into Go value of type %s", val, rv.Type())
+	}
 
-	case reflect.Float32, reflect.Float64:
-		switch v := val.(type) {
-		case float64:
-			if rv.OverflowFloat(v) {
-				return fmt.Errorf("json: value %v overflows %s", v, rv.Type())
-			}
-			rv.SetFloat(v)
-			return nil
-		case int64:
-			f := float64(v)
-			if rv.OverflowFloat(f) {
-				return fmt.Errorf("json: value %v overflows %s", v, rv.Type())
-			}
-			rv.SetFloat(f)
-			return nil
+	start := d.offset(node)
+	var err error
+	switch n := node.(type) {
+	case *ast.ObjectNode:
+		switch rv.Kind() {
+		case reflect.Struct:
+			err = d.decodeStruct(n, rv, val)
+		case reflect.Map:
+			err = d.decodeMap(n, rv, val)
+		default:
+			return fastparser.DecodeAt(d.data, start, rv, d.at(val))
 		}
-		return fmt.Errorf("json: cannot unmarshal %T into Go value of type %s", val, rv.Type())
-
-	case reflect.Bool:
-		if b, ok := val.(bool); ok {
-			rv.SetBool(b)
-			return nil
+	case *ast.ArrayDataNode:
+		switch rv.Kind() {
+		case reflect.Slice:
+			err = d.decodeSlice(n, rv, val)
+		case reflect.Array:
+			err = d.decodeArray(n, rv, val)
+		default:
+			return fastparser.DecodeAt(d.data, start, rv, d.at(val))
 		}
-		return fmt.Errorf("json: cannot unmarshal %T into Go value of type bool", val)
-
-	default:
-		return fmt.Errorf("json: unsupported type %s", rv.Type())
 	}
+	if err != nil || val == nil {
+		return err
+	}
+	end, err := fastparser.SkipValue(d.data, start)
+	if err != nil {
+		return err
+	}
+	val.Done(d.data[start:end], true)
+	return nil
 }
 
-// unmarshalObject unmarshals an object node into a reflect.Value (struct, map, or slice)
-func unmarshalObject(node *ast.ObjectNode, rv reflect.Value) error {
-	props := node.Properties()
-
-	// Check if this is an array (all keys are numeric strings "0", "1", "2", etc.)
-	if isArray(props) {
-		return unmarshalArray(node, rv)
-	}
+// member is an object member, with the rune offset of its value.
+type member struct {
+	key    string
+	node   ast.SchemaNode
+	offset int
+}
 
-	switch rv.Kind() {
-	case reflect.Struct:
-		return unmarshalStruct(node, rv)
-	case reflect.Map:
-		return unmarshalMap(node, rv)
-	case reflect.Slice:
-		return unmarshalArray(node, rv)
-	default:
-		return fmt.Errorf("json: cannot unmarshal object into Go value of type %s", rv.Type())
+// members returns the members of node in document order.
+func members(node *ast.ObjectNode) []member {
+	props := node.Properties()
+	ms := make([]member, 0, len(props))
+	for key, value := range props {
+		ms = append(ms, member{key, value, value.Position().Offset})
 	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i].offset < ms[j].offset })
+	return ms
 }
 
-// isArray checks if the object node represents a JSON array (numeric string keys)
-func isArray(props map[string]ast.SchemaNode) bool {
-	if len(props) == 0 {
-		return false
+// child returns the validator for a member of the value val checks.
+func child(val fastparser.Validator, key string) fastparser.Validator {
+	if val == nil {
+		return nil
 	}
+	return val.Member(key)
+}
 
-	for i := 0; i < len(props); i++ {
-		if _, ok := props[strconv.Itoa(i)]; !ok {
-			return false
-		}
+// element returns the validator for an element of the value val checks.
+func element(val fastparser.Validator, index int) fastparser.Validator {
+	if val == nil {
+		return nil
 	}
-	return true
+	return val.Element(index)
 }
 
-// unmarshalStruct unmarshals an object node into a struct
-func unmarshalStruct(node *ast.ObjectNode, rv reflect.Value) error {
-	props := node.Properties()
-	structType := rv.Type()
-
-	// Build a map of JSON field names to struct field indices
-	fieldMap := make(map[string]int)
-	for i := 0; i < structType.NumField(); i++ {
-		field := structType.Field(i)
-		if field.PkgPath != "" { // Skip unexported fields
+// decodeStruct decodes an object into a struct.
+func (d *nodeDecoder) decodeStruct(node *ast.ObjectNode, rv reflect.Value, val fastparser.Validator) error {
+	var fields *fastparser.StructFields
+	if d.opts.Stdlib {
+		fields = fastparser.NewStdlibStructFields(rv.Type())
+	} else {
+		fields = fastparser.NewStructFields(rv.Type())
+	}
+	required := fields.Required
+
+	for _, m := range members(node) {
+		mval := child(val, m.key)
+		index, ok := fields.Lookup(m.key, d.opts.Stdlib)
+		if !ok && fields.Unknown >= 0 {
+			if err := d.decodeUnknown(m.node, rv.Field(fields.Unknown), m.key, mval); err != nil {
+				return fastparser.WithKey(err, m.key)
+			}
 			continue
 		}
-
-		info := getFieldInfo(field)
-		if info.skip {
+		if !ok {
+			if mval != nil {
+				start := d.offset(m.node)
+				end, err := fastparser.SkipValue(d.data, start)
+				if err != nil {
+					return err
+				}
+				mval.Done(d.data[start:end], false)
+			}
 			continue
 		}
 
-		fieldMap[info.name] = i
-	}
-
-	// Set struct fields from JSON properties
-	for jsonName, propNode := range props {
-		if fieldIdx, ok := fieldMap[jsonName]; ok {
-			fieldVal := rv.Field(fieldIdx)
-			if err := unmarshalValue(propNode, fieldVal); err != nil {
-				return err
-			}
+		delete(required, index)
+		field, err := fields.Field(rv, index)
+		if err != nil {
+			return fastparser.WithKey(err, m.key)
+		}
+		if name, ok := fields.Transforms[index]; ok {
+			err = fastparser.DecodeTransformedAt(d.data, d.offset(m.node), field, d.at(mval), name, m.key)
+		} else if start := d.offset(m.node); fields.Quoted[index] && d.data[start] == '"' {
+			err = fastparser.DecodeQuotedAt(d.data, start, field, d.at(mval))
+		} else {
+			err = d.decode(m.node, field, mval)
+		}
+		if err != nil {
+			return fastparser.WithKey(err, m.key)
 		}
 	}
+	return fastparser.CheckRequired(rv.Type(), required)
+}
 
+// decodeUnknown decodes a member that matches no struct field into a new
+// entry of m, the field with the ",unknown" option.
+func (d *nodeDecoder) decodeUnknown(node ast.SchemaNode, m reflect.Value, key string, val fastparser.Validator) error {
+	if m.IsNil() {
+		m.Set(reflect.MakeMap(m.Type()))
+	}
+	elem := reflect.New(m.Type().Elem()).Elem()
+	if err := d.decode(node, elem, val); err != nil {
+		return err
+	}
+	m.SetMapIndex(reflect.ValueOf(key).Convert(m.Type().Key()), elem)
 	return nil
 }
 
-// unmarshalMap unmarshals an object node into a map
-func unmarshalMap(node *ast.ObjectNode, rv reflect.Value) error {
-	props := node.Properties()
+// decodeMap decodes an object into a map.
+func (d *nodeDecoder) decodeMap(node *ast.ObjectNode, rv reflect.Value, val fastparser.Validator) error {
 	mapType := rv.Type()
-
-	// Create the map if nil
+	if !fastparser.MapKeySupported(mapType.Key(), d.opts.Stdlib) {
+		return fmt.Errorf("json: unsupported map key type %s", mapType.Key())
+	}
 	if rv.IsNil() {
 		rv.Set(reflect.MakeMap(mapType))
 	}
 
-	keyType := mapType.Key()
-	valueType := mapType.Elem()
-
-	// Only support string keys
-	if keyType.Kind() != reflect.String {
-		return fmt.Errorf("json: unsupported map key type %s", keyType)
-	}
-
-	for key, propNode := range props {
-		// Create a new value of the map's value type
-		elemVal := reflect.New(valueType).Elem()
-
-		// Unmarshal the property into the value
-		if err := unmarshalValue(propNode, elemVal); err != nil {
+	for _, m := range members(node) {
+		elem := reflect.New(mapType.Elem()).Elem()
+		if err := d.decode(m.node, elem, child(val, m.key)); err != nil {
+			return fastparser.WithKey(err, m.key)
+		}
+		key, err := fastparser.MapKey(mapType.Key(), m.key, d.opts.Stdlib)
+		if err != nil {
 			return err
 		}
-
-		// Set the map entry
-		rv.SetMapIndex(reflect.ValueOf(key), elemVal)
+		rv.SetMapIndex(key, elem)
 	}
-
 	return nil
 }
 
-// unmarshalArray unmarshals an array (object with numeric keys) into a slice
-func unmarshalArray(node *ast.ObjectNode, rv reflect.Value) error {
-	props := node.Properties()
-
-	// Determine array length
-	arrayLen := len(props)
-
-	switch rv.Kind() {
-	case reflect.Slice:
-		// Create a new slice of the correct length
-		sliceType := rv.Type()
-		slice := reflect.MakeSlice(sliceType, arrayLen, arrayLen)
-
-		// Unmarshal each element
-		for i := 0; i < arrayLen; i++ {
-			key := strconv.Itoa(i)
-			if propNode, ok := props[key]; ok {
-				elemVal := slice.Index(i)
-				if err := unmarshalValue(propNode, elemVal); err != nil {
-					return err
-				}
-			}
-		}
-
-		rv.Set(slice)
-		return nil
-
-	case reflect.Array:
-		if arrayLen > rv.Len() {
-			return fmt.Errorf("json: array length %d exceeds target array length %d", arrayLen, rv.Len())
-		}
-
-		// Unmarshal each element
-		for i := 0; i < arrayLen; i++ {
-			key := strconv.Itoa(i)
-			if propNode, ok := props[key]; ok {
-				elemVal := rv.Index(i)
-				if err := unmarshalValue(propNode, elemVal); err != nil {
-					return err
-				}
-			}
+// decodeSlice decodes an array into a new slice.
+func (d *nodeDecoder) decodeSlice(node *ast.ArrayDataNode, rv reflect.Value, val fastparser.Validator) error {
+	elements := node.Elements()
+	slice := reflect.MakeSlice(rv.Type(), len(elements), len(elements))
+	for i, e := range elements {
+		if err := d.decode(e, slice.Index(i), element(val, i)); err != nil {
+			return fastparser.WithIndex(err, i)
 		}
-
-		return nil
-
-	default:
-		return fmt.Errorf("json: cannot unmarshal array into Go value of type %s", rv.Type())
 	}
+	rv.Set(slice)
+	return nil
 }
 
-// unmarshalArrayData unmarshals an ArrayDataNode into a slice or array.
-func unmarshalArrayData(node *ast.ArrayDataNode, rv reflect.Value) error {
-	elements := node.Elements()
-	arrayLen := len(elements)
-
-	switch rv.Kind() {
-	case reflect.Slice:
-		// Create a new slice of the correct length
-		sliceType := rv.Type()
-		slice := reflect.MakeSlice(sliceType, arrayLen, arrayLen)
-
-		// Unmarshal each element
-		for i, elem := range elements {
-			elemVal := slice.Index(i)
-			if err := unmarshalValue(elem, elemVal); err != nil {
-				return err
-			}
-		}
-
-		rv.Set(slice)
-		return nil
-
-	case reflect.Array:
-		if arrayLen > rv.Len() {
-			return fmt.Errorf("json: array length %d exceeds target array length %d", arrayLen, rv.Len())
+// decodeArray decodes an array into a Go array, leaving elements past the
+// end of the JSON array as they were.
+func (d *nodeDecoder) decodeArray(node *ast.ArrayDataNode, rv reflect.Value, val fastparser.Validator) error {
+	for i, e := range node.Elements() {
+		if i >= rv.Len() {
+			return fmt.Errorf("json: array length exceeds target array length %d", rv.Len())
 		}
-
-		// Unmarshal each element
-		for i, elem := range elements {
-			elemVal := rv.Index(i)
-			if err := unmarshalValue(elem, elemVal); err != nil {
-				return err
-			}
+		if err := d.decode(e, rv.Index(i), element(val, i)); err != nil {
+			return fastparser.WithIndex(err, i)
 		}
-
-		return nil
-
-	default:
-		return fmt.Errorf("json: cannot unmarshal array into Go value of type %s", rv.Type())
 	}
-}
-
-// nodeToInterface is a wrapper around NodeToInterface for backward compatibility.
-// New code should use NodeToInterface from convert.go directly.
-func nodeToInterface(node ast.SchemaNode) interface{} {
-	return NodeToInterface(node)
+	return nil
 }
@@ -1,9 +1,19 @@
+//go:build !tinygo
+
 package json
 
 import (
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
+// typedCodec is set where Marshal and Unmarshal handle Go values of any
+// type, which TinyGo builds leave to the reflection-based codec.
+const typedCodec = true
+
 // TestUnmarshal_BasicTypes tests unmarshaling into basic Go types
 func TestUnmarshal_BasicTypes(t *testing.T) {
 	tests := []struct {
@@ -614,3 +624,300 @@ func TestUnmarshal_Interface(t *testing.T) {
 		})
 	}
 }
+
+// TestUnmarshalFields tests selective decoding of top-level members
+func TestUnmarshalFields(t *testing.T) {
+	data := []byte(`{
+		"id": 42,
+		"payload": {"big": [1, 2, 3, {"nested": "ignored é"}], "more": null},
+		"status": "active",
+		"tags": ["a", "b"],
+		"extra": true
+	}`)
+
+	t.Run("decodes requested keys only", func(t *testing.T) {
+		var id int64
+		var status string
+		var tags []string
+		err := UnmarshalFields(data, map[string]interface{}{
+			"id":     &id,
+			"status": &status,
+			"tags":   &tags,
+		})
+		if err != nil {
+			t.Fatalf("UnmarshalFields() error = %v", err)
+		}
+		if id != 42 || status != "active" || len(tags) != 2 || tags[1] != "b" {
+			t.Errorf("got id=%d status=%q tags=%v", id, status, tags)
+		}
+	})
+
+	t.Run("missing keys leave target untouched", func(t *testing.T) {
+		missing := "default"
+		if err := UnmarshalFields(data, map[string]interface{}{"nope": &missing}); err != nil {
+			t.Fatalf("UnmarshalFields() error = %v", err)
+		}
+		if missing != "default" {
+			t.Errorf("missing = %q, want default", missing)
+		}
+	})
+
+	t.Run("struct and Unmarshaler targets", func(t *testing.T) {
+		var payload struct {
+			More *int `json:"more"`
+		}
+		doc := NewDocument()
+		err := UnmarshalFields([]byte(`{"p": {"more": null}, "d": {"k": "v"}}`), map[string]interface{}{
+			"p": &payload,
+			"d": doc,
+		})
+		if err != nil {
+			t.Fatalf("UnmarshalFields() error = %v", err)
+		}
+		if payload.More != nil {
+			t.Errorf("payload.More = %v, want nil", payload.More)
+		}
+		if v, _ := doc.GetString("k"); v != "v" {
+			t.Errorf("doc k = %q, want v", v)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var n int
+		var s string
+		tests := []struct {
+			name   string
+			data   string
+			fields map[string]interface{}
+		}{
+			{"non-object", `[1, 2]`, map[string]interface{}{"a": &n}},
+			{"empty input", ``, map[string]interface{}{"a": &n}},
+			{"non-pointer target", `{"a": 1}`, map[string]interface{}{"a": n}},
+			{"nil target", `{"a": 1}`, map[string]interface{}{"a": nil}},
+			{"type mismatch", `{"a": "x"}`, map[string]interface{}{"a": &n}},
+			{"invalid skipped value", `{"a": 1, "b": [1,}`, map[string]interface{}{"a": &n}},
+			{"trailing data", `{"a": 1} x`, map[string]interface{}{"a": &n}},
+			{"bad string in skipped value", `{"b": "\q", "a": "ok"}`, map[string]interface{}{"a": &s}},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if err := UnmarshalFields([]byte(tt.data), tt.fields); err == nil {
+					t.Error("expected error, got nil")
+				}
+			})
+		}
+	})
+}
+
+func TestUnmarshal_NestedUnmarshaler(t *testing.T) {
+	type config struct {
+		Name string    `json:"name"`
+		Meta Document  `json:"meta"`
+		Tags *Array    `json:"tags"`
+		When upperText `json:"when"`
+	}
+	input := `{"name":"svc","meta":{"owner":"ops"},"tags":["a"],"when":"soon"}`
+
+	check := func(t *testing.T, c config) {
+		t.Helper()
+		if owner, _ := c.Meta.GetString("owner"); owner != "ops" {
+			t.Errorf("Meta = %v", c.Meta.ToMap())
+		}
+		if c.Tags == nil || c.Tags.Len() != 1 {
+			t.Errorf("Tags = %v", c.Tags)
+		}
+		if c.When != "SOON" {
+			t.Errorf("When = %q, want SOON", c.When)
+		}
+	}
+
+	t.Run("fast path", func(t *testing.T) {
+		var c config
+		if err := Unmarshal([]byte(input), &c); err != nil {
+			t.Fatal(err)
+		}
+		check(t, c)
+	})
+	t.Run("decoder", func(t *testing.T) {
+		var c config
+		if err := NewDecoder(strings.NewReader(input)).Decode(&c); err != nil {
+			t.Fatal(err)
+		}
+		check(t, c)
+	})
+}
+
+// upperText is a non-struct type with a pointer-receiver UnmarshalJSON.
+type upperText string
+
+func (u *upperText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*u = upperText(strings.ToUpper(s))
+	return nil
+}
+
+func TestUnmarshal_RequiredField(t *testing.T) {
+	type inner struct {
+		Code string `json:"code,required"`
+	}
+	type order struct {
+		ID     string  `json:"id,required"`
+		Note   *string `json:"note,required"`
+		Amount int     `json:"amount,omitempty"`
+		Inner  *inner  `json:"inner,omitempty"`
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "all present", input: `{"id":"a","note":"n","inner":{"code":"x"}}`},
+		{name: "null counts as present", input: `{"id":"a","note":null}`},
+		{name: "empty object", input: `{}`, wantErr: `json: missing required field "id" in Go value of type json.order`},
+		{name: "second missing", input: `{"amount":1,"id":"a"}`, wantErr: `json: missing required field "note" in Go value of type json.order`},
+		{name: "nested missing", input: `{"id":"a","note":"n","inner":{}}`, wantErr: `json: inner: missing required field "code" in Go value of type json.inner`},
+		{name: "null struct is not checked", input: `{"id":"a","note":"n","inner":null}`},
+	}
+
+	decoders := map[string]func(string, *order) error{
+		"fast path": func(s string, o *order) error { return Unmarshal([]byte(s), o) },
+		"ast path":  func(s string, o *order) error { return UnmarshalWithAST([]byte(s), o) },
+		"decoder":   func(s string, o *order) error { return NewDecoder(strings.NewReader(s)).Decode(o) },
+	}
+
+	for _, tt := range tests {
+		for name, decode := range decoders {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				var o order
+				err := decode(tt.input, &o)
+				if tt.wantErr == "" {
+					if err != nil {
+						t.Errorf("unexpected error: %v", err)
+					}
+					return
+				}
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestUnmarshal_ErrorPath(t *testing.T) {
+	type address struct {
+		Zip string `json:"zip"`
+	}
+	type user struct {
+		Name    string   `json:"name"`
+		Address *address `json:"address"`
+	}
+	type payload struct {
+		Users []user          `json:"users"`
+		Meta  map[string]bool `json:"meta"`
+	}
+
+	decoders := map[string]func(string, *payload) error{
+		"fast path": func(s string, p *payload) error { return Unmarshal([]byte(s), p) },
+		"ast path":  func(s string, p *payload) error { return UnmarshalWithAST([]byte(s), p) },
+		"decoder":   func(s string, p *payload) error { return NewDecoder(strings.NewReader(s)).Decode(p) },
+	}
+	tests := []struct {
+		input    string
+		wantPath string
+	}{
+		{`{"users": [{"name": "a"}, {"name": "b"}, {"name": "c"}, {"address": {"zip": 8000}}]}`, "users[3].address.zip"},
+		{`{"meta": {"is-admin": "yes"}}`, "meta['is-admin']"},
+		{`{"users": [{"name": 1}]}`, "users[0].name"},
+	}
+	for _, tt := range tests {
+		for name, decode := range decoders {
+			var p payload
+			err := decode(tt.input, &p)
+			var pe *PathError
+			if !errors.As(err, &pe) {
+				t.Errorf("%s: error = %v, want a *PathError", name, err)
+				continue
+			}
+			if pe.Path != tt.wantPath {
+				t.Errorf("%s: Path = %q, want %q", name, pe.Path, tt.wantPath)
+			}
+			if !strings.HasPrefix(err.Error(), "json: "+tt.wantPath+": cannot unmarshal ") {
+				t.Errorf("%s: error = %q", name, err)
+			}
+		}
+	}
+}
+
+func TestDecodeColumns(t *testing.T) {
+	data := []byte(`[
+		{"name": "pen", "price": 1.5, "tags": ["a"], "skip": {"x": [1, 2]}},
+		{"price": 2, "name": "ink", "when": "2026-10-16T00:00:00Z"},
+		{"name": null, "extra": true},
+		{}
+	]`)
+
+	prices := make([]float64, 5, 16)
+	prices[4] = 99
+	var names []string
+	var tags [][]string
+	var when []time.Time
+	err := DecodeColumns(data, map[string]interface{}{
+		"price": &prices,
+		"name":  &names,
+		"tags":  &tags,
+		"when":  &when,
+	})
+	if err != nil {
+		t.Fatalf("DecodeColumns() error = %v", err)
+	}
+	if want := []float64{1.5, 2, 0, 0}; !reflect.DeepEqual(prices, want) || cap(prices) != 16 {
+		t.Errorf("prices = %v (cap %d), want %v", prices, cap(prices), want)
+	}
+	if want := []string{"pen", "ink", "", ""}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names = %q, want %q", names, want)
+	}
+	if want := [][]string{{"a"}, nil, nil, nil}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %q, want %q", tags, want)
+	}
+	if len(when) != 4 || when[1].Year() != 2026 || !when[0].IsZero() {
+		t.Errorf("when = %v", when)
+	}
+
+	var empty []int
+	if err := DecodeColumns([]byte(` [ ] `), map[string]interface{}{"n": &empty}); err != nil || len(empty) != 0 {
+		t.Errorf("DecodeColumns(empty) = %v, %v", empty, err)
+	}
+}
+
+func TestDecodeColumns_Errors(t *testing.T) {
+	var n []int
+	var s string
+	tests := []struct {
+		name    string
+		data    string
+		columns map[string]interface{}
+		want    string
+	}{
+		{"non-array", `{"n": 1}`, map[string]interface{}{"n": &n}, "json: DecodeColumns requires a JSON array, found '{' at position 0"},
+		{"non-object row", `[{"n": 1}, 2]`, map[string]interface{}{"n": &n}, "json: DecodeColumns row 1 is not an object at position 11"},
+		{"not a slice", `[]`, map[string]interface{}{"n": &s}, `json: DecodeColumns target for "n" must be a non-nil pointer to a slice`},
+		{"nil target", `[]`, map[string]interface{}{"n": nil}, `json: DecodeColumns target for "n" must be a non-nil pointer to a slice`},
+		{"type mismatch", `[{"n": 1}, {"n": "x"}]`, map[string]interface{}{"n": &n}, `json: row 1, column "n": `},
+		{"invalid skipped value", `[{"n": 1, "b": [1,}]`, map[string]interface{}{"n": &n}, ""},
+		{"trailing data", `[] x`, map[string]interface{}{"n": &n}, "unexpected data after JSON value at position 3"},
+		{"unterminated", `[{"n": 1}`, map[string]interface{}{"n": &n}, "unexpected end of JSON input in array"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DecodeColumns([]byte(tt.data), tt.columns)
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("DecodeColumns() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+//go:build tinygo
+
+package json
+
+import (
+	"fmt"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Unmarshal parses the JSON-encoded data and stores the result in the
+// value pointed to by v.
+//
+// TinyGo builds leave out the reflection-based decoder. v must implement
+// Unmarshaler, as Document and Array do, or be a *interface{},
+// *map[string]interface{}, *[]interface{}, *string, *bool, or *float64.
+// Values stored in interfaces are those of the full build.
+func Unmarshal(data []byte, v interface{}) error {
+	m, start := startMetrics(nil)
+	err := fastparser.Unmarshal(data, v)
+	observe(m, OpUnmarshal, len(data), start, err)
+	if err != nil {
+		rejected(OpUnmarshal, data, err)
+	}
+	return err
+}
+
+// UnmarshalWithOptions is like Unmarshal but applies opts. TinyGo builds
+// honor Schema, NumberPolicy, Compatibility as it affects numbers, Path,
+// and Metrics, and return an error if an option that concerns Go types,
+// such as Transforms or RejectNull, is set.
+func UnmarshalWithOptions(data []byte, v interface{}, opts UnmarshalOptions) error {
+	m, start := startMetrics(opts.Metrics)
+	err := unmarshalWithOptions(data, v, opts)
+	observe(m, OpUnmarshal, len(data), start, err)
+	if err != nil {
+		rejected(OpUnmarshal, data, err)
+	}
+	return err
+}
+
+// unmarshalWithOptions implements UnmarshalWithOptions.
+func unmarshalWithOptions(data []byte, v interface{}, opts UnmarshalOptions) error {
+	if name := unsupportedDecodeOption(opts); name != "" {
+		return fmt.Errorf("json: UnmarshalOptions.%s is not supported in TinyGo builds", name)
+	}
+	fopts := fastparser.Options{Numbers: opts.NumberPolicy.mode(opts.Compatibility)}
+	if opts.Schema == nil {
+		return fastparser.UnmarshalWithOptions(data, v, fopts)
+	}
+
+	cursor := opts.Schema.Cursor()
+	fopts.Validator = newSchemaValidator(cursor)
+	if err := fastparser.UnmarshalWithOptions(data, v, fopts); err != nil {
+		return err
+	}
+	return cursor.Err()
+}
+
+// unsupportedDecodeOption returns the name of the first option set in
+// opts that needs the reflection-based decoder, or "".
+func unsupportedDecodeOption(opts UnmarshalOptions) string {
+	switch {
+	case len(opts.Transforms) > 0:
+		return "Transforms"
+	case len(opts.Resolvers) > 0:
+		return "Resolvers"
+	case opts.RejectNull:
+		return "RejectNull"
+	case opts.StrictNumbers:
+		return "StrictNumbers"
+	case opts.Int64AsString:
+		return "Int64AsString"
+	case opts.CoerceStrings:
+		return "CoerceStrings"
+	case opts.Scanners:
+		return "Scanners"
+	case opts.Types != nil:
+		return "Types"
+	}
+	return ""
+}
+
+// unmarshalAST decodes data into v as UnmarshalWithOptions does. TinyGo
+// builds have no tree decoder, so it uses the fast path, which the tree
+// decoder matches.
+func unmarshalAST(data []byte, v interface{}, opts fastparser.Options) error {
+	return fastparser.UnmarshalWithOptions(data, v, opts)
+}
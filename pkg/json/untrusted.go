@@ -0,0 +1,123 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Limits bounds the documents ParseUntrusted accepts. Every field has a
+// conservative default, so the zero value is a complete policy:
+//
+//	field             default       counts
+//	MaxBytes          8 MiB         the whole document
+//	MaxDepth          128           levels of objects and arrays
+//	MaxStringLength   1 MiB         bytes of a string or key, as written
+//	MaxValues         1,048,576     values of all types, the root included
+//
+// A zero field takes its default and a negative one removes the limit;
+// documents nested more than the package's MaxDepth are rejected
+// regardless.
+type Limits struct {
+	MaxBytes        int
+	MaxDepth        int
+	MaxStringLength int
+	MaxValues       int
+
+	// AllowInvalidUTF8 accepts strings holding bytes that are not valid
+	// UTF-8. By default they are rejected, since parsers that repair them
+	// differently can disagree about what a document says.
+	AllowInvalidUTF8 bool
+}
+
+// Default limits of ParseUntrusted; see Limits.
+const (
+	defaultMaxBytes        = 8 << 20
+	defaultMaxDepth        = 128
+	defaultMaxStringLength = 1 << 20
+	defaultMaxValues       = 1 << 20
+)
+
+// ParseUntrusted parses data like Parse, for input from outside the
+// program, such as a request body. It enforces limits, with the defaults
+// for the zero Limits:
+//
+//   - the size, nesting depth, string length, and number of values of the
+//     document are checked in a validation pass before any of it is
+//     parsed, so an oversized document costs no more than a scan up to
+//     the first value over a limit
+//   - invalid UTF-8 is rejected unless lim.AllowInvalidUTF8 is set
+//   - duplicate member names are rejected, as Parse always does, since
+//     parsers that keep different duplicates can be made to disagree
+//
+// A limit that is exceeded is reported as a *LimitError giving its offset.
+// The same input and Limits always produce the same result.
+//
+// Example:
+//
+//	node, err := json.ParseUntrusted(body, json.Limits{})
+//	var limit *json.LimitError
+//	if errors.As(err, &limit) {
+//	    http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+//	}
+func ParseUntrusted(data []byte, lim Limits) (ast.SchemaNode, error) {
+	maxBytes := limitOrDefault(lim.MaxBytes, defaultMaxBytes)
+	if maxBytes > 0 && len(data) > maxBytes {
+		err := &LimitError{Limit: "document size", Max: int64(maxBytes), Offset: int64(maxBytes)}
+		rejected(OpParse, data, err)
+		return nil, err
+	}
+	if !lim.AllowInvalidUTF8 {
+		if i := invalidUTF8(data); i >= 0 {
+			rejectedAt(OpParse, data, i, "invalid UTF-8")
+			return nil, fmt.Errorf("json: invalid UTF-8 at offset %d", i)
+		}
+	}
+
+	err := fastparser.ValidateLimits(data, fastparser.Limits{
+		MaxDepth:        limitOrDefault(lim.MaxDepth, defaultMaxDepth),
+		MaxStringLength: limitOrDefault(lim.MaxStringLength, defaultMaxStringLength),
+		MaxValues:       limitOrDefault(lim.MaxValues, defaultMaxValues),
+	})
+	var le *fastparser.LimitError
+	if errors.As(err, &le) {
+		err = &LimitError{Limit: le.Limit, Max: int64(le.Max), Offset: int64(le.Offset)}
+	}
+	if err != nil {
+		rejected(OpParse, data, err)
+		return nil, err
+	}
+	return Parse(string(data))
+}
+
+// limitOrDefault returns n, def if n is zero, or 0, meaning no limit, if n
+// is negative.
+func limitOrDefault(n, def int) int {
+	switch {
+	case n == 0:
+		return def
+	case n < 0:
+		return 0
+	}
+	return n
+}
+
+// invalidUTF8 returns the offset of the first byte of data that is not part
+// of a valid UTF-8 sequence, or -1.
+func invalidUTF8(data []byte) int {
+	for i := 0; i < len(data); {
+		if data[i] < utf8.RuneSelf {
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return -1
+}
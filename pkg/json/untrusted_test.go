@@ -0,0 +1,75 @@
+package json
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+func TestParseUntrusted(t *testing.T) {
+	node, err := ParseUntrusted([]byte(`{"name": "café", "tags": ["a", "b"]}`), Limits{})
+	if err != nil {
+		t.Fatalf("ParseUntrusted: %v", err)
+	}
+	if obj, ok := node.(*ast.ObjectNode); !ok || len(obj.Properties()) != 2 {
+		t.Errorf("ParseUntrusted = %#v", node)
+	}
+
+	tests := []struct {
+		name  string
+		data  string
+		lim   Limits
+		limit string
+		max   int64
+		off   int64
+	}{
+		{"size", `[1, 2, 3]`, Limits{MaxBytes: 8}, "document size", 8, 8},
+		{"depth", `{"a": [[1]]}`, Limits{MaxDepth: 2}, "nesting depth", 2, 7},
+		{"default depth", strings.Repeat("[", 129) + strings.Repeat("]", 129), Limits{}, "nesting depth", 128, 128},
+		{"string", `{"a": "abcdef"}`, Limits{MaxStringLength: 5}, "string length", 5, 6},
+		{"key", `{"abcdef": 1}`, Limits{MaxStringLength: 5}, "string length", 5, 1},
+		{"values", `[1, 2, 3]`, Limits{MaxValues: 3}, "value count", 3, 7},
+	}
+	for _, tt := range tests {
+		_, err := ParseUntrusted([]byte(tt.data), tt.lim)
+		var le *LimitError
+		if !errors.As(err, &le) {
+			t.Errorf("%s: error = %v, want a *LimitError", tt.name, err)
+			continue
+		}
+		if le.Limit != tt.limit || le.Max != tt.max || le.Offset != tt.off {
+			t.Errorf("%s: error = %+v, want %s %d at %d", tt.name, le, tt.limit, tt.max, tt.off)
+		}
+	}
+}
+
+func TestParseUntrusted_Policies(t *testing.T) {
+	deep := strings.Repeat("[", 200) + strings.Repeat("]", 200)
+	if _, err := ParseUntrusted([]byte(deep), Limits{MaxDepth: -1}); err != nil {
+		t.Errorf("MaxDepth -1: %v", err)
+	}
+	if _, err := ParseUntrusted([]byte(deep), Limits{MaxDepth: 300}); err != nil {
+		t.Errorf("MaxDepth 300: %v", err)
+	}
+	deeper := strings.Repeat("[", MaxDepth+1) + strings.Repeat("]", MaxDepth+1)
+	if _, err := ParseUntrusted([]byte(deeper), Limits{MaxDepth: -1}); !errors.Is(err, ErrDepthExceeded) {
+		t.Errorf("beyond MaxDepth: error = %v, want ErrDepthExceeded", err)
+	}
+
+	bad := []byte("{\"a\": \"\xff\"}")
+	if _, err := ParseUntrusted(bad, Limits{}); err == nil || !strings.Contains(err.Error(), "invalid UTF-8 at offset 7") {
+		t.Errorf("invalid UTF-8: error = %v", err)
+	}
+	if _, err := ParseUntrusted(bad, Limits{AllowInvalidUTF8: true}); err != nil {
+		t.Errorf("AllowInvalidUTF8: %v", err)
+	}
+
+	if _, err := ParseUntrusted([]byte(`{"a": 1, "a": 2}`), Limits{}); err == nil {
+		t.Error("duplicate keys: expected an error")
+	}
+	if _, err := ParseUntrusted([]byte(`{"a" 1, "b": 2}`), Limits{MaxValues: 2}); err == nil || errors.As(err, new(*LimitError)) {
+		t.Errorf("syntax error before a limit: error = %v", err)
+	}
+}
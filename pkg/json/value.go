@@ -0,0 +1,485 @@
+package json
+
+import (
+	"fmt"
+	"iter"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Kind identifies the JSON type held by a Value.
+type Kind uint8
+
+// Kinds of Value. The zero Kind is KindNull.
+const (
+	KindNull Kind = iota
+	KindBool
+	KindNumber
+	KindString
+	KindArray
+	KindObject
+)
+
+// String returns the JSON type name, matching the Type* constants.
+func (k Kind) String() string {
+	switch k {
+	case KindNull:
+		return TypeNull
+	case KindBool:
+		return TypeBoolean
+	case KindNumber:
+		return TypeNumber
+	case KindString:
+		return TypeString
+	case KindArray:
+		return TypeArray
+	case KindObject:
+		return TypeObject
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Value is a tagged union holding any JSON value. It is a safer alternative
+// to interface{} for dynamic data: Kind reports what it holds, and the As*
+// accessors return the value together with a success flag instead of
+// panicking on a failed type assertion.
+//
+// Scalars are stored inline, so creating and copying Values of booleans,
+// numbers, and strings does not allocate. Integers and floating-point
+// numbers are kept apart, so large integers do not lose precision.
+//
+// Objects and arrays share the underlying data with the Document, Array, or
+// native map or slice they came from; use Clone for an independent copy.
+//
+// The zero Value is JSON null.
+//
+// Example:
+//
+//	v, _ := doc.GetValue("port")
+//	switch v.Kind() {
+//	case json.KindNumber:
+//	    port, ok := v.AsInt()
+//	    ...
+//	case json.KindString:
+//	    name, _ := v.AsString()
+//	    ...
+//	}
+type Value struct {
+	kind    Kind
+	isFloat bool        // KindNumber: bits holds a float64, not an int64
+	bits    uint64      // bool, int64, or float64 payload
+	str     string      // KindString payload
+	ref     interface{} // map[string]interface{} or []interface{}
+}
+
+// NullValue returns a Value holding JSON null.
+func NullValue() Value { return Value{} }
+
+// BoolValue returns a Value holding b.
+func BoolValue(b bool) Value {
+	v := Value{kind: KindBool}
+	if b {
+		v.bits = 1
+	}
+	return v
+}
+
+// IntValue returns a Value holding the integer n.
+func IntValue(n int64) Value {
+	return Value{kind: KindNumber, bits: uint64(n)}
+}
+
+// FloatValue returns a Value holding f.
+func FloatValue(f float64) Value {
+	return Value{kind: KindNumber, isFloat: true, bits: math.Float64bits(f)}
+}
+
+// StringValue returns a Value holding s.
+func StringValue(s string) Value {
+	return Value{kind: KindString, str: s}
+}
+
+// ObjectValue returns a Value holding the object d. The Value shares d's
+// data. A nil d yields null.
+func ObjectValue(d *Document) Value {
+	if d == nil {
+		return Value{}
+	}
+	return Value{kind: KindObject, ref: d.data}
+}
+
+// ArrayValue returns a Value holding the array a. The Value shares a's
+// data. A nil a yields null.
+func ArrayValue(a *Array) Value {
+	if a == nil {
+		return Value{}
+	}
+	return Value{kind: KindArray, ref: a.data}
+}
+
+// ValueOf converts a Go value to a Value.
+//
+// Native JSON values (nil, bool, string, any integer or float type,
+// map[string]interface{}, []interface{}), *Document, *Array, frozen
+// documents, and Values are converted directly; maps and slices are shared,
+// frozen data is copied. Any other value is converted by marshaling it, so
+// structs and typed maps work too.
+func ValueOf(v interface{}) (Value, error) {
+	switch val := v.(type) {
+	case nil:
+		return Value{}, nil
+	case Value:
+		return val, nil
+	case bool:
+		return BoolValue(val), nil
+	case string:
+		return StringValue(val), nil
+	case int64:
+		return IntValue(val), nil
+	case int:
+		return IntValue(int64(val)), nil
+	case int8:
+		return IntValue(int64(val)), nil
+	case int16:
+		return IntValue(int64(val)), nil
+	case int32:
+		return IntValue(int64(val)), nil
+	case uint8:
+		return IntValue(int64(val)), nil
+	case uint16:
+		return IntValue(int64(val)), nil
+	case uint32:
+		return IntValue(int64(val)), nil
+	case uint:
+		return uintValue(uint64(val)), nil
+	case uint64:
+		return uintValue(val), nil
+	case float64:
+		return FloatValue(val), nil
+	case float32:
+		return FloatValue(float64(val)), nil
+	case map[string]interface{}:
+		if val == nil {
+			return Value{}, nil
+		}
+		return Value{kind: KindObject, ref: val}, nil
+	case []interface{}:
+		if val == nil {
+			return Value{}, nil
+		}
+		return Value{kind: KindArray, ref: val}, nil
+	case *Document:
+		return ObjectValue(val), nil
+	case *Array:
+		return ArrayValue(val), nil
+	case FrozenDocument:
+		return Value{kind: KindObject, ref: deepCopyMap(val.data)}, nil
+	case FrozenArray:
+		return Value{kind: KindArray, ref: deepCopySlice(val.data)}, nil
+	}
+
+	if rv := reflect.ValueOf(v); (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && rv.IsNil() {
+		return Value{}, nil
+	}
+	data, err := Marshal(v)
+	if err != nil {
+		return Value{}, fmt.Errorf("json: ValueOf: %w", err)
+	}
+	return ParseValue(data)
+}
+
+// uintValue stores n as an integer when it fits in int64.
+func uintValue(n uint64) Value {
+	if n > math.MaxInt64 {
+		return FloatValue(float64(n))
+	}
+	return IntValue(int64(n))
+}
+
+// ParseValue parses a single JSON value.
+func ParseValue(data []byte) (Value, error) {
+	raw, err := fastparser.NewParser(data).Parse()
+	if err != nil {
+		return Value{}, err
+	}
+	return ValueOf(raw)
+}
+
+// ValuesOf converts a slice of native values, such as the results of a
+// jsonpath query, to Values:
+//
+//	expr, _ := jsonpath.ParseString("$.items[*].price")
+//	for _, v := range json.ValuesOf(expr.Get(data)) {
+//	    if price, ok := v.AsFloat(); ok {
+//	        total += price
+//	    }
+//	}
+//
+// Elements that cannot be converted become null.
+func ValuesOf(items []interface{}) []Value {
+	values := make([]Value, len(items))
+	for i, item := range items {
+		values[i], _ = ValueOf(item)
+	}
+	return values
+}
+
+// Kind returns the JSON type of the value.
+func (v Value) Kind() Kind { return v.kind }
+
+// IsNull reports whether the value is JSON null.
+func (v Value) IsNull() bool { return v.kind == KindNull }
+
+// AsBool returns the boolean and true, or false and false if v is not a
+// boolean.
+func (v Value) AsBool() (bool, bool) {
+	if v.kind != KindBool {
+		return false, false
+	}
+	return v.bits != 0, true
+}
+
+// AsInt returns the number as an int64. It fails for non-numbers and for
+// floats that are not integral or are out of range, rather than truncating.
+func (v Value) AsInt() (int64, bool) {
+	if v.kind != KindNumber {
+		return 0, false
+	}
+	if !v.isFloat {
+		return int64(v.bits), true
+	}
+	f := math.Float64frombits(v.bits)
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// AsFloat returns the number as a float64, or 0 and false if v is not a
+// number.
+func (v Value) AsFloat() (float64, bool) {
+	if v.kind != KindNumber {
+		return 0, false
+	}
+	if v.isFloat {
+		return math.Float64frombits(v.bits), true
+	}
+	return float64(int64(v.bits)), true
+}
+
+// IsInt reports whether v holds a number stored as an integer.
+func (v Value) IsInt() bool { return v.kind == KindNumber && !v.isFloat }
+
+// AsString returns the string and true, or "" and false if v is not a
+// string.
+func (v Value) AsString() (string, bool) {
+	if v.kind != KindString {
+		return "", false
+	}
+	return v.str, true
+}
+
+// AsObject returns the object as a Document sharing v's data, or nil and
+// false if v is not an object.
+func (v Value) AsObject() (*Document, bool) {
+	if v.kind != KindObject {
+		return nil, false
+	}
+	return &Document{data: v.ref.(map[string]interface{})}, true
+}
+
+// AsArray returns the array as an Array sharing v's data, or nil and false
+// if v is not an array.
+func (v Value) AsArray() (*Array, bool) {
+	if v.kind != KindArray {
+		return nil, false
+	}
+	return &Array{data: v.ref.([]interface{})}, true
+}
+
+// Len returns the number of elements of an array or members of an object,
+// and 0 for any other kind.
+func (v Value) Len() int {
+	switch ref := v.ref.(type) {
+	case map[string]interface{}:
+		return len(ref)
+	case []interface{}:
+		return len(ref)
+	}
+	return 0
+}
+
+// Get returns the member named key of an object. It returns false if v is
+// not an object or has no such member.
+func (v Value) Get(key string) (Value, bool) {
+	m, ok := v.ref.(map[string]interface{})
+	if !ok {
+		return Value{}, false
+	}
+	child, ok := m[key]
+	if !ok {
+		return Value{}, false
+	}
+	cv, err := ValueOf(child)
+	return cv, err == nil
+}
+
+// Index returns the i'th element of an array. It returns false if v is not
+// an array or i is out of range.
+func (v Value) Index(i int) (Value, bool) {
+	s, ok := v.ref.([]interface{})
+	if !ok || i < 0 || i >= len(s) {
+		return Value{}, false
+	}
+	cv, err := ValueOf(s[i])
+	return cv, err == nil
+}
+
+// Keys returns the member names of an object in sorted order, or nil if v
+// is not an object.
+func (v Value) Keys() []string {
+	m, ok := v.ref.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Interface returns the value in its native form: nil, bool, int64,
+// float64, string, map[string]interface{}, or []interface{}.
+func (v Value) Interface() interface{} {
+	switch v.kind {
+	case KindBool:
+		return v.bits != 0
+	case KindNumber:
+		if v.isFloat {
+			return math.Float64frombits(v.bits)
+		}
+		return int64(v.bits)
+	case KindString:
+		return v.str
+	case KindArray, KindObject:
+		return v.ref
+	}
+	return nil
+}
+
+// Clone returns a deep copy of v.
+func (v Value) Clone() Value {
+	if v.ref != nil {
+		v.ref = deepCopyValue(v.ref)
+	}
+	return v
+}
+
+// String returns the value as compact JSON text.
+func (v Value) String() string {
+	data, err := v.MarshalJSON()
+	if err != nil {
+		return "<invalid: " + err.Error() + ">"
+	}
+	return string(data)
+}
+
+// MarshalJSON implements the Marshaler interface.
+func (v Value) MarshalJSON() ([]byte, error) {
+	switch v.kind {
+	case KindNull:
+		return []byte("null"), nil
+	case KindBool:
+		if v.bits != 0 {
+			return []byte("true"), nil
+		}
+		return []byte("false"), nil
+	case KindNumber:
+		if !v.isFloat {
+			return strconv.AppendInt(nil, int64(v.bits), 10), nil
+		}
+	}
+	return marshal(defaultEncodeState, v.Interface())
+}
+
+// UnmarshalJSON implements the Unmarshaler interface.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	parsed, err := ParseValue(data)
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// GetValue gets a value as a Value. Returns false if the key is not found.
+func (d *Document) GetValue(key string) (Value, bool) {
+	val, ok := d.data[key]
+	if !ok {
+		return Value{}, false
+	}
+	v, err := ValueOf(val)
+	return v, err == nil
+}
+
+// SetValue sets a Value. Objects and arrays are stored shared, not copied.
+func (d *Document) SetValue(key string, value Value) *Document {
+	d.store(key, value.Interface())
+	return d
+}
+
+// GetValue gets the value at index as a Value. Returns false if the index
+// is out of range.
+func (a *Array) GetValue(index int) (Value, bool) {
+	if index < 0 || index >= len(a.data) {
+		return Value{}, false
+	}
+	v, err := ValueOf(a.data[index])
+	return v, err == nil
+}
+
+// AddValue appends a Value. Objects and arrays are stored shared, not copied.
+func (a *Array) AddValue(value Value) *Array {
+	a.data = append(a.data, value.Interface())
+	return a
+}
+
+// All returns an iterator over the members of the Document as Values, in
+// no particular order, as for Keys:
+//
+//	for key, v := range doc.All() {
+//	    fmt.Println(key, v.Kind())
+//	}
+//
+// Unlike Keys, it builds no slice. As in ValuesOf, values that cannot be
+// converted become null. Members may be set or removed during the
+// iteration, with the same effect as for a range over a map.
+func (d *Document) All() iter.Seq2[string, Value] {
+	return func(yield func(string, Value) bool) {
+		for key, val := range d.data {
+			v, _ := ValueOf(val)
+			if !yield(key, v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the elements of the Array as Values, in
+// order. As in ValuesOf, elements that cannot be converted become null.
+func (a *Array) Values() iter.Seq[Value] {
+	return func(yield func(Value) bool) {
+		for _, val := range a.data {
+			v, _ := ValueOf(val)
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package json
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValue_Scalars(t *testing.T) {
+	var zero Value
+	if !zero.IsNull() || zero.Kind() != KindNull || zero.String() != "null" {
+		t.Errorf("zero Value = %v (%v), want null", zero, zero.Kind())
+	}
+
+	b := BoolValue(true)
+	if got, ok := b.AsBool(); !ok || !got {
+		t.Errorf("AsBool() = %v, %v", got, ok)
+	}
+	if _, ok := b.AsString(); ok {
+		t.Error("AsString() on bool succeeded")
+	}
+
+	i := IntValue(math.MaxInt64)
+	if got, ok := i.AsInt(); !ok || got != math.MaxInt64 {
+		t.Errorf("AsInt() = %v, %v", got, ok)
+	}
+	if !i.IsInt() || i.String() != "9223372036854775807" {
+		t.Errorf("large int = %v", i)
+	}
+
+	f := FloatValue(2.5)
+	if got, ok := f.AsFloat(); !ok || got != 2.5 {
+		t.Errorf("AsFloat() = %v, %v", got, ok)
+	}
+	if _, ok := f.AsInt(); ok {
+		t.Error("AsInt() truncated 2.5")
+	}
+	if got, ok := FloatValue(3).AsInt(); !ok || got != 3 {
+		t.Errorf("AsInt(3.0) = %v, %v", got, ok)
+	}
+	if _, ok := FloatValue(1e20).AsInt(); ok {
+		t.Error("AsInt(1e20) succeeded")
+	}
+	if got, ok := IntValue(-4).AsFloat(); !ok || got != -4 {
+		t.Errorf("AsFloat(int) = %v, %v", got, ok)
+	}
+
+	s := StringValue("hi")
+	if got, ok := s.AsString(); !ok || got != "hi" || s.Kind() != KindString {
+		t.Errorf("AsString() = %q, %v", got, ok)
+	}
+	if s.Len() != 0 || s.Keys() != nil {
+		t.Error("Len/Keys on string should be empty")
+	}
+}
+
+func TestValue_ScalarsDoNotAllocate(t *testing.T) {
+	allocs := testing.AllocsPerRun(100, func() {
+		v := StringValue("x")
+		_, _ = v.AsString()
+		n := IntValue(42)
+		_, _ = n.AsInt()
+		_, _ = BoolValue(true).AsBool()
+	})
+	if allocs != 0 {
+		t.Errorf("scalar Values allocated %v times", allocs)
+	}
+}
+
+func TestValue_Containers(t *testing.T) {
+	v, err := ParseValue([]byte(`{"name":"svc","ports":[80,443],"tls":{"on":true},"ratio":0.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Kind() != KindObject || v.Len() != 4 {
+		t.Fatalf("Kind = %v, Len = %d", v.Kind(), v.Len())
+	}
+	if got := v.Keys(); !reflect.DeepEqual(got, []string{"name", "ports", "ratio", "tls"}) {
+		t.Errorf("Keys() = %v", got)
+	}
+
+	ports, ok := v.Get("ports")
+	if !ok || ports.Kind() != KindArray || ports.Len() != 2 {
+		t.Fatalf("Get(ports) = %v, %v", ports, ok)
+	}
+	p1, ok := ports.Index(1)
+	if n, _ := p1.AsInt(); !ok || n != 443 {
+		t.Errorf("Index(1) = %v, %v", p1, ok)
+	}
+	if _, ok := ports.Index(2); ok {
+		t.Error("Index(2) out of range succeeded")
+	}
+	if _, ok := v.Get("missing"); ok {
+		t.Error("Get(missing) succeeded")
+	}
+	if _, ok := ports.Get("x"); ok {
+		t.Error("Get on array succeeded")
+	}
+
+	// Objects share data with the Document view
+	doc, ok := v.AsObject()
+	if !ok {
+		t.Fatal("AsObject failed")
+	}
+	doc.SetString("name", "changed")
+	if name, _ := v.Get("name"); name.String() != `"changed"` {
+		t.Errorf("AsObject did not share data: %v", name)
+	}
+
+	// Clone does not
+	c := v.Clone()
+	doc.SetString("name", "again")
+	if name, _ := c.Get("name"); name.String() != `"changed"` {
+		t.Errorf("Clone shares data: %v", name)
+	}
+
+	arr, ok := ports.AsArray()
+	if !ok || arr.Len() != 2 {
+		t.Errorf("AsArray() = %v, %v", arr, ok)
+	}
+	if _, ok := v.AsArray(); ok {
+		t.Error("AsArray on object succeeded")
+	}
+}
+
+func TestValueOf(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	type point struct {
+		X int `json:"x"`
+	}
+	var nilDoc *Document
+	tests := []struct {
+		in   interface{}
+		kind Kind
+		json string
+	}{
+		{nil, KindNull, `null`},
+		{true, KindBool, `true`},
+		{"s", KindString, `"s"`},
+		{7, KindNumber, `7`},
+		{int8(-7), KindNumber, `-7`},
+		{uint64(math.MaxUint64), KindNumber, `1.8446744073709552e+19`},
+		{float32(1.5), KindNumber, `1.5`},
+		{map[string]interface{}{"a": int64(1)}, KindObject, `{"a":1}`},
+		{[]interface{}{"x"}, KindArray, `["x"]`},
+		{NewDocument().SetInt("a", 1), KindObject, `{"a":1}`},
+		{NewArray().AddBool(false), KindArray, `[false]`},
+		{NewDocument().SetInt("a", 1).Freeze(), KindObject, `{"a":1}`},
+		{IntValue(3), KindNumber, `3`},
+		{point{X: 2}, KindObject, `{"x":2}`},
+		{[]string{"a"}, KindArray, `["a"]`},
+		{nilDoc, KindNull, `null`},
+		{[]int(nil), KindNull, `null`},
+	}
+	for _, tt := range tests {
+		v, err := ValueOf(tt.in)
+		if err != nil {
+			t.Errorf("ValueOf(%#v) error = %v", tt.in, err)
+			continue
+		}
+		if v.Kind() != tt.kind || v.String() != tt.json {
+			t.Errorf("ValueOf(%#v) = %s (%v), want %s (%v)", tt.in, v, v.Kind(), tt.json, tt.kind)
+		}
+	}
+
+	if _, err := ValueOf(make(chan int)); err == nil {
+		t.Error("ValueOf(chan) expected error")
+	}
+}
+
+func TestValue_MarshalUnmarshal(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	type wrapper struct {
+		ID    Value   `json:"id"`
+		Extra []Value `json:"extra"`
+	}
+
+	var w wrapper
+	if err := Unmarshal([]byte(`{"id":"abc","extra":[1,2.5,null,{"k":[true]}]}`), &w); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if s, ok := w.ID.AsString(); !ok || s != "abc" {
+		t.Errorf("ID = %v", w.ID)
+	}
+	if len(w.Extra) != 4 || !w.Extra[0].IsInt() || w.Extra[1].IsInt() || !w.Extra[2].IsNull() || w.Extra[3].Kind() != KindObject {
+		t.Errorf("Extra = %v", w.Extra)
+	}
+
+	data, err := Marshal(w)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if want := `{"id":"abc","extra":[1,2.5,null,{"k":[true]}]}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	// The AST path used by Decoder honors nested Unmarshalers too
+	var viaDecoder wrapper
+	if err := NewDecoder(strings.NewReader(`{"id":7,"extra":[]}`)).Decode(&viaDecoder); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if n, ok := viaDecoder.ID.AsInt(); !ok || n != 7 {
+		t.Errorf("Decode() ID = %v", viaDecoder.ID)
+	}
+
+	var v Value
+	if err := v.UnmarshalJSON([]byte(`[1,`)); err == nil {
+		t.Error("UnmarshalJSON(invalid) expected error")
+	}
+}
+
+func TestValue_DOM(t *testing.T) {
+	doc := NewDocument().SetValue("n", IntValue(5)).SetValue("s", StringValue("x"))
+	if n, ok := doc.GetInt("n"); !ok || n != 5 {
+		t.Errorf("GetInt after SetValue = %v, %v", n, ok)
+	}
+	v, ok := doc.GetValue("s")
+	if got, _ := v.AsString(); !ok || got != "x" {
+		t.Errorf("GetValue(s) = %v, %v", v, ok)
+	}
+	if _, ok := doc.GetValue("missing"); ok {
+		t.Error("GetValue(missing) succeeded")
+	}
+
+	arr := NewArray().AddValue(BoolValue(true)).AddValue(ObjectValue(doc))
+	if v, ok := arr.GetValue(1); !ok || v.Kind() != KindObject {
+		t.Errorf("GetValue(1) = %v, %v", v, ok)
+	}
+	if _, ok := arr.GetValue(5); ok {
+		t.Error("GetValue(5) succeeded")
+	}
+}
+
+func TestValuesOf(t *testing.T) {
+	got := ValuesOf([]interface{}{int64(1), "a", nil, make(chan int)})
+	kinds := []Kind{KindNumber, KindString, KindNull, KindNull}
+	for i, k := range kinds {
+		if got[i].Kind() != k {
+			t.Errorf("ValuesOf()[%d].Kind() = %v, want %v", i, got[i].Kind(), k)
+		}
+	}
+}
+
+func TestKind_String(t *testing.T) {
+	if KindObject.String() != TypeObject || KindBool.String() != TypeBoolean || Kind(42).String() != "Kind(42)" {
+		t.Error("unexpected Kind names")
+	}
+}
+
+func TestDocument_All(t *testing.T) {
+	doc := NewDocument().SetString("a", "x").SetInt("b", 2).SetNull("c")
+	got := map[string]Kind{}
+	for key, v := range doc.All() {
+		got[key] = v.Kind()
+	}
+	want := map[string]Kind{"a": KindString, "b": KindNumber, "c": KindNull}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("All() = %v, want %v", got, want)
+	}
+
+	n := 0
+	for range doc.All() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("All() continued after break: %d", n)
+	}
+}
+
+func TestArray_Values(t *testing.T) {
+	arr := NewArray().AddString("a").AddInt(1).AddBool(true)
+	var got []string
+	for v := range arr.Values() {
+		got = append(got, v.String())
+	}
+	if want := []string{`"a"`, "1", "true"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Values() = %q, want %q", got, want)
+	}
+}
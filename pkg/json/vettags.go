@@ -0,0 +1,74 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"reflect"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/internal/structtag"
+)
+
+// TagProblem is a mistake in a json struct tag found by VetTags.
+type TagProblem struct {
+	Field   string // path to the field, such as "main.Order.Items[*].SKU"
+	Tag     string // the json tag of the field
+	Message string
+}
+
+// String formats the problem as "<field>: <message>".
+func (p TagProblem) String() string {
+	return p.Field + ": " + p.Message
+}
+
+// VetTags checks the json tags of the fields of t, and of every struct
+// type reachable from it, and reports the options that this package would
+// silently ignore: misspellings such as "omitempy", options missing their
+// value, unterminated quotes, and options that do not apply to the type
+// of their field. It returns nil if it finds nothing. Tests can call it to
+// catch typos that would otherwise surface as fields quietly encoded the
+// wrong way:
+//
+//	func TestTags(t *testing.T) {
+//	    for _, p := range json.VetTags(reflect.TypeOf(Order{})) {
+//	        t.Error(p) // main.Order.Items[*].SKU: unknown option "omitempy" (did you mean "omitempty"?)
+//	    }
+//	}
+func VetTags(t reflect.Type) []TagProblem {
+	if t == nil {
+		return nil
+	}
+	var problems []TagProblem
+	vetTags(t, t.String(), map[reflect.Type]bool{}, &problems)
+	return problems
+}
+
+// vetTags adds the problems of the struct types reachable from t, which
+// is at path, to problems.
+func vetTags(t reflect.Type, path string, seen map[reflect.Type]bool, problems *[]TagProblem) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		vetTags(t.Elem(), path, seen, problems)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		vetTags(t.Elem(), path+"[*]", seen, problems)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if sf.PkgPath != "" {
+				continue
+			}
+			fieldPath := path + "." + sf.Name
+			if tag, ok := sf.Tag.Lookup("json"); ok {
+				for _, msg := range structtag.Vet(tag, sf.Type.String(), fastparser.CapturesUnknown(sf)) {
+					*problems = append(*problems, TagProblem{Field: fieldPath, Tag: tag, Message: msg})
+				}
+			}
+			vetTags(sf.Type, fieldPath, seen, problems)
+		}
+	}
+}
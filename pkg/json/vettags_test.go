@@ -0,0 +1,57 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestVetTags(t *testing.T) {
+	type line struct {
+		SKU   string            `json:"sku,omitempy"`
+		Qty   int               `json:"qty,strnig"`
+		Extra map[string]string `json:",unknown"`
+	}
+	type order struct {
+		ID      int64             `json:"id,required,required"`
+		Lines   []line            `json:"lines"`
+		Secret  string            `json:"secret,transform"`
+		Flag    bool              `json:"flag,omitempty:yes"`
+		When    string            `json:"when,default:'a,b"`
+		Layout  string            `json:"layout,format:'2006-01-02,15:04'"`
+		Rest    []string          `json:"rest,unknown"`
+		Skipped chan int          `json:"-"`
+		Fine    map[string]string `json:"fine,omitempty,transform:'vault,v2'"`
+		Empty   int               `json:"empty,,omitempty"`
+		Next    *order            `json:"next,omitempty"`
+	}
+
+	var got []string
+	for _, p := range VetTags(reflect.TypeOf(order{})) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`json.order.ID: option "required" repeated`,
+		`json.order.Lines[*].SKU: unknown option "omitempy" (did you mean "omitempty"?)`,
+		`json.order.Lines[*].Qty: unknown option "strnig" (did you mean "string"?)`,
+		`json.order.Secret: option "transform" needs a value, as in transform:<name>`,
+		`json.order.Flag: option "omitempty" takes no value`,
+		`json.order.When: option "default:'a,b": unterminated quote`,
+		`json.order.Layout: option "format" is reserved and has no effect`,
+		`json.order.Rest: option "unknown" is ignored on a field of type []string; it needs a map with string keys`,
+		`json.order.Empty: empty option`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("VetTags =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestVetTagsClean(t *testing.T) {
+	for _, v := range []interface{}{planOrder{}, 0, nil} {
+		if problems := VetTags(reflect.TypeOf(v)); problems != nil {
+			t.Errorf("VetTags(%T) = %v", v, problems)
+		}
+	}
+}
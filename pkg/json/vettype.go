@@ -0,0 +1,132 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/internal/structtag"
+)
+
+// TypeIssue is a problem with a Go type found by VetType.
+type TypeIssue struct {
+	Field   string // path to the field, such as "main.Order.Items[*].SKU"
+	Message string
+}
+
+// String formats the issue as "<field>: <message>".
+func (i TypeIssue) String() string {
+	return i.Field + ": " + i.Message
+}
+
+// VetType checks t, and every type reachable from it, for what would fail
+// or surprise at run time, so that a test or a startup check catches it
+// before production does:
+//
+//   - channel, func, and complex fields, which cannot be encoded or decoded
+//   - maps whose keys are not strings
+//   - fields of one struct that share a JSON name, which Marshal writes as
+//     duplicate members
+//   - embedded structs, which this package encodes as a member named after
+//     the type rather than flattening their fields into the parent as
+//     encoding/json does, and unexported embedded structs, whose fields
+//     it does not encode at all
+//   - the tag mistakes VetTags reports
+//
+// Types with their own MarshalJSON or UnmarshalJSON methods, or an
+// encoder in the TypeRegistry, are not looked into. It returns nil if it
+// finds nothing.
+//
+// Example:
+//
+//	for _, issue := range json.VetType(reflect.TypeOf(Order{})) {
+//	    log.Println(issue) // main.Order.Notify: unsupported type chan main.Event
+//	}
+func VetType(t reflect.Type) []TypeIssue {
+	if t == nil {
+		return nil
+	}
+	var issues []TypeIssue
+	vetType(t, t.String(), map[reflect.Type]bool{}, &issues)
+	return issues
+}
+
+// vetType adds the issues of t, which is at path, and of the types
+// reachable from it to issues.
+func vetType(t reflect.Type, path string, seen map[reflect.Type]bool, issues *[]TypeIssue) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) ||
+		t.Implements(unmarshalerType) || reflect.PointerTo(t).Implements(unmarshalerType) {
+		return
+	}
+	if _, ok := overridden.Load(t); ok {
+		return
+	}
+	if fastparser.IsSQLNull(t) {
+		vetType(t.Field(0).Type, path, seen, issues)
+		return
+	}
+
+	report := func(path, format string, args ...interface{}) {
+		*issues = append(*issues, TypeIssue{Field: path, Message: fmt.Sprintf(format, args...)})
+	}
+	switch t.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Complex64, reflect.Complex128, reflect.UnsafePointer:
+		report(path, "unsupported type %s", t)
+	case reflect.Ptr:
+		vetType(t.Elem(), path, seen, issues)
+	case reflect.Slice, reflect.Array:
+		vetType(t.Elem(), path+"[*]", seen, issues)
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			report(path, "unsupported map key type %s", t.Key())
+		}
+		vetType(t.Elem(), path+"[*]", seen, issues)
+	case reflect.Struct:
+		names := map[string]string{} // JSON name -> Go field name
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			fieldPath := path + "." + sf.Name
+			tag, tagged := sf.Tag.Lookup("json")
+			embedded := sf.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if sf.PkgPath != "" {
+				if sf.Anonymous && embedded.Kind() == reflect.Struct && tag != "-" {
+					report(fieldPath, "embedded %s is not encoded, since it is unexported; its fields are not promoted as in encoding/json", sf.Type)
+				}
+				continue
+			}
+			name, ok := structtag.Name(sf.Name, tag)
+			if !ok {
+				continue
+			}
+			if tagged {
+				for _, msg := range structtag.Vet(tag, sf.Type.String(), fastparser.CapturesUnknown(sf)) {
+					report(fieldPath, "%s", msg)
+				}
+			}
+
+			ft := sf.Type
+			if fastparser.CapturesUnknown(sf) {
+				ft = ft.Elem()
+			} else {
+				if other, dup := names[name]; dup {
+					report(fieldPath, "JSON name %q is also that of field %s", name, other)
+				}
+				names[name] = sf.Name
+				if sf.Anonymous && embedded.Kind() == reflect.Struct && (tag == "" || tag[0] == ',') {
+					report(fieldPath, "embedded %s is encoded as member %q, not flattened as in encoding/json", sf.Type, name)
+				}
+			}
+			vetType(ft, fieldPath, seen, issues)
+		}
+	}
+}
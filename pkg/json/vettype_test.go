@@ -0,0 +1,94 @@
+//go:build !tinygo
+
+package json
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type vetEvent struct {
+	At time.Time `json:"at"`
+}
+
+type vetBase struct {
+	ID int `json:"id"`
+}
+
+type vetAudit struct {
+	By string
+}
+
+type vetOrder struct {
+	vetBase
+	*vetAudit `json:"-"`
+	Base      vetBase        `json:"base"`
+	Notify    chan vetEvent  `json:"notify"`
+	Callback  func()         `json:"-"`
+	Ratio     complex128     `json:"ratio"`
+	ByCode    map[int]string `json:"by_code"`
+	Name      string
+	Title     string              `json:"Name,omitempy"`
+	Items     []vetItem           `json:"items"`
+	Extra     map[string]string   `json:",unknown"`
+	Raw       RawMessage          `json:"raw"`
+	Hooks     map[string]func()   `json:"hooks"`
+	Nested    *vetOrder           `json:"nested,omitempty"`
+	Embedded  struct{ vetBase }   `json:"embedded"`
+	Scores    map[string][]string `json:"scores"`
+}
+
+type vetItem struct {
+	Events []vetEvent `json:"events"`
+	When   chan int
+}
+
+func TestVetType(t *testing.T) {
+	var got []string
+	for _, issue := range VetType(reflect.TypeOf(vetOrder{})) {
+		got = append(got, issue.String())
+	}
+	want := []string{
+		`json.vetOrder.vetBase: embedded json.vetBase is not encoded, since it is unexported; its fields are not promoted as in encoding/json`,
+		`json.vetOrder.Notify: unsupported type chan json.vetEvent`,
+		`json.vetOrder.Ratio: unsupported type complex128`,
+		`json.vetOrder.ByCode: unsupported map key type int`,
+		`json.vetOrder.Title: unknown option "omitempy" (did you mean "omitempty"?)`,
+		`json.vetOrder.Title: JSON name "Name" is also that of field Name`,
+		`json.vetOrder.Items[*].When: unsupported type chan int`,
+		`json.vetOrder.Hooks[*]: unsupported type func()`,
+		`json.vetOrder.Embedded.vetBase: embedded json.vetBase is not encoded, since it is unexported; its fields are not promoted as in encoding/json`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("VetType =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+type VetExported struct {
+	Name string `json:"name"`
+}
+
+type VetTagged struct {
+	SKU string `json:"sku"`
+}
+
+func TestVetTypeEmbedded(t *testing.T) {
+	type wrapper struct {
+		VetExported
+		*VetTagged `json:"item"`
+	}
+	issues := VetType(reflect.TypeOf(wrapper{}))
+	if len(issues) != 1 || issues[0].Message != `embedded json.VetExported is encoded as member "VetExported", not flattened as in encoding/json` {
+		t.Errorf("VetType = %v", issues)
+	}
+}
+
+func TestVetTypeClean(t *testing.T) {
+	for _, v := range []interface{}{planOrder{}, Nullable[int]{}, map[string]interface{}{}, 0, nil} {
+		if issues := VetType(reflect.TypeOf(v)); issues != nil {
+			t.Errorf("VetType(%T) = %v", v, issues)
+		}
+	}
+}
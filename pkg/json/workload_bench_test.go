@@ -0,0 +1,119 @@
+package json_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	shapejson "github.com/shapestone/shape-json/pkg/json"
+)
+
+// ================================
+// Workload Benchmarks
+// ================================
+//
+// Each workload file stresses one kind of content, so that optimizations
+// are measured on the data they target. The files are written by
+// scripts/generate_workloads. Each workload decodes into the Go type an
+// application would use for it, by the fast path, the AST path, and
+// encoding/json. The benchmark report groups the results by workload,
+// named by the middle part of the benchmark name.
+
+// workloadRecord is a record of objects.json.
+type workloadRecord struct {
+	ID       int     `json:"id"`
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Score    float64 `json:"score"`
+	Verified bool    `json:"verified"`
+	Team     *string `json:"team"`
+}
+
+// workloads maps workload names to their file and a function returning a
+// new value to decode into.
+var workloads = map[string]struct {
+	file   string
+	target func() interface{}
+}{
+	"Nested":  {"nested.json", func() interface{} { return new(interface{}) }},
+	"Floats":  {"floats.json", func() interface{} { return new([]float64) }},
+	"Escapes": {"escapes.json", func() interface{} { return new([]string) }},
+	"Objects": {"objects.json", func() interface{} { return new([]workloadRecord) }},
+}
+
+// benchmarkWorkload decodes the named workload with decode b.N times.
+func benchmarkWorkload(b *testing.B, name string, decode func([]byte, interface{}) error) {
+	w := workloads[name]
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "benchmarks", w.file))
+	if err != nil {
+		b.Fatalf("Failed to load workload %s: %v", name, err)
+	}
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := decode(data, w.target()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func astPath(data []byte, v interface{}) error {
+	return shapejson.UnmarshalWithOptions(data, v, shapejson.UnmarshalOptions{Path: shapejson.ASTPath})
+}
+
+// BenchmarkWorkload_Nested_FastPath benchmarks deeply nested objects and arrays.
+func BenchmarkWorkload_Nested_FastPath(b *testing.B) {
+	benchmarkWorkload(b, "Nested", shapejson.Unmarshal)
+}
+
+func BenchmarkWorkload_Nested_ASTPath(b *testing.B) {
+	benchmarkWorkload(b, "Nested", astPath)
+}
+
+func BenchmarkWorkload_Nested_EncodingJSON(b *testing.B) {
+	benchmarkWorkload(b, "Nested", json.Unmarshal)
+}
+
+// BenchmarkWorkload_Floats_FastPath benchmarks a flat array of floats.
+func BenchmarkWorkload_Floats_FastPath(b *testing.B) {
+	benchmarkWorkload(b, "Floats", shapejson.Unmarshal)
+}
+
+func BenchmarkWorkload_Floats_ASTPath(b *testing.B) {
+	benchmarkWorkload(b, "Floats", astPath)
+}
+
+func BenchmarkWorkload_Floats_EncodingJSON(b *testing.B) {
+	benchmarkWorkload(b, "Floats", json.Unmarshal)
+}
+
+// BenchmarkWorkload_Escapes_FastPath benchmarks strings made mostly of
+// escape sequences.
+func BenchmarkWorkload_Escapes_FastPath(b *testing.B) {
+	benchmarkWorkload(b, "Escapes", shapejson.Unmarshal)
+}
+
+func BenchmarkWorkload_Escapes_ASTPath(b *testing.B) {
+	benchmarkWorkload(b, "Escapes", astPath)
+}
+
+func BenchmarkWorkload_Escapes_EncodingJSON(b *testing.B) {
+	benchmarkWorkload(b, "Escapes", json.Unmarshal)
+}
+
+// BenchmarkWorkload_Objects_FastPath benchmarks many small objects decoded
+// into structs.
+func BenchmarkWorkload_Objects_FastPath(b *testing.B) {
+	benchmarkWorkload(b, "Objects", shapejson.Unmarshal)
+}
+
+func BenchmarkWorkload_Objects_ASTPath(b *testing.B) {
+	benchmarkWorkload(b, "Objects", astPath)
+}
+
+func BenchmarkWorkload_Objects_EncodingJSON(b *testing.B) {
+	benchmarkWorkload(b, "Objects", json.Unmarshal)
+}
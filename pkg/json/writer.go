@@ -0,0 +1,443 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// writerFlushThreshold is the buffered size at which a Writer flushes to the
+// underlying io.Writer. Keeping the buffer small bounds memory use when
+// generating very large documents.
+const writerFlushThreshold = 32 * 1024
+
+// containerKind identifies the kind of container a Writer is inside.
+type containerKind uint8
+
+const (
+	containerObject containerKind = iota + 1
+	containerArray
+)
+
+// writerFrame tracks the state of one open object or array.
+type writerFrame struct {
+	kind    containerKind
+	count   int  // number of members/elements written so far
+	hasName bool // object only: a Name has been written and awaits its value
+}
+
+// A Writer emits JSON tokens directly to an io.Writer without building any
+// intermediate tree. It tracks nesting, inserts commas and colons, and escapes
+// strings, so callers only describe the document structure:
+//
+//	w := json.NewWriter(os.Stdout)
+//	w.BeginObject()
+//	w.Name("name")
+//	w.String("Alice")
+//	w.Name("tags")
+//	w.BeginArray()
+//	w.String("go")
+//	w.String("json")
+//	w.EndArray()
+//	w.EndObject()
+//	if err := w.Flush(); err != nil {
+//	    // handle error
+//	}
+//	// Output: {"name":"Alice","tags":["go","json"]}
+//
+// Errors are sticky: after the first error (an I/O failure or a call that
+// would produce invalid JSON) every method returns that same error and writes
+// nothing further. It is therefore safe to ignore the return values of the
+// individual emit calls and check only the result of Flush.
+//
+// Multiple top-level values may be written in sequence; they are separated by
+// newlines, matching the output of Encoder.
+//
+// A Writer is not safe for concurrent use.
+type Writer struct {
+	w     io.Writer
+	buf   []byte
+	stack []writerFrame
+	top   int // number of completed top-level values
+	err   error
+}
+
+// NewWriter returns a new Writer that writes to w.
+// Output is buffered; call Flush when done.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w:   w,
+		buf: make([]byte, 0, 1024),
+	}
+}
+
+// BeginObject starts a JSON object.
+func (w *Writer) BeginObject() error {
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	w.buf = append(w.buf, '{')
+	w.stack = append(w.stack, writerFrame{kind: containerObject})
+	return nil
+}
+
+// EndObject closes the innermost open object.
+func (w *Writer) EndObject() error {
+	if w.err != nil {
+		return w.err
+	}
+	frame := w.current()
+	if frame == nil || frame.kind != containerObject {
+		return w.fail(errors.New("json: Writer.EndObject called outside of an object"))
+	}
+	if frame.hasName {
+		return w.fail(errors.New("json: Writer.EndObject called after Name without a value"))
+	}
+	w.buf = append(w.buf, '}')
+	return w.endContainer()
+}
+
+// BeginArray starts a JSON array.
+func (w *Writer) BeginArray() error {
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	w.buf = append(w.buf, '[')
+	w.stack = append(w.stack, writerFrame{kind: containerArray})
+	return nil
+}
+
+// EndArray closes the innermost open array.
+func (w *Writer) EndArray() error {
+	if w.err != nil {
+		return w.err
+	}
+	frame := w.current()
+	if frame == nil || frame.kind != containerArray {
+		return w.fail(errors.New("json: Writer.EndArray called outside of an array"))
+	}
+	w.buf = append(w.buf, ']')
+	return w.endContainer()
+}
+
+// Name writes an object member name. It must be called inside an object and
+// be followed by exactly one value.
+func (w *Writer) Name(name string) error {
+	if w.err != nil {
+		return w.err
+	}
+	frame := w.current()
+	if frame == nil || frame.kind != containerObject {
+		return w.fail(errors.New("json: Writer.Name called outside of an object"))
+	}
+	if frame.hasName {
+		return w.fail(errors.New("json: Writer.Name called twice without a value"))
+	}
+	if frame.count > 0 {
+		w.buf = append(w.buf, ',')
+	}
+	w.buf = append(w.buf, '"')
+	w.buf = appendEscapedString(w.buf, name)
+	w.buf = append(w.buf, '"', ':')
+	frame.hasName = true
+	return nil
+}
+
+// String writes a JSON string value.
+func (w *Writer) String(s string) error {
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	w.buf = append(w.buf, '"')
+	w.buf = appendEscapedString(w.buf, s)
+	w.buf = append(w.buf, '"')
+	return w.afterValue()
+}
+
+// Int writes an integer value.
+func (w *Writer) Int(n int64) error {
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	w.buf = strconv.AppendInt(w.buf, n, 10)
+	return w.afterValue()
+}
+
+// Uint writes an unsigned integer value.
+func (w *Writer) Uint(n uint64) error {
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	w.buf = strconv.AppendUint(w.buf, n, 10)
+	return w.afterValue()
+}
+
+// Float writes a floating-point value.
+// NaN and infinities cannot be represented in JSON and produce an error.
+func (w *Writer) Float(f float64) error {
+	if w.err != nil {
+		return w.err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return w.fail(errors.New("json: unsupported value: " + strconv.FormatFloat(f, 'g', -1, 64)))
+	}
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	w.buf = strconv.AppendFloat(w.buf, f, 'g', -1, 64)
+	return w.afterValue()
+}
+
+// Number writes a number given as its JSON literal text, such as "-1.5e3",
+// unchanged. It keeps the exact spelling and precision of numbers copied
+// from another document. Text that is not a JSON number produces an error.
+func (w *Writer) Number(raw string) error {
+	if w.err != nil {
+		return w.err
+	}
+	if !isJSONNumber(raw) {
+		return w.fail(fmt.Errorf("json: invalid number literal %q", raw))
+	}
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	w.buf = append(w.buf, raw...)
+	return w.afterValue()
+}
+
+// Bool writes a boolean value.
+func (w *Writer) Bool(b bool) error {
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	if b {
+		w.buf = append(w.buf, "true"...)
+	} else {
+		w.buf = append(w.buf, "false"...)
+	}
+	return w.afterValue()
+}
+
+// Null writes a JSON null.
+func (w *Writer) Null() error {
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	w.buf = append(w.buf, "null"...)
+	return w.afterValue()
+}
+
+// Value writes an arbitrary Go value using the same encoding rules as Marshal.
+// It is useful for embedding small pre-built values inside a streamed document.
+func (w *Writer) Value(v interface{}) error {
+	if w.err != nil {
+		return w.err
+	}
+	data, err := Marshal(v)
+	if err != nil {
+		return w.fail(err)
+	}
+	if err := w.beforeValue(); err != nil {
+		return err
+	}
+	w.buf = append(w.buf, data...)
+	return w.afterValue()
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+// It returns the first error encountered by the Writer, if any.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+	if _, err := w.w.Write(w.buf); err != nil {
+		return w.fail(err)
+	}
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// Close flushes the Writer and reports an error if any object or array is
+// still open. It does not close the underlying io.Writer.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(w.stack) > 0 {
+		return w.fail(errors.New("json: Writer closed with unterminated object or array"))
+	}
+	return nil
+}
+
+// Depth returns the current nesting depth (0 at the top level).
+func (w *Writer) Depth() int {
+	return len(w.stack)
+}
+
+// current returns the innermost open container, or nil at the top level.
+func (w *Writer) current() *writerFrame {
+	if len(w.stack) == 0 {
+		return nil
+	}
+	return &w.stack[len(w.stack)-1]
+}
+
+// beforeValue validates that a value may be written here and emits any
+// separator required before it.
+func (w *Writer) beforeValue() error {
+	if w.err != nil {
+		return w.err
+	}
+	frame := w.current()
+	if frame == nil {
+		if w.top > 0 {
+			w.buf = append(w.buf, '\n')
+		}
+		return nil
+	}
+	switch frame.kind {
+	case containerObject:
+		if !frame.hasName {
+			return w.fail(errors.New("json: Writer value inside object must be preceded by Name"))
+		}
+	case containerArray:
+		if frame.count > 0 {
+			w.buf = append(w.buf, ',')
+		}
+	}
+	return nil
+}
+
+// afterValue records that a value was completed in the current container.
+func (w *Writer) afterValue() error {
+	frame := w.current()
+	if frame == nil {
+		w.top++
+	} else {
+		frame.count++
+		frame.hasName = false
+	}
+	if len(w.buf) >= writerFlushThreshold {
+		return w.Flush()
+	}
+	return nil
+}
+
+// endContainer pops the innermost container and completes it as a value of its parent.
+func (w *Writer) endContainer() error {
+	w.stack = w.stack[:len(w.stack)-1]
+	return w.afterValue()
+}
+
+// fail records err as the sticky error and returns it.
+func (w *Writer) fail(err error) error {
+	if w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// ArrayWriter streams a single JSON array one element at a time, for
+// endpoints that write rows as they are read instead of collecting them
+// first:
+//
+//	aw := json.NewArrayWriter(w)
+//	for rows.Next() {
+//	    var u User
+//	    // scan u
+//	    if err := aw.Write(u); err != nil {
+//	        return err
+//	    }
+//	}
+//	return aw.Close() // writes the closing ']'
+//
+// Elements are encoded with Marshal and buffered like a Writer's output;
+// call Flush to push what has been written so far to the client. Errors
+// are sticky, as for Writer. An ArrayWriter closed without elements writes
+// [].
+type ArrayWriter struct {
+	w      *Writer
+	closed bool
+}
+
+// NewArrayWriter returns an ArrayWriter that writes an array to w.
+func NewArrayWriter(w io.Writer) *ArrayWriter {
+	aw := &ArrayWriter{w: NewWriter(w)}
+	aw.w.BeginArray()
+	return aw
+}
+
+// Write appends v to the array.
+func (aw *ArrayWriter) Write(v interface{}) error {
+	if aw.closed {
+		return aw.w.fail(errors.New("json: ArrayWriter.Write called after Close"))
+	}
+	return aw.w.Value(v)
+}
+
+// Flush writes the buffered elements to the underlying io.Writer.
+func (aw *ArrayWriter) Flush() error {
+	return aw.w.Flush()
+}
+
+// Close ends the array and flushes it. It does not close the underlying
+// io.Writer. Calling Close again has no effect.
+func (aw *ArrayWriter) Close() error {
+	if !aw.closed {
+		aw.closed = true
+		aw.w.EndArray()
+	}
+	return aw.w.Close()
+}
+
+// ObjectWriter streams a single JSON object one member at a time. It is the
+// object counterpart of ArrayWriter:
+//
+//	ow := json.NewObjectWriter(w)
+//	ow.Write("count", n)
+//	ow.Write("items", items)
+//	return ow.Close() // writes the closing '}'
+//
+// Keys are written as given; ObjectWriter does not check for duplicates.
+type ObjectWriter struct {
+	w      *Writer
+	closed bool
+}
+
+// NewObjectWriter returns an ObjectWriter that writes an object to w.
+func NewObjectWriter(w io.Writer) *ObjectWriter {
+	ow := &ObjectWriter{w: NewWriter(w)}
+	ow.w.BeginObject()
+	return ow
+}
+
+// Write adds the member key with value v to the object.
+func (ow *ObjectWriter) Write(key string, v interface{}) error {
+	if ow.closed {
+		return ow.w.fail(errors.New("json: ObjectWriter.Write called after Close"))
+	}
+	if err := ow.w.Name(key); err != nil {
+		return err
+	}
+	return ow.w.Value(v)
+}
+
+// Flush writes the buffered members to the underlying io.Writer.
+func (ow *ObjectWriter) Flush() error {
+	return ow.w.Flush()
+}
+
+// Close ends the object and flushes it. It does not close the underlying
+// io.Writer. Calling Close again has no effect.
+func (ow *ObjectWriter) Close() error {
+	if !ow.closed {
+		ow.closed = true
+		ow.w.EndObject()
+	}
+	return ow.w.Close()
+}
@@ -0,0 +1,288 @@
+package json
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestWriter_Object(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+
+	w.BeginObject()
+	w.Name("name")
+	w.String("Alice \"A\"\n")
+	w.Name("age")
+	w.Int(30)
+	w.Name("score")
+	w.Float(9.5)
+	w.Name("active")
+	w.Bool(true)
+	w.Name("manager")
+	w.Null()
+	w.Name("tags")
+	w.BeginArray()
+	w.String("go")
+	w.Uint(7)
+	w.BeginObject()
+	w.EndObject()
+	w.EndArray()
+	w.EndObject()
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	want := `{"name":"Alice \"A\"\n","age":30,"score":9.5,"active":true,"manager":null,"tags":["go",7,{}]}`
+	if out.String() != want {
+		t.Errorf("output = %s, want %s", out.String(), want)
+	}
+	if err := Validate(out.String()); err != nil {
+		t.Errorf("output is not valid JSON: %v", err)
+	}
+}
+
+func TestWriter_Value(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	var out bytes.Buffer
+	w := NewWriter(&out)
+
+	w.BeginArray()
+	w.Value(map[string]interface{}{"b": 2, "a": 1})
+	w.Value(struct {
+		ID int `json:"id"`
+	}{ID: 5})
+	w.EndArray()
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := `[{"a":1,"b":2},{"id":5}]`
+	if out.String() != want {
+		t.Errorf("output = %s, want %s", out.String(), want)
+	}
+}
+
+func TestWriter_MultipleTopLevelValues(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+
+	w.Int(1)
+	w.BeginObject()
+	w.EndObject()
+	w.String("x")
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if out.String() != "1\n{}\n\"x\"" {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestWriter_StateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		emit func(w *Writer) error
+	}{
+		{"name at top level", func(w *Writer) error { return w.Name("a") }},
+		{"name in array", func(w *Writer) error {
+			w.BeginArray()
+			return w.Name("a")
+		}},
+		{"value without name", func(w *Writer) error {
+			w.BeginObject()
+			return w.String("a")
+		}},
+		{"name twice", func(w *Writer) error {
+			w.BeginObject()
+			w.Name("a")
+			return w.Name("b")
+		}},
+		{"end object with dangling name", func(w *Writer) error {
+			w.BeginObject()
+			w.Name("a")
+			return w.EndObject()
+		}},
+		{"mismatched end", func(w *Writer) error {
+			w.BeginObject()
+			return w.EndArray()
+		}},
+		{"end at top level", func(w *Writer) error { return w.EndObject() }},
+		{"NaN", func(w *Writer) error { return w.Float(math.NaN()) }},
+		{"unclosed on close", func(w *Writer) error {
+			w.BeginArray()
+			return w.Close()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWriter(&bytes.Buffer{})
+			if err := tt.emit(w); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestWriter_StickyError(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+
+	first := w.Name("oops")
+	if first == nil {
+		t.Fatal("expected error")
+	}
+	if err := w.String("ignored"); err != first {
+		t.Errorf("String() error = %v, want sticky %v", err, first)
+	}
+	if err := w.Flush(); err != first {
+		t.Errorf("Flush() error = %v, want sticky %v", err, first)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output after error, got %q", out.String())
+	}
+}
+
+func TestWriter_WriteError(t *testing.T) {
+	w := NewWriter(&failingWriter{failAfter: 0})
+	w.String("x")
+	if err := w.Flush(); err == nil || err.Error() != "write error" {
+		t.Errorf("Flush() error = %v, want write error", err)
+	}
+	if err := w.String("y"); err == nil {
+		t.Error("expected sticky write error")
+	}
+}
+
+func TestWriter_LargeDocumentFlushes(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	var out bytes.Buffer
+	w := NewWriter(&out)
+
+	w.BeginArray()
+	for i := 0; i < 10000; i++ {
+		w.String(strings.Repeat("x", 16))
+	}
+	// Buffer should have been flushed at least once before EndArray.
+	if out.Len() == 0 {
+		t.Error("expected intermediate flush for large document")
+	}
+	w.EndArray()
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	var got []string
+	if err := Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(got) != 10000 {
+		t.Errorf("len = %d, want 10000", len(got))
+	}
+}
+
+func TestWriter_Number(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out)
+
+	w.BeginArray()
+	w.Number("12345678901234567890123")
+	w.Number("-1.50e+3")
+	w.EndArray()
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if want := `[12345678901234567890123,-1.50e+3]`; out.String() != want {
+		t.Errorf("output = %s, want %s", out.String(), want)
+	}
+
+	for _, raw := range []string{"", "01", "+1", ".5", "NaN", "1 2", `"1"`} {
+		if err := NewWriter(&out).Number(raw); err == nil {
+			t.Errorf("Number(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestArrayWriter(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	type row struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	var out bytes.Buffer
+	aw := NewArrayWriter(&out)
+	for i := 1; i <= 3; i++ {
+		if err := aw.Write(row{ID: i, Name: "r"}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := aw.Flush(); err != nil || !strings.HasPrefix(out.String(), `[{"id":1`) {
+		t.Fatalf("Flush() = %v, output %q", err, out.String())
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	want := `[{"id":1,"name":"r"},{"id":2,"name":"r"},{"id":3,"name":"r"}]`
+	if out.String() != want {
+		t.Errorf("output = %s, want %s", out.String(), want)
+	}
+	if err := aw.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if out.String() != want {
+		t.Errorf("second Close() wrote %q", out.String()[len(want):])
+	}
+	if err := aw.Write(1); err == nil {
+		t.Error("Write() after Close succeeded")
+	}
+
+	out.Reset()
+	if err := NewArrayWriter(&out).Close(); err != nil || out.String() != `[]` {
+		t.Errorf("empty array = %q, %v", out.String(), err)
+	}
+
+	aw = NewArrayWriter(&out)
+	if err := aw.Write(func() {}); err == nil {
+		t.Error("Write(func) succeeded")
+	}
+	if err := aw.Close(); err == nil {
+		t.Error("Close() after a failed Write succeeded")
+	}
+}
+
+func TestObjectWriter(t *testing.T) {
+	if !typedCodec {
+		t.Skip("TinyGo builds encode and decode only the types Marshal and Unmarshal list")
+	}
+	var out bytes.Buffer
+	ow := NewObjectWriter(&out)
+	ow.Write("count", 2)
+	ow.Write("items", []string{"a", "b"})
+	ow.Write("next", nil)
+	if err := ow.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if want := `{"count":2,"items":["a","b"],"next":null}`; out.String() != want {
+		t.Errorf("output = %s, want %s", out.String(), want)
+	}
+	if err := ow.Write("late", 1); err == nil {
+		t.Error("Write() after Close succeeded")
+	}
+
+	out.Reset()
+	if err := NewObjectWriter(&out).Close(); err != nil || out.String() != `{}` {
+		t.Errorf("empty object = %q, %v", out.String(), err)
+	}
+}
@@ -0,0 +1,325 @@
+package json
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+)
+
+// XMLOptions configures the conversion between XML and JSON AST nodes.
+// The zero value uses the defaults noted on each field.
+type XMLOptions struct {
+	// AttributePrefix is prepended to attribute names to form member names.
+	// Default "@".
+	AttributePrefix string
+
+	// TextKey is the member name for an element's text when the element
+	// also has attributes or child elements. Default "#text".
+	TextKey string
+
+	// ArrayItemName is the element name used for elements of arrays nested
+	// directly in arrays, and for a root array. Default "item".
+	ArrayItemName string
+
+	// RootName is the root element name used by NodeToXML when the node is
+	// not an object with exactly one member. Default "root".
+	RootName string
+
+	// ForceArray lists element names that XMLToNode always converts to
+	// arrays, even when they occur once. Without it, a single <item> becomes
+	// an object and repeated ones an array, so paths change shape with the
+	// data.
+	ForceArray []string
+
+	// Indent, if non-empty, makes NodeToXML put each element on its own
+	// line, indented by this string per level.
+	Indent string
+}
+
+// withDefaults returns opts with empty fields set to their defaults.
+func (o XMLOptions) withDefaults() XMLOptions {
+	if o.AttributePrefix == "" {
+		o.AttributePrefix = "@"
+	}
+	if o.TextKey == "" {
+		o.TextKey = "#text"
+	}
+	if o.ArrayItemName == "" {
+		o.ArrayItemName = "item"
+	}
+	if o.RootName == "" {
+		o.RootName = "root"
+	}
+	return o
+}
+
+// XMLToNode converts an XML document into a JSON AST, so that it can be
+// queried with JSONPath or processed like any parsed JSON:
+//
+//	<order id="7"><item>a</item><item>b</item><note>rush</note></order>
+//
+// becomes
+//
+//	{"order": {"@id": "7", "item": ["a", "b"], "note": "rush"}}
+//
+// The mapping is lossy by design:
+//   - the root element becomes the single member of the top-level object;
+//   - attributes become members named with AttributePrefix;
+//   - an element with only text becomes a string; text mixed with
+//     attributes or children is stored under TextKey, with surrounding
+//     whitespace trimmed;
+//   - repeated child elements become arrays (see ForceArray);
+//   - all values are strings, and namespaces, comments, processing
+//     instructions, and the relative order of different child elements are
+//     discarded. Element and attribute names use their local part only.
+func XMLToNode(r io.Reader, opts XMLOptions) (ast.SchemaNode, error) {
+	opts = opts.withDefaults()
+	force := make(map[string]bool, len(opts.ForceArray))
+	for _, name := range opts.ForceArray {
+		force[name] = true
+	}
+
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, errors.New("json: XMLToNode: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("json: XMLToNode: %w", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue // prolog: declaration, comments, whitespace
+		}
+
+		value, err := xmlElement(d, start, opts, force)
+		if err != nil {
+			return nil, fmt.Errorf("json: XMLToNode: %w", err)
+		}
+		if force[start.Name.Local] {
+			value = ast.NewArrayDataNode([]ast.SchemaNode{value}, ast.Position{})
+		}
+		return ast.NewObjectNode(map[string]ast.SchemaNode{start.Name.Local: value}, ast.Position{}), nil
+	}
+}
+
+// xmlElement converts the element opened by start, consuming tokens up to
+// and including its end tag.
+func xmlElement(d *xml.Decoder, start xml.StartElement, opts XMLOptions, force map[string]bool) (ast.SchemaNode, error) {
+	props := make(map[string]ast.SchemaNode)
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" {
+			continue
+		}
+		props[opts.AttributePrefix+attr.Name.Local] = ast.NewLiteralNode(attr.Value, ast.Position{})
+	}
+
+	var children map[string][]ast.SchemaNode
+	var order []string
+	var text strings.Builder
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := xmlElement(d, t, opts, force)
+			if err != nil {
+				return nil, err
+			}
+			if children == nil {
+				children = make(map[string][]ast.SchemaNode)
+			}
+			name := t.Name.Local
+			if _, seen := children[name]; !seen {
+				order = append(order, name)
+			}
+			children[name] = append(children[name], child)
+
+		case xml.CharData:
+			text.Write(t)
+
+		case xml.EndElement:
+			content := strings.TrimSpace(text.String())
+			if len(props) == 0 && children == nil {
+				return ast.NewLiteralNode(content, ast.Position{}), nil
+			}
+
+			for _, name := range order {
+				nodes := children[name]
+				if len(nodes) == 1 && !force[name] {
+					props[name] = nodes[0]
+				} else {
+					props[name] = ast.NewArrayDataNode(nodes, ast.Position{})
+				}
+			}
+			if content != "" {
+				props[opts.TextKey] = ast.NewLiteralNode(content, ast.Position{})
+			}
+			return ast.NewObjectNode(props, ast.Position{}), nil
+		}
+	}
+}
+
+// NodeToXML writes a JSON AST as XML, reversing the mapping of XMLToNode:
+// members named with AttributePrefix become attributes, the TextKey member
+// becomes the element's text, arrays become repeated elements, and null
+// becomes an empty element.
+//
+// If node is an object with exactly one member whose value is not an
+// array, that member is the root element; otherwise the output is wrapped
+// in a RootName element. Object members are written in sorted order.
+//
+// Example:
+//
+//	node, _ := json.Parse(`{"order": {"@id": 7, "item": ["a", "b"]}}`)
+//	err := json.NodeToXML(os.Stdout, node, json.XMLOptions{})
+//	// <order id="7"><item>a</item><item>b</item></order>
+func NodeToXML(w io.Writer, node ast.SchemaNode, opts XMLOptions) error {
+	opts = opts.withDefaults()
+	e := xml.NewEncoder(w)
+	if opts.Indent != "" {
+		e.Indent("", opts.Indent)
+	}
+
+	name, value := opts.RootName, node
+	if obj, ok := node.(*ast.ObjectNode); ok {
+		props := obj.Properties()
+		if len(props) == 1 {
+			for k, v := range props {
+				if _, isArray := v.(*ast.ArrayDataNode); !isArray {
+					name, value = k, v
+				}
+			}
+		}
+	}
+
+	if err := writeXMLElement(e, name, value, opts); err != nil {
+		return fmt.Errorf("json: NodeToXML: %w", err)
+	}
+	return e.Flush()
+}
+
+// writeXMLElement writes value as an element called name.
+func writeXMLElement(e *xml.Encoder, name string, value ast.SchemaNode, opts XMLOptions) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+
+	switch n := value.(type) {
+	case *ast.LiteralNode:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		if n.Value() != nil {
+			text, err := xmlText(n)
+			if err != nil {
+				return err
+			}
+			if err := e.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+
+	case *ast.ArrayDataNode:
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		for _, elem := range n.Elements() {
+			if err := writeXMLElement(e, opts.ArrayItemName, elem, opts); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+
+	case *ast.ObjectNode:
+		props := n.Properties()
+		keys := make([]string, 0, len(props))
+		for k := range props {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var text string
+		var childKeys []string
+		for _, k := range keys {
+			switch {
+			case k == opts.TextKey:
+				lit, ok := props[k].(*ast.LiteralNode)
+				if !ok {
+					return fmt.Errorf("%q must be a scalar", k)
+				}
+				t, err := xmlText(lit)
+				if err != nil {
+					return err
+				}
+				text = t
+			case strings.HasPrefix(k, opts.AttributePrefix):
+				lit, ok := props[k].(*ast.LiteralNode)
+				if !ok {
+					return fmt.Errorf("attribute %q must be a scalar", k)
+				}
+				v, err := xmlText(lit)
+				if err != nil {
+					return err
+				}
+				start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: k[len(opts.AttributePrefix):]}, Value: v})
+			default:
+				childKeys = append(childKeys, k)
+			}
+		}
+
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		if text != "" {
+			if err := e.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+		}
+		for _, k := range childKeys {
+			// Arrays under a member become repeated elements with its name
+			if arr, ok := props[k].(*ast.ArrayDataNode); ok {
+				for _, elem := range arr.Elements() {
+					if err := writeXMLElement(e, k, elem, opts); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := writeXMLElement(e, k, props[k], opts); err != nil {
+				return err
+			}
+		}
+		return e.EncodeToken(start.End())
+
+	default:
+		return fmt.Errorf("unsupported node type %T", value)
+	}
+}
+
+// xmlText formats a scalar as XML text: strings as-is, everything else as
+// its JSON encoding.
+func xmlText(n *ast.LiteralNode) (string, error) {
+	switch v := n.Value().(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		data, err := Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,167 @@
+package json
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-core/pkg/ast"
+	"github.com/shapestone/shape-json/pkg/jsonpath"
+)
+
+const testSOAP = `<?xml version="1.0"?>
+<!-- order export -->
+<order id="7" xmlns="urn:orders">
+  <customer vip="true">Ada <b>L.</b></customer>
+  <item sku="A1">Widget</item>
+  <item sku="B2">Gadget</item>
+  <note>rush</note>
+  <empty/>
+</order>`
+
+func TestXMLToNode(t *testing.T) {
+	node, err := XMLToNode(strings.NewReader(testSOAP), XMLOptions{})
+	if err != nil {
+		t.Fatalf("XMLToNode() error = %v", err)
+	}
+
+	got := NodeToInterface(node)
+	want := map[string]interface{}{
+		"order": map[string]interface{}{
+			"@id": "7",
+			"customer": map[string]interface{}{
+				"@vip":  "true",
+				"#text": "Ada",
+				"b":     "L.",
+			},
+			"item": []interface{}{
+				map[string]interface{}{"@sku": "A1", "#text": "Widget"},
+				map[string]interface{}{"@sku": "B2", "#text": "Gadget"},
+			},
+			"note":  "rush",
+			"empty": "",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("XMLToNode() = %#v\nwant %#v", got, want)
+	}
+
+	expr, err := jsonpath.ParseString("$.order.item[*]['@sku']")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skus := expr.Get(got); !reflect.DeepEqual(skus, []interface{}{"A1", "B2"}) {
+		t.Errorf("JSONPath over converted XML = %v", skus)
+	}
+}
+
+func TestXMLToNode_Options(t *testing.T) {
+	input := `<list kind="x"><entry>one</entry></list>`
+	node, err := XMLToNode(strings.NewReader(input), XMLOptions{
+		AttributePrefix: "_",
+		ForceArray:      []string{"entry", "list"},
+	})
+	if err != nil {
+		t.Fatalf("XMLToNode() error = %v", err)
+	}
+
+	got := NodeToInterface(node)
+	want := map[string]interface{}{
+		"list": []interface{}{
+			map[string]interface{}{
+				"_kind": "x",
+				"entry": []interface{}{"one"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("XMLToNode() = %#v\nwant %#v", got, want)
+	}
+}
+
+func TestNodeToXML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		opts  XMLOptions
+		want  string
+	}{
+		{
+			name:  "single root member",
+			input: `{"order": {"@id": 7, "item": ["a", "b"], "note": null, "#text": "hi"}}`,
+			want:  `<order id="7">hi<item>a</item><item>b</item><note></note></order>`,
+		},
+		{
+			name:  "wrapped root and nested arrays",
+			input: `{"a": [[1, true], "x & y"], "b": {"c": 1.5}}`,
+			opts:  XMLOptions{RootName: "doc", ArrayItemName: "li"},
+			want:  `<doc><a><li>1</li><li>true</li></a><a>x &amp; y</a><b><c>1.5</c></b></doc>`,
+		},
+		{
+			name:  "root array",
+			input: `[1, 2]`,
+			want:  `<root><item>1</item><item>2</item></root>`,
+		},
+		{
+			name:  "indent",
+			input: `{"a": {"b": "x"}}`,
+			opts:  XMLOptions{Indent: "  "},
+			want:  "<a>\n  <b>x</b>\n</a>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := NodeToXML(&buf, node, tt.opts); err != nil {
+				t.Fatalf("NodeToXML() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("NodeToXML() = %s, want %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestXML_RoundTrip(t *testing.T) {
+	input := `<order id="7"><item sku="A1">Widget</item><item sku="B2">Gadget</item><note>rush</note></order>`
+	node, err := XMLToNode(strings.NewReader(input), XMLOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := NodeToXML(&buf, node, XMLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != input {
+		t.Errorf("round trip = %s, want %s", buf.String(), input)
+	}
+}
+
+func TestXML_Errors(t *testing.T) {
+	for _, input := range []string{``, `<!-- only a comment -->`, `<a><b></a>`, `<a>`} {
+		if _, err := XMLToNode(strings.NewReader(input), XMLOptions{}); err == nil {
+			t.Errorf("XMLToNode(%q) expected error", input)
+		}
+	}
+
+	for _, input := range []string{`{"a": {"@x": {"y": 1}}}`, `{"a": {"#text": [1]}}`} {
+		node, err := Parse(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := NodeToXML(&bytes.Buffer{}, node, XMLOptions{}); err == nil {
+			t.Errorf("NodeToXML(%s) expected error", input)
+		}
+	}
+
+	var bad ast.SchemaNode
+	if err := NodeToXML(&bytes.Buffer{}, bad, XMLOptions{}); err == nil {
+		t.Error("NodeToXML(nil) expected error")
+	}
+}
@@ -1,14 +1,14 @@
-package tokenizer_test
+package jsonlex_test
 
 import (
 	"testing"
 
 	shapetokenizer "github.com/shapestone/shape-core/pkg/tokenizer"
-	"github.com/shapestone/shape-json/internal/tokenizer"
+	"github.com/shapestone/shape-json/pkg/jsonlex"
 )
 
 func lenientTokenize(input string) []*shapetokenizer.Token {
-	tok := tokenizer.NewLenientTokenizer()
+	tok := jsonlex.NewLenientTokenizer()
 	tok.InitializeFromStream(shapetokenizer.NewStream(input))
 	var tokens []*shapetokenizer.Token
 	for {
@@ -41,7 +41,7 @@ func TestCommentMatcher_LineComment(t *testing.T) {
 			if len(tokens) == 0 {
 				t.Fatal("expected at least one token")
 			}
-			if tokens[0].Kind() != tokenizer.TokenComment {
+			if tokens[0].Kind() != jsonlex.TokenComment {
 				t.Errorf("expected Comment token, got %s", tokens[0].Kind())
 			}
 			if tokens[0].ValueString() != tt.want {
@@ -69,7 +69,7 @@ func TestCommentMatcher_BlockComment(t *testing.T) {
 			if len(tokens) == 0 {
 				t.Fatal("expected at least one token")
 			}
-			if tokens[0].Kind() != tokenizer.TokenComment {
+			if tokens[0].Kind() != jsonlex.TokenComment {
 				t.Errorf("expected Comment token, got %s", tokens[0].Kind())
 			}
 			if tokens[0].ValueString() != tt.want {
@@ -97,7 +97,7 @@ func TestLenientStringMatcher_SingleQuotes(t *testing.T) {
 			if len(tokens) == 0 {
 				t.Fatal("expected at least one token")
 			}
-			if tokens[0].Kind() != tokenizer.TokenSingleString {
+			if tokens[0].Kind() != jsonlex.TokenSingleString {
 				t.Errorf("expected SingleString token, got %s", tokens[0].Kind())
 			}
 			if tokens[0].ValueString() != tt.want {
@@ -112,7 +112,7 @@ func TestLenientStringMatcher_DoubleQuotes(t *testing.T) {
 	if len(tokens) == 0 {
 		t.Fatal("expected at least one token")
 	}
-	if tokens[0].Kind() != tokenizer.TokenString {
+	if tokens[0].Kind() != jsonlex.TokenString {
 		t.Errorf("expected String token, got %s", tokens[0].Kind())
 	}
 	if tokens[0].ValueString() != `"hello"` {
@@ -138,7 +138,7 @@ func TestUnquotedKeyMatcher(t *testing.T) {
 			if len(tokens) == 0 {
 				t.Fatal("expected at least one token")
 			}
-			if tokens[0].Kind() != tokenizer.TokenIdentifier {
+			if tokens[0].Kind() != jsonlex.TokenIdentifier {
 				t.Errorf("expected Identifier token, got %s", tokens[0].Kind())
 			}
 			if tokens[0].ValueString() != tt.want {
@@ -160,7 +160,7 @@ func TestUnquotedKeyMatcher_NotMatched(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tokens := lenientTokenize(tt.input)
-			if len(tokens) > 0 && tokens[0].Kind() == tokenizer.TokenIdentifier {
+			if len(tokens) > 0 && tokens[0].Kind() == jsonlex.TokenIdentifier {
 				t.Errorf("should not match as Identifier, got %q", tokens[0].ValueString())
 			}
 		})
@@ -173,9 +173,9 @@ func TestLenientTokenizer_KeywordsBeforeIdentifiers(t *testing.T) {
 		input string
 		kind  string
 	}{
-		{"true", tokenizer.TokenTrue},
-		{"false", tokenizer.TokenFalse},
-		{"null", tokenizer.TokenNull},
+		{"true", jsonlex.TokenTrue},
+		{"false", jsonlex.TokenFalse},
+		{"null", jsonlex.TokenNull},
 	}
 
 	for _, tt := range tests {
@@ -199,15 +199,15 @@ func TestLenientTokenizer_FullObject(t *testing.T) {
 		kind  string
 		value string
 	}{
-		{tokenizer.TokenLBrace, "{"},
-		{tokenizer.TokenIdentifier, "name"},
-		{tokenizer.TokenColon, ":"},
-		{tokenizer.TokenSingleString, `'Alice'`},
-		{tokenizer.TokenComma, ","},
-		{tokenizer.TokenIdentifier, "age"},
-		{tokenizer.TokenColon, ":"},
-		{tokenizer.TokenNumber, "30"},
-		{tokenizer.TokenRBrace, "}"},
+		{jsonlex.TokenLBrace, "{"},
+		{jsonlex.TokenIdentifier, "name"},
+		{jsonlex.TokenColon, ":"},
+		{jsonlex.TokenSingleString, `'Alice'`},
+		{jsonlex.TokenComma, ","},
+		{jsonlex.TokenIdentifier, "age"},
+		{jsonlex.TokenColon, ":"},
+		{jsonlex.TokenNumber, "30"},
+		{jsonlex.TokenRBrace, "}"},
 	}
 
 	if len(tokens) != len(expected) {
@@ -0,0 +1,169 @@
+package jsonlex
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-core/pkg/tokenizer"
+)
+
+// Token is a lexical token produced by Scanner.
+type Token struct {
+	// Kind is one of the Token constants, such as TokenString.
+	Kind string
+
+	// Raw is the exact source text of the token, including the quotes and
+	// escapes of strings. It aliases the Scanner's input.
+	Raw []byte
+
+	// Offset is the byte offset of the token in the input. Line and Column
+	// are 1-based; Column counts characters, not bytes.
+	Offset int
+	Line   int
+	Column int
+}
+
+// String returns a representation of the token for debugging, such as
+// `String "a" at 1:2`.
+func (t Token) String() string {
+	return fmt.Sprintf("%s %s at %d:%d", t.Kind, t.Raw, t.Line, t.Column)
+}
+
+// Scanner splits JSON input into tokens.
+//
+// It does not check that the tokens form a valid document; that is the
+// parser's job. Input that matches no token is returned as a TokenInvalid
+// token running to the next whitespace or structural character, and
+// scanning continues after it, so tools can still highlight or format the
+// rest of the document. Err reports the first such token.
+type Scanner struct {
+	// Whitespace, if set, makes Next return TokenWhitespace tokens instead
+	// of skipping them, so that the Raw text of all tokens adds up to the
+	// whole input.
+	Whitespace bool
+
+	data    []byte
+	lenient bool
+	tok     tokenizer.Tokenizer
+	pos     int // byte offset of the next token
+	line    int
+	column  int
+	err     error
+}
+
+// NewScanner returns a Scanner for strict JSON (RFC 8259).
+func NewScanner(data []byte) *Scanner {
+	return newScanner(data, false)
+}
+
+// NewLenientScanner returns a Scanner that also recognizes the extensions
+// accepted by lenient parsing: comments (TokenComment), single-quoted
+// strings (TokenSingleString), and unquoted keys (TokenIdentifier).
+func NewLenientScanner(data []byte) *Scanner {
+	return newScanner(data, true)
+}
+
+func newScanner(data []byte, lenient bool) *Scanner {
+	s := &Scanner{data: data, lenient: lenient, line: 1, column: 1}
+	s.reset()
+	return s
+}
+
+// reset restarts the tokenizer at s.pos.
+func (s *Scanner) reset() {
+	if s.lenient {
+		s.tok = NewLenientTokenizer()
+	} else {
+		s.tok = NewTokenizer()
+	}
+	s.tok.Initialize(string(s.data[s.pos:]))
+}
+
+// Next returns the next token. At the end of the input it returns a
+// TokenEOF token with empty Raw, and keeps doing so.
+func (s *Scanner) Next() Token {
+	for {
+		if s.pos >= len(s.data) {
+			return Token{Kind: TokenEOF, Offset: s.pos, Line: s.line, Column: s.column}
+		}
+
+		var kind string
+		var n int
+		if t, ok := s.tok.NextToken(); ok {
+			kind = t.Kind()
+			n = runeBytes(s.data[s.pos:], len(t.Value()))
+		} else {
+			kind = TokenInvalid
+			n = invalidLength(s.data[s.pos:])
+		}
+
+		tok := Token{
+			Kind:   kind,
+			Raw:    s.data[s.pos : s.pos+n : s.pos+n],
+			Offset: s.pos,
+			Line:   s.line,
+			Column: s.column,
+		}
+		s.advance(tok.Raw)
+
+		if kind == TokenInvalid {
+			if s.err == nil {
+				s.err = fmt.Errorf("jsonlex: invalid token %q at line %d, column %d", tok.Raw, tok.Line, tok.Column)
+			}
+			s.reset()
+		}
+		if kind == TokenWhitespace && !s.Whitespace {
+			continue
+		}
+		return tok
+	}
+}
+
+// Err returns an error describing the first TokenInvalid token returned by
+// Next, or nil if there was none.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// advance moves the position past raw.
+func (s *Scanner) advance(raw []byte) {
+	s.pos += len(raw)
+	for len(raw) > 0 {
+		r, size := utf8.DecodeRune(raw)
+		raw = raw[size:]
+		if r == '\n' {
+			s.line++
+			s.column = 1
+		} else {
+			s.column++
+		}
+	}
+}
+
+// runeBytes returns the byte length of the first count characters of data.
+// Tokens hold runes, and invalid UTF-8 bytes become one U+FFFD each, so the
+// source length cannot be taken from the token text.
+func runeBytes(data []byte, count int) int {
+	n := 0
+	for ; count > 0 && n < len(data); count-- {
+		_, size := utf8.DecodeRune(data[n:])
+		n += size
+	}
+	return n
+}
+
+// invalidLength returns the length of the invalid token at the start of
+// data: up to the next whitespace or structural character, and at least
+// one character.
+func invalidLength(data []byte) int {
+	_, n := utf8.DecodeRune(data)
+	for n < len(data) {
+		switch data[n] {
+		case ' ', '\t', '\n', '\r', '{', '}', '[', ']', ':', ',':
+			return n
+		}
+		_, size := utf8.DecodeRune(data[n:])
+		n += size
+	}
+	return n
+}
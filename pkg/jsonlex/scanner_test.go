@@ -0,0 +1,103 @@
+package jsonlex
+
+import (
+	"strings"
+	"testing"
+)
+
+// scanAll returns the tokens of s up to and including EOF, formatted with
+// Token.String.
+func scanAll(s *Scanner) []string {
+	var out []string
+	for {
+		tok := s.Next()
+		out = append(out, tok.String())
+		if tok.Kind == TokenEOF {
+			return out
+		}
+	}
+}
+
+func TestScanner(t *testing.T) {
+	s := NewScanner([]byte("{\"é\": [1.5e3,\n  true, null, \"a\\\"b\"]}"))
+	want := []string{
+		`LBrace { at 1:1`,
+		`String "é" at 1:2`,
+		`Colon : at 1:5`,
+		`LBracket [ at 1:7`,
+		`Number 1.5e3 at 1:8`,
+		`Comma , at 1:13`,
+		`True true at 2:3`,
+		`Comma , at 2:7`,
+		`Null null at 2:9`,
+		`Comma , at 2:13`,
+		`String "a\"b" at 2:15`,
+		`RBracket ] at 2:21`,
+		`RBrace } at 2:22`,
+		`EOF  at 2:23`,
+	}
+	if got := scanAll(s); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("tokens =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if s.Err() != nil {
+		t.Errorf("Err() = %v", s.Err())
+	}
+	if tok := s.Next(); tok.Kind != TokenEOF {
+		t.Errorf("Next() after EOF = %v", tok)
+	}
+}
+
+func TestScanner_Offsets(t *testing.T) {
+	input := []byte("{\"ü\":\t\"\xff\" , \"x\"}\n")
+	s := NewScanner(input)
+	s.Whitespace = true
+
+	var joined []byte
+	for tok := s.Next(); tok.Kind != TokenEOF; tok = s.Next() {
+		if string(input[tok.Offset:tok.Offset+len(tok.Raw)]) != string(tok.Raw) {
+			t.Errorf("token %v does not match the input at offset %d", tok, tok.Offset)
+		}
+		joined = append(joined, tok.Raw...)
+	}
+	if string(joined) != string(input) {
+		t.Errorf("tokens add up to %q, want %q", joined, input)
+	}
+}
+
+func TestScanner_Invalid(t *testing.T) {
+	s := NewScanner([]byte(`{"a": @bad, 'x': tru}`))
+	got := scanAll(s)
+	want := []string{
+		`LBrace { at 1:1`,
+		`String "a" at 1:2`,
+		`Colon : at 1:5`,
+		`Invalid @bad at 1:7`,
+		`Comma , at 1:11`,
+		`Invalid 'x' at 1:13`,
+		`Colon : at 1:16`,
+		`Invalid tru at 1:18`,
+		`RBrace } at 1:21`,
+		`EOF  at 1:22`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("tokens =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if err := s.Err(); err == nil || err.Error() != `jsonlex: invalid token "@bad" at line 1, column 7` {
+		t.Errorf("Err() = %v", err)
+	}
+}
+
+func TestLenientScanner(t *testing.T) {
+	s := NewLenientScanner([]byte("{ // note\n  key: 'v', /* c */ }"))
+	var kinds []string
+	for tok := s.Next(); tok.Kind != TokenEOF; tok = s.Next() {
+		kinds = append(kinds, tok.Kind)
+	}
+	want := "LBrace Comment Identifier Colon SingleString Comma Comment RBrace"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Errorf("kinds = %s, want %s", got, want)
+	}
+	if s.Err() != nil {
+		t.Errorf("Err() = %v", s.Err())
+	}
+}
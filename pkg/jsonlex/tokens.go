@@ -0,0 +1,50 @@
+// Package jsonlex splits JSON text into tokens, for tools such as syntax
+// highlighters, formatters, and linters that work on the source rather than
+// on parsed values.
+//
+// Scanner is the entry point. NewScanner reads strict JSON and
+// NewLenientScanner also recognizes comments, single-quoted strings, and
+// unquoted keys. Each call to Next returns a Token with its kind, its exact
+// source text, and its offset, line, and column:
+//
+//	s := jsonlex.NewScanner(data)
+//	for tok := s.Next(); tok.Kind != jsonlex.TokenEOF; tok = s.Next() {
+//	    fmt.Println(tok)
+//	}
+//	if err := s.Err(); err != nil {
+//	    return err
+//	}
+//
+// Input that is not a token is returned as TokenInvalid and scanning goes
+// on after it. The package also exports the tokenizers and matchers that
+// the json package's parser is built on, from Shape's tokenizer framework.
+package jsonlex
+
+// Token type constants for JSON format.
+// These correspond to the terminals in the JSON grammar (docs/grammar/json.ebnf).
+const (
+	// Structural tokens
+	TokenLBrace   = "LBrace"   // {
+	TokenRBrace   = "RBrace"   // }
+	TokenLBracket = "LBracket" // [
+	TokenRBracket = "RBracket" // ]
+	TokenColon    = "Colon"    // :
+	TokenComma    = "Comma"    // ,
+
+	// Literal value tokens
+	TokenString = "String" // "..." (quoted string with possible escapes)
+	TokenNumber = "Number" // 123, -45.67, 1.23e10
+	TokenTrue   = "True"   // true
+	TokenFalse  = "False"  // false
+	TokenNull   = "Null"   // null
+
+	// Special tokens
+	TokenEOF        = "EOF"        // End of file
+	TokenWhitespace = "Whitespace" // spaces, tabs, and line breaks between tokens
+	TokenInvalid    = "Invalid"    // input that is not a token (Scanner only)
+
+	// Lenient-mode tokens (not produced by the strict tokenizer)
+	TokenComment      = "Comment"      // // line or /* block */
+	TokenIdentifier   = "Identifier"   // unquoted key: name, age, $id
+	TokenSingleString = "SingleString" // '...' (single-quoted string)
+)
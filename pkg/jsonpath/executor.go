@@ -1,5 +1,11 @@
 package jsonpath
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // execute applies a sequence of selectors to data and returns all matching values
 func execute(selectors []selector, data interface{}) []interface{} {
 	if len(selectors) == 0 {
@@ -20,3 +26,95 @@ func execute(selectors []selector, data interface{}) []interface{} {
 
 	return current
 }
+
+// matchFrom applies selectors to item depth-first, yielding each final
+// value as a Match. It returns false once yield has.
+func matchFrom(selectors []selector, item interface{}, path *matchPath, yield func(Match) bool) bool {
+	return walk(selectors, item, path, func(v interface{}) bool {
+		return yield(Match{Value: v, path: path.clone()})
+	})
+}
+
+// walk applies selectors to item depth-first, calling fn with each final
+// value while path holds its location. It returns false once fn has.
+func walk(selectors []selector, item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	if len(selectors) == 0 {
+		return fn(item)
+	}
+	return selectors[0].each(item, path, func(v interface{}) bool {
+		return walk(selectors[1:], v, path, fn)
+	})
+}
+
+// Match is a value selected by Expr.Matches, with its location.
+type Match struct {
+	// Value is the selected value.
+	Value interface{}
+
+	path matchPath
+}
+
+// Path returns the location of the value as a normalized path (RFC 9535,
+// section 2.7), such as $['store']['book'][0]['title'].
+func (m Match) Path() string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, step := range m.path {
+		if step.index >= 0 {
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(step.index))
+			b.WriteByte(']')
+			continue
+		}
+		b.WriteString("['")
+		writeNormalizedName(&b, step.name)
+		b.WriteString("']")
+	}
+	return b.String()
+}
+
+// writeNormalizedName writes a member name escaped as in a normalized path.
+func writeNormalizedName(b *strings.Builder, name string) {
+	for _, r := range name {
+		switch r {
+		case '\'', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+}
+
+// matchStep is one step of a path: an array index, or a member name if
+// index is -1.
+type matchStep struct {
+	name  string
+	index int
+}
+
+// matchPath is the path to the value being visited, used as a stack.
+type matchPath []matchStep
+
+func (p *matchPath) pushName(name string) { *p = append(*p, matchStep{name: name, index: -1}) }
+func (p *matchPath) pushIndex(i int)      { *p = append(*p, matchStep{index: i}) }
+func (p *matchPath) pop()                 { *p = (*p)[:len(*p)-1] }
+
+// clone returns a copy of p that later pushes and pops do not change.
+func (p *matchPath) clone() matchPath {
+	return append(matchPath(nil), *p...)
+}
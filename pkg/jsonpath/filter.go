@@ -1,7 +1,9 @@
 package jsonpath
 
 import (
-	"fmt"
+	"cmp"
+	"math"
+	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
@@ -30,11 +32,23 @@ func (s *filterSelector) apply(current []interface{}) []interface{} {
 	return results
 }
 
+func (s *filterSelector) each(item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	if arr, ok := item.([]interface{}); ok {
+		for i, elem := range arr {
+			if s.expr.evaluate(elem) && !visitElement(path, i, elem, fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // filterExpression represents a filter condition
 type filterExpression struct {
 	left     *filterOperand    // pointer: 8 bytes
 	right    *filterOperand    // pointer: 8 bytes
 	next     *filterExpression // pointer: 8 bytes
+	sub      *filterExpression // parenthesized condition, in place of left (pointer: 8 bytes)
 	operator string            // string: 16 bytes
 	logicOp  string            // "&&" or "||" (string: 16 bytes)
 }
@@ -42,7 +56,7 @@ type filterExpression struct {
 // filterOperand represents a value in a filter expression
 type filterOperand struct {
 	value   interface{} // literal value (string, number, bool) (interface: 16 bytes)
-	field   string      // field path like "price" or "details.category" (string: 16 bytes)
+	fields  []string    // field path like ["details", "category"], [] for @ itself (slice: 24 bytes)
 	isField bool        // bool: 1 byte (+ 7 bytes padding at end)
 }
 
@@ -53,12 +67,11 @@ func (f *filterExpression) evaluate(item interface{}) bool {
 
 	// If there's a chained expression, combine with logical operator
 	if f.next != nil {
-		nextResult := f.next.evaluate(item)
 		switch f.logicOp {
 		case "&&":
-			return result && nextResult
+			return result && f.next.evaluate(item)
 		case "||":
-			return result || nextResult
+			return result || f.next.evaluate(item)
 		}
 	}
 
@@ -67,122 +80,252 @@ func (f *filterExpression) evaluate(item interface{}) bool {
 
 // evaluateSingle evaluates a single comparison expression
 func (f *filterExpression) evaluateSingle(item interface{}) bool {
+	if f.sub != nil {
+		return f.sub.evaluate(item)
+	}
+
 	// Get left operand value
-	leftVal := f.getOperandValue(f.left, item)
+	leftVal, leftOK := f.getOperandValue(f.left, item)
 
-	// Field existence check (no operator)
+	// Field existence check (no operator); a member holding null exists
 	if f.operator == "" {
-		return leftVal != nil
+		return leftOK
 	}
 
 	// Get right operand value
-	rightVal := f.getOperandValue(f.right, item)
+	rightVal, rightOK := f.getOperandValue(f.right, item)
 
 	// Perform comparison
-	return f.compare(leftVal, rightVal)
+	return f.compare(leftVal, leftOK, rightVal, rightOK)
 }
 
-// getOperandValue extracts the value of an operand
-func (f *filterExpression) getOperandValue(op *filterOperand, item interface{}) interface{} {
+// getOperandValue extracts the value of an operand, and false for a field
+// that does not exist
+func (f *filterExpression) getOperandValue(op *filterOperand, item interface{}) (interface{}, bool) {
 	if !op.isField {
-		return op.value
+		return op.value, true
 	}
 
 	// Navigate through nested fields
 	current := item
-	fields := strings.Split(op.field, ".")
-
-	for _, field := range fields {
+	for _, field := range op.fields {
 		if obj, ok := current.(map[string]interface{}); ok {
 			val, exists := obj[field]
 			if !exists {
-				return nil
+				return nil, false
 			}
 			current = val
 		} else {
-			return nil
+			return nil, false
 		}
 	}
 
-	return current
+	return current, true
 }
 
-// compare performs the comparison based on the operator
-func (f *filterExpression) compare(left, right interface{}) bool {
+// compare performs the comparison based on the operator, following RFC
+// 9535, section 2.3.5.2.2: two missing fields are equal and a missing
+// field equals nothing else; numbers compare by value whatever their Go
+// type; strings order by code point; and values of different types are
+// never equal and never ordered, so that only != holds between them.
+func (f *filterExpression) compare(left interface{}, leftOK bool, right interface{}, rightOK bool) bool {
+	if f.operator == "=~" {
+		return leftOK && rightOK && compareRegex(left, right)
+	}
+
+	equal := leftOK == rightOK && (!leftOK || compareEqual(left, right))
 	switch f.operator {
 	case "==":
-		return compareEqual(left, right)
+		return equal
 	case "!=":
-		return !compareEqual(left, right)
+		return !equal
+	}
+
+	c, ordered := 0, false
+	if leftOK && rightOK {
+		c, ordered = compareOrder(left, right)
+	}
+	switch f.operator {
 	case "<":
-		return compareLessThan(left, right)
+		return ordered && c < 0
 	case ">":
-		return compareGreaterThan(left, right)
+		return ordered && c > 0
 	case "<=":
-		return compareLessThanOrEqual(left, right)
+		return ordered && c < 0 || equal
 	case ">=":
-		return compareGreaterThanOrEqual(left, right)
-	case "=~":
-		return compareRegex(left, right)
+		return ordered && c > 0 || equal
 	}
 	return false
 }
 
-// compareEqual checks equality
+// compareEqual reports whether two JSON values are equal: numbers by
+// value, and arrays and objects member by member.
 func compareEqual(left, right interface{}) bool {
-	if left == nil || right == nil {
-		return left == right
+	if c, ok := compareNumbers(left, right); ok {
+		return c == 0
 	}
+	switch l := left.(type) {
+	case nil:
+		return right == nil
+	case string:
+		r, ok := right.(string)
+		return ok && l == r
+	case bool:
+		r, ok := right.(bool)
+		return ok && l == r
+	case []interface{}:
+		r, ok := right.([]interface{})
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for i := range l {
+			if !compareEqual(l[i], r[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		r, ok := right.(map[string]interface{})
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for k, lv := range l {
+			rv, ok := r[k]
+			if !ok || !compareEqual(lv, rv) {
+				return false
+			}
+		}
+		return true
+	}
+	if _, ok := toNumber(left); ok {
+		// A NaN, or a number against something else
+		return false
+	}
+	return reflect.TypeOf(left) == reflect.TypeOf(right) && reflect.DeepEqual(left, right)
+}
 
-	// Handle numeric comparisons with type conversion
-	leftNum, leftIsNum := toNumber(left)
-	rightNum, rightIsNum := toNumber(right)
-	if leftIsNum && rightIsNum {
-		return leftNum == rightNum
+// compareOrder compares two numbers or two strings, the only values that
+// are ordered, reporting false for any other pair.
+func compareOrder(left, right interface{}) (int, bool) {
+	if c, ok := compareNumbers(left, right); ok {
+		return c, true
+	}
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		return 0, false
 	}
+	// Byte order of UTF-8 is code point order
+	return strings.Compare(l, r), true
+}
 
-	// Direct equality check for other types
-	return left == right
+// number is a numeric filter value, kept exact: an int64, a uint64, or a
+// float64, as kind 'i', 'u', or 'f'.
+type number struct {
+	kind byte
+	i    int64
+	u    uint64
+	f    float64
 }
 
-// compareLessThan checks if left < right
-func compareLessThan(left, right interface{}) bool {
-	leftNum, leftOk := toNumber(left)
-	rightNum, rightOk := toNumber(right)
-	if !leftOk || !rightOk {
-		return false
+// numberText is a number kept as its text, such as json.Number.
+type numberText interface {
+	Int64() (int64, error)
+	Float64() (float64, error)
+}
+
+// toNumber converts a value of any Go numeric type, or a numberText, to a
+// number. Strings are not numbers.
+func toNumber(v interface{}) (number, bool) {
+	switch n := v.(type) {
+	case float64:
+		return number{kind: 'f', f: n}, true
+	case numberText:
+		if i, err := n.Int64(); err == nil {
+			return number{kind: 'i', i: i}, true
+		}
+		if f, err := n.Float64(); err == nil {
+			return number{kind: 'f', f: f}, true
+		}
+		return number{}, false
 	}
-	return leftNum < rightNum
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return number{kind: 'i', i: rv.Int()}, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return number{kind: 'u', u: rv.Uint()}, true
+	case reflect.Float32, reflect.Float64:
+		return number{kind: 'f', f: rv.Float()}, true
+	}
+	return number{}, false
 }
 
-// compareGreaterThan checks if left > right
-func compareGreaterThan(left, right interface{}) bool {
-	leftNum, leftOk := toNumber(left)
-	rightNum, rightOk := toNumber(right)
-	if !leftOk || !rightOk {
-		return false
+// compareNumbers compares two numbers exactly, so that int64(1<<53 + 1)
+// is greater than float64(1<<53). It reports false unless both are numbers
+// and neither is NaN.
+func compareNumbers(left, right interface{}) (int, bool) {
+	x, ok := toNumber(left)
+	if !ok {
+		return 0, false
 	}
-	return leftNum > rightNum
+	y, ok := toNumber(right)
+	if !ok {
+		return 0, false
+	}
+	return x.compare(y)
 }
 
-// compareLessThanOrEqual checks if left <= right
-func compareLessThanOrEqual(left, right interface{}) bool {
-	leftNum, leftOk := toNumber(left)
-	rightNum, rightOk := toNumber(right)
-	if !leftOk || !rightOk {
-		return false
+func (x number) compare(y number) (int, bool) {
+	switch {
+	case x.kind == 'f' && y.kind == 'f':
+		if math.IsNaN(x.f) || math.IsNaN(y.f) {
+			return 0, false
+		}
+		return cmp.Compare(x.f, y.f), true
+	case x.kind == 'f':
+		c, ok := y.compare(x)
+		return -c, ok
+	case y.kind == 'f':
+		return x.compareFloat(y.f)
+	case x.kind == 'i' && y.kind == 'i':
+		return cmp.Compare(x.i, y.i), true
+	case x.kind == 'u' && y.kind == 'u':
+		return cmp.Compare(x.u, y.u), true
+	case x.kind == 'i': // and y a uint64
+		if x.i < 0 {
+			return -1, true
+		}
+		return cmp.Compare(uint64(x.i), y.u), true
 	}
-	return leftNum <= rightNum
+	c, ok := y.compare(x)
+	return -c, ok
 }
 
-// compareGreaterThanOrEqual checks if left >= right
-func compareGreaterThanOrEqual(left, right interface{}) bool {
-	leftNum, leftOk := toNumber(left)
-	rightNum, rightOk := toNumber(right)
-	if !leftOk || !rightOk {
-		return false
+// compareFloat compares the integer x with f, without rounding x to a
+// float64.
+func (x number) compareFloat(f float64) (int, bool) {
+	switch {
+	case math.IsNaN(f):
+		return 0, false
+	case x.kind == 'u' && f < 0, x.kind == 'i' && f < -(1<<63):
+		return 1, true
+	case x.kind == 'u' && f >= 1<<64, x.kind == 'i' && f >= 1<<63:
+		return -1, true
+	}
+	// f is within range of x's type: compare the integer parts, then
+	// the fraction of f
+	t := math.Trunc(f)
+	var c int
+	if x.kind == 'u' {
+		c = cmp.Compare(x.u, uint64(t))
+	} else {
+		c = cmp.Compare(x.i, int64(t))
+	}
+	if c != 0 {
+		return c, true
 	}
-	return leftNum >= rightNum
+	return cmp.Compare(0, f-t), true
 }
 
 // compareRegex checks if left matches the regex pattern in right
@@ -209,263 +352,183 @@ func compareRegex(left, right interface{}) bool {
 	return re.MatchString(leftStr)
 }
 
-// toNumber converts a value to float64 if possible
-func toNumber(val interface{}) (float64, bool) {
-	switch v := val.(type) {
-	case float64:
-		return v, true
-	case int:
-		return float64(v), true
-	case int64:
-		return float64(v), true
-	case int32:
-		return float64(v), true
-	case float32:
-		return float64(v), true
-	case string:
-		if num, err := strconv.ParseFloat(v, 64); err == nil {
-			return num, true
-		}
+// parseLogical parses the condition of a filter up to its ')': comparisons
+// joined by || and &&, in which && binds tighter.
+func (p *parser) parseLogical() (*filterExpression, error) {
+	left, err := p.parseConjunction()
+	if err != nil {
+		return nil, err
 	}
-	return 0, false
-}
-
-// parseFilterExpression parses a filter expression string
-// Example: "@.price < 10" or "@.price < 15 && @.inStock == true"
-func parseFilterExpression(filterStr string) (*filterExpression, error) {
-	// First, check for logical operators (&&, ||) to split the expression
-	if idx := findLogicalOperator(filterStr); idx != -1 {
-		op := ""
-		splitIdx := idx
-		if strings.HasPrefix(filterStr[idx:], "&&") {
-			op = "&&"
-		} else if strings.HasPrefix(filterStr[idx:], "||") {
-			op = "||"
-		}
-
-		left := strings.TrimSpace(filterStr[:splitIdx])
-		right := strings.TrimSpace(filterStr[splitIdx+2:])
-
-		leftExpr, err := parseSimpleFilterExpression(left)
-		if err != nil {
-			return nil, err
-		}
-
-		rightExpr, err := parseFilterExpression(right)
-		if err != nil {
-			return nil, err
-		}
-
-		leftExpr.logicOp = op
-		leftExpr.next = rightExpr
-		return leftExpr, nil
+	if !p.matchOperator("||") {
+		return left, nil
 	}
-
-	return parseSimpleFilterExpression(filterStr)
-}
-
-// findLogicalOperator finds the index of the first logical operator (&&, ||)
-// that is not inside quotes
-func findLogicalOperator(s string) int {
-	inSingleQuote := false
-	inDoubleQuote := false
-
-	for i := 0; i < len(s)-1; i++ {
-		ch := s[i]
-
-		if ch == '\'' && !inDoubleQuote {
-			inSingleQuote = !inSingleQuote
-		} else if ch == '"' && !inSingleQuote {
-			inDoubleQuote = !inDoubleQuote
-		}
-
-		if !inSingleQuote && !inDoubleQuote {
-			if s[i] == '&' && s[i+1] == '&' {
-				return i
-			}
-			if s[i] == '|' && s[i+1] == '|' {
-				return i
-			}
-		}
+	p.advance()
+	right, err := p.parseLogical()
+	if err != nil {
+		return nil, err
 	}
-
-	return -1
+	left = group(left)
+	left.logicOp, left.next = "||", right
+	return left, nil
 }
 
-// parseSimpleFilterExpression parses a simple filter expression (no logical operators)
-// Example: "@.price < 10" or "@.role == 'admin'" or "@.email"
-func parseSimpleFilterExpression(filterStr string) (*filterExpression, error) {
-	filterStr = strings.TrimSpace(filterStr)
-
-	// Check for existence expression (just a field reference)
-	if !strings.Contains(filterStr, "==") &&
-		!strings.Contains(filterStr, "!=") &&
-		!strings.Contains(filterStr, "<=") &&
-		!strings.Contains(filterStr, ">=") &&
-		!strings.Contains(filterStr, "=~") &&
-		!strings.Contains(filterStr, "<") &&
-		!strings.Contains(filterStr, ">") {
-		// Field existence check
-		field := parseFieldReference(filterStr)
-		if field == "" {
-			return nil, fmt.Errorf("invalid field reference: %s", filterStr)
-		}
-		return &filterExpression{
-			left: &filterOperand{
-				isField: true,
-				field:   field,
-			},
-		}, nil
-	}
-
-	// Parse comparison operators
-	operators := []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
-	for _, op := range operators {
-		idx := findOperator(filterStr, op)
-		if idx == -1 {
-			continue
-		}
-
-		left := strings.TrimSpace(filterStr[:idx])
-		right := strings.TrimSpace(filterStr[idx+len(op):])
-
-		leftOp, err := parseOperand(left)
-		if err != nil {
-			return nil, fmt.Errorf("invalid left operand: %w", err)
-		}
-
-		rightOp, err := parseOperand(right)
-		if err != nil {
-			return nil, fmt.Errorf("invalid right operand: %w", err)
-		}
-
-		return &filterExpression{
-			left:     leftOp,
-			operator: op,
-			right:    rightOp,
-		}, nil
+// parseConjunction parses comparisons joined by &&.
+func (p *parser) parseConjunction() (*filterExpression, error) {
+	left, err := p.parseComparison()
+	if err != nil {
+		return nil, err
 	}
-
-	return nil, fmt.Errorf("invalid filter expression: %s", filterStr)
+	if !p.matchOperator("&&") {
+		return left, nil
+	}
+	p.advance()
+	right, err := p.parseConjunction()
+	if err != nil {
+		return nil, err
+	}
+	left = group(left)
+	left.logicOp, left.next = "&&", right
+	return left, nil
 }
 
-// findOperator finds the index of an operator, skipping operators inside quotes
-func findOperator(s, op string) int {
-	inSingleQuote := false
-	inDoubleQuote := false
-
-	for i := 0; i <= len(s)-len(op); i++ {
-		ch := s[i]
-
-		if ch == '\'' && !inDoubleQuote {
-			inSingleQuote = !inSingleQuote
-		} else if ch == '"' && !inSingleQuote {
-			inDoubleQuote = !inDoubleQuote
-		}
-
-		if !inSingleQuote && !inDoubleQuote {
-			if strings.HasPrefix(s[i:], op) {
-				// Make sure it's not part of a longer operator
-				// e.g., don't match "<" when it's part of "<="
-				if op == "<" || op == ">" {
-					if i+len(op) < len(s) && s[i+len(op)] == '=' {
-						continue
-					}
-				}
-				return i
-			}
-		}
+// group returns f as one expression that can be chained to another.
+func group(f *filterExpression) *filterExpression {
+	if f.next == nil {
+		return f
 	}
-
-	return -1
+	return &filterExpression{sub: f}
 }
 
-// parseOperand parses a filter operand (field reference or literal value)
-func parseOperand(s string) (*filterOperand, error) {
-	s = strings.TrimSpace(s)
+// comparisonOperators are the operators a comparison may use.
+var comparisonOperators = map[string]bool{
+	"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true, "=~": true,
+}
 
-	// Check if it's a field reference (starts with @)
-	if strings.HasPrefix(s, "@") {
-		field := parseFieldReference(s)
-		if field == "" {
-			return nil, fmt.Errorf("invalid field reference: %s", s)
+// parseComparison parses a comparison of two operands, an existence test
+// such as @.email, or a parenthesized condition.
+func (p *parser) parseComparison() (*filterExpression, error) {
+	if p.match(tokenLeftParen) {
+		p.advance()
+		sub, err := p.parseLogical()
+		if err != nil {
+			return nil, err
 		}
-		return &filterOperand{
-			isField: true,
-			field:   field,
-		}, nil
-	}
-
-	// Check if it's a string literal
-	if (strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'")) ||
-		(strings.HasPrefix(s, `"`) && strings.HasSuffix(s, `"`)) {
-		return &filterOperand{
-			isField: false,
-			value:   s[1 : len(s)-1],
-		}, nil
-	}
-
-	// Check if it's a regex pattern
-	if strings.HasPrefix(s, "/") && strings.HasSuffix(s, "/") {
-		return &filterOperand{
-			isField: false,
-			value:   s, // Keep the slashes for regex matching
-		}, nil
-	}
-
-	// Check for regex pattern with flags (e.g., /pattern/i)
-	if strings.HasPrefix(s, "/") {
-		lastSlash := strings.LastIndex(s[1:], "/")
-		if lastSlash != -1 {
-			pattern := s[:lastSlash+2]
-			flags := s[lastSlash+2:]
-			// Convert flags to regex syntax
-			if flags != "" {
-				// For now, support case insensitive flag
-				if strings.Contains(flags, "i") {
-					pattern = "/(?i)" + pattern[1:]
+		if !p.match(tokenRightParen) {
+			if p.isAtEnd() || p.match(tokenRightBracket) {
+				// The ']' that was meant to follow is usually there
+				fix := &queryEdit{off: -1, insert: ")]"}
+				if !p.isAtEnd() {
+					fix = &queryEdit{off: p.current().pos, insert: ")"}
 				}
+				return nil, syntaxError(p.offset(), "unclosed filter expression: missing ')'", fix)
 			}
-			return &filterOperand{
-				isField: false,
-				value:   pattern,
-			}, nil
+			return nil, syntaxError(p.current().pos, "expected ')', '&&', or '||' in filter, found "+describe(p.current()), nil)
 		}
+		p.advance()
+		return &filterExpression{sub: sub}, nil
 	}
 
-	// Check if it's a boolean
-	if s == "true" {
-		return &filterOperand{
-			isField: false,
-			value:   true,
-		}, nil
+	start := p.current()
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
 	}
-	if s == "false" {
-		return &filterOperand{
-			isField: false,
-			value:   false,
-		}, nil
+	op := p.current()
+	if !p.match(tokenOperator) || op.value == "&&" || op.value == "||" {
+		if !left.isField {
+			return nil, syntaxError(start.pos, "expected comparison after "+describe(start), nil)
+		}
+		return &filterExpression{left: left}, nil
 	}
-
-	// Try to parse as number
-	if num, err := strconv.ParseFloat(s, 64); err == nil {
-		return &filterOperand{
-			isField: false,
-			value:   num,
-		}, nil
+	switch {
+	case op.value == "=":
+		return nil, syntaxError(op.pos, "'=' is not a comparison operator", &queryEdit{off: op.pos, del: 1, insert: "=="})
+	case !comparisonOperators[op.value]:
+		return nil, syntaxError(op.pos, "unexpected operator "+describe(op), nil)
 	}
+	p.advance()
 
-	return nil, fmt.Errorf("invalid operand: %s", s)
+	right, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	return &filterExpression{left: left, operator: op.value, right: right}, nil
 }
 
-// parseFieldReference extracts the field path from a field reference
-// Example: "@.price" -> "price", "@.details.category" -> "details.category"
-func parseFieldReference(s string) string {
-	s = strings.TrimSpace(s)
-	if !strings.HasPrefix(s, "@") {
-		return ""
+// parseOperand parses a filter operand: a field reference such as @,
+// @.price, or @.details['category'], or a string, regex, number, or
+// boolean literal.
+func (p *parser) parseOperand() (*filterOperand, error) {
+	tok := p.current()
+	switch tok.typ {
+	case tokenAt:
+		p.advance()
+		operand := &filterOperand{isField: true}
+		for {
+			switch {
+			case p.match(tokenDot) && p.peek().typ == tokenIdentifier:
+				operand.fields = append(operand.fields, p.peek().value)
+				p.pos += 2
+			case p.match(tokenLeftBracket) && p.peek().typ == tokenString:
+				p.advance()
+				name := p.current()
+				p.advance()
+				if !p.match(tokenRightBracket) {
+					return nil, p.expectedBracket(describe(name))
+				}
+				operand.fields = append(operand.fields, name.value)
+				p.advance()
+			default:
+				return operand, nil
+			}
+		}
+
+	case tokenString:
+		p.advance()
+		if !strings.HasPrefix(tok.value, "/") {
+			return &filterOperand{value: tok.value}, nil
+		}
+		// Keep the slashes for regex matching, with the flags after the
+		// last one turned into regexp syntax
+		pattern := tok.value
+		last := strings.LastIndex(pattern, "/")
+		if flags := pattern[last+1:]; strings.Contains(flags, "i") {
+			pattern = "/(?i)" + pattern[1:last+1]
+		} else {
+			pattern = pattern[:last+1]
+		}
+		return &filterOperand{value: pattern}, nil
+
+	case tokenNumber:
+		p.advance()
+		text := tok.value
+		// A fraction is tokenized as number, '.', number
+		if p.match(tokenDot) && p.current().pos == tok.pos+len(tok.value) &&
+			p.peek().typ == tokenNumber && p.peek().pos == p.current().pos+1 {
+			text += "." + p.peek().value
+			p.pos += 2
+		}
+		// Integers stay exact for comparing with integer fields
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return &filterOperand{value: i}, nil
+		}
+		num, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, syntaxError(tok.pos, "invalid number "+text, nil)
+		}
+		return &filterOperand{value: num}, nil
+
+	case tokenIdentifier:
+		switch tok.value {
+		case "true", "false":
+			p.advance()
+			return &filterOperand{value: tok.value == "true"}, nil
+		case "null":
+			p.advance()
+			return &filterOperand{}, nil
+		}
+		// price for @.price
+		return nil, syntaxError(tok.pos, "unknown name "+describe(tok)+" in filter", &queryEdit{off: tok.pos, insert: "@."})
 	}
-	s = strings.TrimPrefix(s, "@")
-	s = strings.TrimPrefix(s, ".")
-	return s
+	return nil, syntaxError(p.offset(), "expected operand in filter, found "+describe(tok), nil)
 }
@@ -1,6 +1,8 @@
 package jsonpath
 
 import (
+	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -362,3 +364,129 @@ func TestFilterEdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterGrammar(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"id": "a", "price": 5.0, "stock": 0.0, "tags": map[string]interface{}{"on sale": true}},
+			map[string]interface{}{"id": "b", "price": 15.0, "stock": 3.0},
+			map[string]interface{}{"id": "c", "price": 25.0, "stock": -2.0, "tags": map[string]interface{}{"on sale": false}},
+		},
+		"scores": []interface{}{-1.5, 0.0, 2.5, 10.0},
+	}
+
+	tests := []struct {
+		query string
+		want  []interface{}
+	}{
+		// && binds tighter than ||
+		{"$.items[?(@.price < 10 || @.price > 10 && @.stock > 0)].id", []interface{}{"a", "b"}},
+		{"$.items[?(@.price > 10 && @.stock > 0 || @.price < 10)].id", []interface{}{"a", "b"}},
+		{"$.items[?((@.price < 10 || @.price > 20) && @.stock == 0)].id", []interface{}{"a"}},
+		// RFC 9535 filters need no parentheses
+		{"$.items[?@.price >= 15].id", []interface{}{"b", "c"}},
+		{"$.items[?@.tags].id", []interface{}{"a", "c"}},
+		{"$.items[?(@.tags['on sale'] == true)].id", []interface{}{"a"}},
+		{"$.items[?(@.stock < -1)].id", []interface{}{"c"}},
+		{"$.items[?(@.stock > @.price)].id", nil},
+		{"$.items[?(@.stock < @.price)].id", []interface{}{"a", "b", "c"}},
+		{"$.scores[?(@ > 0.5)]", []interface{}{2.5, 10.0}},
+		{"$.scores[?(@ < -1.25)]", []interface{}{-1.5}},
+		{"$.scores[-1]", []interface{}{10.0}},
+		{"$.scores[-2:]", []interface{}{2.5, 10.0}},
+		{"$.scores[:-3]", []interface{}{-1.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := ParseString(tt.query)
+			if err != nil {
+				t.Fatalf("ParseString() error: %v", err)
+			}
+			if got := expr.Get(data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expr.Get() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// textNumber is a number kept as text, like json.Number.
+type textNumber string
+
+func (n textNumber) Int64() (int64, error)     { return strconv.ParseInt(string(n), 10, 64) }
+func (n textNumber) Float64() (float64, error) { return strconv.ParseFloat(string(n), 64) }
+
+func TestFilterComparisonSemantics(t *testing.T) {
+	values := []struct {
+		id string
+		v  interface{}
+	}{
+		{"int", 1},
+		{"float", 1.0},
+		{"fraction", 1.5},
+		{"numeric string", "1"},
+		{"apple", "apple"},
+		{"banana", "banana"},
+		{"true", true},
+		{"null", nil},
+		{"missing", nil},
+		{"array", []interface{}{1.0}},
+		{"object", map[string]interface{}{"x": 1.0}},
+		{"2^53+1", int64(1<<53 + 1)},
+		{"2^63", uint64(1 << 63)},
+		{"text", textNumber("2")},
+	}
+	var data []interface{}
+	for _, v := range values {
+		item := map[string]interface{}{"id": v.id}
+		if v.id != "missing" {
+			item["v"] = v.v
+		}
+		data = append(data, item)
+	}
+
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		// Numbers compare by value whatever their type, and strings are
+		// not numbers
+		{"@.v == 1", []string{"int", "float"}},
+		{"@.v < 2", []string{"int", "float", "fraction"}},
+		{"@.v >= 1.5", []string{"fraction", "2^53+1", "2^63", "text"}},
+		{"@.v == 2", []string{"text"}},
+		// Integers are not rounded to float64
+		{"@.v == 9007199254740992.0", nil},
+		{"@.v > 9007199254740992", []string{"2^53+1", "2^63"}},
+		{"@.v < -9223372036854775808.5", nil},
+		// Strings order by code point
+		{"@.v >= 'apple'", []string{"apple", "banana"}},
+		{"@.v < 'b'", []string{"numeric string", "apple"}},
+		// Other types are only equal or unequal
+		{"@.v == true", []string{"true"}},
+		{"@.v > false", nil},
+		{"@.v == null", []string{"null"}},
+		{"@.v <= null", []string{"null"}},
+		// A missing member equals only another missing member
+		{"@.v == @.v", []string{"int", "float", "fraction", "numeric string", "apple", "banana", "true", "null", "missing", "array", "object", "2^53+1", "2^63", "text"}},
+		{"@.v != 1", []string{"fraction", "numeric string", "apple", "banana", "true", "null", "missing", "array", "object", "2^53+1", "2^63", "text"}},
+		{"@.absent == @.v", []string{"missing"}},
+		{"@.absent < @.v", nil},
+		// A member holding null exists
+		{"@.v", []string{"int", "float", "fraction", "numeric string", "apple", "banana", "true", "null", "array", "object", "2^53+1", "2^63", "text"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			expr, err := ParseString("$[?(" + tt.filter + ")].id")
+			if err != nil {
+				t.Fatalf("ParseString() error: %v", err)
+			}
+			var got []string
+			for _, id := range expr.Get(data) {
+				got = append(got, id.(string))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ids = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,9 +2,7 @@
 // It supports RFC 9535 JSONPath syntax for querying JSON-like data structures.
 package jsonpath
 
-import (
-	"fmt"
-)
+import "iter"
 
 // Expr is a compiled JSONPath expression that can be executed against data.
 type Expr interface {
@@ -13,6 +11,28 @@ type Expr interface {
 	// (map[string]interface{}, []interface{}, or primitive types).
 	// Returns a slice of all values that match the query path.
 	Get(data interface{}) []interface{}
+
+	// Matches returns an iterator over the values Get would return, in the
+	// same order, each with its location in data. Values are found one at a
+	// time as the loop asks for them, so breaking out of the loop early
+	// saves evaluating the rest of the query:
+	//
+	//	for m := range expr.Matches(data) {
+	//	    fmt.Println(m.Path(), m.Value)
+	//	}
+	Matches(data interface{}) iter.Seq[Match]
+
+	// Exists reports whether the query matches any value in data. It stops
+	// at the first match.
+	Exists(data interface{}) bool
+
+	// Count returns the number of values Get would return, without
+	// collecting them.
+	Count(data interface{}) int
+
+	// First returns the first value Get would return, and false if there
+	// is none. It stops at the first match.
+	First(data interface{}) (interface{}, bool)
 }
 
 // ParseString parses a JSONPath query string into a compiled expression.
@@ -22,10 +42,14 @@ type Expr interface {
 //   - Root selector: $
 //   - Child selector: .property or ['property']
 //   - Wildcard: * or [*]
-//   - Array index: [0], [1], etc.
-//   - Array slice: [0:5], [:5], [2:], etc.
-//   - Recursive descent: ..property
+//   - Array index: [0], [1], [-1], etc.
+//   - Array slice: [0:5], [:5], [2:], [-2:], etc.
+//   - Recursive descent: ..property or ..*
 //   - Multiple selectors: $.a.b.c
+//   - Filters: [?(@.price < 10 && @.tags['on sale'])] or [?@.price < 10],
+//     with ==, !=, <, <=, >, >=, =~ /regex/, &&, ||, and parentheses
+//
+// A query that does not parse returns a *SyntaxError.
 //
 // Example:
 //
@@ -35,20 +59,16 @@ type Expr interface {
 //	}
 //	results := expr.Get(data)
 func ParseString(query string) (Expr, error) {
-	if query == "" {
-		return nil, fmt.Errorf("query string cannot be empty")
-	}
-
 	// Parse the query into tokens
 	tokens, err := tokenize(query)
 	if err != nil {
-		return nil, fmt.Errorf("tokenization failed: %w", err)
+		return nil, forQuery(err, query)
 	}
 
 	// Parse tokens into an expression
 	expr, err := parse(tokens)
 	if err != nil {
-		return nil, fmt.Errorf("parsing failed: %w", err)
+		return nil, forQuery(err, query)
 	}
 
 	return expr, nil
@@ -64,8 +84,59 @@ func (e *expr) Get(data interface{}) []interface{} {
 	return execute(e.selectors, data)
 }
 
+// Matches implements the Expr interface
+func (e *expr) Matches(data interface{}) iter.Seq[Match] {
+	return func(yield func(Match) bool) {
+		if len(e.selectors) == 0 {
+			return
+		}
+		var path matchPath
+		matchFrom(e.selectors, data, &path, yield)
+	}
+}
+
+// Exists implements the Expr interface
+func (e *expr) Exists(data interface{}) bool {
+	_, ok := e.First(data)
+	return ok
+}
+
+// Count implements the Expr interface
+func (e *expr) Count(data interface{}) int {
+	if len(e.selectors) == 0 {
+		return 0
+	}
+	n := 0
+	var path matchPath
+	walk(e.selectors, data, &path, func(interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
+// First implements the Expr interface
+func (e *expr) First(data interface{}) (interface{}, bool) {
+	if len(e.selectors) == 0 {
+		return nil, false
+	}
+	var first interface{}
+	found := false
+	var path matchPath
+	walk(e.selectors, data, &path, func(v interface{}) bool {
+		first, found = v, true
+		return false
+	})
+	return first, found
+}
+
 // selector represents a single path segment in a JSONPath expression
 type selector interface {
 	// apply applies this selector to the current values and returns new matches
 	apply(current []interface{}) []interface{}
+
+	// each calls fn with each value this selector selects from item, in the
+	// order apply returns them, with the steps to it pushed onto path. It
+	// stops and returns false as soon as fn does.
+	each(item interface{}, path *matchPath, fn func(interface{}) bool) bool
 }
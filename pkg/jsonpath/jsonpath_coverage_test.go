@@ -540,7 +540,7 @@ func TestInvalidFilterExpressions(t *testing.T) {
 	}
 }
 
-// TestNumberConversionEdgeCases tests toNumber function with various types
+// TestNumberConversionEdgeCases tests numeric comparisons against values of various types
 func TestNumberConversionEdgeCases(t *testing.T) {
 	data := []interface{}{
 		map[string]interface{}{"value": 42},
@@ -0,0 +1,239 @@
+package jsonpath
+
+import "fmt"
+
+// ExprSet is a set of JSONPath expressions evaluated together, in one walk
+// of the data, for callers that extract many values from each document,
+// such as a dashboard backend reading dozens of metrics from a payload.
+// Expressions that share a prefix, like $.metrics.cpu and $.metrics.mem,
+// share the steps of the walk, and subtrees no expression selects from are
+// not visited.
+type ExprSet struct {
+	queries []string
+	exprs   [][]selector
+}
+
+// ParseStrings parses queries into an ExprSet. The error for a query that
+// does not parse names the query.
+//
+// Example:
+//
+//	set, err := jsonpath.ParseStrings([]string{"$.cpu.load", "$.disks[*].free"})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	for _, payload := range payloads {
+//	    values := set.Get(payload)
+//	    fmt.Println(values["$.cpu.load"], values["$.disks[*].free"])
+//	}
+func ParseStrings(queries []string) (*ExprSet, error) {
+	s := &ExprSet{}
+	seen := make(map[string]bool, len(queries))
+	for _, query := range queries {
+		if seen[query] {
+			continue
+		}
+		seen[query] = true
+		e, err := ParseString(query)
+		if err != nil {
+			return nil, fmt.Errorf("query %q: %w", query, err)
+		}
+		s.queries = append(s.queries, query)
+		s.exprs = append(s.exprs, e.(*expr).selectors)
+	}
+	return s, nil
+}
+
+// Get evaluates every expression of the set against data and returns the
+// values each one selects, keyed by its query string. Each expression
+// selects the same values as its Expr.Get would; every query has an entry,
+// nil if nothing matched.
+func (s *ExprSet) Get(data interface{}) map[string][]interface{} {
+	w := &manyWalker{exprs: s.exprs, results: make([][]interface{}, len(s.exprs))}
+	states := make([]manyState, len(s.exprs))
+	for q := range states {
+		states[q] = manyState{q: q}
+	}
+	w.visit(data, states)
+
+	out := make(map[string][]interface{}, len(s.queries))
+	for q, query := range s.queries {
+		out[query] = w.results[q]
+	}
+	return out
+}
+
+// GetMany is ParseStrings followed by Get, for one-off use. To evaluate
+// the same queries against many documents, parse them once with
+// ParseStrings.
+//
+// Example:
+//
+//	values, err := jsonpath.GetMany(data, []string{"$.status", "$.items[*].id"})
+func GetMany(data interface{}, queries []string) (map[string][]interface{}, error) {
+	s, err := ParseStrings(queries)
+	if err != nil {
+		return nil, err
+	}
+	return s.Get(data), nil
+}
+
+// manyState is the progress of expression q at a value: its selectors from
+// i on remain to be applied. depth counts the levels a recursive selector
+// at i has descended, which it limits as Expr.Get does.
+type manyState struct {
+	q, i, depth int
+}
+
+// manyWalker walks data for ExprSet.Get.
+type manyWalker struct {
+	exprs   [][]selector
+	results [][]interface{}
+}
+
+// visit applies states to node: it records node for the expressions that
+// end there and visits the children of node that any of them selects.
+// visit owns states and may append to it.
+func (w *manyWalker) visit(node interface{}, states []manyState) {
+	// The states that select among the children of node are kept in
+	// states[:n], over those already applied
+	n := 0
+	for k := 0; k < len(states); k++ {
+		st := states[k]
+		sels := w.exprs[st.q]
+		if st.i == len(sels) {
+			w.results[st.q] = append(w.results[st.q], node)
+			continue
+		}
+		switch sels[st.i].(type) {
+		case *rootSelector:
+			states[k].i++
+			k-- // apply the next selector to node
+			continue
+		case *recursiveWildcardSelector:
+			if st.depth > 100 {
+				continue
+			}
+			// ..* selects node itself, and then its descendants
+			states = append(states, manyState{q: st.q, i: st.i + 1})
+		case *recursiveSelector:
+			if st.depth > 100 {
+				continue
+			}
+		}
+		states[n] = st
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	next := states[:n]
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if w.onlyChildren(next) {
+			w.visitNamed(v, next)
+			return
+		}
+		// The children take turns with one slice of states
+		var child []manyState
+		for key, val := range v {
+			if child = w.memberStates(child[:0], next, key); len(child) > 0 {
+				w.visit(val, child)
+			}
+		}
+	case []interface{}:
+		var child []manyState
+		for i, val := range v {
+			if child = w.elementStates(child[:0], next, v, i); len(child) > 0 {
+				w.visit(val, child)
+			}
+		}
+	}
+}
+
+// onlyChildren reports whether every state selects a member by name, so
+// that the members can be looked up rather than iterated.
+func (w *manyWalker) onlyChildren(states []manyState) bool {
+	for _, st := range states {
+		if _, ok := w.exprs[st.q][st.i].(*childSelector); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// visitNamed visits the members of obj named by the child selectors of
+// states, once per name. It reorders states to group them by name.
+func (w *manyWalker) visitNamed(obj map[string]interface{}, states []manyState) {
+	for len(states) > 0 {
+		name := w.exprs[states[0].q][states[0].i].(*childSelector).name
+		n := 0
+		for k, st := range states {
+			if w.exprs[st.q][st.i].(*childSelector).name == name {
+				states[n], states[k] = st, states[n]
+				n++
+			}
+		}
+		if val, ok := obj[name]; ok {
+			child := states[:n:n]
+			for k := range child {
+				child[k] = manyState{q: child[k].q, i: child[k].i + 1}
+			}
+			w.visit(val, child)
+		}
+		states = states[n:]
+	}
+}
+
+// memberStates appends to child the states that apply to the member named
+// key of an object that states apply to.
+func (w *manyWalker) memberStates(child, states []manyState, key string) []manyState {
+	for _, st := range states {
+		switch sel := w.exprs[st.q][st.i].(type) {
+		case *childSelector:
+			if sel.name == key {
+				child = append(child, manyState{q: st.q, i: st.i + 1})
+			}
+		case *wildcardSelector:
+			child = append(child, manyState{q: st.q, i: st.i + 1})
+		case *recursiveSelector:
+			if sel.name == key {
+				child = append(child, manyState{q: st.q, i: st.i + 1})
+			}
+			child = append(child, manyState{q: st.q, i: st.i, depth: st.depth + 1})
+		case *recursiveWildcardSelector:
+			child = append(child, manyState{q: st.q, i: st.i, depth: st.depth + 1})
+		}
+	}
+	return child
+}
+
+// elementStates appends to child the states that apply to element i of
+// arr, an array that states apply to.
+func (w *manyWalker) elementStates(child, states []manyState, arr []interface{}, i int) []manyState {
+	for _, st := range states {
+		selected := false
+		switch sel := w.exprs[st.q][st.i].(type) {
+		case *indexSelector:
+			idx := sel.index
+			if idx < 0 {
+				idx += len(arr)
+			}
+			selected = idx == i
+		case *sliceSelector:
+			start, end := sel.bounds(len(arr))
+			selected = start <= i && i < end
+		case *wildcardSelector:
+			selected = true
+		case *filterSelector:
+			selected = sel.expr.evaluate(arr[i])
+		case *recursiveSelector, *recursiveWildcardSelector:
+			child = append(child, manyState{q: st.q, i: st.i, depth: st.depth + 1})
+		}
+		if selected {
+			child = append(child, manyState{q: st.q, i: st.i + 1})
+		}
+	}
+	return child
+}
@@ -0,0 +1,178 @@
+package jsonpath
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestGetMany(t *testing.T) {
+	data := map[string]interface{}{
+		"status": "ok",
+		"metrics": map[string]interface{}{
+			"cpu": map[string]interface{}{"load": 0.5, "cores": 8.0},
+			"mem": map[string]interface{}{"used": 512.0, "free": 1024.0},
+		},
+		"disks": []interface{}{
+			map[string]interface{}{"name": "sda", "free": 10.0},
+			map[string]interface{}{"name": "sdb", "free": 20.0},
+			map[string]interface{}{"name": "sdc", "free": 30.0, "parts": []interface{}{
+				map[string]interface{}{"name": "sdc1", "free": 5.0},
+			}},
+		},
+	}
+
+	queries := []string{
+		"$",
+		"$.status",
+		"$.metrics.cpu.load",
+		"$.metrics.cpu.cores",
+		"$.metrics.mem.used",
+		"$.metrics.mem.*",
+		"$.metrics['mem'].free",
+		"$.disks[0].name",
+		"$.disks[-1].name",
+		"$.disks[1:].free",
+		"$.disks[*].free",
+		"$.disks[?(@.free > 15)].name",
+		"$..free",
+		"$..name",
+		"$.disks..name",
+		"$..*",
+		"$.disks[2]..*",
+		"$.missing",
+		"$.missing.deeper",
+		"$.disks[9]",
+	}
+	got, err := GetMany(data, queries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(queries) {
+		t.Errorf("GetMany() returned %d entries, want %d", len(got), len(queries))
+	}
+	for _, query := range queries {
+		expr, err := ParseString(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := expr.Get(data)
+		values, ok := got[query]
+		if !ok {
+			t.Errorf("GetMany() has no entry for %s", query)
+			continue
+		}
+		// Members of objects are visited in no fixed order
+		if !reflect.DeepEqual(sortedValues(values), sortedValues(want)) {
+			t.Errorf("GetMany()[%s] = %v, Get() = %v", query, values, want)
+		}
+	}
+
+	// Without objects in the way, the order is that of Get
+	if got := got["$.disks[*].free"]; !reflect.DeepEqual(got, []interface{}{10.0, 20.0, 30.0}) {
+		t.Errorf("GetMany()[$.disks[*].free] = %v", got)
+	}
+}
+
+func TestParseStrings(t *testing.T) {
+	set, err := ParseStrings([]string{"$.a", "$.b[0]", "$.a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, doc := range []string{"x", "y"} {
+		data := map[string]interface{}{"a": doc, "b": []interface{}{doc + "0"}}
+		got := set.Get(data)
+		want := map[string][]interface{}{"$.a": {doc}, "$.b[0]": {doc + "0"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Get() = %v, want %v", got, want)
+		}
+	}
+
+	_, err = ParseStrings([]string{"$.ok", "$.bad["})
+	if err == nil || !strings.Contains(err.Error(), `query "$.bad["`) {
+		t.Errorf("ParseStrings() error = %v, want one naming the query", err)
+	}
+	if _, err := GetMany(nil, []string{"no-dollar"}); err == nil {
+		t.Error("GetMany() error = nil for an invalid query")
+	}
+}
+
+func TestGetMany_DeepRecursion(t *testing.T) {
+	// The recursive selectors stop as deep as they do in Get
+	var data interface{} = map[string]interface{}{"x": 1.0}
+	for i := 0; i < 150; i++ {
+		data = map[string]interface{}{"x": float64(i), "next": data}
+	}
+	got, err := GetMany(data, []string{"$..x", "$..*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for query, values := range got {
+		expr, _ := ParseString(query)
+		if want := expr.Get(data); len(values) != len(want) {
+			t.Errorf("GetMany()[%s] has %d values, Get() %d", query, len(values), len(want))
+		}
+	}
+}
+
+// sortedValues returns values ordered by their printed form.
+func sortedValues(values []interface{}) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = fmt.Sprint(v)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func BenchmarkGetMany(b *testing.B) {
+	data := map[string]interface{}{}
+	var queries []string
+	for i := 0; i < 40; i++ {
+		group := fmt.Sprintf("group%d", i%8)
+		g, ok := data[group].(map[string]interface{})
+		if !ok {
+			g = map[string]interface{}{}
+			data[group] = g
+		}
+		name := fmt.Sprintf("metric%d", i)
+		g[name] = float64(i)
+		queries = append(queries, "$."+group+"."+name)
+	}
+	hosts := make([]interface{}, 50)
+	for i := range hosts {
+		hosts[i] = map[string]interface{}{"cpu": float64(i), "mem": float64(i), "disk": float64(i), "up": true}
+	}
+	data["hosts"] = hosts
+	queries = append(queries, "$.hosts[*].cpu", "$.hosts[*].mem", "$.hosts[*].disk", "$.hosts[?(@.up == true)].cpu")
+
+	b.Run("GetMany", func(b *testing.B) {
+		set, err := ParseStrings(queries)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			set.Get(data)
+		}
+	})
+	b.Run("Get", func(b *testing.B) {
+		var exprs []Expr
+		for _, query := range queries {
+			expr, err := ParseString(query)
+			if err != nil {
+				b.Fatal(err)
+			}
+			exprs = append(exprs, expr)
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			out := make(map[string][]interface{}, len(queries))
+			for j, expr := range exprs {
+				out[queries[j]] = expr.Get(data)
+			}
+		}
+	})
+}
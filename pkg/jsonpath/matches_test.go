@@ -0,0 +1,165 @@
+package jsonpath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExprMatches(t *testing.T) {
+	data := map[string]interface{}{
+		"store": map[string]interface{}{
+			"book": []interface{}{
+				map[string]interface{}{"title": "A", "price": 8.95},
+				map[string]interface{}{"title": "B", "price": 12.99},
+				map[string]interface{}{"title": "C", "price": 22.99},
+			},
+		},
+	}
+
+	tests := []struct {
+		query string
+		paths []string
+	}{
+		{"$", []string{"$"}},
+		{"$.store.book[1].title", []string{"$['store']['book'][1]['title']"}},
+		{"$.store.book[2].price", []string{"$['store']['book'][2]['price']"}},
+		{"$.store.book[*].title", []string{"$['store']['book'][0]['title']", "$['store']['book'][1]['title']", "$['store']['book'][2]['title']"}},
+		{"$.store.book[1:].title", []string{"$['store']['book'][1]['title']", "$['store']['book'][2]['title']"}},
+		{"$..title", []string{"$['store']['book'][0]['title']", "$['store']['book'][1]['title']", "$['store']['book'][2]['title']"}},
+		{"$.store.book[?(@.price > 10)].title", []string{"$['store']['book'][1]['title']", "$['store']['book'][2]['title']"}},
+		{"$.missing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := ParseString(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var values []interface{}
+			var paths []string
+			for m := range expr.Matches(data) {
+				values = append(values, m.Value)
+				paths = append(paths, m.Path())
+			}
+			if want := expr.Get(data); !reflect.DeepEqual(values, want) {
+				t.Errorf("Matches() values = %v, Get() = %v", values, want)
+			}
+			if !reflect.DeepEqual(paths, tt.paths) {
+				t.Errorf("Matches() paths = %q, want %q", paths, tt.paths)
+			}
+		})
+	}
+}
+
+func TestMatchPath_Escaping(t *testing.T) {
+	data := map[string]interface{}{"it's": []interface{}{map[string]interface{}{"a\\b\n\x01": true}}}
+	expr, err := ParseString("$.*[0].*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for m := range expr.Matches(data) {
+		n++
+		if got, want := m.Path(), `$['it\'s'][0]['a\\b\n\u0001']`; got != want {
+			t.Errorf("Path() = %s, want %s", got, want)
+		}
+	}
+	if n != 1 {
+		t.Errorf("Matches() yielded %d values, want 1", n)
+	}
+}
+
+func TestExprMatches_Break(t *testing.T) {
+	data := []interface{}{1.0, []interface{}{2.0, 3.0}, 4.0}
+	expr, err := ParseString("$..*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for m := range expr.Matches(data) {
+		got = append(got, m.Path())
+		if len(got) == 3 {
+			break
+		}
+	}
+	if want := []string{"$", "$[0]", "$[1]"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Matches() paths = %q, want %q", got, want)
+	}
+}
+
+func TestExprExistsCountFirst(t *testing.T) {
+	data := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"id": 1.0, "tags": []interface{}{"a", "b"}},
+			map[string]interface{}{"id": 2.0},
+			map[string]interface{}{"id": 3.0, "tags": []interface{}{}},
+		},
+	}
+
+	tests := []struct {
+		query  string
+		exists bool
+		count  int
+		first  interface{}
+	}{
+		{"$", true, 1, data},
+		{"$.items[*].id", true, 3, 1.0},
+		{"$.items[1:].id", true, 2, 2.0},
+		{"$..tags[*]", true, 2, "a"},
+		{"$.items[?(@.id > 1)].id", true, 2, 2.0},
+		{"$.items[5]", false, 0, nil},
+		{"$.missing.id", false, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			expr, err := ParseString(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.Exists(data); got != tt.exists {
+				t.Errorf("Exists() = %v, want %v", got, tt.exists)
+			}
+			if got, want := expr.Count(data), len(expr.Get(data)); got != tt.count || got != want {
+				t.Errorf("Count() = %d, want %d (len of Get() = %d)", got, tt.count, want)
+			}
+			first, ok := expr.First(data)
+			if ok != tt.exists || !reflect.DeepEqual(first, tt.first) {
+				t.Errorf("First() = %v, %v, want %v, %v", first, ok, tt.first, tt.exists)
+			}
+		})
+	}
+}
+
+func TestExprExists_StopsAtFirstMatch(t *testing.T) {
+	data := make([]interface{}, 1000)
+	for i := range data {
+		data[i] = float64(i)
+	}
+	e, err := ParseString("$[*]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := &countingSelector{selector: e.(*expr).selectors[1]}
+	e.(*expr).selectors[1] = counter
+
+	if !e.Exists(data) || counter.n != 1 {
+		t.Errorf("Exists() looked at %d values, want 1", counter.n)
+	}
+	counter.n = 0
+	if v, ok := e.First(data); !ok || v != 0.0 || counter.n != 1 {
+		t.Errorf("First() = %v after looking at %d values", v, counter.n)
+	}
+}
+
+// countingSelector counts the values its selector passes on.
+type countingSelector struct {
+	selector
+	n int
+}
+
+func (s *countingSelector) each(item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	return s.selector.each(item, path, func(v interface{}) bool {
+		s.n++
+		return fn(v)
+	})
+}
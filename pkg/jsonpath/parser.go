@@ -38,7 +38,7 @@ type token struct {
 // tokenize converts a JSONPath query string into a sequence of tokens
 func tokenize(query string) ([]token, error) {
 	if query == "" {
-		return nil, fmt.Errorf("empty query string")
+		return nil, syntaxError(0, "empty query", nil)
 	}
 
 	var tokens []token
@@ -46,7 +46,7 @@ func tokenize(query string) ([]token, error) {
 
 	// First token must be root ($)
 	if query[0] != '$' {
-		return nil, fmt.Errorf("query must start with '$' (root selector)")
+		return nil, syntaxError(0, fmt.Sprintf("query must start with '$', found '%c'", query[0]), rootFix(query))
 	}
 	tokens = append(tokens, token{typ: tokenRoot, value: "$", pos: 0})
 	pos = 1
@@ -107,7 +107,7 @@ func tokenize(query string) ([]token, error) {
 				pos++
 			}
 			if pos >= len(query) {
-				return nil, fmt.Errorf("unclosed string at position %d", start)
+				return nil, syntaxError(start, "unclosed string", closeQuote(query, '\''))
 			}
 			pos++ // skip closing quote
 			tokens = append(tokens, token{typ: tokenString, value: value, pos: start})
@@ -122,15 +122,19 @@ func tokenize(query string) ([]token, error) {
 				pos++
 			}
 			if pos >= len(query) {
-				return nil, fmt.Errorf("unclosed string at position %d", start)
+				return nil, syntaxError(start, "unclosed string", closeQuote(query, '"'))
 			}
 			pos++ // skip closing quote
 			tokens = append(tokens, token{typ: tokenString, value: value, pos: start})
 
-		case unicode.IsDigit(rune(ch)):
+		case unicode.IsDigit(rune(ch)) || ch == '-' && signAllowed(query, tokens, pos):
 			// Parse number
 			start := pos
 			value := ""
+			if ch == '-' {
+				value = "-"
+				pos++
+			}
 			for pos < len(query) && unicode.IsDigit(rune(query[pos])) {
 				value += string(query[pos])
 				pos++
@@ -161,7 +165,7 @@ func tokenize(query string) ([]token, error) {
 				pos++
 			}
 			if pos >= len(query) {
-				return nil, fmt.Errorf("unclosed regex pattern at position %d", start)
+				return nil, syntaxError(start, "unclosed regex pattern", nil)
 			}
 			value += "/" // add closing /
 			pos++        // skip closing /
@@ -200,13 +204,26 @@ func tokenize(query string) ([]token, error) {
 			tokens = append(tokens, token{typ: tokenOperator, value: value, pos: start})
 
 		default:
-			return nil, fmt.Errorf("unexpected character '%c' at position %d", ch, pos)
+			return nil, syntaxError(pos, fmt.Sprintf("unexpected character '%c'", ch), nameFix(query, tokens, pos))
 		}
 	}
 
 	return tokens, nil
 }
 
+// signAllowed reports whether the '-' at pos is the sign of a number: an
+// index, a slice bound, or a filter operand.
+func signAllowed(query string, tokens []token, pos int) bool {
+	if pos+1 >= len(query) || !unicode.IsDigit(rune(query[pos+1])) {
+		return false
+	}
+	switch tokens[len(tokens)-1].typ {
+	case tokenLeftBracket, tokenColon, tokenLeftParen, tokenOperator:
+		return true
+	}
+	return false
+}
+
 // parser holds the parsing state
 type parser struct {
 	tokens []token
@@ -216,7 +233,7 @@ type parser struct {
 // parse converts tokens into a compiled expression
 func parse(tokens []token) (*expr, error) {
 	if len(tokens) == 0 {
-		return nil, fmt.Errorf("empty token list")
+		return nil, syntaxError(0, "empty query", nil)
 	}
 
 	p := &parser{tokens: tokens, pos: 0}
@@ -227,7 +244,7 @@ func parse(tokens []token) (*expr, error) {
 func (p *parser) parseExpr() (*expr, error) {
 	// First token must be root
 	if !p.match(tokenRoot) {
-		return nil, fmt.Errorf("expression must start with root selector ($)")
+		return nil, syntaxError(p.current().pos, "query must start with '$', found "+describe(p.current()), nil)
 	}
 	p.advance()
 
@@ -264,15 +281,23 @@ func (p *parser) parseSelector() (selector, error) {
 	case tokenLeftBracket:
 		return p.parseBracket()
 
+	case tokenIdentifier:
+		// $store for $.store, unless a name with a space came before
+		var fix *queryEdit
+		if prev := p.tokens[p.pos-1].typ; prev == tokenRoot || prev == tokenRightBracket {
+			fix = &queryEdit{off: current.pos, insert: "."}
+		}
+		return nil, syntaxError(current.pos, "expected '.', '..', or '[' before "+describe(current), fix)
+
 	default:
-		return nil, fmt.Errorf("unexpected token type %v at position %d", current.typ, current.pos)
+		return nil, syntaxError(current.pos, "expected '.', '..', or '[', found "+describe(current), nil)
 	}
 }
 
 // parseChildOrWildcard parses a child selector or wildcard after a dot
 func (p *parser) parseChildOrWildcard() (selector, error) {
 	if p.isAtEnd() {
-		return nil, fmt.Errorf("unexpected end of expression after '.'")
+		return nil, syntaxError(-1, "unexpected end of query after '.'", nil)
 	}
 
 	current := p.current()
@@ -287,13 +312,18 @@ func (p *parser) parseChildOrWildcard() (selector, error) {
 		return &childSelector{name: name}, nil
 	}
 
-	return nil, fmt.Errorf("expected identifier or wildcard after '.', got %v", current.typ)
+	if current.typ == tokenLeftBracket {
+		// $.[0] and $.['name'] for $[0] and $['name']
+		dot := p.tokens[p.pos-1].pos
+		return nil, syntaxError(current.pos, "unexpected '[' after '.'", &queryEdit{off: dot, del: 1})
+	}
+	return nil, syntaxError(current.pos, "expected member name or '*' after '.', found "+describe(current), nil)
 }
 
 // parseRecursive parses a recursive descent selector
 func (p *parser) parseRecursive() (selector, error) {
 	if p.isAtEnd() {
-		return nil, fmt.Errorf("unexpected end of expression after '..'")
+		return nil, syntaxError(-1, "unexpected end of query after '..'", nil)
 	}
 
 	current := p.current()
@@ -308,18 +338,18 @@ func (p *parser) parseRecursive() (selector, error) {
 		return &recursiveWildcardSelector{}, nil
 	}
 
-	return nil, fmt.Errorf("expected identifier or wildcard after '..', got %v", current.typ)
+	return nil, syntaxError(current.pos, "expected member name or '*' after '..', found "+describe(current), nil)
 }
 
 // parseBracket parses bracket notation [...]
 func (p *parser) parseBracket() (selector, error) {
 	if !p.match(tokenLeftBracket) {
-		return nil, fmt.Errorf("expected '['")
+		return nil, syntaxError(p.current().pos, "expected '[', found "+describe(p.current()), nil)
 	}
 	p.advance()
 
 	if p.isAtEnd() {
-		return nil, fmt.Errorf("unexpected end of expression in bracket")
+		return nil, syntaxError(-1, "unexpected end of query after '['", nil)
 	}
 
 	current := p.current()
@@ -333,7 +363,7 @@ func (p *parser) parseBracket() (selector, error) {
 	if current.typ == tokenWildcard {
 		p.advance()
 		if !p.match(tokenRightBracket) {
-			return nil, fmt.Errorf("expected ']' after '*'")
+			return nil, p.expectedBracket("'*'")
 		}
 		p.advance()
 		return &wildcardSelector{}, nil
@@ -344,7 +374,7 @@ func (p *parser) parseBracket() (selector, error) {
 		name := current.value
 		p.advance()
 		if !p.match(tokenRightBracket) {
-			return nil, fmt.Errorf("expected ']' after string")
+			return nil, p.expectedBracket(describe(current))
 		}
 		p.advance()
 		return &childSelector{name: name}, nil
@@ -354,7 +384,7 @@ func (p *parser) parseBracket() (selector, error) {
 	if current.typ == tokenNumber {
 		start, err := strconv.Atoi(current.value)
 		if err != nil {
-			return nil, fmt.Errorf("invalid number: %v", err)
+			return nil, syntaxError(current.pos, "index "+current.value+" out of range", nil)
 		}
 		p.advance()
 
@@ -366,7 +396,7 @@ func (p *parser) parseBracket() (selector, error) {
 
 		// Simple index
 		if !p.match(tokenRightBracket) {
-			return nil, fmt.Errorf("expected ']' after number")
+			return nil, p.expectedBracket(current.value)
 		}
 		p.advance()
 		return &indexSelector{index: start}, nil
@@ -378,7 +408,12 @@ func (p *parser) parseBracket() (selector, error) {
 		return p.parseSlice(0, false)
 	}
 
-	return nil, fmt.Errorf("unexpected token in bracket: %v", current.typ)
+	if current.typ == tokenIdentifier {
+		// $[name] for $['name']
+		return nil, syntaxError(current.pos, "unquoted member name "+describe(current)+" in brackets",
+			&queryEdit{off: current.pos, del: len(current.value), insert: "'" + current.value + "'"})
+	}
+	return nil, syntaxError(current.pos, "expected index, slice, quoted name, '*', or filter after '[', found "+describe(current), nil)
 }
 
 // parseSlice parses the rest of an array slice
@@ -389,25 +424,33 @@ func (p *parser) parseSlice(start int, hasStart bool) (selector, error) {
 	if !p.match(tokenRightBracket) {
 		// There's an end value
 		if !p.match(tokenNumber) {
-			return nil, fmt.Errorf("expected number or ']' in slice")
+			return nil, syntaxError(p.current().pos, "expected number or ']' in slice, found "+describe(p.current()), nil)
 		}
 		var err error
 		end, err = strconv.Atoi(p.current().value)
 		if err != nil {
-			return nil, fmt.Errorf("invalid number in slice: %v", err)
+			return nil, syntaxError(p.current().pos, "index "+p.current().value+" out of range", nil)
 		}
 		hasEnd = true
 		p.advance()
 	}
 
 	if !p.match(tokenRightBracket) {
-		return nil, fmt.Errorf("expected ']' after slice")
+		return nil, p.expectedBracket("slice")
 	}
 	p.advance()
 
 	return &sliceSelector{start: start, end: end, hasStart: hasStart, hasEnd: hasEnd}, nil
 }
 
+// expectedBracket returns the error for a missing ']' after what.
+func (p *parser) expectedBracket(what string) error {
+	if p.isAtEnd() {
+		return syntaxError(-1, "expected ']' after "+what+", found end of query", &queryEdit{off: -1, insert: "]"})
+	}
+	return syntaxError(p.current().pos, "expected ']' after "+what+", found "+describe(p.current()), nil)
+}
+
 // Helper methods for parser
 func (p *parser) current() token {
 	if p.pos >= len(p.tokens) {
@@ -416,6 +459,27 @@ func (p *parser) current() token {
 	return p.tokens[p.pos]
 }
 
+// peek returns the token after the current one.
+func (p *parser) peek() token {
+	if p.pos+1 >= len(p.tokens) {
+		return token{typ: -1}
+	}
+	return p.tokens[p.pos+1]
+}
+
+// offset returns the position of the current token, or -1 at the end.
+func (p *parser) offset() int {
+	if p.isAtEnd() {
+		return -1
+	}
+	return p.current().pos
+}
+
+// matchOperator reports whether the current token is the operator op.
+func (p *parser) matchOperator(op string) bool {
+	return p.match(tokenOperator) && p.current().value == op
+}
+
 func (p *parser) advance() {
 	p.pos++
 }
@@ -435,130 +499,26 @@ func (p *parser) isAtEnd() bool {
 func (p *parser) parseFilter() (selector, error) {
 	// Consume the '?'
 	if !p.match(tokenQuestion) {
-		return nil, fmt.Errorf("expected '?' for filter")
-	}
-	p.advance()
-
-	// Expect '('
-	if !p.match(tokenLeftParen) {
-		return nil, fmt.Errorf("expected '(' after '?'")
+		return nil, syntaxError(p.current().pos, "expected '?', found "+describe(p.current()), nil)
 	}
 	p.advance()
 
-	// Collect all tokens until we find the matching ')'
-	// This includes handling nested parentheses and quotes
-	filterTokens, err := p.collectFilterTokens()
+	// The condition, parenthesized as [?(@.price < 10)] or bare as in
+	// RFC 9535: [?@.price < 10]
+	expr, err := p.parseLogical()
 	if err != nil {
 		return nil, err
 	}
 
-	// Reconstruct the filter expression string from tokens
-	filterStr := p.reconstructFilterString(filterTokens)
-
-	// Parse the filter expression
-	expr, err := parseFilterExpression(filterStr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid filter expression: %w", err)
-	}
-
 	// Expect ']'
 	if !p.match(tokenRightBracket) {
-		return nil, fmt.Errorf("expected ']' after filter expression")
+		return nil, p.expectedBracket("filter expression")
 	}
 	p.advance()
 
 	return &filterSelector{expr: expr}, nil
 }
 
-// collectFilterTokens collects tokens until the matching ')' is found
-func (p *parser) collectFilterTokens() ([]token, error) {
-	var tokens []token
-	parenDepth := 1 // We already consumed the opening '('
-
-	for !p.isAtEnd() {
-		current := p.current()
-
-		switch current.typ {
-		case tokenLeftParen:
-			parenDepth++
-		case tokenRightParen:
-			parenDepth--
-			if parenDepth == 0 {
-				p.advance() // consume the ')'
-				return tokens, nil
-			}
-		}
-
-		tokens = append(tokens, current)
-		p.advance()
-	}
-
-	return nil, fmt.Errorf("unclosed filter expression: missing ')'")
-}
-
-// reconstructFilterString rebuilds the filter expression string from tokens
-func (p *parser) reconstructFilterString(tokens []token) string {
-	var parts []string
-
-	for i := 0; i < len(tokens); i++ {
-		tok := tokens[i]
-
-		switch tok.typ {
-		case tokenAt:
-			parts = append(parts, "@")
-		case tokenDot:
-			parts = append(parts, ".")
-		case tokenIdentifier:
-			parts = append(parts, tok.value)
-		case tokenNumber:
-			parts = append(parts, tok.value)
-		case tokenString:
-			// Check if it's a regex pattern (starts with /)
-			if tok.value != "" && tok.value[0] == '/' {
-				parts = append(parts, tok.value)
-			} else {
-				// Re-add quotes for regular strings
-				parts = append(parts, "'"+tok.value+"'")
-			}
-		case tokenOperator:
-			parts = append(parts, tok.value)
-		default:
-			// For other tokens, just use their value
-			parts = append(parts, tok.value)
-		}
-	}
-
-	// Join parts and handle operators specially
-	result := ""
-	for i, part := range parts {
-		// Add space around operators for better parsing
-		if isOperator(part) {
-			if i > 0 && !isOperator(parts[i-1]) {
-				result += " "
-			}
-			result += part
-			if i < len(parts)-1 && !isOperator(parts[i+1]) {
-				result += " "
-			}
-		} else {
-			result += part
-		}
-	}
-
-	return result
-}
-
-// isOperator checks if a string is an operator
-func isOperator(s string) bool {
-	operators := []string{"==", "!=", "<=", ">=", "=~", "<", ">", "&&", "||", "!"}
-	for _, op := range operators {
-		if s == op {
-			return true
-		}
-	}
-	return false
-}
-
 // String returns a string representation of a tokenType
 func (t tokenType) String() string {
 	switch t {
@@ -596,6 +556,10 @@ func (s *rootSelector) apply(current []interface{}) []interface{} {
 	return current
 }
 
+func (s *rootSelector) each(item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	return fn(item)
+}
+
 // childSelector selects a named child property
 type childSelector struct {
 	name string
@@ -613,6 +577,15 @@ func (s *childSelector) apply(current []interface{}) []interface{} {
 	return results
 }
 
+func (s *childSelector) each(item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	if obj, ok := item.(map[string]interface{}); ok {
+		if val, exists := obj[s.name]; exists {
+			return visitMember(path, s.name, val, fn)
+		}
+	}
+	return true
+}
+
 // indexSelector selects an array element by index
 type indexSelector struct {
 	index int
@@ -634,6 +607,19 @@ func (s *indexSelector) apply(current []interface{}) []interface{} {
 	return results
 }
 
+func (s *indexSelector) each(item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	if arr, ok := item.([]interface{}); ok {
+		idx := s.index
+		if idx < 0 {
+			idx = len(arr) + idx
+		}
+		if idx >= 0 && idx < len(arr) {
+			return visitElement(path, idx, arr[idx], fn)
+		}
+	}
+	return true
+}
+
 // wildcardSelector selects all children
 type wildcardSelector struct{}
 
@@ -652,6 +638,24 @@ func (s *wildcardSelector) apply(current []interface{}) []interface{} {
 	return results
 }
 
+func (s *wildcardSelector) each(item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	switch v := item.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			if !visitMember(path, key, val, fn) {
+				return false
+			}
+		}
+	case []interface{}:
+		for i, val := range v {
+			if !visitElement(path, i, val, fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // sliceSelector selects a slice of array elements
 type sliceSelector struct {
 	start    int
@@ -667,36 +671,52 @@ func (s *sliceSelector) apply(current []interface{}) []interface{} {
 		if !ok {
 			continue
 		}
+		start, end := s.bounds(len(arr))
+		for i := start; i < end; i++ {
+			results = append(results, arr[i])
+		}
+	}
+	return results
+}
 
-		start := 0
-		if s.hasStart {
-			start = s.start
-			if start < 0 {
-				start = len(arr) + start
-			}
-			if start < 0 {
-				start = 0
-			}
+func (s *sliceSelector) each(item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	arr, ok := item.([]interface{})
+	if !ok {
+		return true
+	}
+	start, end := s.bounds(len(arr))
+	for i := start; i < end; i++ {
+		if !visitElement(path, i, arr[i], fn) {
+			return false
 		}
+	}
+	return true
+}
 
-		end := len(arr)
-		if s.hasEnd {
-			end = s.end
-			if end < 0 {
-				end = len(arr) + end
-			}
-			if end > len(arr) {
-				end = len(arr)
-			}
+// bounds returns the range of indexes the slice selects from an array of
+// length n. It is empty if start >= end.
+func (s *sliceSelector) bounds(n int) (start, end int) {
+	if s.hasStart {
+		start = s.start
+		if start < 0 {
+			start = n + start
+		}
+		if start < 0 {
+			start = 0
 		}
+	}
 
-		if start < end && start < len(arr) {
-			for i := start; i < end && i < len(arr); i++ {
-				results = append(results, arr[i])
-			}
+	end = n
+	if s.hasEnd {
+		end = s.end
+		if end < 0 {
+			end = n + end
+		}
+		if end > n {
+			end = n
 		}
 	}
-	return results
+	return start, end
 }
 
 // recursiveSelector recursively searches for a named property
@@ -739,6 +759,46 @@ func (s *recursiveSelector) apply(current []interface{}) []interface{} {
 	return results
 }
 
+func (s *recursiveSelector) each(item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	return s.descend(item, path, 0, fn)
+}
+
+// descend visits the member named s.name of item and of every value nested
+// in it, in the order apply finds them.
+func (s *recursiveSelector) descend(item interface{}, path *matchPath, depth int, fn func(interface{}) bool) bool {
+	// Limit recursion depth to prevent infinite loops
+	if depth > 100 {
+		return true
+	}
+
+	switch v := item.(type) {
+	case map[string]interface{}:
+		if val, exists := v[s.name]; exists {
+			if !visitMember(path, s.name, val, fn) {
+				return false
+			}
+		}
+		for key, child := range v {
+			path.pushName(key)
+			ok := s.descend(child, path, depth+1, fn)
+			path.pop()
+			if !ok {
+				return false
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			path.pushIndex(i)
+			ok := s.descend(child, path, depth+1, fn)
+			path.pop()
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // recursiveWildcardSelector recursively selects all values
 type recursiveWildcardSelector struct{}
 
@@ -772,3 +832,59 @@ func (s *recursiveWildcardSelector) apply(current []interface{}) []interface{} {
 
 	return results
 }
+
+func (s *recursiveWildcardSelector) each(item interface{}, path *matchPath, fn func(interface{}) bool) bool {
+	return s.descend(item, path, 0, fn)
+}
+
+// descend visits item and every value nested in it, in the order apply
+// finds them.
+func (s *recursiveWildcardSelector) descend(item interface{}, path *matchPath, depth int, fn func(interface{}) bool) bool {
+	// Limit recursion depth to prevent infinite loops
+	if depth > 100 {
+		return true
+	}
+	if !fn(item) {
+		return false
+	}
+
+	switch v := item.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path.pushName(key)
+			ok := s.descend(child, path, depth+1, fn)
+			path.pop()
+			if !ok {
+				return false
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			path.pushIndex(i)
+			ok := s.descend(child, path, depth+1, fn)
+			path.pop()
+			if !ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// visitMember calls fn with val, the member of an object named key, with
+// key pushed onto path.
+func visitMember(path *matchPath, key string, val interface{}, fn func(interface{}) bool) bool {
+	path.pushName(key)
+	ok := fn(val)
+	path.pop()
+	return ok
+}
+
+// visitElement calls fn with val, element i of an array, with i pushed onto
+// path.
+func visitElement(path *matchPath, i int, val interface{}, fn func(interface{}) bool) bool {
+	path.pushIndex(i)
+	ok := fn(val)
+	path.pop()
+	return ok
+}
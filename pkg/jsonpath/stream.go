@@ -0,0 +1,89 @@
+package jsonpath
+
+import "github.com/shapestone/shape-json/internal/pathplan"
+
+func init() {
+	pathplan.ForStream = func(query string) (pathplan.Plan, error) {
+		e, err := ParseString(query)
+		if err != nil {
+			return nil, err
+		}
+		return planStream(e.(*expr)), nil
+	}
+}
+
+// streamPlan is an expression split for evaluation over a stream, as
+// json.Decoder.Filter does; see pathplan.Plan. Its steps are followed
+// through the stream, and rest is run by Apply on the values they reach.
+type streamPlan struct {
+	steps []selector
+	rest  []selector
+}
+
+// planStream splits e into its leading child, index, and wildcard
+// selectors, and a filter after them, and the selectors after those.
+func planStream(e *expr) *streamPlan {
+	selectors := e.selectors
+	if len(selectors) == 0 {
+		return &streamPlan{}
+	}
+	selectors = selectors[1:] // the root selector
+
+	n := 0
+	for n < len(selectors) && streamable(selectors[n]) {
+		n++
+	}
+	if n < len(selectors) {
+		if _, ok := selectors[n].(*filterSelector); ok {
+			n++
+		}
+	}
+	return &streamPlan{steps: selectors[:n], rest: selectors[n:]}
+}
+
+// streamable reports whether sel selects children by name or index alone.
+func streamable(sel selector) bool {
+	switch s := sel.(type) {
+	case *childSelector, *wildcardSelector:
+		return true
+	case *indexSelector:
+		return s.index >= 0
+	}
+	return false
+}
+
+func (p *streamPlan) Len() int {
+	return len(p.steps)
+}
+
+func (p *streamPlan) Member(i int, name string) bool {
+	switch s := p.steps[i].(type) {
+	case *childSelector:
+		return s.name == name
+	case *wildcardSelector:
+		return true
+	}
+	return false
+}
+
+func (p *streamPlan) Element(i, n int) bool {
+	switch s := p.steps[i].(type) {
+	case *indexSelector:
+		return s.index == n
+	case *wildcardSelector, *filterSelector:
+		return true
+	}
+	return false
+}
+
+func (p *streamPlan) Apply(v interface{}) []interface{} {
+	if n := len(p.steps); n > 0 {
+		if f, ok := p.steps[n-1].(*filterSelector); ok && !f.expr.evaluate(v) {
+			return nil
+		}
+	}
+	if len(p.rest) == 0 {
+		return []interface{}{v}
+	}
+	return execute(p.rest, v)
+}
@@ -0,0 +1,90 @@
+package jsonpath
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shapestone/shape-json/internal/pathplan"
+)
+
+func TestStreamPlan(t *testing.T) {
+	data := map[string]interface{}{
+		"events": []interface{}{
+			map[string]interface{}{"level": "info", "msg": "a"},
+			map[string]interface{}{"level": "error", "msg": "b"},
+			map[string]interface{}{"level": "error", "msg": "c"},
+		},
+	}
+
+	tests := []struct {
+		query string
+		steps int
+	}{
+		{"$", 0},
+		{"$.events", 1},
+		{"$.events[1]", 2},
+		{"$.events[*].msg", 3},
+		{"$.events[?(@.level=='error')]", 2},
+		{"$.events[?(@.level=='error')].msg", 2},
+		{"$.events[-1].msg", 1},
+		{"$..msg", 0},
+		{"$.events[0:2].msg", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			e, err := ParseString(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			plan := planStream(e.(*expr))
+			if plan.Len() != tt.steps {
+				t.Fatalf("Len() = %d, want %d", plan.Len(), tt.steps)
+			}
+
+			// Following the steps by hand and applying the rest gives Get's results
+			got := follow(plan, 0, data)
+			if want := e.Get(data); !reflect.DeepEqual(got, want) {
+				t.Errorf("streamed %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// follow evaluates plan against v, at depth d, as a streaming reader would.
+func follow(plan pathplan.Plan, d int, v interface{}) []interface{} {
+	if d == plan.Len() {
+		return plan.Apply(v)
+	}
+	var out []interface{}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, child := range v {
+			if plan.Member(d, name) {
+				out = append(out, follow(plan, d+1, child)...)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if plan.Element(d, i) {
+				out = append(out, follow(plan, d+1, child)...)
+			}
+		}
+	}
+	return out
+}
+
+func TestStreamPlan_FilterSkipsObjects(t *testing.T) {
+	plan, err := pathplan.ForStream("$.a[?(@ == 1)]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Member(1, "x") {
+		t.Error("a filter step selected an object member")
+	}
+	if !plan.Element(1, 7) {
+		t.Error("a filter step did not select an array element")
+	}
+	if got := plan.Apply(2.0); got != nil {
+		t.Errorf("Apply(2) = %v, want nil", got)
+	}
+}
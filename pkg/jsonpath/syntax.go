@@ -0,0 +1,155 @@
+package jsonpath
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError is returned by ParseString for a query it cannot parse. Its
+// Error method gives the problem on one line; Snippet points at it in the
+// query.
+//
+// Example:
+//
+//	_, err := jsonpath.ParseString("$.[0]")
+//	var syntax *jsonpath.SyntaxError
+//	if errors.As(err, &syntax) {
+//	    fmt.Println(syntax) // jsonpath: unexpected '[' after '.' at position 2; did you mean $[0]?
+//	    fmt.Println(syntax.Snippet())
+//	    // $.[0]
+//	    //   ^
+//	}
+type SyntaxError struct {
+	// Query is the query that failed to parse.
+	Query string
+	// Offset is the byte offset in Query of the problem. It is len(Query)
+	// for a query that ends too early.
+	Offset int
+	// Msg describes the problem, quoting the text found at Offset.
+	Msg string
+	// Suggestion is Query corrected for a common mistake, such as an
+	// unquoted member name in brackets, or "" if there is none.
+	Suggestion string
+
+	fix *queryEdit // the edit behind Suggestion, until Query is known
+}
+
+// queryEdit is a change to a query: del bytes at off replaced by insert.
+// An off of -1 is the end of the query.
+type queryEdit struct {
+	off, del int
+	insert   string
+}
+
+func (e *SyntaxError) Error() string {
+	var b strings.Builder
+	b.WriteString("jsonpath: ")
+	b.WriteString(e.Msg)
+	b.WriteString(" at position ")
+	b.WriteString(strconv.Itoa(e.Offset))
+	if e.Suggestion != "" {
+		b.WriteString("; did you mean ")
+		b.WriteString(e.Suggestion)
+		b.WriteString("?")
+	}
+	return b.String()
+}
+
+// Snippet returns the query on one line and, under it, a caret at the
+// problem.
+func (e *SyntaxError) Snippet() string {
+	col := utf8.RuneCountInString(e.Query[:min(e.Offset, len(e.Query))])
+	return e.Query + "\n" + strings.Repeat(" ", col) + "^"
+}
+
+// syntaxError returns a *SyntaxError for the problem msg at off, -1 for
+// the end of the query, with fix as a likely correction.
+func syntaxError(off int, msg string, fix *queryEdit) *SyntaxError {
+	return &SyntaxError{Offset: off, Msg: msg, fix: fix}
+}
+
+// forQuery completes a *SyntaxError err with the query it was found in,
+// and returns err.
+func forQuery(err error, query string) error {
+	var e *SyntaxError
+	if !errors.As(err, &e) || e.Query != "" {
+		return err
+	}
+	e.Query = query
+	if e.Offset < 0 || e.Offset > len(query) {
+		e.Offset = len(query)
+	}
+	if fix := e.fix; fix != nil {
+		off := fix.off
+		if off < 0 || off > len(query) {
+			off = len(query)
+		}
+		end := min(off+fix.del, len(query))
+		e.Suggestion = query[:off] + fix.insert + query[end:]
+		e.fix = nil
+	}
+	return err
+}
+
+// describe quotes tok for an error message.
+func describe(tok token) string {
+	switch {
+	case tok.typ < 0:
+		return "end of query"
+	case tok.typ == tokenString && strings.HasPrefix(tok.value, "/"):
+		return tok.value
+	}
+	return "'" + tok.value + "'"
+}
+
+// quoteName returns name in bracket notation, as in ['first name'].
+func quoteName(name string) string {
+	if strings.Contains(name, "'") {
+		return `["` + name + `"]`
+	}
+	return "['" + name + "']"
+}
+
+// rootFix returns the edit that roots a query missing its '$', or nil.
+func rootFix(query string) *queryEdit {
+	switch c := query[0]; {
+	case c == '.' || c == '[':
+		return &queryEdit{insert: "$"}
+	case c == '@':
+		return &queryEdit{del: 1, insert: "$"}
+	case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		return &queryEdit{insert: "$."}
+	}
+	return nil
+}
+
+// nameFix returns the edit that puts a member name in brackets when the
+// character at pos, which the tokenizer rejects, continues a name after a
+// '.', as in $.first-name; or nil.
+func nameFix(query string, tokens []token, pos int) *queryEdit {
+	n := len(tokens)
+	if n < 2 || tokens[n-2].typ != tokenDot {
+		return nil
+	}
+	name := tokens[n-1]
+	if name.typ != tokenIdentifier || name.pos+len(name.value) != pos {
+		return nil
+	}
+	end := pos
+	for end < len(query) && query[end] != '.' && query[end] != '[' {
+		end++
+	}
+	dot := tokens[n-2].pos
+	return &queryEdit{off: dot, del: end - dot, insert: quoteName(query[name.pos:end])}
+}
+
+// closeQuote returns the edit that closes a string left open in brackets
+// at the end of a query, as in $['name], or nil.
+func closeQuote(query string, quote byte) *queryEdit {
+	if !strings.HasSuffix(query, "]") {
+		return nil
+	}
+	return &queryEdit{off: len(query) - 1, insert: string(quote)}
+}
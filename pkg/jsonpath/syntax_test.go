@@ -0,0 +1,74 @@
+package jsonpath
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseString_SyntaxError(t *testing.T) {
+	tests := []struct {
+		query      string
+		offset     int
+		msg        string
+		suggestion string
+	}{
+		{"", 0, "empty query", ""},
+		{"store.book", 0, "query must start with '$', found 's'", "$.store.book"},
+		{"@.a", 0, "query must start with '$', found '@'", "$.a"},
+		{"[0]", 0, "query must start with '$', found '['", "$[0]"},
+		{"$.[0]", 2, "unexpected '[' after '.'", "$[0]"},
+		{"$.a.['b']", 4, "unexpected '[' after '.'", "$.a['b']"},
+		{"$[name]", 2, "unquoted member name 'name' in brackets", "$['name']"},
+		{"$.first-name.x", 7, "unexpected character '-'", "$['first-name'].x"},
+		{"$.o-neil's", 3, "unexpected character '-'", `$["o-neil's"]`},
+		{"$store", 1, "expected '.', '..', or '[' before 'store'", "$.store"},
+		{"$.a b", 4, "expected '.', '..', or '[' before 'b'", ""},
+		{"$[0", 3, "expected ']' after 0, found end of query", "$[0]"},
+		{"$[*.a", 3, "expected ']' after '*', found '.'", ""},
+		{"$['a]", 2, "unclosed string", "$['a']"},
+		{"$[?(@.a = 1)]", 8, "'=' is not a comparison operator", "$[?(@.a == 1)]"},
+		{"$[?(@.a == 1]", 12, "unclosed filter expression: missing ')'", "$[?(@.a == 1)]"},
+		{"$[?(@.a == 1", 12, "unclosed filter expression: missing ')'", "$[?(@.a == 1)]"},
+		{"$[?(price < 10)]", 4, "unknown name 'price' in filter", "$[?(@.price < 10)]"},
+		{"$[?(@.a 5)]", 8, "expected ')', '&&', or '||' in filter, found '5'", ""},
+		{"$[?(1)]", 4, "expected comparison after '1'", ""},
+		{"$[?(@.a == )]", 11, "expected operand in filter, found ')'", ""},
+		{"$.", 2, "unexpected end of query after '.'", ""},
+		{"$..[0]", 3, "expected member name or '*' after '..', found '['", ""},
+		{"$[1:x]", 4, "expected number or ']' in slice, found 'x'", ""},
+		{"$[99999999999999999999]", 2, "index 99999999999999999999 out of range", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseString(tt.query)
+			var syntax *SyntaxError
+			if !errors.As(err, &syntax) {
+				t.Fatalf("ParseString() error = %v, want *SyntaxError", err)
+			}
+			if syntax.Query != tt.query || syntax.Offset != tt.offset || syntax.Msg != tt.msg || syntax.Suggestion != tt.suggestion {
+				t.Errorf("ParseString() error = %+v, want offset %d, msg %q, suggestion %q", syntax, tt.offset, tt.msg, tt.suggestion)
+			}
+			if tt.suggestion != "" {
+				if _, err := ParseString(tt.suggestion); err != nil {
+					t.Errorf("suggestion %s does not parse: %v", tt.suggestion, err)
+				}
+			}
+		})
+	}
+}
+
+func TestSyntaxError_Format(t *testing.T) {
+	_, err := ParseString("$.items.[0]")
+	if want := "jsonpath: unexpected '[' after '.' at position 8; did you mean $.items[0]?"; err == nil || err.Error() != want {
+		t.Errorf("Error() = %v, want %q", err, want)
+	}
+	if got, want := err.(*SyntaxError).Snippet(), "$.items.[0]\n        ^"; got != want {
+		t.Errorf("Snippet() = %q, want %q", got, want)
+	}
+
+	// The caret counts characters, not bytes
+	_, err = ParseString("$['é'].#")
+	if got, want := err.(*SyntaxError).Snippet(), "$['é'].#\n       ^"; got != want {
+		t.Errorf("Snippet() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,439 @@
+package jsonschema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// node is a compiled schema or subschema.
+type node struct {
+	ptr string // location within the schema document, for error messages
+
+	always *bool // boolean schema: true accepts everything, false nothing
+
+	types    []string
+	enum     []interface{}
+	hasConst bool
+	constVal interface{}
+
+	allOf []*node
+	anyOf []*node
+	oneOf []*node
+	not   *node
+	ifS   *node
+	thenS *node
+	elseS *node
+
+	ref     string
+	refNode *node
+
+	properties    map[string]*node
+	required      []string
+	additional    *node
+	patternProps  []patternProperty
+	propertyNames *node
+	minProperties int
+	maxProperties int // -1 means no limit
+
+	items       *node
+	prefixItems []*node
+	contains    *node
+	minItems    int
+	maxItems    int // -1 means no limit
+	uniqueItems bool
+
+	minLength int
+	maxLength int // -1 means no limit
+	pattern   *regexp.Regexp
+	format    string
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+	multipleOf       *float64
+
+	// whole is set when a keyword needs the complete value, which rules out
+	// checking the node incrementally (see Cursor).
+	whole bool
+}
+
+// patternProperty pairs a patternProperties regexp with its schema.
+type patternProperty struct {
+	re     *regexp.Regexp
+	schema *node
+}
+
+// compiler compiles a schema document, sharing nodes between $refs.
+type compiler struct {
+	doc   interface{}
+	nodes map[string]*node // by JSON Pointer into doc
+	refs  []*node          // nodes whose $ref is not yet resolved
+}
+
+// invalid returns a compile error for the schema at ptr.
+func invalid(ptr, format string, args ...interface{}) error {
+	if ptr == "" {
+		ptr = "#"
+	} else {
+		ptr = "#" + ptr
+	}
+	return fmt.Errorf("%w at %s: %s", ErrInvalidSchema, ptr, fmt.Sprintf(format, args...))
+}
+
+// compile compiles the schema raw found at ptr.
+func (c *compiler) compile(raw interface{}, ptr string) (*node, error) {
+	if n, ok := c.nodes[ptr]; ok {
+		return n, nil
+	}
+
+	n := &node{ptr: ptr, maxProperties: -1, maxItems: -1, maxLength: -1}
+	c.nodes[ptr] = n
+
+	if b, ok := raw.(bool); ok {
+		n.always = &b
+		return n, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, invalid(ptr, "schema must be an object or a boolean")
+	}
+
+	var err error
+	sub := func(key string) (*node, error) {
+		v, ok := m[key]
+		if !ok {
+			return nil, nil
+		}
+		return c.compile(v, ptr+"/"+escape(key))
+	}
+	subList := func(key string) ([]*node, error) {
+		v, ok := m[key]
+		if !ok {
+			return nil, nil
+		}
+		list, ok := v.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, invalid(ptr, "%s must be a non-empty array", key)
+		}
+		nodes := make([]*node, len(list))
+		for i, item := range list {
+			if nodes[i], err = c.compile(item, ptr+"/"+key+"/"+strconv.Itoa(i)); err != nil {
+				return nil, err
+			}
+		}
+		return nodes, nil
+	}
+
+	// Generic keywords
+	if v, ok := m["type"]; ok {
+		switch t := v.(type) {
+		case string:
+			n.types = []string{t}
+		case []interface{}:
+			for _, item := range t {
+				s, ok := item.(string)
+				if !ok {
+					return nil, invalid(ptr, "type must be a string or an array of strings")
+				}
+				n.types = append(n.types, s)
+			}
+		default:
+			return nil, invalid(ptr, "type must be a string or an array of strings")
+		}
+		for _, t := range n.types {
+			switch t {
+			case "null", "boolean", "object", "array", "number", "integer", "string":
+			default:
+				return nil, invalid(ptr, "unknown type %q", t)
+			}
+		}
+	}
+	if v, ok := m["enum"]; ok {
+		if n.enum, ok = v.([]interface{}); !ok {
+			return nil, invalid(ptr, "enum must be an array")
+		}
+	}
+	if v, ok := m["const"]; ok {
+		n.hasConst, n.constVal = true, v
+	}
+	if n.allOf, err = subList("allOf"); err != nil {
+		return nil, err
+	}
+	if n.anyOf, err = subList("anyOf"); err != nil {
+		return nil, err
+	}
+	if n.oneOf, err = subList("oneOf"); err != nil {
+		return nil, err
+	}
+	if n.not, err = sub("not"); err != nil {
+		return nil, err
+	}
+	if n.ifS, err = sub("if"); err != nil {
+		return nil, err
+	}
+	if n.thenS, err = sub("then"); err != nil {
+		return nil, err
+	}
+	if n.elseS, err = sub("else"); err != nil {
+		return nil, err
+	}
+	if v, ok := m["$ref"]; ok {
+		if n.ref, ok = v.(string); !ok {
+			return nil, invalid(ptr, "$ref must be a string")
+		}
+		c.refs = append(c.refs, n)
+	}
+
+	// Compile definitions eagerly so that errors in them are reported
+	for _, key := range []string{"$defs", "definitions"} {
+		if v, ok := m[key]; ok {
+			defs, ok := v.(map[string]interface{})
+			if !ok {
+				return nil, invalid(ptr, "%s must be an object", key)
+			}
+			for name, def := range defs {
+				if _, err := c.compile(def, ptr+"/"+key+"/"+escape(name)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	// Object keywords
+	if v, ok := m["properties"]; ok {
+		props, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, invalid(ptr, "properties must be an object")
+		}
+		n.properties = make(map[string]*node, len(props))
+		for name, prop := range props {
+			if n.properties[name], err = c.compile(prop, ptr+"/properties/"+escape(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if v, ok := m["required"]; ok {
+		list, ok := v.([]interface{})
+		if !ok {
+			return nil, invalid(ptr, "required must be an array of strings")
+		}
+		for _, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, invalid(ptr, "required must be an array of strings")
+			}
+			n.required = append(n.required, s)
+		}
+	}
+	if n.additional, err = sub("additionalProperties"); err != nil {
+		return nil, err
+	}
+	if v, ok := m["patternProperties"]; ok {
+		pats, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, invalid(ptr, "patternProperties must be an object")
+		}
+		keys := make([]string, 0, len(pats))
+		for k := range pats {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, pat := range keys {
+			re, err := regexp.Compile(pat)
+			if err != nil {
+				return nil, invalid(ptr, "patternProperties: %v", err)
+			}
+			s, err := c.compile(pats[pat], ptr+"/patternProperties/"+escape(pat))
+			if err != nil {
+				return nil, err
+			}
+			n.patternProps = append(n.patternProps, patternProperty{re: re, schema: s})
+		}
+	}
+	if n.propertyNames, err = sub("propertyNames"); err != nil {
+		return nil, err
+	}
+	if n.minProperties, err = nonNegative(m, "minProperties", 0, ptr); err != nil {
+		return nil, err
+	}
+	if n.maxProperties, err = nonNegative(m, "maxProperties", -1, ptr); err != nil {
+		return nil, err
+	}
+
+	// Array keywords
+	if v, ok := m["items"]; ok {
+		if list, isList := v.([]interface{}); isList {
+			// Draft-07 tuple form
+			for i, item := range list {
+				s, err := c.compile(item, ptr+"/items/"+strconv.Itoa(i))
+				if err != nil {
+					return nil, err
+				}
+				n.prefixItems = append(n.prefixItems, s)
+			}
+			if n.items, err = sub("additionalItems"); err != nil {
+				return nil, err
+			}
+		} else if n.items, err = sub("items"); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := m["prefixItems"]; ok {
+		if n.prefixItems, err = subList("prefixItems"); err != nil {
+			return nil, err
+		}
+	}
+	if n.contains, err = sub("contains"); err != nil {
+		return nil, err
+	}
+	if n.minItems, err = nonNegative(m, "minItems", 0, ptr); err != nil {
+		return nil, err
+	}
+	if n.maxItems, err = nonNegative(m, "maxItems", -1, ptr); err != nil {
+		return nil, err
+	}
+	if v, ok := m["uniqueItems"]; ok {
+		if n.uniqueItems, ok = v.(bool); !ok {
+			return nil, invalid(ptr, "uniqueItems must be a boolean")
+		}
+	}
+
+	// String keywords
+	if n.minLength, err = nonNegative(m, "minLength", 0, ptr); err != nil {
+		return nil, err
+	}
+	if n.maxLength, err = nonNegative(m, "maxLength", -1, ptr); err != nil {
+		return nil, err
+	}
+	if v, ok := m["pattern"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, invalid(ptr, "pattern must be a string")
+		}
+		if n.pattern, err = regexp.Compile(s); err != nil {
+			return nil, invalid(ptr, "pattern: %v", err)
+		}
+	}
+	if v, ok := m["format"]; ok {
+		if n.format, ok = v.(string); !ok {
+			return nil, invalid(ptr, "format must be a string")
+		}
+	}
+
+	// Numeric keywords
+	for _, kw := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"minimum", &n.minimum},
+		{"maximum", &n.maximum},
+		{"exclusiveMinimum", &n.exclusiveMinimum},
+		{"exclusiveMaximum", &n.exclusiveMaximum},
+		{"multipleOf", &n.multipleOf},
+	} {
+		if v, ok := m[kw.name]; ok {
+			f, ok := toFloat(v)
+			if !ok {
+				return nil, invalid(ptr, "%s must be a number", kw.name)
+			}
+			*kw.dst = &f
+		}
+	}
+	if n.multipleOf != nil && *n.multipleOf <= 0 {
+		return nil, invalid(ptr, "multipleOf must be greater than 0")
+	}
+
+	n.whole = n.allOf != nil || n.anyOf != nil || n.oneOf != nil || n.not != nil ||
+		n.ifS != nil || n.contains != nil || n.uniqueItems ||
+		(n.hasConst && isContainer(n.constVal))
+	for _, e := range n.enum {
+		n.whole = n.whole || isContainer(e)
+	}
+
+	return n, nil
+}
+
+// resolveRefs links every $ref to its target node.
+func (c *compiler) resolveRefs() error {
+	// Compiling a target may add more refs, so loop by index
+	for i := 0; i < len(c.refs); i++ {
+		n := c.refs[i]
+		if !strings.HasPrefix(n.ref, "#") {
+			return invalid(n.ptr, "only local $ref values are supported, got %q", n.ref)
+		}
+		ptr := n.ref[1:]
+
+		target, err := lookupPointer(c.doc, ptr)
+		if err != nil {
+			return invalid(n.ptr, "$ref %q: %v", n.ref, err)
+		}
+		if n.refNode, err = c.compile(target, ptr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isContainer reports whether v is a JSON object or array.
+func isContainer(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// lookupPointer resolves a JSON Pointer within doc.
+func lookupPointer(doc interface{}, ptr string) (interface{}, error) {
+	if ptr == "" {
+		return doc, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON Pointer %q", ptr)
+	}
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			child, ok := v[tok]
+			if !ok {
+				return nil, fmt.Errorf("%q not found", tok)
+			}
+			doc = child
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("invalid index %q", tok)
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("%q not found", tok)
+		}
+	}
+	return doc, nil
+}
+
+// nonNegative reads an optional non-negative integer keyword.
+func nonNegative(m map[string]interface{}, key string, def int, ptr string) (int, error) {
+	v, ok := m[key]
+	if !ok {
+		return def, nil
+	}
+	f, ok := toFloat(v)
+	if !ok || f < 0 || f != float64(int(f)) {
+		return 0, invalid(ptr, "%s must be a non-negative integer", key)
+	}
+	return int(f), nil
+}
+
+// escape escapes a JSON Pointer reference token.
+func escape(s string) string {
+	if !strings.ContainsAny(s, "~/") {
+		return s
+	}
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
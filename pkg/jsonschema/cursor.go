@@ -0,0 +1,246 @@
+package jsonschema
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Cursor validates a document incrementally while a decoder walks it, so a
+// document can be decoded and validated in a single pass. It is the engine
+// behind json.UnmarshalOptions.Schema; most code should use that or
+// Schema.Validate instead.
+//
+// A Cursor stands for one value of the document. The decoder starts with
+// Schema.Cursor for the root, asks each Cursor for the Cursor of a member or
+// element before decoding it, and calls Done once the value has been read.
+// Member and Element return nil for values that need no checks, and every
+// method accepts a nil receiver.
+//
+// Keywords that only look at the value itself or at its member names and
+// counts are checked without building anything. Subschemas that use allOf,
+// anyOf, oneOf, not, if, contains, uniqueItems, or an object or array in
+// enum or const need the whole value, which is parsed from its raw JSON
+// text at Done; the same happens for containers the decoder did not walk.
+type Cursor struct {
+	nodes []*node
+	path  string
+	errs  *ValidationErrors
+	keys  []string // member names, when a node has required
+	count int      // members or elements seen
+}
+
+// Cursor returns a Cursor for the root of a document. Violations found
+// through it and its descendants are returned by its Err method.
+func (s *Schema) Cursor() *Cursor {
+	errs := &ValidationErrors{}
+	c := newCursor(expand(nil, s.root, "", errs), "", errs)
+	if c == nil {
+		return &Cursor{errs: errs}
+	}
+	return c
+}
+
+// Err returns the violations found so far through c and every Cursor
+// derived from it, as ValidationErrors, or nil if there are none.
+func (c *Cursor) Err() error {
+	if c == nil || len(*c.errs) == 0 {
+		return nil
+	}
+	return *c.errs
+}
+
+// Member returns the Cursor for the value of the object member key.
+func (c *Cursor) Member(key string) *Cursor {
+	if c == nil {
+		return nil
+	}
+	c.count++
+	if c.keys != nil {
+		c.keys = append(c.keys, key)
+	}
+
+	path := c.path + "/" + escape(key)
+	var children []*node
+	for _, n := range c.nodes {
+		if n.whole || n.always != nil || !allowsType(n, "object") {
+			continue
+		}
+		if n.propertyNames != nil && !n.propertyNames.valid(key, 0) {
+			failer(c.path, c.errs)("propertyNames", "invalid property name %q", key)
+		}
+
+		matched := false
+		if s, ok := n.properties[key]; ok {
+			children = expand(children, s, path, c.errs)
+			matched = true
+		}
+		for _, pp := range n.patternProps {
+			if pp.re.MatchString(key) {
+				children = expand(children, pp.schema, path, c.errs)
+				matched = true
+			}
+		}
+		if !matched && n.additional != nil {
+			if n.additional.always != nil && !*n.additional.always {
+				failer(c.path, c.errs)("additionalProperties", "property %q is not allowed", key)
+			} else {
+				children = expand(children, n.additional, path, c.errs)
+			}
+		}
+	}
+	return newCursor(children, path, c.errs)
+}
+
+// Element returns the Cursor for the array element at index.
+func (c *Cursor) Element(index int) *Cursor {
+	if c == nil {
+		return nil
+	}
+	c.count++
+
+	path := c.path + "/" + strconv.Itoa(index)
+	var children []*node
+	for _, n := range c.nodes {
+		if n.whole || n.always != nil || !allowsType(n, "array") {
+			continue
+		}
+		switch {
+		case index < len(n.prefixItems):
+			children = expand(children, n.prefixItems[index], path, c.errs)
+		case n.items != nil:
+			children = expand(children, n.items, path, c.errs)
+		}
+	}
+	return newCursor(children, path, c.errs)
+}
+
+// Done checks the value once it has been read. raw is its JSON text, and
+// walked reports whether Member or Element was called for each of its
+// members or elements.
+func (c *Cursor) Done(raw []byte, walked bool) {
+	if c == nil || len(raw) == 0 {
+		return
+	}
+	fail := failer(c.path, c.errs)
+	container := raw[0] == '{' || raw[0] == '['
+
+	var v interface{}
+	parsed := false
+	for _, n := range c.nodes {
+		if n.always != nil {
+			fail("false", "no value is allowed here")
+			continue
+		}
+		if n.whole || !walked || !container {
+			if !parsed {
+				var err error
+				if v, err = fastparser.NewParser(raw).Parse(); err != nil {
+					return // the decoder reports syntax errors
+				}
+				parsed = true
+			}
+			n.validateKeywords(v, c.path, c.errs, 0)
+			continue
+		}
+		n.checkContainer(c, raw[0] == '{', fail)
+	}
+}
+
+// checkContainer checks the keywords of n that apply to a walked object or
+// array as a whole.
+func (n *node) checkContainer(c *Cursor, isObject bool, fail func(string, string, ...interface{})) {
+	actual := "array"
+	if isObject {
+		actual = "object"
+	}
+	if !allowsType(n, actual) {
+		fail("type", "expected %s, got %s", strings.Join(n.types, " or "), actual)
+		return
+	}
+	// enum and const hold only scalars here; see node.whole
+	if n.enum != nil {
+		fail("enum", "must be one of the allowed values")
+	}
+	if n.hasConst {
+		fail("const", "must be equal to the constant value")
+	}
+
+	if !isObject {
+		if c.count < n.minItems {
+			fail("minItems", "must have at least %d items", n.minItems)
+		}
+		if n.maxItems >= 0 && c.count > n.maxItems {
+			fail("maxItems", "must have at most %d items", n.maxItems)
+		}
+		return
+	}
+
+	for _, name := range n.required {
+		found := false
+		for _, k := range c.keys {
+			if k == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("required", "missing property %q", name)
+		}
+	}
+	if c.count < n.minProperties {
+		fail("minProperties", "must have at least %d properties", n.minProperties)
+	}
+	if n.maxProperties >= 0 && c.count > n.maxProperties {
+		fail("maxProperties", "must have at most %d properties", n.maxProperties)
+	}
+}
+
+// newCursor returns a Cursor checking nodes at path, or nil if there is
+// nothing to check.
+func newCursor(nodes []*node, path string, errs *ValidationErrors) *Cursor {
+	if len(nodes) == 0 {
+		return nil
+	}
+	c := &Cursor{nodes: nodes, path: path, errs: errs}
+	for _, n := range nodes {
+		if len(n.required) > 0 {
+			c.keys = make([]string, 0, 8)
+			break
+		}
+	}
+	return c
+}
+
+// expand appends n and the targets of its $ref chain to nodes, skipping
+// schemas that accept everything.
+func expand(nodes []*node, n *node, path string, errs *ValidationErrors) []*node {
+	for depth := 0; n != nil; depth++ {
+		if depth > maxRefDepth {
+			failer(path, errs)("$ref", "reference depth limit exceeded")
+			return nodes
+		}
+		if n.always == nil || !*n.always {
+			nodes = append(nodes, n)
+		}
+		if n.always != nil || n.ref == "" {
+			break
+		}
+		n = n.refNode
+	}
+	return nodes
+}
+
+// allowsType reports whether n accepts values of the given JSON type.
+func allowsType(n *node, typ string) bool {
+	if len(n.types) == 0 {
+		return true
+	}
+	for _, t := range n.types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package jsonschema
+
+import (
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	schema := MustCompile([]byte(`{
+		"type": "object",
+		"required": ["a", "b"],
+		"properties": {
+			"a": {"type": "array", "maxItems": 1, "items": {"type": "integer"}},
+			"b": {"type": "object", "required": ["c"]}
+		},
+		"additionalProperties": false
+	}`))
+
+	// Walk {"a":[1,"x"],"b":{"d":1},"z":0}, leaving "b" to be checked whole.
+	root := schema.Cursor()
+	a := root.Member("a")
+	a.Element(0).Done([]byte(`1`), false)
+	a.Element(1).Done([]byte(`"x"`), false)
+	a.Done([]byte(`[1,"x"]`), true)
+	root.Member("b").Done([]byte(`{"d":1}`), false)
+	if c := root.Member("z"); c != nil {
+		t.Errorf("Member(z) = %v, want nil", c)
+	}
+	root.Done([]byte(`{"a":[1,"x"],"b":{"d":1},"z":0}`), true)
+
+	want := []string{
+		`/a/1: type: expected integer, got string`,
+		`/a: maxItems: must have at most 1 items`,
+		`/b: required: missing property "c"`,
+		`(root): additionalProperties: property "z" is not allowed`,
+	}
+	errs, _ := root.Err().(ValidationErrors)
+	if len(errs) != len(want) {
+		t.Fatalf("Err() = %v, want %d violations", root.Err(), len(want))
+	}
+	for i, e := range errs {
+		if e.Error() != want[i] {
+			t.Errorf("violation %d = %q, want %q", i, e.Error(), want[i])
+		}
+	}
+}
+
+func TestCursor_NilAndTrue(t *testing.T) {
+	var c *Cursor
+	if c.Member("x") != nil || c.Element(0) != nil || c.Err() != nil {
+		t.Error("nil Cursor methods must be no-ops")
+	}
+	c.Done([]byte(`1`), false)
+
+	root := MustCompile([]byte(`true`)).Cursor()
+	if root.Member("x") != nil {
+		t.Error("true schema should not descend")
+	}
+	root.Done([]byte(`{"x":1}`), true)
+	if err := root.Err(); err != nil {
+		t.Errorf("Err() = %v", err)
+	}
+
+	root = MustCompile([]byte(`false`)).Cursor()
+	root.Done([]byte(`1`), false)
+	if root.Err() == nil {
+		t.Error("false schema accepted a value")
+	}
+}
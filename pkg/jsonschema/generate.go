@@ -0,0 +1,512 @@
+package jsonschema
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/shapestone/shape-json/internal/fake"
+)
+
+// generateAttempts bounds how many candidates Generate makes before giving
+// up on a schema it cannot satisfy, such as one whose "not" or "oneOf"
+// rules out most of what the other keywords allow.
+const generateAttempts = 100
+
+// maxGenerateDepth is the nesting depth past which Generate adds only the
+// required members and the minimum number of items, so that recursive
+// schemas produce finite documents.
+const maxGenerateDepth = 6
+
+// Generate returns a random value that the schema accepts, as a native
+// JSON value such as Validate takes: the value of a const, one of an enum,
+// strings in the declared format or matching the declared pattern,
+// numbers within the declared bounds, and objects with every required
+// property and some of the optional ones. Where the schema leaves a string
+// open, its property name picks what it looks like, so that "email" holds
+// an e-mail address and "createdAt" a timestamp.
+//
+// The same seed gives the same value. Generate checks each candidate with
+// Validate and returns an error if it finds none that the schema accepts.
+//
+// Example:
+//
+//	v, err := schema.Generate(42)
+//	// map[string]interface{}{"id": int64(618), "email": "grace.cedar@example.org"}
+func (s *Schema) Generate(seed int64) (interface{}, error) {
+	r := rand.New(rand.NewSource(seed))
+	for i := 0; i < generateAttempts; i++ {
+		g := &generator{r: r}
+		v := g.value([]*node{s.root}, "", 0)
+		if !g.impossible && s.root.valid(v, 0) {
+			return v, nil
+		}
+	}
+	return nil, errors.New("jsonschema: Generate found no value that the schema accepts")
+}
+
+// generator makes up a candidate value for a schema.
+type generator struct {
+	r          *rand.Rand
+	impossible bool // a false schema was reached
+}
+
+// constraints merges the keywords of the schemas a value must satisfy at
+// once: a node, its $ref target, its allOf members, and the branches of
+// anyOf and oneOf picked for this value.
+type constraints struct {
+	parts []*node
+
+	types    []string
+	enum     []interface{}
+	hasEnum  bool
+	constVal interface{}
+	hasConst bool
+
+	properties    map[string]*node
+	required      map[string]bool
+	additional    *node
+	minProperties int
+	maxProperties int
+
+	prefixItems []*node
+	items       *node
+	contains    *node
+	minItems    int
+	maxItems    int
+	uniqueItems bool
+
+	minLength int
+	maxLength int
+	pattern   string
+	format    string
+
+	lo, hi         float64
+	loExcl, hiExcl bool
+	multipleOf     float64
+}
+
+// merge collects the constraints of nodes.
+func (g *generator) merge(nodes []*node) *constraints {
+	c := &constraints{
+		properties:    map[string]*node{},
+		required:      map[string]bool{},
+		maxProperties: -1,
+		maxItems:      -1,
+		maxLength:     -1,
+		lo:            math.Inf(-1),
+		hi:            math.Inf(1),
+	}
+	for _, n := range nodes {
+		g.add(c, n, 0)
+	}
+	return c
+}
+
+// add adds the constraints of n, its $ref target, its allOf members, and
+// a randomly picked branch of its anyOf and oneOf to c.
+func (g *generator) add(c *constraints, n *node, depth int) {
+	if depth > maxRefDepth {
+		g.impossible = true
+		return
+	}
+	if n.always != nil {
+		g.impossible = g.impossible || !*n.always
+		return
+	}
+	c.parts = append(c.parts, n)
+	if n.refNode != nil {
+		g.add(c, n.refNode, depth+1)
+	}
+	for _, s := range n.allOf {
+		g.add(c, s, depth+1)
+	}
+	if len(n.anyOf) > 0 {
+		g.add(c, n.anyOf[g.r.Intn(len(n.anyOf))], depth+1)
+	}
+	if len(n.oneOf) > 0 {
+		g.add(c, n.oneOf[g.r.Intn(len(n.oneOf))], depth+1)
+	}
+
+	if len(n.types) > 0 {
+		c.types = intersectTypes(c.types, n.types)
+		if len(c.types) == 0 {
+			g.impossible = true
+		}
+	}
+	if n.hasConst && !c.hasConst {
+		c.hasConst, c.constVal = true, n.constVal
+	}
+	if n.enum != nil && !c.hasEnum {
+		c.hasEnum, c.enum = true, n.enum
+	}
+
+	names := make([]string, 0, len(n.properties))
+	for name := range n.properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if _, ok := c.properties[name]; !ok {
+			c.properties[name] = n.properties[name]
+		}
+	}
+	for _, name := range n.required {
+		c.required[name] = true
+	}
+	if c.additional == nil {
+		c.additional = n.additional
+	}
+	c.minProperties = max(c.minProperties, n.minProperties)
+	c.maxProperties = minLimit(c.maxProperties, n.maxProperties)
+
+	if c.prefixItems == nil {
+		c.prefixItems = n.prefixItems
+	}
+	if c.items == nil {
+		c.items = n.items
+	}
+	if c.contains == nil {
+		c.contains = n.contains
+	}
+	c.minItems = max(c.minItems, n.minItems)
+	c.maxItems = minLimit(c.maxItems, n.maxItems)
+	c.uniqueItems = c.uniqueItems || n.uniqueItems
+
+	c.minLength = max(c.minLength, n.minLength)
+	c.maxLength = minLimit(c.maxLength, n.maxLength)
+	if c.pattern == "" && n.pattern != nil {
+		c.pattern = n.pattern.String()
+	}
+	if c.format == "" {
+		c.format = n.format
+	}
+
+	if n.minimum != nil && *n.minimum >= c.lo {
+		c.lo, c.loExcl = *n.minimum, c.loExcl && *n.minimum == c.lo
+	}
+	if n.exclusiveMinimum != nil && *n.exclusiveMinimum >= c.lo {
+		c.lo, c.loExcl = *n.exclusiveMinimum, true
+	}
+	if n.maximum != nil && *n.maximum <= c.hi {
+		c.hi, c.hiExcl = *n.maximum, c.hiExcl && *n.maximum == c.hi
+	}
+	if n.exclusiveMaximum != nil && *n.exclusiveMaximum <= c.hi {
+		c.hi, c.hiExcl = *n.exclusiveMaximum, true
+	}
+	if n.multipleOf != nil && c.multipleOf == 0 {
+		c.multipleOf = *n.multipleOf
+	}
+}
+
+// value returns a value for the schemas nodes, found at a member called
+// name, depth levels down.
+func (g *generator) value(nodes []*node, name string, depth int) interface{} {
+	c := g.merge(nodes)
+	if c.hasConst {
+		return copyValue(c.constVal)
+	}
+	if c.hasEnum {
+		for _, i := range g.r.Perm(len(c.enum)) {
+			if c.accepts(c.enum[i]) {
+				return copyValue(c.enum[i])
+			}
+		}
+		g.impossible = true
+		return nil
+	}
+
+	switch g.pickType(c) {
+	case "null":
+		return nil
+	case "boolean":
+		return g.r.Intn(2) == 1
+	case "object":
+		return g.object(c, depth)
+	case "array":
+		return g.array(c, name, depth)
+	case "integer":
+		return g.integer(c)
+	case "number":
+		return g.number(c)
+	default:
+		return g.string(c, name)
+	}
+}
+
+// pickType picks the JSON type of the value: one of those the schemas
+// allow, or the type their keywords are about.
+func (g *generator) pickType(c *constraints) string {
+	if len(c.types) > 0 {
+		return c.types[g.r.Intn(len(c.types))]
+	}
+	switch {
+	case len(c.properties) > 0 || len(c.required) > 0 || c.minProperties > 0:
+		return "object"
+	case c.items != nil || c.prefixItems != nil || c.contains != nil || c.minItems > 0:
+		return "array"
+	case c.pattern != "" || c.format != "" || c.minLength > 0 || c.maxLength >= 0:
+		return "string"
+	case c.multipleOf != 0 && c.multipleOf == math.Trunc(c.multipleOf):
+		return "integer"
+	case !math.IsInf(c.lo, 0) || !math.IsInf(c.hi, 0) || c.multipleOf != 0:
+		return "number"
+	}
+	return [...]string{"string", "integer", "boolean"}[g.r.Intn(3)]
+}
+
+// accepts reports whether every schema merged into c accepts v.
+func (c *constraints) accepts(v interface{}) bool {
+	for _, n := range c.parts {
+		if !n.valid(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *generator) object(c *constraints, depth int) map[string]interface{} {
+	obj := map[string]interface{}{}
+	names := make([]string, 0, len(c.properties)+len(c.required))
+	for name := range c.properties {
+		names = append(names, name)
+	}
+	for name := range c.required {
+		if _, ok := c.properties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var optional []string
+	for _, name := range names {
+		if !c.required[name] {
+			optional = append(optional, name)
+			if depth >= maxGenerateDepth || g.r.Intn(2) == 0 {
+				continue
+			}
+		}
+		obj[name] = g.member(c, name, depth)
+	}
+	for _, name := range optional {
+		if len(obj) >= c.minProperties {
+			break
+		}
+		if _, ok := obj[name]; !ok {
+			obj[name] = g.member(c, name, depth)
+		}
+	}
+	for len(obj) < c.minProperties {
+		if c.additional != nil && c.additional.always != nil && !*c.additional.always {
+			break
+		}
+		name := fake.Word(g.r)
+		if _, ok := obj[name]; !ok {
+			obj[name] = g.member(c, name, depth)
+		}
+	}
+	return obj
+}
+
+// member returns a value for the member name of an object.
+func (g *generator) member(c *constraints, name string, depth int) interface{} {
+	if s, ok := c.properties[name]; ok {
+		return g.value([]*node{s}, name, depth+1)
+	}
+	if c.additional != nil {
+		return g.value([]*node{c.additional}, name, depth+1)
+	}
+	return fake.ForName(g.r, name)
+}
+
+func (g *generator) array(c *constraints, name string, depth int) []interface{} {
+	n := c.minItems
+	if depth < maxGenerateDepth {
+		n += g.r.Intn(3)
+	}
+	if c.items != nil && c.items.always != nil && !*c.items.always {
+		n = min(n, len(c.prefixItems))
+	}
+	if c.maxItems >= 0 {
+		n = min(n, c.maxItems)
+	}
+
+	arr := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		var item interface{}
+		for try := 0; try < 10; try++ {
+			switch {
+			case i < len(c.prefixItems):
+				item = g.value([]*node{c.prefixItems[i]}, name, depth+1)
+			case c.items != nil:
+				item = g.value([]*node{c.items}, name, depth+1)
+			default:
+				item = fake.ForName(g.r, name)
+			}
+			if !c.uniqueItems || !containsEqual(arr, item) {
+				break
+			}
+		}
+		arr = append(arr, item)
+	}
+
+	if c.contains != nil {
+		for _, item := range arr {
+			if c.contains.valid(item, 0) {
+				return arr
+			}
+		}
+		item := g.value([]*node{c.contains}, name, depth+1)
+		if len(arr) > len(c.prefixItems) && (c.maxItems < 0 || len(arr) >= c.maxItems) {
+			arr[len(arr)-1] = item
+		} else {
+			arr = append(arr, item)
+		}
+	}
+	return arr
+}
+
+func (g *generator) string(c *constraints, name string) string {
+	if s, ok := fake.Format(g.r, c.format); ok {
+		return s
+	}
+	if c.pattern != "" {
+		if s, ok := fake.Match(g.r, c.pattern); ok {
+			return s
+		}
+	}
+	s := fake.ForName(g.r, name)
+	if c.maxLength >= 0 {
+		s = fake.Truncate(s, c.maxLength)
+	}
+	if n := utf8.RuneCountInString(s); n < c.minLength {
+		s += fake.Letters(g.r, c.minLength-n)
+	}
+	return s
+}
+
+// bounds returns the range of numbers to pick from: the declared bounds,
+// or a range of a thousand next to the one declared, or from 0 to 1000.
+func (c *constraints) bounds() (lo, hi float64) {
+	lo, hi = c.lo, c.hi
+	switch {
+	case math.IsInf(lo, 0) && math.IsInf(hi, 0):
+		lo, hi = 0, 1000
+	case math.IsInf(lo, 0):
+		lo = min(0, hi-1000)
+	case math.IsInf(hi, 0):
+		hi = lo + 1000
+	}
+	return lo, hi
+}
+
+func (g *generator) integer(c *constraints) int64 {
+	lo, hi := c.bounds()
+	a, b := math.Ceil(lo), math.Floor(hi)
+	if c.loExcl && a == lo {
+		a++
+	}
+	if c.hiExcl && b == hi {
+		b--
+	}
+	if c.multipleOf != 0 {
+		m := c.multipleOf
+		ka, kb := math.Ceil(a/m), math.Floor(b/m)
+		if ka > kb {
+			g.impossible = true
+			return int64(a)
+		}
+		return int64(m * (ka + float64(g.r.Int63n(int64(min(kb-ka, 1<<40))+1))))
+	}
+	if a > b {
+		g.impossible = true
+		return int64(a)
+	}
+	return int64(a) + g.r.Int63n(int64(min(b-a, 1<<52))+1)
+}
+
+func (g *generator) number(c *constraints) float64 {
+	lo, hi := c.bounds()
+	if c.multipleOf != 0 {
+		m := c.multipleOf
+		ka, kb := math.Ceil(lo/m), math.Floor(hi/m)
+		if c.loExcl && ka*m == lo {
+			ka++
+		}
+		if c.hiExcl && kb*m == hi {
+			kb--
+		}
+		if ka > kb {
+			g.impossible = true
+			return lo
+		}
+		return m * (ka + float64(g.r.Int63n(int64(min(kb-ka, 1<<40))+1)))
+	}
+	f := lo + g.r.Float64()*(hi-lo)
+	// Two decimal places look like real data, if they stay in range
+	if rounded := math.Round(f*100) / 100; rounded > lo && rounded < hi ||
+		rounded == lo && !c.loExcl || rounded == hi && !c.hiExcl {
+		return rounded
+	}
+	return f
+}
+
+// intersectTypes returns the types in both a and b, treating "integer" as
+// a kind of "number". A nil a allows every type.
+func intersectTypes(a, b []string) []string {
+	if a == nil {
+		return append([]string(nil), b...)
+	}
+	out := []string{}
+	for _, x := range a {
+		for _, y := range b {
+			switch {
+			case x == y:
+				out = append(out, x)
+			case x == "integer" && y == "number", x == "number" && y == "integer":
+				out = append(out, "integer")
+			}
+		}
+	}
+	return out
+}
+
+// minLimit returns the tighter of two limits, where -1 means no limit.
+func minLimit(a, b int) int {
+	if a < 0 || b >= 0 && b < a {
+		return b
+	}
+	return a
+}
+
+// containsEqual reports whether list holds a value equal to v.
+func containsEqual(list []interface{}, v interface{}) bool {
+	for _, item := range list {
+		if equal(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyValue returns a deep copy of a native JSON value, so that values
+// taken from a const or an enum do not share the schema's.
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[k] = copyValue(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = copyValue(e)
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,74 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	schemas := []string{
+		`{"type":"object","required":["id","email","status","code","when"],"properties":{
+			"id":{"type":"string","format":"uuid"},
+			"email":{"type":"string","format":"email"},
+			"status":{"enum":["active","disabled"]},
+			"code":{"type":"string","pattern":"^[A-Z]{3}-\\d{4}$"},
+			"when":{"type":"string","format":"date-time"},
+			"title":{"type":"string","minLength":3,"maxLength":5},
+			"score":{"type":"number","minimum":0,"exclusiveMaximum":1},
+			"count":{"type":"integer","multipleOf":5,"minimum":10,"maximum":30},
+			"tags":{"type":"array","minItems":2,"uniqueItems":true,"items":{"type":"integer","minimum":1,"maximum":3}}
+		}}`,
+		`{"$defs":{"node":{"type":"object","required":["name"],"properties":{
+			"name":{"type":"string"},"children":{"type":"array","items":{"$ref":"#/$defs/node"}}}}},
+		  "$ref":"#/$defs/node"}`,
+		`{"allOf":[{"type":"object","required":["a"],"properties":{"a":{"type":"integer","minimum":5}}},
+		           {"required":["b"],"properties":{"b":{"const":{"x":[1,2]}}}}],
+		  "minProperties":3}`,
+		`{"oneOf":[{"type":"string","format":"ipv4"},{"type":"integer","exclusiveMinimum":0,"maximum":1}]}`,
+		`{"type":"array","prefixItems":[{"type":"boolean"},{"type":"null"}],"items":false,"contains":{"type":"null"}}`,
+		`{"type":["number","string"],"not":{"type":"string"},"minimum":-2.5,"maximum":-2.5}`,
+		`true`,
+	}
+	for _, src := range schemas {
+		s := MustCompile([]byte(src))
+		for seed := int64(0); seed < 50; seed++ {
+			v, err := s.Generate(seed)
+			if err != nil {
+				t.Fatalf("Generate(%d) error = %v for %s", seed, err, src)
+			}
+			if err := s.Validate(v); err != nil {
+				t.Fatalf("Generate(%d) = %#v, which is invalid: %v", seed, v, err)
+			}
+		}
+	}
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	s := MustCompile([]byte(`{"type":"object","properties":{"a":{"type":"string"},"b":{"type":"number"},"c":{"type":"array"}}}`))
+	a, _ := s.Generate(7)
+	b, _ := s.Generate(7)
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("Generate(7) = %#v, then %#v", a, b)
+	}
+	different := false
+	for seed := int64(8); seed < 20 && !different; seed++ {
+		c, _ := s.Generate(seed)
+		different = !reflect.DeepEqual(a, c)
+	}
+	if !different {
+		t.Error("Generate returns the same value for every seed")
+	}
+}
+
+func TestGenerate_Unsatisfiable(t *testing.T) {
+	for _, src := range []string{
+		`false`,
+		`{"type":"integer","minimum":3,"maximum":2}`,
+		`{"allOf":[{"type":"string"},{"type":"integer"}]}`,
+		`{"enum":[1,2],"minimum":5}`,
+	} {
+		if v, err := MustCompile([]byte(src)).Generate(1); err == nil {
+			t.Errorf("Generate() = %#v for %s, want an error", v, src)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package jsonschema validates JSON values against JSON Schema documents.
+//
+// It implements the commonly used validation vocabulary of JSON Schema
+// draft 2020-12 (which also covers most draft-07 schemas):
+//
+//   - Any type: type, enum, const, allOf, anyOf, oneOf, not, if/then/else,
+//     $ref (local references such as "#", "#/$defs/name", and
+//     "#/definitions/name"), and boolean schemas
+//   - Objects: properties, required, additionalProperties,
+//     patternProperties, propertyNames, minProperties, maxProperties
+//   - Arrays: items, prefixItems, contains, minItems, maxItems, uniqueItems
+//   - Strings: minLength, maxLength, pattern, format (date-time, date,
+//     time, email, uuid, ipv4, ipv6, uri)
+//   - Numbers: minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+//     multipleOf
+//
+// Unknown keywords are ignored, as the specification requires. Remote
+// references are not fetched.
+//
+// ValidateStream validates NDJSON records or the elements of a large array
+// one at a time as they are read, reporting failures with line numbers.
+// The json package's UnmarshalOptions.Schema validates a document in the
+// same pass that decodes it, using a Cursor. Schema.Generate makes up
+// random values that a schema accepts, for test data.
+//
+// Example:
+//
+//	schema, err := jsonschema.Compile([]byte(`{
+//	    "type": "object",
+//	    "required": ["id"],
+//	    "properties": {"id": {"type": "integer", "minimum": 1}}
+//	}`))
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := schema.ValidateBytes(data); err != nil {
+//	    fmt.Println(err) // /id: minimum: must be >= 1
+//	}
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// Schema is a compiled JSON Schema. It is safe for concurrent use.
+type Schema struct {
+	root *node
+}
+
+// Compile parses and compiles a JSON Schema document.
+func Compile(data []byte) (*Schema, error) {
+	raw, err := fastparser.NewParser(data).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: invalid schema JSON: %w", err)
+	}
+	return CompileValue(raw)
+}
+
+// CompileValue compiles a JSON Schema held as a native value, as produced by
+// unmarshaling into interface{}.
+func CompileValue(raw interface{}) (*Schema, error) {
+	c := &compiler{doc: raw, nodes: make(map[string]*node)}
+	root, err := c.compile(raw, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.resolveRefs(); err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// CompileAt compiles the subschema found at the JSON Pointer ptr within doc,
+// a larger document such as an OpenAPI description. Local $refs resolve
+// against doc rather than the subschema, so "#/components/schemas/Pet"
+// works from anywhere in it, and compile errors name locations in doc.
+func CompileAt(doc interface{}, ptr string) (*Schema, error) {
+	raw, err := lookupPointer(doc, ptr)
+	if err != nil {
+		return nil, fmt.Errorf("jsonschema: %v", err)
+	}
+	c := &compiler{doc: doc, nodes: make(map[string]*node)}
+	root, err := c.compile(raw, ptr)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.resolveRefs(); err != nil {
+		return nil, err
+	}
+	return &Schema{root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the schema is invalid.
+// It simplifies initialization of global schema variables.
+func MustCompile(data []byte) *Schema {
+	s, err := Compile(data)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Validate checks a native JSON value (map[string]interface{},
+// []interface{}, string, float64, int64, bool, or nil) against the schema.
+// It returns nil if the value is valid, and otherwise a ValidationErrors
+// listing every violation found.
+func (s *Schema) Validate(v interface{}) error {
+	var errs ValidationErrors
+	s.root.validate(v, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// ValidateBytes parses a JSON document and validates it against the schema.
+// Syntax errors are returned as is; schema violations as ValidationErrors.
+func (s *Schema) ValidateBytes(data []byte) error {
+	v, err := fastparser.NewParser(data).Parse()
+	if err != nil {
+		return err
+	}
+	return s.Validate(v)
+}
+
+// ValidationError describes one schema violation.
+type ValidationError struct {
+	// InstancePath is a JSON Pointer to the offending value ("" for the
+	// root, "/items/0/name" for a nested one).
+	InstancePath string
+
+	// Keyword is the schema keyword that failed, such as "required".
+	Keyword string
+
+	// Message describes the violation.
+	Message string
+}
+
+// Error formats the error as "<path>: <keyword>: <message>".
+func (e *ValidationError) Error() string {
+	path := e.InstancePath
+	if path == "" {
+		path = "(root)"
+	}
+	return path + ": " + e.Keyword + ": " + e.Message
+}
+
+// ValidationErrors is the list of violations returned by Validate.
+type ValidationErrors []*ValidationError
+
+// Error joins all violations, one per line.
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns the individual errors, for use with errors.As.
+func (e ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ErrInvalidSchema is wrapped by errors returned from Compile for schemas
+// that are valid JSON but not a valid JSON Schema.
+var ErrInvalidSchema = errors.New("jsonschema: invalid schema")
@@ -0,0 +1,220 @@
+package jsonschema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		data    string
+		wantErr string // substring of the error, "" for valid
+	}{
+		{"type ok", `{"type":"string"}`, `"x"`, ""},
+		{"type mismatch", `{"type":"string"}`, `1`, "(root): type: expected string, got integer"},
+		{"integer accepts 1.0", `{"type":"integer"}`, `1.0`, ""},
+		{"integer rejects 1.5", `{"type":"integer"}`, `1.5`, "type"},
+		{"number accepts int", `{"type":"number"}`, `3`, ""},
+		{"type list", `{"type":["string","null"]}`, `null`, ""},
+		{"enum", `{"enum":["a",1]}`, `1.0`, ""},
+		{"enum miss", `{"enum":["a",1]}`, `"b"`, "enum"},
+		{"const", `{"const":{"a":[1]}}`, `{"a":[1]}`, ""},
+		{"false schema", `false`, `1`, "false"},
+		{"true schema", `true`, `{"x":1}`, ""},
+
+		{"required", `{"required":["id"]}`, `{}`, `required: missing property "id"`},
+		{"nested path", `{"properties":{"a":{"items":{"type":"string"}}}}`, `{"a":["x",2]}`, "/a/1: type"},
+		{"additional false", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"b":2}`, `property "b" is not allowed`},
+		{"additional schema", `{"additionalProperties":{"type":"integer"}}`, `{"b":"x"}`, "/b: type"},
+		{"pattern properties", `{"patternProperties":{"^x-":{"type":"string"}},"additionalProperties":false}`, `{"x-a":"ok"}`, ""},
+		{"property names", `{"propertyNames":{"maxLength":3}}`, `{"long":1}`, "propertyNames"},
+		{"min properties", `{"minProperties":1}`, `{}`, "minProperties"},
+
+		{"prefix items", `{"prefixItems":[{"type":"string"}],"items":{"type":"integer"}}`, `["a",1,2]`, ""},
+		{"draft-07 tuple", `{"items":[{"type":"string"}],"additionalItems":false}`, `["a",1]`, "/1: false"},
+		{"contains", `{"contains":{"const":3}}`, `[1,2]`, "contains"},
+		{"unique items", `{"uniqueItems":true}`, `[1,2,1.0]`, "items 0 and 2 are equal"},
+		{"max items", `{"maxItems":1}`, `[1,2]`, "maxItems"},
+
+		{"min length runes", `{"minLength":2}`, `"é"`, "minLength"},
+		{"pattern", `{"pattern":"^[a-z]+$"}`, `"abc1"`, "pattern"},
+		{"format date", `{"format":"date"}`, `"2024-02-30"`, "format"},
+		{"format date-time", `{"format":"date-time"}`, `"2024-01-02T03:04:05Z"`, ""},
+		{"format email", `{"format":"email"}`, `"not an email"`, "format"},
+		{"format uuid", `{"format":"uuid"}`, `"123e4567-e89b-12d3-a456-426614174000"`, ""},
+		{"format ipv4", `{"format":"ipv4"}`, `"::1"`, "format"},
+		{"unknown format", `{"format":"color"}`, `"red"`, ""},
+
+		{"minimum", `{"minimum":1}`, `0`, "minimum: must be >= 1"},
+		{"exclusive maximum", `{"exclusiveMaximum":10}`, `10`, "exclusiveMaximum"},
+		{"multiple of", `{"multipleOf":0.1}`, `0.3`, ""},
+		{"not multiple", `{"multipleOf":3}`, `10`, "multipleOf"},
+
+		{"anyOf", `{"anyOf":[{"type":"string"},{"type":"integer"}]}`, `true`, "anyOf"},
+		{"oneOf both", `{"oneOf":[{"type":"number"},{"type":"integer"}]}`, `1`, "matched 2"},
+		{"not", `{"not":{"type":"null"}}`, `null`, "not"},
+		{"if then", `{"if":{"properties":{"k":{"const":"a"}}},"then":{"required":["a"]},"else":{"required":["b"]}}`, `{"k":"a"}`, `missing property "a"`},
+		{"if else", `{"if":{"properties":{"k":{"const":"a"}}},"then":{"required":["a"]},"else":{"required":["b"]}}`, `{"k":"z"}`, `missing property "b"`},
+
+		{"ref defs", `{"$defs":{"pos":{"minimum":0}},"properties":{"n":{"$ref":"#/$defs/pos"}}}`, `{"n":-1}`, "/n: minimum"},
+		{"ref definitions", `{"definitions":{"s":{"type":"string"}},"$ref":"#/definitions/s"}`, `1`, "type"},
+		{"recursive ref", `{"type":"object","properties":{"child":{"$ref":"#"}},"additionalProperties":false}`, `{"child":{"child":{"x":1}}}`, "/child/child"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Compile([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			err = s.ValidateBytes([]byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateBytes() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateBytes() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_CollectsAllErrors(t *testing.T) {
+	s := MustCompile([]byte(`{
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {"tags": {"type": "array", "items": {"type": "string"}}}
+	}`))
+
+	err := s.Validate(map[string]interface{}{
+		"tags": []interface{}{"a", int64(1), true},
+	})
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("error = %T, want ValidationErrors", err)
+	}
+	want := []string{
+		`(root): required: missing property "id"`,
+		`(root): required: missing property "name"`,
+		`/tags/1: type: expected string, got integer`,
+		`/tags/2: type: expected string, got boolean`,
+	}
+	if len(verrs) != len(want) {
+		t.Fatalf("got %d errors, want %d:\n%v", len(verrs), len(want), err)
+	}
+	for i, w := range want {
+		if verrs[i].Error() != w {
+			t.Errorf("error %d = %q, want %q", i, verrs[i].Error(), w)
+		}
+	}
+
+	var single *ValidationError
+	if !errors.As(err, &single) || single.Keyword != "required" {
+		t.Errorf("errors.As(*ValidationError) = %v", single)
+	}
+}
+
+func TestValidate_EscapedPointer(t *testing.T) {
+	s := MustCompile([]byte(`{"additionalProperties": {"type": "string"}}`))
+	err := s.ValidateBytes([]byte(`{"a/b~c": 1}`))
+	if err == nil || !strings.HasPrefix(err.Error(), "/a~1b~0c: ") {
+		t.Errorf("error = %v, want instance path /a~1b~0c", err)
+	}
+}
+
+func TestValidate_SyntaxError(t *testing.T) {
+	s := MustCompile([]byte(`{}`))
+	err := s.ValidateBytes([]byte(`{"a":`))
+	if err == nil {
+		t.Fatal("expected syntax error")
+	}
+	var verrs ValidationErrors
+	if errors.As(err, &verrs) {
+		t.Errorf("syntax error reported as ValidationErrors: %v", err)
+	}
+}
+
+func TestCompile_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"not an object", `1`},
+		{"bad type", `{"type":"text"}`},
+		{"bad pattern", `{"pattern":"("}`},
+		{"negative minLength", `{"minLength":-1}`},
+		{"zero multipleOf", `{"multipleOf":0}`},
+		{"empty allOf", `{"allOf":[]}`},
+		{"missing ref", `{"$ref":"#/$defs/nope"}`},
+		{"remote ref", `{"$ref":"https://example.com/s.json"}`},
+		{"bad nested", `{"properties":{"a":{"type":5}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile([]byte(tt.schema))
+			if !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("Compile() error = %v, want ErrInvalidSchema", err)
+			}
+		})
+	}
+
+	if _, err := Compile([]byte(`{`)); err == nil || errors.Is(err, ErrInvalidSchema) {
+		t.Errorf("Compile(malformed JSON) error = %v, want syntax error", err)
+	}
+}
+
+func TestMustCompile_Panics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("MustCompile did not panic")
+		}
+	}()
+	MustCompile([]byte(`{"type":1}`))
+}
+
+func TestValidate_InfiniteRef(t *testing.T) {
+	s := MustCompile([]byte(`{"$ref":"#"}`))
+	if err := s.ValidateBytes([]byte(`1`)); err == nil {
+		t.Error("expected reference depth error")
+	}
+}
+
+func TestCompileAt(t *testing.T) {
+	doc, err := fastparser.NewParser([]byte(`{
+		"components": {"schemas": {
+			"Pet": {"type": "object", "required": ["name"], "properties": {"tag": {"$ref": "#/components/schemas/Tag"}}},
+			"Tag": {"type": "string"},
+			"Bad": {"type": 5}
+		}},
+		"paths": {"/pets": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}
+	}`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := CompileAt(doc, "/paths/~1pets/schema")
+	if err != nil {
+		t.Fatalf("CompileAt() error = %v", err)
+	}
+	if err := s.ValidateBytes([]byte(`[{"name":"rex","tag":"dog"}]`)); err != nil {
+		t.Errorf("valid value: %v", err)
+	}
+	err = s.ValidateBytes([]byte(`[{"tag":1}]`))
+	if err == nil || !strings.Contains(err.Error(), "/0: required") || !strings.Contains(err.Error(), "/0/tag: type") {
+		t.Errorf("error = %v", err)
+	}
+
+	if _, err := CompileAt(doc, "/components/schemas/Bad"); err == nil || !strings.Contains(err.Error(), "#/components/schemas/Bad") {
+		t.Errorf("CompileAt(Bad) error = %v, want location in doc", err)
+	}
+	if _, err := CompileAt(doc, "/components/schemas/Nope"); err == nil {
+		t.Error("CompileAt(missing) succeeded")
+	}
+}
@@ -0,0 +1,288 @@
+package jsonschema
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+)
+
+// RecordError reports a record of a stream that failed validation.
+type RecordError struct {
+	// Index is the zero-based position of the record in the stream. Blank
+	// NDJSON lines are not counted.
+	Index int
+
+	// Line is the 1-based line on which the record starts.
+	Line int
+
+	// Err is the ValidationErrors for the record, or the syntax error for an
+	// NDJSON line that is not valid JSON.
+	Err error
+}
+
+// Error formats the error as "line <n>: <err>".
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// ValidateStream validates a stream of records against schema as they are
+// read from r, holding only one record in memory at a time.
+//
+// If the first non-whitespace character of the input is '[', the input is a
+// single JSON array and each element is a record. Otherwise it is NDJSON
+// (JSON Lines): each non-blank line is a record.
+//
+// Every record that fails is passed to onError, and validation continues
+// with the next one. If onError returns a non-nil error, ValidateStream
+// stops and returns it. A nil onError stops at the first failure and
+// returns its *RecordError.
+//
+// An NDJSON line that is not valid JSON is reported to onError like any
+// other failing record. Malformed array input cannot be resynchronized, so
+// it stops validation with an error naming the line. Read errors from r are
+// returned as is.
+//
+// Example:
+//
+//	err := jsonschema.ValidateStream(file, schema, func(e *jsonschema.RecordError) error {
+//	    log.Printf("record %d: %v", e.Index, e) // record 3: line 4: /id: type: ...
+//	    return nil
+//	})
+func ValidateStream(r io.Reader, schema *Schema, onError func(*RecordError) error) error {
+	if onError == nil {
+		onError = func(e *RecordError) error { return e }
+	}
+	s := &recordScanner{r: bufio.NewReader(r), line: 1}
+
+	c, err := s.skipSpace()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.r.UnreadByte(); err != nil {
+		return err
+	}
+	if c == '[' {
+		return s.validateArray(schema, onError)
+	}
+	return s.validateLines(schema, onError)
+}
+
+// recordScanner reads records from a stream and tracks the current line.
+type recordScanner struct {
+	r    *bufio.Reader
+	line int
+	buf  []byte
+}
+
+// check validates one record and reports a failure to onError.
+func check(schema *Schema, v interface{}, index, line int, onError func(*RecordError) error) error {
+	if err := schema.Validate(v); err != nil {
+		return onError(&RecordError{Index: index, Line: line, Err: err})
+	}
+	return nil
+}
+
+// validateLines validates NDJSON input, one record per non-blank line.
+func (s *recordScanner) validateLines(schema *Schema, onError func(*RecordError) error) error {
+	index := 0
+	for {
+		line, err := s.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			v, perr := fastparser.NewParser(trimmed).Parse()
+			if perr != nil {
+				if cbErr := onError(&RecordError{Index: index, Line: s.line, Err: perr}); cbErr != nil {
+					return cbErr
+				}
+			} else if cbErr := check(schema, v, index, s.line, onError); cbErr != nil {
+				return cbErr
+			}
+			index++
+		}
+		if err == io.EOF {
+			return nil
+		}
+		s.line++
+	}
+}
+
+// validateArray validates each element of a top-level JSON array.
+func (s *recordScanner) validateArray(schema *Schema, onError func(*RecordError) error) error {
+	if _, err := s.readByte(); err != nil { // '['
+		return err
+	}
+
+	c, err := s.skipSpace()
+	if err != nil {
+		return s.syntaxError(err)
+	}
+	if c != ']' {
+		for index := 0; ; index++ {
+			line := s.line
+			if err := s.scanValue(c); err != nil {
+				return s.syntaxError(err)
+			}
+			v, err := fastparser.NewParser(s.buf).Parse()
+			if err != nil {
+				return fmt.Errorf("jsonschema: line %d: %w", line, err)
+			}
+			if err := check(schema, v, index, line, onError); err != nil {
+				return err
+			}
+
+			if c, err = s.skipSpace(); err != nil {
+				return s.syntaxError(err)
+			}
+			if c == ']' {
+				break
+			}
+			if c != ',' {
+				return fmt.Errorf("jsonschema: line %d: expected ',' or ']' in array", s.line)
+			}
+			if c, err = s.skipSpace(); err != nil {
+				return s.syntaxError(err)
+			}
+		}
+	}
+
+	if _, err := s.skipSpace(); err != io.EOF {
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("jsonschema: line %d: unexpected data after JSON array", s.line)
+	}
+	return nil
+}
+
+// syntaxError turns an unexpected EOF into a syntax error and returns
+// other errors unchanged.
+func (s *recordScanner) syntaxError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("jsonschema: line %d: unexpected end of JSON input", s.line)
+	}
+	return err
+}
+
+// readByte reads one byte, counting newlines.
+func (s *recordScanner) readByte() (byte, error) {
+	c, err := s.r.ReadByte()
+	if err == nil && c == '\n' {
+		s.line++
+	}
+	return c, err
+}
+
+// unreadByte pushes back the last byte read by readByte.
+func (s *recordScanner) unreadByte(c byte) error {
+	if c == '\n' {
+		s.line--
+	}
+	return s.r.UnreadByte()
+}
+
+// skipSpace reads and returns the first non-whitespace byte.
+func (s *recordScanner) skipSpace() (byte, error) {
+	for {
+		c, err := s.readByte()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\n', '\r':
+		default:
+			return c, nil
+		}
+	}
+}
+
+// scanValue reads the raw text of the value that begins with first into
+// s.buf. It only finds the value's extent; the caller validates the text.
+func (s *recordScanner) scanValue(first byte) error {
+	s.buf = append(s.buf[:0], first)
+
+	switch first {
+	case '{', '[':
+		depth := 1
+		inString := false
+		for depth > 0 {
+			c, err := s.readByte()
+			if err != nil {
+				return eofError(err)
+			}
+			s.buf = append(s.buf, c)
+			switch {
+			case inString:
+				if c == '\\' {
+					if c, err = s.readByte(); err != nil {
+						return eofError(err)
+					}
+					s.buf = append(s.buf, c)
+				} else if c == '"' {
+					inString = false
+				}
+			case c == '"':
+				inString = true
+			case c == '{' || c == '[':
+				depth++
+			case c == '}' || c == ']':
+				depth--
+			}
+		}
+		return nil
+
+	case '"':
+		for {
+			c, err := s.readByte()
+			if err != nil {
+				return eofError(err)
+			}
+			s.buf = append(s.buf, c)
+			if c == '\\' {
+				if c, err = s.readByte(); err != nil {
+					return eofError(err)
+				}
+				s.buf = append(s.buf, c)
+			} else if c == '"' {
+				return nil
+			}
+		}
+
+	default:
+		for {
+			c, err := s.readByte()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			switch c {
+			case ',', ']', '}', ' ', '\t', '\n', '\r':
+				return s.unreadByte(c)
+			}
+			s.buf = append(s.buf, c)
+		}
+	}
+}
+
+// eofError converts io.EOF inside a value to io.ErrUnexpectedEOF.
+func eofError(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,131 @@
+package jsonschema
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+var recordSchema = MustCompile([]byte(`{
+	"type": "object",
+	"required": ["id"],
+	"properties": {"id": {"type": "integer"}}
+}`))
+
+// collect runs ValidateStream and returns the reported failures as strings.
+func collect(t *testing.T, input string) ([]string, error) {
+	t.Helper()
+	var got []string
+	err := ValidateStream(strings.NewReader(input), recordSchema, func(e *RecordError) error {
+		got = append(got, e.Error())
+		return nil
+	})
+	return got, err
+}
+
+func TestValidateStream(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:  "ndjson",
+			input: "{\"id\":1}\n{\"id\":\"x\"}\n\n{}\n{\"id\":2}",
+			want: []string{
+				"line 2: /id: type: expected integer, got string",
+				"line 4: (root): required: missing property \"id\"",
+			},
+		},
+		{
+			name:  "ndjson syntax error continues",
+			input: "{\"id\":1\n{\"id\":2}\r\n{\"id\":true}\n",
+			want: []string{
+				"line 1: unexpected end of JSON input in object",
+				"line 3: /id: type: expected integer, got boolean",
+			},
+		},
+		{
+			name:  "array",
+			input: "[\n  {\"id\": 1},\n  {\"id\": \"a]\\\"}\"},\n  {\n    \"x\": [1, {}]\n  },\n  7\n]\n",
+			want: []string{
+				"line 3: /id: type: expected integer, got string",
+				"line 4: (root): required: missing property \"id\"",
+				"line 7: (root): type: expected object, got integer",
+			},
+		},
+		{name: "empty array", input: " [ ] "},
+		{name: "empty input", input: " \n "},
+		{name: "array missing comma", input: "[{\"id\":1} {\"id\":2}]", wantErr: "jsonschema: line 1: expected ',' or ']' in array"},
+		{name: "truncated array", input: "[{\"id\":1},\n{\"id\":", wantErr: "jsonschema: line 2: unexpected end of JSON input"},
+		{name: "invalid element", input: "[\n{\"id\":01}]", wantErr: "jsonschema: line 2:"},
+		{name: "trailing data", input: "[]\n[]", wantErr: "jsonschema: line 2: unexpected data after JSON array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collect(t, tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want prefix %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("failures =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidateStream_RecordError(t *testing.T) {
+	var records []*RecordError
+	err := ValidateStream(strings.NewReader("{}\n{\"id\":1}\n{}"), recordSchema, func(e *RecordError) error {
+		records = append(records, e)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0].Index != 0 || records[1].Index != 2 || records[1].Line != 3 {
+		t.Fatalf("records = %+v", records)
+	}
+	var verrs ValidationErrors
+	if !errors.As(records[0], &verrs) || verrs[0].Keyword != "required" {
+		t.Errorf("errors.As(ValidationErrors) failed for %v", records[0])
+	}
+}
+
+func TestValidateStream_Stop(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	err := ValidateStream(strings.NewReader("[{}, {}, {}]"), recordSchema, func(e *RecordError) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("err = %v, calls = %d", err, calls)
+	}
+
+	// A nil callback stops at the first failure.
+	err = ValidateStream(strings.NewReader("{\"id\":1}\n{}\n{}"), recordSchema, nil)
+	var re *RecordError
+	if !errors.As(err, &re) || re.Line != 2 {
+		t.Errorf("err = %v, want RecordError on line 2", err)
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, io.ErrClosedPipe }
+
+func TestValidateStream_ReadError(t *testing.T) {
+	if err := ValidateStream(failingReader{}, recordSchema, nil); err != io.ErrClosedPipe {
+		t.Errorf("err = %v, want io.ErrClosedPipe", err)
+	}
+}
@@ -0,0 +1,428 @@
+package jsonschema
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxRefDepth bounds $ref recursion so that a schema such as
+// {"$ref": "#"} fails instead of overflowing the stack.
+const maxRefDepth = 512
+
+// validate checks v against n, appending violations to errs.
+func (n *node) validate(v interface{}, path string, errs *ValidationErrors) {
+	n.validateDepth(v, path, errs, 0)
+}
+
+// valid reports whether v satisfies n without collecting errors.
+func (n *node) valid(v interface{}, depth int) bool {
+	var errs ValidationErrors
+	n.validateDepth(v, "", &errs, depth)
+	return len(errs) == 0
+}
+
+func (n *node) validateDepth(v interface{}, path string, errs *ValidationErrors, depth int) {
+	fail := failer(path, errs)
+
+	if n.always != nil {
+		if !*n.always {
+			fail("false", "no value is allowed here")
+		}
+		return
+	}
+
+	if n.ref != "" {
+		if depth >= maxRefDepth {
+			fail("$ref", "reference depth limit exceeded")
+			return
+		}
+		n.refNode.validateDepth(v, path, errs, depth+1)
+	}
+
+	n.validateKeywords(v, path, errs, depth)
+}
+
+// validateKeywords checks v against every keyword of n except $ref.
+func (n *node) validateKeywords(v interface{}, path string, errs *ValidationErrors, depth int) {
+	fail := failer(path, errs)
+
+	if len(n.types) > 0 && !matchesAnyType(v, n.types) {
+		fail("type", "expected %s, got %s", strings.Join(n.types, " or "), typeName(v))
+		// The remaining keywords would only report the same problem again
+		return
+	}
+	if n.enum != nil {
+		found := false
+		for _, e := range n.enum {
+			if equal(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("enum", "must be one of the allowed values")
+		}
+	}
+	if n.hasConst && !equal(v, n.constVal) {
+		fail("const", "must be equal to the constant value")
+	}
+
+	for _, s := range n.allOf {
+		s.validateDepth(v, path, errs, depth)
+	}
+	if n.anyOf != nil {
+		ok := false
+		for _, s := range n.anyOf {
+			if s.valid(v, depth) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			fail("anyOf", "must match at least one schema")
+		}
+	}
+	if n.oneOf != nil {
+		matched := 0
+		for _, s := range n.oneOf {
+			if s.valid(v, depth) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			fail("oneOf", "must match exactly one schema, matched %d", matched)
+		}
+	}
+	if n.not != nil && n.not.valid(v, depth) {
+		fail("not", "must not match the schema")
+	}
+	if n.ifS != nil {
+		if n.ifS.valid(v, depth) {
+			if n.thenS != nil {
+				n.thenS.validateDepth(v, path, errs, depth)
+			}
+		} else if n.elseS != nil {
+			n.elseS.validateDepth(v, path, errs, depth)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		n.validateObject(val, path, errs, depth, fail)
+	case []interface{}:
+		n.validateArray(val, path, errs, depth, fail)
+	case string:
+		n.validateString(val, fail)
+	default:
+		if f, ok := toFloat(v); ok {
+			n.validateNumber(f, fail)
+		}
+	}
+}
+
+// failer returns a function that appends violations at path to errs.
+func failer(path string, errs *ValidationErrors) func(keyword, format string, args ...interface{}) {
+	return func(keyword, format string, args ...interface{}) {
+		*errs = append(*errs, &ValidationError{
+			InstancePath: path,
+			Keyword:      keyword,
+			Message:      fmt.Sprintf(format, args...),
+		})
+	}
+}
+
+func (n *node) validateObject(obj map[string]interface{}, path string, errs *ValidationErrors, depth int, fail func(string, string, ...interface{})) {
+	for _, name := range n.required {
+		if _, ok := obj[name]; !ok {
+			fail("required", "missing property %q", name)
+		}
+	}
+	if len(obj) < n.minProperties {
+		fail("minProperties", "must have at least %d properties", n.minProperties)
+	}
+	if n.maxProperties >= 0 && len(obj) > n.maxProperties {
+		fail("maxProperties", "must have at most %d properties", n.maxProperties)
+	}
+
+	// Visit members in a stable order so that errors are reproducible
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		child := path + "/" + escape(k)
+		if n.propertyNames != nil && !n.propertyNames.valid(k, depth) {
+			fail("propertyNames", "invalid property name %q", k)
+		}
+
+		matched := false
+		if s, ok := n.properties[k]; ok {
+			s.validateDepth(obj[k], child, errs, depth)
+			matched = true
+		}
+		for _, pp := range n.patternProps {
+			if pp.re.MatchString(k) {
+				pp.schema.validateDepth(obj[k], child, errs, depth)
+				matched = true
+			}
+		}
+		if !matched && n.additional != nil {
+			if n.additional.always != nil && !*n.additional.always {
+				fail("additionalProperties", "property %q is not allowed", k)
+			} else {
+				n.additional.validateDepth(obj[k], child, errs, depth)
+			}
+		}
+	}
+}
+
+func (n *node) validateArray(arr []interface{}, path string, errs *ValidationErrors, depth int, fail func(string, string, ...interface{})) {
+	if len(arr) < n.minItems {
+		fail("minItems", "must have at least %d items", n.minItems)
+	}
+	if n.maxItems >= 0 && len(arr) > n.maxItems {
+		fail("maxItems", "must have at most %d items", n.maxItems)
+	}
+
+	for i, item := range arr {
+		child := path + "/" + strconv.Itoa(i)
+		switch {
+		case i < len(n.prefixItems):
+			n.prefixItems[i].validateDepth(item, child, errs, depth)
+		case n.items != nil:
+			n.items.validateDepth(item, child, errs, depth)
+		}
+	}
+
+	if n.contains != nil {
+		found := false
+		for _, item := range arr {
+			if n.contains.valid(item, depth) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("contains", "must contain at least one matching item")
+		}
+	}
+
+	if n.uniqueItems {
+		for i := 1; i < len(arr); i++ {
+			for j := 0; j < i; j++ {
+				if equal(arr[i], arr[j]) {
+					fail("uniqueItems", "items %d and %d are equal", j, i)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (n *node) validateString(s string, fail func(string, string, ...interface{})) {
+	if n.minLength > 0 || n.maxLength >= 0 {
+		length := utf8.RuneCountInString(s)
+		if length < n.minLength {
+			fail("minLength", "must be at least %d characters", n.minLength)
+		}
+		if n.maxLength >= 0 && length > n.maxLength {
+			fail("maxLength", "must be at most %d characters", n.maxLength)
+		}
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		fail("pattern", "must match pattern %q", n.pattern.String())
+	}
+	if n.format != "" && !checkFormat(n.format, s) {
+		fail("format", "must be a valid %s", n.format)
+	}
+}
+
+func (n *node) validateNumber(f float64, fail func(string, string, ...interface{})) {
+	if n.minimum != nil && f < *n.minimum {
+		fail("minimum", "must be >= %s", formatNumber(*n.minimum))
+	}
+	if n.maximum != nil && f > *n.maximum {
+		fail("maximum", "must be <= %s", formatNumber(*n.maximum))
+	}
+	if n.exclusiveMinimum != nil && f <= *n.exclusiveMinimum {
+		fail("exclusiveMinimum", "must be > %s", formatNumber(*n.exclusiveMinimum))
+	}
+	if n.exclusiveMaximum != nil && f >= *n.exclusiveMaximum {
+		fail("exclusiveMaximum", "must be < %s", formatNumber(*n.exclusiveMaximum))
+	}
+	if n.multipleOf != nil {
+		q := f / *n.multipleOf
+		if math.IsInf(q, 0) || math.Abs(q-math.Round(q)) > 1e-9 {
+			fail("multipleOf", "must be a multiple of %s", formatNumber(*n.multipleOf))
+		}
+	}
+}
+
+// matchesAnyType reports whether v is an instance of one of the named types.
+func matchesAnyType(v interface{}, types []string) bool {
+	actual := typeName(v)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns the JSON Schema type of v. Integral numbers are reported
+// as "integer".
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if f, ok := toFloat(v); ok {
+		if f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// toFloat converts any Go numeric type to float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// equal compares two JSON values structurally, treating numbers of
+// different Go types as equal when their values are.
+func equal(a, b interface{}) bool {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for k, v := range av {
+			w, ok := bv[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equal(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	case nil:
+		return b == nil
+	}
+	af, ok := toFloat(a)
+	if !ok {
+		return false
+	}
+	bf, ok := toFloat(b)
+	return ok && af == bf
+}
+
+// formatNumber renders a schema bound for an error message.
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+var (
+	dateRE = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	timeRE = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})$`)
+	uuidRE = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// checkFormat validates s against a format. Unknown formats are annotations
+// only and always pass.
+func checkFormat(format, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, strings.ToUpper(s))
+		return err == nil
+	case "date":
+		if !dateRE.MatchString(s) {
+			return false
+		}
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	case "time":
+		if !timeRE.MatchString(s) {
+			return false
+		}
+		_, err := time.Parse("15:04:05", s[:8])
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	case "uuid":
+		return uuidRE.MatchString(s)
+	case "ipv4":
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	case "ipv6":
+		ip := net.ParseIP(s)
+		return ip != nil && strings.Contains(s, ":")
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	}
+	return true
+}
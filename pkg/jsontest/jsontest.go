@@ -0,0 +1,394 @@
+// Package jsontest provides assertions for tests that produce JSON: a
+// comparison that ignores formatting and reports differences as a list of
+// changes, and golden files that hold the expected output.
+//
+//	func TestHandler(t *testing.T) {
+//	    got := render(t)
+//	    jsontest.AssertEqualJSON(t, []byte(`{"id":1,"name":"a"}`), got)
+//	    jsontest.AssertGoldenWithOptions(t, "testdata/user.json", got, jsontest.Options{
+//	        IgnorePaths: []string{"/createdAt", "/items/*/id"},
+//	    })
+//	}
+//
+// A failure lists each difference with the JSON Pointer to it:
+//
+//	JSON differs (-want +got):
+//	    replace /name: "a" -> "b"
+//	    remove /items/1: {"id":7}
+//	    add /tags: ["new"]
+//
+// Run the tests with -jsontest.update to write the output of
+// AssertGolden to its golden file instead of comparing with it.
+package jsontest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/shapestone/shape-json/pkg/json"
+)
+
+var update = flag.Bool("jsontest.update", false, "write the output of AssertGolden to its golden files")
+
+// Options configures the comparisons made by the functions of this
+// package. The zero value compares the whole documents, member order
+// included, with numbers compared by value.
+type Options struct {
+	// IgnorePaths lists JSON Pointers to values that are left out of the
+	// comparison, such as timestamps and generated IDs. A token "*"
+	// matches any member or element, so "/items/*/id" ignores the id of
+	// every item.
+	IgnorePaths []string
+
+	// FloatTolerance, if positive, treats two numbers as equal when their
+	// float64 values differ by no more than it.
+	FloatTolerance float64
+
+	// IgnoreKeyOrder compares objects regardless of the order of their
+	// members.
+	IgnoreKeyOrder bool
+}
+
+// AssertEqualJSON reports an error to t, with a list of the differences,
+// unless the JSON documents want and got hold the same data. Formatting
+// is ignored; the order of object members is not. It returns whether the
+// documents matched.
+func AssertEqualJSON(t testing.TB, want, got []byte) bool {
+	t.Helper()
+	return AssertEqualJSONWithOptions(t, want, got, Options{})
+}
+
+// AssertEqualJSONWithOptions is like AssertEqualJSON but compares as opts
+// says.
+func AssertEqualJSONWithOptions(t testing.TB, want, got []byte, opts Options) bool {
+	t.Helper()
+	diff, err := Compare(want, got, opts)
+	if err != nil {
+		t.Errorf("jsontest: %v", err)
+		return false
+	}
+	if diff != "" {
+		t.Errorf("%s", diff)
+		return false
+	}
+	return true
+}
+
+// AssertGolden compares got with the JSON document in the golden file at
+// path as AssertEqualJSON does. With -jsontest.update, it writes got to
+// the file instead, one member or element per line, creating the
+// directories on the way to it.
+func AssertGolden(t testing.TB, path string, got []byte) bool {
+	t.Helper()
+	return AssertGoldenWithOptions(t, path, got, Options{})
+}
+
+// AssertGoldenWithOptions is like AssertGolden but compares as opts says.
+func AssertGoldenWithOptions(t testing.TB, path string, got []byte, opts Options) bool {
+	t.Helper()
+	if *update {
+		if err := writeGolden(path, got); err != nil {
+			t.Errorf("jsontest: %v", err)
+			return false
+		}
+		return true
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("jsontest: golden file %s does not exist; run the test with -jsontest.update to create it", path)
+		return false
+	}
+	if err != nil {
+		t.Errorf("jsontest: %v", err)
+		return false
+	}
+	diff, err := Compare(want, got, opts)
+	if err != nil {
+		t.Errorf("jsontest: golden file %s: %v", path, err)
+		return false
+	}
+	if diff != "" {
+		t.Errorf("golden file %s: %s", path, diff)
+		return false
+	}
+	return true
+}
+
+// writeGolden writes doc to path, one member or element per line, with
+// its member order kept.
+func writeGolden(path string, doc []byte) error {
+	formatted, err := json.FormatSource(doc, json.FormatOptions{})
+	if err != nil {
+		return fmt.Errorf("got is not valid JSON: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, formatted, 0o644)
+}
+
+// Compare compares the JSON documents want and got as opts says and
+// describes how got differs from want, or returns "" if it does not. It
+// returns an error if either is not valid JSON.
+func Compare(want, got []byte, opts Options) (string, error) {
+	patterns := make([][]string, len(opts.IgnorePaths))
+	for i, ptr := range opts.IgnorePaths {
+		tokens, err := splitPointer(ptr)
+		if err != nil {
+			return "", fmt.Errorf("IgnorePaths: %w", err)
+		}
+		patterns[i] = tokens
+	}
+	wantTree, err := parse(want, patterns)
+	if err != nil {
+		return "", fmt.Errorf("want is not valid JSON: %w", err)
+	}
+	gotTree, err := parse(got, patterns)
+	if err != nil {
+		return "", fmt.Errorf("got is not valid JSON: %w", err)
+	}
+
+	var lines []string
+	patch := json.DiffWithOptions(wantTree.value, gotTree.value, json.CompareOptions{FloatTolerance: opts.FloatTolerance})
+	for _, op := range patch {
+		switch op.Op {
+		case "add":
+			lines = append(lines, fmt.Sprintf("add %s: %s", displayPointer(op.Path), render(op.Value)))
+		case "remove":
+			lines = append(lines, fmt.Sprintf("remove %s: %s", displayPointer(op.Path), render(lookup(wantTree.value, op.Path))))
+		default:
+			lines = append(lines, fmt.Sprintf("replace %s: %s -> %s", displayPointer(op.Path), render(lookup(wantTree.value, op.Path)), render(op.Value)))
+		}
+	}
+	if len(patch) == 0 && !opts.IgnoreKeyOrder {
+		lines = orderDiffs(wantTree.order, gotTree.order)
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return "JSON differs (-want +got):\n    " + strings.Join(lines, "\n    "), nil
+}
+
+// orderDiffs describes the objects whose members got has in another order
+// than want. The two hold the same objects.
+func orderDiffs(want, got map[string][]string) []string {
+	var lines []string
+	for ptr, keys := range want {
+		if other := got[ptr]; strings.Join(keys, "\x00") != strings.Join(other, "\x00") {
+			lines = append(lines, fmt.Sprintf("order %s: %s -> %s", displayPointer(ptr), render(keys), render(other)))
+		}
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// tree is a parsed document with the ignored values left out.
+type tree struct {
+	value interface{}
+	order map[string][]string // JSON Pointer of each object -> its member names
+}
+
+// parse decodes doc, leaving out the values matched by patterns.
+func parse(doc []byte, patterns [][]string) (*tree, error) {
+	var value interface{}
+	if err := json.UnmarshalWithOptions(doc, &value, json.UnmarshalOptions{NumberPolicy: json.NumberPolicyNumber}); err != nil {
+		return nil, err
+	}
+	order, err := memberOrder(doc, patterns)
+	if err != nil {
+		return nil, err
+	}
+	return &tree{value: prune(value, nil, patterns), order: order}, nil
+}
+
+// prune removes the values matched by patterns from v, found at path. The
+// root itself is never removed.
+func prune(v interface{}, path []string, patterns [][]string) interface{} {
+	if len(patterns) == 0 {
+		return v
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		kept := map[string]interface{}{}
+		for k, child := range v {
+			childPath := append(path[:len(path):len(path)], k)
+			if !matchesAny(childPath, patterns) {
+				kept[k] = prune(child, childPath, patterns)
+			}
+		}
+		return kept
+	case []interface{}:
+		kept := make([]interface{}, 0, len(v))
+		for i, child := range v {
+			childPath := append(path[:len(path):len(path)], strconv.Itoa(i))
+			if !matchesAny(childPath, patterns) {
+				kept = append(kept, prune(child, childPath, patterns))
+			}
+		}
+		return kept
+	}
+	return v
+}
+
+// memberOrder returns the member names of each object in doc, in the
+// order they are written, by the JSON Pointer to the object. Values
+// matched by patterns are left out. Unmarshal keeps no member order, so it
+// is read from the events of the document.
+func memberOrder(doc []byte, patterns [][]string) (map[string][]string, error) {
+	type frame struct {
+		path  []string
+		array bool
+		next  int    // index of the next element of an array
+		key   string // name of the current member of an object
+	}
+	order := map[string][]string{}
+	var stack []*frame
+
+	// valuePath returns the path of the value that starts now
+	valuePath := func() []string {
+		if len(stack) == 0 {
+			return nil
+		}
+		f := stack[len(stack)-1]
+		tok := f.key
+		if f.array {
+			tok = strconv.Itoa(f.next)
+			f.next++
+		}
+		return append(f.path[:len(f.path):len(f.path)], tok)
+	}
+	open := func(array bool) error {
+		path := valuePath()
+		if len(path) > 0 && matchesAny(path, patterns) {
+			return json.SkipSubtree
+		}
+		if !array {
+			order[pointer(path)] = []string{}
+		}
+		stack = append(stack, &frame{path: path, array: array})
+		return nil
+	}
+	closeFrame := func() error {
+		stack = stack[:len(stack)-1]
+		return nil
+	}
+	scalar := func() {
+		if n := len(stack); n > 0 && stack[n-1].array {
+			stack[n-1].next++
+		}
+	}
+
+	err := json.ParseEvents(bytes.NewReader(doc), json.EventHandler{
+		OnObjectStart: func() error { return open(false) },
+		OnArrayStart:  func() error { return open(true) },
+		OnObjectEnd:   closeFrame,
+		OnArrayEnd:    closeFrame,
+		OnKey: func(key string) error {
+			f := stack[len(stack)-1]
+			if matchesAny(append(f.path[:len(f.path):len(f.path)], key), patterns) {
+				return json.SkipSubtree
+			}
+			f.key = key
+			ptr := pointer(f.path)
+			order[ptr] = append(order[ptr], key)
+			return nil
+		},
+		OnString: func(string) error { scalar(); return nil },
+		OnNumber: func(string) error { scalar(); return nil },
+		OnBool:   func(bool) error { scalar(); return nil },
+		OnNull:   func() error { scalar(); return nil },
+	})
+	return order, err
+}
+
+// matchesAny reports whether path is matched by one of patterns.
+func matchesAny(path []string, patterns [][]string) bool {
+	for _, p := range patterns {
+		if len(p) != len(path) {
+			continue
+		}
+		match := true
+		for i := range p {
+			if p[i] != "*" && p[i] != path[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPointer splits a JSON Pointer into unescaped reference tokens.
+func splitPointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON Pointer %q", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, tok := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// pointer joins path into a JSON Pointer.
+func pointer(path []string) string {
+	var b strings.Builder
+	for _, tok := range path {
+		b.WriteByte('/')
+		b.WriteString(strings.ReplaceAll(strings.ReplaceAll(tok, "~", "~0"), "/", "~1"))
+	}
+	return b.String()
+}
+
+// lookup returns the value at ptr in v, or nil if there is none.
+func lookup(v interface{}, ptr string) interface{} {
+	path, _ := splitPointer(ptr)
+	for _, tok := range path {
+		switch c := v.(type) {
+		case map[string]interface{}:
+			v = c[tok]
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil
+			}
+			v = c[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
+
+// render returns v as compact JSON.
+func render(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// displayPointer returns ptr, or "/" for the root, whose pointer is empty.
+func displayPointer(ptr string) string {
+	if ptr == "" {
+		return "/"
+	}
+	return ptr
+}
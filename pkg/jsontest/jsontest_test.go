@@ -0,0 +1,159 @@
+package jsontest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recorder is a testing.TB that records the errors reported to it.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name      string
+		want, got string
+		opts      Options
+		diff      string
+	}{
+		{
+			name: "formatting and numbers",
+			want: `{"a": 1, "b": [1.0, "xA"]}`,
+			got:  "{\n  \"a\": 1e0,\n  \"b\": [1, \"xA\"]\n}",
+		},
+		{
+			name: "changes",
+			want: `{"name":"a","items":[{"id":7},{"id":8}],"n":null}`,
+			got:  `{"name":"b","items":[{"id":7}],"n":null,"tags":["new"]}`,
+			diff: "JSON differs (-want +got):\n" +
+				"    remove /items/1: {\"id\":8}\n" +
+				"    replace /name: \"a\" -> \"b\"\n" +
+				"    add /tags: [\"new\"]",
+		},
+		{
+			name: "root",
+			want: `1`,
+			got:  `[1]`,
+			diff: "JSON differs (-want +got):\n    replace /: 1 -> [1]",
+		},
+		{
+			name: "key order",
+			want: `{"a":1,"b":{"x":1,"y":2}}`,
+			got:  `{"a":1,"b":{"y":2,"x":1}}`,
+			diff: "JSON differs (-want +got):\n    order /b: [\"x\",\"y\"] -> [\"y\",\"x\"]",
+		},
+		{
+			name: "ignore key order",
+			want: `{"a":1,"b":{"x":1,"y":2}}`,
+			got:  `{"b":{"y":2,"x":1},"a":1}`,
+			opts: Options{IgnoreKeyOrder: true},
+		},
+		{
+			name: "ignore paths",
+			want: `{"at":"t1","items":[{"id":1,"v":"a"},{"v":"b","id":2}],"list":[0,5]}`,
+			got:  `{"items":[{"id":3,"v":"a"},{"v":"b"}],"at":"t2","list":[9,5]}`,
+			opts: Options{IgnorePaths: []string{"/at", "/items/*/id", "/list/0"}},
+		},
+		{
+			name: "float tolerance",
+			want: `{"x":0.3}`,
+			got:  `{"x":0.30000000000000004}`,
+			opts: Options{FloatTolerance: 1e-9},
+		},
+		{
+			name: "no float tolerance",
+			want: `{"x":0.3}`,
+			got:  `{"x":0.30000000000000004}`,
+			diff: "JSON differs (-want +got):\n    replace /x: 0.3 -> 0.30000000000000004",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := Compare([]byte(tt.want), []byte(tt.got), tt.opts)
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if diff != tt.diff {
+				t.Errorf("Compare() =\n%s\nwant\n%s", diff, tt.diff)
+			}
+		})
+	}
+}
+
+func TestCompareErrors(t *testing.T) {
+	if _, err := Compare([]byte(`{`), []byte(`{}`), Options{}); err == nil || !strings.HasPrefix(err.Error(), "want is not valid JSON") {
+		t.Errorf("Compare(invalid want) error = %v", err)
+	}
+	if _, err := Compare([]byte(`{}`), []byte(`[1,]`), Options{}); err == nil || !strings.HasPrefix(err.Error(), "got is not valid JSON") {
+		t.Errorf("Compare(invalid got) error = %v", err)
+	}
+	if _, err := Compare([]byte(`{}`), []byte(`{}`), Options{IgnorePaths: []string{"a"}}); err == nil {
+		t.Error("Compare(invalid pointer) returned no error")
+	}
+}
+
+func TestAssertEqualJSON(t *testing.T) {
+	r := &recorder{TB: t}
+	if !AssertEqualJSON(r, []byte(`{"a":[1,2]}`), []byte(`{ "a" : [ 1, 2 ] }`)) || len(r.errors) != 0 {
+		t.Errorf("AssertEqualJSON(equal) reported %q", r.errors)
+	}
+	if AssertEqualJSON(r, []byte(`{"a":1}`), []byte(`{"a":2}`)) || len(r.errors) != 1 {
+		t.Fatalf("AssertEqualJSON(different) reported %q", r.errors)
+	}
+	if want := "JSON differs (-want +got):\n    replace /a: 1 -> 2"; r.errors[0] != want {
+		t.Errorf("error = %q, want %q", r.errors[0], want)
+	}
+	if AssertEqualJSONWithOptions(r, []byte(`{"a":1}`), []byte(`nope`), Options{}) || !strings.HasPrefix(r.errors[1], "jsontest: got is not valid JSON") {
+		t.Errorf("AssertEqualJSONWithOptions(invalid) reported %q", r.errors)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	got := []byte(`{"id":7,"name":"Ada","createdAt":"2026-10-16T00:00:00Z","scores":[1.5,2.25],"items":[{"id":"x","sku":"pen"},{"id":"y","sku":"ink"}]}`)
+	opts := Options{IgnorePaths: []string{"/createdAt", "/items/*/id"}}
+
+	r := &recorder{TB: t}
+	if !AssertGoldenWithOptions(r, "testdata/user.json", got, opts) {
+		t.Errorf("AssertGoldenWithOptions() reported %q", r.errors)
+	}
+	if AssertGolden(r, "testdata/user.json", got) || !strings.HasPrefix(r.errors[0], "golden file testdata/user.json: JSON differs") {
+		t.Errorf("AssertGolden() reported %q", r.errors)
+	}
+	if AssertGolden(r, "testdata/missing.json", got) || !strings.Contains(r.errors[1], "-jsontest.update") {
+		t.Errorf("AssertGolden(missing) reported %q", r.errors)
+	}
+}
+
+func TestAssertGolden_Update(t *testing.T) {
+	*update = true
+	defer func() { *update = false }()
+
+	path := filepath.Join(t.TempDir(), "out", "doc.json")
+	r := &recorder{TB: t}
+	if !AssertGolden(r, path, []byte(`{"b":[1,2],"a":{}}`)) {
+		t.Fatalf("AssertGolden() reported %q", r.errors)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"b\": [\n    1,\n    2\n  ],\n  \"a\": {}\n}\n"
+	if string(data) != want {
+		t.Errorf("golden file =\n%s\nwant\n%s", data, want)
+	}
+
+	if AssertGolden(r, path, []byte(`{`)) || len(r.errors) != 1 {
+		t.Errorf("AssertGolden(invalid) reported %q", r.errors)
+	}
+}
@@ -0,0 +1,228 @@
+// Package jsonvet is a static analyzer that reports struct types that
+// shape-json cannot encode or decode as written, the compile-time
+// counterpart of json.VetType.
+//
+// It checks every struct type declared in a package that has at least one
+// field with a json tag, and reports:
+//
+//   - channel, func, and complex fields, which cannot be encoded or decoded
+//   - maps whose keys are not strings
+//   - fields that share a JSON name, which Marshal writes as duplicate
+//     members
+//   - embedded structs, which shape-json encodes as a member named after
+//     the type rather than flattening them as encoding/json does, and
+//     unexported embedded structs, which it skips
+//   - misspelled, misplaced, or malformed tag options, such as "omitempy"
+//
+// Run it on its own with cmd/jsonvet, or as part of go vet:
+//
+//	go install github.com/shapestone/shape-json/cmd/jsonvet@latest
+//	go vet -vettool=$(which jsonvet) ./...
+package jsonvet
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+
+	"github.com/shapestone/shape-json/internal/fastparser"
+	"github.com/shapestone/shape-json/internal/structtag"
+)
+
+// Analyzer reports struct types that shape-json cannot encode or decode as
+// written.
+var Analyzer = &analysis.Analyzer{
+	Name: "jsonvet",
+	Doc:  "report struct types that shape-json cannot encode or decode as written",
+	URL:  "https://pkg.go.dev/github.com/shapestone/shape-json/pkg/jsonvet",
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if st, ok := n.(*ast.StructType); ok && hasJSONTag(st) {
+				vetStruct(pass, st)
+			}
+			return true
+		})
+	}
+	return nil, nil
+}
+
+// hasJSONTag reports whether a field of st has a json tag, which marks
+// the struct as one meant for JSON.
+func hasJSONTag(st *ast.StructType) bool {
+	for _, field := range st.Fields.List {
+		if _, ok := jsonTag(field); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTag returns the json tag of field, and whether it has one.
+func jsonTag(field *ast.Field) (string, bool) {
+	if field.Tag == nil {
+		return "", false
+	}
+	lit, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return "", false
+	}
+	return reflect.StructTag(lit).Lookup("json")
+}
+
+// vetStruct reports the problems with the fields of st.
+func vetStruct(pass *analysis.Pass, st *ast.StructType) {
+	names := map[string]string{} // JSON name -> Go field name
+	for _, field := range st.Fields.List {
+		t := pass.TypesInfo.TypeOf(field.Type)
+		if t == nil {
+			continue
+		}
+		tag, tagged := jsonTag(field)
+
+		idents := field.Names
+		if len(idents) == 0 { // embedded
+			name := embeddedName(field.Type)
+			if name == nil {
+				continue
+			}
+			idents = []*ast.Ident{name}
+			if _, isStruct := deref(t).Underlying().(*types.Struct); isStruct && tag != "-" {
+				switch {
+				case !name.IsExported():
+					pass.Reportf(field.Pos(), "embedded %s is not encoded, since it is unexported; its fields are not promoted as in encoding/json", name.Name)
+					continue
+				case tag == "" || tag[0] == ',':
+					pass.Reportf(field.Pos(), "embedded %s is encoded as member %q, not flattened as in encoding/json", name.Name, name.Name)
+				}
+			}
+		}
+
+		for _, ident := range idents {
+			if !ident.IsExported() {
+				continue
+			}
+			name, ok := structtag.Name(ident.Name, tag)
+			if !ok {
+				continue
+			}
+			unknown := tagged && isStringMap(t) && hasOption(tag, "unknown")
+			if tagged {
+				for _, msg := range structtag.Vet(tag, types.TypeString(t, types.RelativeTo(pass.Pkg)), isStringMap(t)) {
+					pass.Reportf(field.Tag.Pos(), "%s: %s", ident.Name, msg)
+				}
+			}
+			if !unknown {
+				if other, dup := names[name]; dup {
+					pass.Reportf(ident.Pos(), "%s: JSON name %q is also that of field %s", ident.Name, name, other)
+				}
+				names[name] = ident.Name
+			}
+			if msg := unsupported(t, pass.Pkg); msg != "" {
+				pass.Reportf(ident.Pos(), "%s: %s", ident.Name, msg)
+			}
+		}
+	}
+}
+
+// unsupported describes the part of t that cannot be encoded or decoded,
+// looking through pointers, slices, arrays, and maps, or returns "". Named
+// types with their own MarshalJSON or UnmarshalJSON are not looked into.
+func unsupported(t types.Type, pkg *types.Package) string {
+	for {
+		if hasCodec(t) {
+			return ""
+		}
+		switch u := t.Underlying().(type) {
+		case *types.Chan, *types.Signature:
+			return "unsupported type " + types.TypeString(t, types.RelativeTo(pkg))
+		case *types.Basic:
+			if u.Info()&types.IsComplex != 0 || u.Kind() == types.UnsafePointer {
+				return "unsupported type " + types.TypeString(t, types.RelativeTo(pkg))
+			}
+			return ""
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Array:
+			t = u.Elem()
+		case *types.Map:
+			if k, ok := u.Key().Underlying().(*types.Basic); !ok || k.Info()&types.IsString == 0 {
+				return "unsupported map key type " + types.TypeString(u.Key(), types.RelativeTo(pkg))
+			}
+			t = u.Elem()
+		default:
+			return ""
+		}
+	}
+}
+
+// hasCodec reports whether t or *t has a MarshalJSON or UnmarshalJSON
+// method.
+func hasCodec(t types.Type) bool {
+	if _, ok := t.(*types.Named); !ok {
+		return false
+	}
+	ms := types.NewMethodSet(types.NewPointer(t))
+	return ms.Lookup(nil, "MarshalJSON") != nil || ms.Lookup(nil, "UnmarshalJSON") != nil
+}
+
+// isStringMap reports whether t is a map with string keys.
+func isStringMap(t types.Type) bool {
+	m, ok := t.Underlying().(*types.Map)
+	if !ok {
+		return false
+	}
+	k, ok := m.Key().Underlying().(*types.Basic)
+	return ok && k.Info()&types.IsString != 0
+}
+
+// hasOption reports whether a json tag lists option.
+func hasOption(tag, option string) bool {
+	_, opts, _ := strings.Cut(tag, ",")
+	for opts != "" {
+		var opt string
+		opt, opts = fastparser.NextTagOption(opts)
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// deref returns the element type of t if it is a pointer, or t.
+func deref(t types.Type) types.Type {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		return p.Elem()
+	}
+	return t
+}
+
+// embeddedName returns the identifier that names an embedded field of
+// type expr, such as T, *T, pkg.T, or T[int].
+func embeddedName(expr ast.Expr) *ast.Ident {
+	for {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			return e
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.SelectorExpr:
+			return e.Sel
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		default:
+			return nil
+		}
+	}
+}
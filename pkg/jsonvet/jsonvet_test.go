@@ -0,0 +1,13 @@
+package jsonvet_test
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+
+	"github.com/shapestone/shape-json/pkg/jsonvet"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), jsonvet.Analyzer, "a")
+}
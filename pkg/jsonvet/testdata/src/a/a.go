@@ -0,0 +1,48 @@
+package a
+
+import "unsafe"
+
+type Event struct {
+	Name string `json:"name"`
+}
+
+type Base struct {
+	ID string `json:"id"`
+}
+
+type inner struct {
+	Secret string `json:"secret"`
+}
+
+type Stamp struct{}
+
+func (Stamp) MarshalJSON() ([]byte, error) { return nil, nil }
+
+type Order struct {
+	ID      string            `json:"id,omitempy"` // want `ID: unknown option "omitempy" \(did you mean "omitempty"\?\)`
+	Ref     string            `json:"id"`          // want `Ref: JSON name "id" is also that of field ID`
+	Notify  chan Event        `json:"notify"`      // want `Notify: unsupported type chan Event`
+	OnDone  func()            `json:"-"`
+	Hooks   []func()          `json:"hooks"`  // want `Hooks: unsupported type func\(\)`
+	ByCode  map[int]string    `json:"codes"`  // want `ByCode: unsupported map key type int`
+	Phase   complex128        `json:"phase"`  // want `Phase: unsupported type complex128`
+	Raw     unsafe.Pointer    `json:"raw"`    // want `Raw: unsupported type unsafe.Pointer`
+	Stamped map[Stamp]Stamp   `json:"stamps"` // want `Stamped: unsupported map key type Stamp`
+	Extra   map[string]string `json:",unknown"`
+	Events  []Event           `json:"events"`
+	Key     string            `json:"key,transform"` // want `Key: option "transform" needs a value, as in transform:<name>`
+	Base                      // want `embedded Base is encoded as member "Base", not flattened as in encoding/json`
+	inner                     // want `embedded inner is not encoded, since it is unexported; its fields are not promoted as in encoding/json`
+	private chan int
+}
+
+type Named struct {
+	Base `json:"base"`
+	When Stamp `json:"when"`
+}
+
+// Untagged structs are not meant for JSON and are not checked.
+type Worker struct {
+	Jobs chan Event
+	Run  func()
+}
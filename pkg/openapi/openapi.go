@@ -0,0 +1,535 @@
+// Package openapi parses OpenAPI 3.1 documents into typed structures and
+// exposes the request and response schemas of each operation as compiled
+// JSON Schemas for validation.
+//
+// Documents are read with the json package and must be JSON. Every $ref is
+// resolved while parsing:
+//
+//   - References to Path Items, Parameters, Request Bodies, and Responses
+//     are replaced by their targets, so the typed structures never contain
+//     a $ref.
+//   - References inside schemas are kept and compiled by the jsonschema
+//     package against the whole document, so "#/components/schemas/Pet"
+//     works from any schema and recursive schemas are supported.
+//   - References into other documents ("common.json#/components/schemas/Error")
+//     are loaded through Options.Fetch and copied into the document under the
+//     "x-bundled" extension member, where local references can reach them.
+//
+// Example:
+//
+//	doc, err := openapi.Load("api.json")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	op := doc.Operation("POST", "/pets")
+//	if err := op.RequestSchema("application/json").ValidateBytes(body); err != nil {
+//	    // the request body does not match the API description
+//	}
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-json/pkg/json"
+	"github.com/shapestone/shape-json/pkg/jsonschema"
+)
+
+// Document is a parsed OpenAPI document.
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths,omitempty"`
+	Webhooks   map[string]*PathItem `json:"webhooks,omitempty"`
+	Components Components           `json:"components"`
+
+	raw map[string]interface{}
+}
+
+// Info holds the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Summary     string `json:"summary,omitempty"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is a base URL at which the API is served.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the reusable objects of the document. Parameters,
+// request bodies, and responses that reference these have already been
+// resolved; Schemas is mainly useful for validating values directly.
+type Components struct {
+	Schemas       map[string]*Schema      `json:"schemas,omitempty"`
+	Parameters    map[string]*Parameter   `json:"parameters,omitempty"`
+	RequestBodies map[string]*RequestBody `json:"requestBodies,omitempty"`
+	Responses     map[string]*Response    `json:"responses,omitempty"`
+}
+
+// PathItem describes the operations available on a path.
+type PathItem struct {
+	Summary     string       `json:"summary,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Parameters  []*Parameter `json:"parameters,omitempty"`
+	Get         *Operation   `json:"get,omitempty"`
+	Put         *Operation   `json:"put,omitempty"`
+	Post        *Operation   `json:"post,omitempty"`
+	Delete      *Operation   `json:"delete,omitempty"`
+	Options     *Operation   `json:"options,omitempty"`
+	Head        *Operation   `json:"head,omitempty"`
+	Patch       *Operation   `json:"patch,omitempty"`
+	Trace       *Operation   `json:"trace,omitempty"`
+}
+
+// methods lists the HTTP methods of a PathItem in the order of the
+// specification.
+var methods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+// operation returns the operation for an upper-case HTTP method.
+func (p *PathItem) operation(method string) *Operation {
+	switch method {
+	case "GET":
+		return p.Get
+	case "PUT":
+		return p.Put
+	case "POST":
+		return p.Post
+	case "DELETE":
+		return p.Delete
+	case "OPTIONS":
+		return p.Options
+	case "HEAD":
+		return p.Head
+	case "PATCH":
+		return p.Patch
+	case "TRACE":
+		return p.Trace
+	}
+	return nil
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string       `json:"operationId,omitempty"`
+	Summary     string       `json:"summary,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Tags        []string     `json:"tags,omitempty"`
+	Deprecated  bool         `json:"deprecated,omitempty"`
+	RequestBody *RequestBody `json:"requestBody,omitempty"`
+
+	// Parameters includes the parameters declared on the Path Item, unless
+	// the operation overrides them with the same name and location.
+	Parameters []*Parameter `json:"parameters,omitempty"`
+
+	// Responses maps status codes ("200", "4XX") and "default" to responses.
+	Responses map[string]*Response `json:"responses,omitempty"`
+
+	// Method is the upper-case HTTP method, and Path the path template or,
+	// for webhooks, the webhook name.
+	Method string `json:"-"`
+	Path   string `json:"-"`
+}
+
+// Parameter describes a single operation parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Deprecated  bool    `json:"deprecated,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Response describes a single response of an operation.
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType describes the body for one content type.
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Schema is a JSON Schema embedded in the document.
+type Schema struct {
+	// Raw is the schema as written, with its $refs intact.
+	Raw interface{}
+
+	// Pointer is the JSON Pointer of the schema within the document, such
+	// as "/components/schemas/Pet". Schemas reached through a resolved
+	// reference report the location of the target.
+	Pointer string
+
+	compiled *jsonschema.Schema
+}
+
+// UnmarshalJSON decodes the schema into Raw. A Schema decoded on its own
+// rather than by Parse is not compiled.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	return json.Unmarshal(data, &s.Raw)
+}
+
+// MarshalJSON encodes Raw.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Raw)
+}
+
+// Compiled returns the compiled schema, or nil if s was not produced by
+// Parse.
+func (s *Schema) Compiled() *jsonschema.Schema {
+	if s == nil {
+		return nil
+	}
+	return s.compiled
+}
+
+// Validate checks a native JSON value against the schema. See
+// jsonschema.Schema.Validate.
+func (s *Schema) Validate(v interface{}) error {
+	if s.Compiled() == nil {
+		return errNotCompiled
+	}
+	return s.compiled.Validate(v)
+}
+
+// ValidateBytes parses a JSON document and validates it against the schema.
+// See jsonschema.Schema.ValidateBytes.
+func (s *Schema) ValidateBytes(data []byte) error {
+	if s.Compiled() == nil {
+		return errNotCompiled
+	}
+	return s.compiled.ValidateBytes(data)
+}
+
+var errNotCompiled = errors.New("openapi: schema was not compiled by Parse")
+
+// Options configures Parse.
+type Options struct {
+	// BaseURI is the location of the document, against which relative
+	// references to other documents are resolved. It may be a file path or
+	// a URL. Load sets it to the file path.
+	BaseURI string
+
+	// Fetch returns the content of the document at uri, which is a file path
+	// or an absolute URL. The default reads files and file: URLs and rejects
+	// other schemes, so that parsing never touches the network unless Fetch
+	// is set.
+	Fetch func(uri string) ([]byte, error)
+}
+
+// Load reads and parses the OpenAPI document in the named file. References
+// to other files are resolved relative to it.
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, Options{BaseURI: path})
+}
+
+// Parse parses an OpenAPI 3.x document, resolves its references, and
+// compiles its schemas. Errors are returned for malformed JSON, a missing
+// or unsupported "openapi" version, unresolvable or circular references,
+// and invalid schemas.
+func Parse(data []byte, opts Options) (*Document, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	root, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("openapi: document must be a JSON object")
+	}
+	if version, _ := root["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("openapi: unsupported version %q, want 3.x", version)
+	}
+
+	r := newResolver(root, opts)
+	if err := r.bundle(); err != nil {
+		return nil, err
+	}
+	view, err := r.view(root, "", false, nil)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(view)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+
+	doc := &Document{raw: root}
+	if err := json.Unmarshal(b, doc); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	if err := doc.link(); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// link fills in the Method and Path of each operation, merges Path Item
+// parameters into operations, and compiles every schema.
+func (d *Document) link() error {
+	compiled := make(map[string]*jsonschema.Schema)
+	compile := func(s *Schema) error {
+		if s == nil || s.compiled != nil {
+			return nil // path-level parameters are shared with operations
+		}
+		// Parse decodes each schema as a {"$ref": "#<pointer>"} stub
+		ref, _ := s.Raw.(map[string]interface{})["$ref"].(string)
+		s.Pointer = ref[1:]
+		raw, err := pointer(d.raw, s.Pointer)
+		if err != nil {
+			return fmt.Errorf("openapi: %v", err)
+		}
+		s.Raw = raw
+		if s.compiled = compiled[s.Pointer]; s.compiled != nil {
+			return nil
+		}
+		if s.compiled, err = jsonschema.CompileAt(d.raw, s.Pointer); err != nil {
+			return fmt.Errorf("openapi: %w", err)
+		}
+		compiled[s.Pointer] = s.compiled
+		return nil
+	}
+	content := func(m map[string]*MediaType) error {
+		for _, mt := range m {
+			if mt == nil {
+				continue
+			}
+			if err := compile(mt.Schema); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	parameters := func(params []*Parameter) error {
+		for _, p := range params {
+			if p == nil {
+				continue
+			}
+			if err := compile(p.Schema); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, s := range d.Components.Schemas {
+		if err := compile(s); err != nil {
+			return err
+		}
+	}
+	for _, p := range d.Components.Parameters {
+		if err := parameters([]*Parameter{p}); err != nil {
+			return err
+		}
+	}
+	for _, rb := range d.Components.RequestBodies {
+		if rb == nil {
+			continue
+		}
+		if err := content(rb.Content); err != nil {
+			return err
+		}
+	}
+	for _, resp := range d.Components.Responses {
+		if resp == nil {
+			continue
+		}
+		if err := content(resp.Content); err != nil {
+			return err
+		}
+	}
+
+	for _, items := range []map[string]*PathItem{d.Paths, d.Webhooks} {
+		for path, item := range items {
+			if item == nil {
+				continue
+			}
+			if err := parameters(item.Parameters); err != nil {
+				return err
+			}
+			for _, method := range methods {
+				op := item.operation(method)
+				if op == nil {
+					continue
+				}
+				op.Method, op.Path = method, path
+				op.Parameters = mergeParameters(item.Parameters, op.Parameters)
+				if err := parameters(op.Parameters); err != nil {
+					return err
+				}
+				if op.RequestBody != nil {
+					if err := content(op.RequestBody.Content); err != nil {
+						return err
+					}
+				}
+				for _, resp := range op.Responses {
+					if resp == nil {
+						continue
+					}
+					if err := content(resp.Content); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mergeParameters appends the path-level parameters that the operation does
+// not override to the operation's own.
+func mergeParameters(path, op []*Parameter) []*Parameter {
+	merged := op
+outer:
+	for _, p := range path {
+		if p == nil {
+			continue
+		}
+		for _, o := range op {
+			if o != nil && o.Name == p.Name && o.In == p.In {
+				continue outer
+			}
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// Operations returns every operation under Paths, ordered by path and then
+// by method in the order of the specification.
+func (d *Document) Operations() []*Operation {
+	paths := make([]string, 0, len(d.Paths))
+	for p := range d.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	var ops []*Operation
+	for _, p := range paths {
+		item := d.Paths[p]
+		if item == nil {
+			continue
+		}
+		for _, method := range methods {
+			if op := item.operation(method); op != nil {
+				ops = append(ops, op)
+			}
+		}
+	}
+	return ops
+}
+
+// Operation returns the operation for an HTTP method (in any case) and a
+// path template exactly as written in the document, such as "/pets/{id}",
+// or nil if there is none.
+func (d *Document) Operation(method, path string) *Operation {
+	item := d.Paths[path]
+	if item == nil {
+		return nil
+	}
+	return item.operation(strings.ToUpper(method))
+}
+
+// OperationByID returns the operation with the given operationId, or nil.
+func (d *Document) OperationByID(id string) *Operation {
+	for _, op := range d.Operations() {
+		if op.OperationID == id {
+			return op
+		}
+	}
+	return nil
+}
+
+// RequestSchema returns the schema of the request body for a content type,
+// or nil if the operation declares none. See MediaType for how contentType
+// is matched.
+func (o *Operation) RequestSchema(contentType string) *Schema {
+	if o.RequestBody == nil {
+		return nil
+	}
+	if mt := mediaType(o.RequestBody.Content, contentType); mt != nil {
+		return mt.Schema
+	}
+	return nil
+}
+
+// Response returns the response declared for an HTTP status code: the
+// exact code, then its range ("4XX"), then "default". It returns nil if
+// none applies.
+func (o *Operation) Response(status int) *Response {
+	code := strconv.Itoa(status)
+	if r := o.Responses[code]; r != nil {
+		return r
+	}
+	if len(code) == 3 {
+		for k, r := range o.Responses {
+			if strings.EqualFold(k, code[:1]+"XX") {
+				return r
+			}
+		}
+	}
+	return o.Responses["default"]
+}
+
+// ResponseSchema returns the schema of the response body for a status code
+// and content type, or nil if the operation declares none.
+func (o *Operation) ResponseSchema(status int, contentType string) *Schema {
+	r := o.Response(status)
+	if r == nil {
+		return nil
+	}
+	if mt := mediaType(r.Content, contentType); mt != nil {
+		return mt.Schema
+	}
+	return nil
+}
+
+// mediaType returns the entry of content for contentType, ignoring
+// parameters such as charset and case. Ranges ("application/*", "*/*")
+// match when no exact entry exists. An empty contentType means
+// "application/json".
+func mediaType(content map[string]*MediaType, contentType string) *MediaType {
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+
+	candidates := []string{contentType, "*/*"}
+	if i := strings.IndexByte(contentType, '/'); i >= 0 {
+		candidates = []string{contentType, contentType[:i] + "/*", "*/*"}
+	}
+	for _, want := range candidates {
+		for k, mt := range content {
+			if i := strings.IndexByte(k, ';'); i >= 0 {
+				k = k[:i]
+			}
+			if strings.EqualFold(strings.TrimSpace(k), want) {
+				return mt
+			}
+		}
+	}
+	return nil
+}
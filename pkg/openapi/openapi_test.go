@@ -0,0 +1,182 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func loadPetstore(t *testing.T) *Document {
+	t.Helper()
+	doc, err := Load("testdata/petstore.json")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return doc
+}
+
+func TestLoad(t *testing.T) {
+	doc := loadPetstore(t)
+
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "Petstore" || len(doc.Servers) != 1 {
+		t.Errorf("document header = %q %+v %+v", doc.OpenAPI, doc.Info, doc.Servers)
+	}
+
+	var ids []string
+	for _, op := range doc.Operations() {
+		ids = append(ids, op.Method+" "+op.Path+" "+op.OperationID)
+	}
+	want := "GET /pets listPets, POST /pets createPet, GET /pets/{id} getPet"
+	if got := strings.Join(ids, ", "); got != want {
+		t.Errorf("Operations() = %s, want %s", got, want)
+	}
+
+	if doc.Operation("post", "/pets") != doc.OperationByID("createPet") || doc.Operation("GET", "/dogs") != nil {
+		t.Error("Operation() and OperationByID() disagree")
+	}
+
+	// Referenced request bodies and path-level parameters are resolved
+	create := doc.OperationByID("createPet")
+	if create.RequestBody == nil || !create.RequestBody.Required {
+		t.Fatalf("createPet request body = %+v", create.RequestBody)
+	}
+	get := doc.OperationByID("getPet")
+	if len(get.Parameters) != 1 || get.Parameters[0].Name != "id" || !get.Parameters[0].Required {
+		t.Fatalf("getPet parameters = %+v", get.Parameters)
+	}
+	if err := get.Parameters[0].Schema.Validate("x"); err == nil {
+		t.Error("path parameter schema accepted a string")
+	}
+}
+
+func TestOperation_Schemas(t *testing.T) {
+	doc := loadPetstore(t)
+	create := doc.OperationByID("createPet")
+
+	body := create.RequestSchema("application/json; charset=utf-8")
+	if body == nil {
+		t.Fatal("RequestSchema() = nil")
+	}
+	if body.Pointer != "/components/requestBodies/NewPet/content/application~1json/schema" {
+		t.Errorf("Pointer = %q", body.Pointer)
+	}
+	if err := body.ValidateBytes([]byte(`{"id":1,"name":"rex","parent":{"id":2,"name":"max"}}`)); err != nil {
+		t.Errorf("valid pet: %v", err)
+	}
+	err := body.ValidateBytes([]byte(`{"id":1,"parent":{"id":"2","name":"max"}}`))
+	if err == nil || !strings.Contains(err.Error(), `(root): required: missing property "name"`) ||
+		!strings.Contains(err.Error(), "/parent/id: type") {
+		t.Errorf("error = %v", err)
+	}
+	if create.RequestSchema("text/plain") != nil {
+		t.Error("RequestSchema(text/plain) != nil")
+	}
+
+	// Responses fall back from the exact code to its range and to default,
+	// and reach the remote error schema
+	list := doc.OperationByID("listPets")
+	if err := list.ResponseSchema(200, "").ValidateBytes([]byte(`[{"id":1,"name":"a"}]`)); err != nil {
+		t.Errorf("200 response: %v", err)
+	}
+	for _, tt := range []struct {
+		op     *Operation
+		status int
+	}{{list, 500}, {create, 404}} {
+		s := tt.op.ResponseSchema(tt.status, "application/json")
+		if s == nil {
+			t.Fatalf("%s %d: no schema", tt.op.OperationID, tt.status)
+		}
+		if err := s.ValidateBytes([]byte(`{"code":404,"cause":{"code":500}}`)); err != nil {
+			t.Errorf("%s %d: %v", tt.op.OperationID, tt.status, err)
+		}
+		if err := s.ValidateBytes([]byte(`{"code":200}`)); err == nil {
+			t.Errorf("%s %d: accepted code 200", tt.op.OperationID, tt.status)
+		}
+	}
+	if create.ResponseSchema(201, "") != nil || create.ResponseSchema(500, "") != nil {
+		t.Error("ResponseSchema() returned a schema for a response without one")
+	}
+}
+
+func TestMediaType(t *testing.T) {
+	content := map[string]*MediaType{
+		"application/json": {Schema: &Schema{Pointer: "json"}},
+		"image/*":          {Schema: &Schema{Pointer: "image"}},
+		"*/*":              {Schema: &Schema{Pointer: "any"}},
+	}
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"", "json"},
+		{"Application/JSON; charset=utf-8", "json"},
+		{"image/png", "image"},
+		{"text/plain", "any"},
+	}
+	for _, tt := range tests {
+		if got := mediaType(content, tt.contentType); got == nil || got.Schema.Pointer != tt.want {
+			t.Errorf("mediaType(%q) = %+v, want %s", tt.contentType, got, tt.want)
+		}
+	}
+	if mediaType(nil, "application/json") != nil {
+		t.Error("mediaType(nil) != nil")
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "invalid JSON", input: `{"openapi":`, wantErr: "openapi: "},
+		{name: "not an object", input: `[]`, wantErr: "openapi: document must be a JSON object"},
+		{name: "missing version", input: `{}`, wantErr: `openapi: unsupported version "", want 3.x`},
+		{name: "swagger 2", input: `{"swagger":"2.0","openapi":"2.0"}`, wantErr: `unsupported version "2.0"`},
+		{
+			name:    "missing reference",
+			input:   `{"openapi":"3.1.0","paths":{"/a":{"$ref":"#/components/pathItems/A"}}}`,
+			wantErr: `openapi: #/paths/~1a: $ref "#/components/pathItems/A": "components" not found`,
+		},
+		{
+			name:    "circular reference",
+			input:   `{"openapi":"3.1.0","components":{"responses":{"A":{"$ref":"#/components/responses/B"},"B":{"$ref":"#/components/responses/A"}}}}`,
+			wantErr: "circular $ref",
+		},
+		{
+			name:    "invalid schema",
+			input:   `{"openapi":"3.1.0","components":{"schemas":{"A":{"type":"text"}}}}`,
+			wantErr: "openapi: jsonschema: invalid schema at #/components/schemas/A",
+		},
+		{
+			name:    "remote without fetch",
+			input:   `{"openapi":"3.1.0","components":{"schemas":{"A":{"$ref":"https://example.com/s.json"}}}}`,
+			wantErr: "openapi: loading https://example.com/s.json: fetching https URIs requires Options.Fetch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input), Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchema_NotCompiled(t *testing.T) {
+	var s *Schema
+	if err := s.Validate(1); err != errNotCompiled {
+		t.Errorf("nil Schema Validate() = %v", err)
+	}
+	s = &Schema{}
+	if err := s.UnmarshalJSON([]byte(`{"type":"string"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.ValidateBytes([]byte(`1`)); err != errNotCompiled {
+		t.Errorf("ValidateBytes() = %v", err)
+	}
+	if b, err := s.MarshalJSON(); err != nil || string(b) != `{"type":"string"}` {
+		t.Errorf("MarshalJSON() = %s, %v", b, err)
+	}
+}
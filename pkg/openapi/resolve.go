@@ -0,0 +1,335 @@
+package openapi
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/shapestone/shape-json/pkg/json"
+)
+
+// bundleKey is the root member under which Parse stores the targets of
+// references into other documents.
+const bundleKey = "x-bundled"
+
+// resolver resolves the references of a document.
+type resolver struct {
+	root  map[string]interface{}
+	base  string // location of the root document
+	fetch func(string) ([]byte, error)
+
+	docs    map[string]interface{} // fetched documents by location
+	bundled map[string]string      // "location#fragment" -> key under bundleKey
+	store   map[string]interface{} // the bundleKey member
+}
+
+func newResolver(root map[string]interface{}, opts Options) *resolver {
+	r := &resolver{
+		root:    root,
+		base:    opts.BaseURI,
+		fetch:   opts.Fetch,
+		docs:    make(map[string]interface{}),
+		bundled: make(map[string]string),
+		store:   make(map[string]interface{}),
+	}
+	if r.fetch == nil {
+		r.fetch = readFile
+	}
+	if r.base != "" && !hasScheme(r.base) {
+		r.base = path.Clean(r.base)
+	}
+	return r
+}
+
+// readFile is the default Options.Fetch.
+func readFile(uri string) ([]byte, error) {
+	if !hasScheme(uri) {
+		return os.ReadFile(uri)
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "file" {
+		return nil, fmt.Errorf("fetching %s URIs requires Options.Fetch", u.Scheme)
+	}
+	return os.ReadFile(u.Path)
+}
+
+// hasScheme reports whether s is an absolute URL rather than a file path.
+func hasScheme(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && len(u.Scheme) > 1 // "C:" is a drive letter
+}
+
+// bundle copies the targets of references into other documents under
+// bundleKey and rewrites those references to point there, so that every
+// reference left in the document is local.
+func (r *resolver) bundle() error {
+	if err := r.rewrite(r.root, r.base); err != nil {
+		return err
+	}
+	if len(r.store) > 0 {
+		r.root[bundleKey] = r.store
+	}
+	return nil
+}
+
+// rewrite makes the references in v, which belongs to the document at
+// base, local to the root document.
+func (r *resolver) rewrite(v interface{}, base string) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			local, err := r.localize(ref, base)
+			if err != nil {
+				return err
+			}
+			v["$ref"] = local
+		}
+		for k, child := range v {
+			if k == "$ref" || k == "example" || k == "examples" {
+				continue
+			}
+			if err := r.rewrite(child, base); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := r.rewrite(child, base); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// localize returns the local reference for ref, found in the document at
+// base, bundling its target if it lives in another document.
+func (r *resolver) localize(ref, base string) (string, error) {
+	loc, fragment, err := resolveRef(base, ref)
+	if err != nil {
+		return "", fmt.Errorf("openapi: invalid $ref %q: %v", ref, err)
+	}
+	if loc == r.base {
+		return "#" + fragment, nil
+	}
+
+	id := loc + "#" + fragment
+	key, ok := r.bundled[id]
+	if !ok {
+		doc, err := r.load(loc)
+		if err != nil {
+			return "", err
+		}
+		target, err := pointer(doc, fragment)
+		if err != nil {
+			return "", fmt.Errorf("openapi: $ref %q: %v", ref, err)
+		}
+		key = r.newKey(loc, fragment)
+		r.bundled[id] = key
+
+		// Copy the target so that rewriting its references leaves the
+		// fetched document intact for later lookups
+		target = deepCopy(target)
+		r.store[key] = target
+		if err := r.rewrite(target, loc); err != nil {
+			return "", err
+		}
+	}
+	return "#/" + bundleKey + "/" + escape(key), nil
+}
+
+// load fetches and parses the document at loc, once.
+func (r *resolver) load(loc string) (interface{}, error) {
+	if doc, ok := r.docs[loc]; ok {
+		return doc, nil
+	}
+	data, err := r.fetch(loc)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: loading %s: %w", loc, err)
+	}
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("openapi: loading %s: %w", loc, err)
+	}
+	r.docs[loc] = doc
+	return doc, nil
+}
+
+// newKey returns an unused, readable key under bundleKey for a target,
+// such as "common.json_components_schemas_Error".
+func (r *resolver) newKey(loc, fragment string) string {
+	name := strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '.', c == '-', c == '_':
+			return c
+		}
+		return '_'
+	}, path.Base(loc)+fragment)
+	name = strings.TrimRight(name, "_")
+
+	key := name
+	for i := 2; ; i++ {
+		if _, taken := r.store[key]; !taken {
+			return key
+		}
+		key = name + "_" + strconv.Itoa(i)
+	}
+}
+
+// resolveRef resolves ref against the location of the document containing
+// it, returning the location of the target document and the JSON Pointer
+// fragment within it.
+func resolveRef(base, ref string) (string, string, error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", "", err
+	}
+	fragment := u.Fragment
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	switch {
+	case u.String() == "":
+		return base, fragment, nil
+	case u.IsAbs() || hasScheme(base):
+		b, err := url.Parse(base)
+		if err != nil {
+			return "", "", err
+		}
+		return b.ResolveReference(u).String(), fragment, nil
+	}
+
+	// Both are file paths; url.ResolveReference would make them absolute
+	p := u.Path
+	if !path.IsAbs(p) {
+		p = path.Join(path.Dir(base), p)
+	}
+	return p, fragment, nil
+}
+
+// view returns a copy of v, found at ptr in the root document, in the form
+// decoded into the typed structures: references outside schemas are
+// replaced by their targets, and every schema by a {"$ref": "#<pointer>"}
+// stub that Document.link swaps for the compiled schema. stack holds the
+// references being followed, to detect cycles.
+func (r *resolver) view(v interface{}, ptr string, schema bool, stack []string) (interface{}, error) {
+	if schema {
+		return map[string]interface{}{"$ref": "#" + ptr}, nil
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			for _, seen := range stack {
+				if seen == ref {
+					return nil, fmt.Errorf("openapi: #%s: circular $ref %q", ptr, ref)
+				}
+			}
+			target, err := pointer(r.root, ref[1:])
+			if err != nil {
+				return nil, fmt.Errorf("openapi: #%s: $ref %q: %v", ptr, ref, err)
+			}
+			return r.view(target, ref[1:], false, append(stack, ref))
+		}
+
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			childPtr := ptr + "/" + escape(k)
+			var err error
+			switch {
+			case ptr == "" && k == bundleKey:
+				continue
+			case k == "example" || k == "examples":
+				// Example values are data and may hold anything, even "$ref";
+				// rewrite skips them too
+				out[k] = child
+			case k == "schema":
+				out[k], err = r.view(child, childPtr, true, stack)
+			case ptr == "/components" && k == "schemas":
+				schemas, _ := child.(map[string]interface{})
+				stubs := make(map[string]interface{}, len(schemas))
+				for name := range schemas {
+					stubs[name], _ = r.view(nil, childPtr+"/"+escape(name), true, nil)
+				}
+				out[k] = stubs
+			default:
+				out[k], err = r.view(child, childPtr, false, stack)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			var err error
+			if out[i], err = r.view(child, ptr+"/"+strconv.Itoa(i), false, stack); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// pointer resolves a JSON Pointer within doc.
+func pointer(doc interface{}, ptr string) (interface{}, error) {
+	if ptr == "" {
+		return doc, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON Pointer %q", ptr)
+	}
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = strings.ReplaceAll(strings.ReplaceAll(tok, "~1", "/"), "~0", "~")
+		switch v := doc.(type) {
+		case map[string]interface{}:
+			child, ok := v[tok]
+			if !ok {
+				return nil, fmt.Errorf("%q not found", tok)
+			}
+			doc = child
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("invalid index %q", tok)
+			}
+			doc = v[i]
+		default:
+			return nil, fmt.Errorf("%q not found", tok)
+		}
+	}
+	return doc, nil
+}
+
+// escape escapes a JSON Pointer reference token.
+func escape(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+// deepCopy copies the objects and arrays of a decoded JSON value.
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			m[k] = deepCopy(child)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, child := range v {
+			s[i] = deepCopy(child)
+		}
+		return s
+	}
+	return v
+}
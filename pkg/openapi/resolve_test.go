@@ -0,0 +1,104 @@
+package openapi
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestResolveRef(t *testing.T) {
+	tests := []struct {
+		base, ref string
+		wantLoc   string
+		wantFrag  string
+	}{
+		{"", "#/a/b", "", "/a/b"},
+		{"", "common.json#/c", "common.json", "/c"},
+		{"specs/api.json", "common.json#/c", "specs/common.json", "/c"},
+		{"specs/api.json", "../x/y.json", "x/y.json", ""},
+		{"/abs/api.json", "#/a", "/abs/api.json", "/a"},
+		{"https://x.com/a/api.json", "common.json#/c", "https://x.com/a/common.json", "/c"},
+		{"specs/api.json", "https://x.com/s.json#/d", "https://x.com/s.json", "/d"},
+	}
+	for _, tt := range tests {
+		loc, frag, err := resolveRef(tt.base, tt.ref)
+		if err != nil || loc != tt.wantLoc || frag != tt.wantFrag {
+			t.Errorf("resolveRef(%q, %q) = %q, %q, %v; want %q, %q", tt.base, tt.ref, loc, frag, err, tt.wantLoc, tt.wantFrag)
+		}
+	}
+}
+
+func TestParse_RemoteFetch(t *testing.T) {
+	files := map[string]string{
+		"https://api.example.com/api.json": `{
+			"openapi": "3.1.0",
+			"paths": {
+				"/items": {"$ref": "paths.json#/items"}
+			},
+			"components": {"schemas": {"Item": {"$ref": "models/item.json"}}}
+		}`,
+		"https://api.example.com/paths.json": `{
+			"items": {"get": {"responses": {"200": {"description": "ok", "content": {
+				"application/json": {"schema": {"type": "array", "items": {"$ref": "api.json#/components/schemas/Item"}}}
+			}}}}}
+		}`,
+		"https://api.example.com/models/item.json": `{
+			"type": "object",
+			"properties": {"tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}}, "child": {"$ref": "#"}},
+			"$defs": {"tag": {"type": "string"}}
+		}`,
+	}
+	var fetched []string
+	fetch := func(uri string) ([]byte, error) {
+		fetched = append(fetched, uri)
+		data, ok := files[uri]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return []byte(data), nil
+	}
+
+	const base = "https://api.example.com/api.json"
+	doc, err := Parse([]byte(files[base]), Options{BaseURI: base, Fetch: fetch})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	want := []string{"https://api.example.com/models/item.json", "https://api.example.com/paths.json"}
+	sort.Strings(fetched)
+	if !reflect.DeepEqual(fetched, want) {
+		t.Errorf("fetched = %v, want each document once: %v", fetched, want)
+	}
+
+	s := doc.Operation("GET", "/items").ResponseSchema(200, "")
+	if s == nil {
+		t.Fatal("no response schema")
+	}
+	if !strings.HasPrefix(s.Pointer, "/x-bundled/paths.json_items") {
+		t.Errorf("Pointer = %q", s.Pointer)
+	}
+	if err := s.ValidateBytes([]byte(`[{"tags":["a"],"child":{"tags":[]}}]`)); err != nil {
+		t.Errorf("valid items: %v", err)
+	}
+	if err := s.ValidateBytes([]byte(`[{"child":{"tags":[1]}}]`)); err == nil || !strings.Contains(err.Error(), "/0/child/tags/0: type") {
+		t.Errorf("error = %v", err)
+	}
+
+	delete(files, "https://api.example.com/models/item.json")
+	_, err = Parse([]byte(files[base]), Options{BaseURI: base, Fetch: fetch})
+	if err == nil || !strings.Contains(err.Error(), "openapi: loading https://api.example.com/models/item.json: not found") {
+		t.Errorf("missing remote document error = %v", err)
+	}
+}
+
+func TestNewKey(t *testing.T) {
+	r := newResolver(map[string]interface{}{}, Options{})
+	r.store["common.json_a_b"] = true
+	if got := r.newKey("dir/common.json", "/a/b"); got != "common.json_a_b_2" {
+		t.Errorf("newKey() = %q", got)
+	}
+	if got := r.newKey("https://x.com/s.json", ""); got != "s.json" {
+		t.Errorf("newKey() = %q", got)
+	}
+}
@@ -11,9 +11,12 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	shapejson "github.com/shapestone/shape-json/pkg/json"
 )
 
 // BenchmarkResult represents a single benchmark result
@@ -28,12 +31,13 @@ type BenchmarkResult struct {
 
 // BenchmarkGroup groups related benchmarks for comparison
 type BenchmarkGroup struct {
-	Name            string
-	FastPath        *BenchmarkResult // shape-json fast path (Unmarshal)
-	ASTPath         *BenchmarkResult // shape-json AST path (Parse)
-	EncodingJSON    *BenchmarkResult // encoding/json
-	Size            string
-	InputSize       int64
+	Name         string
+	FastPath     *BenchmarkResult // shape-json fast path (Unmarshal)
+	ASTPath      *BenchmarkResult // shape-json AST path (Parse)
+	EncodingJSON *BenchmarkResult // encoding/json
+	Size         string
+	InputSize    int64
+	Workload     *Workload // set for workload groups
 
 	// Comparison ratios (Fast Path vs encoding/json)
 	SpeedupFactor   float64
@@ -47,6 +51,24 @@ type BenchmarkGroup struct {
 	FastVsASTAllocs float64
 }
 
+// Workload describes one of the workload corpora, so that the report can
+// say what kind of content each result was measured on
+type Workload struct {
+	Name        string // as in BenchmarkWorkload_<Name>_FastPath
+	File        string
+	Description string
+	Size        int64
+	Stats       shapejson.Stats
+}
+
+// workloadFiles lists the workload corpora written by scripts/generate_workloads
+var workloadFiles = []Workload{
+	{Name: "Nested", File: "nested.json", Description: "Deeply nested objects and arrays"},
+	{Name: "Floats", File: "floats.json", Description: "One flat array of floats"},
+	{Name: "Escapes", File: "escapes.json", Description: "Strings made mostly of escape sequences"},
+	{Name: "Objects", File: "objects.json", Description: "Many small objects decoded into structs"},
+}
+
 // BenchmarkMetadata contains information about a benchmark run
 type BenchmarkMetadata struct {
 	Timestamp   string `json:"timestamp"`
@@ -104,8 +126,14 @@ func main() {
 	fmt.Printf("Parsed %d benchmark results\n", len(results))
 	fmt.Println()
 
+	// Measure the workload corpora
+	workloads, err := loadWorkloads(projectRoot)
+	if err != nil {
+		fatal("Failed to load workloads: %v", err)
+	}
+
 	// Group benchmarks for comparison
-	groups := groupBenchmarks(results)
+	groups := groupBenchmarks(results, workloads)
 	fmt.Printf("Created %d comparison groups\n", len(groups))
 	fmt.Println()
 
@@ -221,8 +249,28 @@ func parseBenchmarkOutput(output string) (map[string]*BenchmarkResult, error) {
 	return results, nil
 }
 
+// loadWorkloads reads the workload corpora and summarizes their structure
+func loadWorkloads(projectRoot string) ([]*Workload, error) {
+	var workloads []*Workload
+	for _, w := range workloadFiles {
+		data, err := os.ReadFile(filepath.Join(projectRoot, "testdata", "benchmarks", w.File))
+		if err != nil {
+			return nil, err
+		}
+		stats, err := shapejson.ValidateWithStats(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", w.File, err)
+		}
+		w := w
+		w.Size = int64(len(data))
+		w.Stats = stats
+		workloads = append(workloads, &w)
+	}
+	return workloads, nil
+}
+
 // groupBenchmarks creates comparison groups for all benchmark types
-func groupBenchmarks(results map[string]*BenchmarkResult) []*BenchmarkGroup {
+func groupBenchmarks(results map[string]*BenchmarkResult, workloads []*Workload) []*BenchmarkGroup {
 	var groups []*BenchmarkGroup
 
 	// Define size mappings
@@ -296,6 +344,24 @@ func groupBenchmarks(results map[string]*BenchmarkResult) []*BenchmarkGroup {
 		}
 	}
 
+	// Group validation state machine benchmarks, compared with encoding/json.Valid
+	for _, size := range []string{"Small", "Medium", "Large"} {
+		stateMachine := findFirstResult(results, []string{"BenchmarkValidate_StateMachine_" + size})
+		encodingJSON := findFirstResult(results, []string{"BenchmarkEncodingJSON_Valid_" + size})
+
+		if stateMachine != nil && encodingJSON != nil {
+			group := &BenchmarkGroup{
+				Name:         "Validation_" + size,
+				FastPath:     stateMachine,
+				EncodingJSON: encodingJSON,
+				Size:         size,
+				InputSize:    sizes[size],
+			}
+			calculateRatios(group)
+			groups = append(groups, group)
+		}
+	}
+
 	// Group ParseReader/Decoder benchmarks
 	for _, size := range []string{"Small", "Medium", "Large"} {
 		shapeKeys := []string{
@@ -322,6 +388,28 @@ func groupBenchmarks(results map[string]*BenchmarkResult) []*BenchmarkGroup {
 		}
 	}
 
+	// Group workload benchmarks, one group per workload corpus
+	for _, w := range workloads {
+		prefix := "BenchmarkWorkload_" + w.Name + "_"
+		fastPath := findFirstResult(results, []string{prefix + "FastPath"})
+		astPath := findFirstResult(results, []string{prefix + "ASTPath"})
+		encodingJSON := findFirstResult(results, []string{prefix + "EncodingJSON"})
+
+		if fastPath != nil && encodingJSON != nil {
+			group := &BenchmarkGroup{
+				Name:         "Workload_" + w.Name,
+				FastPath:     fastPath,
+				ASTPath:      astPath,
+				EncodingJSON: encodingJSON,
+				Size:         w.Name,
+				InputSize:    w.Size,
+				Workload:     w,
+			}
+			calculateRatios(group)
+			groups = append(groups, group)
+		}
+	}
+
 	return groups
 }
 
@@ -492,6 +580,18 @@ func generateReport(groups []*BenchmarkGroup) string {
 	buf.WriteString("## Performance Comparison Summary\n\n")
 	writeSummaryTables(&buf, parseGroups)
 
+	// Validation state machine vs encoding/json.Valid
+	if validationGroups := filterGroups(groups, "Validation"); len(validationGroups) > 0 {
+		buf.WriteString("---\n\n")
+		writeValidationSection(&buf, validationGroups)
+	}
+
+	// Results by workload
+	if workloadGroups := filterGroups(groups, "Workload"); len(workloadGroups) > 0 {
+		buf.WriteString("---\n\n")
+		writeWorkloadSection(&buf, workloadGroups)
+	}
+
 	// Analysis and recommendations
 	buf.WriteString("---\n\n")
 	buf.WriteString("## Analysis and Recommendations\n\n")
@@ -578,7 +678,7 @@ func writeUnmarshalPerformanceSection(buf *bytes.Buffer, groups []*BenchmarkGrou
 	buf.WriteString("### API Reference\n\n")
 	buf.WriteString("**Primary API**:\n")
 	buf.WriteString("- `json.Unmarshal(data, &v)` - Fast JSON unmarshaling (benchmarked above)\n")
-	buf.WriteString("- `json.Validate(input)` - Fast syntax validation\n")
+	buf.WriteString("- `json.Validate(input)` - Fast syntax validation (zero-allocation state machine)\n")
 	buf.WriteString("- `json.ValidateReader(r)` - Fast stream validation\n\n")
 	buf.WriteString("**Note:** shape-json also provides `Parse()` and `ParseDocument()` APIs for JSONPath queries and tree manipulation when you need those advanced features.\n\n")
 }
@@ -699,6 +799,113 @@ func writeBenchmarkSection(buf *bytes.Buffer, group *BenchmarkGroup) {
 	buf.WriteString("\n---\n\n")
 }
 
+// writeValidationSection writes the validation state machine results
+func writeValidationSection(buf *bytes.Buffer, groups []*BenchmarkGroup) {
+	buf.WriteString("## Validation Performance\n\n")
+	buf.WriteString("*Comparing `json.ValidateBytes()` (dedicated validation state machine) vs `encoding/json.Valid()`*\n\n")
+	buf.WriteString("| Test Size | shape-json Validate | encoding/json Valid | Performance | Allocations |\n")
+	buf.WriteString("|-----------|---------------------|---------------------|-------------|-------------|\n")
+	for _, group := range groups {
+		speedRatio := group.EncodingJSON.NsPerOp / group.FastPath.NsPerOp
+
+		var perfLabel string
+		if speedRatio > 1.0 {
+			perfLabel = fmt.Sprintf("**%.1fx FASTER** ⚡", speedRatio)
+		} else {
+			perfLabel = fmt.Sprintf("%.1fx slower", 1.0/speedRatio)
+		}
+
+		buf.WriteString(fmt.Sprintf("| %s | %.2f MB/s | %.2f MB/s | %s | %d vs %d |\n",
+			group.Size,
+			group.FastPath.MBPerSec,
+			group.EncodingJSON.MBPerSec,
+			perfLabel,
+			group.FastPath.AllocsPerOp,
+			group.EncodingJSON.AllocsPerOp))
+	}
+	buf.WriteString("\n")
+}
+
+// writeWorkloadSection writes the workload characteristics and the results
+// measured on each workload
+func writeWorkloadSection(buf *bytes.Buffer, groups []*BenchmarkGroup) {
+	buf.WriteString("## Performance by Workload\n\n")
+	buf.WriteString("*Each workload stresses one kind of content; see `scripts/generate_workloads`*\n\n")
+
+	buf.WriteString("| Workload | Content | Size | Values | Max Depth | Longest Array | Longest String | Mix |\n")
+	buf.WriteString("|----------|---------|------|--------|-----------|---------------|----------------|-----|\n")
+	for _, group := range groups {
+		w := group.Workload
+		buf.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %d | %s | %d B | %s |\n",
+			w.Name,
+			w.Description,
+			formatBytes(w.Size),
+			formatInt(int64(valueCount(w.Stats))),
+			w.Stats.MaxDepth,
+			formatInt(int64(w.Stats.LongestArray)),
+			w.Stats.LongestString,
+			valueMix(w.Stats)))
+	}
+	buf.WriteString("\n")
+
+	buf.WriteString("| Workload | Fast Path | AST Path | encoding/json | Fast Path vs encoding/json | Allocations (Fast vs encoding/json) |\n")
+	buf.WriteString("|----------|-----------|----------|---------------|----------------------------|-------------------------------------|\n")
+	for _, group := range groups {
+		astPath := "-"
+		if group.ASTPath != nil {
+			astPath = fmt.Sprintf("%.2f MB/s", group.ASTPath.MBPerSec)
+		}
+
+		speedRatio := group.EncodingJSON.NsPerOp / group.FastPath.NsPerOp
+		var perfLabel string
+		if speedRatio > 1.0 {
+			perfLabel = fmt.Sprintf("**%.1fx FASTER** ⚡", speedRatio)
+		} else {
+			perfLabel = fmt.Sprintf("%.1fx slower", 1.0/speedRatio)
+		}
+
+		buf.WriteString(fmt.Sprintf("| %s | %.2f MB/s | %s | %.2f MB/s | %s | %s vs %s |\n",
+			group.Workload.Name,
+			group.FastPath.MBPerSec,
+			astPath,
+			group.EncodingJSON.MBPerSec,
+			perfLabel,
+			formatInt(group.FastPath.AllocsPerOp),
+			formatInt(group.EncodingJSON.AllocsPerOp)))
+	}
+	buf.WriteString("\n")
+}
+
+// valueCount returns the number of values in a document
+func valueCount(st shapejson.Stats) int {
+	return st.Objects + st.Arrays + st.Strings + st.Numbers + st.Bools + st.Nulls
+}
+
+// valueMix describes the share of each JSON type among a document's values,
+// largest first, leaving out types under 5%
+func valueMix(st shapejson.Stats) string {
+	total := valueCount(st)
+	if total == 0 {
+		return "-"
+	}
+	kinds := []struct {
+		name  string
+		count int
+	}{
+		{"objects", st.Objects}, {"arrays", st.Arrays}, {"strings", st.Strings},
+		{"numbers", st.Numbers}, {"bools", st.Bools}, {"nulls", st.Nulls},
+	}
+	sort.SliceStable(kinds, func(i, j int) bool { return kinds[i].count > kinds[j].count })
+
+	var parts []string
+	for _, k := range kinds {
+		if pct := 100 * k.count / total; pct >= 5 {
+			parts = append(parts, fmt.Sprintf("%d%% %s", pct, k.name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 // writeStreamingSection writes streaming benchmark results
 func writeStreamingSection(buf *bytes.Buffer, group *BenchmarkGroup) {
 	buf.WriteString(fmt.Sprintf("#### %s JSON\n", group.Size))
@@ -0,0 +1,157 @@
+// Command generate_workloads writes the workload corpora used by the
+// workload benchmarks in pkg/json. Each file stresses one kind of content,
+// so that an optimization can be checked against the shape of data it
+// targets rather than only against the general-purpose files:
+//
+//	nested.json   deeply nested objects and arrays
+//	floats.json   one flat array of floats
+//	escapes.json  strings full of \uXXXX and short escapes
+//	objects.json  many small, uniform objects
+//
+// The output is deterministic, so regenerating the files does not change
+// them unless this program changes.
+//
+// Usage:
+//
+//	go run ./scripts/generate_workloads [dir]
+//
+// dir defaults to testdata/benchmarks.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+func main() {
+	dir := filepath.Join("testdata", "benchmarks")
+	if len(os.Args) > 1 {
+		dir = os.Args[1]
+	}
+
+	workloads := []struct {
+		file     string
+		generate func(r *rand.Rand) []byte
+	}{
+		{"nested.json", nested},
+		{"floats.json", floats},
+		{"escapes.json", escapes},
+		{"objects.json", objects},
+	}
+	for _, w := range workloads {
+		data := w.generate(rand.New(rand.NewSource(1)))
+		path := filepath.Join(dir, w.file)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Generated %s: %.1f KB\n", path, float64(len(data))/1024)
+	}
+}
+
+// nested writes an array of trees, each a chain of objects and arrays 48
+// levels deep with a few scalars at every level.
+func nested(r *rand.Rand) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i := 0; i < 40; i++ {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		for depth := 0; depth < 48; depth++ {
+			if depth%3 == 2 {
+				fmt.Fprintf(&buf, "[%d, ", r.Intn(1000))
+			} else {
+				fmt.Fprintf(&buf, `{"level": %d, "ok": %t, "child": `, depth, r.Intn(2) == 0)
+			}
+		}
+		buf.WriteString("null")
+		for depth := 47; depth >= 0; depth-- {
+			if depth%3 == 2 {
+				buf.WriteString("]")
+			} else {
+				buf.WriteString("}")
+			}
+		}
+	}
+	buf.WriteString("\n]\n")
+	return buf.Bytes()
+}
+
+// floats writes one array of floats of mixed magnitudes, in their shortest
+// form, some with exponents.
+func floats(r *rand.Rand) []byte {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i := 0; i < 6000; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+			if i%8 == 0 {
+				buf.WriteByte('\n')
+			}
+		}
+		f := r.NormFloat64() * math.Pow(10, float64(r.Intn(24)-12))
+		buf.WriteString(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	buf.WriteString("]\n")
+	return buf.Bytes()
+}
+
+// escapes writes an array of strings in which most characters are escaped:
+// \uXXXX for letters outside ASCII, surrogate pairs for emoji, and the
+// short escapes for quotes, backslashes, and control characters.
+func escapes(r *rand.Rand) []byte {
+	runes := []rune{'é', 'ü', 'ñ', 'ж', 'λ', '中', '文', '✓', '€', '😀', '🚀'}
+	short := []string{`\"`, `\\`, `\/`, `\n`, `\t`, `\r`, `\b`, `\f`}
+
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i := 0; i < 1200; i++ {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		buf.WriteByte('"')
+		for j := 0; j < 24; j++ {
+			switch n := r.Intn(10); {
+			case n < 6:
+				c := runes[r.Intn(len(runes))]
+				if c > 0xFFFF {
+					c -= 0x10000
+					fmt.Fprintf(&buf, `\u%04x\u%04x`, 0xD800+(c>>10), 0xDC00+(c&0x3FF))
+				} else {
+					fmt.Fprintf(&buf, `\u%04x`, c)
+				}
+			case n < 9:
+				buf.WriteString(short[r.Intn(len(short))])
+			default:
+				buf.WriteByte(byte('a' + r.Intn(26)))
+			}
+		}
+		buf.WriteByte('"')
+	}
+	buf.WriteString("\n]\n")
+	return buf.Bytes()
+}
+
+// objects writes an array of small records with the same members, as a
+// paginated API or a log export would.
+func objects(r *rand.Rand) []byte {
+	statuses := []string{"active", "pending", "disabled"}
+
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i := 0; i < 2000; i++ {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		fmt.Fprintf(&buf, `{"id":%d,"name":"user%d","status":"%s","score":%d.%02d,"verified":%t,"team":null}`,
+			i, r.Intn(100000), statuses[r.Intn(len(statuses))], r.Intn(100), r.Intn(100), r.Intn(2) == 0)
+	}
+	buf.WriteString("\n]\n")
+	return buf.Bytes()
+}